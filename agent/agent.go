@@ -61,6 +61,12 @@ const (
 	MongoOplogSize         = "MONGO_OPLOG_SIZE"
 	NumaCtlPreference      = "NUMA_CTL_PREFERENCE"
 	AllowsSecureConnection = "SECURE_STATESERVER_CONNECTION"
+	APIMaxConcurrentLogins = "API_MAX_CONCURRENT_LOGINS"
+	APILoginRetryDelay     = "API_LOGIN_RETRY_DELAY"
+	APIUserRequestRate     = "API_USER_REQUEST_RATE"
+	APIUserRequestBurst    = "API_USER_REQUEST_BURST"
+	APIAgentRequestRate    = "API_AGENT_REQUEST_RATE"
+	APIAgentRequestBurst   = "API_AGENT_REQUEST_BURST"
 )
 
 // The Config interface is the sole way that the agent gets access to the
@@ -170,6 +176,10 @@ type ConfigSetterOnly interface {
 	// SetAPIHostPorts sets the API host/port addresses to connect to.
 	SetAPIHostPorts(servers [][]network.HostPort)
 
+	// SetCACert sets the PEM-encoded CA certificates trusted when
+	// connecting to the state servers.
+	SetCACert(caCert string)
+
 	// Migrate takes an existing agent config and applies the given
 	// parameters to change it.
 	//
@@ -501,6 +511,10 @@ func (c *configInternal) SetAPIHostPorts(servers [][]network.HostPort) {
 	c.apiDetails.addresses = addrs
 }
 
+func (c *configInternal) SetCACert(caCert string) {
+	c.caCert = caCert
+}
+
 func (c *configInternal) SetValue(key, value string) {
 	if value == "" {
 		delete(c.values, key)
@@ -112,3 +112,49 @@ func (c *Client) ServiceCharmActions(arg params.Entity) (*charm.Actions, error)
 	}
 	return result.Actions, nil
 }
+
+// AddSchedules adds schedules on which actions are enqueued, returning
+// each schedule as stored, or an error if it could not be added.
+func (c *Client) AddSchedules(arg params.ActionSchedules) (params.ActionScheduleResults, error) {
+	results := params.ActionScheduleResults{}
+	err := c.facade.FacadeCall("AddSchedules", arg, &results)
+	return results, err
+}
+
+// ListSchedules returns all the action schedules in the environment.
+func (c *Client) ListSchedules() (params.ActionSchedules, error) {
+	results := params.ActionSchedules{}
+	err := c.facade.FacadeCall("ListSchedules", nil, &results)
+	return results, err
+}
+
+// RemoveSchedules removes the action schedules with the given ids.
+func (c *Client) RemoveSchedules(arg params.ActionScheduleIds) (params.ErrorResults, error) {
+	results := params.ErrorResults{}
+	err := c.facade.FacadeCall("RemoveSchedules", arg, &results)
+	return results, err
+}
+
+// ScheduleRuns returns the record of the runs of each of the action
+// schedules with the given ids, newest first.
+func (c *Client) ScheduleRuns(arg params.ActionScheduleIds) (params.ActionScheduleRunsResults, error) {
+	results := params.ActionScheduleRunsResults{}
+	err := c.facade.FacadeCall("ScheduleRuns", arg, &results)
+	return results, err
+}
+
+// EnqueueBatches runs actions across the units of services, returning
+// each batch as enqueued, or an error if it could not be enqueued.
+func (c *Client) EnqueueBatches(arg params.ActionBatches) (params.ActionBatchResults, error) {
+	results := params.ActionBatchResults{}
+	err := c.facade.FacadeCall("EnqueueBatches", arg, &results)
+	return results, err
+}
+
+// Batches returns the action batches with the given ids, together with
+// the progress of each on its units.
+func (c *Client) Batches(arg params.ActionBatchIds) (params.ActionBatchResults, error) {
+	results := params.ActionBatchResults{}
+	err := c.facade.FacadeCall("Batches", arg, &results)
+	return results, err
+}
@@ -132,3 +132,66 @@ func patchServiceCharmActions(c *gc.C, apiCli *action.Client, patchResults []par
 		},
 	)
 }
+
+func (s *actionSuite) TestListSchedules(c *gc.C) {
+	expected := []params.ActionSchedule{{
+		Id:        "1",
+		Receivers: []string{"service-mysql"},
+		Name:      "backup",
+		Cron:      "@daily",
+	}}
+	cleanup := action.PatchClientFacadeCall(s.client,
+		func(req string, paramsIn interface{}, resp interface{}) error {
+			c.Check(req, gc.Equals, "ListSchedules")
+			c.Check(paramsIn, gc.IsNil)
+			result := resp.(*params.ActionSchedules)
+			result.Schedules = expected
+			return nil
+		},
+	)
+	defer cleanup()
+	result, err := s.client.ListSchedules()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Schedules, jc.DeepEquals, expected)
+}
+
+func (s *actionSuite) TestRemoveSchedules(c *gc.C) {
+	cleanup := action.PatchClientFacadeCall(s.client,
+		func(req string, paramsIn interface{}, resp interface{}) error {
+			c.Check(req, gc.Equals, "RemoveSchedules")
+			c.Check(paramsIn, jc.DeepEquals, params.ActionScheduleIds{Ids: []string{"1", "2"}})
+			result := resp.(*params.ErrorResults)
+			result.Results = []params.ErrorResult{{}, {Error: &params.Error{Message: "boom"}}}
+			return nil
+		},
+	)
+	defer cleanup()
+	result, err := s.client.RemoveSchedules(params.ActionScheduleIds{Ids: []string{"1", "2"}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 2)
+	c.Assert(result.Results[1].Error, gc.ErrorMatches, "boom")
+}
+
+func (s *actionSuite) TestBatches(c *gc.C) {
+	expected := []params.ActionBatchResult{{
+		Batch: &params.ActionBatch{
+			Id:      "1",
+			Service: "service-mysql",
+			Name:    "backup",
+			Status:  params.ActionRunning,
+		},
+	}}
+	cleanup := action.PatchClientFacadeCall(s.client,
+		func(req string, paramsIn interface{}, resp interface{}) error {
+			c.Check(req, gc.Equals, "Batches")
+			c.Check(paramsIn, jc.DeepEquals, params.ActionBatchIds{Ids: []string{"1"}})
+			result := resp.(*params.ActionBatchResults)
+			result.Results = expected
+			return nil
+		},
+	)
+	defer cleanup()
+	result, err := s.client.Batches(params.ActionBatchIds{Ids: []string{"1"}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, jc.DeepEquals, expected)
+}
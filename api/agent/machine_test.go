@@ -6,6 +6,7 @@ package agent_test
 import (
 	"fmt"
 	stdtesting "testing"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/names"
@@ -16,11 +17,13 @@ import (
 	"github.com/juju/juju/api"
 	apiserveragent "github.com/juju/juju/apiserver/agent"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cert"
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/mongo"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/multiwatcher"
+	statetesting "github.com/juju/juju/state/testing"
 	coretesting "github.com/juju/juju/testing"
 )
 
@@ -171,6 +174,33 @@ func (s *machineSuite) TestClearReboot(c *gc.C) {
 	c.Assert(rFlag, jc.IsFalse)
 }
 
+func (s *machineSuite) TestTrustedCACert(c *gc.C) {
+	caCert, err := s.st.Agent().TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(caCert, gc.Equals, coretesting.CACert)
+}
+
+func (s *machineSuite) TestWatchTrustedCACert(c *gc.C) {
+	w, err := s.st.Agent().WatchTrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.BackingState, w)
+	wc.AssertOneChange()
+
+	newCACert, newCAKey, err := cert.NewCA("testenv", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+
+	caCert, err := s.st.Agent().TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(caCert, gc.Equals, coretesting.CACert+newCACert)
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
 func tryOpenState(info *mongo.MongoInfo) error {
 	st, err := state.Open(info, mongo.DialOpts{}, environs.NewStatePolicy())
 	if err == nil {
@@ -9,6 +9,7 @@ import (
 	"github.com/juju/names"
 
 	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/api/watcher"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/state/multiwatcher"
@@ -62,6 +63,32 @@ func (st *State) IsMaster() (bool, error) {
 	return results.Master, err
 }
 
+// TrustedCACert returns the CA certificates that the agent should
+// trust when connecting to the state servers. While the CA is being
+// rotated, the result holds both the old and the new CA certificates.
+func (st *State) TrustedCACert() (string, error) {
+	var result params.BytesResult
+	err := st.facade.FacadeCall("TrustedCACert", nil, &result)
+	if err != nil {
+		return "", err
+	}
+	return string(result.Result), nil
+}
+
+// WatchTrustedCACert returns a watcher that notifies when the CA
+// certificates returned by TrustedCACert may have changed.
+func (st *State) WatchTrustedCACert() (watcher.NotifyWatcher, error) {
+	var result params.NotifyWatchResult
+	err := st.facade.FacadeCall("WatchTrustedCACert", nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return watcher.NewNotifyWatcher(st.facade.RawAPICaller(), result), nil
+}
+
 type Entity struct {
 	st  *State
 	tag names.Tag
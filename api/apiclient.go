@@ -7,6 +7,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"io"
+	"math/rand"
 	"net/http"
 	"strings"
 	"time"
@@ -128,6 +129,13 @@ type DialOpts struct {
 	// RetryDelay is the amount of time to wait between
 	// unsucssful connection attempts.
 	RetryDelay time.Duration
+
+	// LoginRetryTimeout is the amount of time to keep retrying
+	// a login that the server refuses because it is busy. The
+	// delay between attempts follows the server's suggestion,
+	// backing off from there. If it is zero, refused logins
+	// are not retried.
+	LoginRetryTimeout time.Duration
 }
 
 // DefaultDialOpts returns a DialOpts representing the default
@@ -137,6 +145,7 @@ func DefaultDialOpts() DialOpts {
 		DialAddressInterval: 50 * time.Millisecond,
 		Timeout:             10 * time.Minute,
 		RetryDelay:          2 * time.Second,
+		LoginRetryTimeout:   5 * time.Minute,
 	}
 }
 
@@ -173,7 +182,7 @@ func open(info *Info, opts DialOpts, loginFunc func(st *State, tag, pwd, nonce s
 		certPool: conn.Config().TlsConfig.RootCAs,
 	}
 	if info.Tag != nil || info.Password != "" {
-		if err := loginFunc(st, info.Tag.String(), info.Password, info.Nonce); err != nil {
+		if err := loginWithRetry(st, info, opts, loginFunc); err != nil {
 			conn.Close()
 			return nil, err
 		}
@@ -184,6 +193,64 @@ func open(info *Info, opts DialOpts, loginFunc func(st *State, tag, pwd, nonce s
 	return st, nil
 }
 
+const (
+	// defaultLoginRetryDelay is the delay before retrying a
+	// refused login used when neither the server nor the dial
+	// options suggest one.
+	defaultLoginRetryDelay = time.Second
+
+	// maxLoginRetryDelay bounds the delay between attempts to
+	// retry a refused login.
+	maxLoginRetryDelay = 2 * time.Minute
+)
+
+// loginRetryAfter is used to wait before retrying a refused login.
+// It is a variable so it can be changed in tests.
+var loginRetryAfter = time.After
+
+// loginWithRetry logs in using loginFunc, retrying for up to
+// opts.LoginRetryTimeout while the server refuses the login because
+// it is busy.
+func loginWithRetry(st *State, info *Info, opts DialOpts, loginFunc func(st *State, tag, pwd, nonce string) error) error {
+	deadline := time.Now().Add(opts.LoginRetryTimeout)
+	for attempt := 0; ; attempt++ {
+		err := loginFunc(st, info.Tag.String(), info.Password, info.Nonce)
+		if !params.IsCodeTryAgain(err) {
+			return err
+		}
+		delay := loginRetryDelay(err, attempt, opts.RetryDelay)
+		if time.Now().Add(delay).After(deadline) {
+			return err
+		}
+		logger.Infof("login refused by busy API server; retrying in %v", delay)
+		<-loginRetryAfter(delay)
+	}
+}
+
+// loginRetryDelay returns how long to wait before retrying a login
+// refused with the given error for the given attempt, counting from
+// zero. The delay starts at the one suggested by the server, or
+// fallback if it made no suggestion, doubles with each attempt up to
+// maxLoginRetryDelay, and is jittered so that agents refused at the
+// same time do not all retry at the same time.
+func loginRetryDelay(err error, attempt int, fallback time.Duration) time.Duration {
+	delay, ok := params.RetryAfter(err)
+	if !ok {
+		delay = fallback
+	}
+	if delay <= 0 {
+		delay = defaultLoginRetryDelay
+	}
+	for i := 0; i < attempt && delay < maxLoginRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxLoginRetryDelay {
+		delay = maxLoginRetryDelay
+	}
+	// Wait for between one and one and a half times the delay.
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
 // OpenWithVersion uses an explicit version of the Admin facade to call Login
 // on. This allows the caller to pretend to be an older client, and is used
 // only in testing.
@@ -217,12 +284,16 @@ func Connect(info *Info, pathTail string, header http.Header, opts DialOpts) (*w
 		return nil, errors.New(`path tail must start with "/"`)
 	}
 
+	// The CA certificate may be a bundle holding both the old and
+	// the new CA certificates while the CA is being rotated.
 	pool := x509.NewCertPool()
-	xcert, err := cert.ParseCert(info.CACert)
+	xcerts, err := cert.ParseCerts(info.CACert)
 	if err != nil {
 		return nil, errors.Annotate(err, "cert pool creation failed")
 	}
-	pool.AddCert(xcert)
+	for _, xcert := range xcerts {
+		pool.AddCert(xcert)
+	}
 
 	path := makeAPIPath(info.EnvironTag.Id(), pathTail)
 
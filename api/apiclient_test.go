@@ -9,6 +9,7 @@ import (
 	"io"
 	"net"
 	"strconv"
+	"time"
 
 	"golang.org/x/net/websocket"
 
@@ -231,6 +232,45 @@ func (s *apiclientSuite) TestDialWebsocketStopped(c *gc.C) {
 	c.Assert(result, gc.IsNil)
 }
 
+func (s *apiclientSuite) TestLoginRetryDelay(c *gc.C) {
+	tryAgain := &params.Error{
+		Code:    params.CodeTryAgain,
+		Message: "try again",
+		Info:    params.RetryAfterInfo(4 * time.Second),
+	}
+	for i, test := range []struct {
+		err      error
+		attempt  int
+		fallback time.Duration
+		min      time.Duration
+	}{{
+		err: tryAgain,
+		min: 4 * time.Second,
+	}, {
+		err:     tryAgain,
+		attempt: 2,
+		min:     16 * time.Second,
+	}, {
+		err:     tryAgain,
+		attempt: 20,
+		min:     api.MaxLoginRetryDelay,
+	}, {
+		err:      &params.Error{Code: params.CodeTryAgain},
+		fallback: 2 * time.Second,
+		min:      2 * time.Second,
+	}, {
+		err: &params.Error{Code: params.CodeTryAgain},
+		min: time.Second,
+	}} {
+		c.Logf("test %d", i)
+		for j := 0; j < 10; j++ {
+			delay := api.LoginRetryDelay(test.err, test.attempt, test.fallback)
+			c.Check(delay >= test.min, jc.IsTrue, gc.Commentf("delay %v", delay))
+			c.Check(delay <= test.min*3/2, jc.IsTrue, gc.Commentf("delay %v", delay))
+		}
+	}
+}
+
 func assertConnAddrForEnv(c *gc.C, conn *websocket.Conn, addr, envUUID, tail string) {
 	c.Assert(conn.RemoteAddr(), gc.Matches, "^wss://"+addr+"/environment/"+envUUID+tail+"$")
 }
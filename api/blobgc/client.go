@@ -0,0 +1,41 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The blobgc package provides a client for the BlobGC API, used to
+// remove unused charm archives, tools tarballs and container images
+// from controller storage.
+package blobgc
+
+import (
+	"time"
+
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the blob garbage collection service.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new BlobGC client.
+func NewClient(caller base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(caller, "BlobGC")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// CollectUnusedBlobs reports the unused blobs in controller storage,
+// and removes those unused for longer than the grace period unless
+// dryRun is true. A zero grace period selects the default.
+func (c *Client) CollectUnusedBlobs(gracePeriod time.Duration, dryRun bool) ([]params.UnusedBlob, error) {
+	var result params.UnusedBlobsResult
+	args := params.CollectUnusedBlobsArgs{
+		GracePeriod: gracePeriod,
+		DryRun:      dryRun,
+	}
+	if err := c.facade.FacadeCall("CollectUnusedBlobs", args, &result); err != nil {
+		return nil, err
+	}
+	return result.Blobs, nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package blobgc_test
+
+import (
+	stdtesting "testing"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/blobgc"
+	jujutesting "github.com/juju/juju/juju/testing"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type clientSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+func (s *clientSuite) TestCollectUnusedBlobs(c *gc.C) {
+	ch := s.AddTestingCharm(c, "mysql")
+
+	client := blobgc.NewClient(s.APIState)
+	defer client.Close()
+
+	blobs, err := client.CollectUnusedBlobs(0, true)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(blobs, gc.HasLen, 1)
+	c.Assert(blobs[0].Kind, gc.Equals, "charm")
+	c.Assert(blobs[0].Name, gc.Equals, ch.String())
+	c.Assert(blobs[0].Removed, jc.IsFalse)
+}
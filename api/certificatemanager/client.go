@@ -0,0 +1,37 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The certificatemanager package provides a client for the
+// CertificateManager API, used to rotate the CA that signs the
+// certificates of the state servers.
+package certificatemanager
+
+import (
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the certificate manager service.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new CertificateManager client.
+func NewClient(caller base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(caller, "CertificateManager")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// RotateCertificates starts rotating the CA that signs the certificates
+// of the state servers, or advances the rotation in progress to its
+// next phase. The rotation is only advanced once every agent has fetched
+// the CA certificates trusted by its current phase, unless force is
+// true. It returns the phase reached, and the CA certificates that
+// clients should now trust.
+func (c *Client) RotateCertificates(force bool) (params.RotateCertificatesResult, error) {
+	var result params.RotateCertificatesResult
+	args := params.RotateCertificatesArgs{Force: force}
+	err := c.facade.FacadeCall("RotateCertificates", args, &result)
+	return result, err
+}
@@ -0,0 +1,38 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package certificatemanager_test
+
+import (
+	stdtesting "testing"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/certificatemanager"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type clientSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+func (s *clientSuite) TestRotateCertificates(c *gc.C) {
+	client := certificatemanager.NewClient(s.APIState)
+	defer client.Close()
+
+	result, err := client.RotateCertificates(false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Phase, gc.Equals, string(state.CARotationTrusting))
+	rotation, err := s.State.CARotation()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.TrustedCACert, gc.Equals, coretesting.CACert+rotation.NewCACert())
+}
@@ -91,6 +91,7 @@ type MachineStatus struct {
 type ServiceStatus struct {
 	Err           error
 	Charm         string
+	CharmSigner   string
 	Exposed       bool
 	Life          string
 	Relations     map[string][]string
@@ -106,6 +107,23 @@ type UnitStatusHistory struct {
 	Statuses []AgentStatus
 }
 
+// StatusHistory holds the past statuses of one or more entities,
+// oldest first.
+type StatusHistory struct {
+	Statuses []HistoricalStatus
+}
+
+// HistoricalStatus holds a past status of an entity: a unit, service,
+// machine or relation.
+type HistoricalStatus struct {
+	Entity string
+	Kind   params.HistoryKind
+	Status params.Status
+	Info   string
+	Data   map[string]interface{}
+	Since  *time.Time
+}
+
 // UnitStatus holds status info about a unit.
 type UnitStatus struct {
 	// UnitAgent holds the status for a unit's agent.
@@ -127,6 +145,7 @@ type UnitStatus struct {
 	PublicAddress string
 	Charm         string
 	Subordinates  map[string]UnitStatus
+	Payloads      []params.Payload
 }
 
 // RelationStatus holds status info about a relation.
@@ -158,6 +177,24 @@ type NetworkStatus struct {
 	VLANTag    int
 }
 
+// CACertificateStatus holds status info about a CA certificate.
+type CACertificateStatus struct {
+	Subject string
+	Expiry  time.Time
+}
+
+// CertificatesStatus holds status info about the CA certificates
+// trusted by the environment.
+type CertificatesStatus struct {
+	// CACertificates holds the trusted CA certificates. While a CA
+	// rotation is in progress, both the old and the new CA are trusted.
+	CACertificates []CACertificateStatus
+
+	// RotationPhase holds the phase of the CA rotation in progress,
+	// if any.
+	RotationPhase string
+}
+
 // Status holds information about the status of a juju environment.
 type Status struct {
 	EnvironmentName string
@@ -165,13 +202,26 @@ type Status struct {
 	Services        map[string]ServiceStatus
 	Networks        map[string]NetworkStatus
 	Relations       []RelationStatus
+	Certificates    CertificatesStatus
 }
 
 // Status returns the status of the juju environment.
 func (c *Client) Status(patterns []string) (*Status, error) {
+	return c.FilteredStatus(params.StatusParams{Patterns: patterns})
+}
+
+// FilteredStatus returns the status of the juju environment, restricted
+// to the entities that match all of the given filters. Servers older
+// than version 1 of the Client facade only filter by pattern, so an
+// error is returned if any other filter is given.
+func (c *Client) FilteredStatus(args params.StatusParams) (*Status, error) {
+	if c.facade.BestAPIVersion() < 1 {
+		if len(args.Statuses) > 0 || len(args.Machines) > 0 || len(args.Relations) > 0 {
+			return nil, errors.NotSupportedf("filtering status by status, machine or relation with this server")
+		}
+	}
 	var result Status
-	p := params.StatusParams{Patterns: patterns}
-	if err := c.facade.FacadeCall("FullStatus", p, &result); err != nil {
+	if err := c.facade.FacadeCall("FullStatus", args, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -196,6 +246,20 @@ func (c *Client) UnitStatusHistory(kind params.HistoryKind, unitName string, siz
 	return &results, nil
 }
 
+// StatusHistory retrieves the past statuses of the kind given in args
+// for the named unit, service, machine or relation.
+func (c *Client) StatusHistory(args params.StatusHistory) (*StatusHistory, error) {
+	var results StatusHistory
+	err := c.facade.FacadeCall("StatusHistory", args, &results)
+	if err != nil {
+		if params.IsCodeNotImplemented(err) {
+			return &StatusHistory{}, errors.NotImplementedf("StatusHistory")
+		}
+		return &StatusHistory{}, errors.Trace(err)
+	}
+	return &results, nil
+}
+
 // LegacyMachineStatus holds just the instance-id of a machine.
 type LegacyMachineStatus struct {
 	InstanceId string // Not type instance.Id just to match original api.
@@ -726,6 +790,33 @@ func (c *Client) Run(run params.RunParams) ([]params.RunResult, error) {
 	return results.Results, err
 }
 
+// StartRun starts running the Commands specified on the machines
+// identified through the ids provided in the machines, services and
+// units slices in the background, and returns the id of the job from
+// which the results can be fetched with RunJobResults.
+func (c *Client) StartRun(run params.RunParams) (string, error) {
+	var result params.RunJob
+	err := c.facade.FacadeCall("StartRun", run, &result)
+	return result.Id, err
+}
+
+// StartRunOnAllMachines starts running the Commands specified on all
+// the machines in the background, as StartRun does.
+func (c *Client) StartRunOnAllMachines(run params.RunParams) (string, error) {
+	var result params.RunJob
+	err := c.facade.FacadeCall("StartRunOnAllMachines", run, &result)
+	return result.Id, err
+}
+
+// RunJobResults returns the results of the background run job with the
+// given id, skipping the first skip results.
+func (c *Client) RunJobResults(id string, skip int) (params.RunJobResults, error) {
+	var results params.RunJobResults
+	args := params.RunJobQuery{Id: id, Skip: skip}
+	err := c.facade.FacadeCall("RunJobResults", args, &results)
+	return results, err
+}
+
 // DestroyEnvironment puts the environment into a "dying" state,
 // and removes all non-manager machine instances. DestroyEnvironment
 // will fail if there are any manually-provisioned non-manager machines
@@ -834,6 +925,42 @@ func (n *noopCloser) Close() error {
 	return nil
 }
 
+// OpenCharm returns a reader for the archive of the given charm, which
+// must already have been added to the environment. It is the caller's
+// responsibility to close the returned reader.
+func (c *Client) OpenCharm(curl *charm.URL) (io.ReadCloser, error) {
+	query := url.Values{
+		"url":  {curl.String()},
+		"file": {"*"},
+	}
+	endPoint, err := c.apiEndpoint("charms", query.Encode())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	req, err := http.NewRequest("GET", endPoint, nil)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot create charm download request")
+	}
+	req.SetBasicAuth(c.st.tag, c.st.password)
+
+	// See the comment in AddLocalCharm about the use of a non-validating
+	// HTTP client.
+	resp, err := utils.GetNonValidatingHTTPClient().Do(req)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot download charm")
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var jsonResponse params.CharmsResponse
+		body, err := ioutil.ReadAll(resp.Body)
+		if err == nil && json.Unmarshal(body, &jsonResponse) == nil && jsonResponse.Error != "" {
+			return nil, errors.Errorf("cannot download charm %q: %v", curl, jsonResponse.Error)
+		}
+		return nil, errors.Errorf("charm download failed: %v (%s)", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.Body, nil
+}
+
 func (c *Client) apiEndpoint(destination, query string) (string, error) {
 	root, err := c.apiRoot()
 	if err != nil {
@@ -166,6 +166,35 @@ func (s *clientSuite) TestAddLocalCharmError(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "charm upload failed: 405 \\(Method Not Allowed\\)")
 }
 
+func (s *clientSuite) TestOpenCharm(c *gc.C) {
+	charmArchive := testcharms.Repo.CharmArchive(c.MkDir(), "dummy")
+	curl := charm.MustParseURL(
+		fmt.Sprintf("local:quantal/%s-%d", charmArchive.Meta().Name, charmArchive.Revision()),
+	)
+	client := s.APIState.Client()
+	savedURL, err := client.AddLocalCharm(curl, charmArchive)
+	c.Assert(err, jc.ErrorIsNil)
+
+	reader, err := client.OpenCharm(savedURL)
+	c.Assert(err, jc.ErrorIsNil)
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, jc.ErrorIsNil)
+	archivePath := path.Join(c.MkDir(), "dummy.charm")
+	err = ioutil.WriteFile(archivePath, data, 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	downloaded, err := charm.ReadCharmArchive(archivePath)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(downloaded.Meta(), jc.DeepEquals, charmArchive.Meta())
+	c.Assert(downloaded.Revision(), gc.Equals, savedURL.Revision)
+}
+
+func (s *clientSuite) TestOpenCharmNotFound(c *gc.C) {
+	client := s.APIState.Client()
+	_, err := client.OpenCharm(charm.MustParseURL("local:quantal/missing-1"))
+	c.Assert(err, gc.ErrorMatches, `cannot download charm "local:quantal/missing-1": .*charm "local:quantal/missing-1" not found`)
+}
+
 func fakeAPIEndpoint(c *gc.C, client *api.Client, address, method string, handle func(http.ResponseWriter, *http.Request)) net.Listener {
 	lis, err := net.Listen("tcp", "127.0.0.1:0")
 	c.Assert(err, jc.ErrorIsNil)
@@ -275,6 +304,29 @@ func (s *clientSuite) TestDestroyEnvironment(c *gc.C) {
 	c.Assert(called, jc.IsTrue)
 }
 
+func (s *clientSuite) TestFilteredStatusWithOlderServer(c *gc.C) {
+	client := s.APIState.Client()
+	var called bool
+	// The patched facade reports version 0, which only filters by
+	// pattern.
+	cleanup := api.PatchClientFacadeCall(client,
+		func(req string, args interface{}, resp interface{}) error {
+			c.Assert(req, gc.Equals, "FullStatus")
+			called = true
+			return nil
+		})
+	defer cleanup()
+
+	_, err := client.FilteredStatus(params.StatusParams{Statuses: []string{"error"}})
+	c.Assert(err, gc.ErrorMatches, "filtering status by status, machine or relation with this server not supported")
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+	c.Assert(called, jc.IsFalse)
+
+	_, err = client.FilteredStatus(params.StatusParams{Patterns: []string{"wordpress"}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(called, jc.IsTrue)
+}
+
 func (s *clientSuite) TestShareEnvironmentThreeUsers(c *gc.C) {
 	client := s.APIState.Client()
 	existingUser := s.Factory.MakeEnvUser(c, nil)
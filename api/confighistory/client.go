@@ -0,0 +1,61 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The confighistory package provides a client for the ConfigHistory
+// API, used to report the recorded changes to the environment config
+// and to services' charm config settings, and to restore earlier
+// revisions of them.
+package confighistory
+
+import (
+	"github.com/juju/names"
+
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the config history service.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new ConfigHistory client.
+func NewClient(caller base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(caller, "ConfigHistory")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// EnvironConfigHistory returns the recorded revisions of the
+// environment config, newest first.
+func (c *Client) EnvironConfigHistory() ([]params.ConfigRevision, error) {
+	var result params.ConfigHistoryResult
+	err := c.facade.FacadeCall("EnvironConfigHistory", nil, &result)
+	return result.Revisions, err
+}
+
+// ServiceConfigHistory returns the recorded revisions of the charm
+// config settings of the named service, newest first.
+func (c *Client) ServiceConfigHistory(service string) ([]params.ConfigRevision, error) {
+	var result params.ConfigHistoryResult
+	arg := params.Entity{Tag: names.NewServiceTag(service).String()}
+	err := c.facade.FacadeCall("ServiceConfigHistory", arg, &result)
+	return result.Revisions, err
+}
+
+// RollbackEnvironConfig restores the environment config to the given
+// revision.
+func (c *Client) RollbackEnvironConfig(revision int) error {
+	args := params.RollbackEnvironConfig{Revision: revision}
+	return c.facade.FacadeCall("RollbackEnvironConfig", args, nil)
+}
+
+// RollbackServiceConfig restores the charm config settings of the
+// named service to the given revision.
+func (c *Client) RollbackServiceConfig(service string, revision int) error {
+	args := params.RollbackServiceConfig{
+		Service:  names.NewServiceTag(service).String(),
+		Revision: revision,
+	}
+	return c.facade.FacadeCall("RollbackServiceConfig", args, nil)
+}
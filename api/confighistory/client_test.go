@@ -0,0 +1,71 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package confighistory_test
+
+import (
+	stdtesting "testing"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5"
+
+	"github.com/juju/juju/api/confighistory"
+	jujutesting "github.com/juju/juju/juju/testing"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type clientSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+func (s *clientSuite) TestEnvironConfigHistory(c *gc.C) {
+	client := confighistory.NewClient(s.APIState)
+	defer client.Close()
+
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{"arbitrary-key": "one"}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	revisions, err := client.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(revisions, gc.Not(gc.HasLen), 0)
+	revision := revisions[0].Revision
+	c.Assert(revisions[0].Settings["arbitrary-key"], gc.Equals, "one")
+
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{"arbitrary-key": "two"}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = client.RollbackEnvironConfig(revision)
+	c.Assert(err, jc.ErrorIsNil)
+
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.UnknownAttrs()["arbitrary-key"], gc.Equals, "one")
+}
+
+func (s *clientSuite) TestServiceConfigHistory(c *gc.C) {
+	client := confighistory.NewClient(s.APIState)
+	defer client.Close()
+
+	service := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	err := service.UpdateConfigSettings(charm.Settings{"outlook": "grim"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = service.UpdateConfigSettings(charm.Settings{"outlook": "rosy"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	revisions, err := client.ServiceConfigHistory("dummy")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(revisions, gc.HasLen, 2)
+	c.Assert(revisions[0].Revision, gc.Equals, 2)
+	c.Assert(revisions[1].Settings, jc.DeepEquals, map[string]interface{}{"outlook": "grim"})
+
+	err = client.RollbackServiceConfig("dummy", 1)
+	c.Assert(err, jc.ErrorIsNil)
+	settings, err := service.ConfigSettings()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(settings, jc.DeepEquals, charm.Settings{"outlook": "grim"})
+}
@@ -0,0 +1,32 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The controllerhealth package provides a client for the
+// ControllerHealth API, used to report on the health of the state
+// servers.
+package controllerhealth
+
+import (
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the controller health service.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new ControllerHealth client.
+func NewClient(caller base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(caller, "ControllerHealth")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// Report returns a report on the health of the state servers and of
+// the database they share.
+func (c *Client) Report() (params.ControllerHealthReport, error) {
+	var result params.ControllerHealthReport
+	err := c.facade.FacadeCall("Report", nil, &result)
+	return result, err
+}
@@ -0,0 +1,39 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package controllerhealth_test
+
+import (
+	stdtesting "testing"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/controllerhealth"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type clientSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+func (s *clientSuite) TestReport(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, jc.ErrorIsNil)
+
+	client := controllerhealth.NewClient(s.APIState)
+	defer client.Close()
+
+	report, err := client.Report()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(report.StateServers, gc.HasLen, 1)
+	c.Assert(report.StateServers[0].MachineId, gc.Equals, machine.Id())
+}
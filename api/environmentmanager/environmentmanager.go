@@ -84,3 +84,126 @@ func (c *Client) ListEnvironments(user string) ([]params.Environment, error) {
 	}
 	return result.Environments, nil
 }
+
+// DestroyEnvironment destroys the hosted environment with the given
+// UUID. The environment's services and machines are destroyed, and
+// the environment removed, asynchronously; EnvironmentStatus can be
+// used to follow the progress.
+func (c *Client) DestroyEnvironment(uuid string) error {
+	if !names.IsValidEnvironment(uuid) {
+		return fmt.Errorf("invalid environment UUID %q", uuid)
+	}
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	err := c.facade.FacadeCall("DestroyEnvironment", entity, nil)
+	return errors.Trace(err)
+}
+
+// EnvironmentStatus returns the lifecycle state of the environment
+// with the given UUID, along with the number of machines and services
+// that remain in it. Once a destroyed environment has been removed,
+// an error satisfying params.IsCodeNotFound is returned.
+func (c *Client) EnvironmentStatus(uuid string) (params.EnvironmentStatus, error) {
+	var results params.EnvironmentStatusResults
+	if !names.IsValidEnvironment(uuid) {
+		return params.EnvironmentStatus{}, fmt.Errorf("invalid environment UUID %q", uuid)
+	}
+	args := params.Entities{
+		Entities: []params.Entity{{names.NewEnvironTag(uuid).String()}},
+	}
+	err := c.facade.FacadeCall("EnvironmentStatus", args, &results)
+	if err != nil {
+		return params.EnvironmentStatus{}, errors.Trace(err)
+	}
+	if len(results.Results) != 1 {
+		return params.EnvironmentStatus{}, errors.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return params.EnvironmentStatus{}, result.Error
+	}
+	return result.Status, nil
+}
+
+// ExportEnvironment returns an export of the documents of the hosted
+// environment with the given UUID, for import into the state server
+// with the given CA certificate. The environment cannot be changed
+// until SetEnvironmentMigrated or AbortEnvironmentExport is called.
+func (c *Client) ExportEnvironment(uuid, targetCACert string) (params.EnvironmentExport, error) {
+	var result params.EnvironmentExport
+	if !names.IsValidEnvironment(uuid) {
+		return result, fmt.Errorf("invalid environment UUID %q", uuid)
+	}
+	args := params.ExportEnvironmentArgs{
+		EnvironTag:   names.NewEnvironTag(uuid).String(),
+		TargetCACert: targetCACert,
+	}
+	err := c.facade.FacadeCall("ExportEnvironment", args, &result)
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	return result, nil
+}
+
+// AbortEnvironmentExport allows the hosted environment with the given
+// UUID to be changed again after ExportEnvironment, when its migration
+// cannot be completed.
+func (c *Client) AbortEnvironmentExport(uuid string) error {
+	if !names.IsValidEnvironment(uuid) {
+		return fmt.Errorf("invalid environment UUID %q", uuid)
+	}
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	err := c.facade.FacadeCall("AbortEnvironmentExport", entity, nil)
+	return errors.Trace(err)
+}
+
+// ImportEnvironment recreates the documents of an environment exported
+// by ExportEnvironment from another state server. Its charm archives,
+// resources and tools must then be uploaded with a MigrationClient
+// before CompleteEnvironmentImport is called.
+func (c *Client) ImportEnvironment(export params.EnvironmentExport) error {
+	err := c.facade.FacadeCall("ImportEnvironment", export, nil)
+	return errors.Trace(err)
+}
+
+// CompleteEnvironmentImport makes the environment with the given UUID,
+// imported by ImportEnvironment, usable. It returns the API addresses
+// that the environment's agents should connect to.
+func (c *Client) CompleteEnvironmentImport(uuid string) ([][]params.HostPort, error) {
+	var result params.EnvironmentImportResult
+	if !names.IsValidEnvironment(uuid) {
+		return nil, fmt.Errorf("invalid environment UUID %q", uuid)
+	}
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	err := c.facade.FacadeCall("CompleteEnvironmentImport", entity, &result)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return result.Servers, nil
+}
+
+// AbortEnvironmentImport removes the environment with the given UUID
+// after ImportEnvironment, if its migration cannot be completed. The
+// environment's machines are left untouched.
+func (c *Client) AbortEnvironmentImport(uuid string) error {
+	if !names.IsValidEnvironment(uuid) {
+		return fmt.Errorf("invalid environment UUID %q", uuid)
+	}
+	entity := params.Entity{names.NewEnvironTag(uuid).String()}
+	err := c.facade.FacadeCall("AbortEnvironmentImport", entity, nil)
+	return errors.Trace(err)
+}
+
+// SetEnvironmentMigrated records that the hosted environment with the
+// given UUID has been imported into the state server with the given
+// API addresses, and tells the environment's agents to connect to it.
+func (c *Client) SetEnvironmentMigrated(uuid string, servers [][]params.HostPort) error {
+	if !names.IsValidEnvironment(uuid) {
+		return fmt.Errorf("invalid environment UUID %q", uuid)
+	}
+	args := params.SetEnvironmentMigrated{
+		EnvironTag: names.NewEnvironTag(uuid).String(),
+		Servers:    servers,
+	}
+	err := c.facade.FacadeCall("SetEnvironmentMigrated", args, nil)
+	return errors.Trace(err)
+}
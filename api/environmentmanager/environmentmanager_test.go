@@ -15,6 +15,8 @@ import (
 	"github.com/juju/juju/feature"
 	"github.com/juju/juju/juju"
 	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
 	coretesting "github.com/juju/juju/testing"
 	"github.com/juju/juju/testing/factory"
 )
@@ -115,3 +117,95 @@ func (s *environmentmanagerSuite) TestListEnvironments(c *gc.C) {
 	envNames := []string{envs[0].Name, envs[1].Name}
 	c.Assert(envNames, jc.SameContents, []string{"first", "second"})
 }
+
+func (s *environmentmanagerSuite) TestDestroyEnvironmentBadUUID(c *gc.C) {
+	envManager := s.OpenAPI(c)
+	err := envManager.DestroyEnvironment("not-a-uuid")
+	c.Assert(err, gc.ErrorMatches, `invalid environment UUID "not-a-uuid"`)
+}
+
+func (s *environmentmanagerSuite) TestDestroyEnvironment(c *gc.C) {
+	s.SetFeatureFlags(feature.JES)
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{
+		Name: "doomed", Owner: s.AdminUserTag(c)})
+	defer st.Close()
+	_, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+
+	envManager := s.OpenAPI(c)
+	err = envManager.DestroyEnvironment(st.EnvironUUID())
+	c.Assert(err, jc.ErrorIsNil)
+
+	status, err := envManager.EnvironmentStatus(st.EnvironUUID())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(status, jc.DeepEquals, params.EnvironmentStatus{
+		Life:         params.Dying,
+		MachineCount: 1,
+	})
+}
+
+func (s *environmentmanagerSuite) TestEnvironmentStatusNotFound(c *gc.C) {
+	s.SetFeatureFlags(feature.JES)
+	envManager := s.OpenAPI(c)
+	_, err := envManager.EnvironmentStatus(utils.MustNewUUID().String())
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+}
+
+func (s *environmentmanagerSuite) TestExportImportEnvironment(c *gc.C) {
+	s.SetFeatureFlags(feature.JES)
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{
+		Name: "moving", Owner: s.AdminUserTag(c)})
+	defer st.Close()
+	_, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+
+	envManager := s.OpenAPI(c)
+	_, err = envManager.ExportEnvironment(st.EnvironUUID(), coretesting.OtherCACert)
+	c.Assert(err, jc.ErrorIsNil)
+	err = envManager.AbortEnvironmentExport(st.EnvironUUID())
+	c.Assert(err, jc.ErrorIsNil)
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationNone)
+
+	export, err := envManager.ExportEnvironment(st.EnvironUUID(), coretesting.OtherCACert)
+	c.Assert(err, jc.ErrorIsNil)
+	err = envManager.AbortEnvironmentImport(st.EnvironUUID())
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = envManager.ImportEnvironment(export)
+	c.Assert(err, jc.ErrorIsNil)
+	servers, err := envManager.CompleteEnvironmentImport(st.EnvironUUID())
+	c.Assert(err, jc.ErrorIsNil)
+	hostPorts, err := s.State.APIHostPorts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(servers, jc.DeepEquals, params.FromNetworkHostsPorts(hostPorts))
+	machines, err := st.AllMachines()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(machines, gc.HasLen, 1)
+}
+
+func (s *environmentmanagerSuite) TestSetEnvironmentMigrated(c *gc.C) {
+	s.SetFeatureFlags(feature.JES)
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{
+		Name: "moving", Owner: s.AdminUserTag(c)})
+	defer st.Close()
+
+	envManager := s.OpenAPI(c)
+	_, err := envManager.ExportEnvironment(st.EnvironUUID(), coretesting.OtherCACert)
+	c.Assert(err, jc.ErrorIsNil)
+	servers := [][]params.HostPort{
+		params.FromNetworkHostPorts(network.NewHostPorts(17070, "10.0.0.1")),
+	}
+	err = envManager.SetEnvironmentMigrated(st.EnvironUUID(), servers)
+	c.Assert(err, jc.ErrorIsNil)
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.Life(), gc.Equals, state.Dead)
+}
+
+func (s *environmentmanagerSuite) TestExportEnvironmentBadUUID(c *gc.C) {
+	envManager := s.OpenAPI(c)
+	_, err := envManager.ExportEnvironment("not-a-uuid", coretesting.OtherCACert)
+	c.Assert(err, gc.ErrorMatches, `invalid environment UUID "not-a-uuid"`)
+}
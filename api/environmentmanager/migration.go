@@ -0,0 +1,109 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environmentmanager
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	apihttp "github.com/juju/juju/apiserver/http"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// HTTPClient represents the methods of api.State (see api/http.go)
+// needed for direct HTTP requests to the migration endpoint.
+type HTTPClient interface {
+	// SendHTTPRequest sends an HTTP GET request relative to the client.
+	SendHTTPRequest(path string, args interface{}) (*http.Request, *http.Response, error)
+	// SendHTTPRequestReader sends an HTTP PUT request relative to the client.
+	SendHTTPRequestReader(path string, attached io.Reader, meta interface{}, name string) (*http.Request, *http.Response, error)
+}
+
+// MigrationClient provides access to the migration HTTP endpoint,
+// through which the charm archives, resources and tools of a migrated
+// environment are copied. They are downloaded from the environment
+// being exported, and uploaded to the state server environment of the
+// state server importing it.
+type MigrationClient struct {
+	http HTTPClient
+}
+
+// NewMigrationClient returns a new migration client using the given
+// HTTP client.
+func NewMigrationClient(http HTTPClient) *MigrationClient {
+	return &MigrationClient{http: http}
+}
+
+// OpenBlob returns a reader for the charm archive or resource content
+// stored at the given path in the environment being exported, and its
+// size. The caller is responsible for closing the reader.
+func (c *MigrationClient) OpenBlob(path string) (io.ReadCloser, int64, error) {
+	return c.open(params.MigrationBlobArgs{StoragePath: path})
+}
+
+// OpenTools returns a reader for the tools tarball with the given
+// metadata in the environment being exported. The caller is
+// responsible for closing the reader.
+func (c *MigrationClient) OpenTools(tools params.ExportedTools) (io.ReadCloser, error) {
+	r, _, err := c.open(params.MigrationBlobArgs{Tools: &tools})
+	return r, err
+}
+
+func (c *MigrationClient) open(args params.MigrationBlobArgs) (io.ReadCloser, int64, error) {
+	_, resp, err := c.http.SendHTTPRequest("migration", &args)
+	if err != nil {
+		return nil, 0, errors.Annotate(err, "while sending HTTP request")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, extractError(resp)
+	}
+	if resp.ContentLength < 0 {
+		resp.Body.Close()
+		return nil, 0, errors.New("missing content length in response")
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// AddBlob uploads the charm archive or resource content with the given
+// storage path and size to the environment with the given UUID, which
+// is being imported.
+func (c *MigrationClient) AddBlob(uuid, path string, content io.Reader, size int64) error {
+	return c.add(params.MigrationBlobArgs{
+		EnvironTag:  names.NewEnvironTag(uuid).String(),
+		StoragePath: path,
+		Size:        size,
+	}, content)
+}
+
+// AddTools uploads the tools tarball with the given metadata to the
+// environment with the given UUID, which is being imported.
+func (c *MigrationClient) AddTools(uuid string, tools params.ExportedTools, content io.Reader) error {
+	return c.add(params.MigrationBlobArgs{
+		EnvironTag: names.NewEnvironTag(uuid).String(),
+		Tools:      &tools,
+	}, content)
+}
+
+func (c *MigrationClient) add(args params.MigrationBlobArgs, content io.Reader) error {
+	_, resp, err := c.http.SendHTTPRequestReader("migration", content, &args, "blob")
+	if err != nil {
+		return errors.Annotate(err, "while sending HTTP request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return extractError(resp)
+	}
+	return nil
+}
+
+func extractError(resp *http.Response) error {
+	failure, err := apihttp.ExtractAPIError(resp)
+	if err != nil {
+		return errors.Annotate(err, "while extracting failure")
+	}
+	return errors.Trace(failure)
+}
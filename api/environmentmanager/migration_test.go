@@ -0,0 +1,108 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environmentmanager_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/environmentmanager"
+	httptesting "github.com/juju/juju/api/http/testing"
+	apihttp "github.com/juju/juju/apiserver/http"
+	"github.com/juju/juju/apiserver/params"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
+)
+
+type migrationClientSuite struct {
+	httptesting.APIHTTPClientSuite
+	client *environmentmanager.MigrationClient
+}
+
+var _ = gc.Suite(&migrationClientSuite{})
+
+var migratedTools = params.ExportedTools{
+	Version: version.MustParseBinary("1.25.0-trusty-amd64"),
+	Size:    5,
+	SHA256:  "0123",
+}
+
+func (s *migrationClientSuite) SetUpTest(c *gc.C) {
+	s.APIHTTPClientSuite.SetUpTest(c)
+	s.client = environmentmanager.NewMigrationClient(&s.FakeClient)
+}
+
+func (s *migrationClientSuite) TestOpenBlob(c *gc.C) {
+	s.SetResponse(c, http.StatusOK, []byte("charm"), apihttp.CTypeRaw)
+	s.FakeClient.Response.ContentLength = 5
+
+	r, size, err := s.client.OpenBlob("charms/wordpress")
+	c.Assert(err, jc.ErrorIsNil)
+	defer r.Close()
+	c.Assert(size, gc.Equals, int64(5))
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "charm")
+}
+
+func (s *migrationClientSuite) TestOpenBlobMissingLength(c *gc.C) {
+	s.SetResponse(c, http.StatusOK, []byte("charm"), apihttp.CTypeRaw)
+	s.FakeClient.Response.ContentLength = -1
+	_, _, err := s.client.OpenBlob("charms/wordpress")
+	c.Assert(err, gc.ErrorMatches, "missing content length in response")
+}
+
+func (s *migrationClientSuite) TestOpenBlobFailure(c *gc.C) {
+	s.SetFailure(c, `environment "moving" is not exporting`, http.StatusInternalServerError)
+	_, _, err := s.client.OpenBlob("charms/wordpress")
+	c.Assert(err, gc.ErrorMatches, `environment "moving" is not exporting`)
+}
+
+func (s *migrationClientSuite) TestOpenTools(c *gc.C) {
+	s.SetResponse(c, http.StatusOK, []byte("tools"), apihttp.CTypeRaw)
+	s.FakeClient.Response.ContentLength = 5
+
+	r, err := s.client.OpenTools(migratedTools)
+	c.Assert(err, jc.ErrorIsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "tools")
+}
+
+func (s *migrationClientSuite) TestAddBlob(c *gc.C) {
+	s.SetJSONSuccess(c, &params.ErrorResult{})
+
+	content := strings.NewReader("charm")
+	err := s.client.AddBlob(coretesting.EnvironmentTag.Id(), "charms/wordpress", content, 5)
+	c.Assert(err, jc.ErrorIsNil)
+	s.FakeClient.CheckCalledReader(c, "migration", content, &params.MigrationBlobArgs{
+		EnvironTag:  coretesting.EnvironmentTag.String(),
+		StoragePath: "charms/wordpress",
+		Size:        5,
+	}, "blob", "SendHTTPRequestReader")
+}
+
+func (s *migrationClientSuite) TestAddTools(c *gc.C) {
+	s.SetJSONSuccess(c, &params.ErrorResult{})
+
+	content := strings.NewReader("tools")
+	err := s.client.AddTools(coretesting.EnvironmentTag.Id(), migratedTools, content)
+	c.Assert(err, jc.ErrorIsNil)
+	tools := migratedTools
+	s.FakeClient.CheckCalledReader(c, "migration", content, &params.MigrationBlobArgs{
+		EnvironTag: coretesting.EnvironmentTag.String(),
+		Tools:      &tools,
+	}, "blob", "SendHTTPRequestReader")
+}
+
+func (s *migrationClientSuite) TestAddBlobFailure(c *gc.C) {
+	s.SetFailure(c, `environment "moving" is not importing`, http.StatusInternalServerError)
+	err := s.client.AddBlob(coretesting.EnvironmentTag.Id(), "charms/wordpress", strings.NewReader(""), 0)
+	c.Assert(err, gc.ErrorMatches, `environment "moving" is not importing`)
+}
@@ -16,8 +16,12 @@ var (
 	BestVersion           = bestVersion
 	FacadeVersions        = &facadeVersions
 	NewHTTPClient         = &newHTTPClient
+	LoginRetryDelay       = loginRetryDelay
+	LoginRetryAfter       = &loginRetryAfter
 )
 
+const MaxLoginRetryDelay = maxLoginRetryDelay
+
 // SetServerAddress allows changing the URL to the internal API server
 // that AddLocalCharm uses in order to test NotImplementedError.
 func SetServerAddress(c *Client, scheme, addr string) {
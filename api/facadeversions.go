@@ -17,10 +17,14 @@ var facadeVersions = map[string]int{
 	"Annotations":                  1,
 	"Backups":                      0,
 	"Block":                        1,
+	"BlobGC":                       1,
+	"CertificateManager":           1,
 	"Charms":                       1,
 	"CharmRevisionUpdater":         0,
-	"Client":                       0,
+	"Client":                       1,
+	"ConfigHistory":                1,
 	"Cleaner":                      1,
+	"ControllerHealth":             1,
 	"Deployer":                     0,
 	"DiskManager":                  1,
 	"Environment":                  0,
@@ -28,6 +32,7 @@ var facadeVersions = map[string]int{
 	"FilesystemAttachmentsWatcher": 1,
 	"Firewaller":                   1,
 	"HighAvailability":             1,
+	"HookTranscripts":              1,
 	"ImageManager":                 1,
 	"InstancePoller":               1,
 	"KeyManager":                   0,
@@ -39,18 +44,22 @@ var facadeVersions = map[string]int{
 	"MetricsManager":               0,
 	"Networker":                    0,
 	"NotifyWatcher":                0,
+	"Payloads":                     1,
 	"Pinger":                       0,
 	"Provisioner":                  1,
+	"Quota":                        1,
 	"Reboot":                       1,
 	"RelationUnitsWatcher":         0,
 	"Resumer":                      1,
 	"Rsyslog":                      0,
 	"Service":                      1,
+	"Spaces":                       1,
 	"Storage":                      1,
 	"StorageProvisioner":           1,
 	"StringsWatcher":               0,
+	"Subnets":                      1,
 	"Upgrader":                     0,
-	"Uniter":                       2,
+	"Uniter":                       3,
 	"UserManager":                  0,
 	"VolumeAttachmentsWatcher":     1,
 }
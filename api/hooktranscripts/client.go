@@ -0,0 +1,47 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package hooktranscripts
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the hook transcripts facade, used to fetch
+// the hook transcripts recorded by units.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new hook transcripts client.
+func NewClient(st base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(st, "HookTranscripts")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// HookTranscripts returns the hook transcripts recorded for the named
+// unit, newest first.
+func (c *Client) HookTranscripts(unitName string) ([]params.HookTranscript, error) {
+	if !names.IsValidUnit(unitName) {
+		return nil, errors.NotValidf("unit name %q", unitName)
+	}
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: names.NewUnitTag(unitName).String()}},
+	}
+	var result params.HookTranscriptsResults
+	if err := c.facade.FacadeCall("List", args, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Results) != 1 {
+		return nil, errors.Errorf("expected 1 result, got %d", len(result.Results))
+	}
+	if err := result.Results[0].Error; err != nil {
+		return nil, err
+	}
+	return result.Results[0].Transcripts, nil
+}
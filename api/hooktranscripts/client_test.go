@@ -0,0 +1,91 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package hooktranscripts_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/base/testing"
+	"github.com/juju/juju/api/hooktranscripts"
+	"github.com/juju/juju/apiserver/params"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type hookTranscriptsSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&hookTranscriptsSuite{})
+
+func (s *hookTranscriptsSuite) TestHookTranscripts(c *gc.C) {
+	var callCount int
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Check(objType, gc.Equals, "HookTranscripts")
+		c.Check(version, gc.Equals, 0)
+		c.Check(id, gc.Equals, "")
+		c.Check(request, gc.Equals, "List")
+		c.Check(arg, gc.DeepEquals, params.Entities{
+			Entities: []params.Entity{{Tag: "unit-mysql-0"}},
+		})
+		c.Assert(result, gc.FitsTypeOf, &params.HookTranscriptsResults{})
+		*(result.(*params.HookTranscriptsResults)) = params.HookTranscriptsResults{
+			Results: []params.HookTranscriptsResult{{
+				Transcripts: []params.HookTranscript{{
+					Id:   "3",
+					Unit: "mysql/0",
+					Kind: "hook",
+					Name: "install",
+				}},
+			}},
+		}
+		callCount++
+		return nil
+	})
+
+	client := hooktranscripts.NewClient(apiCaller)
+	result, err := client.HookTranscripts("mysql/0")
+	c.Check(err, jc.ErrorIsNil)
+	c.Check(callCount, gc.Equals, 1)
+	c.Check(result, jc.DeepEquals, []params.HookTranscript{{
+		Id:   "3",
+		Unit: "mysql/0",
+		Kind: "hook",
+		Name: "install",
+	}})
+}
+
+func (s *hookTranscriptsSuite) TestHookTranscriptsResultError(c *gc.C) {
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		*(result.(*params.HookTranscriptsResults)) = params.HookTranscriptsResults{
+			Results: []params.HookTranscriptsResult{{
+				Error: &params.Error{Message: `unit "mysql/0" not found`, Code: params.CodeNotFound},
+			}},
+		}
+		return nil
+	})
+	client := hooktranscripts.NewClient(apiCaller)
+	_, err := client.HookTranscripts("mysql/0")
+	c.Check(err, gc.ErrorMatches, `unit "mysql/0" not found`)
+}
+
+func (s *hookTranscriptsSuite) TestHookTranscriptsInvalidUnit(c *gc.C) {
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Fatalf("unexpected API call")
+		return nil
+	})
+	client := hooktranscripts.NewClient(apiCaller)
+	_, err := client.HookTranscripts("mysql")
+	c.Check(err, gc.ErrorMatches, `unit name "mysql" not valid`)
+}
+
+func (s *hookTranscriptsSuite) TestHookTranscriptsCallError(c *gc.C) {
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		return errors.New("boom")
+	})
+	client := hooktranscripts.NewClient(apiCaller)
+	_, err := client.HookTranscripts("mysql/0")
+	c.Check(err, gc.ErrorMatches, "boom")
+}
@@ -0,0 +1,33 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package payloads
+
+import (
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the payloads facade, used to list the
+// workload payloads registered by units.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new payloads client.
+func NewClient(st base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(st, "Payloads")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// ListPayloads returns the payloads matching any of the given
+// patterns, or all payloads if no patterns are given.
+func (c *Client) ListPayloads(patterns ...string) ([]params.Payload, error) {
+	args := params.PayloadListArgs{Patterns: patterns}
+	var result params.PayloadListResults
+	if err := c.facade.FacadeCall("List", args, &result); err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package payloads_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/base/testing"
+	"github.com/juju/juju/api/payloads"
+	"github.com/juju/juju/apiserver/params"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type payloadsSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&payloadsSuite{})
+
+func (s *payloadsSuite) TestListPayloads(c *gc.C) {
+	var callCount int
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		c.Check(objType, gc.Equals, "Payloads")
+		c.Check(version, gc.Equals, 0)
+		c.Check(id, gc.Equals, "")
+		c.Check(request, gc.Equals, "List")
+		c.Check(arg, gc.DeepEquals, params.PayloadListArgs{
+			Patterns: []string{"docker", "mysql/*"},
+		})
+		c.Assert(result, gc.FitsTypeOf, &params.PayloadListResults{})
+		*(result.(*params.PayloadListResults)) = params.PayloadListResults{
+			Results: []params.Payload{{
+				Name:    "web",
+				Type:    "docker",
+				ID:      "abc",
+				Status:  "running",
+				Unit:    "mysql/0",
+				Machine: "1",
+			}},
+		}
+		callCount++
+		return nil
+	})
+
+	client := payloads.NewClient(apiCaller)
+	result, err := client.ListPayloads("docker", "mysql/*")
+	c.Check(err, jc.ErrorIsNil)
+	c.Check(callCount, gc.Equals, 1)
+	c.Check(result, jc.DeepEquals, []params.Payload{{
+		Name:    "web",
+		Type:    "docker",
+		ID:      "abc",
+		Status:  "running",
+		Unit:    "mysql/0",
+		Machine: "1",
+	}})
+}
+
+func (s *payloadsSuite) TestListPayloadsError(c *gc.C) {
+	apiCaller := testing.APICallerFunc(func(objType string, version int, id, request string, arg, result interface{}) error {
+		return errors.New("boom")
+	})
+	client := payloads.NewClient(apiCaller)
+	_, err := client.ListPayloads()
+	c.Check(err, gc.ErrorMatches, "boom")
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The quota package provides a client for the Quota API, used to
+// report and set the resource quotas placed on environments and on
+// their owners.
+package quota
+
+import (
+	"github.com/juju/names"
+
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the quota service.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new Quota client.
+func NewClient(caller base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(caller, "Quota")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// EnvironQuota returns the quota placed on the environment, and the
+// resources the environment currently uses.
+func (c *Client) EnvironQuota() (params.QuotaResult, error) {
+	var result params.QuotaResult
+	err := c.facade.FacadeCall("EnvironQuota", nil, &result)
+	return result, err
+}
+
+// SetEnvironQuota replaces the quota placed on the environment.
+func (c *Client) SetEnvironQuota(quota params.Quota) error {
+	return c.facade.FacadeCall("SetEnvironQuota", quota, nil)
+}
+
+// OwnerQuota returns the quota placed on the environments owned by the
+// given user, and the resources those environments currently use.
+func (c *Client) OwnerQuota(owner names.UserTag) (params.QuotaResult, error) {
+	var result params.QuotaResult
+	arg := params.Entity{Tag: owner.String()}
+	err := c.facade.FacadeCall("OwnerQuota", arg, &result)
+	return result, err
+}
+
+// SetOwnerQuota replaces the quota placed on the environments owned by
+// the given user.
+func (c *Client) SetOwnerQuota(owner names.UserTag, quota params.Quota) error {
+	args := params.SetOwnerQuota{
+		Owner: owner.String(),
+		Quota: quota,
+	}
+	return c.facade.FacadeCall("SetOwnerQuota", args, nil)
+}
@@ -0,0 +1,64 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package quota_test
+
+import (
+	stdtesting "testing"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/quota"
+	"github.com/juju/juju/apiserver/params"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type clientSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func (s *clientSuite) TestEnvironQuota(c *gc.C) {
+	client := quota.NewClient(s.APIState)
+	defer client.Close()
+
+	err := client.SetEnvironQuota(params.Quota{MaxMachines: intPtr(1)})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := client.EnvironQuota()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.QuotaResult{
+		Quota: params.Quota{MaxMachines: intPtr(1)},
+		Usage: params.QuotaUsage{Machines: 1},
+	})
+}
+
+func (s *clientSuite) TestOwnerQuota(c *gc.C) {
+	client := quota.NewClient(s.APIState)
+	defer client.Close()
+
+	user := s.Factory.MakeUser(c, &factory.UserParams{Name: "bob"}).UserTag()
+	err := client.SetOwnerQuota(user, params.Quota{MaxUnits: intPtr(5)})
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := client.OwnerQuota(user)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.QuotaResult{
+		Quota: params.Quota{MaxUnits: intPtr(5)},
+	})
+}
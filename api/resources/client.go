@@ -0,0 +1,130 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package resources provides access to the charm resources HTTP
+// endpoint of the API server, through which users upload resources
+// for services and units download them.
+package resources
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/juju/errors"
+
+	apihttp "github.com/juju/juju/apiserver/http"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// HTTPClient represents the methods of api.State (see api/http.go)
+// needed for direct HTTP requests to the resources endpoint.
+type HTTPClient interface {
+	// SendHTTPRequest sends an HTTP GET request relative to the client.
+	SendHTTPRequest(path string, args interface{}) (*http.Request, *http.Response, error)
+	// SendHTTPRequestReader sends an HTTP PUT request relative to the client.
+	SendHTTPRequestReader(path string, attached io.Reader, meta interface{}, name string) (*http.Request, *http.Response, error)
+}
+
+// Client provides access to the resources HTTP endpoint.
+type Client struct {
+	http HTTPClient
+}
+
+// NewClient returns a new resources client using the given HTTP
+// client.
+func NewClient(http HTTPClient) *Client {
+	return &Client{http: http}
+}
+
+// Upload uploads a new revision of the named resource of the service.
+// The content must supply exactly size bytes, whose hex-encoded
+// SHA-256 hash is given; the server refuses the upload otherwise.
+func (c *Client) Upload(service, name string, content io.Reader, size int64, sha256 string) (params.Resource, error) {
+	args := params.ResourceUploadArgs{
+		Service: service,
+		Name:    name,
+		Size:    size,
+		SHA256:  sha256,
+	}
+	_, resp, err := c.http.SendHTTPRequestReader("resources", content, &args, name)
+	if err != nil {
+		return params.Resource{}, errors.Annotate(err, "while sending HTTP request")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return params.Resource{}, extractError(resp)
+	}
+	var result params.Resource
+	if err := apihttp.ExtractJSONResult(resp, &result); err != nil {
+		return params.Resource{}, errors.Annotate(err, "while extracting result")
+	}
+	return result, nil
+}
+
+// List returns the current revisions of the resources uploaded for
+// the service.
+func (c *Client) List(service string) ([]params.Resource, error) {
+	args := params.ResourceArgs{Service: service}
+	_, resp, err := c.http.SendHTTPRequest("resources", &args)
+	if err != nil {
+		return nil, errors.Annotate(err, "while sending HTTP request")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, extractError(resp)
+	}
+	var result params.ResourcesResult
+	if err := apihttp.ExtractJSONResult(resp, &result); err != nil {
+		return nil, errors.Annotate(err, "while extracting result")
+	}
+	return result.Resources, nil
+}
+
+// Open returns a reader for the content of the current revision of
+// the named resource of the service, and the hex-encoded SHA-256
+// hash of the content as reported by the server. The caller is
+// responsible for verifying the content against the hash, and for
+// closing the reader.
+func (c *Client) Open(service, name string) (io.ReadCloser, string, error) {
+	args := params.ResourceArgs{Service: service, Name: name}
+	_, resp, err := c.http.SendHTTPRequest("resources", &args)
+	if err != nil {
+		return nil, "", errors.Annotate(err, "while sending HTTP request")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", extractError(resp)
+	}
+	hash, err := sha256Digest(resp.Header.Get("Digest"))
+	if err != nil {
+		resp.Body.Close()
+		return nil, "", errors.Trace(err)
+	}
+	return resp.Body, hash, nil
+}
+
+// sha256Digest returns the hex-encoded SHA-256 hash held in the given
+// Digest header value.
+func sha256Digest(header string) (string, error) {
+	prefix := string(apihttp.DigestSHA256) + "="
+	for _, digest := range strings.Split(header, ",") {
+		digest = strings.TrimSpace(digest)
+		if !strings.HasPrefix(digest, prefix) {
+			continue
+		}
+		hash, err := base64.StdEncoding.DecodeString(digest[len(prefix):])
+		if err != nil {
+			return "", errors.Annotatef(err, "invalid digest %q", digest)
+		}
+		return hex.EncodeToString(hash), nil
+	}
+	return "", errors.Errorf("missing %s digest in response", apihttp.DigestSHA256)
+}
+
+func extractError(resp *http.Response) error {
+	failure, err := apihttp.ExtractAPIError(resp)
+	if err != nil {
+		return errors.Annotate(err, "while extracting failure")
+	}
+	return errors.Trace(failure)
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package resources_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	httptesting "github.com/juju/juju/api/http/testing"
+	"github.com/juju/juju/api/resources"
+	apihttp "github.com/juju/juju/apiserver/http"
+	"github.com/juju/juju/apiserver/params"
+)
+
+type clientSuite struct {
+	httptesting.APIHTTPClientSuite
+	client *resources.Client
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+func (s *clientSuite) SetUpTest(c *gc.C) {
+	s.APIHTTPClientSuite.SetUpTest(c)
+	s.client = resources.NewClient(&s.FakeClient)
+}
+
+func (s *clientSuite) TestUpload(c *gc.C) {
+	s.SetJSONSuccess(c, &params.Resource{Service: "java", Name: "jdk", Revision: 3})
+
+	content := strings.NewReader("java")
+	result, err := s.client.Upload("java", "jdk", content, 4, "0123")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.Resource{Service: "java", Name: "jdk", Revision: 3})
+	s.FakeClient.CheckCalledReader(c, "resources", content, &params.ResourceUploadArgs{
+		Service: "java",
+		Name:    "jdk",
+		Size:    4,
+		SHA256:  "0123",
+	}, "jdk", "SendHTTPRequestReader")
+}
+
+func (s *clientSuite) TestUploadFailure(c *gc.C) {
+	s.SetFailure(c, `resource "jre" not found`, http.StatusNotFound)
+	_, err := s.client.Upload("java", "jre", strings.NewReader(""), 0, "")
+	c.Assert(err, gc.ErrorMatches, `resource "jre" not found`)
+}
+
+func (s *clientSuite) TestList(c *gc.C) {
+	s.SetJSONSuccess(c, &params.ResourcesResult{
+		Resources: []params.Resource{{Service: "java", Name: "jdk", Revision: 1}},
+	})
+	result, err := s.client.List("java")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, []params.Resource{{Service: "java", Name: "jdk", Revision: 1}})
+}
+
+func (s *clientSuite) TestOpen(c *gc.C) {
+	s.SetResponse(c, http.StatusOK, []byte("java"), apihttp.CTypeRaw)
+	// The base64 encoding of the bytes 0x01 0x23.
+	s.FakeClient.Response.Header.Set("Digest", "SHA=AAAA, SHA-256=ASM=")
+
+	r, hash, err := s.client.Open("java", "jdk")
+	c.Assert(err, jc.ErrorIsNil)
+	defer r.Close()
+	c.Assert(hash, gc.Equals, "0123")
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "java")
+}
+
+func (s *clientSuite) TestOpenMissingDigest(c *gc.C) {
+	s.SetResponse(c, http.StatusOK, []byte("java"), apihttp.CTypeRaw)
+	_, _, err := s.client.Open("java", "jdk")
+	c.Assert(err, gc.ErrorMatches, "missing SHA-256 digest in response")
+}
+
+func (s *clientSuite) TestOpenFailure(c *gc.C) {
+	s.SetFailure(c, `resource "jdk" not found`, http.StatusNotFound)
+	_, _, err := s.client.Open("java", "jdk")
+	c.Assert(err, gc.ErrorMatches, `resource "jdk" not found`)
+}
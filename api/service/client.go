@@ -48,7 +48,8 @@ func (c *Client) SetMetricCredentials(service string, credentials []byte) error
 // the charm store, and deploys it. It allows the specification of
 // requested networks that must be present on the machines where the
 // service is deployed. Another way to specify networks to include/exclude
-// is using constraints.
+// is using constraints. Endpoint bindings map charm endpoint names to
+// the spaces whose addresses are used for relations on them.
 func (c *Client) ServiceDeploy(
 	charmURL string,
 	serviceName string,
@@ -58,17 +59,19 @@ func (c *Client) ServiceDeploy(
 	toMachineSpec string,
 	networks []string,
 	storage map[string]storage.Constraints,
+	bindings map[string]string,
 ) error {
 	args := params.ServicesDeploy{
 		Services: []params.ServiceDeploy{{
-			ServiceName:   serviceName,
-			CharmUrl:      charmURL,
-			NumUnits:      numUnits,
-			ConfigYAML:    configYAML,
-			Constraints:   cons,
-			ToMachineSpec: toMachineSpec,
-			Networks:      networks,
-			Storage:       storage,
+			ServiceName:      serviceName,
+			CharmUrl:         charmURL,
+			NumUnits:         numUnits,
+			ConfigYAML:       configYAML,
+			Constraints:      cons,
+			ToMachineSpec:    toMachineSpec,
+			Networks:         networks,
+			Storage:          storage,
+			EndpointBindings: bindings,
 		}},
 	}
 	var results params.ErrorResults
@@ -0,0 +1,49 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The spaces package provides a client for the Spaces API, used to
+// create and list the network spaces of an environment.
+package spaces
+
+import (
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the spaces service.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new Spaces client.
+func NewClient(caller base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(caller, "Spaces")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// CreateSpace creates a space with the given name, containing the
+// existing subnets with the given CIDRs.
+func (c *Client) CreateSpace(name string, subnetCIDRs []string) error {
+	args := params.CreateSpacesParams{
+		Spaces: []params.CreateSpaceParams{{
+			Name:        name,
+			SubnetCIDRs: subnetCIDRs,
+		}},
+	}
+	var results params.ErrorResults
+	if err := c.facade.FacadeCall("CreateSpaces", args, &results); err != nil {
+		return err
+	}
+	return results.OneError()
+}
+
+// ListSpaces returns all the spaces of the environment, along with the
+// subnets in each of them.
+func (c *Client) ListSpaces() ([]params.Space, error) {
+	var result params.ListSpacesResults
+	if err := c.facade.FacadeCall("ListSpaces", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package spaces_test
+
+import (
+	stdtesting "testing"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/spaces"
+	"github.com/juju/juju/apiserver/params"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type clientSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+func (s *clientSuite) TestCreateAndListSpaces(c *gc.C) {
+	_, err := s.State.AddSubnet(state.SubnetInfo{CIDR: "10.0.0.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	client := spaces.NewClient(s.APIState)
+	defer client.Close()
+
+	err = client.CreateSpace("db", []string{"10.0.0.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = client.CreateSpace("db", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add space "db": space "db" already exists`)
+
+	list, err := client.ListSpaces()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(list, jc.DeepEquals, []params.Space{{
+		Name: "db",
+		Subnets: []params.Subnet{{
+			CIDR:      "10.0.0.0/24",
+			SpaceName: "db",
+			Life:      params.Alive,
+		}},
+	}})
+}
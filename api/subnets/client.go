@@ -0,0 +1,36 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The subnets package provides a client for the Subnets API, used to
+// add subnets to the network spaces of an environment.
+package subnets
+
+import (
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Client provides access to the subnets service.
+type Client struct {
+	base.ClientFacade
+	facade base.FacadeCaller
+}
+
+// NewClient returns a new Subnets client.
+func NewClient(caller base.APICallCloser) *Client {
+	frontend, backend := base.NewClientFacade(caller, "Subnets")
+	return &Client{ClientFacade: frontend, facade: backend}
+}
+
+// AddSubnet adds the given subnet to the environment, in the existing
+// space it names.
+func (c *Client) AddSubnet(subnet params.Subnet) error {
+	args := params.AddSubnetsParams{
+		Subnets: []params.Subnet{subnet},
+	}
+	var results params.ErrorResults
+	if err := c.facade.FacadeCall("AddSubnets", args, &results); err != nil {
+		return err
+	}
+	return results.OneError()
+}
@@ -0,0 +1,44 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package subnets_test
+
+import (
+	stdtesting "testing"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api/subnets"
+	"github.com/juju/juju/apiserver/params"
+	jujutesting "github.com/juju/juju/juju/testing"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type clientSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+func (s *clientSuite) TestAddSubnet(c *gc.C) {
+	_, err := s.State.AddSpace("db", nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	client := subnets.NewClient(s.APIState)
+	defer client.Close()
+
+	err = client.AddSubnet(params.Subnet{CIDR: "10.0.0.0/24", Zone: "zone0", SpaceName: "db"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = client.AddSubnet(params.Subnet{CIDR: "10.1.0.0/24", SpaceName: "missing"})
+	c.Assert(err, gc.ErrorMatches, `cannot add subnet "10.1.0.0/24": space "missing" not found`)
+
+	subnet, err := s.State.Subnet("10.0.0.0/24")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(subnet.SpaceName(), gc.Equals, "db")
+	c.Assert(subnet.AvailabilityZone(), gc.Equals, "zone0")
+}
@@ -5,6 +5,7 @@ package uniter
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/juju/errors"
 	"github.com/juju/names"
@@ -163,6 +164,104 @@ func (u *Unit) AddMetricBatches(batches []params.MetricBatch) (map[string]error,
 	return batchResults, nil
 }
 
+// RegisterPayload records the given workload payload against the unit.
+func (u *Unit) RegisterPayload(payload params.Payload) error {
+	if u.st.facade.BestAPIVersion() < 3 {
+		return errors.NotImplementedf("RegisterPayload")
+	}
+	var result params.ErrorResults
+	args := params.RegisterPayloadArgs{
+		Args: []params.RegisterPayloadArg{{
+			Tag:     u.tag.String(),
+			Payload: payload,
+		}},
+	}
+	err := u.st.facade.FacadeCall("RegisterPayloads", args, &result)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return result.OneError()
+}
+
+// Resources returns the current revisions of the charm resources
+// uploaded for the unit's service.
+func (u *Unit) Resources() ([]params.Resource, error) {
+	if u.st.resources == nil {
+		return nil, errors.NotSupportedf("resources")
+	}
+	return u.st.resources.List(u.ServiceName())
+}
+
+// OpenResource returns a reader for the content of the current
+// revision of the named charm resource of the unit's service, and
+// the hex-encoded SHA-256 hash the content is expected to have. The
+// caller is responsible for closing the reader.
+func (u *Unit) OpenResource(name string) (io.ReadCloser, string, error) {
+	if u.st.resources == nil {
+		return nil, "", errors.NotSupportedf("resources")
+	}
+	return u.st.resources.Open(u.ServiceName(), name)
+}
+
+// AddHookTranscript stores the supplied hook transcript for the unit.
+func (u *Unit) AddHookTranscript(transcript params.HookTranscript) error {
+	if u.st.facade.BestAPIVersion() < 3 {
+		return errors.NotImplementedf("AddHookTranscript")
+	}
+	var result params.ErrorResults
+	args := params.HookTranscriptArgs{
+		Args: []params.HookTranscriptArg{{
+			Tag:        u.tag.String(),
+			Transcript: transcript,
+		}},
+	}
+	err := u.st.facade.FacadeCall("AddHookTranscripts", args, &result)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return result.OneError()
+}
+
+// UnregisterPayload removes the named workload payload from the unit.
+func (u *Unit) UnregisterPayload(name string) error {
+	if u.st.facade.BestAPIVersion() < 3 {
+		return errors.NotImplementedf("UnregisterPayload")
+	}
+	var result params.ErrorResults
+	args := params.PayloadStatusArgs{
+		Args: []params.PayloadStatusArg{{
+			Tag:  u.tag.String(),
+			Name: name,
+		}},
+	}
+	err := u.st.facade.FacadeCall("UnregisterPayloads", args, &result)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return result.OneError()
+}
+
+// SetPayloadStatus sets the status of the named workload payload of
+// the unit.
+func (u *Unit) SetPayloadStatus(name, status string) error {
+	if u.st.facade.BestAPIVersion() < 3 {
+		return errors.NotImplementedf("SetPayloadStatus")
+	}
+	var result params.ErrorResults
+	args := params.PayloadStatusArgs{
+		Args: []params.PayloadStatusArg{{
+			Tag:    u.tag.String(),
+			Name:   name,
+			Status: status,
+		}},
+	}
+	err := u.st.facade.FacadeCall("SetPayloadsStatus", args, &result)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return result.OneError()
+}
+
 // EnsureDead sets the unit lifecycle to Dead if it is Alive or
 // Dying. It does nothing otherwise.
 func (u *Unit) EnsureDead() error {
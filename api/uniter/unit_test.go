@@ -4,7 +4,11 @@
 package uniter_test
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/juju/errors"
@@ -129,6 +133,99 @@ func (s *unitSuite) TestSetUnitStatusOldServer(c *gc.C) {
 	c.Assert(err.Error(), gc.Equals, "SetUnitStatus not implemented")
 }
 
+func (s *unitSuite) TestPayloads(c *gc.C) {
+	err := s.apiUnit.RegisterPayload(params.Payload{
+		Name:   "web",
+		Type:   "docker",
+		ID:     "abc123",
+		Status: "starting",
+		Labels: []string{"frontend"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.apiUnit.SetPayloadStatus("web", "running")
+	c.Assert(err, jc.ErrorIsNil)
+
+	payload, err := s.wordpressUnit.Payload("web")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(payload.PayloadInfo, jc.DeepEquals, state.PayloadInfo{
+		Name:   "web",
+		Type:   "docker",
+		ID:     "abc123",
+		Status: state.PayloadRunning,
+		Labels: []string{"frontend"},
+	})
+
+	err = s.apiUnit.UnregisterPayload("web")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.wordpressUnit.Payload("web")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *unitSuite) TestPayloadsOldServer(c *gc.C) {
+	s.patchNewState(c, uniter.NewStateV2)
+
+	err := s.apiUnit.RegisterPayload(params.Payload{Name: "web"})
+	c.Assert(err, jc.Satisfies, errors.IsNotImplemented)
+	err = s.apiUnit.UnregisterPayload("web")
+	c.Assert(err, jc.Satisfies, errors.IsNotImplemented)
+	err = s.apiUnit.SetPayloadStatus("web", "running")
+	c.Assert(err, jc.Satisfies, errors.IsNotImplemented)
+}
+
+func (s *unitSuite) TestResources(c *gc.C) {
+	resources, err := s.apiUnit.Resources()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resources, gc.HasLen, 0)
+
+	hash := sha256.Sum256([]byte("java"))
+	hexHash := hex.EncodeToString(hash[:])
+	_, err = s.wordpressService.SetResource("jdk", strings.NewReader("java"), 4, hexHash, s.AdminUserTag(c))
+	c.Assert(err, jc.ErrorIsNil)
+
+	resources, err = s.apiUnit.Resources()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resources, gc.HasLen, 1)
+	c.Assert(resources[0].Name, gc.Equals, "jdk")
+	c.Assert(resources[0].Revision, gc.Equals, 1)
+	c.Assert(resources[0].SHA256, gc.Equals, hexHash)
+
+	r, digest, err := s.apiUnit.OpenResource("jdk")
+	c.Assert(err, jc.ErrorIsNil)
+	defer r.Close()
+	c.Assert(digest, gc.Equals, hexHash)
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "java")
+
+	_, _, err = s.apiUnit.OpenResource("jre")
+	c.Assert(err, gc.ErrorMatches, `resource "jre" not found`)
+}
+
+func (s *unitSuite) TestAddHookTranscript(c *gc.C) {
+	err := s.apiUnit.AddHookTranscript(params.HookTranscript{
+		Kind:     "hook",
+		Name:     "install",
+		Env:      []string{"JUJU_UNIT_NAME=wordpress/0"},
+		ExitCode: 0,
+		Started:  time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC),
+		Duration: time.Second,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	transcripts, err := s.wordpressUnit.HookTranscripts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(transcripts, gc.HasLen, 1)
+	c.Assert(transcripts[0].Name, gc.Equals, "install")
+	c.Assert(transcripts[0].Duration, gc.Equals, time.Second)
+}
+
+func (s *unitSuite) TestAddHookTranscriptOldServer(c *gc.C) {
+	s.patchNewState(c, uniter.NewStateV2)
+
+	err := s.apiUnit.AddHookTranscript(params.HookTranscript{Name: "install"})
+	c.Assert(err, jc.Satisfies, errors.IsNotImplemented)
+}
+
 func (s *unitSuite) TestSetAgentStatusOldServer(c *gc.C) {
 	s.patchNewState(c, uniter.NewStateV1)
 
@@ -12,6 +12,7 @@ import (
 
 	"github.com/juju/juju/api/base"
 	"github.com/juju/juju/api/common"
+	"github.com/juju/juju/api/resources"
 	"github.com/juju/juju/api/watcher"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/network"
@@ -27,6 +28,8 @@ type State struct {
 
 	LeadershipSettings *LeadershipSettingsAccessor
 	facade             base.FacadeCaller
+	// resources is nil if the caller cannot make direct HTTP requests.
+	resources *resources.Client
 	// unitTag contains the authenticated unit's tag.
 	unitTag names.UnitTag
 }
@@ -50,6 +53,9 @@ func newStateForVersion(
 		facade:          facadeCaller,
 		unitTag:         authTag,
 	}
+	if client, ok := caller.(resources.HTTPClient); ok {
+		state.resources = resources.NewClient(client)
+	}
 
 	if version >= 2 {
 		newWatcher := func(result params.NotifyWatchResult) watcher.NotifyWatcher {
@@ -80,9 +86,12 @@ var newStateV1 = newStateForVersionFn(1)
 // newStateV2 creates a new client-side Uniter facade, version 2.
 var newStateV2 = newStateForVersionFn(2)
 
+// newStateV3 creates a new client-side Uniter facade, version 3.
+var newStateV3 = newStateForVersionFn(3)
+
 // NewState creates a new client-side Uniter facade.
 // Defined like this to allow patching during tests.
-var NewState = newStateV2
+var NewState = newStateV3
 
 // BestAPIVersion returns the API version that we were able to
 // determine is supported by both the client and the API Server.
@@ -6,6 +6,7 @@ package usermanager
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
@@ -166,3 +167,49 @@ func (c *Client) SetPassword(username, password string) error {
 	}
 	return results.OneError()
 }
+
+// CreateAPIToken creates an API token for the logged in user, and
+// returns the credentials with which the token is used in place of the
+// user's password. If facades is not empty, connections made with the
+// token may only use the named facades; if expires is not nil, the
+// token cannot be used after that time.
+func (c *Client) CreateAPIToken(name string, facades []string, expires *time.Time) (string, error) {
+	args := params.CreateAPITokens{
+		Tokens: []params.CreateAPIToken{{
+			Name:    name,
+			Facades: facades,
+			Expires: expires,
+		}},
+	}
+	var results params.CreateAPITokenResults
+	if err := c.facade.FacadeCall("CreateAPITokens", args, &results); err != nil {
+		return "", errors.Trace(err)
+	}
+	if count := len(results.Results); count != 1 {
+		return "", errors.Errorf("expected 1 result, got %d", count)
+	}
+	if err := results.Results[0].Error; err != nil {
+		return "", errors.Trace(err)
+	}
+	return results.Results[0].Credentials, nil
+}
+
+// ListAPITokens returns the logged in user's API tokens.
+func (c *Client) ListAPITokens() ([]params.APIToken, error) {
+	var result params.APITokensResult
+	if err := c.facade.FacadeCall("ListAPITokens", nil, &result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return result.Tokens, nil
+}
+
+// RevokeAPIToken revokes the logged in user's API token with the given
+// name.
+func (c *Client) RevokeAPIToken(name string) error {
+	args := params.APITokenNames{Names: []string{name}}
+	var results params.ErrorResults
+	if err := c.facade.FacadeCall("RevokeAPITokens", args, &results); err != nil {
+		return errors.Trace(err)
+	}
+	return results.OneError()
+}
@@ -8,6 +8,7 @@ import (
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
+	"github.com/juju/juju/api"
 	"github.com/juju/juju/api/usermanager"
 	"github.com/juju/juju/apiserver/params"
 	jujutesting "github.com/juju/juju/juju/testing"
@@ -177,3 +178,55 @@ func (s *usermanagerSuite) TestSetUserPasswordBadName(c *gc.C) {
 	err := s.usermanager.SetPassword("not@home", "new-password")
 	c.Assert(err, gc.ErrorMatches, `"not@home" is not a valid username`)
 }
+
+func (s *usermanagerSuite) TestAPITokens(c *gc.C) {
+	credentials, err := s.usermanager.CreateAPIToken("ci", []string{"Client"}, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(credentials, gc.Matches, "token:ci:.+")
+
+	tokens, err := s.usermanager.ListAPITokens()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(tokens, gc.HasLen, 1)
+	c.Assert(tokens[0].Name, gc.Equals, "ci")
+	c.Assert(tokens[0].Facades, jc.DeepEquals, []string{"Client"})
+	c.Assert(tokens[0].Expires, gc.IsNil)
+
+	err = s.usermanager.RevokeAPIToken("ci")
+	c.Assert(err, jc.ErrorIsNil)
+	tokens, err = s.usermanager.ListAPITokens()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(tokens, gc.HasLen, 0)
+
+	err = s.usermanager.RevokeAPIToken("ci")
+	c.Assert(err, gc.ErrorMatches, `API token "ci" for user ".*" not found`)
+}
+
+func (s *usermanagerSuite) TestCreateExistingAPIToken(c *gc.C) {
+	_, err := s.usermanager.CreateAPIToken("ci", nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.usermanager.CreateAPIToken("ci", nil, nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add API token "ci" for user ".*": API token "ci" already exists`)
+}
+
+func (s *usermanagerSuite) TestLoginWithAPIToken(c *gc.C) {
+	credentials, err := s.usermanager.CreateAPIToken("ci", []string{"Client"}, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	info := s.APIInfo(c)
+	info.Password = credentials
+	st, err := api.Open(info, api.DialOpts{})
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+
+	// The token allows the Client facade, but not UserManager.
+	_, err = st.Client().Status(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = usermanager.NewClient(st).ListAPITokens()
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+
+	// Once revoked, the token can no longer be used.
+	err = s.usermanager.RevokeAPIToken("ci")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = api.Open(info, api.DialOpts{})
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+}
@@ -0,0 +1,127 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package action
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/leadership"
+	"github.com/juju/juju/lease"
+	"github.com/juju/juju/state"
+)
+
+// serviceLeader returns the name of the unit that currently leads the
+// named service.
+var serviceLeader = func(serviceName string) (string, error) {
+	return leadership.NewLeadershipManager(lease.Manager()).ServiceLeader(serviceName)
+}
+
+// EnqueueBatches runs each of the given actions across the units of a
+// service, returning the batch as enqueued or an error for each.
+func (a *ActionAPI) EnqueueBatches(args params.ActionBatches) (params.ActionBatchResults, error) {
+	response := params.ActionBatchResults{Results: make([]params.ActionBatchResult, len(args.Batches))}
+	for i, arg := range args.Batches {
+		currentResult := &response.Results[i]
+		serviceTag, err := names.ParseServiceTag(arg.Service)
+		if err != nil {
+			currentResult.Error = common.ServerError(common.ErrBadId)
+			continue
+		}
+		var units []string
+		for _, unit := range arg.Units {
+			unitTag, err := names.ParseUnitTag(unit.Unit)
+			if err != nil {
+				units = nil
+				break
+			}
+			units = append(units, unitTag.Id())
+		}
+		if len(units) != len(arg.Units) {
+			currentResult.Error = common.ServerError(common.ErrBadId)
+			continue
+		}
+		if arg.LeaderOnly {
+			leader, err := serviceLeader(serviceTag.Id())
+			if err != nil {
+				currentResult.Error = common.ServerError(err)
+				continue
+			}
+			units = []string{leader}
+		}
+		batch, err := a.state.AddActionBatch(state.ActionBatchParams{
+			Service:       serviceTag.Id(),
+			Units:         units,
+			Name:          arg.Name,
+			Parameters:    arg.Parameters,
+			MaxConcurrent: arg.MaxConcurrent,
+			LeaderOnly:    arg.LeaderOnly,
+		})
+		if err != nil {
+			currentResult.Error = common.ServerError(err)
+			continue
+		}
+		currentResult.Batch, err = makeActionBatch(batch)
+		if err != nil {
+			currentResult.Error = common.ServerError(err)
+		}
+	}
+	return response, nil
+}
+
+// Batches returns the action batches with the given ids, together with
+// the progress of each on its units.
+func (a *ActionAPI) Batches(args params.ActionBatchIds) (params.ActionBatchResults, error) {
+	response := params.ActionBatchResults{Results: make([]params.ActionBatchResult, len(args.Ids))}
+	for i, id := range args.Ids {
+		currentResult := &response.Results[i]
+		batch, err := a.state.ActionBatch(id)
+		if err != nil {
+			currentResult.Error = common.ServerError(err)
+			continue
+		}
+		currentResult.Batch, err = makeActionBatch(batch)
+		if err != nil {
+			currentResult.Error = common.ServerError(err)
+		}
+	}
+	return response, nil
+}
+
+// makeActionBatch converts a *state.ActionBatch to a params.ActionBatch.
+func makeActionBatch(batch *state.ActionBatch) (*params.ActionBatch, error) {
+	actions, err := batch.Actions()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	result := &params.ActionBatch{
+		Id:            batch.Id(),
+		Service:       names.NewServiceTag(batch.Service()).String(),
+		Name:          batch.Name(),
+		Parameters:    batch.Parameters(),
+		MaxConcurrent: batch.MaxConcurrent(),
+		LeaderOnly:    batch.LeaderOnly(),
+		Status:        string(batch.Status()),
+		Message:       batch.Message(),
+		Enqueued:      batch.Enqueued(),
+		Completed:     batch.Completed(),
+	}
+	for i, unit := range batch.Units() {
+		unitResult := params.ActionBatchUnit{
+			Unit:   names.NewUnitTag(unit).String(),
+			Status: params.ActionBatchWaiting,
+		}
+		switch {
+		case i < len(actions):
+			unitResult.Action = actions[i].ActionTag().String()
+			unitResult.Status = string(actions[i].Status())
+		case batch.Status() != state.ActionRunning:
+			unitResult.Status = params.ActionBatchSkipped
+		}
+		result.Units = append(result.Units, unitResult)
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package action_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/action"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+	jujuFactory "github.com/juju/juju/testing/factory"
+)
+
+func (s *actionSuite) TestEnqueueBatches(c *gc.C) {
+	factory := jujuFactory.NewFactory(s.State)
+	second := factory.MakeUnit(c, &jujuFactory.UnitParams{
+		Service: s.wordpress,
+		Machine: s.machine1,
+	})
+	arg := params.ActionBatches{
+		Batches: []params.ActionBatch{{
+			Service:       s.wordpress.Tag().String(),
+			Name:          "fakeaction",
+			MaxConcurrent: 1,
+		}, {
+			Service: "bogus",
+			Name:    "fakeaction",
+		}, {
+			Service: s.mysql.Tag().String(),
+			Name:    "nosuchaction",
+		}},
+	}
+	r, err := s.action.EnqueueBatches(arg)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(r.Results, gc.HasLen, 3)
+
+	c.Assert(r.Results[0].Error, gc.IsNil)
+	batch := r.Results[0].Batch
+	c.Assert(batch.Service, gc.Equals, "service-wordpress")
+	c.Assert(batch.Status, gc.Equals, params.ActionRunning)
+	c.Assert(batch.MaxConcurrent, gc.Equals, 1)
+	c.Assert(batch.Units, gc.HasLen, 2)
+	c.Assert(batch.Units[0].Unit, gc.Equals, s.wordpressUnit.Tag().String())
+	c.Assert(batch.Units[0].Status, gc.Equals, params.ActionPending)
+	c.Assert(batch.Units[1], jc.DeepEquals, params.ActionBatchUnit{
+		Unit:   second.Tag().String(),
+		Status: params.ActionBatchWaiting,
+	})
+
+	c.Assert(r.Results[1].Error, gc.ErrorMatches, "id not found")
+	c.Assert(r.Results[2].Error, gc.ErrorMatches, `cannot add action batch: action "nosuchaction" not defined for "mysql"`)
+
+	// Fail the first action, and check the batch stops.
+	actions, err := s.wordpressUnit.PendingActions()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(actions, gc.HasLen, 1)
+	_, err = actions[0].Finish(state.ActionResults{Status: state.ActionFailed})
+	c.Assert(err, jc.ErrorIsNil)
+
+	batches, err := s.action.Batches(params.ActionBatchIds{Ids: []string{batch.Id, "42"}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(batches.Results, gc.HasLen, 2)
+	c.Assert(batches.Results[0].Error, gc.IsNil)
+	stopped := batches.Results[0].Batch
+	c.Assert(stopped.Status, gc.Equals, params.ActionFailed)
+	c.Assert(stopped.Units[0].Action, gc.Equals, batch.Units[0].Action)
+	c.Assert(stopped.Units[0].Status, gc.Equals, params.ActionFailed)
+	c.Assert(stopped.Units[1].Status, gc.Equals, params.ActionBatchSkipped)
+	c.Assert(batches.Results[1].Error, gc.ErrorMatches, "action batch 42 not found")
+	c.Assert(batches.Results[1].Error, jc.Satisfies, params.IsCodeNotFound)
+}
+
+func (s *actionSuite) TestEnqueueBatchesLeaderOnly(c *gc.C) {
+	factory := jujuFactory.NewFactory(s.State)
+	second := factory.MakeUnit(c, &jujuFactory.UnitParams{
+		Service: s.wordpress,
+		Machine: s.machine1,
+	})
+	s.PatchValue(action.ServiceLeader, func(serviceName string) (string, error) {
+		switch serviceName {
+		case "wordpress":
+			return second.Name(), nil
+		}
+		return "", errors.NotFoundf("leader of service %q", serviceName)
+	})
+	r, err := s.action.EnqueueBatches(params.ActionBatches{
+		Batches: []params.ActionBatch{{
+			Service:    s.wordpress.Tag().String(),
+			Name:       "fakeaction",
+			LeaderOnly: true,
+		}, {
+			Service:    s.mysql.Tag().String(),
+			Name:       "fakeaction",
+			LeaderOnly: true,
+		}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(r.Results, gc.HasLen, 2)
+	c.Assert(r.Results[0].Error, gc.IsNil)
+	batch := r.Results[0].Batch
+	c.Assert(batch.LeaderOnly, jc.IsTrue)
+	c.Assert(batch.Units, gc.HasLen, 1)
+	c.Assert(batch.Units[0].Unit, gc.Equals, second.Tag().String())
+	c.Assert(batch.Units[0].Status, gc.Equals, params.ActionPending)
+	c.Assert(r.Results[1].Error, gc.ErrorMatches, `leader of service "mysql" not found`)
+}
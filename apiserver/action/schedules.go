@@ -0,0 +1,121 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package action
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+// AddSchedules adds each of the given action schedules, returning the
+// schedule as stored or an error for each.
+func (a *ActionAPI) AddSchedules(args params.ActionSchedules) (params.ActionScheduleResults, error) {
+	response := params.ActionScheduleResults{Results: make([]params.ActionScheduleResult, len(args.Schedules))}
+	for i, arg := range args.Schedules {
+		currentResult := &response.Results[i]
+		receivers := make([]names.Tag, len(arg.Receivers))
+		var err error
+		for j, receiver := range arg.Receivers {
+			if receivers[j], err = names.ParseTag(receiver); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			currentResult.Error = common.ServerError(common.ErrBadId)
+			continue
+		}
+		schedule, err := a.state.AddActionSchedule(state.ActionScheduleParams{
+			Receivers:  receivers,
+			Name:       arg.Name,
+			Parameters: arg.Parameters,
+			Cron:       arg.Cron,
+			Interval:   arg.Interval,
+		})
+		if err != nil {
+			currentResult.Error = common.ServerError(err)
+			continue
+		}
+		currentResult.Schedule = makeActionSchedule(schedule)
+	}
+	return response, nil
+}
+
+// ListSchedules returns all the action schedules in the environment.
+func (a *ActionAPI) ListSchedules() (params.ActionSchedules, error) {
+	schedules, err := a.state.ActionSchedules()
+	if err != nil {
+		return params.ActionSchedules{}, errors.Trace(err)
+	}
+	response := params.ActionSchedules{Schedules: make([]params.ActionSchedule, len(schedules))}
+	for i, schedule := range schedules {
+		response.Schedules[i] = *makeActionSchedule(schedule)
+	}
+	return response, nil
+}
+
+// RemoveSchedules removes the action schedules with the given ids.
+func (a *ActionAPI) RemoveSchedules(args params.ActionScheduleIds) (params.ErrorResults, error) {
+	response := params.ErrorResults{Results: make([]params.ErrorResult, len(args.Ids))}
+	for i, id := range args.Ids {
+		response.Results[i].Error = common.ServerError(a.state.RemoveActionSchedule(id))
+	}
+	return response, nil
+}
+
+// ScheduleRuns returns the record of the runs of each of the action
+// schedules with the given ids, newest first.
+func (a *ActionAPI) ScheduleRuns(args params.ActionScheduleIds) (params.ActionScheduleRunsResults, error) {
+	response := params.ActionScheduleRunsResults{Results: make([]params.ActionScheduleRunsResult, len(args.Ids))}
+	for i, id := range args.Ids {
+		currentResult := &response.Results[i]
+		schedule, err := a.state.ActionSchedule(id)
+		if err != nil {
+			currentResult.Error = common.ServerError(err)
+			continue
+		}
+		runs, err := schedule.Runs()
+		if err != nil {
+			currentResult.Error = common.ServerError(err)
+			continue
+		}
+		currentResult.Runs = make([]params.ActionScheduleRun, len(runs))
+		for j, run := range runs {
+			actions := make([]string, len(run.Actions))
+			for k, tag := range run.Actions {
+				actions[k] = tag.String()
+			}
+			currentResult.Runs[j] = params.ActionScheduleRun{
+				ScheduleId: run.ScheduleId,
+				Time:       run.Time,
+				Actions:    actions,
+				Errors:     run.Errors,
+			}
+		}
+	}
+	return response, nil
+}
+
+// makeActionSchedule converts a *state.ActionSchedule to a
+// params.ActionSchedule.
+func makeActionSchedule(schedule *state.ActionSchedule) *params.ActionSchedule {
+	receivers := []string{}
+	for _, tag := range schedule.Receivers() {
+		receivers = append(receivers, tag.String())
+	}
+	return &params.ActionSchedule{
+		Id:         schedule.Id(),
+		Receivers:  receivers,
+		Name:       schedule.Name(),
+		Parameters: schedule.Parameters(),
+		Cron:       schedule.Cron(),
+		Interval:   schedule.Interval(),
+		Created:    schedule.Created(),
+		NextRun:    schedule.NextRun(),
+		LastRun:    schedule.LastRun(),
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package action_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+func (s *actionSuite) TestAddSchedules(c *gc.C) {
+	arg := params.ActionSchedules{
+		Schedules: []params.ActionSchedule{{
+			Receivers: []string{s.wordpress.Tag().String()},
+			Name:      "fakeaction",
+			Cron:      "30 2 * * *",
+		}, {
+			Receivers: []string{s.mysqlUnit.Tag().String()},
+			Name:      "fakeaction",
+			Interval:  time.Hour,
+		}, {
+			Receivers: []string{"bogus"},
+			Name:      "fakeaction",
+			Interval:  time.Hour,
+		}, {
+			Receivers: []string{s.wordpressUnit.Tag().String()},
+			Name:      "nosuchaction",
+			Interval:  time.Hour,
+		}},
+	}
+	r, err := s.action.AddSchedules(arg)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(r.Results, gc.HasLen, 4)
+
+	c.Assert(r.Results[0].Error, gc.IsNil)
+	first := r.Results[0].Schedule
+	c.Assert(first.Receivers, jc.DeepEquals, []string{"service-wordpress"})
+	c.Assert(first.Name, gc.Equals, "fakeaction")
+	c.Assert(first.Cron, gc.Equals, "30 2 * * *")
+	c.Assert(first.NextRun.Hour(), gc.Equals, 2)
+	c.Assert(first.NextRun.Minute(), gc.Equals, 30)
+
+	c.Assert(r.Results[1].Error, gc.IsNil)
+	c.Assert(r.Results[1].Schedule.Interval, gc.Equals, time.Hour)
+
+	c.Assert(r.Results[2].Error, gc.ErrorMatches, "id not found")
+	c.Assert(r.Results[3].Error, gc.ErrorMatches, `cannot add action schedule: action "nosuchaction" not defined for "wordpress/0"`)
+
+	list, err := s.action.ListSchedules()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(list.Schedules, gc.HasLen, 2)
+	c.Assert(list.Schedules[0], jc.DeepEquals, *first)
+	c.Assert(list.Schedules[1].Id, gc.Equals, r.Results[1].Schedule.Id)
+}
+
+func (s *actionSuite) TestRemoveSchedules(c *gc.C) {
+	r, err := s.action.AddSchedules(params.ActionSchedules{
+		Schedules: []params.ActionSchedule{{
+			Receivers: []string{s.wordpressUnit.Tag().String()},
+			Name:      "fakeaction",
+			Interval:  time.Hour,
+		}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	id := r.Results[0].Schedule.Id
+
+	removed, err := s.action.RemoveSchedules(params.ActionScheduleIds{Ids: []string{id, "42"}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(removed.Results, gc.HasLen, 2)
+	c.Assert(removed.Results[0].Error, gc.IsNil)
+	c.Assert(removed.Results[1].Error, gc.ErrorMatches, "action schedule 42 not found")
+	c.Assert(removed.Results[1].Error, jc.Satisfies, params.IsCodeNotFound)
+
+	list, err := s.action.ListSchedules()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(list.Schedules, gc.HasLen, 0)
+}
+
+func (s *actionSuite) TestScheduleRuns(c *gc.C) {
+	r, err := s.action.AddSchedules(params.ActionSchedules{
+		Schedules: []params.ActionSchedule{{
+			Receivers: []string{s.wordpressUnit.Tag().String()},
+			Name:      "fakeaction",
+			Interval:  time.Hour,
+		}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	id := r.Results[0].Schedule.Id
+	schedule, err := s.State.ActionSchedule(id)
+	c.Assert(err, jc.ErrorIsNil)
+	run, err := schedule.RunIfDue(schedule.NextRun())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(run.Actions, gc.HasLen, 1)
+
+	runs, err := s.action.ScheduleRuns(params.ActionScheduleIds{Ids: []string{id, "42"}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(runs.Results, gc.HasLen, 2)
+	c.Assert(runs.Results[0].Error, gc.IsNil)
+	c.Assert(runs.Results[0].Runs, jc.DeepEquals, []params.ActionScheduleRun{{
+		ScheduleId: id,
+		Time:       run.Time,
+		Actions:    []string{run.Actions[0].String()},
+	}})
+	c.Assert(runs.Results[1].Error, gc.ErrorMatches, "action schedule 42 not found")
+}
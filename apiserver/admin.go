@@ -75,7 +75,7 @@ func (a *admin) doLogin(req params.LoginRequest, loginVersion int) (params.Login
 		// Users are not rate limited, all other entities are
 		if !a.srv.limiter.Acquire() {
 			logger.Debugf("rate limiting for agent %s", req.AuthTag)
-			return fail, common.ErrTryAgain
+			return fail, common.ErrTryAgainAfter(a.srv.loginRetryDelay)
 		}
 		defer a.srv.limiter.Release()
 	} else {
@@ -84,7 +84,7 @@ func (a *admin) doLogin(req params.LoginRequest, loginVersion int) (params.Login
 
 	serverOnlyLogin := loginVersion > 1 && a.root.envUUID == ""
 
-	entity, lastConnection, err := doCheckCreds(a.root.state, req, !serverOnlyLogin)
+	entity, token, lastConnection, err := doCheckCreds(a.root.state, req, !serverOnlyLogin, a.srv.userDirectory)
 	if err != nil {
 		if a.maintenanceInProgress() {
 			// An upgrade, restore or similar operation is in
@@ -117,6 +117,21 @@ func (a *admin) doLogin(req params.LoginRequest, loginVersion int) (params.Login
 		agentPingerNeeded = false
 	}
 	a.root.entity = entity
+	if token != nil && len(token.Facades()) > 0 {
+		authedApi = newTokenRoot(authedApi, token)
+	}
+	if a.root.state.EnvironUUID() != a.srv.state.EnvironUUID() {
+		// Only hosted environments can be migrated.
+		authedApi = newMigratingRoot(authedApi, a.root.state)
+	}
+	// The state server machines' agents are never rate limited.
+	envUUID := a.root.state.EnvironUUID()
+	isServerAgent := !agentPingerNeeded || (entity.Tag() == a.srv.tag && envUUID == a.srv.state.EnvironUUID())
+	if !isServerAgent {
+		if key, limit, ok := a.srv.requestLimiters.limit(envUUID, entity.Tag()); ok {
+			authedApi = newRateLimitedRoot(authedApi, a.srv.requestLimiters, key, limit)
+		}
+	}
 
 	if a.reqNotifier != nil {
 		a.reqNotifier.login(entity.Tag().String())
@@ -162,6 +177,18 @@ func (a *admin) doLogin(req params.LoginRequest, loginVersion int) (params.Login
 		ServerVersion: version.Current.Number.String(),
 	}
 
+	// Connections made with a token only see the facades the token
+	// allows.
+	if token != nil && len(token.Facades()) > 0 {
+		var facades []params.FacadeVersions
+		for _, facade := range loginResult.Facades {
+			if tokenRootAllows(token, facade.Name) {
+				facades = append(facades, facade)
+			}
+		}
+		loginResult.Facades = facades
+	}
+
 	// For sufficiently modern login versions, stop serving the
 	// state server environment at the root of the API.
 	if serverOnlyLogin {
@@ -190,7 +217,7 @@ func (a *admin) doLogin(req params.LoginRequest, loginVersion int) (params.Login
 // machines.
 func (a *admin) checkCredsOfStateServerMachine(req params.LoginRequest) (state.Entity, error) {
 	// Check the credentials against the state server environment.
-	entity, _, err := doCheckCreds(a.srv.state, req, false)
+	entity, _, _, err := doCheckCreds(a.srv.state, req, false, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -233,32 +260,49 @@ var doCheckCreds = checkCreds
 // If the entity is a user, and lookForEnvUser is true, an env user must exist
 // for the environment.  In the case of a user logging in to the server, but
 // not an environment, there is no env user needed.  While we have the env
-// user, if we do have it, update the last login time.
-func checkCreds(st *state.State, req params.LoginRequest, lookForEnvUser bool) (state.Entity, *time.Time, error) {
+// user, if we do have it, update the last login time. If userDirectory is
+// not nil, users unknown to state and users created from the directory are
+// authenticated against it. If a user logged in with an API token in place
+// of a password, the token is also returned.
+func checkCreds(st *state.State, req params.LoginRequest, lookForEnvUser bool, userDirectory authentication.Directory) (state.Entity, *state.APIToken, *time.Time, error) {
 	tag, err := names.ParseTag(req.AuthTag)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	entity, err := st.FindEntity(tag)
-	if errors.IsNotFound(err) {
+	var token *state.APIToken
+	if err == nil {
+		token, err = checkAPIToken(st, entity, req.Credentials, lookForEnvUser)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if token != nil {
+		// The user has been authenticated with the token.
+	} else if userTag, ok := tag.(names.UserTag); ok && userDirectory != nil && isDirectoryUser(entity, err) {
+		entity, err = authentication.AuthenticateDirectoryUser(st, userDirectory, userTag, req.Credentials, lookForEnvUser)
+		if err != nil {
+			logger.Debugf("bad credentials for directory user %q: %v", userTag.Name(), err)
+			return nil, nil, nil, err
+		}
+	} else if errors.IsNotFound(err) {
 		// We return the same error when an entity does not exist as for a bad
 		// password, so that we don't allow unauthenticated users to find
 		// information about existing entities.
 		logger.Debugf("entity %q not found", tag)
-		return nil, nil, common.ErrBadCreds
-	}
-	if err != nil {
-		return nil, nil, errors.Trace(err)
-	}
-
-	authenticator, err := authentication.FindEntityAuthenticator(entity)
-	if err != nil {
-		return nil, nil, err
-	}
+		return nil, nil, nil, common.ErrBadCreds
+	} else if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	} else {
+		authenticator, err := authentication.FindEntityAuthenticator(entity)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 
-	if err = authenticator.Authenticate(entity, req.Credentials, req.Nonce); err != nil {
-		logger.Debugf("bad credentials")
-		return nil, nil, err
+		if err = authenticator.Authenticate(entity, req.Credentials, req.Nonce); err != nil {
+			logger.Debugf("bad credentials")
+			return nil, nil, nil, err
+		}
 	}
 
 	// For user logins, update the last login time.
@@ -271,7 +315,7 @@ func checkCreds(st *state.State, req params.LoginRequest, lookForEnvUser bool) (
 		if lookForEnvUser {
 			envUser, err := st.EnvironmentUser(user.UserTag())
 			if err != nil {
-				return nil, nil, errors.Wrap(err, common.ErrBadCreds)
+				return nil, nil, nil, errors.Wrap(err, common.ErrBadCreds)
 			}
 			// The last connection for the environment takes precedence over
 			// the local user last login time.
@@ -285,7 +329,45 @@ func checkCreds(st *state.State, req params.LoginRequest, lookForEnvUser bool) (
 		user.UpdateLastLogin()
 	}
 
-	return entity, lastLogin, nil
+	return entity, token, lastLogin, nil
+}
+
+// checkAPIToken checks credentials that hold one of the user's API
+// tokens, returning the token if they do. It returns nil if the entity
+// is not a user or the credentials hold no token of the user's, in
+// which case they are treated as a password. Tokens are scoped to an
+// environment, so they cannot be used to log in to the server alone.
+func checkAPIToken(st *state.State, entity state.Entity, credentials string, lookForEnvUser bool) (*state.APIToken, error) {
+	user, ok := entity.(*state.User)
+	if !ok {
+		return nil, nil
+	}
+	name, secret, ok := state.ParseAPITokenCredentials(credentials)
+	if !ok {
+		return nil, nil
+	}
+	token, err := st.APIToken(user.UserTag(), name)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if user.IsDisabled() || !lookForEnvUser || !token.SecretValid(secret) {
+		logger.Debugf("bad credentials for API token %q of user %q", name, user.Name())
+		return nil, common.ErrBadCreds
+	}
+	return token, nil
+}
+
+// isDirectoryUser returns whether a user looked up in state, with the
+// given result, should be authenticated against the user directory:
+// either the user is not in state yet, or was created from the directory.
+func isDirectoryUser(entity state.Entity, err error) bool {
+	if errors.IsNotFound(err) {
+		return true
+	}
+	user, ok := entity.(*state.User)
+	return err == nil && ok && user.Directory() != ""
 }
 
 func checkForValidMachineAgent(entity state.Entity, req params.LoginRequest) error {
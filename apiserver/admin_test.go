@@ -19,6 +19,7 @@ import (
 
 	"github.com/juju/juju/api"
 	"github.com/juju/juju/apiserver"
+	"github.com/juju/juju/apiserver/authentication"
 	"github.com/juju/juju/apiserver/params"
 	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/network"
@@ -29,7 +30,9 @@ import (
 
 type baseLoginSuite struct {
 	jujutesting.JujuConnSuite
-	setAdminApi func(*apiserver.Server)
+	setAdminApi   func(*apiserver.Server)
+	userDirectory authentication.Directory
+	limits        apiserver.ServerLimits
 }
 
 type loginSuite struct {
@@ -47,6 +50,8 @@ var _ = gc.Suite(&loginSuite{
 func (s *baseLoginSuite) SetUpTest(c *gc.C) {
 	s.JujuConnSuite.SetUpTest(c)
 	loggo.GetLogger("juju.apiserver").SetLogLevel(loggo.TRACE)
+	s.userDirectory = nil
+	s.limits = apiserver.ServerLimits{}
 }
 
 type loginV0Suite struct {
@@ -191,6 +196,100 @@ func (s *loginSuite) TestLoginAsDeactivatedUser(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `.*unknown object type "Client"`)
 }
 
+func (s *loginSuite) TestLoginAsDirectoryUser(c *gc.C) {
+	s.userDirectory = &fakeUserDirectory{
+		users: map[string]fakeDirectoryUser{
+			"bob": {password: "bob-secret", envUUID: s.State.EnvironUUID()},
+		},
+	}
+	info, cleanup := s.setupServerWithValidator(c, nil)
+	defer cleanup()
+	st := s.openAPIWithoutLogin(c, info)
+	defer st.Close()
+
+	// Since these are user login tests, the nonce is empty.
+	err := st.Login("user-bob", "wrong", "")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+	err = st.Login("user-alice", "bob-secret", "")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+
+	err = st.Login("user-bob", "bob-secret", "")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = st.Client().Status([]string{})
+	c.Assert(err, jc.ErrorIsNil)
+
+	// The user was created in state, with access to the environment.
+	user, err := s.State.User(names.NewLocalUserTag("bob"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.Directory(), gc.Equals, "fake")
+	_, err = s.State.EnvironmentUser(user.UserTag())
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *loginSuite) TestLoginAsDirectoryUserWithoutEnvironmentAccess(c *gc.C) {
+	s.userDirectory = &fakeUserDirectory{
+		users: map[string]fakeDirectoryUser{
+			"bob": {password: "bob-secret", envUUID: "some-other-uuid"},
+		},
+	}
+	info, cleanup := s.setupServerWithValidator(c, nil)
+	defer cleanup()
+	st := s.openAPIWithoutLogin(c, info)
+	defer st.Close()
+
+	err := st.Login("user-bob", "bob-secret", "")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+}
+
+func (s *loginSuite) TestLoginAsLocalUserWithDirectory(c *gc.C) {
+	s.userDirectory = &fakeUserDirectory{}
+	info, cleanup := s.setupServerWithValidator(c, nil)
+	defer cleanup()
+	st := s.openAPIWithoutLogin(c, info)
+	defer st.Close()
+
+	u := s.Factory.MakeUser(c, &factory.UserParams{Password: "password"})
+	err := st.Login(u.Tag().String(), "password", "")
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+type fakeDirectoryUser struct {
+	password string
+	envUUID  string
+}
+
+// fakeUserDirectory is an authentication.Directory holding its users
+// in memory.
+type fakeUserDirectory struct {
+	users map[string]fakeDirectoryUser
+}
+
+func (d *fakeUserDirectory) Name() string {
+	return "fake"
+}
+
+func (d *fakeUserDirectory) Authenticate(name, password string) (*authentication.DirectoryUser, error) {
+	user, err := d.User(name)
+	if err != nil {
+		return nil, err
+	}
+	if d.users[name].password != password {
+		return nil, errors.Unauthorizedf("invalid password for user %q", name)
+	}
+	return user, nil
+}
+
+func (d *fakeUserDirectory) User(name string) (*authentication.DirectoryUser, error) {
+	user, ok := d.users[name]
+	if !ok {
+		return nil, errors.NotFoundf("user %q", name)
+	}
+	return &authentication.DirectoryUser{
+		Name:         name,
+		Environments: []string{user.envUUID},
+	}, nil
+}
+
 func (s *loginV0Suite) TestLoginSetsLogIdentifier(c *gc.C) {
 	s.runLoginSetsLogIdentifier(c)
 }
@@ -277,6 +376,10 @@ func (s *baseLoginSuite) loginHostPorts(c *gc.C, info *api.Info) (connectedAddr
 }
 
 func startNLogins(c *gc.C, n int, info *api.Info) (chan error, *sync.WaitGroup) {
+	return startNLoginsWithOpts(c, n, info, fastDialOpts)
+}
+
+func startNLoginsWithOpts(c *gc.C, n int, info *api.Info, opts api.DialOpts) (chan error, *sync.WaitGroup) {
 	errResults := make(chan error, 100)
 	var doneWG sync.WaitGroup
 	var startedWG sync.WaitGroup
@@ -289,7 +392,7 @@ func startNLogins(c *gc.C, n int, info *api.Info) (chan error, *sync.WaitGroup)
 		go func() {
 			c.Logf("started login %d", i)
 			startedWG.Done()
-			st, err := api.Open(info, fastDialOpts)
+			st, err := api.Open(info, opts)
 			errResults <- err
 			if err == nil {
 				st.Close()
@@ -372,6 +475,9 @@ func (s *loginSuite) TestLoginRateLimited(c *gc.C) {
 	select {
 	case err := <-errResults:
 		c.Check(err, jc.Satisfies, params.IsCodeTryAgain)
+		delay, ok := params.RetryAfter(err)
+		c.Check(ok, jc.IsTrue)
+		c.Check(delay, gc.Equals, apiserver.DefaultLoginRetryDelay)
 	case <-time.After(coretesting.LongWait):
 		c.Fatalf("timed out waiting for login to get rejected.")
 	}
@@ -413,6 +519,130 @@ func (s *loginSuite) TestLoginRateLimited(c *gc.C) {
 	}
 }
 
+func (s *loginSuite) TestLoginRateLimitedWithConfiguredLimits(c *gc.C) {
+	s.limits = apiserver.ServerLimits{
+		MaxConcurrentLogins: 2,
+		LoginRetryDelay:     time.Second,
+	}
+	info, cleanup := s.setupMachineAndServer(c)
+	defer cleanup()
+	delayChan, cleanup := apiserver.DelayLogins()
+	defer cleanup()
+
+	errResults, wg := startNLogins(c, 3, info)
+	select {
+	case err := <-errResults:
+		c.Check(err, jc.Satisfies, params.IsCodeTryAgain)
+		delay, ok := params.RetryAfter(err)
+		c.Check(ok, jc.IsTrue)
+		c.Check(delay, gc.Equals, time.Second)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for login to get rejected.")
+	}
+	for i := 0; i < 2; i++ {
+		delayChan <- struct{}{}
+	}
+	wg.Wait()
+	close(errResults)
+	for err := range errResults {
+		c.Check(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *loginSuite) TestLoginRetriedWhileRateLimited(c *gc.C) {
+	s.limits = apiserver.ServerLimits{
+		MaxConcurrentLogins: 1,
+		LoginRetryDelay:     10 * time.Millisecond,
+	}
+	info, cleanup := s.setupMachineAndServer(c)
+	defer cleanup()
+	delayChan, cleanup := apiserver.DelayLogins()
+	defer cleanup()
+
+	// The login that is refused is retried until the first one
+	// completes, rather than failing.
+	opts := api.DialOpts{LoginRetryTimeout: coretesting.LongWait}
+	errResults, wg := startNLoginsWithOpts(c, 2, info, opts)
+	select {
+	case err := <-errResults:
+		c.Fatalf("no login should have completed yet: %v", err)
+	case <-time.After(coretesting.ShortWait):
+	}
+	for i := 0; i < 2; i++ {
+		delayChan <- struct{}{}
+	}
+	wg.Wait()
+	close(errResults)
+	for err := range errResults {
+		c.Check(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *loginSuite) TestAgentRequestsRateLimited(c *gc.C) {
+	s.limits = apiserver.ServerLimits{
+		AgentRequests: apiserver.RequestLimit{Rate: 0.001, Burst: 2},
+	}
+	info, cleanup := s.setupMachineAndServer(c)
+	defer cleanup()
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err = st.Agent().Entity(info.Tag)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	_, err = st.Agent().Entity(info.Tag)
+	c.Assert(err, jc.Satisfies, params.IsCodeTryAgain)
+	delay, ok := params.RetryAfter(err)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(delay, gc.Equals, 1000*time.Second)
+
+	// Pings are never refused.
+	c.Assert(st.Ping(), jc.ErrorIsNil)
+
+	// The limit applies to all of the agent's connections.
+	st2, err := api.Open(info, fastDialOpts)
+	c.Assert(err, jc.ErrorIsNil)
+	defer st2.Close()
+	_, err = st2.Agent().Entity(info.Tag)
+	c.Assert(err, jc.Satisfies, params.IsCodeTryAgain)
+
+	// Users are limited separately.
+	userInfo := *info
+	userInfo.Tag = s.AdminUserTag(c)
+	userInfo.Password = "dummy-secret"
+	userInfo.Nonce = ""
+	userSt, err := api.Open(&userInfo, fastDialOpts)
+	c.Assert(err, jc.ErrorIsNil)
+	defer userSt.Close()
+	for i := 0; i < 3; i++ {
+		_, err = userSt.Client().EnvironmentGet()
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *loginSuite) TestUserRequestsRateLimited(c *gc.C) {
+	s.limits = apiserver.ServerLimits{
+		UserRequests: apiserver.RequestLimit{Rate: 0.5},
+	}
+	info, cleanup := s.setupServerWithValidator(c, nil)
+	defer cleanup()
+	info.Tag = s.AdminUserTag(c)
+	info.Password = "dummy-secret"
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+
+	_, err = st.Client().EnvironmentGet()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = st.Client().EnvironmentGet()
+	c.Assert(err, jc.Satisfies, params.IsCodeTryAgain)
+	delay, ok := params.RetryAfter(err)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(delay, gc.Equals, 2*time.Second)
+}
+
 func (s *loginSuite) TestUsersLoginWhileRateLimited(c *gc.C) {
 	info, cleanup := s.setupMachineAndServer(c)
 	defer cleanup()
@@ -657,10 +887,12 @@ func (s *baseLoginSuite) setupServerForEnvironmentWithValidator(c *gc.C, envTag
 		s.State,
 		listener,
 		apiserver.ServerConfig{
-			Cert:      []byte(coretesting.ServerCert),
-			Key:       []byte(coretesting.ServerKey),
-			Validator: validator,
-			Tag:       names.NewMachineTag("0"),
+			Cert:          []byte(coretesting.ServerCert),
+			Key:           []byte(coretesting.ServerKey),
+			Validator:     validator,
+			Tag:           names.NewMachineTag("0"),
+			UserDirectory: s.userDirectory,
+			Limits:        s.limits,
 		},
 	)
 	c.Assert(err, jc.ErrorIsNil)
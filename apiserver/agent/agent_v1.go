@@ -7,12 +7,16 @@ import (
 	"github.com/juju/errors"
 
 	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/watcher"
 )
 
 // AgentAPIV1 implements the version 1 of the API provided to an agent.
 type AgentAPIV1 struct {
 	*AgentAPIV0
+
+	resources *common.Resources
 }
 
 // NewAgentAPIV1 returns an object implementing version 1 of the Agent API
@@ -24,5 +28,32 @@ func NewAgentAPIV1(st *state.State, resources *common.Resources, auth common.Aut
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return &AgentAPIV1{apiV0}, nil
+	return &AgentAPIV1{apiV0, resources}, nil
+}
+
+// TrustedCACert returns the CA certificates that agents should trust
+// when connecting to the state servers. While the CA is being rotated,
+// the result holds both the old and the new CA certificates, and the
+// agent is recorded as having fetched them.
+func (api *AgentAPIV1) TrustedCACert() (params.BytesResult, error) {
+	caCert, err := api.st.TrustedCACert()
+	if err != nil {
+		return params.BytesResult{}, err
+	}
+	if err := api.st.AcknowledgeTrustedCACert(api.auth.GetAuthTag(), caCert); err != nil {
+		return params.BytesResult{}, err
+	}
+	return params.BytesResult{Result: []byte(caCert)}, nil
+}
+
+// WatchTrustedCACert returns a NotifyWatcher that notifies when the
+// CA certificates returned by TrustedCACert may have changed.
+func (api *AgentAPIV1) WatchTrustedCACert() (params.NotifyWatchResult, error) {
+	watch := api.st.WatchTrustedCACert()
+	if _, ok := <-watch.Changes(); ok {
+		return params.NotifyWatchResult{
+			NotifyWatcherId: api.resources.Register(watch),
+		}, nil
+	}
+	return params.NotifyWatchResult{}, watcher.EnsureErr(watch)
 }
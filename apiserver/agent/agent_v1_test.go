@@ -1,6 +1,9 @@
 package agent_test
 
 import (
+	"time"
+
+	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
@@ -8,10 +11,13 @@ import (
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
 	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/cert"
 	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+	coretesting "github.com/juju/juju/testing"
 )
 
-// V1 test suite, no additional or changed tests.
+// V1 test suite, adding the trusted CA certificate methods.
 
 func factoryWrapperV1(st *state.State, resources *common.Resources, auth common.Authorizer) (interface{}, error) {
 	return agent.NewAgentAPIV1(st, resources, auth)
@@ -85,6 +91,49 @@ func (s *agentSuiteV1) TestSetPasswordsShort(c *gc.C) {
 	s.testSetPasswordsShortV0(c, s.newAPI(c))
 }
 
+func (s *agentSuiteV1) TestTrustedCACert(c *gc.C) {
+	result, err := s.newAPI(c).TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(result.Result), gc.Equals, coretesting.CACert)
+
+	newCACert, newCAKey, err := cert.NewCA("testenv", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, jc.ErrorIsNil)
+	result, err = s.newAPI(c).TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(result.Result), gc.Equals, coretesting.CACert+newCACert)
+
+	// Machine 1 no longer holds up the rotation.
+	_, err = s.State.AdvanceCARotation(false)
+	c.Assert(err, jc.Satisfies, state.IsCARotationPendingError)
+	uuid := s.State.EnvironUUID()
+	c.Assert(errors.Cause(err).(*state.CARotationPendingError).Agents, jc.SameContents, []string{
+		uuid + ":" + s.machine0.Tag().String(),
+		uuid + ":" + s.container.Tag().String(),
+	})
+}
+
+func (s *agentSuiteV1) TestWatchTrustedCACert(c *gc.C) {
+	c.Assert(s.resources.Count(), gc.Equals, 0)
+	result, err := s.newAPI(c).WatchTrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, gc.DeepEquals, params.NotifyWatchResult{NotifyWatcherId: "1"})
+	c.Assert(s.resources.Count(), gc.Equals, 1)
+	resource := s.resources.Get("1")
+	defer statetesting.AssertStop(c, resource)
+
+	// Check that the Watch has consumed the initial event.
+	wc := statetesting.NewNotifyWatcherC(c, s.State, resource.(state.NotifyWatcher))
+	wc.AssertNoChange()
+
+	newCACert, newCAKey, err := cert.NewCA("testenv", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+}
+
 func (s *agentSuiteV1) newAPI(c *gc.C) *agent.AgentAPIV1 {
 	api, err := agent.NewAgentAPIV1(s.State, s.resources, s.authorizer)
 	c.Assert(err, jc.ErrorIsNil)
@@ -11,16 +11,21 @@ import (
 	_ "github.com/juju/juju/apiserver/agent"
 	_ "github.com/juju/juju/apiserver/annotations"
 	_ "github.com/juju/juju/apiserver/backups"
+	_ "github.com/juju/juju/apiserver/blobgc"
 	_ "github.com/juju/juju/apiserver/block"
+	_ "github.com/juju/juju/apiserver/certificatemanager"
 	_ "github.com/juju/juju/apiserver/charmrevisionupdater"
 	_ "github.com/juju/juju/apiserver/charms"
 	_ "github.com/juju/juju/apiserver/cleaner"
 	_ "github.com/juju/juju/apiserver/client"
+	_ "github.com/juju/juju/apiserver/confighistory"
+	_ "github.com/juju/juju/apiserver/controllerhealth"
 	_ "github.com/juju/juju/apiserver/deployer"
 	_ "github.com/juju/juju/apiserver/diskmanager"
 	_ "github.com/juju/juju/apiserver/environment"
 	_ "github.com/juju/juju/apiserver/environmentmanager"
 	_ "github.com/juju/juju/apiserver/firewaller"
+	_ "github.com/juju/juju/apiserver/hooktranscripts"
 	_ "github.com/juju/juju/apiserver/imagemanager"
 	_ "github.com/juju/juju/apiserver/instancepoller"
 	_ "github.com/juju/juju/apiserver/keymanager"
@@ -30,13 +35,17 @@ import (
 	_ "github.com/juju/juju/apiserver/machinemanager"
 	_ "github.com/juju/juju/apiserver/metricsmanager"
 	_ "github.com/juju/juju/apiserver/networker"
+	_ "github.com/juju/juju/apiserver/payloads"
 	_ "github.com/juju/juju/apiserver/provisioner"
+	_ "github.com/juju/juju/apiserver/quota"
 	_ "github.com/juju/juju/apiserver/reboot"
 	_ "github.com/juju/juju/apiserver/resumer"
 	_ "github.com/juju/juju/apiserver/rsyslog"
 	_ "github.com/juju/juju/apiserver/service"
+	_ "github.com/juju/juju/apiserver/spaces"
 	_ "github.com/juju/juju/apiserver/storage"
 	_ "github.com/juju/juju/apiserver/storageprovisioner"
+	_ "github.com/juju/juju/apiserver/subnets"
 	_ "github.com/juju/juju/apiserver/uniter"
 	_ "github.com/juju/juju/apiserver/upgrader"
 	_ "github.com/juju/juju/apiserver/usermanager"
@@ -22,6 +22,7 @@ import (
 	"golang.org/x/net/websocket"
 	"launchpad.net/tomb"
 
+	"github.com/juju/juju/apiserver/authentication"
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/feature"
@@ -32,10 +33,15 @@ import (
 
 var logger = loggo.GetLogger("juju.apiserver")
 
-// loginRateLimit defines how many concurrent Login requests we will
-// accept
+// loginRateLimit defines how many concurrent agent Login requests we
+// will accept, unless the server is configured otherwise.
 const loginRateLimit = 10
 
+// defaultLoginRetryDelay is how long agents whose logins are refused
+// because too many are in progress are asked to wait before retrying,
+// unless the server is configured otherwise.
+const defaultLoginRetryDelay = 5 * time.Second
+
 // Server holds the server side of the API.
 type Server struct {
 	tomb              tomb.Tomb
@@ -46,11 +52,15 @@ type Server struct {
 	dataDir           string
 	logDir            string
 	limiter           utils.Limiter
+	loginRetryDelay   time.Duration
+	requestLimiters   *requestLimiters
 	validator         LoginValidator
+	userDirectory     authentication.Directory
 	adminApiFactories map[int]adminApiFactory
 
 	mu          sync.Mutex // protects the fields that follow
 	environUUID string
+	connections int
 }
 
 // LoginValidator functions are used to decide whether login requests
@@ -67,6 +77,37 @@ type ServerConfig struct {
 	LogDir      string
 	Validator   LoginValidator
 	CertChanged chan params.StateServingInfo
+
+	// UserDirectory, if not nil, is used to authenticate users
+	// that are not held in state, and those created from it.
+	UserDirectory authentication.Directory
+
+	// Limits holds the limits on the load that clients may place
+	// on the server.
+	Limits ServerLimits
+}
+
+// ServerLimits holds the limits on the load that clients may place on
+// an API server.
+type ServerLimits struct {
+	// MaxConcurrentLogins holds the number of agent logins that
+	// may be in progress at once. Further logins are refused and
+	// the agents asked to retry after LoginRetryDelay. Logins by
+	// users are not limited. If it is zero, a default is used.
+	MaxConcurrentLogins int
+
+	// LoginRetryDelay holds how long agents whose logins are
+	// refused are asked to wait before retrying. If it is zero, a
+	// default is used.
+	LoginRetryDelay time.Duration
+
+	// UserRequests limits the rate at which each user may make
+	// requests.
+	UserRequests RequestLimit
+
+	// AgentRequests limits the rate at which each agent may make
+	// requests.
+	AgentRequests RequestLimit
 }
 
 // changeCertListener wraps a TLS net.Listener.
@@ -166,14 +207,25 @@ func NewServer(s *state.State, lis net.Listener, cfg ServerConfig) (*Server, err
 
 func newServer(s *state.State, lis *net.TCPListener, cfg ServerConfig) (*Server, error) {
 	logger.Infof("listening on %q", lis.Addr())
+	maxConcurrentLogins := cfg.Limits.MaxConcurrentLogins
+	if maxConcurrentLogins <= 0 {
+		maxConcurrentLogins = loginRateLimit
+	}
+	loginRetryDelay := cfg.Limits.LoginRetryDelay
+	if loginRetryDelay <= 0 {
+		loginRetryDelay = defaultLoginRetryDelay
+	}
 	srv := &Server{
-		state:     s,
-		addr:      lis.Addr().(*net.TCPAddr), // cannot fail
-		tag:       cfg.Tag,
-		dataDir:   cfg.DataDir,
-		logDir:    cfg.LogDir,
-		limiter:   utils.NewLimiter(loginRateLimit),
-		validator: cfg.Validator,
+		state:           s,
+		addr:            lis.Addr().(*net.TCPAddr), // cannot fail
+		tag:             cfg.Tag,
+		dataDir:         cfg.DataDir,
+		logDir:          cfg.LogDir,
+		limiter:         utils.NewLimiter(maxConcurrentLogins),
+		loginRetryDelay: loginRetryDelay,
+		requestLimiters: newRequestLimiters(cfg.Limits.UserRequests, cfg.Limits.AgentRequests),
+		validator:       cfg.Validator,
+		userDirectory:   cfg.UserDirectory,
 		adminApiFactories: map[int]adminApiFactory{
 			0: newAdminApiV0,
 			1: newAdminApiV1,
@@ -307,6 +359,25 @@ func (srv *Server) run(lis net.Listener) {
 		srv.tomb.Kill(err)
 		srv.wg.Done()
 	}()
+	if srv.userDirectory != nil {
+		srv.wg.Add(1)
+		go func() {
+			srv.syncUserDirectory()
+			srv.wg.Done()
+		}()
+	}
+	if machineTag, ok := srv.tag.(names.MachineTag); ok {
+		// Jobs started by this server before it last stopped
+		// will never finish.
+		if err := srv.state.FailInterruptedRunJobs(machineTag.Id()); err != nil {
+			logger.Warningf("cannot fail interrupted run jobs: %v", err)
+		}
+		srv.wg.Add(1)
+		go func() {
+			srv.reportAPIServer(machineTag.Id())
+			srv.wg.Done()
+		}()
+	}
 	// for pat based handlers, they are matched in-order of being
 	// registered, first match wins. So more specific ones have to be
 	// registered first.
@@ -314,19 +385,19 @@ func (srv *Server) run(lis net.Listener) {
 	// For backwards compatibility we register all the old paths
 	handleAll(mux, "/environment/:envuuid/log",
 		&debugLogHandler{
-			httpHandler: httpHandler{ssState: srv.state},
+			httpHandler: httpHandler{ssState: srv.state, userDirectory: srv.userDirectory},
 			logDir:      srv.logDir},
 	)
 	if featureflag.Enabled(feature.DbLog) {
 		handleAll(mux, "/environment/:envuuid/logsink",
 			&logSinkHandler{
-				httpHandler: httpHandler{ssState: srv.state},
+				httpHandler: httpHandler{ssState: srv.state, userDirectory: srv.userDirectory},
 			},
 		)
 	}
 	handleAll(mux, "/environment/:envuuid/charms",
 		&charmsHandler{
-			httpHandler: httpHandler{ssState: srv.state},
+			httpHandler: httpHandler{ssState: srv.state, userDirectory: srv.userDirectory},
 			dataDir:     srv.dataDir},
 	)
 	// TODO: We can switch from handleAll to mux.Post/Get/etc for entries
@@ -335,46 +406,73 @@ func (srv *Server) run(lis net.Listener) {
 	// pat only does "text/plain" responses.
 	handleAll(mux, "/environment/:envuuid/tools",
 		&toolsUploadHandler{toolsHandler{
-			httpHandler{ssState: srv.state},
+			httpHandler{ssState: srv.state, userDirectory: srv.userDirectory},
 		}},
 	)
 	handleAll(mux, "/environment/:envuuid/tools/:version",
 		&toolsDownloadHandler{toolsHandler{
-			httpHandler{ssState: srv.state},
+			httpHandler{ssState: srv.state, userDirectory: srv.userDirectory},
 		}},
 	)
 	handleAll(mux, "/environment/:envuuid/backups",
 		&backupHandler{httpHandler{
 			ssState:            srv.state,
+			userDirectory:      srv.userDirectory,
 			strictValidation:   true,
 			stateServerEnvOnly: true,
 		}},
 	)
+	handleAll(mux, "/environment/:envuuid/resources",
+		&resourcesHandler{
+			httpHandler: httpHandler{ssState: srv.state, userDirectory: srv.userDirectory},
+			dataDir:     srv.dataDir},
+	)
+	handleAll(mux, "/environment/:envuuid/charmrepository",
+		&charmRepositoryHandler{httpHandler{
+			ssState:            srv.state,
+			userDirectory:      srv.userDirectory,
+			stateServerEnvOnly: true,
+		}},
+	)
+	handleAll(mux, "/environment/:envuuid/migration",
+		&migrationHandler{httpHandler{
+			ssState:          srv.state,
+			userDirectory:    srv.userDirectory,
+			strictValidation: true,
+		}},
+	)
 	handleAll(mux, "/environment/:envuuid/api", http.HandlerFunc(srv.apiHandler))
 	handleAll(mux, "/environment/:envuuid/images/:kind/:series/:arch/:filename",
 		&imagesDownloadHandler{
-			httpHandler: httpHandler{ssState: srv.state},
+			httpHandler: httpHandler{ssState: srv.state, userDirectory: srv.userDirectory},
 			dataDir:     srv.dataDir},
 	)
 	// For backwards compatibility we register all the old paths
 	handleAll(mux, "/log",
 		&debugLogHandler{
-			httpHandler: httpHandler{ssState: srv.state},
+			httpHandler: httpHandler{ssState: srv.state, userDirectory: srv.userDirectory},
 			logDir:      srv.logDir},
 	)
 	handleAll(mux, "/charms",
 		&charmsHandler{
-			httpHandler: httpHandler{ssState: srv.state},
+			httpHandler: httpHandler{ssState: srv.state, userDirectory: srv.userDirectory},
 			dataDir:     srv.dataDir},
 	)
+	handleAll(mux, "/charmrepository",
+		&charmRepositoryHandler{httpHandler{
+			ssState:            srv.state,
+			userDirectory:      srv.userDirectory,
+			stateServerEnvOnly: true,
+		}},
+	)
 	handleAll(mux, "/tools",
 		&toolsUploadHandler{toolsHandler{
-			httpHandler{ssState: srv.state},
+			httpHandler{ssState: srv.state, userDirectory: srv.userDirectory},
 		}},
 	)
 	handleAll(mux, "/tools/:version",
 		&toolsDownloadHandler{toolsHandler{
-			httpHandler{ssState: srv.state},
+			httpHandler{ssState: srv.state, userDirectory: srv.userDirectory},
 		}},
 	)
 	handleAll(mux, "/", http.HandlerFunc(srv.apiHandler))
@@ -403,6 +501,8 @@ func (srv *Server) apiHandler(w http.ResponseWriter, req *http.Request) {
 			if srv.tomb.Err() != tomb.ErrStillAlive {
 				return
 			}
+			srv.addConnections(1)
+			defer srv.addConnections(-1)
 			envUUID := req.URL.Query().Get(":envuuid")
 			logger.Tracef("got a request for env %q", envUUID)
 			if err := srv.serveConn(conn, reqNotifier, envUUID); err != nil {
@@ -470,6 +570,57 @@ func (srv *Server) mongoPinger() error {
 	}
 }
 
+// syncUserDirectory periodically disables the users created from the
+// user directory that the directory no longer knows. Failures are only
+// logged, as the directory may be temporarily unavailable.
+func (srv *Server) syncUserDirectory() {
+	timer := time.NewTimer(0)
+	for {
+		select {
+		case <-timer.C:
+		case <-srv.tomb.Dying():
+			return
+		}
+		if err := authentication.DisableRemovedDirectoryUsers(srv.state, srv.userDirectory); err != nil {
+			logger.Warningf("cannot check users against directory %q: %v", srv.userDirectory.Name(), err)
+		}
+		timer.Reset(userDirectorySyncInterval)
+	}
+}
+
+// reportAPIServer periodically records the number of open API
+// connections, along with a sample of the local clock, so that they
+// can be included in controller health reports. Failures are only
+// logged.
+func (srv *Server) reportAPIServer(machineId string) {
+	timer := time.NewTimer(0)
+	for {
+		select {
+		case <-timer.C:
+		case <-srv.tomb.Dying():
+			return
+		}
+		if err := srv.state.ReportAPIServer(machineId, srv.connectionCount()); err != nil {
+			logger.Warningf("cannot report API server health: %v", err)
+		}
+		timer.Reset(apiServerReportInterval)
+	}
+}
+
+// addConnections adjusts the count of open API connections.
+func (srv *Server) addConnections(delta int) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.connections += delta
+}
+
+// connectionCount returns the number of open API connections.
+func (srv *Server) connectionCount() int {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.connections
+}
+
 func serverError(err error) error {
 	if err := common.ServerError(err); err != nil {
 		return err
@@ -0,0 +1,178 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package authentication
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/state"
+)
+
+var logger = loggo.GetLogger("juju.apiserver.authentication")
+
+// Directory is implemented by external stores of user identities, such
+// as LDAP servers, against which users can be authenticated instead of
+// with passwords held in state.
+type Directory interface {
+	// Name returns the name of the directory, which is recorded
+	// against the users created in state on their first login.
+	Name() string
+
+	// Authenticate checks the given user's password and returns the
+	// user's details. It returns an error satisfying
+	// errors.IsNotFound if the directory has no such user, and one
+	// satisfying errors.IsUnauthorized if the password is wrong.
+	Authenticate(name, password string) (*DirectoryUser, error)
+
+	// User returns the details of the given user, or an error
+	// satisfying errors.IsNotFound if the directory has no such user.
+	User(name string) (*DirectoryUser, error)
+}
+
+// DirectoryUser holds the details of a user held in a Directory.
+type DirectoryUser struct {
+	Name        string
+	DisplayName string
+
+	// Groups holds the names of the groups the user belongs to.
+	Groups []string
+
+	// Environments holds the UUIDs of the environments the user's
+	// groups grant access to. The special value "*" grants access
+	// to all environments.
+	Environments []string
+}
+
+// CanAccess returns whether the user's groups grant access to the
+// environment with the given UUID.
+func (u *DirectoryUser) CanAccess(envUUID string) bool {
+	for _, uuid := range u.Environments {
+		if uuid == envUUID || uuid == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthenticateDirectoryUser authenticates the given user against the
+// directory, and returns the user from state. Users are created in state
+// on their first login, disabled if the directory no longer knows them,
+// and enabled again if they later authenticate with the directory.
+// Users disabled by an administrator stay disabled.
+//
+// If checkEnvAccess is true, the user must also belong to a group that
+// grants access to the environment of st, and is added to the
+// environment if not already there.
+func AuthenticateDirectoryUser(st *state.State, dir Directory, tag names.UserTag, password string, checkEnvAccess bool) (*state.User, error) {
+	user, err := st.User(tag)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, errors.Trace(err)
+	}
+	if user != nil && user.Directory() != dir.Name() {
+		// The user is not from this directory.
+		return nil, common.ErrBadCreds
+	}
+	dirUser, err := dir.Authenticate(tag.Name(), password)
+	if errors.IsNotFound(err) {
+		if user != nil {
+			disableRemovedUser(user)
+		}
+		return nil, common.ErrBadCreds
+	} else if errors.IsUnauthorized(err) {
+		return nil, common.ErrBadCreds
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot authenticate %q with directory %q", tag.Name(), dir.Name())
+	}
+	if user == nil {
+		if user, err = addDirectoryUser(st, dir, dirUser); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if user.IsDisabled() {
+		if !user.IsRemovedFromDirectory() {
+			return nil, common.ErrBadCreds
+		}
+		if err := user.EnableReturnedToDirectory(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		logger.Infof("enabled user %q returned to directory %q", user.Name(), dir.Name())
+	}
+	if !checkEnvAccess {
+		return user, nil
+	}
+	if !dirUser.CanAccess(st.EnvironUUID()) {
+		logger.Debugf("groups of user %q grant no access to environment %s", tag.Name(), st.EnvironUUID())
+		return nil, common.ErrBadCreds
+	}
+	if _, err := st.EnvironmentUser(user.UserTag()); errors.IsNotFound(err) {
+		env, err := st.Environment()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		_, err = st.AddEnvironmentUser(user.UserTag(), env.Owner(), dirUser.DisplayName)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return nil, errors.Annotatef(err, "cannot add user %q to environment", tag.Name())
+		}
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return user, nil
+}
+
+// addDirectoryUser adds the given directory user to state. The user is
+// recorded as having been created by the owner of the state server
+// environment.
+func addDirectoryUser(st *state.State, dir Directory, dirUser *DirectoryUser) (*state.User, error) {
+	env, err := st.StateServerEnvironment()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	user, err := st.AddDirectoryUser(dirUser.Name, dirUser.DisplayName, dir.Name(), env.Owner().Name())
+	if errors.IsAlreadyExists(err) {
+		// The user logged in concurrently.
+		return st.User(names.NewLocalUserTag(dirUser.Name))
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot add user %q", dirUser.Name)
+	}
+	logger.Infof("added user %q from directory %q", dirUser.Name, dir.Name())
+	return user, nil
+}
+
+// DisableRemovedDirectoryUsers disables the users created from the
+// directory that the directory no longer knows.
+func DisableRemovedDirectoryUsers(st *state.State, dir Directory) error {
+	users, err := st.AllUsers(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, user := range users {
+		if user.Directory() != dir.Name() {
+			continue
+		}
+		_, err := dir.User(user.Name())
+		if errors.IsNotFound(err) {
+			disableRemovedUser(user)
+		} else if err != nil {
+			return errors.Annotatef(err, "cannot look up %q in directory %q", user.Name(), dir.Name())
+		}
+	}
+	return nil
+}
+
+// disableRemovedUser disables a user that has been removed from its
+// directory. Failures are logged rather than returned, as the user is
+// refused access regardless.
+func disableRemovedUser(user *state.User) {
+	if user.IsDisabled() {
+		return
+	}
+	if err := user.DisableRemovedFromDirectory(); err != nil {
+		logger.Errorf("cannot disable user %q removed from directory %q: %v", user.Name(), user.Directory(), err)
+		return
+	}
+	logger.Infof("disabled user %q removed from directory %q", user.Name(), user.Directory())
+}
@@ -0,0 +1,248 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package authentication_test
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/authentication"
+	"github.com/juju/juju/apiserver/common"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+type directorySuite struct {
+	jujutesting.JujuConnSuite
+	dir *fakeDirectory
+}
+
+var _ = gc.Suite(&directorySuite{})
+
+func (s *directorySuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.dir = &fakeDirectory{
+		passwords: map[string]string{"bob": "bob-secret"},
+		users: map[string]*authentication.DirectoryUser{
+			"bob": {
+				Name:         "bob",
+				DisplayName:  "Bob Brown",
+				Groups:       []string{"ops"},
+				Environments: []string{s.State.EnvironUUID()},
+			},
+		},
+	}
+}
+
+func (s *directorySuite) TestAuthenticateCreatesUser(c *gc.C) {
+	tag := names.NewLocalUserTag("bob")
+	user, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.Name(), gc.Equals, "bob")
+	c.Assert(user.DisplayName(), gc.Equals, "Bob Brown")
+	c.Assert(user.Directory(), gc.Equals, "fake")
+	c.Assert(user.CreatedBy(), gc.Equals, s.AdminUserTag(c).Name())
+
+	// The user is only created once.
+	again, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(again.Tag(), gc.Equals, user.Tag())
+
+	// Passwords held in state are never valid for directory users.
+	c.Assert(user.PasswordValid("bob-secret"), jc.IsFalse)
+}
+
+func (s *directorySuite) TestAuthenticateWrongPassword(c *gc.C) {
+	tag := names.NewLocalUserTag("bob")
+	_, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "wrong", false)
+	c.Assert(err, gc.Equals, common.ErrBadCreds)
+	_, err = s.State.User(tag)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *directorySuite) TestAuthenticateUnknownUser(c *gc.C) {
+	tag := names.NewLocalUserTag("alice")
+	_, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "secret", false)
+	c.Assert(err, gc.Equals, common.ErrBadCreds)
+}
+
+func (s *directorySuite) TestAuthenticateDirectoryError(c *gc.C) {
+	s.dir.err = errors.New("directory unavailable")
+	tag := names.NewLocalUserTag("bob")
+	_, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, gc.ErrorMatches, `cannot authenticate "bob" with directory "fake": directory unavailable`)
+}
+
+func (s *directorySuite) TestAuthenticateLocalUser(c *gc.C) {
+	s.Factory.MakeUser(c, &factory.UserParams{Name: "bob", Password: "local-secret"})
+	tag := names.NewLocalUserTag("bob")
+	_, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, gc.Equals, common.ErrBadCreds)
+	c.Assert(s.dir.authenticated, gc.HasLen, 0)
+}
+
+func (s *directorySuite) TestAuthenticateGrantsEnvironmentAccess(c *gc.C) {
+	tag := names.NewLocalUserTag("bob")
+	_, err := s.State.EnvironmentUser(tag)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	_, err = authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", true)
+	c.Assert(err, jc.ErrorIsNil)
+	envUser, err := s.State.EnvironmentUser(tag)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(envUser.DisplayName(), gc.Equals, "Bob Brown")
+}
+
+func (s *directorySuite) TestAuthenticateAllEnvironments(c *gc.C) {
+	s.dir.users["bob"].Environments = []string{"*"}
+	tag := names.NewLocalUserTag("bob")
+	_, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", true)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.EnvironmentUser(tag)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *directorySuite) TestAuthenticateDeniesEnvironmentAccess(c *gc.C) {
+	s.dir.users["bob"].Environments = []string{"some-other-uuid"}
+	tag := names.NewLocalUserTag("bob")
+	_, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", true)
+	c.Assert(err, gc.Equals, common.ErrBadCreds)
+	_, err = s.State.EnvironmentUser(tag)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *directorySuite) TestAuthenticateRemovedUserDisablesUser(c *gc.C) {
+	tag := names.NewLocalUserTag("bob")
+	_, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, jc.ErrorIsNil)
+
+	delete(s.dir.users, "bob")
+	_, err = authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, gc.Equals, common.ErrBadCreds)
+	user, err := s.State.User(tag)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.IsDisabled(), jc.IsTrue)
+	c.Assert(user.IsRemovedFromDirectory(), jc.IsTrue)
+}
+
+func (s *directorySuite) TestAuthenticateReturnedUserEnablesUser(c *gc.C) {
+	tag := names.NewLocalUserTag("bob")
+	_, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, jc.ErrorIsNil)
+	bob := s.dir.users["bob"]
+	delete(s.dir.users, "bob")
+	err = authentication.DisableRemovedDirectoryUsers(s.State, s.dir)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Once back in the directory, the user is enabled again on
+	// authenticating.
+	s.dir.users["bob"] = bob
+	user, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.IsDisabled(), jc.IsFalse)
+	err = user.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.IsDisabled(), jc.IsFalse)
+	c.Assert(user.IsRemovedFromDirectory(), jc.IsFalse)
+}
+
+func (s *directorySuite) TestAuthenticateDisabledUser(c *gc.C) {
+	tag := names.NewLocalUserTag("bob")
+	user, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, jc.ErrorIsNil)
+	err = user.Disable()
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, gc.Equals, common.ErrBadCreds)
+}
+
+func (s *directorySuite) TestAuthenticateRemovedUserDisabledByAdmin(c *gc.C) {
+	tag := names.NewLocalUserTag("bob")
+	_, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, jc.ErrorIsNil)
+	bob := s.dir.users["bob"]
+	delete(s.dir.users, "bob")
+	err = authentication.DisableRemovedDirectoryUsers(s.State, s.dir)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// An administrator disabling the user overrides the directory.
+	user, err := s.State.User(tag)
+	c.Assert(err, jc.ErrorIsNil)
+	err = user.Disable()
+	c.Assert(err, jc.ErrorIsNil)
+	s.dir.users["bob"] = bob
+	_, err = authentication.AuthenticateDirectoryUser(s.State, s.dir, tag, "bob-secret", false)
+	c.Assert(err, gc.Equals, common.ErrBadCreds)
+}
+
+func (s *directorySuite) TestDisableRemovedDirectoryUsers(c *gc.C) {
+	s.dir.passwords["carol"] = "carol-secret"
+	s.dir.users["carol"] = &authentication.DirectoryUser{Name: "carol"}
+	for _, name := range []string{"bob", "carol"} {
+		_, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, names.NewLocalUserTag(name), name+"-secret", false)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	local := s.Factory.MakeUser(c, &factory.UserParams{Name: "dave"})
+
+	delete(s.dir.users, "carol")
+	err := authentication.DisableRemovedDirectoryUsers(s.State, s.dir)
+	c.Assert(err, jc.ErrorIsNil)
+
+	for name, disabled := range map[string]bool{"bob": false, "carol": true} {
+		user, err := s.State.User(names.NewLocalUserTag(name))
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(user.IsDisabled(), gc.Equals, disabled, gc.Commentf("user %q", name))
+	}
+	err = local.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(local.IsDisabled(), jc.IsFalse)
+}
+
+func (s *directorySuite) TestDisableRemovedDirectoryUsersError(c *gc.C) {
+	_, err := authentication.AuthenticateDirectoryUser(s.State, s.dir, names.NewLocalUserTag("bob"), "bob-secret", false)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.dir.err = errors.New("directory unavailable")
+	err = authentication.DisableRemovedDirectoryUsers(s.State, s.dir)
+	c.Assert(err, gc.ErrorMatches, `cannot look up "bob" in directory "fake": directory unavailable`)
+}
+
+// fakeDirectory is an authentication.Directory holding its users in
+// memory.
+type fakeDirectory struct {
+	passwords     map[string]string
+	users         map[string]*authentication.DirectoryUser
+	err           error
+	authenticated []string
+}
+
+func (d *fakeDirectory) Name() string {
+	return "fake"
+}
+
+func (d *fakeDirectory) Authenticate(name, password string) (*authentication.DirectoryUser, error) {
+	d.authenticated = append(d.authenticated, name)
+	user, err := d.User(name)
+	if err != nil {
+		return nil, err
+	}
+	if d.passwords[name] != password {
+		return nil, errors.Unauthorizedf("invalid password for user %q", name)
+	}
+	return user, nil
+}
+
+func (d *fakeDirectory) User(name string) (*authentication.DirectoryUser, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	user, ok := d.users[name]
+	if !ok {
+		return nil, errors.NotFoundf("user %q", name)
+	}
+	return user, nil
+}
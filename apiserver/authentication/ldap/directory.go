@@ -0,0 +1,347 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package ldap authenticates Juju users against an LDAP directory.
+package ldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	goldap "gopkg.in/ldap.v2"
+	goyaml "gopkg.in/yaml.v1"
+
+	"github.com/juju/juju/apiserver/authentication"
+)
+
+var logger = loggo.GetLogger("juju.apiserver.authentication.ldap")
+
+// DirectoryName is the name recorded against users created in state
+// from an LDAP directory.
+const DirectoryName = "ldap"
+
+const (
+	defaultUserFilter           = "(uid=%s)"
+	defaultDisplayNameAttribute = "cn"
+	defaultGroupFilter          = "(member=%s)"
+	defaultGroupNameAttribute   = "cn"
+	defaultTimeout              = 30 * time.Second
+)
+
+// Config holds the configuration of an LDAP directory.
+type Config struct {
+	// URL holds the address of the LDAP server, as
+	// ldap://host[:port] or, to use TLS from the start,
+	// ldaps://host[:port].
+	URL string `yaml:"url"`
+
+	// StartTLS specifies that an ldap connection is upgraded to TLS
+	// before any credentials are sent.
+	StartTLS bool `yaml:"start-tls,omitempty"`
+
+	// CACert holds the PEM-encoded certificates with which the
+	// server's certificate is verified. If it is empty, the host's
+	// root certificates are used.
+	CACert string `yaml:"ca-cert,omitempty"`
+
+	// BindDN and BindPassword hold the credentials with which users
+	// and groups are searched for. If BindDN is empty, searches are
+	// made anonymously.
+	BindDN       string `yaml:"bind-dn,omitempty"`
+	BindPassword string `yaml:"bind-password,omitempty"`
+
+	// UserBaseDN holds the entry below which users are searched for.
+	UserBaseDN string `yaml:"user-base-dn"`
+
+	// UserFilter holds the filter that finds a user's entry, with %s
+	// standing for the user name. It defaults to "(uid=%s)".
+	UserFilter string `yaml:"user-filter,omitempty"`
+
+	// DisplayNameAttribute holds the attribute of a user's entry
+	// holding the user's display name. It defaults to "cn".
+	DisplayNameAttribute string `yaml:"display-name-attribute,omitempty"`
+
+	// GroupBaseDN holds the entry below which groups are searched
+	// for. If it is empty, UserBaseDN is used.
+	GroupBaseDN string `yaml:"group-base-dn,omitempty"`
+
+	// GroupFilter holds the filter that finds the groups a user
+	// belongs to, with %s standing for the DN of the user's entry.
+	// It defaults to "(member=%s)".
+	GroupFilter string `yaml:"group-filter,omitempty"`
+
+	// GroupNameAttribute holds the attribute of a group's entry
+	// holding the group's name. It defaults to "cn".
+	GroupNameAttribute string `yaml:"group-name-attribute,omitempty"`
+
+	// Environments maps the names of groups to the UUIDs of the
+	// environments their members can access. The UUID "*" stands
+	// for all environments.
+	Environments map[string][]string `yaml:"environments,omitempty"`
+
+	// Timeout limits how long each request to the server may take.
+	// It defaults to 30 seconds, and is not read from the environment
+	// configuration.
+	Timeout time.Duration `yaml:"-"`
+}
+
+// ParseConfig parses an LDAP configuration from YAML, as held in the
+// ldap-config setting of the state server environment.
+func ParseConfig(data string) (Config, error) {
+	var config Config
+	if err := goyaml.Unmarshal([]byte(data), &config); err != nil {
+		return Config{}, errors.Annotate(err, "cannot parse LDAP configuration")
+	}
+	return config, nil
+}
+
+// Directory authenticates users against an LDAP directory. It
+// implements authentication.Directory.
+type Directory struct {
+	config    Config
+	addr      string
+	useTLS    bool
+	tlsConfig *tls.Config
+}
+
+var _ authentication.Directory = (*Directory)(nil)
+
+// New returns a Directory that uses the LDAP server with the given
+// configuration.
+func New(config Config) (*Directory, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, errors.Annotate(err, "invalid LDAP URL")
+	}
+	d := &Directory{config: config}
+	var port string
+	switch u.Scheme {
+	case "ldap":
+		port = "389"
+	case "ldaps":
+		if config.StartTLS {
+			return nil, errors.New("StartTLS cannot be used with ldaps URLs")
+		}
+		port = "636"
+		d.useTLS = true
+	default:
+		return nil, errors.Errorf("invalid LDAP URL %q: scheme must be ldap or ldaps", config.URL)
+	}
+	if u.Host == "" {
+		return nil, errors.Errorf("invalid LDAP URL %q: missing host", config.URL)
+	}
+	d.addr = u.Host
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		d.addr = net.JoinHostPort(u.Host, port)
+	}
+	host, _, _ := net.SplitHostPort(d.addr)
+	d.tlsConfig = &tls.Config{ServerName: host}
+	if config.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CACert)) {
+			return nil, errors.New("no certificates found in LDAP CA certificate")
+		}
+		d.tlsConfig.RootCAs = pool
+	}
+	if config.UserBaseDN == "" {
+		return nil, errors.New("missing LDAP user base DN")
+	}
+	if config.BindDN == "" && config.BindPassword != "" {
+		return nil, errors.New("LDAP bind password given without bind DN")
+	}
+	if d.config.UserFilter == "" {
+		d.config.UserFilter = defaultUserFilter
+	}
+	if d.config.DisplayNameAttribute == "" {
+		d.config.DisplayNameAttribute = defaultDisplayNameAttribute
+	}
+	if d.config.GroupBaseDN == "" {
+		d.config.GroupBaseDN = d.config.UserBaseDN
+	}
+	if d.config.GroupFilter == "" {
+		d.config.GroupFilter = defaultGroupFilter
+	}
+	if d.config.GroupNameAttribute == "" {
+		d.config.GroupNameAttribute = defaultGroupNameAttribute
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = defaultTimeout
+	}
+	// Check that the filters are valid now, rather than at each login.
+	for _, filter := range []string{d.config.UserFilter, d.config.GroupFilter} {
+		filter = fmt.Sprintf(filter, "x")
+		if _, err := goldap.CompileFilter(filter); err != nil {
+			return nil, errors.Annotatef(err, "invalid filter %q", filter)
+		}
+	}
+	return d, nil
+}
+
+// Name implements authentication.Directory.Name.
+func (d *Directory) Name() string {
+	return DirectoryName
+}
+
+// Authenticate implements authentication.Directory.Authenticate.
+func (d *Directory) Authenticate(name, password string) (*authentication.DirectoryUser, error) {
+	// LDAP servers treat a bind with an empty password as an
+	// anonymous bind, which succeeds regardless of the name.
+	if password == "" {
+		return nil, errors.Unauthorizedf("empty password")
+	}
+	conn, err := d.connect()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer conn.Close()
+	userEntry, err := d.findUser(conn, name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := conn.Bind(userEntry.DN, password); goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+		return nil, errors.Unauthorizedf("invalid password for user %q", name)
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot bind as %q", userEntry.DN)
+	}
+	// Look up the groups with the service credentials, as users
+	// may not be able to search for them.
+	if err := d.bind(conn); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return d.directoryUser(conn, name, userEntry)
+}
+
+// User implements authentication.Directory.User.
+func (d *Directory) User(name string) (*authentication.DirectoryUser, error) {
+	conn, err := d.connect()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer conn.Close()
+	userEntry, err := d.findUser(conn, name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return d.directoryUser(conn, name, userEntry)
+}
+
+// connect connects to the LDAP server, and binds with the service
+// credentials.
+func (d *Directory) connect() (*goldap.Conn, error) {
+	dialer := &net.Dialer{Timeout: d.config.Timeout}
+	var netConn net.Conn
+	var err error
+	if d.useTLS {
+		netConn, err = tls.DialWithDialer(dialer, "tcp", d.addr, d.tlsConfig)
+	} else {
+		netConn, err = dialer.Dial("tcp", d.addr)
+	}
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot connect to LDAP server")
+	}
+	conn := goldap.NewConn(netConn, d.useTLS)
+	conn.SetTimeout(d.config.Timeout)
+	conn.Start()
+	if d.config.StartTLS {
+		if err := conn.StartTLS(d.tlsConfig); err != nil {
+			conn.Close()
+			return nil, errors.Annotate(err, "cannot start TLS")
+		}
+	}
+	if err := d.bind(conn); err != nil {
+		conn.Close()
+		return nil, errors.Trace(err)
+	}
+	return conn, nil
+}
+
+// bind binds the connection with the service credentials, if any.
+func (d *Directory) bind(conn *goldap.Conn) error {
+	if d.config.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(d.config.BindDN, d.config.BindPassword); err != nil {
+		return errors.Annotatef(err, "cannot bind as %q", d.config.BindDN)
+	}
+	return nil
+}
+
+// search returns the entries below baseDN matching the filter, with
+// the given attributes. If sizeLimit is not zero, at most that many
+// entries are returned.
+func (d *Directory) search(conn *goldap.Conn, baseDN, filter string, attributes []string, sizeLimit int) ([]*goldap.Entry, error) {
+	timeLimit := int(d.config.Timeout / time.Second)
+	req := goldap.NewSearchRequest(
+		baseDN, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases,
+		sizeLimit, timeLimit, false, filter, attributes, nil,
+	)
+	result, err := conn.Search(req)
+	if goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) && result != nil {
+		// The entries found up to the limit are still returned.
+		return result.Entries, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return result.Entries, nil
+}
+
+// findUser returns the entry of the named user.
+func (d *Directory) findUser(conn *goldap.Conn, name string) (*goldap.Entry, error) {
+	filter := fmt.Sprintf(d.config.UserFilter, goldap.EscapeFilter(name))
+	entries, err := d.search(conn, d.config.UserBaseDN, filter, []string{d.config.DisplayNameAttribute}, 2)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot search for user %q", name)
+	}
+	switch len(entries) {
+	case 0:
+		return nil, errors.NotFoundf("LDAP user %q", name)
+	case 1:
+		return entries[0], nil
+	}
+	return nil, errors.Errorf("more than one LDAP entry found for user %q", name)
+}
+
+// directoryUser returns the details of the user with the given entry,
+// including the groups the user belongs to.
+func (d *Directory) directoryUser(conn *goldap.Conn, name string, userEntry *goldap.Entry) (*authentication.DirectoryUser, error) {
+	filter := fmt.Sprintf(d.config.GroupFilter, goldap.EscapeFilter(userEntry.DN))
+	groupEntries, err := d.search(conn, d.config.GroupBaseDN, filter, []string{d.config.GroupNameAttribute}, 0)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot search for groups of user %q", name)
+	}
+	user := &authentication.DirectoryUser{
+		Name:        name,
+		DisplayName: userEntry.GetAttributeValue(d.config.DisplayNameAttribute),
+	}
+	seen := make(map[string]bool)
+	for _, groupEntry := range groupEntries {
+		group := groupEntry.GetAttributeValue(d.config.GroupNameAttribute)
+		if group == "" {
+			logger.Warningf("LDAP group %q has no %s attribute", groupEntry.DN, d.config.GroupNameAttribute)
+			continue
+		}
+		user.Groups = append(user.Groups, group)
+		for _, uuid := range d.config.Environments[group] {
+			if !seen[uuid] {
+				seen[uuid] = true
+				user.Environments = append(user.Environments, uuid)
+			}
+		}
+	}
+	return user, nil
+}
+
+// String returns a description of the directory for logging.
+func (d *Directory) String() string {
+	scheme := "ldap"
+	if d.useTLS {
+		scheme = "ldaps"
+	}
+	return fmt.Sprintf("%s://%s", scheme, d.addr)
+}
@@ -0,0 +1,332 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package ldap_test
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/authentication"
+	"github.com/juju/juju/apiserver/authentication/ldap"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type DirectorySuite struct {
+	coretesting.BaseSuite
+	server *ldap.TestServer
+}
+
+var _ = gc.Suite(&DirectorySuite{})
+
+var testEntries = []ldap.TestEntry{{
+	DN:       "cn=juju,ou=services,dc=example,dc=com",
+	Password: "service-secret",
+}, {
+	DN:       "uid=bob,ou=people,dc=example,dc=com",
+	Password: "bob-secret",
+	Attributes: map[string][]string{
+		"uid": {"bob"},
+		"cn":  {"Bob Brown"},
+	},
+}, {
+	DN:       "uid=alice,ou=people,dc=example,dc=com",
+	Password: "alice-secret",
+	Attributes: map[string][]string{
+		"uid": {"alice"},
+		"cn":  {"Alice Allen"},
+	},
+}, {
+	DN: "cn=ops,ou=groups,dc=example,dc=com",
+	Attributes: map[string][]string{
+		"cn":     {"ops"},
+		"member": {"uid=bob,ou=people,dc=example,dc=com", "uid=alice,ou=people,dc=example,dc=com"},
+	},
+}, {
+	DN: "cn=dba,ou=groups,dc=example,dc=com",
+	Attributes: map[string][]string{
+		"cn":     {"dba"},
+		"member": {"uid=bob,ou=people,dc=example,dc=com"},
+	},
+}}
+
+func (s *DirectorySuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	server, err := ldap.NewTestServer(copyEntries(testEntries), nil, false)
+	c.Assert(err, jc.ErrorIsNil)
+	s.server = server
+	s.AddCleanup(func(*gc.C) { server.Close() })
+}
+
+func copyEntries(entries []ldap.TestEntry) []ldap.TestEntry {
+	return append([]ldap.TestEntry(nil), entries...)
+}
+
+func (s *DirectorySuite) config() ldap.Config {
+	return ldap.Config{
+		URL:          "ldap://" + s.server.Addr(),
+		BindDN:       "cn=juju,ou=services,dc=example,dc=com",
+		BindPassword: "service-secret",
+		UserBaseDN:   "ou=people,dc=example,dc=com",
+		GroupBaseDN:  "ou=groups,dc=example,dc=com",
+		Environments: map[string][]string{
+			"ops": {"env-1"},
+			"dba": {"env-1", "env-2"},
+		},
+		Timeout: coretesting.LongWait,
+	}
+}
+
+func (s *DirectorySuite) newDirectory(c *gc.C, config ldap.Config) *ldap.Directory {
+	dir, err := ldap.New(config)
+	c.Assert(err, jc.ErrorIsNil)
+	return dir
+}
+
+func (s *DirectorySuite) TestAuthenticate(c *gc.C) {
+	dir := s.newDirectory(c, s.config())
+	c.Assert(dir.Name(), gc.Equals, "ldap")
+	user, err := dir.Authenticate("bob", "bob-secret")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user, jc.DeepEquals, &authentication.DirectoryUser{
+		Name:         "bob",
+		DisplayName:  "Bob Brown",
+		Groups:       []string{"ops", "dba"},
+		Environments: []string{"env-1", "env-2"},
+	})
+	c.Assert(s.server.Binds(), jc.DeepEquals, []string{
+		"cn=juju,ou=services,dc=example,dc=com",
+		"uid=bob,ou=people,dc=example,dc=com",
+		"cn=juju,ou=services,dc=example,dc=com",
+	})
+	c.Assert(s.server.Searches(), jc.DeepEquals, []string{
+		"ou=people,dc=example,dc=com",
+		"ou=groups,dc=example,dc=com",
+	})
+}
+
+func (s *DirectorySuite) TestAuthenticateWrongPassword(c *gc.C) {
+	dir := s.newDirectory(c, s.config())
+	_, err := dir.Authenticate("bob", "alice-secret")
+	c.Assert(err, jc.Satisfies, errors.IsUnauthorized)
+	c.Assert(err, gc.ErrorMatches, `invalid password for user "bob"`)
+}
+
+func (s *DirectorySuite) TestAuthenticateEmptyPassword(c *gc.C) {
+	dir := s.newDirectory(c, s.config())
+	_, err := dir.Authenticate("bob", "")
+	c.Assert(err, jc.Satisfies, errors.IsUnauthorized)
+	// The server is never asked, as it would allow an anonymous bind.
+	c.Assert(s.server.Binds(), gc.HasLen, 0)
+}
+
+func (s *DirectorySuite) TestAuthenticateUnknownUser(c *gc.C) {
+	dir := s.newDirectory(c, s.config())
+	_, err := dir.Authenticate("carol", "bob-secret")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *DirectorySuite) TestAuthenticateEscapesName(c *gc.C) {
+	dir := s.newDirectory(c, s.config())
+	_, err := dir.Authenticate("*", "bob-secret")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *DirectorySuite) TestBadServiceCredentials(c *gc.C) {
+	config := s.config()
+	config.BindPassword = "wrong"
+	dir := s.newDirectory(c, config)
+	_, err := dir.Authenticate("bob", "bob-secret")
+	c.Assert(err, gc.ErrorMatches, `cannot bind as "cn=juju,ou=services,dc=example,dc=com": LDAP Result Code 49 .*: invalid credentials`)
+}
+
+func (s *DirectorySuite) TestAnonymousSearch(c *gc.C) {
+	config := s.config()
+	config.BindDN = ""
+	config.BindPassword = ""
+	dir := s.newDirectory(c, config)
+	user, err := dir.Authenticate("alice", "alice-secret")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.Groups, jc.DeepEquals, []string{"ops"})
+	c.Assert(s.server.Binds(), jc.DeepEquals, []string{"uid=alice,ou=people,dc=example,dc=com"})
+}
+
+func (s *DirectorySuite) TestUser(c *gc.C) {
+	dir := s.newDirectory(c, s.config())
+	user, err := dir.User("alice")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user, jc.DeepEquals, &authentication.DirectoryUser{
+		Name:         "alice",
+		DisplayName:  "Alice Allen",
+		Groups:       []string{"ops"},
+		Environments: []string{"env-1"},
+	})
+
+	s.server.RemoveEntry("uid=alice,ou=people,dc=example,dc=com")
+	_, err = dir.User("alice")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *DirectorySuite) TestCustomFilters(c *gc.C) {
+	config := s.config()
+	config.UserFilter = "(&(cn=*)(uid=%s))"
+	config.GroupFilter = "(&(member=%s)(!(cn=dba)))"
+	dir := s.newDirectory(c, config)
+	user, err := dir.Authenticate("bob", "bob-secret")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.Groups, jc.DeepEquals, []string{"ops"})
+}
+
+func (s *DirectorySuite) TestStartTLS(c *gc.C) {
+	s.server.Close()
+	server, err := ldap.NewTestServer(copyEntries(testEntries), serverTLSConfig(c), false)
+	c.Assert(err, jc.ErrorIsNil)
+	defer server.Close()
+
+	config := s.config()
+	config.URL = "ldap://localhost:" + server.Port()
+	config.StartTLS = true
+	config.CACert = coretesting.CACert
+	_, err = s.newDirectory(c, config).Authenticate("bob", "bob-secret")
+	c.Assert(err, jc.ErrorIsNil)
+
+	config.CACert = coretesting.OtherCACert
+	_, err = s.newDirectory(c, config).Authenticate("bob", "bob-secret")
+	c.Assert(err, gc.ErrorMatches, "cannot start TLS: .*certificate signed by unknown authority.*")
+}
+
+func (s *DirectorySuite) TestLDAPS(c *gc.C) {
+	s.server.Close()
+	server, err := ldap.NewTestServer(copyEntries(testEntries), serverTLSConfig(c), true)
+	c.Assert(err, jc.ErrorIsNil)
+	defer server.Close()
+
+	config := s.config()
+	config.URL = "ldaps://localhost:" + server.Port()
+	config.CACert = coretesting.CACert
+	_, err = s.newDirectory(c, config).Authenticate("bob", "bob-secret")
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func serverTLSConfig(c *gc.C) *tls.Config {
+	cert, err := tls.X509KeyPair([]byte(coretesting.ServerCert), []byte(coretesting.ServerKey))
+	c.Assert(err, jc.ErrorIsNil)
+	return &tls.Config{Certificates: []tls.Certificate{cert}}
+}
+
+func (s *DirectorySuite) TestConnectionFailure(c *gc.C) {
+	config := s.config()
+	s.server.Close()
+	config.Timeout = time.Second
+	_, err := s.newDirectory(c, config).Authenticate("bob", "bob-secret")
+	c.Assert(err, gc.ErrorMatches, "cannot connect to LDAP server: .*")
+}
+
+func (s *DirectorySuite) TestNewInvalid(c *gc.C) {
+	for i, test := range []struct {
+		about  string
+		modify func(*ldap.Config)
+		err    string
+	}{{
+		about:  "bad scheme",
+		modify: func(cfg *ldap.Config) { cfg.URL = "http://localhost" },
+		err:    `invalid LDAP URL "http://localhost": scheme must be ldap or ldaps`,
+	}, {
+		about:  "no host",
+		modify: func(cfg *ldap.Config) { cfg.URL = "ldap://" },
+		err:    `invalid LDAP URL "ldap://": missing host`,
+	}, {
+		about: "StartTLS with ldaps",
+		modify: func(cfg *ldap.Config) {
+			cfg.URL = "ldaps://localhost"
+			cfg.StartTLS = true
+		},
+		err: "StartTLS cannot be used with ldaps URLs",
+	}, {
+		about:  "bad CA certificate",
+		modify: func(cfg *ldap.Config) { cfg.CACert = "foo" },
+		err:    "no certificates found in LDAP CA certificate",
+	}, {
+		about:  "no user base",
+		modify: func(cfg *ldap.Config) { cfg.UserBaseDN = "" },
+		err:    "missing LDAP user base DN",
+	}, {
+		about:  "password without DN",
+		modify: func(cfg *ldap.Config) { cfg.BindDN = "" },
+		err:    "LDAP bind password given without bind DN",
+	}, {
+		about:  "bad filter",
+		modify: func(cfg *ldap.Config) { cfg.UserFilter = "uid=%s" },
+		err:    `invalid filter "uid=x": .*`,
+	}} {
+		c.Logf("test %d: %s", i, test.about)
+		config := s.config()
+		test.modify(&config)
+		_, err := ldap.New(config)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *DirectorySuite) TestParseConfig(c *gc.C) {
+	config, err := ldap.ParseConfig(`
+url: ldaps://ldap.example.com
+bind-dn: cn=juju,ou=services,dc=example,dc=com
+bind-password: service-secret
+user-base-dn: ou=people,dc=example,dc=com
+environments:
+  ops: ["*"]
+`)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(config, jc.DeepEquals, ldap.Config{
+		URL:          "ldaps://ldap.example.com",
+		BindDN:       "cn=juju,ou=services,dc=example,dc=com",
+		BindPassword: "service-secret",
+		UserBaseDN:   "ou=people,dc=example,dc=com",
+		Environments: map[string][]string{"ops": {"*"}},
+	})
+
+	_, err = ldap.ParseConfig("url: [")
+	c.Assert(err, gc.ErrorMatches, "cannot parse LDAP configuration: .*")
+}
+
+type FilterSuite struct{}
+
+var _ = gc.Suite(&FilterSuite{})
+
+func (*FilterSuite) TestMatchFilter(c *gc.C) {
+	entry := ldap.TestEntry{
+		Attributes: map[string][]string{
+			"uid":  {"bob"},
+			"cn":   {"Bob Brown"},
+			"mail": {"bob@example.com", "b*b@example.com"},
+		},
+	}
+	for i, test := range []struct {
+		filter string
+		match  bool
+	}{
+		{"(uid=bob)", true},
+		{"(uid=alice)", false},
+		{"(uid=*)", true},
+		{"(sn=*)", false},
+		{"(cn=Bob*)", true},
+		{"(cn=*Brown)", true},
+		{"(cn=B*b*n)", true},
+		{"(cn=*Smith*)", false},
+		{`(mail=b\2ab@example.com)`, true},
+		{"(&(uid=bob)(cn=Bob Brown))", true},
+		{"(&(uid=bob)(cn=Alice))", false},
+		{"(|(uid=alice)(uid=bob))", true},
+		{"(!(uid=bob))", false},
+		{"(&(uid=bob)(!(|(cn=Alice)(mail=*@example.org))))", true},
+	} {
+		c.Logf("test %d: %s", i, test.filter)
+		match, err := ldap.MatchFilter(test.filter, entry)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(match, gc.Equals, test.match)
+	}
+}
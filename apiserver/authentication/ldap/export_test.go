@@ -0,0 +1,23 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package ldap
+
+import (
+	ber "gopkg.in/asn1-ber.v1"
+	goldap "gopkg.in/ldap.v2"
+)
+
+var NewTestServer = newTestServer
+
+// MatchFilter returns whether the given entry matches the filter, as
+// the test server would match it.
+func MatchFilter(filter string, e TestEntry) (bool, error) {
+	compiled, err := goldap.CompileFilter(filter)
+	if err != nil {
+		return false, err
+	}
+	// Match the filter as the server receives it.
+	decoded := ber.DecodePacket(compiled.Bytes())
+	return matchFilter(decoded, e), nil
+}
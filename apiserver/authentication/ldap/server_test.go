@@ -0,0 +1,294 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package ldap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"strings"
+	"sync"
+
+	ber "gopkg.in/asn1-ber.v1"
+	goldap "gopkg.in/ldap.v2"
+)
+
+// startTLSOID identifies the StartTLS extended operation.
+const startTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// TestEntry holds an entry in the directory served by a TestServer.
+type TestEntry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// TestServer is an in-process stand-in for an LDAP server, serving a
+// fixed set of entries. It supports simple binds, searches of whole
+// subtrees and StartTLS.
+type TestServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+
+	mu       sync.Mutex
+	entries  []TestEntry
+	binds    []string
+	searches []string
+}
+
+// newTestServer starts a server for the given entries. If tlsConfig is
+// not nil, it is used for StartTLS, or for all connections if ldaps is
+// true.
+func newTestServer(entries []TestEntry, tlsConfig *tls.Config, ldaps bool) (*TestServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	if ldaps {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	srv := &TestServer{
+		listener:  listener,
+		tlsConfig: tlsConfig,
+		entries:   entries,
+	}
+	go srv.serve()
+	return srv, nil
+}
+
+// Addr returns the address of the server.
+func (srv *TestServer) Addr() string {
+	return srv.listener.Addr().String()
+}
+
+// Port returns the port the server listens on.
+func (srv *TestServer) Port() string {
+	_, port, _ := net.SplitHostPort(srv.Addr())
+	return port
+}
+
+// Close stops the server.
+func (srv *TestServer) Close() error {
+	return srv.listener.Close()
+}
+
+// RemoveEntry removes the entry with the given DN.
+func (srv *TestServer) RemoveEntry(dn string) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for i, e := range srv.entries {
+		if e.DN == dn {
+			srv.entries = append(srv.entries[:i], srv.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Binds returns the DNs of the successful binds made so far.
+func (srv *TestServer) Binds() []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return append([]string(nil), srv.binds...)
+}
+
+// Searches returns the base DNs of the searches made so far.
+func (srv *TestServer) Searches() []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return append([]string(nil), srv.searches...)
+}
+
+func (srv *TestServer) serve() {
+	for {
+		netConn, err := srv.listener.Accept()
+		if err != nil {
+			return
+		}
+		go srv.serveConn(netConn)
+	}
+}
+
+func (srv *TestServer) serveConn(netConn net.Conn) {
+	defer func() {
+		netConn.Close()
+	}()
+	reader := bufio.NewReader(netConn)
+	for {
+		message, err := ber.ReadPacket(reader)
+		if err != nil || len(message.Children) < 2 {
+			return
+		}
+		id, op := message.Children[0].Value, message.Children[1]
+		reply := func(ops ...*ber.Packet) {
+			for _, op := range ops {
+				response := ber.NewSequence("")
+				response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+				response.AppendChild(op)
+				netConn.Write(response.Bytes())
+			}
+		}
+		if op.ClassType != ber.ClassApplication {
+			return
+		}
+		switch op.Tag {
+		case goldap.ApplicationBindRequest:
+			reply(srv.bind(op))
+		case goldap.ApplicationSearchRequest:
+			reply(srv.search(op)...)
+		case goldap.ApplicationExtendedRequest:
+			if srv.tlsConfig == nil || str(op.Children[0]) != startTLSOID {
+				reply(result(goldap.ApplicationExtendedResponse, goldap.LDAPResultProtocolError, "unsupported operation"))
+				continue
+			}
+			reply(result(goldap.ApplicationExtendedResponse, goldap.LDAPResultSuccess, ""))
+			tlsConn := tls.Server(netConn, srv.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			netConn = tlsConn
+			reader = bufio.NewReader(netConn)
+		default:
+			// Unbind, or anything unknown.
+			return
+		}
+	}
+}
+
+// str returns the content of a primitive packet as a string.
+func str(p *ber.Packet) string {
+	return p.Data.String()
+}
+
+func octetString(s string) *ber.Packet {
+	return ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, s, "")
+}
+
+func result(tag ber.Tag, code int, message string) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+	p.AppendChild(octetString(""))
+	p.AppendChild(octetString(message))
+	return p
+}
+
+func (srv *TestServer) bind(op *ber.Packet) *ber.Packet {
+	dn, password := str(op.Children[1]), str(op.Children[2])
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if dn == "" && password == "" {
+		return result(goldap.ApplicationBindResponse, goldap.LDAPResultSuccess, "")
+	}
+	for _, e := range srv.entries {
+		if e.DN == dn && e.Password != "" && e.Password == password {
+			srv.binds = append(srv.binds, dn)
+			return result(goldap.ApplicationBindResponse, goldap.LDAPResultSuccess, "")
+		}
+	}
+	return result(goldap.ApplicationBindResponse, goldap.LDAPResultInvalidCredentials, "invalid credentials")
+}
+
+func (srv *TestServer) search(op *ber.Packet) []*ber.Packet {
+	baseDN := str(op.Children[0])
+	sizeLimit, _ := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+	var attrs []string
+	for _, attr := range op.Children[7].Children {
+		attrs = append(attrs, str(attr))
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.searches = append(srv.searches, baseDN)
+	var responses []*ber.Packet
+	for _, e := range srv.entries {
+		if e.DN != baseDN && !strings.HasSuffix(e.DN, ","+baseDN) {
+			continue
+		}
+		if !matchFilter(filter, e) {
+			continue
+		}
+		if sizeLimit > 0 && int64(len(responses)) == sizeLimit {
+			return append(responses, result(goldap.ApplicationSearchResultDone, goldap.LDAPResultSizeLimitExceeded, ""))
+		}
+		attributes := ber.NewSequence("")
+		for _, attr := range attrs {
+			values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+			for _, value := range e.Attributes[attr] {
+				values.AppendChild(octetString(value))
+			}
+			attribute := ber.NewSequence("")
+			attribute.AppendChild(octetString(attr))
+			attribute.AppendChild(values)
+			attributes.AppendChild(attribute)
+		}
+		entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "")
+		entry.AppendChild(octetString(e.DN))
+		entry.AppendChild(attributes)
+		responses = append(responses, entry)
+	}
+	return append(responses, result(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess, ""))
+}
+
+// matchFilter returns whether the entry matches the given filter.
+func matchFilter(filter *ber.Packet, e TestEntry) bool {
+	values := func(attr string) []string {
+		return e.Attributes[attr]
+	}
+	switch filter.Tag {
+	case goldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matchFilter(child, e) {
+				return false
+			}
+		}
+		return true
+	case goldap.FilterOr:
+		for _, child := range filter.Children {
+			if matchFilter(child, e) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterNot:
+		return !matchFilter(filter.Children[0], e)
+	case goldap.FilterPresent:
+		return len(values(str(filter))) > 0
+	case goldap.FilterEqualityMatch:
+		for _, value := range values(str(filter.Children[0])) {
+			if value == str(filter.Children[1]) {
+				return true
+			}
+		}
+	case goldap.FilterSubstrings:
+		for _, value := range values(str(filter.Children[0])) {
+			if matchSubstrings(value, filter.Children[1].Children) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchSubstrings(value string, parts []*ber.Packet) bool {
+	for _, part := range parts {
+		s := str(part)
+		switch part.Tag {
+		case goldap.FilterSubstringsInitial:
+			if !strings.HasPrefix(value, s) {
+				return false
+			}
+			value = value[len(s):]
+		case goldap.FilterSubstringsAny:
+			i := strings.Index(value, s)
+			if i < 0 {
+				return false
+			}
+			value = value[i+len(s):]
+		case goldap.FilterSubstringsFinal:
+			if !strings.HasSuffix(value, s) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The blobgc package implements the API used to remove the charm
+// archives, tools tarballs and container images that are no longer
+// used from controller storage.
+package blobgc
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("BlobGC", 1, NewBlobGCAPI)
+}
+
+// BlobGC defines the methods on the blobgc API end point.
+type BlobGC interface {
+	CollectUnusedBlobs(args params.CollectUnusedBlobsArgs) (params.UnusedBlobsResult, error)
+}
+
+// BlobGCAPI implements the BlobGC interface and is the concrete
+// implementation of the api end point.
+type BlobGCAPI struct {
+	state      *state.State
+	authorizer common.Authorizer
+}
+
+var _ BlobGC = (*BlobGCAPI)(nil)
+
+// NewBlobGCAPI creates a new server-side blobgc API end point.
+func NewBlobGCAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*BlobGCAPI, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &BlobGCAPI{
+		state:      st,
+		authorizer: authorizer,
+	}, nil
+}
+
+// checkCanCollect returns an error unless the connection is to the
+// state server environment, by the user that administers it.
+func (api *BlobGCAPI) checkCanCollect() error {
+	if !api.state.IsStateServer() {
+		return errors.New("unsupported with hosted environments")
+	}
+	env, err := api.state.StateServerEnvironment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	apiUser, ok := api.authorizer.GetAuthTag().(names.UserTag)
+	if !ok || apiUser != env.Owner() {
+		return common.ErrPerm
+	}
+	return nil
+}
+
+// CollectUnusedBlobs reports the unused blobs in the storage of every
+// environment, and removes those that have been unused for longer
+// than the grace period unless a dry run is requested.
+func (api *BlobGCAPI) CollectUnusedBlobs(args params.CollectUnusedBlobsArgs) (params.UnusedBlobsResult, error) {
+	var result params.UnusedBlobsResult
+	if err := api.checkCanCollect(); err != nil {
+		return result, errors.Trace(err)
+	}
+	if args.GracePeriod < 0 {
+		return result, errors.NotValidf("negative grace period")
+	}
+	gracePeriod := args.GracePeriod
+	if gracePeriod == 0 {
+		gracePeriod = state.DefaultBlobGracePeriod
+	}
+	blobs, err := api.state.CollectUnusedBlobs(gracePeriod, args.DryRun)
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	result.Blobs = make([]params.UnusedBlob, len(blobs))
+	for i, blob := range blobs {
+		result.Blobs[i] = params.UnusedBlob{
+			EnvUUID:     blob.EnvUUID,
+			Kind:        string(blob.Kind),
+			Name:        blob.Name,
+			Size:        blob.Size,
+			UnusedSince: blob.UnusedSince,
+			Expired:     blob.Expired,
+			Removed:     blob.Removed,
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package blobgc_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/blobgc"
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/juju/testing"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type blobGCSuite struct {
+	testing.JujuConnSuite
+
+	resources  *common.Resources
+	authoriser apiservertesting.FakeAuthorizer
+	api        *blobgc.BlobGCAPI
+}
+
+var _ = gc.Suite(&blobGCSuite{})
+
+func (s *blobGCSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
+	s.authoriser = apiservertesting.FakeAuthorizer{
+		Tag: s.AdminUserTag(c),
+	}
+	var err error
+	s.api, err = blobgc.NewBlobGCAPI(s.State, s.resources, s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *blobGCSuite) TestNewAPIRefusesAgents(c *gc.C) {
+	auth := apiservertesting.FakeAuthorizer{Tag: names.NewMachineTag("0")}
+	_, err := blobgc.NewBlobGCAPI(s.State, s.resources, auth)
+	c.Assert(err, gc.Equals, common.ErrPerm)
+}
+
+func (s *blobGCSuite) TestDryRun(c *gc.C) {
+	ch := s.AddTestingCharm(c, "mysql")
+
+	result, err := s.api.CollectUnusedBlobs(params.CollectUnusedBlobsArgs{DryRun: true})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Blobs, gc.HasLen, 1)
+	blob := result.Blobs[0]
+	c.Assert(blob.EnvUUID, gc.Equals, s.State.EnvironUUID())
+	c.Assert(blob.Kind, gc.Equals, "charm")
+	c.Assert(blob.Name, gc.Equals, ch.String())
+	c.Assert(blob.Expired, jc.IsFalse)
+	c.Assert(blob.Removed, jc.IsFalse)
+}
+
+func (s *blobGCSuite) TestCollect(c *gc.C) {
+	ch := s.AddTestingCharm(c, "mysql")
+
+	result, err := s.api.CollectUnusedBlobs(params.CollectUnusedBlobsArgs{GracePeriod: time.Nanosecond})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Blobs, gc.HasLen, 1)
+	c.Assert(result.Blobs[0].Removed, jc.IsTrue)
+	_, err = s.State.Charm(ch.URL())
+	c.Assert(err, gc.ErrorMatches, `charm ".*" not found`)
+}
+
+func (s *blobGCSuite) TestNegativeGracePeriod(c *gc.C) {
+	_, err := s.api.CollectUnusedBlobs(params.CollectUnusedBlobsArgs{GracePeriod: -time.Hour})
+	c.Assert(err, gc.ErrorMatches, "negative grace period not valid")
+}
+
+func (s *blobGCSuite) TestNotAdmin(c *gc.C) {
+	user := s.Factory.MakeUser(c, &factory.UserParams{Name: "bob"})
+	auth := apiservertesting.FakeAuthorizer{Tag: user.UserTag()}
+	api, err := blobgc.NewBlobGCAPI(s.State, s.resources, auth)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = api.CollectUnusedBlobs(params.CollectUnusedBlobsArgs{})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *blobGCSuite) TestHostedEnvironment(c *gc.C) {
+	st := s.Factory.MakeEnvironment(c, nil)
+	defer st.Close()
+	api, err := blobgc.NewBlobGCAPI(st, s.resources, s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = api.CollectUnusedBlobs(params.CollectUnusedBlobsArgs{})
+	c.Assert(err, gc.ErrorMatches, "unsupported with hosted environments")
+}
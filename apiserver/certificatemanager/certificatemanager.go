@@ -0,0 +1,126 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The certificatemanager package implements the API used to rotate the
+// CA that signs the certificates of the state servers.
+package certificatemanager
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/state"
+)
+
+var logger = loggo.GetLogger("juju.apiserver.certificatemanager")
+
+func init() {
+	common.RegisterStandardFacade("CertificateManager", 1, NewCertificateManagerAPI)
+}
+
+// caExpiryYears holds how long newly generated CA certificates are
+// valid for, matching those generated at bootstrap.
+const caExpiryYears = 10
+
+// CertificateManager defines the methods on the certificatemanager API
+// end point.
+type CertificateManager interface {
+	RotateCertificates(args params.RotateCertificatesArgs) (params.RotateCertificatesResult, error)
+}
+
+// CertificateManagerAPI implements the CertificateManager interface and
+// is the concrete implementation of the api end point.
+type CertificateManagerAPI struct {
+	state      *state.State
+	authorizer common.Authorizer
+	check      *common.BlockChecker
+}
+
+var _ CertificateManager = (*CertificateManagerAPI)(nil)
+
+// NewCertificateManagerAPI creates a new server-side certificatemanager
+// API end point.
+func NewCertificateManagerAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*CertificateManagerAPI, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &CertificateManagerAPI{
+		state:      st,
+		authorizer: authorizer,
+		check:      common.NewBlockChecker(st),
+	}, nil
+}
+
+// checkCanRotate returns an error unless the connection is to the state
+// server environment, by the user that administers it. Rotating the CA
+// affects every environment hosted by the state servers.
+func (api *CertificateManagerAPI) checkCanRotate() error {
+	if !api.state.IsStateServer() {
+		return errors.New("unsupported with hosted environments")
+	}
+	env, err := api.state.StateServerEnvironment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	apiUser, ok := api.authorizer.GetAuthTag().(names.UserTag)
+	if !ok || apiUser != env.Owner() {
+		return common.ErrPerm
+	}
+	return nil
+}
+
+// RotateCertificates starts rotating the CA that signs the certificates
+// of the state servers if no rotation is in progress, and otherwise
+// advances the rotation to its next phase. It returns the phase that
+// the rotation has reached. A rotation is only advanced once every agent
+// has fetched the CA certificates trusted by its current phase, unless
+// args.Force is set.
+func (api *CertificateManagerAPI) RotateCertificates(args params.RotateCertificatesArgs) (params.RotateCertificatesResult, error) {
+	if err := api.checkCanRotate(); err != nil {
+		return params.RotateCertificatesResult{}, errors.Trace(err)
+	}
+	if err := api.check.ChangeAllowed(); err != nil {
+		return params.RotateCertificatesResult{}, errors.Trace(err)
+	}
+	phase, err := api.rotate(args.Force)
+	if err != nil {
+		return params.RotateCertificatesResult{}, errors.Trace(err)
+	}
+	logger.Infof("CA rotation is now %s", phase)
+	trusted, err := api.state.TrustedCACert()
+	if err != nil {
+		return params.RotateCertificatesResult{}, errors.Trace(err)
+	}
+	return params.RotateCertificatesResult{
+		Phase:         string(phase),
+		TrustedCACert: trusted,
+	}, nil
+}
+
+func (api *CertificateManagerAPI) rotate(force bool) (state.CARotationPhase, error) {
+	_, err := api.state.CARotation()
+	if err == nil {
+		return api.state.AdvanceCARotation(force)
+	} else if !errors.IsNotFound(err) {
+		return "", errors.Trace(err)
+	}
+	env, err := api.state.Environment()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	expiry := time.Now().UTC().AddDate(caExpiryYears, 0, 0)
+	caCert, caKey, err := cert.NewCA(env.Name(), expiry)
+	if err != nil {
+		return "", errors.Annotate(err, "cannot generate CA certificate")
+	}
+	if err := api.state.StartCARotation(caCert, caKey); err != nil {
+		return "", errors.Trace(err)
+	}
+	return state.CARotationTrusting, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package certificatemanager_test
+
+import (
+	stdtesting "testing"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/certificatemanager"
+	"github.com/juju/juju/apiserver/common"
+	commontesting "github.com/juju/juju/apiserver/common/testing"
+	"github.com/juju/juju/apiserver/params"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type certificateManagerSuite struct {
+	testing.JujuConnSuite
+
+	resources  *common.Resources
+	authoriser apiservertesting.FakeAuthorizer
+	api        *certificatemanager.CertificateManagerAPI
+
+	commontesting.BlockHelper
+}
+
+var _ = gc.Suite(&certificateManagerSuite{})
+
+func (s *certificateManagerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
+	s.authoriser = apiservertesting.FakeAuthorizer{
+		Tag: s.AdminUserTag(c),
+	}
+	var err error
+	s.api, err = certificatemanager.NewCertificateManagerAPI(s.State, s.resources, s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.BlockHelper = commontesting.NewBlockHelper(s.APIState)
+	s.AddCleanup(func(*gc.C) { s.BlockHelper.Close() })
+}
+
+func (s *certificateManagerSuite) TestNewAPIRefusesAgents(c *gc.C) {
+	auth := apiservertesting.FakeAuthorizer{Tag: names.NewMachineTag("0")}
+	_, err := certificatemanager.NewCertificateManagerAPI(s.State, s.resources, auth)
+	c.Assert(err, gc.Equals, common.ErrPerm)
+}
+
+func (s *certificateManagerSuite) TestRotateCertificates(c *gc.C) {
+	result, err := s.api.RotateCertificates(params.RotateCertificatesArgs{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Phase, gc.Equals, string(state.CARotationTrusting))
+	rotation, err := s.State.CARotation()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.TrustedCACert, gc.Equals, coretesting.CACert+rotation.NewCACert())
+	newCACert, err := cert.ParseCert(rotation.NewCACert())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(newCACert.IsCA, jc.IsTrue)
+
+	result, err = s.api.RotateCertificates(params.RotateCertificatesArgs{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Phase, gc.Equals, string(state.CARotationReissuing))
+	c.Assert(result.TrustedCACert, gc.Equals, coretesting.CACert+rotation.NewCACert())
+
+	result, err = s.api.RotateCertificates(params.RotateCertificatesArgs{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Phase, gc.Equals, string(state.CARotationFinished))
+	c.Assert(result.TrustedCACert, gc.Equals, rotation.NewCACert())
+}
+
+func (s *certificateManagerSuite) TestRotateCertificatesPendingAgents(c *gc.C) {
+	machine := s.Factory.MakeMachine(c, nil)
+	_, err := s.api.RotateCertificates(params.RotateCertificatesArgs{})
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.api.RotateCertificates(params.RotateCertificatesArgs{})
+	c.Assert(err, gc.ErrorMatches, "cannot advance CA rotation: 1 agents have not fetched the new CA certificate: "+s.State.EnvironUUID()+":"+machine.Tag().String())
+	rotation, err := s.State.CARotation()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(rotation.Phase(), gc.Equals, state.CARotationTrusting)
+
+	result, err := s.api.RotateCertificates(params.RotateCertificatesArgs{Force: true})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Phase, gc.Equals, string(state.CARotationReissuing))
+}
+
+func (s *certificateManagerSuite) TestRotateCertificatesNotAdmin(c *gc.C) {
+	user := s.Factory.MakeUser(c, &factory.UserParams{Name: "bob"})
+	auth := apiservertesting.FakeAuthorizer{Tag: user.UserTag()}
+	api, err := certificatemanager.NewCertificateManagerAPI(s.State, s.resources, auth)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = api.RotateCertificates(params.RotateCertificatesArgs{})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *certificateManagerSuite) TestRotateCertificatesHostedEnvironment(c *gc.C) {
+	st := s.Factory.MakeEnvironment(c, nil)
+	defer st.Close()
+	api, err := certificatemanager.NewCertificateManagerAPI(st, s.resources, s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = api.RotateCertificates(params.RotateCertificatesArgs{})
+	c.Assert(err, gc.ErrorMatches, "unsupported with hosted environments")
+}
+
+func (s *certificateManagerSuite) TestBlockRotateCertificates(c *gc.C) {
+	s.BlockAllChanges(c, "TestBlockRotateCertificates")
+	_, err := s.api.RotateCertificates(params.RotateCertificatesArgs{})
+	s.AssertBlocked(c, err, "TestBlockRotateCertificates")
+	_, err = s.State.CARotation()
+	c.Assert(err, gc.ErrorMatches, "CA rotation not found")
+}
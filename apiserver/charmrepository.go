@@ -0,0 +1,221 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"gopkg.in/juju/charm.v5"
+
+	"github.com/juju/juju/apiserver/common"
+	apihttp "github.com/juju/juju/apiserver/http"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+// charmRepositoryHandler serves the controller's charm repository,
+// from which environments at sites without access to the charm store
+// may fetch cs: charms. Listing and downloading the published charm
+// archives requires the credentials of a user or agent of the state
+// server environment, unless its config allows anonymous access; only
+// users may publish them.
+type charmRepositoryHandler struct {
+	httpHandler
+}
+
+func (h *charmRepositoryHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	stateWrapper, err := h.validateEnvironUUID(req)
+	if err != nil {
+		h.sendError(resp, http.StatusNotFound, err.Error())
+		return
+	}
+	defer stateWrapper.cleanup()
+
+	switch req.Method {
+	case "GET":
+		if err := h.authorizeRead(stateWrapper, req); err != nil {
+			h.authError(resp, h)
+			return
+		}
+		// Requires a "url" query holding a cs: charm URL. If the URL
+		// has a revision, the archive is sent; otherwise the matching
+		// published revisions are listed.
+		ref, err := charm.ParseReference(req.URL.Query().Get("url"))
+		if err != nil {
+			h.sendError(resp, http.StatusBadRequest, err.Error())
+			return
+		}
+		if ref.Series != "" && ref.Revision >= 0 {
+			err = h.download(stateWrapper.state, ref, resp)
+		} else {
+			err = h.list(stateWrapper.state, ref, resp)
+		}
+		if err != nil {
+			h.sendServerError(resp, err)
+		}
+	case "POST":
+		tag, err := stateWrapper.authenticate(req)
+		if err != nil {
+			h.authError(resp, h)
+			return
+		}
+		userTag, ok := tag.(names.UserTag)
+		if !ok {
+			h.authError(resp, h)
+			return
+		}
+		// Requires a "url" query holding the cs: charm URL, with
+		// series and revision, under which to publish the archive.
+		ch, err := h.publish(stateWrapper.state, userTag, req)
+		if err != nil {
+			h.sendServerError(resp, err)
+			return
+		}
+		logger.Infof("published charm %q in the charm repository", ch.URL)
+		h.sendJSON(resp, http.StatusOK, repositoryCharmToParams(ch))
+	default:
+		h.sendError(resp, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", req.Method))
+	}
+}
+
+// authorizeRead returns an error if the request may not list or
+// download charms.
+func (h *charmRepositoryHandler) authorizeRead(stateWrapper *httpStateWrapper, req *http.Request) error {
+	cfg, err := stateWrapper.state.EnvironConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.CharmRepositoryAnonymousAccess() {
+		return nil
+	}
+	_, err = stateWrapper.authenticate(req)
+	return err
+}
+
+func (h *charmRepositoryHandler) list(st *state.State, ref *charm.Reference, resp http.ResponseWriter) error {
+	charms, err := st.RepositoryCharmRevisions(ref)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	result := params.RepositoryCharmsResult{
+		Charms: make([]params.RepositoryCharm, len(charms)),
+	}
+	for i, ch := range charms {
+		result.Charms[i] = repositoryCharmToParams(ch)
+	}
+	h.sendJSON(resp, http.StatusOK, &result)
+	return nil
+}
+
+func (h *charmRepositoryHandler) download(st *state.State, ref *charm.Reference, resp http.ResponseWriter) error {
+	curl, err := ref.URL("")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ch, content, err := st.OpenRepositoryCharm(curl)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer content.Close()
+
+	// The digest is base64-encoded, as required by RFC 3230.
+	hash, err := hex.DecodeString(ch.SHA256)
+	if err != nil {
+		return errors.Annotatef(err, "invalid hash recorded for charm %q", curl)
+	}
+	resp.Header().Set("Content-Type", "application/zip")
+	resp.Header().Set("Content-Length", fmt.Sprint(ch.Size))
+	resp.Header().Set("Digest", fmt.Sprintf("%s=%s", apihttp.DigestSHA256, base64.StdEncoding.EncodeToString(hash)))
+	resp.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(resp, content); err != nil {
+		// The status has already been sent, so the client will only
+		// notice the failure through the size or digest check.
+		logger.Errorf("cannot send charm %q: %v", curl, err)
+	}
+	return nil
+}
+
+func (h *charmRepositoryHandler) publish(st *state.State, user names.UserTag, req *http.Request) (state.RepositoryCharm, error) {
+	defer req.Body.Close()
+
+	if err := common.NewBlockChecker(st).ChangeAllowed(); err != nil {
+		return state.RepositoryCharm{}, errors.Trace(err)
+	}
+	curl, err := charm.ParseURL(req.URL.Query().Get("url"))
+	if err != nil {
+		return state.RepositoryCharm{}, errors.NewNotValid(err, "")
+	}
+	contentType := req.Header.Get("Content-Type")
+	if contentType != "application/zip" {
+		return state.RepositoryCharm{}, errors.NotValidf("Content-Type %q", contentType)
+	}
+
+	// The archive is spooled to disk so that it can be checked before
+	// it is published.
+	tempFile, err := ioutil.TempFile("", "charm")
+	if err != nil {
+		return state.RepositoryCharm{}, errors.Annotate(err, "cannot create temp file")
+	}
+	defer tempFile.Close()
+	defer os.Remove(tempFile.Name())
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tempFile, hasher), req.Body)
+	if err != nil {
+		return state.RepositoryCharm{}, errors.Annotate(err, "error processing file upload")
+	}
+	archive, err := charm.ReadCharmArchive(tempFile.Name())
+	if err != nil {
+		return state.RepositoryCharm{}, errors.NewNotValid(err, "invalid charm archive")
+	}
+	if name := archive.Meta().Name; name != curl.Name {
+		return state.RepositoryCharm{}, errors.NotValidf("charm archive named %q for URL %q", name, curl)
+	}
+	if _, err := tempFile.Seek(0, 0); err != nil {
+		return state.RepositoryCharm{}, errors.Trace(err)
+	}
+	return st.PublishRepositoryCharm(curl, tempFile, size, hex.EncodeToString(hasher.Sum(nil)), user)
+}
+
+func repositoryCharmToParams(ch state.RepositoryCharm) params.RepositoryCharm {
+	return params.RepositoryCharm{
+		URL:         ch.URL.String(),
+		Size:        ch.Size,
+		SHA256:      ch.SHA256,
+		Published:   ch.Published,
+		PublishedBy: ch.PublishedBy,
+	}
+}
+
+// sendJSON sends a JSON-encoded result.
+func (h *charmRepositoryHandler) sendJSON(w http.ResponseWriter, statusCode int, result interface{}) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		logger.Errorf("failed to serialize the result (%v): %v", result, err)
+		return
+	}
+	w.Header().Set("Content-Type", apihttp.CTypeJSON)
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+// sendError sends a JSON-encoded error response.
+func (h *charmRepositoryHandler) sendError(w http.ResponseWriter, statusCode int, message string) {
+	h.sendJSON(w, statusCode, &params.Error{Message: message})
+}
+
+// sendServerError sends a JSON-encoded error response including the
+// error code.
+func (h *charmRepositoryHandler) sendServerError(w http.ResponseWriter, err error) {
+	h.sendJSON(w, statusForError(err), common.ServerError(err))
+}
@@ -0,0 +1,197 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testcharms"
+)
+
+type charmRepositorySuite struct {
+	userAuthHttpSuite
+}
+
+var _ = gc.Suite(&charmRepositorySuite{})
+
+func (s *charmRepositorySuite) SetUpTest(c *gc.C) {
+	s.userAuthHttpSuite.SetUpTest(c)
+	s.archiveContentType = "application/zip"
+}
+
+func (s *charmRepositorySuite) repositoryURL(c *gc.C, curl string) string {
+	uri := s.baseURL(c)
+	uri.Path = fmt.Sprintf("/environment/%s/charmrepository", s.State.EnvironUUID())
+	uri.RawQuery = url.Values{"url": {curl}}.Encode()
+	return uri.String()
+}
+
+func (s *charmRepositorySuite) publish(c *gc.C, curl, charmName string) *http.Response {
+	archive := testcharms.Repo.CharmArchive(c.MkDir(), charmName)
+	resp, err := s.uploadRequest(c, s.repositoryURL(c, curl), true, archive.Path)
+	c.Assert(err, jc.ErrorIsNil)
+	return resp
+}
+
+func (s *charmRepositorySuite) checkErrorResponse(c *gc.C, resp *http.Response, statusCode int, msg string) {
+	c.Check(resp.StatusCode, gc.Equals, statusCode)
+	var failure params.Error
+	err := json.NewDecoder(resp.Body).Decode(&failure)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(&failure, gc.ErrorMatches, msg)
+}
+
+func (s *charmRepositorySuite) TestPublishRequiresAuth(c *gc.C) {
+	resp, err := s.sendRequest(c, "", "", "POST", s.repositoryURL(c, "cs:quantal/dummy-1"), "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+}
+
+func (s *charmRepositorySuite) TestInvalidHTTPMethods(c *gc.C) {
+	for _, method := range []string{"PUT", "DELETE", "OPTIONS"} {
+		c.Logf("testing HTTP method: %s", method)
+		resp, err := s.authRequest(c, method, s.repositoryURL(c, "cs:quantal/dummy-1"), "", nil)
+		c.Assert(err, jc.ErrorIsNil)
+		s.checkErrorResponse(c, resp, http.StatusMethodNotAllowed, `unsupported method: "`+method+`"`)
+		resp.Body.Close()
+	}
+}
+
+func (s *charmRepositorySuite) TestPublish(c *gc.C) {
+	resp := s.publish(c, "cs:quantal/dummy-3", "dummy")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+	var result params.RepositoryCharm
+	err := json.NewDecoder(resp.Body).Decode(&result)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(result.URL, gc.Equals, "cs:quantal/dummy-3")
+	c.Check(result.PublishedBy, gc.Equals, s.userTag.Name())
+
+	ch, err := s.State.RepositoryCharm(charm.MustParseURL("cs:quantal/dummy-3"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ch.SHA256, gc.Equals, result.SHA256)
+}
+
+func (s *charmRepositorySuite) TestPublishNameMismatch(c *gc.C) {
+	resp := s.publish(c, "cs:quantal/mysql-1", "dummy")
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusBadRequest, `charm archive named "dummy" for URL "cs:quantal/mysql-1" not valid`)
+}
+
+func (s *charmRepositorySuite) TestPublishWithoutRevision(c *gc.C) {
+	resp := s.publish(c, "cs:quantal/dummy", "dummy")
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusBadRequest, `.*charm URL "cs:quantal/dummy" without revision not valid`)
+}
+
+func (s *charmRepositorySuite) TestPublishExisting(c *gc.C) {
+	resp := s.publish(c, "cs:quantal/dummy-1", "dummy")
+	resp.Body.Close()
+	resp = s.publish(c, "cs:quantal/dummy-1", "dummy")
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusConflict, `.*charm already exists`)
+}
+
+func (s *charmRepositorySuite) TestPublishRequiresStateServerEnvironment(c *gc.C) {
+	s.setupOtherEnvironment(c)
+	uri := s.baseURL(c)
+	uri.Path = fmt.Sprintf("/environment/%s/charmrepository", s.envUUID)
+	uri.RawQuery = "url=cs:quantal/dummy-1"
+	resp, err := s.authRequest(c, "POST", uri.String(), "application/zip", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusNotFound, `requested environment ".*" is not the state server environment`)
+}
+
+func (s *charmRepositorySuite) TestPublishBlocked(c *gc.C) {
+	err := s.State.SwitchBlockOn(state.ChangeBlock, "TestPublishBlocked")
+	c.Assert(err, jc.ErrorIsNil)
+	resp := s.publish(c, "cs:quantal/dummy-1", "dummy")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusInternalServerError)
+	var failure params.Error
+	err = json.NewDecoder(resp.Body).Decode(&failure)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(params.IsCodeOperationBlocked(&failure), jc.IsTrue)
+}
+
+func (s *charmRepositorySuite) TestList(c *gc.C) {
+	for _, curl := range []string{"cs:quantal/dummy-1", "cs:quantal/dummy-2", "cs:trusty/dummy-1"} {
+		resp := s.publish(c, curl, "dummy")
+		resp.Body.Close()
+	}
+
+	resp, err := s.authRequest(c, "GET", s.repositoryURL(c, "cs:quantal/dummy"), "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+	var result params.RepositoryCharmsResult
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Charms, gc.HasLen, 2)
+	c.Assert(result.Charms[0].URL, gc.Equals, "cs:quantal/dummy-1")
+	c.Assert(result.Charms[1].URL, gc.Equals, "cs:quantal/dummy-2")
+}
+
+func (s *charmRepositorySuite) TestDownload(c *gc.C) {
+	resp := s.publish(c, "cs:quantal/dummy-1", "dummy")
+	resp.Body.Close()
+
+	resp, err := s.authRequest(c, "GET", s.repositoryURL(c, "cs:quantal/dummy-1"), "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), gc.Equals, "application/zip")
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, jc.ErrorIsNil)
+	hash := sha256.Sum256(body)
+	c.Assert(resp.Header.Get("Digest"), gc.Equals, "SHA-256="+base64.StdEncoding.EncodeToString(hash[:]))
+
+	ch, err := s.State.RepositoryCharm(charm.MustParseURL("cs:quantal/dummy-1"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ch.SHA256, gc.Equals, hex.EncodeToString(hash[:]))
+}
+
+func (s *charmRepositorySuite) TestDownloadRequiresAuth(c *gc.C) {
+	resp := s.publish(c, "cs:quantal/dummy-1", "dummy")
+	resp.Body.Close()
+
+	resp, err := s.sendRequest(c, "", "", "GET", s.repositoryURL(c, "cs:quantal/dummy-1"), "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+}
+
+func (s *charmRepositorySuite) TestDownloadAnonymousAccess(c *gc.C) {
+	resp := s.publish(c, "cs:quantal/dummy-1", "dummy")
+	resp.Body.Close()
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{"charm-repository-anonymous-access": true}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	resp, err = s.sendRequest(c, "", "", "GET", s.repositoryURL(c, "cs:quantal/dummy-1"), "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+}
+
+func (s *charmRepositorySuite) TestDownloadNotPublished(c *gc.C) {
+	resp, err := s.authRequest(c, "GET", s.repositoryURL(c, "cs:quantal/dummy-1"), "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusNotFound, `charm "cs:quantal/dummy-1" in repository not found`)
+}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/charmrepository"
+	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/state"
 )
 
@@ -57,13 +59,17 @@ func (api *CharmRevisionUpdaterAPI) UpdateLatestRevisions() (params.ErrorResult,
 		return params.ErrorResult{Error: common.ServerError(err)}, nil
 	}
 	uuid := env.UUID()
+	envConfig, err := api.state.EnvironConfig()
+	if err != nil {
+		return params.ErrorResult{Error: common.ServerError(err)}, nil
+	}
 
 	deployedCharms, err := fetchAllDeployedCharms(api.state)
 	if err != nil {
 		return params.ErrorResult{Error: common.ServerError(err)}, nil
 	}
 	// Look up the revision information for all the deployed charms.
-	curls, err := retrieveLatestCharmInfo(deployedCharms, uuid)
+	curls, err := retrieveLatestCharmInfo(deployedCharms, uuid, envConfig)
 	if err != nil {
 		return params.ErrorResult{Error: common.ServerError(err)}, nil
 	}
@@ -98,9 +104,10 @@ func fetchAllDeployedCharms(st *state.State) (map[string]*charm.URL, error) {
 // It is defined at top level for testing purposes.
 var NewCharmStore = charmrepo.NewCharmStore
 
-// retrieveLatestCharmInfo looks up the charm store to return the charm URLs for the
+// retrieveLatestCharmInfo looks up the charm store, or the controller charm
+// repository if the environment uses one, to return the charm URLs for the
 // latest revision of the deployed charms.
-func retrieveLatestCharmInfo(deployedCharms map[string]*charm.URL, uuid string) ([]*charm.URL, error) {
+func retrieveLatestCharmInfo(deployedCharms map[string]*charm.URL, uuid string, envConfig *config.Config) ([]*charm.URL, error) {
 	var curls []*charm.URL
 	for _, curl := range deployedCharms {
 		if curl.Schema == "local" {
@@ -114,10 +121,19 @@ func retrieveLatestCharmInfo(deployedCharms map[string]*charm.URL, uuid string)
 
 	// Do a bulk call to get the revision info for all charms.
 	logger.Infof("retrieving revision information for %d charms", len(curls))
-	repo := NewCharmStore(charmrepo.NewCharmStoreParams{})
-	repo = repo.(*charmrepo.CharmStore).WithJujuAttrs(map[string]string{
-		"environment_uuid": uuid,
-	})
+	var repo charmrepo.Interface
+	if envConfig.CharmRepositoryURL() != "" {
+		var err error
+		repo, err = charmrepository.NewForEnviron(envConfig)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	} else {
+		repo = NewCharmStore(charmrepo.NewCharmStoreParams{})
+		repo = repo.(*charmrepo.CharmStore).WithJujuAttrs(map[string]string{
+			"environment_uuid": uuid,
+		})
+	}
 	revInfo, err := repo.Latest(curls...)
 	if err != nil {
 		err = errors.Annotate(err, "finding charm revision info")
@@ -6,9 +6,11 @@ package charmrevisionupdater_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
 
 	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
 	gc "gopkg.in/check.v1"
 	"gopkg.in/juju/charm.v5"
 	"gopkg.in/juju/charm.v5/charmrepo"
@@ -19,6 +21,7 @@ import (
 	apiservertesting "github.com/juju/juju/apiserver/testing"
 	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/testcharms"
 )
 
 type charmVersionSuite struct {
@@ -173,3 +176,42 @@ func (s *charmVersionSuite) TestEnvironmentUUIDUsed(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(header.Get(charmrepo.JujuMetadataHTTPHeader), gc.Equals, "environment_uuid="+env.UUID())
 }
+
+func (s *charmVersionSuite) TestUpdateRevisionsFromRepository(c *gc.C) {
+	s.AddMachine(c, "0", state.JobManageEnviron)
+	s.SetupScenario(c)
+
+	for _, url := range []string{"cs:quantal/mysql-30", "cs:quantal/wordpress-26"} {
+		curl := charm.MustParseURL(url)
+		archive := testcharms.Repo.CharmArchive(c.MkDir(), curl.Name)
+		f, err := os.Open(archive.Path)
+		c.Assert(err, jc.ErrorIsNil)
+		hash, size, err := utils.ReadSHA256(f)
+		c.Assert(err, jc.ErrorIsNil)
+		_, err = f.Seek(0, 0)
+		c.Assert(err, jc.ErrorIsNil)
+		_, err = s.State.PublishRepositoryCharm(curl, f, size, hash, s.AdminUserTag(c))
+		f.Close()
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	repositoryURL := "https://" + s.APIInfo(c).Addrs[0] + "/charmrepository"
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"charm-repository-url":              repositoryURL,
+		"charm-repository-anonymous-access": true,
+	}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := s.charmrevisionupdater.UpdateLatestRevisions()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Error, gc.IsNil)
+
+	// The latest revisions come from the repository, not the charm store.
+	curl := charm.MustParseURL("cs:quantal/mysql")
+	pending, err := s.State.LatestPlaceholderCharm(curl)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(pending.String(), gc.Equals, "cs:quantal/mysql-30")
+
+	curl = charm.MustParseURL("cs:quantal/wordpress")
+	_, err = s.State.LatestPlaceholderCharm(curl)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
@@ -219,6 +219,14 @@ func (h *charmsHandler) processPost(r *http.Request, st *state.State) (*charm.UR
 	if err != nil {
 		return nil, fmt.Errorf("invalid charm archive: %v", err)
 	}
+	envConfig, err := st.EnvironConfig()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	signer, err := service.VerifyCharmSignature(envConfig, tempFile.Name())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	// We got it, now let's reserve a charm URL for it in state.
 	archiveURL := &charm.URL{
 		Schema:   "local",
@@ -232,7 +240,7 @@ func (h *charmsHandler) processPost(r *http.Request, st *state.State) (*charm.UR
 	}
 	// Now we need to repackage it with the reserved URL, upload it to
 	// provider storage and update the state.
-	err = h.repackageAndUploadCharm(st, archive, preparedURL)
+	err = h.repackageAndUploadCharm(st, archive, preparedURL, signer)
 	if err != nil {
 		return nil, err
 	}
@@ -329,8 +337,9 @@ func (d byDepth) Less(i, j int) bool { return depth(d[i]) < depth(d[j]) }
 
 // repackageAndUploadCharm expands the given charm archive to a
 // temporary directoy, repackages it with the given curl's revision,
-// then uploads it to storage, and finally updates the state.
-func (h *charmsHandler) repackageAndUploadCharm(st *state.State, archive *charm.CharmArchive, curl *charm.URL) error {
+// then uploads it to storage, and finally updates the state, recording
+// the given signer of the archive.
+func (h *charmsHandler) repackageAndUploadCharm(st *state.State, archive *charm.CharmArchive, curl *charm.URL, signer string) error {
 	// Create a temp dir to contain the extracted charm dir.
 	tempDir, err := ioutil.TempDir("", "charm-download")
 	if err != nil {
@@ -366,6 +375,7 @@ func (h *charmsHandler) repackageAndUploadCharm(st *state.State, archive *charm.
 		&repackagedArchive,
 		int64(repackagedArchive.Len()),
 		bundleSHA256,
+		signer,
 	)
 }
 
@@ -392,25 +402,36 @@ func (h *charmsHandler) processGet(r *http.Request, st *state.State) (string, st
 		filePath = path.Clean(file)
 	}
 
+	charmArchivePath, err := cachedCharmArchive(st, h.dataDir, curl)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	return charmArchivePath, filePath, nil
+}
+
+// cachedCharmArchive returns the path of the archive of the given
+// charm in the charm cache under dataDir, first downloading it from
+// the environment storage if it is not yet cached.
+func cachedCharmArchive(st *state.State, dataDir string, curl *charm.URL) (string, error) {
 	// Prepare the bundle directories.
-	name := charm.Quote(curlString)
-	charmArchivePath := filepath.Join(h.dataDir, "charm-get-cache", name+".zip")
+	name := charm.Quote(curl.String())
+	charmArchivePath := filepath.Join(dataDir, "charm-get-cache", name+".zip")
 
 	// Check if the charm archive is already in the cache.
 	if _, err := os.Stat(charmArchivePath); os.IsNotExist(err) {
 		// Download the charm archive and save it to the cache.
-		if err = h.downloadCharm(st, curl, charmArchivePath); err != nil {
-			return "", "", errors.Annotate(err, "unable to retrieve and save the charm")
+		if err = downloadCharm(st, curl, charmArchivePath); err != nil {
+			return "", errors.Annotate(err, "unable to retrieve and save the charm")
 		}
 	} else if err != nil {
-		return "", "", errors.Annotate(err, "cannot access the charms cache")
+		return "", errors.Annotate(err, "cannot access the charms cache")
 	}
-	return charmArchivePath, filePath, nil
+	return charmArchivePath, nil
 }
 
 // downloadCharm downloads the given charm name from the provider storage and
 // saves the corresponding zip archive to the given charmArchivePath.
-func (h *charmsHandler) downloadCharm(st *state.State, curl *charm.URL, charmArchivePath string) error {
+func downloadCharm(st *state.State, curl *charm.URL, charmArchivePath string) error {
 	storage := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
 	ch, err := st.Charm(curl)
 	if err != nil {
@@ -444,6 +465,18 @@ func (h *charmsHandler) downloadCharm(st *state.State, curl *charm.URL, charmArc
 		return errors.Annotate(err, "error processing charm archive download")
 	}
 	tempCharmArchive.Close()
+
+	// The archive is verified again before being used, in case the
+	// environment storage has been tampered with.
+	envConfig, err := st.EnvironConfig()
+	if err != nil {
+		defer cleanupFile(tempCharmArchive)
+		return errors.Trace(err)
+	}
+	if _, err := service.VerifyCharmSignature(envConfig, tempCharmArchive.Name()); err != nil {
+		defer cleanupFile(tempCharmArchive)
+		return errors.Trace(err)
+	}
 	if err = os.Rename(tempCharmArchive.Name(), charmArchivePath); err != nil {
 		defer cleanupFile(tempCharmArchive)
 		return errors.Annotate(err, "error renaming the charm archive")
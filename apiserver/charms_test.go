@@ -16,11 +16,13 @@ import (
 
 	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils"
+	"golang.org/x/crypto/openpgp"
 	gc "gopkg.in/check.v1"
 	"gopkg.in/juju/charm.v5"
 
 	apihttp "github.com/juju/juju/apiserver/http"
 	"github.com/juju/juju/apiserver/params"
+	charmsigtesting "github.com/juju/juju/charmsig/testing"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/storage"
 	"github.com/juju/juju/testcharms"
@@ -276,6 +278,67 @@ func (s *charmsSuite) TestUploadRepackagesNestedArchives(c *gc.C) {
 	c.Assert(bundle.Config(), jc.DeepEquals, sch.Config())
 }
 
+func (s *charmsSuite) requireSignedCharms(c *gc.C) *openpgp.Entity {
+	key, publicKey := charmsigtesting.NewKey(c, "Charmer", "charmer@example.com")
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{"charm-signing-keys": publicKey}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	return key
+}
+
+func (s *charmsSuite) TestUploadRecordsSigner(c *gc.C) {
+	key := s.requireSignedCharms(c)
+	dir := testcharms.Repo.ClonedDirPath(c.MkDir(), "dummy")
+	archivePath := charmsigtesting.SignedArchive(c, dir, key)
+
+	resp, err := s.uploadRequest(c, s.charmsURI(c, "?series=quantal"), true, archivePath)
+	c.Assert(err, jc.ErrorIsNil)
+	expectedURL := charm.MustParseURL("local:quantal/dummy-1")
+	s.assertUploadResponse(c, resp, expectedURL.String())
+	sch, err := s.State.Charm(expectedURL)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(sch.Signer(), gc.Equals, "Charmer <charmer@example.com>")
+
+	// The repackaged archive is still signed.
+	uri := s.charmsURI(c, "?url=local:quantal/dummy-1&file=revision")
+	resp, err = s.authRequest(c, "GET", uri, "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertGetFileResponse(c, resp, "1", "text/plain; charset=utf-8")
+}
+
+func (s *charmsSuite) TestUploadRejectsUnsignedCharm(c *gc.C) {
+	s.requireSignedCharms(c)
+	ch := testcharms.Repo.CharmArchive(c.MkDir(), "dummy")
+	resp, err := s.uploadRequest(c, s.charmsURI(c, "?series=quantal"), true, ch.Path)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, "charm is not signed")
+	_, err = s.State.Charm(charm.MustParseURL("local:quantal/dummy-1"))
+	c.Assert(err, gc.ErrorMatches, `charm "local:quantal/dummy-1" not found`)
+}
+
+func (s *charmsSuite) TestUploadRejectsUntrustedSignature(c *gc.C) {
+	s.requireSignedCharms(c)
+	other, _ := charmsigtesting.NewKey(c, "Mallory", "mallory@example.com")
+	dir := testcharms.Repo.ClonedDirPath(c.MkDir(), "dummy")
+	archivePath := charmsigtesting.SignedArchive(c, dir, other)
+	resp, err := s.uploadRequest(c, s.charmsURI(c, "?series=quantal"), true, archivePath)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, "charm signature not verified: .*")
+}
+
+func (s *charmsSuite) TestGetVerifiesSignature(c *gc.C) {
+	// A charm added before signatures were required is not
+	// served once they are.
+	ch := testcharms.Repo.CharmArchive(c.MkDir(), "dummy")
+	_, err := s.uploadRequest(c, s.charmsURI(c, "?series=quantal"), true, ch.Path)
+	c.Assert(err, jc.ErrorIsNil)
+	s.requireSignedCharms(c)
+
+	uri := s.charmsURI(c, "?url=local:quantal/dummy-1&file=revision")
+	resp, err := s.authRequest(c, "GET", uri, "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, ".*charm is not signed")
+}
+
 func (s *charmsSuite) TestGetRequiresCharmURL(c *gc.C) {
 	uri := s.charmsURI(c, "?file=hooks/install")
 	resp, err := s.authRequest(c, "GET", uri, "", nil)
@@ -315,6 +315,12 @@ var scenarioStatus = &api.Status{
 		},
 	},
 	Networks: map[string]api.NetworkStatus{},
+	Certificates: api.CertificatesStatus{
+		CACertificates: []api.CACertificateStatus{{
+			Subject: coretesting.CACertX509.Subject.CommonName,
+			Expiry:  coretesting.CACertX509.NotAfter.UTC(),
+		}},
+	},
 }
 
 // setUpScenario makes an environment scenario suitable for
@@ -17,6 +17,7 @@ import (
 	"github.com/juju/juju/apiserver/highavailability"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/apiserver/service"
+	"github.com/juju/juju/cloudconfig/cloudinit"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/manual"
 	"github.com/juju/juju/instance"
@@ -28,6 +29,8 @@ import (
 
 func init() {
 	common.RegisterStandardFacade("Client", 0, NewClient)
+	// Version 1 filters FullStatus by status, machine and relation.
+	common.RegisterStandardFacade("Client", 1, NewClient)
 }
 
 var logger = loggo.GetLogger("juju.apiserver.client")
@@ -65,6 +68,13 @@ func NewClient(st *state.State, resources *common.Resources, authorizer common.A
 		check: common.NewBlockChecker(st)}, nil
 }
 
+// authUser returns the user making the API call, which is recorded
+// as the author of any config changes made.
+func (c *Client) authUser() names.UserTag {
+	user, _ := c.api.auth.GetAuthTag().(names.UserTag)
+	return user
+}
+
 func (c *Client) WatchAll() (params.AllWatcherId, error) {
 	w := c.api.state.Watch()
 	return params.AllWatcherId{
@@ -85,7 +95,7 @@ func (c *Client) ServiceSet(p params.ServiceSet) error {
 	if err != nil {
 		return err
 	}
-	return service.ServiceSetSettingsStrings(svc, p.Options)
+	return service.ServiceSetSettingsStrings(svc, c.authUser(), p.Options)
 }
 
 // NewServiceSetForClientAPI implements the server side of
@@ -101,7 +111,7 @@ func (c *Client) NewServiceSetForClientAPI(p params.ServiceSet) error {
 	if err != nil {
 		return err
 	}
-	return newServiceSetSettingsStringsForClientAPI(svc, p.Options)
+	return newServiceSetSettingsStringsForClientAPI(svc, c.authUser(), p.Options)
 }
 
 // ServiceUnset implements the server side of Client.ServiceUnset.
@@ -118,7 +128,7 @@ func (c *Client) ServiceUnset(p params.ServiceUnset) error {
 	for _, option := range p.Options {
 		settings[option] = nil
 	}
-	return svc.UpdateConfigSettings(settings)
+	return svc.UpdateConfigSettingsAs(c.authUser(), settings)
 }
 
 // ServiceSetYAML implements the server side of Client.ServerSetYAML.
@@ -131,7 +141,7 @@ func (c *Client) ServiceSetYAML(p params.ServiceSetYAML) error {
 	if err != nil {
 		return err
 	}
-	return serviceSetSettingsYAML(svc, p.Config)
+	return serviceSetSettingsYAML(svc, c.authUser(), p.Config)
 }
 
 // ServiceCharmRelations implements the server side of Client.ServiceCharmRelations.
@@ -295,11 +305,11 @@ func (c *Client) ServiceUpdate(args params.ServiceUpdate) error {
 	}
 	// Set up service's settings.
 	if args.SettingsYAML != "" {
-		if err = serviceSetSettingsYAML(svc, args.SettingsYAML); err != nil {
+		if err = serviceSetSettingsYAML(svc, c.authUser(), args.SettingsYAML); err != nil {
 			return err
 		}
 	} else if len(args.SettingsStrings) > 0 {
-		if err = service.ServiceSetSettingsStrings(svc, args.SettingsStrings); err != nil {
+		if err = service.ServiceSetSettingsStrings(svc, c.authUser(), args.SettingsStrings); err != nil {
 			return err
 		}
 	}
@@ -352,8 +362,9 @@ func (c *Client) serviceSetCharm1dot16(service *state.Service, curl *charm.URL,
 }
 
 // serviceSetSettingsYAML updates the settings for the given service,
-// taking the configuration from a YAML string.
-func serviceSetSettingsYAML(service *state.Service, settings string) error {
+// taking the configuration from a YAML string. The change is recorded
+// in the service's config history as made by author.
+func serviceSetSettingsYAML(service *state.Service, author names.UserTag, settings string) error {
 	ch, _, err := service.Charm()
 	if err != nil {
 		return err
@@ -362,7 +373,7 @@ func serviceSetSettingsYAML(service *state.Service, settings string) error {
 	if err != nil {
 		return err
 	}
-	return service.UpdateConfigSettings(changes)
+	return service.UpdateConfigSettingsAs(author, changes)
 }
 
 // newServiceSetSettingsStringsForClientAPI updates the settings for the given
@@ -370,7 +381,7 @@ func serviceSetSettingsYAML(service *state.Service, settings string) error {
 //
 // TODO(Nate): replace serviceSetSettingsStrings with this onces the GUI no
 // longer expects to be able to unset values by sending an empty string.
-func newServiceSetSettingsStringsForClientAPI(service *state.Service, settings map[string]string) error {
+func newServiceSetSettingsStringsForClientAPI(service *state.Service, author names.UserTag, settings map[string]string) error {
 	ch, _, err := service.Charm()
 	if err != nil {
 		return err
@@ -382,7 +393,7 @@ func newServiceSetSettingsStringsForClientAPI(service *state.Service, settings m
 		return err
 	}
 
-	return service.UpdateConfigSettings(changes)
+	return service.UpdateConfigSettingsAs(author, changes)
 }
 
 // ServiceSetCharm sets the charm for a given service.
@@ -636,6 +647,12 @@ func (c *Client) addOneMachine(p params.AddMachineParams) (*state.Machine, error
 		placementDirective = p.Placement.Directive
 	}
 
+	if p.CloudInitUserData != "" {
+		if _, err := cloudinit.ParseUserData(p.CloudInitUserData); err != nil {
+			return nil, errors.Annotate(err, "invalid cloud-init user data")
+		}
+	}
+
 	jobs, err := common.StateJobs(p.Jobs)
 	if err != nil {
 		return nil, err
@@ -649,6 +666,7 @@ func (c *Client) addOneMachine(p params.AddMachineParams) (*state.Machine, error
 		HardwareCharacteristics: p.HardwareCharacteristics,
 		Addresses:               params.NetworkAddresses(p.Addrs),
 		Placement:               placementDirective,
+		CloudInitUserData:       p.CloudInitUserData,
 	}
 	if p.ContainerType == "" {
 		return c.api.state.AddOneMachine(template)
@@ -656,7 +674,10 @@ func (c *Client) addOneMachine(p params.AddMachineParams) (*state.Machine, error
 	if p.ParentId != "" {
 		return c.api.state.AddMachineInsideMachine(template, p.ParentId, p.ContainerType)
 	}
-	return c.api.state.AddMachineInsideNewMachine(template, template, p.ContainerType)
+	// The user data is meant for the container, not its new host.
+	parentTemplate := template
+	parentTemplate.CloudInitUserData = ""
+	return c.api.state.AddMachineInsideNewMachine(template, parentTemplate, p.ContainerType)
 }
 
 // ProvisioningScript returns a shell script that, when run,
@@ -895,11 +916,11 @@ func (c *Client) AgentVersion() (params.AgentVersionResult, error) {
 func (c *Client) EnvironmentGet() (params.EnvironmentConfigResults, error) {
 	result := params.EnvironmentConfigResults{}
 	// Get the existing environment config from the state.
-	config, err := c.api.state.EnvironConfig()
+	cfg, err := c.api.state.EnvironConfig()
 	if err != nil {
 		return result, err
 	}
-	result.Config = config.AllAttrs()
+	result.Config = config.RedactSecrets(cfg.AllAttrs())
 	return result, nil
 }
 
@@ -924,7 +945,7 @@ func (c *Client) EnvironmentSet(args params.EnvironmentSet) error {
 	// TODO(waigani) 2014-3-11 #1167616
 	// Add a txn retry loop to ensure that the settings on disk have not
 	// changed underneath us.
-	return c.api.state.UpdateEnvironConfig(attrs, nil, checkAgentVersion)
+	return c.api.state.UpdateEnvironConfigAs(c.authUser(), attrs, nil, checkAgentVersion)
 }
 
 // EnvironmentUnset implements the server-side part of the
@@ -936,7 +957,7 @@ func (c *Client) EnvironmentUnset(args params.EnvironmentUnset) error {
 	// TODO(waigani) 2014-3-11 #1167616
 	// Add a txn retry loop to ensure that the settings on disk have not
 	// changed underneath us.
-	return c.api.state.UpdateEnvironConfig(nil, args.Keys, nil)
+	return c.api.state.UpdateEnvironConfigAs(c.authUser(), nil, args.Keys, nil)
 }
 
 // SetEnvironAgentVersion sets the environment agent version.
@@ -633,6 +633,32 @@ func (s *clientSuite) TestClientServiceSet(c *gc.C) {
 	})
 }
 
+func (s *serverSuite) TestClientServiceSetRecordsHistory(c *gc.C) {
+	dummy := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	err := s.client.ServiceSet(params.ServiceSet{
+		ServiceName: "dummy",
+		Options:     map[string]string{"title": "foobar"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.client.ServiceUnset(params.ServiceUnset{
+		ServiceName: "dummy",
+		Options:     []string{"title"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := dummy.ConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 2)
+	for _, rev := range history {
+		c.Check(rev.Author, gc.Equals, s.AdminUserTag(c).Name())
+	}
+	c.Assert(history[0].Changes, jc.DeepEquals, []state.ItemChange{{
+		Type:     state.ItemDeleted,
+		Key:      "title",
+		OldValue: "foobar",
+	}})
+}
+
 func (s *serverSuite) assertServiceSetBlocked(c *gc.C, dummy *state.Service, msg string) {
 	err := s.client.ServiceSet(params.ServiceSet{
 		ServiceName: "dummy",
@@ -2655,6 +2681,15 @@ func (s *serverSuite) TestClientEnvironmentGet(c *gc.C) {
 	c.Assert(result.Config, gc.DeepEquals, envConfig.AllAttrs())
 }
 
+func (s *serverSuite) TestClientEnvironmentGetRedactsLDAPBindPassword(c *gc.C) {
+	ldapConfig := "bind-dn: cn=juju\nbind-password: sekrit\nurl: ldap://ldap.example.com\n"
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{"ldap-config": ldapConfig}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	result, err := s.client.EnvironmentGet()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Config["ldap-config"], gc.Equals, "bind-dn: cn=juju\nbind-password: not available\nurl: ldap://ldap.example.com\n")
+}
+
 func (s *serverSuite) assertEnvValue(c *gc.C, key string, expected interface{}) {
 	envConfig, err := s.State.EnvironConfig()
 	c.Assert(err, jc.ErrorIsNil)
@@ -2687,6 +2722,21 @@ func (s *serverSuite) TestClientEnvironmentSet(c *gc.C) {
 	s.assertEnvValue(c, "other-key", "other value")
 }
 
+func (s *serverSuite) TestClientEnvironmentSetRecordsHistory(c *gc.C) {
+	err := s.client.EnvironmentSet(params.EnvironmentSet{
+		Config: map[string]interface{}{"some-key": "value"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	history, err := s.State.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history[0].Author, gc.Equals, s.AdminUserTag(c).Name())
+	c.Assert(history[0].Changes, jc.DeepEquals, []state.ItemChange{{
+		Type:     state.ItemAdded,
+		Key:      "some-key",
+		NewValue: "value",
+	}})
+}
+
 func (s *serverSuite) TestClientEnvironmentSetImmutable(c *gc.C) {
 	// The various immutable config values are tested in
 	// environs/config/config_test.go, so just choosing one here.
@@ -12,6 +12,7 @@ var (
 var (
 	MatchPortRanges = matchPortRanges
 	MatchSubnet     = matchSubnet
+	MatchMachineId  = matchMachineId
 )
 
 // Status exports
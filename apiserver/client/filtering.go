@@ -365,6 +365,61 @@ func matchAgentStatus(patterns []string, status state.Status) (bool, bool, error
 	return false, oneValidStatus, nil
 }
 
+// unitMatchStatus returns whether the unit's agent or workload status
+// is one of the given statuses.
+func unitMatchStatus(u *state.Unit, statuses []string) (bool, error) {
+	if matches, _, err := unitMatchAgentStatus(u, statuses); err != nil || matches {
+		return matches, err
+	}
+	matches, _, err := unitMatchWorkloadStatus(u, statuses)
+	return matches, err
+}
+
+// machineMatchStatus returns whether the machine's status is one of
+// the given statuses.
+func machineMatchStatus(m *state.Machine, statuses []string) (bool, error) {
+	statusInfo, err := m.Status()
+	if err != nil {
+		return false, err
+	}
+	for _, s := range statuses {
+		if statusInfo.Status == state.Status(s) || statusInfo.Status.Matches(state.Status(s)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchMachineId returns whether the machine id is one of the given
+// ids, or that of a container within one of them.
+func matchMachineId(ids []string, machineId string) bool {
+	for _, id := range ids {
+		if machineId == id || strings.HasPrefix(machineId, id+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRelation returns whether any endpoint of the relation matches
+// one of the given endpoints. An endpoint of the form "service:relation"
+// must match exactly; a bare name matches either the relation name or
+// the interface of an endpoint.
+func matchRelation(endpoints []string, relation *state.Relation) bool {
+	for _, ep := range relation.Endpoints() {
+		for _, e := range endpoints {
+			if strings.Contains(e, ":") {
+				if ep.String() == e {
+					return true
+				}
+			} else if ep.Name == e || ep.Interface == e {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type unitMatcher struct {
 	patterns []string
 }
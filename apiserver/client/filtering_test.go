@@ -51,3 +51,11 @@ func (s *filteringUnitTests) TestMatchSubnet(c *gc.C) {
 	c.Check(ok, jc.IsTrue)
 	c.Check(match, jc.IsFalse)
 }
+
+func (s *filteringUnitTests) TestMatchMachineId(c *gc.C) {
+	c.Check(client.MatchMachineId([]string{"1"}, "1"), jc.IsTrue)
+	c.Check(client.MatchMachineId([]string{"1"}, "1/lxc/0"), jc.IsTrue)
+	c.Check(client.MatchMachineId([]string{"2", "1/lxc/0"}, "1/lxc/0/kvm/1"), jc.IsTrue)
+	c.Check(client.MatchMachineId([]string{"1"}, "10"), jc.IsFalse)
+	c.Check(client.MatchMachineId([]string{"1/lxc/0"}, "1"), jc.IsFalse)
+}
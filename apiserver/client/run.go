@@ -12,6 +12,7 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/juju/utils"
+	"github.com/juju/utils/exec"
 	"github.com/juju/utils/set"
 
 	"github.com/juju/juju/agent"
@@ -81,21 +82,152 @@ func (c *Client) getDataDir() string {
 	return dataResource.String()
 }
 
+func (c *Client) getMachineID() string {
+	machineResource, ok := c.api.resources.Get("machineID").(common.StringResource)
+	if !ok {
+		return ""
+	}
+	return machineResource.String()
+}
+
 // Run the commands specified on the machines identified through the
 // list of machines, units and services.
 func (c *Client) Run(run params.RunParams) (results params.RunResults, err error) {
 	if err := c.check.ChangeAllowed(); err != nil {
 		return params.RunResults{}, errors.Trace(err)
 	}
-	units, err := getAllUnitNames(c.api.state, run.Units, run.Services)
+	execParams, err := c.remoteExecParams(run)
 	if err != nil {
 		return results, err
 	}
+	return limitedParallelExecute(c.getDataDir(), execParams, run.MaxConcurrent), nil
+}
+
+// RunOnAllMachines attempts to run the specified command on all the machines.
+func (c *Client) RunOnAllMachines(run params.RunParams) (params.RunResults, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.RunResults{}, errors.Trace(err)
+	}
+	execParams, err := c.allMachinesExecParams(run)
+	if err != nil {
+		return params.RunResults{}, err
+	}
+	return limitedParallelExecute(c.getDataDir(), execParams, run.MaxConcurrent), nil
+}
+
+// StartRun starts running the commands specified on the machines
+// identified through the list of machines, units and services in the
+// background, and returns the id of the job from which the results can
+// be fetched with RunJobResults.
+func (c *Client) StartRun(run params.RunParams) (params.RunJob, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.RunJob{}, errors.Trace(err)
+	}
+	execParams, err := c.remoteExecParams(run)
+	if err != nil {
+		return params.RunJob{}, err
+	}
+	return c.startRunJob(run, execParams)
+}
+
+// StartRunOnAllMachines starts running the specified command on all the
+// machines in the background, as StartRun does.
+func (c *Client) StartRunOnAllMachines(run params.RunParams) (params.RunJob, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.RunJob{}, errors.Trace(err)
+	}
+	execParams, err := c.allMachinesExecParams(run)
+	if err != nil {
+		return params.RunJob{}, err
+	}
+	return c.startRunJob(run, execParams)
+}
+
+// RunJobResults returns the results of the background run job with the
+// given id, skipping those the caller already has.
+func (c *Client) RunJobResults(query params.RunJobQuery) (params.RunJobResults, error) {
+	job, err := c.api.state.RunJob(query.Id)
+	if err != nil {
+		return params.RunJobResults{}, errors.Trace(err)
+	}
+	result := params.RunJobResults{
+		Id:       job.Id(),
+		Commands: job.Commands(),
+		Targets:  job.Targets(),
+		Done:     job.Done(),
+		Error:    job.Error(),
+	}
+	jobResults := job.Results()
+	if query.Skip < 0 || query.Skip > len(jobResults) {
+		return params.RunJobResults{}, errors.NotValidf("skipping %d of %d results", query.Skip, len(jobResults))
+	}
+	for _, jobResult := range jobResults[query.Skip:] {
+		result.Results = append(result.Results, params.RunResult{
+			ExecResponse: exec.ExecResponse{
+				Code:   jobResult.Code,
+				Stdout: jobResult.Stdout,
+				Stderr: jobResult.Stderr,
+			},
+			MachineId: jobResult.MachineId,
+			UnitId:    jobResult.UnitId,
+			Error:     jobResult.Error,
+		})
+	}
+	return result, nil
+}
+
+// startRunJob records a run job for the given parameters, and executes
+// them in the background, adding the result from each target to the job
+// as it completes. The job is run until the API server stops; any
+// targets not yet started by then are reported as failed.
+func (c *Client) startRunJob(run params.RunParams, execParams []*RemoteExec) (params.RunJob, error) {
+	runner, ok := c.api.resources.Get("backgroundRunner").(*common.BackgroundRunner)
+	if !ok {
+		return params.RunJob{}, errors.New("background runs not supported")
+	}
+	job, err := c.api.state.AddRunJob(c.getMachineID(), run.Commands, len(execParams), run.MaxConcurrent)
+	if err != nil {
+		return params.RunJob{}, errors.Trace(err)
+	}
+	dataDir := c.getDataDir()
+	var lock sync.Mutex
+	report := func(result params.RunResult) {
+		lock.Lock()
+		defer lock.Unlock()
+		err := job.AddResult(state.RunJobResult{
+			MachineId: result.MachineId,
+			UnitId:    result.UnitId,
+			Code:      result.Code,
+			Stdout:    result.Stdout,
+			Stderr:    result.Stderr,
+			Error:     result.Error,
+		})
+		if err != nil {
+			logger.Errorf("%v", err)
+		}
+	}
+	err = runner.Go(func(dying <-chan struct{}) {
+		executeEach(dataDir, execParams, run.MaxConcurrent, dying, report)
+	})
+	if err != nil {
+		// The job is marked failed when the server next starts.
+		return params.RunJob{}, errors.Trace(err)
+	}
+	return params.RunJob{Id: job.Id()}, nil
+}
+
+// remoteExecParams returns a RemoteExec for each of the units and
+// machines specified in the run parameters.
+func (c *Client) remoteExecParams(run params.RunParams) ([]*RemoteExec, error) {
+	units, err := getAllUnitNames(c.api.state, run.Units, run.Services)
+	if err != nil {
+		return nil, err
+	}
 	// We want to create a RemoteExec for each unit and each machine.
 	// If we have both a unit and a machine request, we run it twice,
 	// once for the unit inside the exec context using juju-run, and
 	// the other outside the context just using bash.
-	var params []*RemoteExec
+	var execParams []*RemoteExec
 	var quotedCommands = utils.ShQuote(run.Commands)
 	for _, unit := range units {
 		// We know that the unit is both a principal unit, and that it has an
@@ -103,41 +235,39 @@ func (c *Client) Run(run params.RunParams) (results params.RunResults, err error
 		machineId, _ := unit.AssignedMachineId()
 		machine, err := c.api.state.Machine(machineId)
 		if err != nil {
-			return results, err
+			return nil, err
 		}
 		command := fmt.Sprintf("juju-run %s %s", unit.Name(), quotedCommands)
 		execParam := remoteParamsForMachine(machine, command, run.Timeout)
 		execParam.UnitId = unit.Name()
-		params = append(params, execParam)
+		execParams = append(execParams, execParam)
 	}
 	for _, machineId := range run.Machines {
 		machine, err := c.api.state.Machine(machineId)
 		if err != nil {
-			return results, err
+			return nil, err
 		}
 		command := fmt.Sprintf("juju-run --no-context %s", quotedCommands)
 		execParam := remoteParamsForMachine(machine, command, run.Timeout)
-		params = append(params, execParam)
+		execParams = append(execParams, execParam)
 	}
-	return ParallelExecute(c.getDataDir(), params), nil
+	return execParams, nil
 }
 
-// RunOnAllMachines attempts to run the specified command on all the machines.
-func (c *Client) RunOnAllMachines(run params.RunParams) (params.RunResults, error) {
-	if err := c.check.ChangeAllowed(); err != nil {
-		return params.RunResults{}, errors.Trace(err)
-	}
+// allMachinesExecParams returns a RemoteExec for each of the machines
+// in the environment.
+func (c *Client) allMachinesExecParams(run params.RunParams) ([]*RemoteExec, error) {
 	machines, err := c.api.state.AllMachines()
 	if err != nil {
-		return params.RunResults{}, err
+		return nil, err
 	}
-	var params []*RemoteExec
+	var execParams []*RemoteExec
 	quotedCommands := utils.ShQuote(run.Commands)
 	command := fmt.Sprintf("juju-run --no-context %s", quotedCommands)
 	for _, machine := range machines {
-		params = append(params, remoteParamsForMachine(machine, command, run.Timeout))
+		execParams = append(execParams, remoteParamsForMachine(machine, command, run.Timeout))
 	}
-	return ParallelExecute(c.getDataDir(), params), nil
+	return execParams, nil
 }
 
 // RemoteExec extends the standard ssh.ExecParams by providing the machine and
@@ -152,16 +282,55 @@ type RemoteExec struct {
 // ParallelExecute executes all of the requests defined in the params,
 // using the system identity stored in the dataDir.
 func ParallelExecute(dataDir string, runParams []*RemoteExec) params.RunResults {
-	logger.Debugf("exec %#v", runParams)
-	var outstanding sync.WaitGroup
+	return limitedParallelExecute(dataDir, runParams, 0)
+}
+
+// limitedParallelExecute executes all of the requests defined in the
+// params as ParallelExecute does, but no more than maxConcurrent of them
+// at once if maxConcurrent is non-zero.
+func limitedParallelExecute(dataDir string, runParams []*RemoteExec, maxConcurrent int) params.RunResults {
 	var lock sync.Mutex
 	var result []params.RunResult
+	executeEach(dataDir, runParams, maxConcurrent, nil, func(execResponse params.RunResult) {
+		lock.Lock()
+		defer lock.Unlock()
+		result = append(result, execResponse)
+	})
+	sort.Sort(MachineOrder(result))
+	return params.RunResults{result}
+}
+
+// executeEach executes each of the requests defined in the params, no
+// more than maxConcurrent at once if maxConcurrent is non-zero, calling
+// report with the result of each as it completes. Once dying is closed,
+// no more requests are started, and those remaining are reported as
+// failed. It returns once all the requests have completed.
+func executeEach(dataDir string, runParams []*RemoteExec, maxConcurrent int, dying <-chan struct{}, report func(params.RunResult)) {
+	logger.Debugf("exec %#v", runParams)
+	var outstanding sync.WaitGroup
+	var slots chan struct{}
+	if maxConcurrent > 0 {
+		slots = make(chan struct{}, maxConcurrent)
+	}
 	identity := filepath.Join(dataDir, agent.SystemIdentity)
 	for _, param := range runParams {
 		outstanding.Add(1)
 		logger.Debugf("exec on %s: %#v", param.MachineId, *param)
 		param.IdentityFile = identity
+		if !waitForSlot(slots, dying) {
+			outstanding.Done()
+			report(params.RunResult{
+				MachineId: param.MachineId,
+				UnitId:    param.UnitId,
+				Error:     "not run: API server stopping",
+			})
+			continue
+		}
 		go func(param *RemoteExec) {
+			defer outstanding.Done()
+			if slots != nil {
+				defer func() { <-slots }()
+			}
 			response, err := ssh.ExecuteCommandOnMachine(param.ExecParams)
 			logger.Debugf("reponse from %s: %v (err:%v)", param.MachineId, response, err)
 			execResponse := params.RunResult{
@@ -172,17 +341,30 @@ func ParallelExecute(dataDir string, runParams []*RemoteExec) params.RunResults
 			if err != nil {
 				execResponse.Error = fmt.Sprint(err)
 			}
-
-			lock.Lock()
-			defer lock.Unlock()
-			result = append(result, execResponse)
-			outstanding.Done()
+			report(execResponse)
 		}(param)
 	}
-
 	outstanding.Wait()
-	sort.Sort(MachineOrder(result))
-	return params.RunResults{result}
+}
+
+// waitForSlot waits until a request may be started, taking one of the
+// given slots if there are any. It returns false if dying is closed
+// first.
+func waitForSlot(slots chan struct{}, dying <-chan struct{}) bool {
+	select {
+	case <-dying:
+		return false
+	default:
+	}
+	if slots == nil {
+		return true
+	}
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-dying:
+		return false
+	}
 }
 
 // MachineOrder is used to provide the api to sort the results by the machine
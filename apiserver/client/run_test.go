@@ -5,6 +5,7 @@ package client_test
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	gitjujutesting "github.com/juju/testing"
@@ -346,3 +347,104 @@ func (s *runSuite) TestBlockRunMachineAndService(c *gc.C) {
 		})
 	s.AssertBlocked(c, err, "TestBlockRunMachineAndService")
 }
+
+// waitForRunJob polls the run job with the given id until it is done,
+// and returns its results ordered by machine.
+func (s *runSuite) waitForRunJob(c *gc.C, id string) []params.RunResult {
+	apiClient := s.APIState.Client()
+	var results []params.RunResult
+	for a := testing.LongAttempt.Start(); a.Next(); {
+		job, err := apiClient.RunJobResults(id, len(results))
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(job.Id, gc.Equals, id)
+		results = append(results, job.Results...)
+		if job.Done {
+			c.Assert(results, gc.HasLen, job.Targets)
+			sort.Sort(client.MachineOrder(results))
+			return results
+		}
+	}
+	c.Fatalf("run job %s did not finish", id)
+	return nil
+}
+
+func (s *runSuite) TestStartRunMachineAndService(c *gc.C) {
+	s.addMachineWithAddress(c, "10.3.2.1")
+
+	charm := s.AddTestingCharm(c, "dummy")
+	owner := s.Factory.MakeUser(c, nil).Tag()
+	magic, err := s.State.AddService("magic", owner.String(), charm, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	s.addUnit(c, magic)
+	s.addUnit(c, magic)
+
+	s.mockSSH(c, echoInput)
+
+	id, err := s.APIState.Client().StartRun(
+		params.RunParams{
+			Commands:      "hostname",
+			Timeout:       testing.LongWait,
+			Machines:      []string{"0"},
+			Services:      []string{"magic"},
+			MaxConcurrent: 1,
+		})
+	c.Assert(err, jc.ErrorIsNil)
+
+	c.Assert(s.waitForRunJob(c, id), jc.DeepEquals, []params.RunResult{
+		{
+			ExecResponse: exec.ExecResponse{Stdout: []byte(expectedCommand[0])},
+			MachineId:    "0",
+		},
+		{
+			ExecResponse: exec.ExecResponse{Stdout: []byte(expectedCommand[1])},
+			MachineId:    "1",
+			UnitId:       "magic/0",
+		},
+		{
+			ExecResponse: exec.ExecResponse{Stdout: []byte(expectedCommand[2])},
+			MachineId:    "2",
+			UnitId:       "magic/1",
+		},
+	})
+
+	job, err := s.APIState.Client().RunJobResults(id, 3)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(job.Commands, gc.Equals, "hostname")
+	c.Assert(job.Done, jc.IsTrue)
+	c.Assert(job.Results, gc.HasLen, 0)
+}
+
+func (s *runSuite) TestStartRunOnAllMachines(c *gc.C) {
+	s.addMachineWithAddress(c, "10.3.2.1")
+	s.addMachineWithAddress(c, "10.3.2.2")
+
+	s.mockSSH(c, echoInput)
+
+	id, err := s.APIState.Client().StartRunOnAllMachines(params.RunParams{
+		Commands: "hostname",
+		Timeout:  testing.LongWait,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	results := s.waitForRunJob(c, id)
+	c.Assert(results, gc.HasLen, 2)
+	c.Assert(results[0].MachineId, gc.Equals, "0")
+	c.Assert(results[1].MachineId, gc.Equals, "1")
+}
+
+func (s *runSuite) TestBlockStartRun(c *gc.C) {
+	s.addMachineWithAddress(c, "10.3.2.1")
+	s.mockSSH(c, echoInput)
+
+	s.BlockAllChanges(c, "TestBlockStartRun")
+	_, err := s.APIState.Client().StartRun(params.RunParams{
+		Commands: "hostname",
+		Timeout:  testing.LongWait,
+		Machines: []string{"0"},
+	})
+	s.AssertBlocked(c, err, "TestBlockStartRun")
+}
+
+func (s *runSuite) TestRunJobResultsNotFound(c *gc.C) {
+	_, err := s.APIState.Client().RunJobResults("42", 0)
+	c.Assert(err, gc.ErrorMatches, "run job 42 not found")
+}
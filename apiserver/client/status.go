@@ -9,12 +9,14 @@ import (
 	"strings"
 
 	"github.com/juju/errors"
+	"github.com/juju/names"
 	"github.com/juju/utils/set"
 	"gopkg.in/juju/charm.v5"
 	"gopkg.in/juju/charm.v5/hooks"
 
 	"github.com/juju/juju/api"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cert"
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
@@ -62,7 +64,7 @@ func (c *Client) UnitStatusHistory(args params.StatusHistory) (api.UnitStatusHis
 	}
 	statuses := api.UnitStatusHistory{}
 	if args.Kind == params.KindCombined || args.Kind == params.KindWorkload {
-		unitStatuses, err := unit.StatusHistory(size)
+		unitStatuses, err := unit.StatusHistory(state.StatusHistoryFilter{Size: size})
 		if err != nil {
 			return api.UnitStatusHistory{}, errors.Trace(err)
 		}
@@ -81,7 +83,7 @@ func (c *Client) UnitStatusHistory(args params.StatusHistory) (api.UnitStatusHis
 		if !ok {
 			return api.UnitStatusHistory{}, errors.Errorf("cannot obtain agent for %q", args.Name)
 		}
-		agentStatuses, err := agent.StatusHistory(size)
+		agentStatuses, err := agent.StatusHistory(state.StatusHistoryFilter{Size: size})
 		if err != nil {
 			return api.UnitStatusHistory{}, errors.Trace(err)
 		}
@@ -106,6 +108,225 @@ func (c *Client) UnitStatusHistory(args params.StatusHistory) (api.UnitStatusHis
 	return statuses, nil
 }
 
+// historySource yields the past statuses of one kind for a single
+// entity. The current status, if there is one that is not recorded
+// in the history itself, is obtained through current.
+type historySource struct {
+	entity  string
+	kind    params.HistoryKind
+	history func(state.StatusHistoryFilter) ([]state.StatusInfo, error)
+	current func() (state.StatusInfo, error)
+}
+
+type sortableHistoricalStatuses []api.HistoricalStatus
+
+func (s sortableHistoricalStatuses) Len() int {
+	return len(s)
+}
+func (s sortableHistoricalStatuses) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+func (s sortableHistoricalStatuses) Less(i, j int) bool {
+	if s[i].Since == nil || s[j].Since == nil {
+		return s[i].Since == nil && s[j].Since != nil
+	}
+	return s[i].Since.Before(*s[j].Since)
+}
+
+// StatusHistory returns the past statuses of the given kind for a
+// unit, service, machine or relation, oldest first. The statuses of
+// all units of a service are merged into a single timeline.
+func (c *Client) StatusHistory(args params.StatusHistory) (api.StatusHistory, error) {
+	if args.Size < 0 {
+		return api.StatusHistory{}, errors.Errorf("invalid history size: %d", args.Size)
+	}
+	kind := args.Kind
+	if kind == "" {
+		kind = params.KindCombined
+	}
+	sources, err := c.statusHistorySources(args.Name, kind)
+	if err != nil {
+		return api.StatusHistory{}, errors.Trace(err)
+	}
+	filter := state.StatusHistoryFilter{Size: args.Size}
+	if args.From != nil {
+		filter.From = *args.From
+	}
+	if args.To != nil {
+		filter.To = *args.To
+	}
+
+	result := api.StatusHistory{}
+	for _, source := range sources {
+		history, err := source.history(filter)
+		if err != nil {
+			return api.StatusHistory{}, errors.Annotatef(err, "cannot get status history of %q", source.entity)
+		}
+		// The history comes most recent first; statuses set within
+		// the same second must keep their order once sorted.
+		statuses := make([]state.StatusInfo, 0, len(history)+1)
+		for i := len(history) - 1; i >= 0; i-- {
+			statuses = append(statuses, history[i])
+		}
+		if source.current != nil {
+			current, err := source.current()
+			if err != nil {
+				return api.StatusHistory{}, errors.Trace(err)
+			}
+			if statusInRange(current, filter) {
+				statuses = append(statuses, current)
+			}
+		}
+		for _, status := range statuses {
+			result.Statuses = append(result.Statuses, api.HistoricalStatus{
+				Entity: source.entity,
+				Kind:   source.kind,
+				Status: params.Status(status.Status),
+				Info:   status.Message,
+				Data:   status.Data,
+				Since:  status.Since,
+			})
+		}
+	}
+
+	sort.Stable(sortableHistoricalStatuses(result.Statuses))
+	if args.Size > 0 && len(result.Statuses) > args.Size {
+		result.Statuses = result.Statuses[len(result.Statuses)-args.Size:]
+	}
+	return result, nil
+}
+
+// statusInRange reports whether status was set within the time range
+// of filter.
+func statusInRange(status state.StatusInfo, filter state.StatusHistoryFilter) bool {
+	if status.Since == nil {
+		return true
+	}
+	if !filter.From.IsZero() && status.Since.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !status.Since.Before(filter.To) {
+		return false
+	}
+	return true
+}
+
+// statusHistorySources returns the sources of status history of the
+// given kind for the named unit, service, machine or relation.
+func (c *Client) statusHistorySources(name string, kind params.HistoryKind) ([]historySource, error) {
+	st := c.api.state
+	switch {
+	case names.IsValidUnit(name):
+		unit, err := st.Unit(name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return unitHistorySources(unit, kind)
+	case names.IsValidMachine(name):
+		machine, err := st.Machine(name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var sources []historySource
+		if kind == params.KindCombined || kind == params.KindMachine {
+			sources = append(sources, historySource{
+				entity:  name,
+				kind:    params.KindMachine,
+				history: machine.StatusHistory,
+				current: machine.Status,
+			})
+		}
+		if kind == params.KindCombined || kind == params.KindInstance {
+			// Instance statuses are recorded as they are set, so
+			// the current one is already part of the history.
+			sources = append(sources, historySource{
+				entity:  name,
+				kind:    params.KindInstance,
+				history: machine.InstanceStatusHistory,
+			})
+		}
+		if sources == nil {
+			return nil, errors.NotValidf("status type %q for machine %q", kind, name)
+		}
+		return sources, nil
+	case names.IsValidService(name):
+		service, err := st.Service(name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var sources []historySource
+		if kind == params.KindCombined || kind == params.KindService {
+			sources = append(sources, historySource{
+				entity:  name,
+				kind:    params.KindService,
+				history: service.StatusHistory,
+				current: service.Status,
+			})
+		}
+		if kind == params.KindCombined || kind == params.KindWorkload || kind == params.KindAgent {
+			units, err := service.AllUnits()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			for _, unit := range units {
+				unitSources, err := unitHistorySources(unit, kind)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				sources = append(sources, unitSources...)
+			}
+		}
+		if sources == nil {
+			return nil, errors.NotValidf("status type %q for service %q", kind, name)
+		}
+		return sources, nil
+	case strings.Contains(name, ":"):
+		if kind != params.KindCombined && kind != params.KindRelation {
+			return nil, errors.NotValidf("status type %q for relation %q", kind, name)
+		}
+		// The relation may have been removed, but its history is
+		// kept until pruned.
+		return []historySource{{
+			entity: name,
+			kind:   params.KindRelation,
+			history: func(filter state.StatusHistoryFilter) ([]state.StatusInfo, error) {
+				return state.RelationStatusHistory(st, name, filter)
+			},
+		}}, nil
+	}
+	return nil, errors.NotValidf("entity name %q", name)
+}
+
+// unitHistorySources returns the sources of status history of the
+// given kind for unit.
+func unitHistorySources(unit *state.Unit, kind params.HistoryKind) ([]historySource, error) {
+	var sources []historySource
+	if kind == params.KindCombined || kind == params.KindWorkload {
+		sources = append(sources, historySource{
+			entity:  unit.Name(),
+			kind:    params.KindWorkload,
+			history: unit.StatusHistory,
+			current: unit.Status,
+		})
+	}
+	if kind == params.KindCombined || kind == params.KindAgent {
+		agent, ok := unit.Agent().(*state.UnitAgent)
+		if !ok {
+			return nil, errors.Errorf("cannot obtain agent for %q", unit.Name())
+		}
+		sources = append(sources, historySource{
+			entity:  unit.Name(),
+			kind:    params.KindAgent,
+			history: agent.StatusHistory,
+			current: agent.Status,
+		})
+	}
+	if sources == nil {
+		return nil, errors.NotValidf("status type %q for unit %q", kind, unit.Name())
+	}
+	return sources, nil
+}
+
 // FullStatus gives the information needed for juju status over the api
 func (c *Client) FullStatus(args params.StatusParams) (api.Status, error) {
 	cfg, err := c.api.state.EnvironConfig()
@@ -123,6 +344,10 @@ func (c *Client) FullStatus(args params.StatusParams) (api.Status, error) {
 		return noStatus, errors.Annotate(err, "could not fetch relations")
 	} else if context.networks, err = fetchNetworks(c.api.state); err != nil {
 		return noStatus, errors.Annotate(err, "could not fetch networks")
+	} else if context.payloads, err = fetchPayloads(c.api.state); err != nil {
+		return noStatus, errors.Annotate(err, "could not fetch payloads")
+	} else if context.certificates, err = fetchCertificates(c.api.state); err != nil {
+		return noStatus, errors.Annotate(err, "could not fetch certificates")
 	}
 
 	logger.Debugf("Services: %v", context.services)
@@ -197,6 +422,11 @@ func (c *Client) FullStatus(args params.StatusParams) (api.Status, error) {
 			context.machines[status] = filteredList
 		}
 	}
+	if len(args.Statuses) > 0 || len(args.Machines) > 0 || len(args.Relations) > 0 {
+		if err := context.filterByCriteria(args); err != nil {
+			return noStatus, errors.Annotate(err, "could not filter status")
+		}
+	}
 
 	return api.Status{
 		EnvironmentName: cfg.Name(),
@@ -204,9 +434,117 @@ func (c *Client) FullStatus(args params.StatusParams) (api.Status, error) {
 		Services:        context.processServices(),
 		Networks:        context.processNetworks(),
 		Relations:       context.processRelations(),
+		Certificates:    context.certificates,
 	}, nil
 }
 
+// filterByCriteria removes from the context every principal unit that
+// does not match all of the status, machine and relation filters in
+// args, along with the services and machines left unused. Machines are
+// also kept if they match the machine and status filters themselves.
+func (context *statusContext) filterByCriteria(args params.StatusParams) error {
+	statuses := make([]string, len(args.Statuses))
+	for i, s := range args.Statuses {
+		status := state.Status(strings.ToLower(s))
+		if !status.ValidAgentStatus() && !status.ValidWorkloadStatus() {
+			return errors.NotValidf("status %q", s)
+		}
+		statuses[i] = string(status)
+	}
+
+	related := make(set.Strings)
+	for _, relation := range context.getAllRelations() {
+		if matchRelation(args.Relations, relation) {
+			for _, ep := range relation.Endpoints() {
+				related.Add(ep.ServiceName)
+			}
+		}
+	}
+
+	// Subordinates are checked along with their principals: a
+	// principal matches the status filter if it or any of its
+	// subordinates has a matching status.
+	statusPredicate := func(i interface{}) (bool, error) {
+		return unitMatchStatus(i.(*state.Unit), statuses)
+	}
+	unitChainPredicate := UnitChainPredicateFn(statusPredicate, context.unitByName)
+
+	unfilteredSvcs := make(set.Strings)
+	unfilteredMachines := make(set.Strings)
+	for _, unitMap := range context.units {
+		for name, unit := range unitMap {
+			if !unit.IsPrincipal() {
+				continue
+			}
+			machineId, err := unit.AssignedMachineId()
+			if err != nil && !errors.IsNotAssigned(err) {
+				return err
+			}
+			matches := true
+			if len(args.Machines) > 0 && !matchMachineId(args.Machines, machineId) {
+				matches = false
+			} else if len(args.Relations) > 0 && !related.Contains(unit.ServiceName()) {
+				matches = false
+			} else if len(statuses) > 0 {
+				if matches, err = unitChainPredicate(unit); err != nil {
+					return err
+				}
+			}
+			if !matches {
+				delete(unitMap, name)
+				continue
+			}
+			unfilteredSvcs.Add(unit.ServiceName())
+			for _, subName := range unit.SubordinateNames() {
+				unfilteredSvcs.Add(strings.Split(subName, "/")[0])
+			}
+			for machineId != "" {
+				unfilteredMachines.Add(machineId)
+				machineId = state.ParentId(machineId)
+			}
+		}
+	}
+
+	for svcName := range context.services {
+		if !unfilteredSvcs.Contains(svcName) {
+			delete(context.services, svcName)
+		}
+	}
+
+	// Machines that match the machine and status filters are shown
+	// even when none of their units match, unless the status is also
+	// restricted to some relations, which machines take no part in.
+	if len(args.Relations) == 0 {
+		for _, machineList := range context.machines {
+			for _, m := range machineList {
+				if len(args.Machines) > 0 && !matchMachineId(args.Machines, m.Id()) {
+					continue
+				}
+				if len(statuses) > 0 {
+					if matches, err := machineMatchStatus(m, statuses); err != nil {
+						return err
+					} else if !matches {
+						continue
+					}
+				}
+				for id := m.Id(); id != ""; id = state.ParentId(id) {
+					unfilteredMachines.Add(id)
+				}
+			}
+		}
+	}
+	for topId, machineList := range context.machines {
+		filteredList := make([]*state.Machine, 0, len(machineList))
+		for _, m := range machineList {
+			if unfilteredMachines.Contains(m.Id()) {
+				filteredList = append(filteredList, m)
+			}
+		}
+		context.machines[topId] = filteredList
+	}
+	return nil
+}
+
 // Status is a stub version of FullStatus that was introduced in 1.16
 func (c *Client) Status() (api.LegacyStatus, error) {
 	var legacyStatus api.LegacyStatus
@@ -234,6 +572,9 @@ type statusContext struct {
 	units        map[string]map[string]*state.Unit
 	networks     map[string]*state.Network
 	latestCharms map[charm.URL]string
+	// payloads: unit name -> payloads registered by the unit
+	payloads     map[string][]state.Payload
+	certificates api.CertificatesStatus
 }
 
 // fetchMachines returns a map from top level machine id to machines, where machines[0] is the host
@@ -370,6 +711,48 @@ func fetchNetworks(st *state.State) (map[string]*state.Network, error) {
 	return out, nil
 }
 
+// fetchPayloads returns a map from unit name to the payloads
+// registered by that unit.
+func fetchPayloads(st *state.State) (map[string][]state.Payload, error) {
+	payloads, err := st.AllPayloads()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]state.Payload)
+	for _, p := range payloads {
+		out[p.Unit] = append(out[p.Unit], p)
+	}
+	return out, nil
+}
+
+// fetchCertificates returns the expiry of the trusted CA certificates,
+// and the phase of the CA rotation in progress, if any.
+func fetchCertificates(st *state.State) (api.CertificatesStatus, error) {
+	var out api.CertificatesStatus
+	trusted, err := st.TrustedCACert()
+	if err != nil {
+		return out, err
+	}
+	caCerts, err := cert.ParseCerts(trusted)
+	if err != nil {
+		return out, err
+	}
+	for _, caCert := range caCerts {
+		out.CACertificates = append(out.CACertificates, api.CACertificateStatus{
+			Subject: caCert.Subject.CommonName,
+			Expiry:  caCert.NotAfter.UTC(),
+		})
+	}
+	rotation, err := st.CARotation()
+	if errors.IsNotFound(err) {
+		return out, nil
+	} else if err != nil {
+		return out, err
+	}
+	out.RotationPhase = string(rotation.Phase())
+	return out, nil
+}
+
 type machineAndContainers map[string][]*state.Machine
 
 func (m machineAndContainers) HostForMachineId(id string) *state.Machine {
@@ -558,7 +941,12 @@ func (context *statusContext) processService(service *state.Service) (status api
 	if ok && latestCharm != serviceCharmURL.String() {
 		status.CanUpgradeTo = latestCharm
 	}
-	var err error
+	ch, _, err := service.Charm()
+	if err != nil {
+		status.Err = err
+		return
+	}
+	status.CharmSigner = ch.Signer()
 	status.Relations, status.SubordinateTo, err = context.processServiceRelations(service)
 	if err != nil {
 		status.Err = err
@@ -626,6 +1014,15 @@ func (context *statusContext) processUnit(unit *state.Unit, serviceCharm string)
 		result.Charm = curl.String()
 	}
 	processUnitAndAgentStatus(unit, &result)
+	for _, p := range context.payloads[unit.Name()] {
+		result.Payloads = append(result.Payloads, params.Payload{
+			Name:   p.Name,
+			Type:   p.Type,
+			ID:     p.ID,
+			Status: string(p.Status),
+			Labels: p.Labels,
+		})
+	}
 
 	if subUnits := unit.SubordinateNames(); len(subUnits) > 0 {
 		result.Subordinates = make(map[string]api.UnitStatus)
@@ -4,12 +4,19 @@
 package client_test
 
 import (
+	"time"
+
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5"
 
 	"github.com/juju/juju/apiserver/client"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cert"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/testcharms"
+	coretesting "github.com/juju/juju/testing"
 	"github.com/juju/juju/testing/factory"
 )
 
@@ -45,6 +52,144 @@ func (s *statusSuite) TestFullStatus(c *gc.C) {
 	c.Check(resultMachine.Series, gc.Equals, machine.Series())
 }
 
+func (s *statusSuite) TestFullStatusCertificates(c *gc.C) {
+	client := s.APIState.Client()
+	status, err := client.Status(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(status.Certificates.CACertificates, gc.HasLen, 1)
+	caCert := status.Certificates.CACertificates[0]
+	c.Check(caCert.Subject, gc.Equals, coretesting.CACertX509.Subject.CommonName)
+	c.Check(caCert.Expiry.Equal(coretesting.CACertX509.NotAfter), jc.IsTrue)
+	c.Check(status.Certificates.RotationPhase, gc.Equals, "")
+}
+
+func (s *statusSuite) TestFullStatusCertificatesRotating(c *gc.C) {
+	expiry := time.Now().AddDate(0, 0, 10).UTC().Truncate(time.Second)
+	newCACert, newCAKey, err := cert.NewCA("new ca", expiry)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, jc.ErrorIsNil)
+
+	client := s.APIState.Client()
+	status, err := client.Status(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(status.Certificates.CACertificates, gc.HasLen, 2)
+	newStatus := status.Certificates.CACertificates[1]
+	c.Check(newStatus.Subject, gc.Equals, "juju-generated CA for environment \"new ca\"")
+	c.Check(newStatus.Expiry.Equal(expiry), jc.IsTrue)
+	c.Check(status.Certificates.RotationPhase, gc.Equals, string(state.CARotationTrusting))
+}
+
+func (s *statusSuite) TestFilteredStatusByMachine(c *gc.C) {
+	machine0 := s.addMachine(c)
+	machine1 := s.addMachine(c)
+	charm := s.Factory.MakeCharm(c, nil)
+	service0 := s.Factory.MakeService(c, &factory.ServiceParams{Name: "service0", Charm: charm})
+	service1 := s.Factory.MakeService(c, &factory.ServiceParams{Name: "service1", Charm: charm})
+	s.Factory.MakeUnit(c, &factory.UnitParams{Service: service0, Machine: machine0})
+	s.Factory.MakeUnit(c, &factory.UnitParams{Service: service1, Machine: machine1})
+
+	client := s.APIState.Client()
+	status, err := client.FilteredStatus(params.StatusParams{Machines: []string{machine1.Id()}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(status.Machines, gc.HasLen, 1)
+	c.Check(status.Machines[machine1.Id()].Id, gc.Equals, machine1.Id())
+	c.Check(status.Services, gc.HasLen, 1)
+	c.Check(status.Services["service1"].Units, gc.HasLen, 1)
+}
+
+func (s *statusSuite) TestFullStatusCharmSigner(c *gc.C) {
+	curl, err := s.State.PrepareLocalCharmUpload(charm.MustParseURL("local:quantal/dummy-1"))
+	c.Assert(err, jc.ErrorIsNil)
+	ch, err := s.State.UpdateUploadedCharm(testcharms.Repo.CharmDir("dummy"), curl, "dummy-path", "dummy-sha256", "Charmer <charmer@example.com>")
+	c.Assert(err, jc.ErrorIsNil)
+	s.Factory.MakeService(c, &factory.ServiceParams{Name: "signed", Charm: ch})
+	s.Factory.MakeService(c, &factory.ServiceParams{Name: "unsigned"})
+
+	client := s.APIState.Client()
+	status, err := client.Status(nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(status.Services["signed"].CharmSigner, gc.Equals, "Charmer <charmer@example.com>")
+	c.Check(status.Services["unsigned"].CharmSigner, gc.Equals, "")
+}
+
+func (s *statusSuite) TestFilteredStatusInvalidStatus(c *gc.C) {
+	client := s.APIState.Client()
+	_, err := client.FilteredStatus(params.StatusParams{Statuses: []string{"bogus"}})
+	c.Assert(err, gc.ErrorMatches, `could not filter status: status "bogus" not valid`)
+}
+
+func (s *statusSuite) TestStatusHistoryMachine(c *gc.C) {
+	machine := s.addMachine(c)
+	err := machine.SetStatus(state.StatusStarted, "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = machine.SetStatus(state.StatusStopped, "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	client := s.APIState.Client()
+	history, err := client.StatusHistory(params.StatusHistory{
+		Kind: params.KindMachine,
+		Name: machine.Id(),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	var statuses []params.Status
+	for _, status := range history.Statuses {
+		c.Check(status.Entity, gc.Equals, machine.Id())
+		c.Check(status.Kind, gc.Equals, params.KindMachine)
+		statuses = append(statuses, status.Status)
+	}
+	c.Assert(statuses, jc.DeepEquals, []params.Status{
+		params.StatusPending, params.StatusStarted, params.StatusStopped,
+	})
+
+	history, err = client.StatusHistory(params.StatusHistory{
+		Kind: params.KindMachine,
+		Name: machine.Id(),
+		Size: 1,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history.Statuses, gc.HasLen, 1)
+	c.Assert(history.Statuses[0].Status, gc.Equals, params.StatusStopped)
+}
+
+func (s *statusSuite) TestStatusHistoryServiceMergesUnits(c *gc.C) {
+	service := s.Factory.MakeService(c, &factory.ServiceParams{Name: "service0"})
+	unit0 := s.Factory.MakeUnit(c, &factory.UnitParams{Service: service})
+	s.Factory.MakeUnit(c, &factory.UnitParams{Service: service})
+	err := unit0.SetStatus(state.StatusActive, "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	client := s.APIState.Client()
+	history, err := client.StatusHistory(params.StatusHistory{
+		Kind: params.KindWorkload,
+		Name: "service0",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history.Statuses, gc.HasLen, 3)
+	entities := make(map[string]int)
+	for i, status := range history.Statuses {
+		c.Check(status.Kind, gc.Equals, params.KindWorkload)
+		if i > 0 {
+			c.Check(status.Since.Before(*history.Statuses[i-1].Since), jc.IsFalse)
+		}
+		entities[status.Entity]++
+	}
+	c.Assert(entities, jc.DeepEquals, map[string]int{
+		"service0/0": 2,
+		"service0/1": 1,
+	})
+}
+
+func (s *statusSuite) TestStatusHistoryInvalidKind(c *gc.C) {
+	machine := s.addMachine(c)
+	client := s.APIState.Client()
+	_, err := client.StatusHistory(params.StatusHistory{
+		Kind: params.KindAgent,
+		Name: machine.Id(),
+	})
+	c.Assert(err, gc.ErrorMatches, `status type "agent" for machine "0" not valid`)
+}
+
 func (s *statusSuite) TestLegacyStatus(c *gc.C) {
 	machine := s.addMachine(c)
 	instanceId := "i-fakeinstance"
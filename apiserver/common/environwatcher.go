@@ -6,6 +6,7 @@ package common
 import (
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/watcher"
 )
@@ -52,15 +53,18 @@ func (e *EnvironWatcher) WatchForEnvironConfigChanges() (params.NotifyWatchResul
 	return result, nil
 }
 
-// EnvironConfig returns the current environment's configuration.
+// EnvironConfig returns the current environment's configuration, with
+// the secrets the agent has no need of masked out.
 func (e *EnvironWatcher) EnvironConfig() (params.EnvironConfigResult, error) {
 	result := params.EnvironConfigResult{}
 
-	config, err := e.st.EnvironConfig()
+	cfg, err := e.st.EnvironConfig()
 	if err != nil {
 		return result, err
 	}
-	allAttrs := config.AllAttrs()
+	// The LDAP bind password is only used by the state servers,
+	// which read it from state.
+	allAttrs := config.RedactSecrets(cfg.AllAttrs())
 
 	if !e.authorizer.AuthEnvironManager() {
 		// Mask out any secrets in the environment configuration
@@ -70,11 +74,11 @@ func (e *EnvironWatcher) EnvironConfig() (params.EnvironConfigResult, error) {
 		// Delete the code below and mark the bug as fixed,
 		// once it's live tested on MAAS and 1.16 compatibility
 		// is dropped.
-		provider, err := environs.Provider(config.Type())
+		provider, err := environs.Provider(cfg.Type())
 		if err != nil {
 			return result, err
 		}
-		secretAttrs, err := provider.SecretAttrs(config)
+		secretAttrs, err := provider.SecretAttrs(cfg)
 		for k := range secretAttrs {
 			allAttrs[k] = "not available"
 		}
@@ -6,6 +6,7 @@ package common
 import (
 	stderrors "errors"
 	"fmt"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/names"
@@ -85,6 +86,17 @@ var (
 			Message: msg,
 		}
 	}
+
+	// ErrTryAgainAfter returns an error equivalent to ErrTryAgain
+	// that also suggests to the client how long it should wait
+	// before retrying.
+	ErrTryAgainAfter = func(delay time.Duration) *params.Error {
+		return &params.Error{
+			Code:    params.CodeTryAgain,
+			Message: ErrTryAgain.Error(),
+			Info:    params.RetryAfterInfo(delay),
+		}
+	}
 )
 
 var singletonErrorCodes = map[error]string{
@@ -146,13 +158,20 @@ func ServerError(err error) *params.Error {
 		code = params.CodeUpgradeInProgress
 	case state.IsHasAttachmentsError(err):
 		code = params.CodeMachineHasAttachedStorage
+	case state.IsQuotaExceededError(err):
+		code = params.CodeQuotaExceeded
 	case IsUnknownEnviromentError(err):
 		code = params.CodeNotFound
 	default:
 		code = params.ErrCode(err)
 	}
+	var info map[string]interface{}
+	if err, ok := err.(*params.Error); ok {
+		info = err.Info
+	}
 	return &params.Error{
 		Message: msg,
 		Code:    code,
+		Info:    info,
 	}
 }
@@ -5,6 +5,7 @@ package common_test
 
 import (
 	stderrors "errors"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/names"
@@ -113,6 +114,10 @@ var errorTransformTests = []struct {
 	err:        leadership.ErrClaimDenied,
 	code:       params.CodeLeadershipClaimDenied,
 	helperFunc: params.IsCodeLeadershipClaimDenied,
+}, {
+	err:        &state.QuotaExceededError{Quota: "environment quota", Limit: "at most 1 machines"},
+	code:       params.CodeQuotaExceeded,
+	helperFunc: params.IsCodeQuotaExceeded,
 }, {
 	err:        common.ErrOperationBlocked("test"),
 	code:       params.CodeOperationBlocked,
@@ -153,6 +158,15 @@ func (s *errorsSuite) TestErrorTransform(c *gc.C) {
 	}
 }
 
+func (s *errorsSuite) TestTryAgainAfter(c *gc.C) {
+	err := common.ServerError(errors.Trace(common.ErrTryAgainAfter(3 * time.Second)))
+	c.Assert(err, gc.ErrorMatches, "try again")
+	c.Assert(err, jc.Satisfies, params.IsCodeTryAgain)
+	delay, ok := params.RetryAfter(err)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(delay, gc.Equals, 3*time.Second)
+}
+
 func (s *errorsSuite) TestUnknownEnvironment(c *gc.C) {
 	err := common.UnknownEnvironmentError("dead-beef")
 	c.Check(err, gc.ErrorMatches, `unknown environment: "dead-beef"`)
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+
+	"github.com/juju/errors"
 )
 
 // Resource represents any resource that should be cleaned up when an
@@ -141,3 +143,38 @@ func (StringResource) Stop() error {
 func (s StringResource) String() string {
 	return string(s)
 }
+
+// BackgroundRunner runs work that outlives the API request that starts
+// it, but not the API server. It matches the Resource interface so that
+// it can be made available to facades.
+type BackgroundRunner struct {
+	dying <-chan struct{}
+	wg    *sync.WaitGroup
+}
+
+// NewBackgroundRunner returns a BackgroundRunner for a server that is
+// stopping once dying is closed, and that waits for wg before it stops.
+func NewBackgroundRunner(dying <-chan struct{}, wg *sync.WaitGroup) *BackgroundRunner {
+	return &BackgroundRunner{dying: dying, wg: wg}
+}
+
+// Go calls f in its own goroutine, passing it a channel that is closed
+// when the server starts stopping. The server does not stop until f
+// returns. Go must only be called while an API request is in progress.
+func (r *BackgroundRunner) Go(f func(dying <-chan struct{})) error {
+	select {
+	case <-r.dying:
+		return errors.New("API server is stopping")
+	default:
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		f(r.dying)
+	}()
+	return nil
+}
+
+func (*BackgroundRunner) Stop() error {
+	return nil
+}
@@ -157,3 +157,29 @@ func (resourceSuite) TestStringResource(c *gc.C) {
 	asStr := rs.Get(id).(common.StringResource).String()
 	c.Check(asStr, gc.Equals, "foobar")
 }
+
+func (resourceSuite) TestBackgroundRunner(c *gc.C) {
+	dying := make(chan struct{})
+	var wg sync.WaitGroup
+	runner := common.NewBackgroundRunner(dying, &wg)
+
+	stopped := make(chan struct{})
+	err := runner.Go(func(dying <-chan struct{}) {
+		<-dying
+		close(stopped)
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	close(dying)
+	wg.Wait()
+	select {
+	case <-stopped:
+	default:
+		c.Fatalf("background work not finished")
+	}
+
+	err = runner.Go(func(<-chan struct{}) {
+		c.Errorf("background work run while stopping")
+	})
+	c.Assert(err, gc.ErrorMatches, "API server is stopping")
+}
@@ -0,0 +1,154 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The confighistory package implements the API used to report the
+// recorded changes to the environment config and to services' charm
+// config settings, and to restore earlier revisions of them.
+package confighistory
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("ConfigHistory", 1, NewConfigHistoryAPI)
+}
+
+// ConfigHistory defines the methods on the config history API end
+// point.
+type ConfigHistory interface {
+	EnvironConfigHistory() (params.ConfigHistoryResult, error)
+	ServiceConfigHistory(arg params.Entity) (params.ConfigHistoryResult, error)
+	RollbackEnvironConfig(args params.RollbackEnvironConfig) error
+	RollbackServiceConfig(args params.RollbackServiceConfig) error
+}
+
+// ConfigHistoryAPI implements the ConfigHistory interface and is the
+// concrete implementation of the api end point.
+type ConfigHistoryAPI struct {
+	state *state.State
+	user  names.UserTag
+	check *common.BlockChecker
+}
+
+var _ ConfigHistory = (*ConfigHistoryAPI)(nil)
+
+// NewConfigHistoryAPI creates a new server-side config history API end
+// point.
+func NewConfigHistoryAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*ConfigHistoryAPI, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	user, ok := authorizer.GetAuthTag().(names.UserTag)
+	if !ok {
+		return nil, common.ErrPerm
+	}
+	return &ConfigHistoryAPI{
+		state: st,
+		user:  user,
+		check: common.NewBlockChecker(st),
+	}, nil
+}
+
+// EnvironConfigHistory returns the recorded revisions of the
+// environment config, newest first, with the secrets in them redacted.
+func (api *ConfigHistoryAPI) EnvironConfigHistory() (params.ConfigHistoryResult, error) {
+	revisions, err := api.state.EnvironConfigHistory()
+	if err != nil {
+		return params.ConfigHistoryResult{}, errors.Trace(err)
+	}
+	result := historyResult(revisions)
+	for i, rev := range result.Revisions {
+		result.Revisions[i].Settings = config.RedactSecrets(rev.Settings)
+		for j, change := range rev.Changes {
+			rev.Changes[j].OldValue = config.RedactSecret(change.Key, change.OldValue)
+			rev.Changes[j].NewValue = config.RedactSecret(change.Key, change.NewValue)
+		}
+	}
+	return result, nil
+}
+
+// ServiceConfigHistory returns the recorded revisions of the charm
+// config settings of the given service, newest first.
+func (api *ConfigHistoryAPI) ServiceConfigHistory(arg params.Entity) (params.ConfigHistoryResult, error) {
+	service, err := api.service(arg.Tag)
+	if err != nil {
+		return params.ConfigHistoryResult{}, errors.Trace(err)
+	}
+	revisions, err := service.ConfigHistory()
+	if err != nil {
+		return params.ConfigHistoryResult{}, errors.Trace(err)
+	}
+	return historyResult(revisions), nil
+}
+
+// RollbackEnvironConfig restores the environment config to the given
+// revision.
+func (api *ConfigHistoryAPI) RollbackEnvironConfig(args params.RollbackEnvironConfig) error {
+	if err := api.check.ChangeAllowed(); err != nil {
+		return errors.Trace(err)
+	}
+	return api.state.RollbackEnvironConfig(api.user, args.Revision)
+}
+
+// RollbackServiceConfig restores the charm config settings of the
+// given service to the given revision.
+func (api *ConfigHistoryAPI) RollbackServiceConfig(args params.RollbackServiceConfig) error {
+	if err := api.check.ChangeAllowed(); err != nil {
+		return errors.Trace(err)
+	}
+	service, err := api.service(args.Service)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return service.RollbackConfig(api.user, args.Revision)
+}
+
+func (api *ConfigHistoryAPI) service(tag string) (*state.Service, error) {
+	serviceTag, err := names.ParseServiceTag(tag)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return api.state.Service(serviceTag.Id())
+}
+
+func historyResult(revisions []state.ConfigRevision) params.ConfigHistoryResult {
+	result := params.ConfigHistoryResult{
+		Revisions: make([]params.ConfigRevision, len(revisions)),
+	}
+	for i, rev := range revisions {
+		changes := make([]params.ConfigChange, len(rev.Changes))
+		for j, change := range rev.Changes {
+			changes[j] = params.ConfigChange{
+				Type:     changeType(change.Type),
+				Key:      change.Key,
+				OldValue: change.OldValue,
+				NewValue: change.NewValue,
+			}
+		}
+		result.Revisions[i] = params.ConfigRevision{
+			Revision: rev.Revision,
+			Settings: rev.Settings,
+			Changes:  changes,
+			Author:   rev.Author,
+			Time:     rev.Time,
+		}
+	}
+	return result
+}
+
+func changeType(t int) params.ConfigChangeType {
+	switch t {
+	case state.ItemAdded:
+		return params.ConfigSettingAdded
+	case state.ItemDeleted:
+		return params.ConfigSettingDeleted
+	}
+	return params.ConfigSettingModified
+}
@@ -0,0 +1,180 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package confighistory_test
+
+import (
+	stdtesting "testing"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5"
+
+	"github.com/juju/juju/apiserver/common"
+	commontesting "github.com/juju/juju/apiserver/common/testing"
+	"github.com/juju/juju/apiserver/confighistory"
+	"github.com/juju/juju/apiserver/params"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type configHistorySuite struct {
+	testing.JujuConnSuite
+
+	resources  *common.Resources
+	authoriser apiservertesting.FakeAuthorizer
+	api        *confighistory.ConfigHistoryAPI
+	service    *state.Service
+
+	commontesting.BlockHelper
+}
+
+var _ = gc.Suite(&configHistorySuite{})
+
+func (s *configHistorySuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
+	s.authoriser = apiservertesting.FakeAuthorizer{
+		Tag: s.AdminUserTag(c),
+	}
+	var err error
+	s.api, err = confighistory.NewConfigHistoryAPI(s.State, s.resources, s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+	s.service = s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+
+	s.BlockHelper = commontesting.NewBlockHelper(s.APIState)
+	s.AddCleanup(func(*gc.C) { s.BlockHelper.Close() })
+}
+
+// updateEnvironConfig sets "arbitrary-key" in the environment config,
+// returning the config revision recorded for the change.
+func (s *configHistorySuite) updateEnvironConfig(c *gc.C, value string) int {
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{"arbitrary-key": value}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	history, err := s.State.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	return history[0].Revision
+}
+
+func (s *configHistorySuite) TestNewAPIRefusesAgents(c *gc.C) {
+	auth := apiservertesting.FakeAuthorizer{Tag: names.NewMachineTag("0")}
+	_, err := confighistory.NewConfigHistoryAPI(s.State, s.resources, auth)
+	c.Assert(err, gc.Equals, common.ErrPerm)
+}
+
+func (s *configHistorySuite) TestEnvironConfigHistory(c *gc.C) {
+	err := s.State.UpdateEnvironConfigAs(s.AdminUserTag(c), map[string]interface{}{"arbitrary-key": "shazam!"}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := s.State.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := s.api.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Revisions, gc.HasLen, len(history))
+	rev := result.Revisions[0]
+	c.Assert(rev.Revision, gc.Equals, history[0].Revision)
+	c.Assert(rev.Author, gc.Equals, s.AdminUserTag(c).Name())
+	c.Assert(rev.Settings["arbitrary-key"], gc.Equals, "shazam!")
+	c.Assert(rev.Changes, jc.DeepEquals, []params.ConfigChange{{
+		Type:     params.ConfigSettingAdded,
+		Key:      "arbitrary-key",
+		NewValue: "shazam!",
+	}})
+}
+
+func (s *configHistorySuite) TestEnvironConfigHistoryRedactsSecrets(c *gc.C) {
+	ldapConfig := "bind-dn: cn=juju\nbind-password: sekrit\n"
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{"ldap-config": ldapConfig}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := s.api.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	rev := result.Revisions[0]
+	redacted := "bind-dn: cn=juju\nbind-password: not available\n"
+	c.Assert(rev.Settings["ldap-config"], gc.Equals, redacted)
+	c.Assert(rev.Changes, jc.DeepEquals, []params.ConfigChange{{
+		Type:     params.ConfigSettingAdded,
+		Key:      "ldap-config",
+		NewValue: redacted,
+	}})
+}
+
+func (s *configHistorySuite) TestServiceConfigHistory(c *gc.C) {
+	err := s.service.UpdateConfigSettingsAs(s.AdminUserTag(c), charm.Settings{"outlook": "grim"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.service.UpdateConfigSettingsAs(s.AdminUserTag(c), charm.Settings{"outlook": "rosy"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := s.api.ServiceConfigHistory(params.Entity{Tag: s.service.Tag().String()})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Revisions, gc.HasLen, 2)
+	c.Assert(result.Revisions[0].Revision, gc.Equals, 2)
+	c.Assert(result.Revisions[0].Changes, jc.DeepEquals, []params.ConfigChange{{
+		Type:     params.ConfigSettingModified,
+		Key:      "outlook",
+		OldValue: "grim",
+		NewValue: "rosy",
+	}})
+}
+
+func (s *configHistorySuite) TestServiceConfigHistoryNotFound(c *gc.C) {
+	_, err := s.api.ServiceConfigHistory(params.Entity{Tag: "service-wordpress"})
+	c.Assert(err, gc.ErrorMatches, `service "wordpress" not found`)
+	_, err = s.api.ServiceConfigHistory(params.Entity{Tag: "machine-0"})
+	c.Assert(err, gc.ErrorMatches, `"machine-0" is not a valid service tag`)
+}
+
+func (s *configHistorySuite) TestRollbackEnvironConfig(c *gc.C) {
+	revision := s.updateEnvironConfig(c, "one")
+	s.updateEnvironConfig(c, "two")
+
+	err := s.api.RollbackEnvironConfig(params.RollbackEnvironConfig{Revision: revision})
+	c.Assert(err, jc.ErrorIsNil)
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.UnknownAttrs()["arbitrary-key"], gc.Equals, "one")
+
+	// The rollback is recorded as made by the API user.
+	history, err := s.State.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history[0].Author, gc.Equals, s.AdminUserTag(c).Name())
+}
+
+func (s *configHistorySuite) TestRollbackServiceConfig(c *gc.C) {
+	err := s.service.UpdateConfigSettings(charm.Settings{"outlook": "grim"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.service.UpdateConfigSettings(charm.Settings{"outlook": "rosy"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.api.RollbackServiceConfig(params.RollbackServiceConfig{
+		Service:  s.service.Tag().String(),
+		Revision: 1,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	settings, err := s.service.ConfigSettings()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(settings, jc.DeepEquals, charm.Settings{"outlook": "grim"})
+}
+
+func (s *configHistorySuite) TestBlockRollback(c *gc.C) {
+	revision := s.updateEnvironConfig(c, "one")
+	s.BlockAllChanges(c, "TestBlockRollback")
+
+	err := s.api.RollbackEnvironConfig(params.RollbackEnvironConfig{Revision: revision})
+	s.AssertBlocked(c, err, "TestBlockRollback")
+	err = s.api.RollbackServiceConfig(params.RollbackServiceConfig{
+		Service:  s.service.Tag().String(),
+		Revision: 1,
+	})
+	s.AssertBlocked(c, err, "TestBlockRollback")
+}
@@ -0,0 +1,173 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The controllerhealth package implements the API used to report on
+// the health of the state servers.
+package controllerhealth
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/worker/peergrouper"
+)
+
+func init() {
+	common.RegisterStandardFacade("ControllerHealth", 1, NewControllerHealthAPI)
+}
+
+// replicaSetHealth is a variable so it can be patched in tests.
+var replicaSetHealth = peergrouper.ReplicaSetHealth
+
+// ControllerHealth defines the methods on the controllerhealth API end
+// point.
+type ControllerHealth interface {
+	Report() (params.ControllerHealthReport, error)
+}
+
+// ControllerHealthAPI implements the ControllerHealth interface and is
+// the concrete implementation of the api end point.
+type ControllerHealthAPI struct {
+	state      *state.State
+	authorizer common.Authorizer
+}
+
+var _ ControllerHealth = (*ControllerHealthAPI)(nil)
+
+// NewControllerHealthAPI creates a new server-side controllerhealth API
+// end point.
+func NewControllerHealthAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*ControllerHealthAPI, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &ControllerHealthAPI{
+		state:      st,
+		authorizer: authorizer,
+	}, nil
+}
+
+// checkCanReport returns an error unless the connection is to the state
+// server environment, by the user that administers it.
+func (api *ControllerHealthAPI) checkCanReport() error {
+	if !api.state.IsStateServer() {
+		return errors.New("unsupported with hosted environments")
+	}
+	env, err := api.state.StateServerEnvironment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	apiUser, ok := api.authorizer.GetAuthTag().(names.UserTag)
+	if !ok || apiUser != env.Owner() {
+		return common.ErrPerm
+	}
+	return nil
+}
+
+// Report returns a report on the health of the state servers and of
+// the database they share.
+func (api *ControllerHealthAPI) Report() (params.ControllerHealthReport, error) {
+	var report params.ControllerHealthReport
+	if err := api.checkCanReport(); err != nil {
+		return report, errors.Trace(err)
+	}
+	stateServers, err := api.stateServersHealth()
+	if err != nil {
+		return report, errors.Trace(err)
+	}
+	report.StateServers = stateServers
+	report.MaxClockSkew = maxClockSkew(stateServers)
+
+	members, err := replicaSetHealth(api.state.MongoSession())
+	if err != nil {
+		report.ReplicaSetError = err.Error()
+	}
+	for _, member := range members {
+		report.ReplicaSet = append(report.ReplicaSet, params.ReplicaSetMemberHealth{
+			Id:        member.Id,
+			Address:   member.Address,
+			MachineId: member.MachineId,
+			State:     member.State.String(),
+			Healthy:   member.Healthy,
+			Lag:       member.Lag,
+		})
+	}
+
+	health, err := api.state.DatabaseHealth()
+	if err != nil {
+		return report, errors.Trace(err)
+	}
+	report.PendingTransactions = health.PendingTransactions
+	report.PendingCleanups = health.PendingCleanups
+	if !health.OldestCleanup.IsZero() {
+		oldest := health.OldestCleanup
+		report.OldestCleanup = &oldest
+	}
+	report.LogCount = health.LogCount
+	report.LogSize = health.LogSize
+	return report, nil
+}
+
+// stateServersHealth returns the health of each state server machine,
+// combining the presence of its agent with the latest report made by
+// its API server.
+func (api *ControllerHealthAPI) stateServersHealth() ([]params.StateServerHealth, error) {
+	info, err := api.state.StateServerInfo()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	reports, err := api.state.APIServerReports()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	reportsById := make(map[string]state.APIServerReport)
+	for _, report := range reports {
+		reportsById[report.MachineId] = report
+	}
+	var result []params.StateServerHealth
+	for _, id := range info.MachineIds {
+		machine, err := api.state.Machine(id)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		alive, err := machine.AgentPresence()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		health := params.StateServerHealth{
+			MachineId:  id,
+			AgentAlive: alive,
+		}
+		if report, ok := reportsById[id]; ok {
+			health.APIConnections = report.Connections
+			health.ClockOffset, health.ClockUncertainty = report.Clock.Offset()
+			health.ReportedAt = report.Updated()
+		}
+		result = append(result, health)
+	}
+	return result, nil
+}
+
+// maxClockSkew returns the largest difference between the clocks of the
+// state servers whose API servers have reported.
+func maxClockSkew(stateServers []params.StateServerHealth) time.Duration {
+	var min, max time.Duration
+	first := true
+	for _, health := range stateServers {
+		if health.ReportedAt.IsZero() {
+			continue
+		}
+		if first || health.ClockOffset < min {
+			min = health.ClockOffset
+		}
+		if first || health.ClockOffset > max {
+			max = health.ClockOffset
+		}
+		first = false
+	}
+	return max - min
+}
@@ -0,0 +1,186 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package controllerhealth_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/replicaset"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/mgo.v2"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/controllerhealth"
+	"github.com/juju/juju/apiserver/params"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+	"github.com/juju/juju/worker/peergrouper"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type controllerHealthSuite struct {
+	testing.JujuConnSuite
+
+	resources  *common.Resources
+	authoriser apiservertesting.FakeAuthorizer
+	api        *controllerhealth.ControllerHealthAPI
+}
+
+var _ = gc.Suite(&controllerHealthSuite{})
+
+func (s *controllerHealthSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
+	s.authoriser = apiservertesting.FakeAuthorizer{
+		Tag: s.AdminUserTag(c),
+	}
+	var err error
+	s.api, err = controllerhealth.NewControllerHealthAPI(s.State, s.resources, s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.PatchValue(controllerhealth.ReplicaSetHealth, func(*mgo.Session) ([]peergrouper.MemberHealth, error) {
+		return []peergrouper.MemberHealth{{
+			Id:        1,
+			Address:   "10.0.0.1:37017",
+			MachineId: "0",
+			State:     replicaset.PrimaryState,
+			Healthy:   true,
+		}, {
+			Id:      2,
+			Address: "10.0.0.2:37017",
+			State:   replicaset.SecondaryState,
+			Healthy: true,
+			Lag:     2 * time.Second,
+		}}, nil
+	})
+}
+
+// addStateServer adds a state server machine. The API server run by
+// the test environment reports as machine 0, so that machine is kept
+// out of the way.
+func (s *controllerHealthSuite) addStateServer(c *gc.C) *state.Machine {
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	machine, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, jc.ErrorIsNil)
+	return machine
+}
+
+func (s *controllerHealthSuite) TestNewAPIRefusesAgents(c *gc.C) {
+	auth := apiservertesting.FakeAuthorizer{Tag: names.NewMachineTag("0")}
+	_, err := controllerhealth.NewControllerHealthAPI(s.State, s.resources, auth)
+	c.Assert(err, gc.Equals, common.ErrPerm)
+}
+
+func (s *controllerHealthSuite) TestReport(c *gc.C) {
+	machine := s.addStateServer(c)
+	err := s.State.ReportAPIServer(machine.Id(), 2)
+	c.Assert(err, jc.ErrorIsNil)
+
+	report, err := s.api.Report()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(report.StateServers, gc.HasLen, 1)
+	stateServer := report.StateServers[0]
+	c.Assert(stateServer.MachineId, gc.Equals, machine.Id())
+	c.Assert(stateServer.AgentAlive, jc.IsFalse)
+	c.Assert(stateServer.APIConnections, gc.Equals, 2)
+	c.Assert(stateServer.ReportedAt.IsZero(), jc.IsFalse)
+	c.Assert(report.MaxClockSkew, gc.Equals, time.Duration(0))
+
+	c.Assert(report.ReplicaSetError, gc.Equals, "")
+	c.Assert(report.ReplicaSet, jc.DeepEquals, []params.ReplicaSetMemberHealth{{
+		Id:        1,
+		Address:   "10.0.0.1:37017",
+		MachineId: "0",
+		State:     "PRIMARY",
+		Healthy:   true,
+	}, {
+		Id:      2,
+		Address: "10.0.0.2:37017",
+		State:   "SECONDARY",
+		Healthy: true,
+		Lag:     2 * time.Second,
+	}})
+	c.Assert(report.PendingTransactions, gc.Equals, 0)
+	c.Assert(report.PendingCleanups, gc.Equals, 0)
+	c.Assert(report.OldestCleanup, gc.IsNil)
+}
+
+func (s *controllerHealthSuite) TestReportWithoutAPIServerReport(c *gc.C) {
+	machine := s.addStateServer(c)
+
+	report, err := s.api.Report()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(report.StateServers, jc.DeepEquals, []params.StateServerHealth{{
+		MachineId: machine.Id(),
+	}})
+}
+
+func (s *controllerHealthSuite) TestReportPendingCleanups(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = machine.ForceDestroy()
+	c.Assert(err, jc.ErrorIsNil)
+
+	report, err := s.api.Report()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(report.PendingCleanups, gc.Equals, 1)
+	c.Assert(report.OldestCleanup, gc.NotNil)
+}
+
+func (s *controllerHealthSuite) TestReportReplicaSetError(c *gc.C) {
+	s.PatchValue(controllerhealth.ReplicaSetHealth, func(*mgo.Session) ([]peergrouper.MemberHealth, error) {
+		return nil, errors.New("not running with replica set")
+	})
+	report, err := s.api.Report()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(report.ReplicaSet, gc.HasLen, 0)
+	c.Assert(report.ReplicaSetError, gc.Equals, "not running with replica set")
+}
+
+func (s *controllerHealthSuite) TestReportNotAdmin(c *gc.C) {
+	user := s.Factory.MakeUser(c, &factory.UserParams{Name: "bob"})
+	auth := apiservertesting.FakeAuthorizer{Tag: user.UserTag()}
+	api, err := controllerhealth.NewControllerHealthAPI(s.State, s.resources, auth)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = api.Report()
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *controllerHealthSuite) TestReportHostedEnvironment(c *gc.C) {
+	st := s.Factory.MakeEnvironment(c, nil)
+	defer st.Close()
+	api, err := controllerhealth.NewControllerHealthAPI(st, s.resources, s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = api.Report()
+	c.Assert(err, gc.ErrorMatches, "unsupported with hosted environments")
+}
+
+func (s *controllerHealthSuite) TestMaxClockSkew(c *gc.C) {
+	reported := time.Now()
+	skew := controllerhealth.MaxClockSkew([]params.StateServerHealth{{
+		ClockOffset: 2 * time.Second,
+		ReportedAt:  reported,
+	}, {
+		ClockOffset: -time.Second,
+		ReportedAt:  reported,
+	}, {
+		// Never reported, so ignored.
+		ClockOffset: time.Hour,
+	}})
+	c.Assert(skew, gc.Equals, 3*time.Second)
+	c.Assert(controllerhealth.MaxClockSkew(nil), gc.Equals, time.Duration(0))
+}
@@ -2,7 +2,8 @@
 // Licensed under the AGPLv3, see LICENCE file for details.
 
 // The environmentmanager package defines an API end point for functions
-// dealing with envionments.  Creating, listing and sharing environments.
+// dealing with envionments.  Creating, listing, sharing and destroying
+// environments.
 package environmentmanager
 
 import (
@@ -10,6 +11,7 @@ import (
 	"github.com/juju/loggo"
 	"github.com/juju/names"
 	"github.com/juju/utils"
+	"gopkg.in/mgo.v2/bson"
 
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
@@ -17,6 +19,7 @@ import (
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/feature"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/toolstorage"
 	"github.com/juju/juju/version"
 )
 
@@ -32,6 +35,14 @@ type EnvironmentManager interface {
 	ConfigSkeleton(args params.EnvironmentSkeletonConfigArgs) (params.EnvironConfigResult, error)
 	CreateEnvironment(args params.EnvironmentCreateArgs) (params.Environment, error)
 	ListEnvironments(user params.Entity) (params.EnvironmentList, error)
+	DestroyEnvironment(env params.Entity) error
+	EnvironmentStatus(args params.Entities) (params.EnvironmentStatusResults, error)
+	ExportEnvironment(args params.ExportEnvironmentArgs) (params.EnvironmentExport, error)
+	AbortEnvironmentExport(env params.Entity) error
+	ImportEnvironment(args params.EnvironmentExport) error
+	CompleteEnvironmentImport(env params.Entity) (params.EnvironmentImportResult, error)
+	AbortEnvironmentImport(env params.Entity) error
+	SetEnvironmentMigrated(args params.SetEnvironmentMigrated) error
 }
 
 // EnvironmentManagerAPI implements the environment manager interface and is
@@ -360,3 +371,303 @@ func (em *EnvironmentManagerAPI) ListEnvironments(user params.Entity) (params.En
 
 	return result, nil
 }
+
+// hostedEnvironment returns the specified environment if the API user
+// is allowed to manage it. Only the environment owner and the state
+// server owner may do so.
+func (em *EnvironmentManagerAPI) hostedEnvironment(tag string) (*state.Environment, error) {
+	envTag, err := names.ParseEnvironTag(tag)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	stateServerEnv, err := em.state.StateServerEnvironment()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	env, err := em.state.GetEnvironment(envTag)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := em.authCheck(env.Owner(), stateServerEnv.Owner()); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return env, nil
+}
+
+// DestroyEnvironment destroys the specified hosted environment. Its
+// services and machines are destroyed by the environment's workers,
+// and the environment is removed once they have all gone.
+func (em *EnvironmentManagerAPI) DestroyEnvironment(args params.Entity) error {
+	env, err := em.hostedEnvironment(args.Tag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if env.UUID() == env.ServerTag().Id() {
+		return errors.Errorf("state server environment cannot be destroyed with this API, use destroy-environment")
+	}
+	// The environment must be destroyed through its own State, so that
+	// the cleanups it schedules are run by its cleaner.
+	st, err := em.state.ForEnviron(env.EnvironTag())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+	if err := common.NewBlockChecker(st).DestroyAllowed(); err != nil {
+		return errors.Trace(err)
+	}
+	env, err = st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	logger.Infof("destroying environment %s", env.UUID())
+	return errors.Trace(env.Destroy())
+}
+
+// EnvironmentStatus returns the lifecycle state of the specified
+// environments, along with the number of machines and services that
+// remain in each, so that clients can report the progress of their
+// destruction.
+func (em *EnvironmentManagerAPI) EnvironmentStatus(args params.Entities) (params.EnvironmentStatusResults, error) {
+	results := params.EnvironmentStatusResults{
+		Results: make([]params.EnvironmentStatusResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		status, err := em.environmentStatus(entity.Tag)
+		results.Results[i].Status = status
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
+
+func (em *EnvironmentManagerAPI) environmentStatus(tag string) (params.EnvironmentStatus, error) {
+	var status params.EnvironmentStatus
+	env, err := em.hostedEnvironment(tag)
+	if err != nil {
+		return status, errors.Trace(err)
+	}
+	st, err := em.state.ForEnviron(env.EnvironTag())
+	if err != nil {
+		return status, errors.Trace(err)
+	}
+	defer st.Close()
+	machines, err := st.AllMachines()
+	if err != nil {
+		return status, errors.Trace(err)
+	}
+	services, err := st.AllServices()
+	if err != nil {
+		return status, errors.Trace(err)
+	}
+	return params.EnvironmentStatus{
+		Life:         params.Life(env.Life().String()),
+		MachineCount: len(machines),
+		ServiceCount: len(services),
+	}, nil
+}
+
+// hostedEnvironState returns a State for the specified hosted
+// environment, if the API user is allowed to manage it.
+func (em *EnvironmentManagerAPI) hostedEnvironState(tag string) (*state.State, error) {
+	env, err := em.hostedEnvironment(tag)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if env.UUID() == env.ServerTag().Id() {
+		return nil, errors.Errorf("state server environment cannot be migrated")
+	}
+	return em.state.ForEnviron(env.EnvironTag())
+}
+
+// ExportEnvironment returns an export of the documents of the
+// specified hosted environment, for import into another state server
+// by ImportEnvironment. The environment is first prepared for export,
+// so that it cannot be changed until its migration is completed by
+// SetEnvironmentMigrated or abandoned by AbortEnvironmentExport, and
+// its agents are told to trust the given CA certificate of the state
+// server it is moving to.
+func (em *EnvironmentManagerAPI) ExportEnvironment(args params.ExportEnvironmentArgs) (params.EnvironmentExport, error) {
+	var result params.EnvironmentExport
+	st, err := em.hostedEnvironState(args.EnvironTag)
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	defer st.Close()
+	if err := common.NewBlockChecker(st).ChangeAllowed(); err != nil {
+		return result, errors.Trace(err)
+	}
+	env, err := st.Environment()
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	if err := env.StartExport(args.TargetCACert); err != nil {
+		return result, errors.Trace(err)
+	}
+	export, err := st.ExportEnvironment()
+	if err != nil {
+		if abortErr := env.AbortExport(); abortErr != nil {
+			logger.Errorf("cannot abort export of environment %s: %v", env.UUID(), abortErr)
+		}
+		return result, errors.Trace(err)
+	}
+	logger.Infof("exporting environment %s", env.UUID())
+	return exportToParams(export)
+}
+
+func exportToParams(export *state.EnvironmentExport) (params.EnvironmentExport, error) {
+	result := params.EnvironmentExport{
+		Owner:     export.Owner,
+		Config:    export.Config,
+		Charms:    export.Charms,
+		Resources: export.Resources,
+	}
+	for collName, docs := range export.Documents {
+		coll := params.ExportedCollection{
+			Name:      collName,
+			Documents: make([][]byte, len(docs)),
+		}
+		for i, doc := range docs {
+			data, err := bson.Marshal(doc)
+			if err != nil {
+				return params.EnvironmentExport{}, errors.Annotatef(err, "cannot encode document %v in %q", doc["_id"], collName)
+			}
+			coll.Documents[i] = data
+		}
+		result.Documents = append(result.Documents, coll)
+	}
+	for _, metadata := range export.Tools {
+		result.Tools = append(result.Tools, params.ExportedTools{
+			Version: metadata.Version,
+			Size:    metadata.Size,
+			SHA256:  metadata.SHA256,
+		})
+	}
+	return result, nil
+}
+
+func exportFromParams(args params.EnvironmentExport) (*state.EnvironmentExport, error) {
+	export := &state.EnvironmentExport{
+		Owner:     args.Owner,
+		Config:    args.Config,
+		Documents: make(map[string][]bson.M),
+		Charms:    args.Charms,
+		Resources: args.Resources,
+	}
+	for _, coll := range args.Documents {
+		docs := make([]bson.M, len(coll.Documents))
+		for i, data := range coll.Documents {
+			if err := bson.Unmarshal(data, &docs[i]); err != nil {
+				return nil, errors.Annotatef(err, "cannot decode document in %q", coll.Name)
+			}
+		}
+		export.Documents[coll.Name] = docs
+	}
+	for _, tools := range args.Tools {
+		export.Tools = append(export.Tools, toolstorage.Metadata{
+			Version: tools.Version,
+			Size:    tools.Size,
+			SHA256:  tools.SHA256,
+		})
+	}
+	return export, nil
+}
+
+// AbortEnvironmentExport allows the specified hosted environment to be
+// changed again after ExportEnvironment, when its migration cannot be
+// completed.
+func (em *EnvironmentManagerAPI) AbortEnvironmentExport(args params.Entity) error {
+	st, err := em.hostedEnvironState(args.Tag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	logger.Infof("aborting export of environment %s", env.UUID())
+	return errors.Trace(env.AbortExport())
+}
+
+// ImportEnvironment recreates the documents of an environment exported
+// from another state server as a hosted environment of this one. The
+// import is refused if the environment's agents could not work with
+// this state server. The environment's charm archives, resources and
+// tools must then be uploaded through the migration HTTP endpoint
+// before the import is completed by CompleteEnvironmentImport.
+func (em *EnvironmentManagerAPI) ImportEnvironment(args params.EnvironmentExport) error {
+	export, err := exportFromParams(args)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	stateServerEnv, err := em.state.StateServerEnvironment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// As with CreateEnvironment, users may import their own
+	// environments, and admins may import anyone's.
+	if err := em.authCheck(names.NewUserTag(export.Owner), stateServerEnv.Owner()); err != nil {
+		return errors.Trace(err)
+	}
+	env, st, err := em.state.ImportEnvironment(export)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+	logger.Infof("importing environment %s", env.UUID())
+	return nil
+}
+
+// CompleteEnvironmentImport makes the specified environment, imported
+// by ImportEnvironment, usable once its charm archives, resources and
+// tools have been uploaded, and returns the API addresses its agents
+// should connect to.
+func (em *EnvironmentManagerAPI) CompleteEnvironmentImport(args params.Entity) (params.EnvironmentImportResult, error) {
+	var result params.EnvironmentImportResult
+	st, err := em.hostedEnvironState(args.Tag)
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	defer st.Close()
+	if err := st.CompleteImport(); err != nil {
+		return result, errors.Trace(err)
+	}
+	logger.Infof("imported environment %s", st.EnvironUUID())
+	hostPorts, err := em.state.APIHostPorts()
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	result.Servers = params.FromNetworkHostsPorts(hostPorts)
+	return result, nil
+}
+
+// AbortEnvironmentImport removes an environment imported by
+// ImportEnvironment, when its migration cannot be completed. The
+// environment's machines are left untouched, as they are still
+// managed by the state server it was exported from.
+func (em *EnvironmentManagerAPI) AbortEnvironmentImport(args params.Entity) error {
+	st, err := em.hostedEnvironState(args.Tag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+	logger.Infof("removing imported environment %s", st.EnvironUUID())
+	return errors.Trace(st.RemoveImportedEnvironment())
+}
+
+// SetEnvironmentMigrated records that the specified hosted environment
+// has been imported into the state server with the given API
+// addresses. The environment's workers are stopped, and its agents are
+// told to connect to the new state server.
+func (em *EnvironmentManagerAPI) SetEnvironmentMigrated(args params.SetEnvironmentMigrated) error {
+	st, err := em.hostedEnvironState(args.EnvironTag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer st.Close()
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	logger.Infof("environment %s migrated to %v", env.UUID(), args.Servers)
+	return errors.Trace(env.SetMigrated(params.NetworkHostsPorts(args.Servers)))
+}
@@ -4,6 +4,7 @@
 package environmentmanager_test
 
 import (
+	"github.com/juju/errors"
 	"github.com/juju/loggo"
 	"github.com/juju/names"
 	jc "github.com/juju/testing/checkers"
@@ -16,6 +17,7 @@ import (
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/config"
 	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
 	// Register the providers for the field check test
 	_ "github.com/juju/juju/provider/azure"
 	_ "github.com/juju/juju/provider/ec2"
@@ -25,6 +27,7 @@ import (
 	_ "github.com/juju/juju/provider/openstack"
 	"github.com/juju/juju/state"
 	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
 	"github.com/juju/juju/version"
 )
 
@@ -337,6 +340,237 @@ func (s *envManagerSuite) TestListEnvironmentsDenied(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "permission denied")
 }
 
+func (s *envManagerSuite) makeHostedEnvironment(c *gc.C, owner names.UserTag) *state.State {
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Owner: owner})
+	s.AddCleanup(func(*gc.C) { st.Close() })
+	return st
+}
+
+func (s *envManagerSuite) TestDestroyEnvironment(c *gc.C) {
+	owner := names.NewUserTag("external@remote")
+	st := s.makeHostedEnvironment(c, owner)
+	_, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	s.setAPIUser(c, owner)
+
+	envTag := st.EnvironTag().String()
+	err = s.envmanager.DestroyEnvironment(params.Entity{envTag})
+	c.Assert(err, jc.ErrorIsNil)
+
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.Life(), gc.Equals, state.Dying)
+	needsCleanup, err := st.NeedsCleanup()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(needsCleanup, jc.IsTrue)
+
+	results, err := s.envmanager.EnvironmentStatus(params.Entities{
+		Entities: []params.Entity{{envTag}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results, jc.DeepEquals, []params.EnvironmentStatusResult{{
+		Status: params.EnvironmentStatus{
+			Life:         params.Dying,
+			MachineCount: 1,
+		},
+	}})
+}
+
+func (s *envManagerSuite) TestAdminCanDestroyEnvironmentOfSomeoneElse(c *gc.C) {
+	st := s.makeHostedEnvironment(c, names.NewUserTag("external@remote"))
+	s.setAPIUser(c, s.AdminUserTag(c))
+	err := s.envmanager.DestroyEnvironment(params.Entity{st.EnvironTag().String()})
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *envManagerSuite) TestDestroyEnvironmentDenied(c *gc.C) {
+	st := s.makeHostedEnvironment(c, names.NewUserTag("external@remote"))
+	s.setAPIUser(c, names.NewUserTag("other@remote"))
+	err := s.envmanager.DestroyEnvironment(params.Entity{st.EnvironTag().String()})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+
+	results, err := s.envmanager.EnvironmentStatus(params.Entities{
+		Entities: []params.Entity{{st.EnvironTag().String()}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results[0].Error, gc.ErrorMatches, "permission denied")
+}
+
+func (s *envManagerSuite) TestDestroyStateServerEnvironmentRefused(c *gc.C) {
+	s.setAPIUser(c, s.AdminUserTag(c))
+	err := s.envmanager.DestroyEnvironment(params.Entity{s.State.EnvironTag().String()})
+	c.Assert(err, gc.ErrorMatches, "state server environment cannot be destroyed with this API, use destroy-environment")
+}
+
+func (s *envManagerSuite) TestDestroyEnvironmentBlocked(c *gc.C) {
+	owner := names.NewUserTag("external@remote")
+	st := s.makeHostedEnvironment(c, owner)
+	err := st.SwitchBlockOn(state.DestroyBlock, "TestDestroyEnvironmentBlocked")
+	c.Assert(err, jc.ErrorIsNil)
+	s.setAPIUser(c, owner)
+
+	err = s.envmanager.DestroyEnvironment(params.Entity{st.EnvironTag().String()})
+	c.Assert(params.IsCodeOperationBlocked(err), jc.IsTrue)
+	c.Assert(err, gc.ErrorMatches, "TestDestroyEnvironmentBlocked")
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.Life(), gc.Equals, state.Alive)
+}
+
+func (s *envManagerSuite) TestEnvironmentStatusNotFound(c *gc.C) {
+	s.setAPIUser(c, s.AdminUserTag(c))
+	results, err := s.envmanager.EnvironmentStatus(params.Entities{
+		Entities: []params.Entity{{names.NewEnvironTag("deadbeef-0bad-400d-8000-4b1d0d06f00d").String()}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results[0].Error, jc.Satisfies, params.IsCodeNotFound)
+}
+
+func (s *envManagerSuite) exportArgs(st *state.State) params.ExportEnvironmentArgs {
+	return params.ExportEnvironmentArgs{
+		EnvironTag:   st.EnvironTag().String(),
+		TargetCACert: coretesting.OtherCACert,
+	}
+}
+
+func (s *envManagerSuite) TestExportImportEnvironment(c *gc.C) {
+	owner := names.NewUserTag("external@remote")
+	st := s.makeHostedEnvironment(c, owner)
+	_, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	s.setAPIUser(c, owner)
+
+	envTag := st.EnvironTag().String()
+	export, err := s.envmanager.ExportEnvironment(s.exportArgs(st))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(export.Owner, gc.Equals, owner.Username())
+	c.Assert(export.Documents, gc.Not(gc.HasLen), 0)
+
+	// The environment is frozen, and its agents trust the new state
+	// server's CA as well as their own.
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationExporting)
+	trusted, err := st.TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(trusted, gc.Equals, coretesting.CACert+coretesting.OtherCACert)
+
+	// The environment already exists here, so the import is refused.
+	err = s.envmanager.ImportEnvironment(export)
+	c.Assert(err, gc.ErrorMatches, `cannot import environment: environment ".*" already exists`)
+
+	// Once removed, as when rolling back an import, the environment
+	// can be imported.
+	err = s.envmanager.AbortEnvironmentImport(params.Entity{envTag})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.GetEnvironment(st.EnvironTag())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	err = s.envmanager.ImportEnvironment(export)
+	c.Assert(err, jc.ErrorIsNil)
+	env, err = s.State.GetEnvironment(st.EnvironTag())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.Owner(), gc.Equals, owner)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationImporting)
+	machines, err := st.AllMachines()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(machines, gc.HasLen, 1)
+
+	result, err := s.envmanager.CompleteEnvironmentImport(params.Entity{envTag})
+	c.Assert(err, jc.ErrorIsNil)
+	hostPorts, err := s.State.APIHostPorts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Servers, jc.DeepEquals, params.FromNetworkHostsPorts(hostPorts))
+	err = env.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationNone)
+}
+
+func (s *envManagerSuite) TestAbortEnvironmentExport(c *gc.C) {
+	owner := names.NewUserTag("external@remote")
+	st := s.makeHostedEnvironment(c, owner)
+	s.setAPIUser(c, owner)
+
+	_, err := s.envmanager.ExportEnvironment(s.exportArgs(st))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.envmanager.AbortEnvironmentExport(params.Entity{st.EnvironTag().String()})
+	c.Assert(err, jc.ErrorIsNil)
+
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationNone)
+	trusted, err := st.TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(trusted, gc.Equals, coretesting.CACert)
+}
+
+func (s *envManagerSuite) TestExportEnvironmentDenied(c *gc.C) {
+	st := s.makeHostedEnvironment(c, names.NewUserTag("external@remote"))
+	s.setAPIUser(c, names.NewUserTag("other@remote"))
+	_, err := s.envmanager.ExportEnvironment(s.exportArgs(st))
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *envManagerSuite) TestExportStateServerEnvironmentRefused(c *gc.C) {
+	s.setAPIUser(c, s.AdminUserTag(c))
+	_, err := s.envmanager.ExportEnvironment(s.exportArgs(s.State))
+	c.Assert(err, gc.ErrorMatches, "state server environment cannot be migrated")
+}
+
+func (s *envManagerSuite) TestExportEnvironmentBlocked(c *gc.C) {
+	owner := names.NewUserTag("external@remote")
+	st := s.makeHostedEnvironment(c, owner)
+	err := st.SwitchBlockOn(state.ChangeBlock, "TestExportEnvironmentBlocked")
+	c.Assert(err, jc.ErrorIsNil)
+	s.setAPIUser(c, owner)
+
+	_, err = s.envmanager.ExportEnvironment(s.exportArgs(st))
+	c.Assert(params.IsCodeOperationBlocked(err), jc.IsTrue)
+	c.Assert(err, gc.ErrorMatches, "TestExportEnvironmentBlocked")
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationNone)
+}
+
+func (s *envManagerSuite) TestImportEnvironmentDenied(c *gc.C) {
+	st := s.makeHostedEnvironment(c, names.NewUserTag("external@remote"))
+	s.setAPIUser(c, s.AdminUserTag(c))
+	export, err := s.envmanager.ExportEnvironment(s.exportArgs(st))
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.setAPIUser(c, names.NewUserTag("other@remote"))
+	err = s.envmanager.ImportEnvironment(export)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *envManagerSuite) TestSetEnvironmentMigrated(c *gc.C) {
+	owner := names.NewUserTag("external@remote")
+	st := s.makeHostedEnvironment(c, owner)
+	s.setAPIUser(c, owner)
+
+	servers := [][]params.HostPort{
+		params.FromNetworkHostPorts(network.NewHostPorts(17070, "10.0.0.1")),
+	}
+	args := params.SetEnvironmentMigrated{
+		EnvironTag: st.EnvironTag().String(),
+		Servers:    servers,
+	}
+	err := s.envmanager.SetEnvironmentMigrated(args)
+	c.Assert(err, gc.ErrorMatches, `cannot set environment migrated: environment ".*" is not being exported`)
+
+	_, err = s.envmanager.ExportEnvironment(s.exportArgs(st))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.envmanager.SetEnvironmentMigrated(args)
+	c.Assert(err, jc.ErrorIsNil)
+
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.Life(), gc.Equals, state.Dead)
+	hostPorts, err := st.APIHostPorts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(hostPorts, jc.DeepEquals, params.NetworkHostsPorts(servers))
+}
+
 type fakeProvider struct {
 	environs.EnvironProvider
 }
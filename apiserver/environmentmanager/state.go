@@ -7,6 +7,7 @@ import (
 	"github.com/juju/names"
 
 	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 )
 
@@ -18,6 +19,10 @@ type stateInterface interface {
 	StateServerEnvironment() (*state.Environment, error)
 	NewEnvironment(*config.Config, names.UserTag) (*state.Environment, *state.State, error)
 	EnvironmentsForUser(names.UserTag) ([]*state.Environment, error)
+	GetEnvironment(names.EnvironTag) (*state.Environment, error)
+	ForEnviron(names.EnvironTag) (*state.State, error)
+	ImportEnvironment(*state.EnvironmentExport) (*state.Environment, *state.State, error)
+	APIHostPorts() ([][]network.HostPort, error)
 }
 
 type stateShim struct {
@@ -12,6 +12,7 @@ import (
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
+	"github.com/juju/juju/apiserver/authentication"
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/rpc"
@@ -19,20 +20,25 @@ import (
 )
 
 var (
-	RootType              = reflect.TypeOf(&apiHandler{})
-	NewPingTimeout        = newPingTimeout
-	MaxClientPingInterval = &maxClientPingInterval
-	MongoPingInterval     = &mongoPingInterval
-	NewBackups            = &newBackups
-	ParseLogLine          = parseLogLine
-	AgentMatchesFilter    = agentMatchesFilter
+	RootType                  = reflect.TypeOf(&apiHandler{})
+	NewPingTimeout            = newPingTimeout
+	MaxClientPingInterval     = &maxClientPingInterval
+	MongoPingInterval         = &mongoPingInterval
+	UserDirectorySyncInterval = &userDirectorySyncInterval
+	APIServerReportInterval   = &apiServerReportInterval
+	NewBackups                = &newBackups
+	ParseLogLine              = parseLogLine
+	AgentMatchesFilter        = agentMatchesFilter
 )
 
 func ApiHandlerWithEntity(entity state.Entity) *apiHandler {
 	return &apiHandler{entity: entity}
 }
 
-const LoginRateLimit = loginRateLimit
+const (
+	LoginRateLimit         = loginRateLimit
+	DefaultLoginRetryDelay = defaultLoginRetryDelay
+)
 
 // DelayLogins changes how the Login code works so that logins won't proceed
 // until they get a message on the returned channel.
@@ -44,9 +50,9 @@ func DelayLogins() (nextChan chan struct{}, cleanup func()) {
 	cleanup = func() {
 		doCheckCreds = checkCreds
 	}
-	delayedCheckCreds := func(st *state.State, c params.LoginRequest, lookForEnvUser bool) (state.Entity, *time.Time, error) {
+	delayedCheckCreds := func(st *state.State, c params.LoginRequest, lookForEnvUser bool, userDirectory authentication.Directory) (state.Entity, *state.APIToken, *time.Time, error) {
 		<-nextChan
-		return checkCreds(st, c, lookForEnvUser)
+		return checkCreds(st, c, lookForEnvUser, userDirectory)
 	}
 	doCheckCreds = delayedCheckCreds
 	return
@@ -170,6 +176,13 @@ func TestingAboutToRestoreRoot(st *state.State) *aboutToRestoreRoot {
 	return newAboutToRestoreRoot(r)
 }
 
+// TestingMigratingRoot returns a migratingRoot containing a srvRoot
+// as returned by TestingSrvRoot.
+func TestingMigratingRoot(st *state.State) *migratingRoot {
+	r := TestingApiRoot(st)
+	return newMigratingRoot(r, st)
+}
+
 // LogLineAgentTag gives tests access to an internal logLine attribute
 func (logLine *logLine) LogLineAgentTag() string {
 	return logLine.agentTag
@@ -0,0 +1,112 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package hooktranscripts provides the API server facade used by
+// clients to fetch the hook transcripts recorded by units.
+package hooktranscripts
+
+import (
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("HookTranscripts", 1, NewHookTranscriptsAPI)
+}
+
+// HookTranscripts defines the methods on the hook transcripts API end
+// point.
+type HookTranscripts interface {
+	List(args params.Entities) (params.HookTranscriptsResults, error)
+}
+
+// HookTranscriptsAPI implements the HookTranscripts interface and is
+// the concrete implementation of the api end point.
+type HookTranscriptsAPI struct {
+	state      stateInterface
+	authorizer common.Authorizer
+}
+
+var _ HookTranscripts = (*HookTranscriptsAPI)(nil)
+
+var getState = func(st *state.State) stateInterface {
+	return stateShim{st}
+}
+
+// NewHookTranscriptsAPI creates a new server-side hook transcripts API
+// end point.
+func NewHookTranscriptsAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*HookTranscriptsAPI, error) {
+	// Only clients can access the hook transcripts service.
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &HookTranscriptsAPI{
+		state:      getState(st),
+		authorizer: authorizer,
+	}, nil
+}
+
+// List returns the hook transcripts recorded for each of the given
+// units, newest first.
+func (api *HookTranscriptsAPI) List(args params.Entities) (params.HookTranscriptsResults, error) {
+	result := params.HookTranscriptsResults{
+		Results: make([]params.HookTranscriptsResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		transcripts, err := api.list(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(err)
+			continue
+		}
+		result.Results[i].Transcripts = transcripts
+	}
+	return result, nil
+}
+
+func (api *HookTranscriptsAPI) list(unitTag string) ([]params.HookTranscript, error) {
+	tag, err := names.ParseUnitTag(unitTag)
+	if err != nil {
+		return nil, err
+	}
+	transcripts, err := api.state.HookTranscripts(tag.Id())
+	if err != nil {
+		return nil, err
+	}
+	results := make([]params.HookTranscript, len(transcripts))
+	for i, t := range transcripts {
+		results[i] = HookTranscriptToParams(t)
+	}
+	return results, nil
+}
+
+// HookTranscriptToParams converts a state hook transcript into its API
+// representation.
+func HookTranscriptToParams(t state.HookTranscript) params.HookTranscript {
+	calls := make([]params.HookToolCall, len(t.Calls))
+	for i, call := range t.Calls {
+		calls[i] = params.HookToolCall{
+			Command:   call.Command,
+			Args:      call.Args,
+			Stdin:     call.Stdin,
+			Stdout:    call.Stdout,
+			Stderr:    call.Stderr,
+			Code:      call.Code,
+			Truncated: call.Truncated,
+		}
+	}
+	return params.HookTranscript{
+		Id:       t.Id,
+		Unit:     t.Unit,
+		Kind:     t.Kind,
+		Name:     t.Name,
+		Env:      t.Env,
+		Calls:    calls,
+		ExitCode: t.ExitCode,
+		Error:    t.Error,
+		Started:  t.Started,
+		Duration: t.Duration,
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package hooktranscripts_test
+
+import (
+	"time"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/hooktranscripts"
+	"github.com/juju/juju/apiserver/params"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+)
+
+type hookTranscriptsSuite struct {
+	jujutesting.JujuConnSuite
+
+	api        *hooktranscripts.HookTranscriptsAPI
+	authoriser apiservertesting.FakeAuthorizer
+	unit       *state.Unit
+}
+
+var _ = gc.Suite(&hookTranscriptsSuite{})
+
+func (s *hookTranscriptsSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.authoriser = apiservertesting.FakeAuthorizer{
+		Tag: s.AdminUserTag(c),
+	}
+	var err error
+	s.api, err = hooktranscripts.NewHookTranscriptsAPI(s.State, common.NewResources(), s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.unit = s.Factory.MakeUnit(c, nil)
+	for _, name := range []string{"install", "config-changed"} {
+		err := s.unit.AddHookTranscript(state.HookTranscript{
+			Kind: "hook",
+			Name: name,
+			Env:  []string{"JUJU_UNIT_NAME=" + s.unit.Name()},
+			Calls: []state.HookToolCall{{
+				Command: "config-get",
+				Stdout:  "{}",
+			}},
+			Started:  time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC),
+			Duration: time.Second,
+		})
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *hookTranscriptsSuite) TestNewHookTranscriptsAPIRefusesNonClient(c *gc.C) {
+	anAuthoriser := s.authoriser
+	anAuthoriser.Tag = names.NewUnitTag("mysql/0")
+	endPoint, err := hooktranscripts.NewHookTranscriptsAPI(s.State, common.NewResources(), anAuthoriser)
+	c.Assert(endPoint, gc.IsNil)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *hookTranscriptsSuite) TestList(c *gc.C) {
+	result, err := s.api.List(params.Entities{Entities: []params.Entity{
+		{Tag: s.unit.Tag().String()},
+		{Tag: "unit-missing-0"},
+		{Tag: "machine-0"},
+	}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 3)
+
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	transcripts := result.Results[0].Transcripts
+	c.Assert(transcripts, gc.HasLen, 2)
+	c.Assert(transcripts[0].Name, gc.Equals, "config-changed")
+	c.Assert(transcripts[0].Id, gc.Not(gc.Equals), "")
+	c.Assert(transcripts[1], jc.DeepEquals, params.HookTranscript{
+		Id:   transcripts[1].Id,
+		Unit: s.unit.Name(),
+		Kind: "hook",
+		Name: "install",
+		Env:  []string{"JUJU_UNIT_NAME=" + s.unit.Name()},
+		Calls: []params.HookToolCall{{
+			Command: "config-get",
+			Stdout:  "{}",
+		}},
+		Started:  time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC),
+		Duration: time.Second,
+	})
+
+	c.Assert(result.Results[1].Error, gc.ErrorMatches, `unit "missing/0" not found`)
+	c.Assert(result.Results[2].Error, gc.ErrorMatches, `"machine-0" is not a valid unit tag`)
+}
@@ -0,0 +1,25 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package hooktranscripts
+
+import (
+	"github.com/juju/juju/state"
+)
+
+type stateInterface interface {
+	HookTranscripts(unitName string) ([]state.HookTranscript, error)
+}
+
+type stateShim struct {
+	*state.State
+}
+
+// HookTranscripts returns the hook transcripts of the named unit.
+func (s stateShim) HookTranscripts(unitName string) ([]state.HookTranscript, error) {
+	unit, err := s.State.Unit(unitName)
+	if err != nil {
+		return nil, err
+	}
+	return unit.HookTranscripts()
+}
@@ -11,6 +11,10 @@ const (
 	// DigestSHA is the HTTP digest algorithm value used in juju's HTTP code.
 	DigestSHA DigestAlgorithm = "SHA"
 
+	// DigestSHA256 is the HTTP digest algorithm value used for
+	// SHA-256 digests, such as those of charm resources.
+	DigestSHA256 DigestAlgorithm = "SHA-256"
+
 	// The values used for content-type in juju's direct HTTP code:
 
 	// CTypeJSON is the HTTP content-type value used for JSON content.
@@ -11,6 +11,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/juju/names"
 
+	"github.com/juju/juju/apiserver/authentication"
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/state"
@@ -26,6 +27,8 @@ type httpHandler struct {
 	// rename the state variable to catch all uses of it
 	// state server state connection, used for validation
 	ssState *state.State
+	// userDirectory, if not nil, authenticates directory users
+	userDirectory authentication.Directory
 	// strictValidation means that empty envUUID values are not valid.
 	strictValidation bool
 	// stateServerEnvOnly only validates the state server environment
@@ -34,8 +37,9 @@ type httpHandler struct {
 
 // httpStateWrapper reflects a state connection for a given http connection.
 type httpStateWrapper struct {
-	state       *state.State
-	cleanupFunc func()
+	state         *state.State
+	userDirectory authentication.Directory
+	cleanupFunc   func()
 }
 
 func (h *httpHandler) getEnvironUUID(r *http.Request) string {
@@ -59,7 +63,16 @@ func (h *httpHandler) validateEnvironUUID(r *http.Request) (*httpStateWrapper, e
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	wrapper := &httpStateWrapper{state: envState}
+	if r.Method != "GET" && r.Method != "HEAD" {
+		// Nothing may be changed in an environment being migrated.
+		if err := checkEnvironNotMigrating(envState); err != nil {
+			if needsClosing {
+				envState.Close()
+			}
+			return nil, errors.Trace(err)
+		}
+	}
+	wrapper := &httpStateWrapper{state: envState, userDirectory: h.userDirectory}
 	if needsClosing {
 		wrapper.cleanupFunc = func() {
 			logger.Debugf("close connection to environment: %s", envState.EnvironUUID())
@@ -92,12 +105,20 @@ func (h *httpStateWrapper) authenticate(r *http.Request) (names.Tag, error) {
 	if err != nil {
 		return nil, common.ErrBadCreds
 	}
-	_, _, err = checkCreds(h.state, params.LoginRequest{
+	_, token, _, err := checkCreds(h.state, params.LoginRequest{
 		AuthTag:     tagPass[0],
 		Credentials: tagPass[1],
 		Nonce:       r.Header.Get("X-Juju-Nonce"),
-	}, true)
-	return tag, err
+	}, true, h.userDirectory)
+	if err != nil {
+		return nil, err
+	}
+	if token != nil && len(token.Facades()) > 0 {
+		// Tokens restricted to particular facades give no access to
+		// the HTTP endpoints.
+		return nil, common.ErrPerm
+	}
+	return tag, nil
 }
 
 func (h *httpStateWrapper) authenticateUser(r *http.Request) error {
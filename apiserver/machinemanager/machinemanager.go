@@ -10,6 +10,7 @@ import (
 
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cloudconfig/cloudinit"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/state"
@@ -134,6 +135,12 @@ func (mm *MachineManagerAPI) addOneMachine(p params.AddMachineParams) (*state.Ma
 		}
 	}
 
+	if p.CloudInitUserData != "" {
+		if _, err := cloudinit.ParseUserData(p.CloudInitUserData); err != nil {
+			return nil, errors.Annotate(err, "invalid cloud-init user data")
+		}
+	}
+
 	jobs, err := common.StateJobs(p.Jobs)
 	if err != nil {
 		return nil, err
@@ -148,6 +155,7 @@ func (mm *MachineManagerAPI) addOneMachine(p params.AddMachineParams) (*state.Ma
 		HardwareCharacteristics: p.HardwareCharacteristics,
 		Addresses:               params.NetworkAddresses(p.Addrs),
 		Placement:               placementDirective,
+		CloudInitUserData:       p.CloudInitUserData,
 	}
 	if p.ContainerType == "" {
 		return mm.st.AddOneMachine(template)
@@ -155,5 +163,8 @@ func (mm *MachineManagerAPI) addOneMachine(p params.AddMachineParams) (*state.Ma
 	if p.ParentId != "" {
 		return mm.st.AddMachineInsideMachine(template, p.ParentId, p.ContainerType)
 	}
-	return mm.st.AddMachineInsideNewMachine(template, template, p.ContainerType)
+	// The user data is meant for the container, not its new host.
+	parentTemplate := template
+	parentTemplate.CloudInitUserData = ""
+	return mm.st.AddMachineInsideNewMachine(template, parentTemplate, p.ContainerType)
 }
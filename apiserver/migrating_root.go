@@ -0,0 +1,87 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/utils/set"
+
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/rpcreflect"
+	"github.com/juju/juju/state"
+)
+
+var migrationInProgressError = errors.New("environment migration is in progress - changes are not allowed")
+
+// migratingRoot is a root for the API of a hosted environment that
+// refuses all but a limited set of methods, defined in
+// allowedMethodsDuringMigration, while the environment is being
+// migrated to another state server, so that no changes made to it are
+// lost.
+type migratingRoot struct {
+	rpc.MethodFinder
+	st *state.State
+}
+
+// newMigratingRoot creates a root where API calls fail with
+// migrationInProgressError while the environment controlled by st is
+// being migrated.
+func newMigratingRoot(finder rpc.MethodFinder, st *state.State) *migratingRoot {
+	return &migratingRoot{
+		MethodFinder: finder,
+		st:           st,
+	}
+}
+
+// FindMethod extends srvRoot.FindMethod. Whether the environment is
+// being migrated is checked on every call, as a migration may start
+// at any time after the connection was made.
+func (r *migratingRoot) FindMethod(rootName string, version int, methodName string) (rpcreflect.MethodCaller, error) {
+	caller, err := r.MethodFinder.FindMethod(rootName, version, methodName)
+	if err != nil {
+		return nil, err
+	}
+	if allowedMethodsDuringMigration.Contains(rootName + "." + methodName) {
+		return caller, nil
+	}
+	if err := checkEnvironNotMigrating(r.st); err != nil {
+		return nil, err
+	}
+	return caller, nil
+}
+
+var allowedMethodsDuringMigration = set.NewStrings(
+	"Pinger.Ping",
+	"Client.FullStatus",     // for "juju status"
+	"Client.EnvironmentGet", // for "juju ssh"
+	"Client.PrivateAddress", // for "juju ssh"
+	"Client.PublicAddress",  // for "juju ssh"
+	"Client.WatchDebugLog",  // for "juju debug-log"
+
+	// For "juju environment migrate".
+	"EnvironmentManager.ExportEnvironment",
+	"EnvironmentManager.AbortEnvironmentExport",
+	"EnvironmentManager.SetEnvironmentMigrated",
+	"EnvironmentManager.EnvironmentStatus",
+
+	// So that agents learn to trust the CA of the state server
+	// the environment is moving to before they are sent there.
+	"Agent.TrustedCACert",
+	"Agent.WatchTrustedCACert",
+	"NotifyWatcher.Next",
+	"NotifyWatcher.Stop",
+)
+
+// checkEnvironNotMigrating returns migrationInProgressError if the
+// environment controlled by st is being migrated.
+func checkEnvironNotMigrating(st *state.State) error {
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if env.MigrationMode() != state.MigrationNone {
+		return migrationInProgressError
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+)
+
+type migratingRootSuite struct {
+	jujutesting.JujuConnSuite
+	st  *state.State
+	env *state.Environment
+}
+
+var _ = gc.Suite(&migratingRootSuite{})
+
+func (s *migratingRootSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.st = s.Factory.MakeEnvironment(c, nil)
+	s.AddCleanup(func(*gc.C) { s.st.Close() })
+	var err error
+	s.env, err = s.st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *migratingRootSuite) TestAllowedWhenNotMigrating(c *gc.C) {
+	root := apiserver.TestingMigratingRoot(s.st)
+	caller, err := root.FindMethod("Client", 0, "ServiceDeploy")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(caller, gc.NotNil)
+}
+
+func (s *migratingRootSuite) TestDisallowedWhenMigrating(c *gc.C) {
+	root := apiserver.TestingMigratingRoot(s.st)
+	err := s.env.StartExport(testing.OtherCACert)
+	c.Assert(err, jc.ErrorIsNil)
+
+	caller, err := root.FindMethod("Client", 0, "ServiceDeploy")
+	c.Assert(err, gc.ErrorMatches, "environment migration is in progress - changes are not allowed")
+	c.Assert(caller, gc.IsNil)
+
+	caller, err = root.FindMethod("Client", 0, "FullStatus")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(caller, gc.NotNil)
+
+	err = s.env.AbortExport()
+	c.Assert(err, jc.ErrorIsNil)
+	caller, err = root.FindMethod("Client", 0, "ServiceDeploy")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(caller, gc.NotNil)
+}
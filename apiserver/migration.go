@@ -0,0 +1,200 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	apihttp "github.com/juju/juju/apiserver/http"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/toolstorage"
+)
+
+// migrationHandler copies the charm archives, resources and tools of
+// an environment migrated between state servers, which are too large
+// to be sent with the environment's documents over the API. They are
+// downloaded from the environment being exported, and uploaded to the
+// state server environment of the state server importing it.
+type migrationHandler struct {
+	httpHandler
+}
+
+func (h *migrationHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	stateWrapper, err := h.validateEnvironUUID(req)
+	if err != nil {
+		h.sendError(resp, http.StatusNotFound, err.Error())
+		return
+	}
+	defer stateWrapper.cleanup()
+
+	tag, err := stateWrapper.authenticate(req)
+	if err != nil {
+		h.authError(resp, h)
+		return
+	}
+	user, ok := tag.(names.UserTag)
+	if !ok {
+		h.authError(resp, h)
+		return
+	}
+
+	switch req.Method {
+	case "GET":
+		args, err := h.parseGETArgs(req)
+		if err != nil {
+			h.sendError(resp, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := h.download(stateWrapper.state, user, args, resp); err != nil {
+			h.sendServerError(resp, err)
+		}
+	case "PUT":
+		if err := h.upload(stateWrapper.state, user, req); err != nil {
+			h.sendServerError(resp, err)
+			return
+		}
+		h.sendJSON(resp, http.StatusOK, &params.ErrorResult{})
+	default:
+		h.sendError(resp, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", req.Method))
+	}
+}
+
+// checkAccess returns an error unless the user may migrate the given
+// environment: as with the EnvironmentManager facade, users may
+// migrate their own environments, and admins may migrate anyone's.
+func (h *migrationHandler) checkAccess(user names.UserTag, env *state.Environment) error {
+	ssEnv, err := h.ssState.StateServerEnvironment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if user != env.Owner() && user != ssEnv.Owner() {
+		return common.ErrPerm
+	}
+	return nil
+}
+
+// download sends a charm archive, resource or tools tarball of the
+// environment being exported.
+func (h *migrationHandler) download(st *state.State, user names.UserTag, args *params.MigrationBlobArgs, resp http.ResponseWriter) error {
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := h.checkAccess(user, env); err != nil {
+		return errors.Trace(err)
+	}
+	var content io.ReadCloser
+	var size int64
+	if args.Tools != nil {
+		var metadata toolstorage.Metadata
+		metadata, content, err = st.OpenExportedTools(args.Tools.Version)
+		size = metadata.Size
+	} else {
+		content, size, err = st.OpenExportedBlob(args.StoragePath)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer content.Close()
+
+	resp.Header().Set("Content-Type", apihttp.CTypeRaw)
+	resp.Header().Set("Content-Length", fmt.Sprint(size))
+	resp.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(resp, content); err != nil {
+		// The status has already been sent, so the client will only
+		// notice the failure through the size check.
+		logger.Errorf("cannot send migrated blob: %v", err)
+	}
+	return nil
+}
+
+// upload stores a charm archive, resource or tools tarball of an
+// environment being imported. Uploads are made to the state server
+// environment, as the imported environment cannot yet be used.
+func (h *migrationHandler) upload(st *state.State, user names.UserTag, req *http.Request) error {
+	defer req.Body.Close()
+
+	if st.EnvironUUID() != h.ssState.EnvironUUID() {
+		return errors.NotValidf("upload to hosted environment")
+	}
+	var args params.MigrationBlobArgs
+	content, err := apihttp.ExtractRequestAttachment(req, &args)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer content.Close()
+
+	envTag, err := names.ParseEnvironTag(args.EnvironTag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	env, err := h.ssState.GetEnvironment(envTag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := h.checkAccess(user, env); err != nil {
+		return errors.Trace(err)
+	}
+	envState, err := h.ssState.ForEnviron(envTag)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer envState.Close()
+	if args.Tools != nil {
+		return envState.AddImportedTools(content, toolstorage.Metadata{
+			Version: args.Tools.Version,
+			Size:    args.Tools.Size,
+			SHA256:  args.Tools.SHA256,
+		})
+	}
+	if args.Size < 0 {
+		return errors.NotValidf("size %d", args.Size)
+	}
+	return envState.AddImportedBlob(args.StoragePath, content, args.Size)
+}
+
+func (h *migrationHandler) parseGETArgs(req *http.Request) (*params.MigrationBlobArgs, error) {
+	defer req.Body.Close()
+
+	ctype := req.Header.Get("Content-Type")
+	if ctype != apihttp.CTypeJSON {
+		return nil, errors.Errorf("expected Content-Type %q, got %q", apihttp.CTypeJSON, ctype)
+	}
+	var args params.MigrationBlobArgs
+	if err := json.NewDecoder(req.Body).Decode(&args); err != nil {
+		return nil, errors.Annotate(err, "while de-serializing args")
+	}
+	return &args, nil
+}
+
+// sendJSON sends a JSON-encoded result.
+func (h *migrationHandler) sendJSON(w http.ResponseWriter, statusCode int, result interface{}) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		logger.Errorf("failed to serialize the result (%v): %v", result, err)
+		return
+	}
+	w.Header().Set("Content-Type", apihttp.CTypeJSON)
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+// sendError sends a JSON-encoded error response.
+func (h *migrationHandler) sendError(w http.ResponseWriter, statusCode int, message string) {
+	h.sendJSON(w, statusCode, &params.Error{Message: message})
+}
+
+// sendServerError sends a JSON-encoded error response including the
+// error code.
+func (h *migrationHandler) sendServerError(w http.ResponseWriter, err error) {
+	h.sendJSON(w, statusForError(err), common.ServerError(err))
+}
@@ -0,0 +1,195 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	apihttp "github.com/juju/juju/apiserver/http"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/storage"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+type migrationSuite struct {
+	userAuthHttpSuite
+	hostedState *state.State
+	charmPath   string
+}
+
+var _ = gc.Suite(&migrationSuite{})
+
+func (s *migrationSuite) SetUpTest(c *gc.C) {
+	s.userAuthHttpSuite.SetUpTest(c)
+	s.hostedState = s.Factory.MakeEnvironment(c, &factory.EnvParams{Owner: s.userTag})
+	s.AddCleanup(func(*gc.C) { s.hostedState.Close() })
+
+	ch := factory.NewFactory(s.hostedState).MakeCharm(c, nil)
+	s.charmPath = ch.StoragePath()
+	stor := storage.NewStorage(s.hostedState.EnvironUUID(), s.hostedState.MongoSession())
+	err := stor.Put(s.charmPath, bytes.NewReader([]byte("archive")), 7)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *migrationSuite) migrationURL(c *gc.C, uuid string) string {
+	uri := s.baseURL(c)
+	uri.Path = fmt.Sprintf("/environment/%s/migration", uuid)
+	return uri.String()
+}
+
+func (s *migrationSuite) checkErrorResponse(c *gc.C, resp *http.Response, statusCode int, msg string) {
+	c.Check(resp.StatusCode, gc.Equals, statusCode)
+	c.Check(resp.Header.Get("Content-Type"), gc.Equals, apihttp.CTypeJSON)
+
+	var failure params.Error
+	err := json.NewDecoder(resp.Body).Decode(&failure)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(&failure, gc.ErrorMatches, msg)
+}
+
+func (s *migrationSuite) startExport(c *gc.C) {
+	env, err := s.hostedState.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.StartExport(testing.OtherCACert)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *migrationSuite) get(c *gc.C, tag, password, path string) *http.Response {
+	body, err := json.Marshal(params.MigrationBlobArgs{StoragePath: path})
+	c.Assert(err, jc.ErrorIsNil)
+	uri := s.migrationURL(c, s.hostedState.EnvironUUID())
+	resp, err := s.sendRequest(c, tag, password, "GET", uri, apihttp.CTypeJSON, bytes.NewReader(body))
+	c.Assert(err, jc.ErrorIsNil)
+	return resp
+}
+
+func (s *migrationSuite) put(c *gc.C, uuid string, args params.MigrationBlobArgs, content string) *http.Response {
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="metadata"`)
+	header.Set("Content-Type", apihttp.CTypeJSON)
+	part, err := writer.CreatePart(header)
+	c.Assert(err, jc.ErrorIsNil)
+	err = json.NewEncoder(part).Encode(args)
+	c.Assert(err, jc.ErrorIsNil)
+
+	part, err = writer.CreateFormFile("attached", "blob")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = io.WriteString(part, content)
+	c.Assert(err, jc.ErrorIsNil)
+	err = writer.Close()
+	c.Assert(err, jc.ErrorIsNil)
+
+	resp, err := s.authRequest(c, "PUT", s.migrationURL(c, uuid), writer.FormDataContentType(), &parts)
+	c.Assert(err, jc.ErrorIsNil)
+	return resp
+}
+
+func (s *migrationSuite) TestRequiresAuth(c *gc.C) {
+	resp := s.get(c, "", "", s.charmPath)
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+}
+
+func (s *migrationSuite) TestInvalidHTTPMethods(c *gc.C) {
+	for _, method := range []string{"POST", "DELETE", "OPTIONS"} {
+		c.Logf("testing HTTP method: %s", method)
+		uri := s.migrationURL(c, s.State.EnvironUUID())
+		resp, err := s.authRequest(c, method, uri, "", nil)
+		c.Assert(err, jc.ErrorIsNil)
+		s.checkErrorResponse(c, resp, http.StatusMethodNotAllowed, `unsupported method: "`+method+`"`)
+		resp.Body.Close()
+	}
+}
+
+func (s *migrationSuite) TestDownload(c *gc.C) {
+	s.startExport(c)
+	resp := s.get(c, s.userTag.String(), s.password, s.charmPath)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+	c.Assert(resp.ContentLength, gc.Equals, int64(7))
+	data, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "archive")
+}
+
+func (s *migrationSuite) TestDownloadNotExporting(c *gc.C) {
+	resp := s.get(c, s.userTag.String(), s.password, s.charmPath)
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusInternalServerError, `environment ".*" is not exporting`)
+}
+
+func (s *migrationSuite) TestDownloadDenied(c *gc.C) {
+	s.startExport(c)
+	user := s.Factory.MakeUser(c, &factory.UserParams{Password: "other"})
+	_, err := s.hostedState.AddEnvironmentUser(user.UserTag(), s.userTag, "")
+	c.Assert(err, jc.ErrorIsNil)
+	resp := s.get(c, user.Tag().String(), "other", s.charmPath)
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusForbidden, "permission denied")
+}
+
+func (s *migrationSuite) TestUpload(c *gc.C) {
+	s.startExport(c)
+	export, err := s.hostedState.ExportEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.hostedState.RemoveImportedEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	_, importedSt, err := s.State.ImportEnvironment(export)
+	c.Assert(err, jc.ErrorIsNil)
+	defer importedSt.Close()
+
+	// The blob is uploaded through the state server environment.
+	resp := s.put(c, s.State.EnvironUUID(), params.MigrationBlobArgs{
+		EnvironTag:  importedSt.EnvironTag().String(),
+		StoragePath: s.charmPath,
+		Size:        7,
+	}, "archive")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+
+	err = importedSt.CompleteImport()
+	c.Assert(err, jc.ErrorIsNil)
+	stor := storage.NewStorage(importedSt.EnvironUUID(), importedSt.MongoSession())
+	r, _, err := stor.Get(s.charmPath)
+	c.Assert(err, jc.ErrorIsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "archive")
+}
+
+func (s *migrationSuite) TestUploadNotImporting(c *gc.C) {
+	resp := s.put(c, s.State.EnvironUUID(), params.MigrationBlobArgs{
+		EnvironTag:  s.hostedState.EnvironTag().String(),
+		StoragePath: s.charmPath,
+		Size:        7,
+	}, "archive")
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusInternalServerError, `environment ".*" is not importing`)
+}
+
+func (s *migrationSuite) TestUploadToHostedEnvironment(c *gc.C) {
+	resp := s.put(c, s.hostedState.EnvironUUID(), params.MigrationBlobArgs{
+		EnvironTag:  s.hostedState.EnvironTag().String(),
+		StoragePath: s.charmPath,
+		Size:        7,
+	}, "archive")
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusBadRequest, "upload to hosted environment not valid")
+}
@@ -117,3 +117,120 @@ type ServiceCharmActionsResult struct {
 	Actions    *charm.Actions `json:"actions,omitempty"`
 	Error      *Error         `json:"error,omitempty"`
 }
+
+// ActionSchedules holds a slice of ActionSchedule for bulk requests.
+type ActionSchedules struct {
+	Schedules []ActionSchedule `json:"schedules,omitempty"`
+}
+
+// ActionSchedule describes a schedule on which an action is enqueued on
+// a set of receivers. Exactly one of Cron and Interval is set.
+type ActionSchedule struct {
+	Id         string                 `json:"id,omitempty"`
+	Receivers  []string               `json:"receivers"`
+	Name       string                 `json:"name"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Cron       string                 `json:"cron,omitempty"`
+	Interval   time.Duration          `json:"interval,omitempty"`
+	Created    time.Time              `json:"created,omitempty"`
+	NextRun    time.Time              `json:"nextrun,omitempty"`
+	LastRun    time.Time              `json:"lastrun,omitempty"`
+}
+
+// ActionScheduleResults holds a slice of ActionScheduleResult for bulk
+// results.
+type ActionScheduleResults struct {
+	Results []ActionScheduleResult `json:"results,omitempty"`
+}
+
+// ActionScheduleResult holds an action schedule or an error.
+type ActionScheduleResult struct {
+	Schedule *ActionSchedule `json:"schedule,omitempty"`
+	Error    *Error          `json:"error,omitempty"`
+}
+
+// ActionScheduleIds holds the ids of action schedules.
+type ActionScheduleIds struct {
+	Ids []string `json:"ids"`
+}
+
+// ActionScheduleRun records the actions enqueued by one run of an
+// action schedule, and any errors encountered enqueueing them.
+type ActionScheduleRun struct {
+	ScheduleId string    `json:"scheduleid"`
+	Time       time.Time `json:"time"`
+	Actions    []string  `json:"actions,omitempty"`
+	Errors     []string  `json:"errors,omitempty"`
+}
+
+// ActionScheduleRunsResults holds a slice of ActionScheduleRunsResult
+// for bulk results.
+type ActionScheduleRunsResults struct {
+	Results []ActionScheduleRunsResult `json:"results,omitempty"`
+}
+
+// ActionScheduleRunsResult holds the runs of an action schedule, newest
+// first, or an error.
+type ActionScheduleRunsResult struct {
+	Runs  []ActionScheduleRun `json:"runs,omitempty"`
+	Error *Error              `json:"error,omitempty"`
+}
+
+// ActionBatches holds a slice of ActionBatch for bulk requests.
+type ActionBatches struct {
+	Batches []ActionBatch `json:"batches,omitempty"`
+}
+
+// ActionBatch describes an action run across the units of a service as
+// a single operation. When enqueueing a batch, Units may be left empty to
+// run the action on every unit of the service.
+type ActionBatch struct {
+	Id            string                 `json:"id,omitempty"`
+	Service       string                 `json:"service"`
+	Name          string                 `json:"name"`
+	Parameters    map[string]interface{} `json:"parameters,omitempty"`
+	MaxConcurrent int                    `json:"maxconcurrent,omitempty"`
+	LeaderOnly    bool                   `json:"leaderonly,omitempty"`
+	Status        string                 `json:"status,omitempty"`
+	Message       string                 `json:"message,omitempty"`
+	Enqueued      time.Time              `json:"enqueued,omitempty"`
+	Completed     time.Time              `json:"completed,omitempty"`
+	Units         []ActionBatchUnit      `json:"units,omitempty"`
+}
+
+const (
+	// ActionBatchWaiting is the status of a unit in an action batch
+	// on which the action has yet to be enqueued.
+	ActionBatchWaiting string = "waiting"
+
+	// ActionBatchSkipped is the status of a unit in an action batch
+	// on which the action will not be enqueued, because the batch was
+	// stopped early.
+	ActionBatchSkipped string = "skipped"
+)
+
+// ActionBatchUnit describes the progress of an action batch on one of
+// its units. Action is empty until the action has been enqueued on the
+// unit.
+type ActionBatchUnit struct {
+	Unit   string `json:"unit"`
+	Action string `json:"action,omitempty"`
+	Status string `json:"status"`
+}
+
+// ActionBatchResults holds a slice of ActionBatchResult for bulk
+// results.
+type ActionBatchResults struct {
+	Results []ActionBatchResult `json:"results,omitempty"`
+}
+
+// ActionBatchResult holds an action batch or an error.
+type ActionBatchResult struct {
+	Batch *ActionBatch `json:"batch,omitempty"`
+	Error *Error       `json:"error,omitempty"`
+}
+
+// ActionBatchIds holds the ids of action batches.
+type ActionBatchIds struct {
+	Ids []string `json:"ids"`
+}
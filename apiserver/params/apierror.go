@@ -5,6 +5,7 @@ package params
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/juju/errors"
 
@@ -15,6 +16,7 @@ import (
 type Error struct {
 	Message string
 	Code    string
+	Info    map[string]interface{} `json:",omitempty"`
 }
 
 func (e *Error) Error() string {
@@ -25,7 +27,14 @@ func (e *Error) ErrorCode() string {
 	return e.Code
 }
 
-var _ rpc.ErrorCoder = (*Error)(nil)
+func (e *Error) ErrorInfo() map[string]interface{} {
+	return e.Info
+}
+
+var (
+	_ rpc.ErrorCoder  = (*Error)(nil)
+	_ rpc.ErrorInfoer = (*Error)(nil)
+)
 
 // GoString implements fmt.GoStringer.  It means that a *Error shows its
 // contents correctly when printed with %#v.
@@ -55,6 +64,7 @@ const (
 	CodeActionNotAvailable        = "action no longer available"
 	CodeOperationBlocked          = "operation is blocked"
 	CodeLeadershipClaimDenied     = "leadership claim denied"
+	CodeQuotaExceeded             = "quota exceeded"
 )
 
 // ErrCode returns the error code associated with
@@ -82,9 +92,39 @@ func ClientError(err error) error {
 	return &Error{
 		Message: rerr.Message,
 		Code:    rerr.Code,
+		Info:    rerr.Info,
+	}
+}
+
+// retryAfterKey is the key in an error's information that holds the
+// number of seconds after which the server suggests that the failed
+// request be retried.
+const retryAfterKey = "retry-after"
+
+// RetryAfterInfo returns error information holding a suggestion
+// that the failed request be retried after the given delay.
+func RetryAfterInfo(delay time.Duration) map[string]interface{} {
+	return map[string]interface{}{
+		retryAfterKey: delay.Seconds(),
 	}
 }
 
+// RetryAfter returns the delay after which the server suggested
+// that the request that failed with the given error be retried,
+// and whether it made any such suggestion.
+func RetryAfter(err error) (time.Duration, bool) {
+	err = errors.Cause(err)
+	infoer, ok := err.(rpc.ErrorInfoer)
+	if !ok {
+		return 0, false
+	}
+	seconds, ok := infoer.ErrorInfo()[retryAfterKey].(float64)
+	if !ok || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
 func IsCodeActionNotAvailable(err error) bool {
 	return ErrCode(err) == CodeActionNotAvailable
 }
@@ -173,3 +213,7 @@ func IsCodeOperationBlocked(err error) bool {
 func IsCodeLeadershipClaimDenied(err error) bool {
 	return ErrCode(err) == CodeLeadershipClaimDenied
 }
+
+func IsCodeQuotaExceeded(err error) bool {
+	return ErrCode(err) == CodeQuotaExceeded
+}
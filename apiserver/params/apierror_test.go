@@ -4,10 +4,15 @@
 package params_test
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/rpc"
 )
 
 type errorSuite struct{}
@@ -22,3 +27,44 @@ func (*errorSuite) TestErrCode(c *gc.C) {
 	err = errors.Trace(err)
 	c.Check(params.ErrCode(err), gc.Equals, params.CodeDead)
 }
+
+func (*errorSuite) TestRetryAfter(c *gc.C) {
+	var err error
+	err = &params.Error{
+		Code:    params.CodeTryAgain,
+		Message: "try again",
+		Info:    params.RetryAfterInfo(1500 * time.Millisecond),
+	}
+	delay, ok := params.RetryAfter(err)
+	c.Check(ok, jc.IsTrue)
+	c.Check(delay, gc.Equals, 1500*time.Millisecond)
+
+	delay, ok = params.RetryAfter(errors.Trace(err))
+	c.Check(ok, jc.IsTrue)
+	c.Check(delay, gc.Equals, 1500*time.Millisecond)
+
+	_, ok = params.RetryAfter(&params.Error{Code: params.CodeTryAgain})
+	c.Check(ok, jc.IsFalse)
+	_, ok = params.RetryAfter(errors.New("try again"))
+	c.Check(ok, jc.IsFalse)
+}
+
+func (*errorSuite) TestRetryAfterFromClientError(c *gc.C) {
+	// The information survives a round trip through JSON, as
+	// happens when the error is sent to the client.
+	data, err := json.Marshal(params.RetryAfterInfo(2 * time.Second))
+	c.Assert(err, jc.ErrorIsNil)
+	var info map[string]interface{}
+	err = json.Unmarshal(data, &info)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = params.ClientError(&rpc.RequestError{
+		Message: "try again",
+		Code:    params.CodeTryAgain,
+		Info:    info,
+	})
+	c.Check(params.IsCodeTryAgain(err), jc.IsTrue)
+	delay, ok := params.RetryAfter(err)
+	c.Check(ok, jc.IsTrue)
+	c.Check(delay, gc.Equals, 2*time.Second)
+}
@@ -0,0 +1,38 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+import (
+	"time"
+)
+
+// CollectUnusedBlobsArgs holds the arguments for removing unused
+// blobs from controller storage.
+type CollectUnusedBlobsArgs struct {
+	// GracePeriod is the time for which a blob must have been unused
+	// before it is removed. If zero, the default is used.
+	GracePeriod time.Duration `json:"grace-period"`
+
+	// DryRun requests a report of the unused blobs, without
+	// recording or removing anything.
+	DryRun bool `json:"dry-run"`
+}
+
+// UnusedBlob describes a charm archive, tools tarball or container
+// image that is no longer used by its environment.
+type UnusedBlob struct {
+	EnvUUID     string    `json:"env-uuid"`
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Size        int64     `json:"size"`
+	UnusedSince time.Time `json:"unused-since"`
+	Expired     bool      `json:"expired"`
+	Removed     bool      `json:"removed"`
+}
+
+// UnusedBlobsResult holds the unused blobs found in controller
+// storage.
+type UnusedBlobsResult struct {
+	Blobs []UnusedBlob `json:"blobs"`
+}
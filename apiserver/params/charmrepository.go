@@ -0,0 +1,24 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+import (
+	"time"
+)
+
+// RepositoryCharm describes a charm archive published in the
+// controller's charm repository.
+type RepositoryCharm struct {
+	URL         string    `json:"url"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	Published   time.Time `json:"published"`
+	PublishedBy string    `json:"published-by"`
+}
+
+// RepositoryCharmsResult holds the published revisions of a charm, as
+// listed by the controller's charm repository HTTP endpoint.
+type RepositoryCharmsResult struct {
+	Charms []RepositoryCharm `json:"charms"`
+}
@@ -0,0 +1,53 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+import "time"
+
+// ConfigChangeType describes how a config setting was changed.
+type ConfigChangeType string
+
+const (
+	ConfigSettingAdded    ConfigChangeType = "added"
+	ConfigSettingModified ConfigChangeType = "modified"
+	ConfigSettingDeleted  ConfigChangeType = "deleted"
+)
+
+// ConfigChange holds a single change made to a config setting.
+type ConfigChange struct {
+	Type     ConfigChangeType `json:"type"`
+	Key      string           `json:"key"`
+	OldValue interface{}      `json:"old-value,omitempty"`
+	NewValue interface{}      `json:"new-value,omitempty"`
+}
+
+// ConfigRevision holds a recorded change to the environment config or
+// to a service's charm config settings, and the settings as they were
+// after the change.
+type ConfigRevision struct {
+	Revision int                    `json:"revision"`
+	Settings map[string]interface{} `json:"settings"`
+	Changes  []ConfigChange         `json:"changes"`
+	Author   string                 `json:"author,omitempty"`
+	Time     time.Time              `json:"time"`
+}
+
+// ConfigHistoryResult holds the recorded revisions of a config, newest
+// first.
+type ConfigHistoryResult struct {
+	Revisions []ConfigRevision `json:"revisions"`
+}
+
+// RollbackEnvironConfig holds the revision of the environment config
+// to restore.
+type RollbackEnvironConfig struct {
+	Revision int `json:"revision"`
+}
+
+// RollbackServiceConfig holds the revision of a service's charm config
+// settings to restore.
+type RollbackServiceConfig struct {
+	Service  string `json:"service"`
+	Revision int    `json:"revision"`
+}
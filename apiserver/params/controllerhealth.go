@@ -0,0 +1,59 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+import (
+	"time"
+)
+
+// StateServerHealth holds the health of a single state server.
+type StateServerHealth struct {
+	MachineId string `json:"machine-id"`
+
+	// AgentAlive reports whether the presence pinger of the state
+	// server's machine agent is alive.
+	AgentAlive bool `json:"agent-alive"`
+
+	// The following fields are taken from the latest report made by
+	// the state server's API server, at ReportedAt. ReportedAt is zero
+	// if the API server has never reported. ClockOffset holds how far
+	// the state server's clock is ahead of the database clock, give or
+	// take ClockUncertainty.
+	APIConnections   int           `json:"api-connections"`
+	ClockOffset      time.Duration `json:"clock-offset"`
+	ClockUncertainty time.Duration `json:"clock-uncertainty"`
+	ReportedAt       time.Time     `json:"reported-at"`
+}
+
+// ReplicaSetMemberHealth holds the health of a member of the mongo
+// replica set shared by the state servers.
+type ReplicaSetMemberHealth struct {
+	Id        int           `json:"id"`
+	Address   string        `json:"address"`
+	MachineId string        `json:"machine-id,omitempty"`
+	State     string        `json:"state"`
+	Healthy   bool          `json:"healthy"`
+	Lag       time.Duration `json:"lag"`
+}
+
+// ControllerHealthReport holds a report on the health of the state
+// servers and of the database they share.
+type ControllerHealthReport struct {
+	StateServers []StateServerHealth `json:"state-servers"`
+
+	// ReplicaSet holds the health of the replica set members, unless
+	// it could not be read, in which case ReplicaSetError holds why.
+	ReplicaSet      []ReplicaSetMemberHealth `json:"replica-set"`
+	ReplicaSetError string                   `json:"replica-set-error,omitempty"`
+
+	// MaxClockSkew holds the largest difference between the clocks
+	// of the state servers, as last reported by their API servers.
+	MaxClockSkew time.Duration `json:"max-clock-skew"`
+
+	PendingTransactions int        `json:"pending-transactions"`
+	PendingCleanups     int        `json:"pending-cleanups"`
+	OldestCleanup       *time.Time `json:"oldest-cleanup,omitempty"`
+	LogCount            int        `json:"log-count"`
+	LogSize             int64      `json:"log-size"`
+}
@@ -0,0 +1,59 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+import (
+	"time"
+)
+
+// HookToolCall holds a single hook tool invocation recorded in a
+// hook transcript, along with the response it was given.
+type HookToolCall struct {
+	Command   string   `json:"command"`
+	Args      []string `json:"args,omitempty"`
+	Stdin     string   `json:"stdin,omitempty"`
+	Stdout    string   `json:"stdout,omitempty"`
+	Stderr    string   `json:"stderr,omitempty"`
+	Code      int      `json:"code"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// HookTranscript holds the record of a single hook or action
+// execution by a unit.
+type HookTranscript struct {
+	Id       string         `json:"id,omitempty"`
+	Unit     string         `json:"unit,omitempty"`
+	Kind     string         `json:"kind"`
+	Name     string         `json:"name"`
+	Env      []string       `json:"env"`
+	Calls    []HookToolCall `json:"calls,omitempty"`
+	ExitCode int            `json:"exit-code"`
+	Error    string         `json:"error,omitempty"`
+	Started  time.Time      `json:"started"`
+	Duration time.Duration  `json:"duration"`
+}
+
+// HookTranscriptArg holds a hook transcript to store for a unit.
+type HookTranscriptArg struct {
+	Tag        string         `json:"tag"`
+	Transcript HookTranscript `json:"transcript"`
+}
+
+// HookTranscriptArgs holds the arguments for storing hook transcripts.
+type HookTranscriptArgs struct {
+	Args []HookTranscriptArg `json:"args"`
+}
+
+// HookTranscriptsResult holds the hook transcripts recorded for a
+// unit, or an error.
+type HookTranscriptsResult struct {
+	Transcripts []HookTranscript `json:"transcripts,omitempty"`
+	Error       *Error           `json:"error,omitempty"`
+}
+
+// HookTranscriptsResults holds the results of a HookTranscripts.List
+// call.
+type HookTranscriptsResults struct {
+	Results []HookTranscriptsResult `json:"results"`
+}
@@ -121,6 +121,93 @@ type EnvironmentList struct {
 	Environments []Environment
 }
 
+// EnvironmentStatus holds the lifecycle state of an environment, along
+// with the number of machines and services that remain in it, so that
+// the progress of its destruction can be reported.
+type EnvironmentStatus struct {
+	Life         Life
+	MachineCount int
+	ServiceCount int
+}
+
+// EnvironmentStatusResult holds the status of an environment or an error.
+type EnvironmentStatusResult struct {
+	Error  *Error
+	Status EnvironmentStatus
+}
+
+// EnvironmentStatusResults holds the results of an EnvironmentStatus call.
+type EnvironmentStatusResults struct {
+	Results []EnvironmentStatusResult
+}
+
+// ExportEnvironmentArgs holds the arguments for exporting an
+// environment to the state server with the given CA certificate.
+type ExportEnvironmentArgs struct {
+	EnvironTag   string
+	TargetCACert string
+}
+
+// EnvironmentExport holds the documents of an environment exported
+// from one state server, for import into another. The charm archives,
+// resources and tools the environment uses are identified by their
+// storage paths and metadata, and copied separately through the
+// migration HTTP endpoint.
+type EnvironmentExport struct {
+	Owner     string
+	Config    map[string]interface{}
+	Documents []ExportedCollection
+	Charms    []string
+	Resources []string
+	Tools     []ExportedTools
+}
+
+// ExportedCollection holds the documents of an exported environment
+// from a single collection, each one BSON-encoded.
+type ExportedCollection struct {
+	Name      string
+	Documents [][]byte
+}
+
+// ExportedTools holds the metadata of a tools tarball used by an
+// exported environment.
+type ExportedTools struct {
+	Version version.Binary
+	Size    int64
+	SHA256  string
+}
+
+// MigrationBlobArgs identifies a charm archive, resource or tools
+// tarball copied through the migration HTTP endpoint.
+type MigrationBlobArgs struct {
+	// EnvironTag identifies the environment being imported, when
+	// the blob is uploaded to the state server importing it.
+	EnvironTag string
+
+	// StoragePath holds the storage path of a charm archive or of
+	// resource content, and Size its size.
+	StoragePath string
+	Size        int64
+
+	// Tools holds the metadata of a tools tarball, when the blob is
+	// one.
+	Tools *ExportedTools
+}
+
+// EnvironmentImportResult holds the API addresses of the state server
+// that an environment has been imported into.
+type EnvironmentImportResult struct {
+	Servers [][]HostPort
+}
+
+// SetEnvironmentMigrated holds the arguments for recording that an
+// environment has been migrated to the state server with the given
+// API addresses.
+type SetEnvironmentMigrated struct {
+	EnvironTag string
+	Servers    [][]HostPort
+}
+
 // ResolvedModeResult holds a resolved mode or an error.
 type ResolvedModeResult struct {
 	Error *Error
@@ -362,6 +449,10 @@ const (
 	KindCombined HistoryKind = "combined"
 	KindAgent    HistoryKind = "agent"
 	KindWorkload HistoryKind = "workload"
+	KindMachine  HistoryKind = "machine"
+	KindInstance HistoryKind = "instance"
+	KindService  HistoryKind = "service"
+	KindRelation HistoryKind = "relation"
 )
 
 // StatusHistory holds the parameters to filter a status history query.
@@ -369,6 +460,12 @@ type StatusHistory struct {
 	Kind HistoryKind
 	Size int
 	Name string
+
+	// From and To, when set, restrict the history to the statuses set
+	// no earlier than From and before To. They are ignored by
+	// UnitStatusHistory.
+	From *time.Time
+	To   *time.Time
 }
 
 // StatusResult holds an entity status, extra information, or an
@@ -535,11 +632,12 @@ type CharmsResponse struct {
 // Commands and Timeout are expected to have values, and one or more
 // values should be in the Machines, Services, or Units slices.
 type RunParams struct {
-	Commands string
-	Timeout  time.Duration
-	Machines []string
-	Services []string
-	Units    []string
+	Commands      string
+	Timeout       time.Duration
+	Machines      []string
+	Services      []string
+	Units         []string
+	MaxConcurrent int `json:",omitempty"`
 }
 
 // RunResult contains the result from an individual run call on a machine.
@@ -557,6 +655,30 @@ type RunResults struct {
 	Results []RunResult
 }
 
+// RunJob identifies commands being run in the background.
+type RunJob struct {
+	Id string
+}
+
+// RunJobQuery requests the results of a background run job, skipping
+// the first Skip results, which the caller already has.
+type RunJobQuery struct {
+	Id   string
+	Skip int
+}
+
+// RunJobResults holds results of a background run job, in the order in
+// which its targets finished. Done is set once the job has results for
+// all Targets, or once it has failed, when Error describes why.
+type RunJobResults struct {
+	Id       string
+	Commands string
+	Targets  int
+	Done     bool
+	Error    string
+	Results  []RunResult
+}
+
 // AgentVersionResult is used to return the current version number of the
 // agent running the API server.
 type AgentVersionResult struct {
@@ -572,6 +694,15 @@ type ProvisioningInfo struct {
 	Jobs        []multiwatcher.MachineJob
 	Volumes     []VolumeParams
 	Tags        map[string]string
+
+	// CloudInitUserData holds the cloud-init user data given when
+	// the machine was added, if any.
+	CloudInitUserData string
+
+	// SubnetsToZones holds the availability zones of each subnet,
+	// keyed by provider subnet id, in the spaces the machine is
+	// constrained to.
+	SubnetsToZones map[string][]string
 }
 
 // ProvisioningInfoResult holds machine provisioning info or an error.
@@ -431,3 +431,44 @@ type APIHostPortsResult struct {
 func (r APIHostPortsResult) NetworkHostsPorts() [][]network.HostPort {
 	return NetworkHostsPorts(r.Servers)
 }
+
+// Subnet describes a single subnet.
+type Subnet struct {
+	CIDR       string `json:"CIDR"`
+	ProviderId string `json:"ProviderId,omitempty"`
+	VLANTag    int    `json:"VLANTag,omitempty"`
+	Zone       string `json:"Zone,omitempty"`
+	SpaceName  string `json:"SpaceName,omitempty"`
+	Life       Life   `json:"Life"`
+}
+
+// AddSubnetsParams holds the arguments of the Subnets.AddSubnets API
+// call.
+type AddSubnetsParams struct {
+	Subnets []Subnet `json:"Subnets"`
+}
+
+// Space describes a space and the subnets in it.
+type Space struct {
+	Name    string   `json:"Name"`
+	Subnets []Subnet `json:"Subnets"`
+}
+
+// CreateSpaceParams holds the name of a space to create and the CIDRs
+// of the existing subnets to put in it.
+type CreateSpaceParams struct {
+	Name        string   `json:"Name"`
+	SubnetCIDRs []string `json:"SubnetCIDRs"`
+}
+
+// CreateSpacesParams holds the arguments of the Spaces.CreateSpaces
+// API call.
+type CreateSpacesParams struct {
+	Spaces []CreateSpaceParams `json:"Spaces"`
+}
+
+// ListSpacesResults holds the result of the Spaces.ListSpaces API
+// call.
+type ListSpacesResults struct {
+	Results []Space `json:"Results"`
+}
@@ -137,6 +137,11 @@ type AddMachineParams struct {
 	// that will be used to decide how to instantiate the machine.
 	Placement *instance.Placement `json:"Placement"`
 
+	// CloudInitUserData optionally holds a cloud-config document
+	// whose directives are added to the machine's generated
+	// cloud-init configuration.
+	CloudInitUserData string `json:"CloudInitUserData,omitempty"`
+
 	// If ParentId is non-empty, it specifies the id of the
 	// parent machine within which the new machine will
 	// be created. In that case, ContainerType must also be
@@ -202,6 +207,9 @@ type ServiceDeploy struct {
 	ToMachineSpec string
 	Networks      []string
 	Storage       map[string]storage.Constraints
+	// EndpointBindings maps charm endpoint names to the spaces they
+	// are bound to.
+	EndpointBindings map[string]string
 }
 
 // ServiceUpdate holds the parameters for making the ServiceUpdate call.
@@ -492,6 +500,7 @@ type ContainerConfig struct {
 	AptMirror               string
 	PreferIPv6              bool
 	AllowLXCLoopMounts      bool
+	CloudInitUserData       string
 	*UpdateBehavior
 }
 
@@ -527,6 +536,16 @@ type DeployerConnectionValues struct {
 // StatusParams holds parameters for the Status call.
 type StatusParams struct {
 	Patterns []string
+
+	// Statuses, Machines and Relations, when set, restrict the status
+	// to the units whose workload or agent status is one of Statuses,
+	// which are assigned to one of Machines (or to a container within
+	// one of them), and whose service takes part in one of Relations.
+	// Relations are given as "service:relation" endpoints, or as bare
+	// relation or interface names.
+	Statuses  []string
+	Machines  []string
+	Relations []string
 }
 
 // SetRsyslogCertParams holds parameters for the SetRsyslogCert call.
@@ -683,6 +702,25 @@ type StateServersChanges struct {
 	Converted  []string `json:"converted,omitempty"`
 }
 
+// RotateCertificatesArgs holds the arguments to a RotateCertificates
+// API call.
+type RotateCertificatesArgs struct {
+	// Force advances a CA rotation even if some agents have yet to
+	// fetch the CA certificates trusted by its current phase.
+	Force bool
+}
+
+// RotateCertificatesResult holds the result of a RotateCertificates
+// API call.
+type RotateCertificatesResult struct {
+	// Phase holds the phase that the CA rotation has reached.
+	Phase string
+
+	// TrustedCACert holds the PEM-encoded CA certificates that clients
+	// should now trust when connecting to the state servers.
+	TrustedCACert string
+}
+
 // FindToolsParams defines parameters for the FindTools method.
 type FindToolsParams struct {
 	// Number will be used to match tools versions exactly if non-zero.
@@ -0,0 +1,53 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+// Payload holds information about a workload payload registered by
+// a unit.
+type Payload struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	ID      string   `json:"id"`
+	Status  string   `json:"status"`
+	Labels  []string `json:"labels,omitempty"`
+	Unit    string   `json:"unit,omitempty"`
+	Machine string   `json:"machine,omitempty"`
+}
+
+// RegisterPayloadArg holds a payload to register for a unit.
+type RegisterPayloadArg struct {
+	Tag     string  `json:"tag"`
+	Payload Payload `json:"payload"`
+}
+
+// RegisterPayloadArgs holds the arguments for registering payloads
+// for a set of units.
+type RegisterPayloadArgs struct {
+	Args []RegisterPayloadArg `json:"args"`
+}
+
+// PayloadStatusArg identifies a payload of a unit by name, and holds
+// the status to set on it, if any.
+type PayloadStatusArg struct {
+	Tag    string `json:"tag"`
+	Name   string `json:"name"`
+	Status string `json:"status,omitempty"`
+}
+
+// PayloadStatusArgs holds the arguments for unregistering payloads or
+// setting their status.
+type PayloadStatusArgs struct {
+	Args []PayloadStatusArg `json:"args"`
+}
+
+// PayloadListArgs holds the patterns used to filter the payloads
+// returned by the Payloads facade.
+type PayloadListArgs struct {
+	Patterns []string `json:"patterns"`
+}
+
+// PayloadListResults holds the payloads matching a PayloadListArgs.
+type PayloadListResults struct {
+	Results []Payload `json:"results"`
+}
@@ -0,0 +1,34 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+// Quota holds the limits on the resources that an environment, or all
+// the environments owned by a user, may consume. A nil limit leaves
+// the resource unlimited.
+type Quota struct {
+	MaxMachines   *int     `json:"max-machines,omitempty"`
+	MaxUnits      *int     `json:"max-units,omitempty"`
+	MaxStorageGiB *uint64  `json:"max-storage-gib,omitempty"`
+	InstanceTypes []string `json:"instance-types,omitempty"`
+}
+
+// QuotaUsage holds the resources counted against a quota.
+type QuotaUsage struct {
+	Machines   int    `json:"machines"`
+	Units      int    `json:"units"`
+	StorageMiB uint64 `json:"storage-mib"`
+}
+
+// QuotaResult holds a quota and the resources counted against it.
+type QuotaResult struct {
+	Quota Quota      `json:"quota"`
+	Usage QuotaUsage `json:"usage"`
+}
+
+// SetOwnerQuota holds the quota to place on the environments owned by
+// a user.
+type SetOwnerQuota struct {
+	Owner string `json:"owner"`
+	Quota Quota  `json:"quota"`
+}
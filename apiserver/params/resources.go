@@ -0,0 +1,42 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+import (
+	"time"
+)
+
+// Resource describes the current revision of a charm resource
+// uploaded for a service.
+type Resource struct {
+	Service    string    `json:"service"`
+	Name       string    `json:"name"`
+	Revision   int       `json:"revision"`
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256"`
+	Uploaded   time.Time `json:"uploaded"`
+	UploadedBy string    `json:"uploaded-by"`
+}
+
+// ResourceArgs identifies the resources requested from the resources
+// HTTP endpoint. If Name is empty, all the service's resources are
+// listed; otherwise the content of the named resource is sent.
+type ResourceArgs struct {
+	Service string `json:"service"`
+	Name    string `json:"name,omitempty"`
+}
+
+// ResourceUploadArgs holds the metadata sent along with the content of
+// a resource uploaded to the resources HTTP endpoint.
+type ResourceUploadArgs struct {
+	Service string `json:"service"`
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+}
+
+// ResourcesResult holds the resources of a service.
+type ResourcesResult struct {
+	Resources []Resource `json:"resources"`
+}
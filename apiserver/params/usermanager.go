@@ -57,3 +57,50 @@ type AddUserResult struct {
 	Tag   string `json:"tag,omitempty"`
 	Error *Error `json:"error,omitempty"`
 }
+
+// CreateAPITokens holds the parameters for creating API tokens for the
+// logged in user.
+type CreateAPITokens struct {
+	Tokens []CreateAPIToken `json:"tokens"`
+}
+
+// CreateAPIToken holds the parameters for creating one API token. If
+// Facades is empty, the token allows access to all facades; if Expires
+// is nil, the token never expires.
+type CreateAPIToken struct {
+	Name    string     `json:"name"`
+	Facades []string   `json:"facades,omitempty"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// CreateAPITokenResults holds the results of the bulk CreateAPITokens
+// API call.
+type CreateAPITokenResults struct {
+	Results []CreateAPITokenResult `json:"results"`
+}
+
+// CreateAPITokenResult holds the credentials with which the new token
+// is used in place of a password, or an error.
+type CreateAPITokenResult struct {
+	Credentials string `json:"credentials,omitempty"`
+	Error       *Error `json:"error,omitempty"`
+}
+
+// APIToken holds information on an API token. The token's secret is
+// never returned.
+type APIToken struct {
+	Name    string     `json:"name"`
+	Facades []string   `json:"facades,omitempty"`
+	Created time.Time  `json:"created"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// APITokensResult holds the result of a ListAPITokens call.
+type APITokensResult struct {
+	Tokens []APIToken `json:"tokens"`
+}
+
+// APITokenNames holds the names of API tokens.
+type APITokenNames struct {
+	Names []string `json:"names"`
+}
@@ -0,0 +1,80 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package payloads provides the API server facade used by clients to
+// inspect the workload payloads registered by units.
+package payloads
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("Payloads", 1, NewPayloadsAPI)
+}
+
+// Payloads defines the methods on the payloads API end point.
+type Payloads interface {
+	List(args params.PayloadListArgs) (params.PayloadListResults, error)
+}
+
+// PayloadsAPI implements the Payloads interface and is the concrete
+// implementation of the api end point.
+type PayloadsAPI struct {
+	state      stateInterface
+	authorizer common.Authorizer
+}
+
+var _ Payloads = (*PayloadsAPI)(nil)
+
+var getState = func(st *state.State) stateInterface {
+	return st
+}
+
+// NewPayloadsAPI creates a new server-side payloads API end point.
+func NewPayloadsAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*PayloadsAPI, error) {
+	// Only clients can access the payloads service.
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &PayloadsAPI{
+		state:      getState(st),
+		authorizer: authorizer,
+	}, nil
+}
+
+// List returns the payloads registered in the environment that match
+// any of the given patterns, or all payloads if no patterns are given.
+func (api *PayloadsAPI) List(args params.PayloadListArgs) (params.PayloadListResults, error) {
+	var result params.PayloadListResults
+	all, err := api.state.AllPayloads()
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	payloads, err := state.FilterPayloads(all, args.Patterns...)
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	result.Results = make([]params.Payload, len(payloads))
+	for i, p := range payloads {
+		result.Results[i] = PayloadToParams(p)
+	}
+	return result, nil
+}
+
+// PayloadToParams converts a state payload into its API representation.
+func PayloadToParams(p state.Payload) params.Payload {
+	return params.Payload{
+		Name:    p.Name,
+		Type:    p.Type,
+		ID:      p.ID,
+		Status:  string(p.Status),
+		Labels:  p.Labels,
+		Unit:    p.Unit,
+		Machine: p.Machine,
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package payloads_test
+
+import (
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/apiserver/payloads"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+)
+
+type payloadsSuite struct {
+	jujutesting.JujuConnSuite
+
+	api        *payloads.PayloadsAPI
+	authoriser apiservertesting.FakeAuthorizer
+	unit       *state.Unit
+}
+
+var _ = gc.Suite(&payloadsSuite{})
+
+func (s *payloadsSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.authoriser = apiservertesting.FakeAuthorizer{
+		Tag: s.AdminUserTag(c),
+	}
+	var err error
+	s.api, err = payloads.NewPayloadsAPI(s.State, common.NewResources(), s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.unit = s.Factory.MakeUnit(c, nil)
+	for _, info := range []state.PayloadInfo{
+		{Name: "web", Type: "docker", ID: "abc", Status: state.PayloadRunning, Labels: []string{"frontend"}},
+		{Name: "worker", Type: "process", ID: "1234", Status: state.PayloadStopped},
+	} {
+		err := s.unit.RegisterPayload(info)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *payloadsSuite) TestNewPayloadsAPIRefusesNonClient(c *gc.C) {
+	anAuthoriser := s.authoriser
+	anAuthoriser.Tag = names.NewUnitTag("mysql/0")
+	endPoint, err := payloads.NewPayloadsAPI(s.State, common.NewResources(), anAuthoriser)
+	c.Assert(endPoint, gc.IsNil)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *payloadsSuite) TestListAll(c *gc.C) {
+	machineId, err := s.unit.AssignedMachineId()
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := s.api.List(params.PayloadListArgs{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, jc.DeepEquals, []params.Payload{{
+		Name:    "web",
+		Type:    "docker",
+		ID:      "abc",
+		Status:  "running",
+		Labels:  []string{"frontend"},
+		Unit:    s.unit.Name(),
+		Machine: machineId,
+	}, {
+		Name:    "worker",
+		Type:    "process",
+		ID:      "1234",
+		Status:  "stopped",
+		Unit:    s.unit.Name(),
+		Machine: machineId,
+	}})
+}
+
+func (s *payloadsSuite) TestListFiltered(c *gc.C) {
+	result, err := s.api.List(params.PayloadListArgs{Patterns: []string{"frontend"}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 1)
+	c.Assert(result.Results[0].Name, gc.Equals, "web")
+
+	result, err = s.api.List(params.PayloadListArgs{Patterns: []string{"no-match"}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 0)
+}
+
+func (s *payloadsSuite) TestListBadPattern(c *gc.C) {
+	_, err := s.api.List(params.PayloadListArgs{Patterns: []string{"["}})
+	c.Assert(err, gc.ErrorMatches, `invalid payload pattern "\["(.|\n)*`)
+}
@@ -0,0 +1,12 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package payloads
+
+import (
+	"github.com/juju/juju/state"
+)
+
+type stateInterface interface {
+	AllPayloads() ([]state.Payload, error)
+}
@@ -295,6 +295,7 @@ func (p *ProvisionerAPI) ContainerConfig() (params.ContainerConfig, error) {
 	result.AptProxy = config.AptProxySettings()
 	result.PreferIPv6 = config.PreferIPv6()
 	result.AllowLXCLoopMounts, _ = config.AllowLXCLoopMounts()
+	result.CloudInitUserData = config.CloudInitUserData()
 
 	return result, nil
 }
@@ -418,14 +419,20 @@ func (p *ProvisionerAPI) getProvisioningInfo(m *state.Machine) (*params.Provisio
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	subnetsToZones, err := p.machineSubnetsToZones(cons)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	return &params.ProvisioningInfo{
-		Constraints: cons,
-		Series:      m.Series(),
-		Placement:   m.Placement(),
-		Networks:    networks,
-		Jobs:        jobs,
-		Volumes:     volumes,
-		Tags:        tags,
+		Constraints:       cons,
+		Series:            m.Series(),
+		Placement:         m.Placement(),
+		Networks:          networks,
+		Jobs:              jobs,
+		Volumes:           volumes,
+		Tags:              tags,
+		CloudInitUserData: m.CloudInitUserData(),
+		SubnetsToZones:    subnetsToZones,
 	}, nil
 }
 
@@ -1311,3 +1318,46 @@ func (p *ProvisionerAPI) machineTags(m *state.Machine, jobs []multiwatcher.Machi
 	}
 	return machineTags, nil
 }
+
+// machineSubnetsToZones returns the availability zones of the subnets
+// in the spaces required by the given machine constraints, keyed by
+// provider subnet id. Subnets unknown to the provider are skipped.
+//
+// Instances are started with a single network interface, so only one
+// space can be honoured; constraints naming several are rejected
+// rather than merged, which could put the machine in a subnet of
+// just one of them.
+func (p *ProvisionerAPI) machineSubnetsToZones(cons constraints.Value) (map[string][]string, error) {
+	if !cons.HaveSpaces() {
+		return nil, nil
+	}
+	if len(*cons.Spaces) > 1 {
+		return nil, errors.NotSupportedf("starting a machine in multiple spaces %v", *cons.Spaces)
+	}
+	subnetsToZones := make(map[string][]string)
+	for _, spaceName := range *cons.Spaces {
+		space, err := p.st.Space(spaceName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		subnets, err := space.Subnets()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, subnet := range subnets {
+			if subnet.ProviderId() == "" {
+				continue
+			}
+			var zones []string
+			if zone := subnet.AvailabilityZone(); zone != "" {
+				zones = append(zones, zone)
+			}
+			subnetsToZones[subnet.ProviderId()] = zones
+		}
+	}
+	if len(subnetsToZones) == 0 {
+		logger.Warningf("no subnets known to the provider in spaces %v", *cons.Spaces)
+		return nil, nil
+	}
+	return subnetsToZones, nil
+}
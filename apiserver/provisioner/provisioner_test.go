@@ -837,6 +837,61 @@ func (s *withoutStateServerSuite) TestProvisioningInfo(c *gc.C) {
 	c.Assert(result, jc.DeepEquals, expected)
 }
 
+func (s *withoutStateServerSuite) TestProvisioningInfoWithSpaces(c *gc.C) {
+	_, err := s.State.AddSpace("db", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	for _, info := range []state.SubnetInfo{
+		{CIDR: "10.0.0.0/24", ProviderId: "subnet-0", AvailabilityZone: "zone0", SpaceName: "db"},
+		{CIDR: "10.1.0.0/24", ProviderId: "subnet-1", AvailabilityZone: "zone1", SpaceName: "db"},
+		{CIDR: "10.2.0.0/24", SpaceName: "db"},
+		{CIDR: "10.3.0.0/24", ProviderId: "subnet-3", AvailabilityZone: "zone3"},
+	} {
+		_, err := s.State.AddSubnet(info)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	template := state.MachineTemplate{
+		Series:      "quantal",
+		Jobs:        []state.MachineJob{state.JobHostUnits},
+		Constraints: constraints.MustParse("spaces=db"),
+	}
+	machine, err := s.State.AddOneMachine(template)
+	c.Assert(err, jc.ErrorIsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: machine.Tag().String()},
+	}}
+	result, err := s.provisioner.ProvisioningInfo(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 1)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[0].Result.SubnetsToZones, jc.DeepEquals, map[string][]string{
+		"subnet-0": {"zone0"},
+		"subnet-1": {"zone1"},
+	})
+}
+
+func (s *withoutStateServerSuite) TestProvisioningInfoWithMultipleSpaces(c *gc.C) {
+	for _, name := range []string{"db", "dmz"} {
+		_, err := s.State.AddSpace(name, nil)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	template := state.MachineTemplate{
+		Series:      "quantal",
+		Jobs:        []state.MachineJob{state.JobHostUnits},
+		Constraints: constraints.MustParse("spaces=db,dmz"),
+	}
+	machine, err := s.State.AddOneMachine(template)
+	c.Assert(err, jc.ErrorIsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: machine.Tag().String()},
+	}}
+	result, err := s.provisioner.ProvisioningInfo(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 1)
+	c.Assert(result.Results[0].Error, gc.ErrorMatches, `starting a machine in multiple spaces \[db dmz\] not supported`)
+}
+
 func (s *withoutStateServerSuite) TestStorageProviderFallbackToType(c *gc.C) {
 	registry.RegisterProvider("dynamic", &storagedummy.StorageProvider{IsDynamic: true})
 	defer registry.RegisterProvider("dynamic", nil)
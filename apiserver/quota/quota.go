@@ -0,0 +1,152 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The quota package implements the API used to report and set the
+// resource quotas placed on environments and on their owners.
+package quota
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("Quota", 1, NewQuotaAPI)
+}
+
+// Quota defines the methods on the quota API end point.
+type Quota interface {
+	EnvironQuota() (params.QuotaResult, error)
+	SetEnvironQuota(args params.Quota) error
+	OwnerQuota(arg params.Entity) (params.QuotaResult, error)
+	SetOwnerQuota(args params.SetOwnerQuota) error
+}
+
+// QuotaAPI implements the Quota interface and is the concrete
+// implementation of the api end point.
+type QuotaAPI struct {
+	state      *state.State
+	authorizer common.Authorizer
+	check      *common.BlockChecker
+}
+
+var _ Quota = (*QuotaAPI)(nil)
+
+// NewQuotaAPI creates a new server-side quota API end point.
+func NewQuotaAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*QuotaAPI, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &QuotaAPI{
+		state:      st,
+		authorizer: authorizer,
+		check:      common.NewBlockChecker(st),
+	}, nil
+}
+
+// authCheck returns an error unless the API user is the administrator
+// of the state server, or the given user if one is supplied.
+func (api *QuotaAPI) authCheck(user *names.UserTag) error {
+	apiUser, ok := api.authorizer.GetAuthTag().(names.UserTag)
+	if !ok {
+		return common.ErrPerm
+	}
+	if user != nil && apiUser == *user {
+		return nil
+	}
+	env, err := api.state.StateServerEnvironment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if apiUser != env.Owner() {
+		return common.ErrPerm
+	}
+	return nil
+}
+
+// EnvironQuota returns the quota placed on the environment, and the
+// resources the environment currently uses.
+func (api *QuotaAPI) EnvironQuota() (params.QuotaResult, error) {
+	quota, usage, err := api.state.EnvironQuota()
+	if err != nil {
+		return params.QuotaResult{}, errors.Trace(err)
+	}
+	return quotaResult(quota, usage), nil
+}
+
+// SetEnvironQuota replaces the quota placed on the environment. Only
+// the administrator of the state server may set quotas.
+func (api *QuotaAPI) SetEnvironQuota(args params.Quota) error {
+	if err := api.authCheck(nil); err != nil {
+		return errors.Trace(err)
+	}
+	if err := api.check.ChangeAllowed(); err != nil {
+		return errors.Trace(err)
+	}
+	return api.state.SetEnvironQuota(fromParams(args))
+}
+
+// OwnerQuota returns the quota placed on the environments owned by the
+// given user, and the resources those environments currently use.
+// Users may only see their own quota, unless they administer the state
+// server.
+func (api *QuotaAPI) OwnerQuota(arg params.Entity) (params.QuotaResult, error) {
+	owner, err := names.ParseUserTag(arg.Tag)
+	if err != nil {
+		return params.QuotaResult{}, errors.Trace(err)
+	}
+	if err := api.authCheck(&owner); err != nil {
+		return params.QuotaResult{}, errors.Trace(err)
+	}
+	quota, usage, err := api.state.OwnerQuota(owner)
+	if err != nil {
+		return params.QuotaResult{}, errors.Trace(err)
+	}
+	return quotaResult(quota, usage), nil
+}
+
+// SetOwnerQuota replaces the quota placed on the environments owned by
+// the given user. Only the administrator of the state server may set
+// quotas.
+func (api *QuotaAPI) SetOwnerQuota(args params.SetOwnerQuota) error {
+	owner, err := names.ParseUserTag(args.Owner)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := api.authCheck(nil); err != nil {
+		return errors.Trace(err)
+	}
+	if err := api.check.ChangeAllowed(); err != nil {
+		return errors.Trace(err)
+	}
+	return api.state.SetOwnerQuota(owner, fromParams(args.Quota))
+}
+
+func quotaResult(quota state.Quota, usage state.QuotaUsage) params.QuotaResult {
+	return params.QuotaResult{
+		Quota: params.Quota{
+			MaxMachines:   quota.MaxMachines,
+			MaxUnits:      quota.MaxUnits,
+			MaxStorageGiB: quota.MaxStorageGiB,
+			InstanceTypes: quota.InstanceTypes,
+		},
+		Usage: params.QuotaUsage{
+			Machines:   usage.Machines,
+			Units:      usage.Units,
+			StorageMiB: usage.StorageMiB,
+		},
+	}
+}
+
+func fromParams(quota params.Quota) state.Quota {
+	return state.Quota{
+		MaxMachines:   quota.MaxMachines,
+		MaxUnits:      quota.MaxUnits,
+		MaxStorageGiB: quota.MaxStorageGiB,
+		InstanceTypes: quota.InstanceTypes,
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package quota_test
+
+import (
+	stdtesting "testing"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	commontesting "github.com/juju/juju/apiserver/common/testing"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/apiserver/quota"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type quotaSuite struct {
+	testing.JujuConnSuite
+
+	resources  *common.Resources
+	authoriser apiservertesting.FakeAuthorizer
+	api        *quota.QuotaAPI
+
+	commontesting.BlockHelper
+}
+
+var _ = gc.Suite(&quotaSuite{})
+
+func (s *quotaSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
+	s.authoriser = apiservertesting.FakeAuthorizer{
+		Tag: s.AdminUserTag(c),
+	}
+	var err error
+	s.api, err = quota.NewQuotaAPI(s.State, s.resources, s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.BlockHelper = commontesting.NewBlockHelper(s.APIState)
+	s.AddCleanup(func(*gc.C) { s.BlockHelper.Close() })
+}
+
+func (s *quotaSuite) apiFor(c *gc.C, st *state.State, user names.UserTag) *quota.QuotaAPI {
+	auth := apiservertesting.FakeAuthorizer{Tag: user}
+	api, err := quota.NewQuotaAPI(st, s.resources, auth)
+	c.Assert(err, jc.ErrorIsNil)
+	return api
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func (s *quotaSuite) TestNewAPIRefusesAgents(c *gc.C) {
+	auth := apiservertesting.FakeAuthorizer{Tag: names.NewMachineTag("0")}
+	_, err := quota.NewQuotaAPI(s.State, s.resources, auth)
+	c.Assert(err, gc.Equals, common.ErrPerm)
+}
+
+func (s *quotaSuite) TestSetEnvironQuota(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.api.SetEnvironQuota(params.Quota{
+		MaxMachines:   intPtr(1),
+		InstanceTypes: []string{"m1.small"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := s.api.EnvironQuota()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.QuotaResult{
+		Quota: params.Quota{
+			MaxMachines:   intPtr(1),
+			InstanceTypes: []string{"m1.small"},
+		},
+		Usage: params.QuotaUsage{Machines: 1},
+	})
+}
+
+func (s *quotaSuite) TestSetEnvironQuotaNotAdmin(c *gc.C) {
+	user := s.Factory.MakeUser(c, &factory.UserParams{Name: "bob"}).UserTag()
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Owner: user})
+	defer st.Close()
+	api := s.apiFor(c, st, user)
+
+	// Owners may see the quota on their environment, but only the
+	// state server administrator may change it.
+	_, err := api.EnvironQuota()
+	c.Assert(err, jc.ErrorIsNil)
+	err = api.SetEnvironQuota(params.Quota{MaxUnits: intPtr(1)})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *quotaSuite) TestSetEnvironQuotaHostedEnvironment(c *gc.C) {
+	st := s.Factory.MakeEnvironment(c, nil)
+	defer st.Close()
+	api := s.apiFor(c, st, s.AdminUserTag(c))
+	err := api.SetEnvironQuota(params.Quota{MaxUnits: intPtr(1)})
+	c.Assert(err, jc.ErrorIsNil)
+	q, _, err := st.EnvironQuota()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(q, jc.DeepEquals, state.Quota{MaxUnits: intPtr(1)})
+
+	// The state server's own environment is unaffected.
+	q, _, err = s.State.EnvironQuota()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(q, jc.DeepEquals, state.Quota{})
+}
+
+func (s *quotaSuite) TestBlockSetEnvironQuota(c *gc.C) {
+	s.BlockAllChanges(c, "TestBlockSetEnvironQuota")
+	err := s.api.SetEnvironQuota(params.Quota{MaxUnits: intPtr(1)})
+	s.AssertBlocked(c, err, "TestBlockSetEnvironQuota")
+}
+
+func (s *quotaSuite) TestOwnerQuota(c *gc.C) {
+	user := s.Factory.MakeUser(c, &factory.UserParams{Name: "bob"}).UserTag()
+	err := s.api.SetOwnerQuota(params.SetOwnerQuota{
+		Owner: user.String(),
+		Quota: params.Quota{MaxMachines: intPtr(3)},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Users may see their own quota.
+	api := s.apiFor(c, s.State, user)
+	result, err := api.OwnerQuota(params.Entity{Tag: user.String()})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Quota, jc.DeepEquals, params.Quota{MaxMachines: intPtr(3)})
+
+	// But not anyone else's, and may not set it.
+	_, err = api.OwnerQuota(params.Entity{Tag: s.AdminUserTag(c).String()})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	err = api.SetOwnerQuota(params.SetOwnerQuota{
+		Owner: user.String(),
+		Quota: params.Quota{},
+	})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *quotaSuite) TestQuotaExceededErrorCode(c *gc.C) {
+	err := s.State.SetEnvironQuota(state.Quota{MaxMachines: intPtr(0)})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(common.ServerError(err), jc.Satisfies, params.IsCodeQuotaExceeded)
+}
@@ -0,0 +1,160 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/names"
+	"github.com/juju/ratelimit"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/rpcreflect"
+)
+
+// RequestLimit limits the rate at which an entity may make API
+// requests.
+type RequestLimit struct {
+	// Rate holds the number of requests per second that the entity
+	// may make over time. If it is zero, the entity's requests are
+	// not limited.
+	Rate float64
+
+	// Burst holds the number of requests that the entity may make
+	// in quick succession after a quiet period. If it is less than
+	// one, one is used.
+	Burst int64
+}
+
+// requestLimiters holds the token buckets used to limit the rate at
+// which each entity makes API requests. An entity's bucket is shared by
+// all of its connections to the API server. Buckets that have been idle
+// long enough to fill up again are dropped, and created afresh when
+// next needed, so that entities that come and go do not hold on to them.
+type requestLimiters struct {
+	userLimit  RequestLimit
+	agentLimit RequestLimit
+
+	mu        sync.Mutex
+	buckets   map[string]*requestBucket
+	lastSweep time.Time
+}
+
+// requestBucket holds the token bucket of an entity, and when it was
+// last used.
+type requestBucket struct {
+	*ratelimit.Bucket
+	fillTime time.Duration
+	lastUsed time.Time
+}
+
+// requestBucketSweepInterval is the minimum time between checks for
+// idle buckets.
+const requestBucketSweepInterval = time.Minute
+
+// requestLimitersNow returns the current time; it is a variable so
+// that tests can control the age of buckets.
+var requestLimitersNow = time.Now
+
+func newRequestLimiters(userLimit, agentLimit RequestLimit) *requestLimiters {
+	return &requestLimiters{
+		userLimit:  userLimit,
+		agentLimit: agentLimit,
+		buckets:    make(map[string]*requestBucket),
+	}
+}
+
+// limit returns the key of the bucket that limits the requests of the
+// entity with the given tag in the given environment, and the limit it
+// enforces. Users are limited across all environments, agents within
+// their own. It returns false if the entity is not limited.
+func (l *requestLimiters) limit(envUUID string, tag names.Tag) (string, RequestLimit, bool) {
+	limit, key := l.agentLimit, envUUID+":"+tag.String()
+	if tag.Kind() == names.UserTagKind {
+		limit, key = l.userLimit, tag.String()
+	}
+	if limit.Rate <= 0 {
+		return "", limit, false
+	}
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return key, limit, true
+}
+
+// take takes a token from the bucket with the given key, creating the
+// bucket with the given limit if there is none. It returns false if no
+// token is available.
+func (l *requestLimiters) take(key string, limit RequestLimit) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := requestLimitersNow()
+	l.sweep(now)
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &requestBucket{
+			Bucket:   ratelimit.NewBucketWithRate(limit.Rate, limit.Burst),
+			fillTime: time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second)),
+		}
+		l.buckets[key] = bucket
+	}
+	bucket.lastUsed = now
+	return bucket.TakeAvailable(1) > 0
+}
+
+// sweep drops the buckets that have not been used for long enough to
+// have filled up again, as a new bucket is the same as a full one. It
+// does nothing if it was last called less than
+// requestBucketSweepInterval ago. It must be called with l.mu held.
+func (l *requestLimiters) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < requestBucketSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastUsed) >= bucket.fillTime {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimitedRoot refuses API calls made faster than the entity that
+// logged in is allowed to make them.
+type rateLimitedRoot struct {
+	rpc.MethodFinder
+	limiters   *requestLimiters
+	key        string
+	limit      RequestLimit
+	retryAfter time.Duration
+}
+
+// newRateLimitedRoot returns a new rateLimitedRoot that takes a token
+// from the bucket with the given key for each call.
+func newRateLimitedRoot(finder rpc.MethodFinder, limiters *requestLimiters, key string, limit RequestLimit) *rateLimitedRoot {
+	return &rateLimitedRoot{
+		MethodFinder: finder,
+		limiters:     limiters,
+		key:          key,
+		limit:        limit,
+		retryAfter:   time.Duration(float64(time.Second) / limit.Rate),
+	}
+}
+
+// FindMethod returns an error suggesting that the call be retried
+// later if the entity has used up its allowance of requests. Calls
+// to the Pinger facade are never refused, as clients use it to keep
+// their connections alive.
+func (r *rateLimitedRoot) FindMethod(rootName string, version int, methodName string) (rpcreflect.MethodCaller, error) {
+	caller, err := r.MethodFinder.FindMethod(rootName, version, methodName)
+	if err != nil {
+		return nil, err
+	}
+	if rootName != "Pinger" && !r.limiters.take(r.key, r.limit) {
+		logger.Debugf("rate limiting call to %s.%s", rootName, methodName)
+		return nil, common.ErrTryAgainAfter(r.retryAfter)
+	}
+	return caller, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// This is an internal package test.
+
+package apiserver
+
+import (
+	"time"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/testing"
+)
+
+type requestLimitersSuite struct {
+	testing.BaseSuite
+	now time.Time
+}
+
+var _ = gc.Suite(&requestLimitersSuite{})
+
+func (s *requestLimitersSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.now = time.Now()
+	s.PatchValue(&requestLimitersNow, func() time.Time { return s.now })
+}
+
+func (s *requestLimitersSuite) TestLimit(c *gc.C) {
+	limiters := newRequestLimiters(RequestLimit{Rate: 1}, RequestLimit{})
+	user := names.NewUserTag("bob")
+	key, limit, ok := limiters.limit("env-uuid", user)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(key, gc.Equals, user.String())
+	c.Assert(limit, gc.Equals, RequestLimit{Rate: 1, Burst: 1})
+
+	_, _, ok = limiters.limit("env-uuid", names.NewMachineTag("0"))
+	c.Assert(ok, jc.IsFalse)
+}
+
+func (s *requestLimitersSuite) TestIdleBucketsDropped(c *gc.C) {
+	limit := RequestLimit{Rate: 0.001, Burst: 1}
+	limiters := newRequestLimiters(limit, limit)
+	c.Assert(limiters.take("a", limit), jc.IsTrue)
+	c.Assert(limiters.take("a", limit), jc.IsFalse)
+	c.Assert(limiters.buckets, gc.HasLen, 1)
+
+	// A bucket in use is kept, however often the buckets are swept.
+	s.now = s.now.Add(requestBucketSweepInterval)
+	c.Assert(limiters.take("b", limit), jc.IsTrue)
+	c.Assert(limiters.take("a", limit), jc.IsFalse)
+	c.Assert(limiters.buckets, gc.HasLen, 2)
+
+	// Once a bucket has been idle for long enough to fill up again,
+	// it is dropped; a new one is full, as the old one would be.
+	s.now = s.now.Add(1000 * time.Second)
+	c.Assert(limiters.take("b", limit), jc.IsTrue)
+	c.Assert(limiters.buckets, gc.HasLen, 1)
+	c.Assert(limiters.buckets["b"], gc.NotNil)
+}
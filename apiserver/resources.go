@@ -0,0 +1,258 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	apihttp "github.com/juju/juju/apiserver/http"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/resource"
+	"github.com/juju/juju/state"
+)
+
+// resourcesHandler handles the upload of charm resources by users,
+// and their download by users and by the units of the services they
+// belong to.
+type resourcesHandler struct {
+	httpHandler
+	dataDir string
+}
+
+func (h *resourcesHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	stateWrapper, err := h.validateEnvironUUID(req)
+	if err != nil {
+		h.sendError(resp, http.StatusNotFound, err.Error())
+		return
+	}
+	defer stateWrapper.cleanup()
+
+	tag, err := stateWrapper.authenticate(req)
+	if err != nil {
+		h.authError(resp, h)
+		return
+	}
+
+	switch req.Method {
+	case "GET":
+		args, err := h.parseGETArgs(req)
+		if err != nil {
+			h.sendError(resp, http.StatusBadRequest, err.Error())
+			return
+		}
+		service, err := h.accessibleService(stateWrapper.state, tag, args.Service)
+		if err != nil {
+			h.sendServerError(resp, err)
+			return
+		}
+		if args.Name == "" {
+			err = h.list(service, resp)
+		} else {
+			err = h.download(service, args.Name, resp)
+		}
+		if err != nil {
+			h.sendServerError(resp, err)
+		}
+	case "PUT":
+		userTag, ok := tag.(names.UserTag)
+		if !ok {
+			h.authError(resp, h)
+			return
+		}
+		logger.Infof("handling resource upload request")
+		res, err := h.upload(stateWrapper.state, userTag, req)
+		if err != nil {
+			h.sendServerError(resp, err)
+			return
+		}
+		logger.Infof("uploaded revision %d of resource %q for service %q", res.Revision, res.Name, res.Service)
+		h.sendJSON(resp, http.StatusOK, resourceToParams(res))
+	default:
+		h.sendError(resp, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", req.Method))
+	}
+}
+
+// accessibleService returns the named service, if the entity with the
+// given tag may read its resources: users may read those of any
+// service, and units only those of their own service.
+func (h *resourcesHandler) accessibleService(st *state.State, tag names.Tag, serviceName string) (*state.Service, error) {
+	if !names.IsValidService(serviceName) {
+		return nil, errors.NotValidf("service name %q", serviceName)
+	}
+	switch tag := tag.(type) {
+	case names.UserTag:
+	case names.UnitTag:
+		unitService, err := names.UnitService(tag.Id())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if unitService != serviceName {
+			return nil, common.ErrPerm
+		}
+	default:
+		return nil, common.ErrPerm
+	}
+	return st.Service(serviceName)
+}
+
+func (h *resourcesHandler) list(service *state.Service, resp http.ResponseWriter) error {
+	resources, err := service.Resources()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	result := params.ResourcesResult{
+		Resources: make([]params.Resource, len(resources)),
+	}
+	for i, r := range resources {
+		result.Resources[i] = resourceToParams(r)
+	}
+	h.sendJSON(resp, http.StatusOK, &result)
+	return nil
+}
+
+func (h *resourcesHandler) download(service *state.Service, name string, resp http.ResponseWriter) error {
+	res, content, err := service.OpenResource(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer content.Close()
+
+	// The digest is base64-encoded, as required by RFC 3230.
+	hash, err := hex.DecodeString(res.SHA256)
+	if err != nil {
+		return errors.Annotatef(err, "invalid hash recorded for resource %q", name)
+	}
+	resp.Header().Set("Content-Type", apihttp.CTypeRaw)
+	resp.Header().Set("Content-Length", fmt.Sprint(res.Size))
+	resp.Header().Set("Digest", fmt.Sprintf("%s=%s", apihttp.DigestSHA256, base64.StdEncoding.EncodeToString(hash)))
+	resp.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(resp, content); err != nil {
+		// The status has already been sent, so the client will only
+		// notice the failure through the size or digest check.
+		logger.Errorf("cannot send resource %q: %v", name, err)
+	}
+	return nil
+}
+
+func (h *resourcesHandler) upload(st *state.State, user names.UserTag, req *http.Request) (state.Resource, error) {
+	defer req.Body.Close()
+
+	if err := common.NewBlockChecker(st).ChangeAllowed(); err != nil {
+		return state.Resource{}, errors.Trace(err)
+	}
+	var args params.ResourceUploadArgs
+	content, err := apihttp.ExtractRequestAttachment(req, &args)
+	if err != nil {
+		return state.Resource{}, errors.Trace(err)
+	}
+	defer content.Close()
+
+	if !names.IsValidService(args.Service) {
+		return state.Resource{}, errors.NotValidf("service name %q", args.Service)
+	}
+	if args.Size < 0 {
+		return state.Resource{}, errors.NotValidf("resource size %d", args.Size)
+	}
+	service, err := st.Service(args.Service)
+	if err != nil {
+		return state.Resource{}, errors.Trace(err)
+	}
+	if err := h.checkDeclared(st, service, args.Name); err != nil {
+		return state.Resource{}, errors.Trace(err)
+	}
+	return service.SetResource(args.Name, content, args.Size, args.SHA256, user)
+}
+
+// checkDeclared returns an error if the service's charm does not
+// declare a resource with the given name.
+func (h *resourcesHandler) checkDeclared(st *state.State, service *state.Service, name string) error {
+	curl, _ := service.CharmURL()
+	archivePath, err := cachedCharmArchive(st, h.dataDir, curl)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	declared, err := resource.ReadArchiveMeta(archivePath)
+	if err != nil {
+		return errors.Annotatef(err, "cannot read resources of charm %q", curl)
+	}
+	if _, ok := declared[name]; !ok {
+		return errors.NotFoundf("resource %q in charm %q", name, curl)
+	}
+	return nil
+}
+
+func (h *resourcesHandler) parseGETArgs(req *http.Request) (*params.ResourceArgs, error) {
+	defer req.Body.Close()
+
+	ctype := req.Header.Get("Content-Type")
+	if ctype != apihttp.CTypeJSON {
+		return nil, errors.Errorf("expected Content-Type %q, got %q", apihttp.CTypeJSON, ctype)
+	}
+	var args params.ResourceArgs
+	if err := json.NewDecoder(req.Body).Decode(&args); err != nil {
+		return nil, errors.Annotate(err, "while de-serializing args")
+	}
+	return &args, nil
+}
+
+func resourceToParams(r state.Resource) params.Resource {
+	return params.Resource{
+		Service:    r.Service,
+		Name:       r.Name,
+		Revision:   r.Revision,
+		Size:       r.Size,
+		SHA256:     r.SHA256,
+		Uploaded:   r.Uploaded,
+		UploadedBy: r.UploadedBy,
+	}
+}
+
+// statusForError returns the HTTP status with which to report the
+// given error.
+func statusForError(err error) int {
+	switch {
+	case errors.IsNotFound(err):
+		return http.StatusNotFound
+	case errors.IsNotValid(err):
+		return http.StatusBadRequest
+	case errors.IsAlreadyExists(err):
+		return http.StatusConflict
+	case errors.Cause(err) == common.ErrPerm:
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// sendJSON sends a JSON-encoded result.
+func (h *resourcesHandler) sendJSON(w http.ResponseWriter, statusCode int, result interface{}) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		logger.Errorf("failed to serialize the result (%v): %v", result, err)
+		return
+	}
+	w.Header().Set("Content-Type", apihttp.CTypeJSON)
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+// sendError sends a JSON-encoded error response.
+func (h *resourcesHandler) sendError(w http.ResponseWriter, statusCode int, message string) {
+	h.sendJSON(w, statusCode, &params.Error{Message: message})
+}
+
+// sendServerError sends a JSON-encoded error response including the
+// error code, so that clients can recognise, for example, blocked
+// changes.
+func (h *resourcesHandler) sendServerError(w http.ResponseWriter, err error) {
+	h.sendJSON(w, statusForError(err), common.ServerError(err))
+}
@@ -0,0 +1,258 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5"
+
+	apihttp "github.com/juju/juju/apiserver/http"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testcharms"
+	"github.com/juju/juju/testing/factory"
+)
+
+type resourcesSuite struct {
+	userAuthHttpSuite
+	service *state.Service
+}
+
+var _ = gc.Suite(&resourcesSuite{})
+
+func (s *resourcesSuite) SetUpTest(c *gc.C) {
+	s.userAuthHttpSuite.SetUpTest(c)
+	ch := s.AddTestingCharm(c, "resourced")
+	s.service = s.AddTestingService(c, "resourced", ch)
+
+	// Put the charm archive in the charm cache, as the testing charm
+	// is not stored in the environment storage.
+	archive := testcharms.Repo.CharmArchive(c.MkDir(), "resourced")
+	cacheDir := filepath.Join(s.DataDir(), "charm-get-cache")
+	err := os.MkdirAll(cacheDir, 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := ioutil.ReadFile(archive.Path)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ioutil.WriteFile(filepath.Join(cacheDir, charm.Quote(ch.URL().String())+".zip"), data, 0644)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *resourcesSuite) resourcesURL(c *gc.C) string {
+	environ, err := s.State.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	uri := s.baseURL(c)
+	uri.Path = fmt.Sprintf("/environment/%s/resources", environ.UUID())
+	return uri.String()
+}
+
+func (s *resourcesSuite) checkErrorResponse(c *gc.C, resp *http.Response, statusCode int, msg string) {
+	c.Check(resp.StatusCode, gc.Equals, statusCode)
+	c.Check(resp.Header.Get("Content-Type"), gc.Equals, apihttp.CTypeJSON)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, jc.ErrorIsNil)
+
+	var failure params.Error
+	err = json.Unmarshal(body, &failure)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(&failure, gc.ErrorMatches, msg)
+}
+
+func (s *resourcesSuite) upload(c *gc.C, args params.ResourceUploadArgs, content string) *http.Response {
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="metadata"`)
+	header.Set("Content-Type", apihttp.CTypeJSON)
+	part, err := writer.CreatePart(header)
+	c.Assert(err, jc.ErrorIsNil)
+	err = json.NewEncoder(part).Encode(args)
+	c.Assert(err, jc.ErrorIsNil)
+
+	part, err = writer.CreateFormFile("attached", args.Name)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = io.WriteString(part, content)
+	c.Assert(err, jc.ErrorIsNil)
+	err = writer.Close()
+	c.Assert(err, jc.ErrorIsNil)
+
+	resp, err := s.authRequest(c, "PUT", s.resourcesURL(c), writer.FormDataContentType(), &parts)
+	c.Assert(err, jc.ErrorIsNil)
+	return resp
+}
+
+func uploadArgs(service, name, content string) params.ResourceUploadArgs {
+	hash := sha256.Sum256([]byte(content))
+	return params.ResourceUploadArgs{
+		Service: service,
+		Name:    name,
+		Size:    int64(len(content)),
+		SHA256:  hex.EncodeToString(hash[:]),
+	}
+}
+
+func (s *resourcesSuite) get(c *gc.C, tag, password string, args params.ResourceArgs) *http.Response {
+	body, err := json.Marshal(args)
+	c.Assert(err, jc.ErrorIsNil)
+	resp, err := s.sendRequest(c, tag, password, "GET", s.resourcesURL(c), apihttp.CTypeJSON, bytes.NewReader(body))
+	c.Assert(err, jc.ErrorIsNil)
+	return resp
+}
+
+func (s *resourcesSuite) TestRequiresAuth(c *gc.C) {
+	resp, err := s.sendRequest(c, "", "", "GET", s.resourcesURL(c), "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+}
+
+func (s *resourcesSuite) TestInvalidHTTPMethods(c *gc.C) {
+	for _, method := range []string{"POST", "DELETE", "OPTIONS"} {
+		c.Logf("testing HTTP method: %s", method)
+		resp, err := s.authRequest(c, method, s.resourcesURL(c), "", nil)
+		c.Assert(err, jc.ErrorIsNil)
+		s.checkErrorResponse(c, resp, http.StatusMethodNotAllowed, `unsupported method: "`+method+`"`)
+		resp.Body.Close()
+	}
+}
+
+func (s *resourcesSuite) TestUpload(c *gc.C) {
+	args := uploadArgs("resourced", "jdk", "java")
+	resp := s.upload(c, args, "java")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+
+	var result params.Resource
+	err := json.NewDecoder(resp.Body).Decode(&result)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(result.Service, gc.Equals, "resourced")
+	c.Check(result.Name, gc.Equals, "jdk")
+	c.Check(result.Revision, gc.Equals, 1)
+	c.Check(result.Size, gc.Equals, int64(4))
+	c.Check(result.SHA256, gc.Equals, args.SHA256)
+	c.Check(result.UploadedBy, gc.Equals, s.userTag.Name())
+
+	r, err := s.service.Resource("jdk")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(r.Revision, gc.Equals, 1)
+}
+
+func (s *resourcesSuite) TestUploadUndeclared(c *gc.C) {
+	resp := s.upload(c, uploadArgs("resourced", "jre", "java"), "java")
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusNotFound, `resource "jre" in charm "local:quantal/resourced-1" not found`)
+}
+
+func (s *resourcesSuite) TestUploadUnknownService(c *gc.C) {
+	resp := s.upload(c, uploadArgs("unknown", "jdk", "java"), "java")
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusNotFound, `service "unknown" not found`)
+}
+
+func (s *resourcesSuite) TestUploadHashMismatch(c *gc.C) {
+	resp := s.upload(c, uploadArgs("resourced", "jdk", "java"), "JAVA")
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusInternalServerError, `.*SHA-256 hash mismatch.*`)
+}
+
+func (s *resourcesSuite) TestUploadBlocked(c *gc.C) {
+	err := s.State.SwitchBlockOn(state.ChangeBlock, "TestUploadBlocked")
+	c.Assert(err, jc.ErrorIsNil)
+	resp := s.upload(c, uploadArgs("resourced", "jdk", "java"), "java")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusInternalServerError)
+	var failure params.Error
+	err = json.NewDecoder(resp.Body).Decode(&failure)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(params.IsCodeOperationBlocked(&failure), jc.IsTrue)
+}
+
+func (s *resourcesSuite) TestList(c *gc.C) {
+	resp := s.upload(c, uploadArgs("resourced", "jdk", "java"), "java")
+	resp.Body.Close()
+
+	resp = s.get(c, s.userTag.String(), s.password, params.ResourceArgs{Service: "resourced"})
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+	var result params.ResourcesResult
+	err := json.NewDecoder(resp.Body).Decode(&result)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Resources, gc.HasLen, 1)
+	c.Assert(result.Resources[0].Name, gc.Equals, "jdk")
+	c.Assert(result.Resources[0].Revision, gc.Equals, 1)
+}
+
+func (s *resourcesSuite) TestDownload(c *gc.C) {
+	resp := s.upload(c, uploadArgs("resourced", "jdk", "java"), "java")
+	resp.Body.Close()
+
+	resp = s.get(c, s.userTag.String(), s.password, params.ResourceArgs{Service: "resourced", Name: "jdk"})
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), gc.Equals, apihttp.CTypeRaw)
+	hash := sha256.Sum256([]byte("java"))
+	c.Assert(resp.Header.Get("Digest"), gc.Equals, "SHA-256="+base64.StdEncoding.EncodeToString(hash[:]))
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(body), gc.Equals, "java")
+}
+
+func (s *resourcesSuite) TestDownloadNotUploaded(c *gc.C) {
+	resp := s.get(c, s.userTag.String(), s.password, params.ResourceArgs{Service: "resourced", Name: "jdk"})
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusNotFound, `resource "jdk" not found`)
+}
+
+func (s *resourcesSuite) makeUnit(c *gc.C, service *state.Service) (*state.Unit, string) {
+	password, err := utils.RandomPassword()
+	c.Assert(err, jc.ErrorIsNil)
+	unit := s.Factory.MakeUnit(c, &factory.UnitParams{Service: service, Password: password})
+	return unit, password
+}
+
+func (s *resourcesSuite) TestUnitAccess(c *gc.C) {
+	resp := s.upload(c, uploadArgs("resourced", "jdk", "java"), "java")
+	resp.Body.Close()
+
+	unit, password := s.makeUnit(c, s.service)
+	resp = s.get(c, unit.Tag().String(), password, params.ResourceArgs{Service: "resourced", Name: "jdk"})
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(body), gc.Equals, "java")
+}
+
+func (s *resourcesSuite) TestUnitCannotAccessOtherService(c *gc.C) {
+	other := s.AddTestingService(c, "other", s.AddTestingCharm(c, "dummy"))
+	unit, password := s.makeUnit(c, other)
+	resp := s.get(c, unit.Tag().String(), password, params.ResourceArgs{Service: "resourced"})
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusForbidden, "permission denied")
+}
+
+func (s *resourcesSuite) TestUnitCannotUpload(c *gc.C) {
+	unit, password := s.makeUnit(c, s.service)
+	resp, err := s.sendRequest(c, unit.Tag().String(), password, "PUT", s.resourcesURL(c), "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	defer resp.Body.Close()
+	s.checkErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+}
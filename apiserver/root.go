@@ -30,6 +30,16 @@ var (
 	// alive. When the ping returns an error, the server will be
 	// terminated.
 	mongoPingInterval = 10 * time.Second
+
+	// userDirectorySyncInterval defines the interval at which an API
+	// server checks the users created from its user directory, and
+	// disables those removed from the directory.
+	userDirectorySyncInterval = 10 * time.Minute
+
+	// apiServerReportInterval defines the interval at which an API
+	// server records its connection count and clock for controller
+	// health reports.
+	apiServerReportInterval = 30 * time.Second
 )
 
 type objectKey struct {
@@ -74,6 +84,9 @@ func newApiHandler(srv *Server, st *state.State, rpcConn *rpc.Conn, reqNotifier
 	if err := r.resources.RegisterNamed("logDir", common.StringResource(srv.logDir)); err != nil {
 		return nil, errors.Trace(err)
 	}
+	if err := r.resources.RegisterNamed("backgroundRunner", common.NewBackgroundRunner(srv.tomb.Dying(), &srv.wg)); err != nil {
+		return nil, errors.Trace(err)
+	}
 	return r, nil
 }
 
@@ -95,6 +95,36 @@ func (s *serverSuite) TestStop(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 }
 
+func (s *serverSuite) TestReportsAPIServer(c *gc.C) {
+	s.PatchValue(apiserver.APIServerReportInterval, coretesting.ShortWait)
+	listener, err := net.Listen("tcp", ":0")
+	c.Assert(err, jc.ErrorIsNil)
+	srv, err := apiserver.NewServer(s.State, listener, apiserver.ServerConfig{
+		Cert: []byte(coretesting.ServerCert),
+		Key:  []byte(coretesting.ServerKey),
+		Tag:  names.NewMachineTag("7"),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	defer srv.Stop()
+
+	info := s.APIInfo(c)
+	info.Addrs = []string{fmt.Sprintf("localhost:%d", srv.Addr().Port)}
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, jc.ErrorIsNil)
+	defer st.Close()
+
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		reports, err := s.State.APIServerReports()
+		c.Assert(err, jc.ErrorIsNil)
+		for _, report := range reports {
+			if report.MachineId == "7" && report.Connections == 1 {
+				return
+			}
+		}
+	}
+	c.Fatalf("API server did not report its connection")
+}
+
 func (s *serverSuite) TestAPIServerCanListenOnBothIPv4AndIPv6(c *gc.C) {
 	err := s.State.SetAPIHostPorts(nil)
 	c.Assert(err, jc.ErrorIsNil)
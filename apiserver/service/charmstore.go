@@ -18,6 +18,8 @@ import (
 	"gopkg.in/macaroon.v1"
 
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/charmrepository"
+	"github.com/juju/juju/charmsig"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/state"
 )
@@ -56,30 +58,39 @@ func AddCharmWithAuthorization(st *state.State, args params.AddCharmWithAuthoriz
 		return nil
 	}
 
-	// Get the charm and its information from the store.
+	// Get the charm and its information from the store, or from the
+	// controller charm repository if the environment uses one.
 	envConfig, err := st.EnvironConfig()
 	if err != nil {
 		return err
 	}
-	csURL, err := url.Parse(csclient.ServerURL)
-	if err != nil {
-		return err
-	}
-	csParams := charmrepo.NewCharmStoreParams{
-		URL:        csURL.String(),
-		HTTPClient: httpbakery.NewHTTPClient(),
-	}
-	if args.CharmStoreMacaroon != nil {
-		// Set the provided charmstore authorizing macaroon
-		// as a cookie in the HTTP client.
-		// TODO discharge any third party caveats in the macaroon.
-		ms := []*macaroon.Macaroon{args.CharmStoreMacaroon}
-		httpbakery.SetCookie(csParams.HTTPClient.Jar, csURL, ms)
+	var repo charmrepo.Interface
+	if envConfig.CharmRepositoryURL() != "" {
+		repo, err = charmrepository.NewForEnviron(envConfig)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	} else {
+		csURL, err := url.Parse(csclient.ServerURL)
+		if err != nil {
+			return err
+		}
+		csParams := charmrepo.NewCharmStoreParams{
+			URL:        csURL.String(),
+			HTTPClient: httpbakery.NewHTTPClient(),
+		}
+		if args.CharmStoreMacaroon != nil {
+			// Set the provided charmstore authorizing macaroon
+			// as a cookie in the HTTP client.
+			// TODO discharge any third party caveats in the macaroon.
+			ms := []*macaroon.Macaroon{args.CharmStoreMacaroon}
+			httpbakery.SetCookie(csParams.HTTPClient.Jar, csURL, ms)
+		}
+		repo = config.SpecializeCharmRepo(
+			NewCharmStore(csParams),
+			envConfig,
+		)
 	}
-	repo := config.SpecializeCharmRepo(
-		NewCharmStore(csParams),
-		envConfig,
-	)
 	downloadedCharm, err := repo.Get(charmURL)
 	if err != nil {
 		cause := errors.Cause(err)
@@ -94,6 +105,10 @@ func AddCharmWithAuthorization(st *state.State, args params.AddCharmWithAuthoriz
 	if !ok {
 		return errors.Errorf("expected a charm archive, got %T", downloadedCharm)
 	}
+	signer, err := VerifyCharmSignature(envConfig, downloadedBundle.Path)
+	if err != nil {
+		return errors.Annotatef(err, "cannot add charm %q", charmURL)
+	}
 	archive, err := os.Open(downloadedBundle.Path)
 	if err != nil {
 		return errors.Annotate(err, "cannot read downloaded charm")
@@ -115,11 +130,29 @@ func AddCharmWithAuthorization(st *state.State, args params.AddCharmWithAuthoriz
 		archive,
 		size,
 		bundleSHA256,
+		signer,
 	)
 }
 
-// StoreCharmArchive stores a charm archive in environment storage.
-func StoreCharmArchive(st *state.State, curl *charm.URL, ch charm.Charm, r io.Reader, size int64, sha256 string) error {
+// VerifyCharmSignature checks that the charm archive at the given path
+// is signed by one of the keys the environment trusts to sign charms,
+// and returns the identity of the signer. If the environment does not
+// require charms to be signed, it returns the empty string.
+func VerifyCharmSignature(envConfig *config.Config, archivePath string) (string, error) {
+	keys := envConfig.CharmSigningKeys()
+	if keys == "" {
+		return "", nil
+	}
+	keyring, err := charmsig.ReadKeyRing(keys)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return charmsig.VerifyArchive(archivePath, keyring)
+}
+
+// StoreCharmArchive stores a charm archive in environment storage,
+// recording the identity of the signer of the archive, if verified.
+func StoreCharmArchive(st *state.State, curl *charm.URL, ch charm.Charm, r io.Reader, size int64, sha256, signer string) error {
 	storage := newStateStorage(st.EnvironUUID(), st.MongoSession())
 	storagePath, err := charmArchiveStoragePath(curl)
 	if err != nil {
@@ -130,7 +163,7 @@ func StoreCharmArchive(st *state.State, curl *charm.URL, ch charm.Charm, r io.Re
 	}
 
 	// Now update the charm data in state and mark it as no longer pending.
-	_, err = st.UpdateUploadedCharm(ch, curl, storagePath, sha256)
+	_, err = st.UpdateUploadedCharm(ch, curl, storagePath, sha256, signer)
 	if err != nil {
 		alreadyUploaded := err == state.ErrCharmRevisionAlreadyModified ||
 			errors.Cause(err) == state.ErrCharmRevisionAlreadyModified ||
@@ -171,9 +204,17 @@ func ResolveCharms(st *state.State, args params.ResolveCharms) (params.ResolveCh
 	if err != nil {
 		return params.ResolveCharmResults{}, err
 	}
-	repo := config.SpecializeCharmRepo(
-		NewCharmStore(charmrepo.NewCharmStoreParams{}),
-		envConfig)
+	var repo charmrepo.Interface
+	if envConfig.CharmRepositoryURL() != "" {
+		repo, err = charmrepository.NewForEnviron(envConfig)
+		if err != nil {
+			return params.ResolveCharmResults{}, errors.Trace(err)
+		}
+	} else {
+		repo = config.SpecializeCharmRepo(
+			NewCharmStore(charmrepo.NewCharmStoreParams{}),
+			envConfig)
+	}
 
 	for _, ref := range args.References {
 		result := params.ResolveCharmResult{}
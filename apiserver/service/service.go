@@ -153,21 +153,23 @@ func DeployService(st *state.State, owner string, args params.ServiceDeploy) err
 		jjj.DeployServiceParams{
 			ServiceName: args.ServiceName,
 			// TODO(dfc) ServiceOwner should be a tag
-			ServiceOwner:   owner,
-			Charm:          ch,
-			NumUnits:       args.NumUnits,
-			ConfigSettings: settings,
-			Constraints:    args.Constraints,
-			ToMachineSpec:  args.ToMachineSpec,
-			Networks:       requestedNetworks,
-			Storage:        args.Storage,
+			ServiceOwner:     owner,
+			Charm:            ch,
+			NumUnits:         args.NumUnits,
+			ConfigSettings:   settings,
+			Constraints:      args.Constraints,
+			ToMachineSpec:    args.ToMachineSpec,
+			Networks:         requestedNetworks,
+			Storage:          args.Storage,
+			EndpointBindings: args.EndpointBindings,
 		})
 	return err
 }
 
 // ServiceSetSettingsStrings updates the settings for the given service,
-// taking the configuration from a map of strings.
-func ServiceSetSettingsStrings(service *state.Service, settings map[string]string) error {
+// taking the configuration from a map of strings. The change is
+// recorded in the service's config history as made by author.
+func ServiceSetSettingsStrings(service *state.Service, author names.UserTag, settings map[string]string) error {
 	ch, _, err := service.Charm()
 	if err != nil {
 		return err
@@ -177,7 +179,7 @@ func ServiceSetSettingsStrings(service *state.Service, settings map[string]strin
 	if err != nil {
 		return err
 	}
-	return service.UpdateConfigSettings(changes)
+	return service.UpdateConfigSettingsAs(author, changes)
 }
 
 func networkTagsToNames(tags []string) ([]string, error) {
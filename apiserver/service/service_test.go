@@ -6,6 +6,8 @@ package service_test
 import (
 	"fmt"
 	"io"
+	"net/url"
+	"os"
 	"sync"
 
 	"github.com/juju/errors"
@@ -21,6 +23,7 @@ import (
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/apiserver/service"
 	apiservertesting "github.com/juju/juju/apiserver/testing"
+	charmsigtesting "github.com/juju/juju/charmsig/testing"
 	"github.com/juju/juju/constraints"
 	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/state"
@@ -407,6 +410,72 @@ func (s *serviceSuite) TestAddCharmWithAuthorization(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 }
 
+func (s *serviceSuite) TestAddCharmVerifiesSignature(c *gc.C) {
+	key, publicKey := charmsigtesting.NewKey(c, "Charmer", "charmer@example.com")
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{"charm-signing-keys": publicKey}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	client := s.APIState.Client()
+
+	// Unsigned charms are rejected.
+	curl, _ := s.UploadCharm(c, "precise/wordpress-3", "wordpress")
+	err = client.AddCharm(curl)
+	c.Assert(err, gc.ErrorMatches, `cannot add charm ".*wordpress-3": charm is not signed`)
+
+	// Signed charms are added, and their signer recorded.
+	dir := testcharms.Repo.ClonedDirPath(c.MkDir(), "dummy")
+	ch, err := charm.ReadCharmArchive(charmsigtesting.SignedArchive(c, dir, key))
+	c.Assert(err, jc.ErrorIsNil)
+	id := charm.MustParseReference("precise/dummy-1")
+	id.User = "who"
+	curl = (*charm.URL)(s.Srv.UploadCharm(c, ch, id, true))
+	err = client.AddCharm(curl)
+	c.Assert(err, jc.ErrorIsNil)
+	sch, err := s.State.Charm(curl)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(sch.Signer(), gc.Equals, "Charmer <charmer@example.com>")
+}
+
+func (s *serviceSuite) TestAddCharmFromRepository(c *gc.C) {
+	archive := testcharms.Repo.CharmArchive(c.MkDir(), "dummy")
+	f, err := os.Open(archive.Path)
+	c.Assert(err, jc.ErrorIsNil)
+	defer f.Close()
+	hash, size, err := utils.ReadSHA256(f)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = f.Seek(0, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.PublishRepositoryCharm(charm.MustParseURL("cs:precise/dummy-7"), f, size, hash, s.AdminUserTag(c))
+	c.Assert(err, jc.ErrorIsNil)
+
+	apiInfo := s.APIInfo(c)
+	repositoryURL := (&url.URL{
+		Scheme: "https",
+		User:   url.UserPassword(apiInfo.Tag.String(), apiInfo.Password),
+		Host:   apiInfo.Addrs[0],
+		Path:   "/charmrepository",
+	}).String()
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{"charm-repository-url": repositoryURL}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	client := s.APIState.Client()
+
+	curl, err := client.ResolveCharm(charm.MustParseReference("cs:dummy"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(curl.String(), gc.Equals, "cs:precise/dummy")
+
+	curl = curl.WithRevision(7)
+	err = client.AddCharm(curl)
+	c.Assert(err, jc.ErrorIsNil)
+	sch, err := s.State.Charm(curl)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(sch.BundleSha256(), gc.Equals, hash)
+
+	// Charms not published in the repository are not found, even if
+	// they are in the charm store.
+	curl, _ = s.UploadCharm(c, "precise/wordpress-3", "wordpress")
+	err = client.AddCharm(curl)
+	c.Assert(err, gc.ErrorMatches, `cannot get charm ".*wordpress-3": charm ".*wordpress-3" in repository not found`)
+}
+
 func (s *serviceSuite) TestAddCharmConcurrently(c *gc.C) {
 	var putBarrier sync.WaitGroup
 	var blobs blobs
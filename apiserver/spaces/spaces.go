@@ -0,0 +1,96 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The spaces package implements the API used to create and list the
+// network spaces of an environment.
+package spaces
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("Spaces", 1, NewSpacesAPI)
+}
+
+// Spaces defines the methods on the spaces API end point.
+type Spaces interface {
+	CreateSpaces(args params.CreateSpacesParams) (params.ErrorResults, error)
+	ListSpaces() (params.ListSpacesResults, error)
+}
+
+// SpacesAPI implements the Spaces interface and is the concrete
+// implementation of the api end point.
+type SpacesAPI struct {
+	state      *state.State
+	authorizer common.Authorizer
+	check      *common.BlockChecker
+}
+
+var _ Spaces = (*SpacesAPI)(nil)
+
+// NewSpacesAPI creates a new server-side spaces API end point.
+func NewSpacesAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*SpacesAPI, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &SpacesAPI{
+		state:      st,
+		authorizer: authorizer,
+		check:      common.NewBlockChecker(st),
+	}, nil
+}
+
+// CreateSpaces creates the given spaces, each containing the existing
+// subnets with the given CIDRs.
+func (api *SpacesAPI) CreateSpaces(args params.CreateSpacesParams) (params.ErrorResults, error) {
+	if err := api.check.ChangeAllowed(); err != nil {
+		return params.ErrorResults{}, errors.Trace(err)
+	}
+	results := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Spaces)),
+	}
+	for i, space := range args.Spaces {
+		_, err := api.state.AddSpace(space.Name, space.SubnetCIDRs)
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
+
+// ListSpaces returns all the spaces of the environment, along with the
+// subnets in each of them.
+func (api *SpacesAPI) ListSpaces() (params.ListSpacesResults, error) {
+	spaces, err := api.state.AllSpaces()
+	if err != nil {
+		return params.ListSpacesResults{}, errors.Trace(err)
+	}
+	results := params.ListSpacesResults{
+		Results: make([]params.Space, len(spaces)),
+	}
+	for i, space := range spaces {
+		subnets, err := space.Subnets()
+		if err != nil {
+			return params.ListSpacesResults{}, errors.Trace(err)
+		}
+		result := params.Space{
+			Name:    space.Name(),
+			Subnets: make([]params.Subnet, len(subnets)),
+		}
+		for j, subnet := range subnets {
+			result.Subnets[j] = params.Subnet{
+				CIDR:       subnet.CIDR(),
+				ProviderId: subnet.ProviderId(),
+				VLANTag:    subnet.VLANTag(),
+				Zone:       subnet.AvailabilityZone(),
+				SpaceName:  subnet.SpaceName(),
+				Life:       params.Life(subnet.Life().String()),
+			}
+		}
+		results.Results[i] = result
+	}
+	return results, nil
+}
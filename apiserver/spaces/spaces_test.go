@@ -0,0 +1,107 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package spaces_test
+
+import (
+	stdtesting "testing"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	commontesting "github.com/juju/juju/apiserver/common/testing"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/apiserver/spaces"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type spacesSuite struct {
+	testing.JujuConnSuite
+
+	resources  *common.Resources
+	authoriser apiservertesting.FakeAuthorizer
+	api        *spaces.SpacesAPI
+
+	commontesting.BlockHelper
+}
+
+var _ = gc.Suite(&spacesSuite{})
+
+func (s *spacesSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
+	s.authoriser = apiservertesting.FakeAuthorizer{
+		Tag: s.AdminUserTag(c),
+	}
+	var err error
+	s.api, err = spaces.NewSpacesAPI(s.State, s.resources, s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.BlockHelper = commontesting.NewBlockHelper(s.APIState)
+	s.AddCleanup(func(*gc.C) { s.BlockHelper.Close() })
+}
+
+func (s *spacesSuite) TestNewAPIRefusesAgents(c *gc.C) {
+	auth := apiservertesting.FakeAuthorizer{Tag: names.NewMachineTag("0")}
+	_, err := spaces.NewSpacesAPI(s.State, s.resources, auth)
+	c.Assert(err, gc.Equals, common.ErrPerm)
+}
+
+func (s *spacesSuite) TestCreateAndListSpaces(c *gc.C) {
+	_, err := s.State.AddSubnet(state.SubnetInfo{
+		CIDR:             "10.0.0.0/24",
+		ProviderId:       "subnet-0",
+		AvailabilityZone: "zone0",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	results, err := s.api.CreateSpaces(params.CreateSpacesParams{
+		Spaces: []params.CreateSpaceParams{
+			{Name: "db", SubnetCIDRs: []string{"10.0.0.0/24"}},
+			{Name: "apps"},
+			{Name: "db"},
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results, gc.HasLen, 3)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	c.Assert(results.Results[1].Error, gc.IsNil)
+	c.Assert(results.Results[2].Error, gc.ErrorMatches, `cannot add space "db": space "db" already exists`)
+
+	list, err := s.api.ListSpaces()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(list, jc.DeepEquals, params.ListSpacesResults{
+		Results: []params.Space{{
+			Name:    "apps",
+			Subnets: []params.Subnet{},
+		}, {
+			Name: "db",
+			Subnets: []params.Subnet{{
+				CIDR:       "10.0.0.0/24",
+				ProviderId: "subnet-0",
+				Zone:       "zone0",
+				SpaceName:  "db",
+				Life:       params.Alive,
+			}},
+		}},
+	})
+}
+
+func (s *spacesSuite) TestCreateSpacesBlocked(c *gc.C) {
+	s.BlockAllChanges(c, "TestCreateSpacesBlocked")
+	_, err := s.api.CreateSpaces(params.CreateSpacesParams{
+		Spaces: []params.CreateSpaceParams{{Name: "db"}},
+	})
+	s.AssertBlocked(c, err, "TestCreateSpacesBlocked")
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The subnets package implements the API used to add subnets to the
+// network spaces of an environment.
+package subnets
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("Subnets", 1, NewSubnetsAPI)
+}
+
+// Subnets defines the methods on the subnets API end point.
+type Subnets interface {
+	AddSubnets(args params.AddSubnetsParams) (params.ErrorResults, error)
+}
+
+// SubnetsAPI implements the Subnets interface and is the concrete
+// implementation of the api end point.
+type SubnetsAPI struct {
+	state      *state.State
+	authorizer common.Authorizer
+	check      *common.BlockChecker
+}
+
+var _ Subnets = (*SubnetsAPI)(nil)
+
+// NewSubnetsAPI creates a new server-side subnets API end point.
+func NewSubnetsAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*SubnetsAPI, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &SubnetsAPI{
+		state:      st,
+		authorizer: authorizer,
+		check:      common.NewBlockChecker(st),
+	}, nil
+}
+
+// AddSubnets adds the given subnets to the environment, each in the
+// existing space it names.
+func (api *SubnetsAPI) AddSubnets(args params.AddSubnetsParams) (params.ErrorResults, error) {
+	if err := api.check.ChangeAllowed(); err != nil {
+		return params.ErrorResults{}, errors.Trace(err)
+	}
+	results := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Subnets)),
+	}
+	for i, subnet := range args.Subnets {
+		if subnet.SpaceName == "" {
+			err := errors.Errorf("cannot add subnet %q: space not specified", subnet.CIDR)
+			results.Results[i].Error = common.ServerError(err)
+			continue
+		}
+		_, err := api.state.AddSubnet(state.SubnetInfo{
+			CIDR:             subnet.CIDR,
+			ProviderId:       subnet.ProviderId,
+			VLANTag:          subnet.VLANTag,
+			AvailabilityZone: subnet.Zone,
+			SpaceName:        subnet.SpaceName,
+		})
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
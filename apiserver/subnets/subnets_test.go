@@ -0,0 +1,93 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package subnets_test
+
+import (
+	stdtesting "testing"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	commontesting "github.com/juju/juju/apiserver/common/testing"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/apiserver/subnets"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/juju/testing"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestAll(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type subnetsSuite struct {
+	testing.JujuConnSuite
+
+	resources  *common.Resources
+	authoriser apiservertesting.FakeAuthorizer
+	api        *subnets.SubnetsAPI
+
+	commontesting.BlockHelper
+}
+
+var _ = gc.Suite(&subnetsSuite{})
+
+func (s *subnetsSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
+	s.authoriser = apiservertesting.FakeAuthorizer{
+		Tag: s.AdminUserTag(c),
+	}
+	var err error
+	s.api, err = subnets.NewSubnetsAPI(s.State, s.resources, s.authoriser)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.BlockHelper = commontesting.NewBlockHelper(s.APIState)
+	s.AddCleanup(func(*gc.C) { s.BlockHelper.Close() })
+}
+
+func (s *subnetsSuite) TestNewAPIRefusesAgents(c *gc.C) {
+	auth := apiservertesting.FakeAuthorizer{Tag: names.NewMachineTag("0")}
+	_, err := subnets.NewSubnetsAPI(s.State, s.resources, auth)
+	c.Assert(err, gc.Equals, common.ErrPerm)
+}
+
+func (s *subnetsSuite) TestAddSubnets(c *gc.C) {
+	_, err := s.State.AddSpace("db", nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	results, err := s.api.AddSubnets(params.AddSubnetsParams{
+		Subnets: []params.Subnet{
+			{CIDR: "10.0.0.0/24", ProviderId: "subnet-0", VLANTag: 42, Zone: "zone0", SpaceName: "db"},
+			{CIDR: "10.1.0.0/24"},
+			{CIDR: "10.2.0.0/24", SpaceName: "missing"},
+			{CIDR: "bogus", SpaceName: "db"},
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results, gc.HasLen, 4)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	c.Assert(results.Results[1].Error, gc.ErrorMatches, `cannot add subnet "10.1.0.0/24": space not specified`)
+	c.Assert(results.Results[2].Error, gc.ErrorMatches, `cannot add subnet "10.2.0.0/24": space "missing" not found`)
+	c.Assert(results.Results[3].Error, gc.ErrorMatches, `cannot add subnet "bogus": invalid CIDR address: bogus`)
+
+	subnet, err := s.State.Subnet("10.0.0.0/24")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(subnet.ProviderId(), gc.Equals, "subnet-0")
+	c.Assert(subnet.VLANTag(), gc.Equals, 42)
+	c.Assert(subnet.AvailabilityZone(), gc.Equals, "zone0")
+	c.Assert(subnet.SpaceName(), gc.Equals, "db")
+}
+
+func (s *subnetsSuite) TestAddSubnetsBlocked(c *gc.C) {
+	s.BlockAllChanges(c, "TestAddSubnetsBlocked")
+	_, err := s.api.AddSubnets(params.AddSubnetsParams{
+		Subnets: []params.Subnet{{CIDR: "10.0.0.0/24", SpaceName: "db"}},
+	})
+	s.AssertBlocked(c, err, "TestAddSubnetsBlocked")
+}
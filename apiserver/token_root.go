@@ -0,0 +1,44 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/rpcreflect"
+	"github.com/juju/juju/state"
+)
+
+// tokenRoot restricts API calls to the facades allowed by the API token
+// with which the user logged in.
+type tokenRoot struct {
+	rpc.MethodFinder
+	token *state.APIToken
+}
+
+// newTokenRoot returns a new tokenRoot.
+func newTokenRoot(finder rpc.MethodFinder, token *state.APIToken) *tokenRoot {
+	return &tokenRoot{finder, token}
+}
+
+// tokenRootAllows returns whether connections made with the token may
+// use the named facade. The Pinger facade is always allowed, as clients
+// use it to keep their connections alive.
+func tokenRootAllows(token *state.APIToken, rootName string) bool {
+	return rootName == "Pinger" || token.AllowsFacade(rootName)
+}
+
+// FindMethod returns common.ErrPerm if the rootName is not
+// one of the facades allowed by the token.
+func (r *tokenRoot) FindMethod(rootName string, version int, methodName string) (rpcreflect.MethodCaller, error) {
+	caller, err := r.MethodFinder.FindMethod(rootName, version, methodName)
+	if err != nil {
+		return nil, err
+	}
+	if !tokenRootAllows(r.token, rootName) {
+		logger.Debugf("API token %q does not allow access to %q", r.token.Name(), rootName)
+		return nil, common.ErrPerm
+	}
+	return caller, nil
+}
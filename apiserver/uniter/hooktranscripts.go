@@ -0,0 +1,55 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+// AddHookTranscripts stores the supplied hook transcripts for each
+// given unit.
+func (u *UniterAPIV3) AddHookTranscripts(args params.HookTranscriptArgs) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Args)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, arg := range args.Args {
+		unit, err := u.getAccessibleUnit(canAccess, arg.Tag)
+		if err == nil {
+			err = unit.AddHookTranscript(hookTranscriptFromParams(arg.Transcript))
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+func hookTranscriptFromParams(t params.HookTranscript) state.HookTranscript {
+	calls := make([]state.HookToolCall, len(t.Calls))
+	for i, call := range t.Calls {
+		calls[i] = state.HookToolCall{
+			Command:   call.Command,
+			Args:      call.Args,
+			Stdin:     call.Stdin,
+			Stdout:    call.Stdout,
+			Stderr:    call.Stderr,
+			Code:      call.Code,
+			Truncated: call.Truncated,
+		}
+	}
+	return state.HookTranscript{
+		Kind:     t.Kind,
+		Name:     t.Name,
+		Env:      t.Env,
+		Calls:    calls,
+		ExitCode: t.ExitCode,
+		Error:    t.Error,
+		Started:  t.Started,
+		Duration: t.Duration,
+	}
+}
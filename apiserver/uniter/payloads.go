@@ -0,0 +1,85 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+// RegisterPayloads records the supplied workload payloads against
+// each given unit.
+func (u *UniterAPIV3) RegisterPayloads(args params.RegisterPayloadArgs) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Args)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, arg := range args.Args {
+		unit, err := u.getAccessibleUnit(canAccess, arg.Tag)
+		if err == nil {
+			err = unit.RegisterPayload(state.PayloadInfo{
+				Name:   arg.Payload.Name,
+				Type:   arg.Payload.Type,
+				ID:     arg.Payload.ID,
+				Status: state.PayloadStatus(arg.Payload.Status),
+				Labels: arg.Payload.Labels,
+			})
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// UnregisterPayloads removes the named payloads from each given unit.
+func (u *UniterAPIV3) UnregisterPayloads(args params.PayloadStatusArgs) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Args)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, arg := range args.Args {
+		unit, err := u.getAccessibleUnit(canAccess, arg.Tag)
+		if err == nil {
+			err = unit.UnregisterPayload(arg.Name)
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// SetPayloadsStatus sets the status of the named payloads of each
+// given unit.
+func (u *UniterAPIV3) SetPayloadsStatus(args params.PayloadStatusArgs) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Args)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, arg := range args.Args {
+		unit, err := u.getAccessibleUnit(canAccess, arg.Tag)
+		if err == nil {
+			err = unit.SetPayloadStatus(arg.Name, state.PayloadStatus(arg.Status))
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+func (u *UniterAPIV3) getAccessibleUnit(canAccess common.AuthFunc, unitTag string) (*state.Unit, error) {
+	tag, err := names.ParseUnitTag(unitTag)
+	if err != nil || !canAccess(tag) {
+		return nil, common.ErrPerm
+	}
+	return u.getUnit(tag)
+}
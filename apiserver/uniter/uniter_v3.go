@@ -0,0 +1,33 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The uniter package implements the API interface used by the uniter
+// worker. This file contains the API facade version 3.
+
+package uniter
+
+import (
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/state"
+)
+
+func init() {
+	common.RegisterStandardFacade("Uniter", 3, NewUniterAPIV3)
+}
+
+// UniterAPIV3 implements the API version 3, used by the uniter worker.
+// It adds workload payloads and hook transcripts to version 2.
+type UniterAPIV3 struct {
+	UniterAPIV2
+}
+
+// NewUniterAPIV3 creates a new instance of the Uniter API, version 3.
+func NewUniterAPIV3(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*UniterAPIV3, error) {
+	baseAPI, err := NewUniterAPIV2(st, resources, authorizer)
+	if err != nil {
+		return nil, err
+	}
+	return &UniterAPIV3{
+		UniterAPIV2: *baseAPI,
+	}, nil
+}
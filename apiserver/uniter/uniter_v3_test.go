@@ -0,0 +1,159 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/apiserver/uniter"
+	"github.com/juju/juju/state"
+)
+
+type uniterV3Suite struct {
+	uniterBaseSuite
+	uniter *uniter.UniterAPIV3
+}
+
+var _ = gc.Suite(&uniterV3Suite{})
+
+func (s *uniterV3Suite) SetUpTest(c *gc.C) {
+	s.uniterBaseSuite.setUpTest(c)
+
+	uniterAPIV3, err := uniter.NewUniterAPIV3(
+		s.State,
+		s.resources,
+		s.authorizer,
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	s.uniter = uniterAPIV3
+}
+
+func (s *uniterV3Suite) TestRegisterPayloads(c *gc.C) {
+	args := params.RegisterPayloadArgs{Args: []params.RegisterPayloadArg{{
+		Tag: s.wordpressUnit.Tag().String(),
+		Payload: params.Payload{
+			Name:   "web",
+			Type:   "docker",
+			ID:     "abc123",
+			Status: "running",
+			Labels: []string{"frontend"},
+		},
+	}, {
+		Tag:     s.mysqlUnit.Tag().String(),
+		Payload: params.Payload{Name: "db", Type: "process", ID: "42", Status: "running"},
+	}, {
+		Tag:     s.wordpressUnit.Tag().String(),
+		Payload: params.Payload{Name: "bad", Type: "docker", ID: "x", Status: "exploded"},
+	}}}
+	result, err := s.uniter.RegisterPayloads(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 3)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[1].Error, jc.DeepEquals, apiservertesting.ErrUnauthorized)
+	c.Assert(result.Results[2].Error, gc.ErrorMatches, `.*payload "bad" with status "exploded" not valid`)
+
+	payloads, err := s.wordpressUnit.Payloads()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(payloads, gc.HasLen, 1)
+	c.Assert(payloads[0].PayloadInfo, jc.DeepEquals, state.PayloadInfo{
+		Name:   "web",
+		Type:   "docker",
+		ID:     "abc123",
+		Status: state.PayloadRunning,
+		Labels: []string{"frontend"},
+	})
+	payloads, err = s.mysqlUnit.Payloads()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(payloads, gc.HasLen, 0)
+}
+
+func (s *uniterV3Suite) TestSetPayloadsStatus(c *gc.C) {
+	err := s.wordpressUnit.RegisterPayload(state.PayloadInfo{
+		Name: "web", Type: "docker", ID: "abc123", Status: state.PayloadRunning,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := s.uniter.SetPayloadsStatus(params.PayloadStatusArgs{Args: []params.PayloadStatusArg{
+		{Tag: s.wordpressUnit.Tag().String(), Name: "web", Status: "stopped"},
+		{Tag: s.wordpressUnit.Tag().String(), Name: "missing", Status: "stopped"},
+		{Tag: s.mysqlUnit.Tag().String(), Name: "web", Status: "stopped"},
+	}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 3)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[1].Error, gc.ErrorMatches, `.*payload "missing" not found`)
+	c.Assert(result.Results[2].Error, jc.DeepEquals, apiservertesting.ErrUnauthorized)
+
+	payload, err := s.wordpressUnit.Payload("web")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(payload.Status, gc.Equals, state.PayloadStopped)
+}
+
+func (s *uniterV3Suite) TestUnregisterPayloads(c *gc.C) {
+	err := s.wordpressUnit.RegisterPayload(state.PayloadInfo{
+		Name: "web", Type: "docker", ID: "abc123", Status: state.PayloadRunning,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := s.uniter.UnregisterPayloads(params.PayloadStatusArgs{Args: []params.PayloadStatusArg{
+		{Tag: s.wordpressUnit.Tag().String(), Name: "web"},
+		{Tag: "not-a-tag", Name: "web"},
+	}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.ErrorResults{Results: []params.ErrorResult{
+		{nil},
+		{apiservertesting.ErrUnauthorized},
+	}})
+
+	payloads, err := s.wordpressUnit.Payloads()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(payloads, gc.HasLen, 0)
+}
+
+func (s *uniterV3Suite) TestAddHookTranscripts(c *gc.C) {
+	started := time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC)
+	transcript := params.HookTranscript{
+		Kind: "hook",
+		Name: "config-changed",
+		Env:  []string{"JUJU_UNIT_NAME=wordpress/0"},
+		Calls: []params.HookToolCall{{
+			Command: "config-get",
+			Args:    []string{"--format", "json"},
+			Stdout:  "{}",
+		}},
+		ExitCode: 1,
+		Error:    "exit status 1",
+		Started:  started,
+		Duration: time.Second,
+	}
+	result, err := s.uniter.AddHookTranscripts(params.HookTranscriptArgs{Args: []params.HookTranscriptArg{
+		{Tag: s.wordpressUnit.Tag().String(), Transcript: transcript},
+		{Tag: s.mysqlUnit.Tag().String(), Transcript: transcript},
+		{Tag: s.wordpressUnit.Tag().String(), Transcript: params.HookTranscript{Kind: "hook"}},
+	}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Results, gc.HasLen, 3)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[1].Error, jc.DeepEquals, apiservertesting.ErrUnauthorized)
+	c.Assert(result.Results[2].Error, gc.ErrorMatches, `.*missing hook name not valid`)
+
+	transcripts, err := s.wordpressUnit.HookTranscripts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(transcripts, gc.HasLen, 1)
+	c.Assert(transcripts[0].Name, gc.Equals, "config-changed")
+	c.Assert(transcripts[0].Started, gc.Equals, started)
+	c.Assert(transcripts[0].Calls, jc.DeepEquals, []state.HookToolCall{{
+		Command: "config-get",
+		Args:    []string{"--format", "json"},
+		Stdout:  "{}",
+	}})
+	transcripts, err = s.mysqlUnit.HookTranscripts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(transcripts, gc.HasLen, 0)
+}
@@ -22,8 +22,11 @@ func init() {
 // UserManager defines the methods on the usermanager API end point.
 type UserManager interface {
 	AddUser(args params.AddUsers) (params.AddUserResults, error)
+	CreateAPITokens(args params.CreateAPITokens) (params.CreateAPITokenResults, error)
 	DisableUser(args params.Entities) (params.ErrorResults, error)
 	EnableUser(args params.Entities) (params.ErrorResults, error)
+	ListAPITokens() (params.APITokensResult, error)
+	RevokeAPITokens(args params.APITokenNames) (params.ErrorResults, error)
 	SetPassword(args params.EntityPasswords) (params.ErrorResults, error)
 	UserInfo(args params.UserInfoRequest) (params.UserInfoResults, error)
 }
@@ -248,6 +251,81 @@ func (api *UserManagerAPI) SetPassword(args params.EntityPasswords) (params.Erro
 	return result, nil
 }
 
+// CreateAPITokens creates API tokens with which the logged in user can
+// log in to the environment in place of their password.
+func (api *UserManagerAPI) CreateAPITokens(args params.CreateAPITokens) (params.CreateAPITokenResults, error) {
+	result := params.CreateAPITokenResults{
+		Results: make([]params.CreateAPITokenResult, len(args.Tokens)),
+	}
+	if err := api.check.ChangeAllowed(); err != nil {
+		return result, errors.Trace(err)
+	}
+	if len(args.Tokens) == 0 {
+		return result, nil
+	}
+	loggedInUser, err := api.getLoggedInUser()
+	if err != nil {
+		return result, common.ErrPerm
+	}
+	for i, arg := range args.Tokens {
+		_, credentials, err := api.state.AddAPIToken(loggedInUser, arg.Name, arg.Facades, arg.Expires)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(err)
+			continue
+		}
+		result.Results[i].Credentials = credentials
+	}
+	return result, nil
+}
+
+// ListAPITokens returns the logged in user's API tokens for the
+// environment.
+func (api *UserManagerAPI) ListAPITokens() (params.APITokensResult, error) {
+	var result params.APITokensResult
+	loggedInUser, err := api.getLoggedInUser()
+	if err != nil {
+		return result, common.ErrPerm
+	}
+	tokens, err := api.state.APITokens(loggedInUser)
+	if err != nil {
+		return result, errors.Trace(err)
+	}
+	result.Tokens = make([]params.APIToken, len(tokens))
+	for i, token := range tokens {
+		result.Tokens[i] = params.APIToken{
+			Name:    token.Name(),
+			Facades: token.Facades(),
+			Created: token.Created(),
+			Expires: token.Expires(),
+		}
+	}
+	return result, nil
+}
+
+// RevokeAPITokens revokes the logged in user's API tokens with the
+// given names.
+func (api *UserManagerAPI) RevokeAPITokens(args params.APITokenNames) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Names)),
+	}
+	if err := api.check.RemoveAllowed(); err != nil {
+		return result, errors.Trace(err)
+	}
+	if len(args.Names) == 0 {
+		return result, nil
+	}
+	loggedInUser, err := api.getLoggedInUser()
+	if err != nil {
+		return result, common.ErrPerm
+	}
+	for i, name := range args.Names {
+		if err := api.state.RevokeAPIToken(loggedInUser, name); err != nil {
+			result.Results[i].Error = common.ServerError(err)
+		}
+	}
+	return result, nil
+}
+
 func (api *UserManagerAPI) getLoggedInUser() (names.UserTag, error) {
 	switch tag := api.authorizer.GetAuthTag().(type) {
 	case names.UserTag:
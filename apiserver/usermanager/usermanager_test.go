@@ -4,6 +4,8 @@
 package usermanager_test
 
 import (
+	"time"
+
 	"github.com/juju/errors"
 	"github.com/juju/names"
 	jc "github.com/juju/testing/checkers"
@@ -14,6 +16,7 @@ import (
 	apiservertesting "github.com/juju/juju/apiserver/testing"
 	"github.com/juju/juju/apiserver/usermanager"
 	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
 	"github.com/juju/juju/testing/factory"
 )
 
@@ -481,3 +484,75 @@ func (s *userManagerSuite) TestSetPasswordForOther(c *gc.C) {
 
 	c.Assert(barb.PasswordValid("new-password"), jc.IsFalse)
 }
+
+func (s *userManagerSuite) TestCreateAPITokens(c *gc.C) {
+	expires := time.Now().Add(time.Hour).Round(time.Second).UTC()
+	args := params.CreateAPITokens{
+		Tokens: []params.CreateAPIToken{{
+			Name:    "ci",
+			Facades: []string{"Client"},
+			Expires: &expires,
+		}, {
+			Name: "bad name",
+		}}}
+	results, err := s.usermanager.CreateAPITokens(args)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results, gc.HasLen, 2)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	c.Assert(results.Results[1].Error, gc.ErrorMatches, `cannot add API token "bad name" for user ".*": token name "bad name" not valid`)
+
+	token, err := s.State.APIToken(s.AdminUserTag(c), "ci")
+	c.Assert(err, jc.ErrorIsNil)
+	name, secret, ok := state.ParseAPITokenCredentials(results.Results[0].Credentials)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(name, gc.Equals, "ci")
+	c.Assert(token.SecretValid(secret), jc.IsTrue)
+	c.Assert(token.Facades(), jc.DeepEquals, []string{"Client"})
+	c.Assert(*token.Expires(), gc.Equals, expires)
+}
+
+func (s *userManagerSuite) TestBlockCreateAPITokens(c *gc.C) {
+	s.BlockAllChanges(c, "TestBlockCreateAPITokens")
+	_, err := s.usermanager.CreateAPITokens(params.CreateAPITokens{
+		Tokens: []params.CreateAPIToken{{Name: "ci"}},
+	})
+	s.AssertBlocked(c, err, "TestBlockCreateAPITokens")
+	_, err = s.State.APIToken(s.AdminUserTag(c), "ci")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *userManagerSuite) TestListAPITokens(c *gc.C) {
+	expires := time.Now().Add(time.Hour).Round(time.Second).UTC()
+	token, _, err := s.State.AddAPIToken(s.AdminUserTag(c), "ci", []string{"Client"}, &expires)
+	c.Assert(err, jc.ErrorIsNil)
+	// Only the logged in user's tokens are listed.
+	other := s.Factory.MakeUser(c, nil)
+	_, _, err = s.State.AddAPIToken(other.UserTag(), "other", nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := s.usermanager.ListAPITokens()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, params.APITokensResult{
+		Tokens: []params.APIToken{{
+			Name:    "ci",
+			Facades: []string{"Client"},
+			Created: token.Created(),
+			Expires: &expires,
+		}},
+	})
+}
+
+func (s *userManagerSuite) TestRevokeAPITokens(c *gc.C) {
+	_, _, err := s.State.AddAPIToken(s.AdminUserTag(c), "ci", nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	results, err := s.usermanager.RevokeAPITokens(params.APITokenNames{
+		Names: []string{"ci", "missing"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(results.Results, gc.HasLen, 2)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	c.Assert(results.Results[1].Error, gc.ErrorMatches, `API token "missing" for user ".*" not found`)
+	_, err = s.State.APIToken(s.AdminUserTag(c), "ci")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
@@ -80,7 +80,9 @@ func validateEnvironUUID(args validateArgs) (*state.State, bool, error) {
 	envTag := names.NewEnvironTag(args.envUUID)
 	if env, err := args.st.GetEnvironment(envTag); err != nil {
 		return nil, false, errors.Wrap(err, common.UnknownEnvironmentError(args.envUUID))
-	} else if env.Life() != state.Alive {
+	} else if env.Life() != state.Alive && env.MigratedTo() == nil {
+		// The agents of a migrated environment may still connect,
+		// to learn the addresses of its new state server.
 		return nil, false, errors.Errorf("environment %q is no longer live", args.envUUID)
 	}
 	logger.Debugf("validate env uuid: %s", args.envUUID)
@@ -56,6 +56,21 @@ func (certSuite) TestParseCertificate(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "no certificates found")
 }
 
+func (certSuite) TestParseCerts(c *gc.C) {
+	otherCertPEM, _, err := cert.NewCA("other", time.Now().AddDate(1, 0, 0))
+	c.Assert(err, jc.ErrorIsNil)
+
+	xcerts, err := cert.ParseCerts(caCertPEM + caKeyPEM + otherCertPEM)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(xcerts, gc.HasLen, 2)
+	c.Assert(xcerts[0].Subject.CommonName, gc.Equals, "juju testing")
+	c.Assert(xcerts[1].Subject.CommonName, gc.Equals, `juju-generated CA for environment "other"`)
+
+	xcerts, err = cert.ParseCerts(caKeyPEM)
+	c.Check(xcerts, gc.IsNil)
+	c.Assert(err, gc.ErrorMatches, "no certificates found")
+}
+
 func (certSuite) TestParseCertAndKey(c *gc.C) {
 	xcert, key, err := cert.ParseCertAndKey(caCertPEM, caKeyPEM)
 	c.Assert(err, jc.ErrorIsNil)
@@ -0,0 +1,149 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charmrepository_test
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5"
+	"gopkg.in/juju/charm.v5/charmrepo"
+
+	"github.com/juju/juju/charmrepository"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/testcharms"
+)
+
+type repositorySuite struct {
+	jujutesting.JujuConnSuite
+	repo *charmrepository.Repository
+}
+
+var _ = gc.Suite(&repositorySuite{})
+
+func (s *repositorySuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.PatchValue(&charmrepo.CacheDir, c.MkDir())
+	apiInfo := s.APIInfo(c)
+	repositoryURL := &url.URL{
+		Scheme: "https",
+		User:   url.UserPassword(apiInfo.Tag.String(), apiInfo.Password),
+		Host:   apiInfo.Addrs[0],
+		Path:   "/charmrepository",
+	}
+	repo, err := charmrepository.New(repositoryURL.String(), apiInfo.CACert)
+	c.Assert(err, jc.ErrorIsNil)
+	s.repo = repo
+}
+
+func (s *repositorySuite) publish(c *gc.C, url, name string) {
+	archive := testcharms.Repo.CharmArchive(c.MkDir(), name)
+	f, err := os.Open(archive.Path)
+	c.Assert(err, jc.ErrorIsNil)
+	defer f.Close()
+	hash, size, err := utils.ReadSHA256(f)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = f.Seek(0, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.PublishRepositoryCharm(charm.MustParseURL(url), f, size, hash, s.AdminUserTag(c))
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *repositorySuite) TestGet(c *gc.C) {
+	s.publish(c, "cs:quantal/dummy-3", "dummy")
+	ch, err := s.repo.Get(charm.MustParseURL("cs:quantal/dummy-3"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ch.Meta().Name, gc.Equals, "dummy")
+
+	// The archive is now cached.
+	archive, ok := ch.(*charm.CharmArchive)
+	c.Assert(ok, jc.IsTrue)
+	_, err = os.Stat(archive.Path)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *repositorySuite) TestGetNotPublished(c *gc.C) {
+	_, err := s.repo.Get(charm.MustParseURL("cs:quantal/dummy-3"))
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *repositorySuite) TestGetUnauthorized(c *gc.C) {
+	s.publish(c, "cs:quantal/dummy-3", "dummy")
+	apiInfo := s.APIInfo(c)
+	repo, err := charmrepository.New("https://"+apiInfo.Addrs[0]+"/charmrepository", apiInfo.CACert)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = repo.Get(charm.MustParseURL("cs:quantal/dummy-3"))
+	c.Assert(err, gc.ErrorMatches, `cannot get charm "cs:quantal/dummy-3": unauthorized`)
+}
+
+func (s *repositorySuite) TestGetUnresolved(c *gc.C) {
+	_, err := s.repo.Get(charm.MustParseURL("cs:quantal/dummy"))
+	c.Assert(err, gc.ErrorMatches, `charm URL "cs:quantal/dummy" is not fully resolved`)
+}
+
+func (s *repositorySuite) TestLatest(c *gc.C) {
+	s.publish(c, "cs:quantal/dummy-3", "dummy")
+	s.publish(c, "cs:quantal/dummy-12", "dummy")
+	s.publish(c, "cs:trusty/dummy-20", "dummy")
+
+	revisions, err := s.repo.Latest(
+		charm.MustParseURL("cs:quantal/dummy-3"),
+		charm.MustParseURL("cs:trusty/dummy"),
+		charm.MustParseURL("cs:quantal/mysql"),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(revisions, gc.HasLen, 3)
+	c.Assert(revisions[0].Err, jc.ErrorIsNil)
+	c.Assert(revisions[0].Revision, gc.Equals, 12)
+	c.Assert(revisions[1].Err, jc.ErrorIsNil)
+	c.Assert(revisions[1].Revision, gc.Equals, 20)
+	c.Assert(revisions[2].Err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *repositorySuite) TestResolve(c *gc.C) {
+	s.publish(c, "cs:quantal/dummy-3", "dummy")
+	s.publish(c, "cs:quantal/dummy-12", "dummy")
+	s.publish(c, "cs:trusty/mysql-1", "mysql")
+	s.publish(c, "cs:precise/mysql-1", "mysql")
+
+	for i, test := range []struct {
+		ref    string
+		expect string
+		err    string
+	}{{
+		ref:    "cs:quantal/dummy",
+		expect: "cs:quantal/dummy-12",
+	}, {
+		ref:    "cs:dummy",
+		expect: "cs:quantal/dummy-12",
+	}, {
+		ref:    "cs:dummy-3",
+		expect: "cs:quantal/dummy-3",
+	}, {
+		ref:    "cs:trusty/mysql",
+		expect: "cs:trusty/mysql-1",
+	}, {
+		ref: "cs:mysql",
+		err: `cannot resolve charm URL "cs:mysql": published for more than one series`,
+	}, {
+		ref: "cs:dummy-4",
+		err: `charm "cs:dummy-4" in repository not found`,
+	}, {
+		ref: "cs:wordpress",
+		err: `charm "cs:wordpress" in repository not found`,
+	}} {
+		c.Logf("test %d: %s", i, test.ref)
+		curl, err := s.repo.Resolve(charm.MustParseReference(test.ref))
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(curl.String(), gc.Equals, test.expect)
+	}
+}
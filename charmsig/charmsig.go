@@ -0,0 +1,185 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package charmsig implements the signing of charms with OpenPGP keys,
+// and the verification of those signatures against a set of trusted
+// public keys.
+//
+// A charm is signed by an armored OpenPGP detached signature, held in
+// the signature.asc file at the root of the charm, of the charm's
+// manifest. The manifest has one line for each file in the charm, in
+// byte order of path:
+//
+//	<hex-encoded SHA-256 of the content>  <path>\n
+//
+// where the content of a symbolic link is the path it points to. The
+// revision file, the signature itself, and the top level hidden files
+// and build directory, none of which are deployed, are left out of the
+// manifest, so the signature remains valid when juju repackages a
+// charm with a new revision.
+package charmsig
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+	"golang.org/x/crypto/openpgp"
+	"gopkg.in/juju/charm.v5"
+)
+
+// SignatureFile is the name of the file holding a charm's signature.
+const SignatureFile = "signature.asc"
+
+// ReadKeyRing parses the given armored OpenPGP public keys.
+func ReadKeyRing(armoredKeys string) (openpgp.EntityList, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredKeys))
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot parse public keys")
+	}
+	return keyring, nil
+}
+
+// Manifest returns the manifest of the charm archive read by r, as
+// covered by its signature.
+func Manifest(r *zip.Reader) ([]byte, error) {
+	lines := make(map[string]string)
+	var paths []string
+	for _, f := range r.File {
+		name := path.Clean(f.Name)
+		if f.FileInfo().IsDir() || !signed(name) {
+			continue
+		}
+		if _, ok := lines[name]; ok {
+			return nil, errors.Errorf("duplicate file %q in charm archive", name)
+		}
+		content, err := f.Open()
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot read %q", name)
+		}
+		hash := sha256.New()
+		_, err = io.Copy(hash, content)
+		content.Close()
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot read %q", name)
+		}
+		lines[name] = fmt.Sprintf("%x  %s\n", hash.Sum(nil), name)
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+	var manifest bytes.Buffer
+	for _, name := range paths {
+		manifest.WriteString(lines[name])
+	}
+	return manifest.Bytes(), nil
+}
+
+// signed reports whether the file with the given path in a charm is
+// covered by its signature.
+func signed(name string) bool {
+	if name == "revision" || name == SignatureFile {
+		return false
+	}
+	top := strings.SplitN(name, "/", 2)[0]
+	return top != "build" && !strings.HasPrefix(top, ".")
+}
+
+// VerifyArchive checks that the charm archive at the given path is
+// signed by one of the keys in keyring, and returns the identity of
+// the signer.
+func VerifyArchive(archivePath string, keyring openpgp.KeyRing) (string, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return "", errors.Annotate(err, "cannot open charm archive")
+	}
+	defer r.Close()
+	return Verify(&r.Reader, keyring)
+}
+
+// Verify checks that the charm archive read by r is signed by one of
+// the keys in keyring, and returns the identity of the signer.
+func Verify(r *zip.Reader, keyring openpgp.KeyRing) (string, error) {
+	var signature []byte
+	for _, f := range r.File {
+		if path.Clean(f.Name) != SignatureFile {
+			continue
+		}
+		content, err := f.Open()
+		if err != nil {
+			return "", errors.Annotate(err, "cannot read charm signature")
+		}
+		signature, err = ioutil.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return "", errors.Annotate(err, "cannot read charm signature")
+		}
+	}
+	if signature == nil {
+		return "", errors.New("charm is not signed")
+	}
+	manifest, err := Manifest(r)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	signer, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(manifest), bytes.NewReader(signature))
+	if err != nil {
+		return "", errors.Annotate(err, "charm signature not verified")
+	}
+	return Identity(signer), nil
+}
+
+// Identity returns a name for the owner of the given key: its primary
+// user id if it has one, and its key id otherwise.
+func Identity(entity *openpgp.Entity) string {
+	var names []string
+	for name, id := range entity.Identities {
+		if id.SelfSignature != nil && id.SelfSignature.IsPrimaryId != nil && *id.SelfSignature.IsPrimaryId {
+			return name
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return entity.PrimaryKey.KeyIdString()
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// SignDir signs the charm in the given directory with the given key,
+// writing the signature into the directory.
+func SignDir(dir string, signer *openpgp.Entity) error {
+	ch, err := charm.ReadCharmDir(dir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var archive bytes.Buffer
+	if err := ch.ArchiveTo(&archive); err != nil {
+		return errors.Annotate(err, "cannot archive charm")
+	}
+	r, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	manifest, err := Manifest(r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	f, err := os.Create(filepath.Join(dir, SignatureFile))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+	if err := openpgp.ArmoredDetachSign(f, signer, bytes.NewReader(manifest), nil); err != nil {
+		return errors.Annotate(err, "cannot sign charm")
+	}
+	return f.Close()
+}
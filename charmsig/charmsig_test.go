@@ -0,0 +1,115 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charmsig_test
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/crypto/openpgp"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5"
+
+	"github.com/juju/juju/charmsig"
+	charmsigtesting "github.com/juju/juju/charmsig/testing"
+	"github.com/juju/juju/testcharms"
+	"github.com/juju/juju/testing"
+)
+
+type charmsigSuite struct {
+	testing.BaseSuite
+	key       *openpgp.Entity
+	publicKey string
+	keyring   openpgp.EntityList
+}
+
+var _ = gc.Suite(&charmsigSuite{})
+
+func (s *charmsigSuite) SetUpSuite(c *gc.C) {
+	s.BaseSuite.SetUpSuite(c)
+	s.key, s.publicKey = charmsigtesting.NewKey(c, "Charmer", "charmer@example.com")
+	keyring, err := charmsig.ReadKeyRing(s.publicKey)
+	c.Assert(err, jc.ErrorIsNil)
+	s.keyring = keyring
+}
+
+func (s *charmsigSuite) charmDir(c *gc.C) string {
+	return testcharms.Repo.ClonedDirPath(c.MkDir(), "dummy")
+}
+
+func (s *charmsigSuite) TestReadKeyRingInvalid(c *gc.C) {
+	_, err := charmsig.ReadKeyRing("not a key")
+	c.Assert(err, gc.ErrorMatches, "cannot parse public keys: .*")
+}
+
+func (s *charmsigSuite) TestVerify(c *gc.C) {
+	archivePath := charmsigtesting.SignedArchive(c, s.charmDir(c), s.key)
+	signer, err := charmsig.VerifyArchive(archivePath, s.keyring)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(signer, gc.Equals, "Charmer <charmer@example.com>")
+}
+
+func (s *charmsigSuite) TestVerifyUnsigned(c *gc.C) {
+	archivePath := charmsigtesting.Archive(c, s.charmDir(c))
+	_, err := charmsig.VerifyArchive(archivePath, s.keyring)
+	c.Assert(err, gc.ErrorMatches, "charm is not signed")
+}
+
+func (s *charmsigSuite) TestVerifyUntrustedKey(c *gc.C) {
+	other, _ := charmsigtesting.NewKey(c, "Mallory", "mallory@example.com")
+	archivePath := charmsigtesting.SignedArchive(c, s.charmDir(c), other)
+	_, err := charmsig.VerifyArchive(archivePath, s.keyring)
+	c.Assert(err, gc.ErrorMatches, "charm signature not verified: .*")
+}
+
+func (s *charmsigSuite) TestVerifyModified(c *gc.C) {
+	dir := s.charmDir(c)
+	err := charmsig.SignDir(dir, s.key)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ioutil.WriteFile(filepath.Join(dir, "hooks", "install"), []byte("#!/bin/sh\nrm -rf /\n"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = charmsig.VerifyArchive(charmsigtesting.Archive(c, dir), s.keyring)
+	c.Assert(err, gc.ErrorMatches, "charm signature not verified: .*")
+}
+
+func (s *charmsigSuite) TestVerifyAddedFile(c *gc.C) {
+	dir := s.charmDir(c)
+	err := charmsig.SignDir(dir, s.key)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ioutil.WriteFile(filepath.Join(dir, "extra"), []byte("extra"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = charmsig.VerifyArchive(charmsigtesting.Archive(c, dir), s.keyring)
+	c.Assert(err, gc.ErrorMatches, "charm signature not verified: .*")
+}
+
+func (s *charmsigSuite) TestSignatureSurvivesRevisionChange(c *gc.C) {
+	dir := s.charmDir(c)
+	err := charmsig.SignDir(dir, s.key)
+	c.Assert(err, jc.ErrorIsNil)
+	ch, err := charm.ReadCharmDir(dir)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ch.SetDiskRevision(ch.Revision() + 42)
+	c.Assert(err, jc.ErrorIsNil)
+	err = os.Mkdir(filepath.Join(dir, ".bzr"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ioutil.WriteFile(filepath.Join(dir, ".bzr", "branch"), []byte("branch"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = charmsig.VerifyArchive(charmsigtesting.Archive(c, dir), s.keyring)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *charmsigSuite) TestManifest(c *gc.C) {
+	archivePath := charmsigtesting.Archive(c, s.charmDir(c))
+	r, err := zip.OpenReader(archivePath)
+	c.Assert(err, jc.ErrorIsNil)
+	defer r.Close()
+	manifest, err := charmsig.Manifest(&r.Reader)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(manifest), gc.Matches, `(?s)([0-9a-f]{64}  \S+\n)+`)
+	c.Assert(string(manifest), gc.Matches, `(?s).*  metadata.yaml\n.*`)
+	c.Assert(string(manifest), gc.Not(gc.Matches), `(?s).*  revision\n.*`)
+}
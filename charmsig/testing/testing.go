@@ -0,0 +1,55 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package testing
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5"
+
+	"github.com/juju/juju/charmsig"
+)
+
+// NewKey returns a new OpenPGP key for the user with the given name
+// and email address, and its armored public key.
+func NewKey(c *gc.C, name, email string) (*openpgp.Entity, string) {
+	entity, err := openpgp.NewEntity(name, "", email, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	var public bytes.Buffer
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = entity.Serialize(w)
+	c.Assert(err, jc.ErrorIsNil)
+	err = w.Close()
+	c.Assert(err, jc.ErrorIsNil)
+	return entity, public.String()
+}
+
+// SignedArchive signs the charm in the given directory with the given
+// key, and returns the path of an archive of the signed charm.
+func SignedArchive(c *gc.C, dir string, signer *openpgp.Entity) string {
+	err := charmsig.SignDir(dir, signer)
+	c.Assert(err, jc.ErrorIsNil)
+	return Archive(c, dir)
+}
+
+// Archive returns the path of an archive of the charm in the given
+// directory.
+func Archive(c *gc.C, dir string) string {
+	ch, err := charm.ReadCharmDir(dir)
+	c.Assert(err, jc.ErrorIsNil)
+	archivePath := filepath.Join(c.MkDir(), "charm.zip")
+	f, err := os.Create(archivePath)
+	c.Assert(err, jc.ErrorIsNil)
+	defer f.Close()
+	err = ch.ArchiveTo(f)
+	c.Assert(err, jc.ErrorIsNil)
+	return archivePath
+}
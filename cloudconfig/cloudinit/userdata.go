@@ -0,0 +1,118 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudinit
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils/set"
+	"gopkg.in/yaml.v1"
+)
+
+// userDataDirectives holds the cloud-init directives that may be
+// supplied as user data. They only add to the configuration Juju
+// generates, so user data can never replace Juju's own settings.
+var userDataDirectives = set.NewStrings("packages", "bootcmd", "runcmd", "write_files")
+
+// UserData holds cloud-init directives supplied by the user, to be
+// merged into the configuration Juju generates for a machine.
+type UserData struct {
+	Packages   []string       `yaml:"packages,omitempty"`
+	BootCmds   []string       `yaml:"bootcmd,omitempty"`
+	RunCmds    []string       `yaml:"runcmd,omitempty"`
+	WriteFiles []UserDataFile `yaml:"write_files,omitempty"`
+}
+
+// UserDataFile describes a file that user data writes on first boot.
+type UserDataFile struct {
+	Path        string `yaml:"path"`
+	Content     string `yaml:"content"`
+	Permissions string `yaml:"permissions,omitempty"`
+}
+
+// mode returns the file's permissions, which default to 0644.
+func (f UserDataFile) mode() (uint, error) {
+	if f.Permissions == "" {
+		return 0644, nil
+	}
+	mode, err := strconv.ParseUint(f.Permissions, 8, 32)
+	if err != nil {
+		return 0, errors.NotValidf("permissions %q for %q", f.Permissions, f.Path)
+	}
+	return uint(mode), nil
+}
+
+// ParseUserData parses a cloud-config YAML document holding user
+// data. Only the packages, bootcmd, runcmd and write_files
+// directives are supported, and files must have absolute paths.
+func ParseUserData(data string) (*UserData, error) {
+	var directives map[string]interface{}
+	if err := yaml.Unmarshal([]byte(data), &directives); err != nil {
+		return nil, errors.Annotate(err, "cannot parse cloud-init user data")
+	}
+	var names []string
+	for name := range directives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !userDataDirectives.Contains(name) {
+			return nil, errors.NotSupportedf("cloud-init directive %q in user data", name)
+		}
+	}
+
+	var userData UserData
+	if err := yaml.Unmarshal([]byte(data), &userData); err != nil {
+		return nil, errors.Annotate(err, "cannot parse cloud-init user data")
+	}
+	for _, file := range userData.WriteFiles {
+		if !path.IsAbs(file.Path) {
+			return nil, errors.NotValidf("relative path %q in user data", file.Path)
+		}
+		if _, err := file.mode(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return &userData, nil
+}
+
+// AddUserData adds the directives held in userData to cfg, after
+// those already present. Files may not be written to any of the
+// reserved paths, or below them, as they hold Juju's own files.
+func AddUserData(cfg CloudConfig, userData *UserData, reserved ...string) error {
+	for _, file := range userData.WriteFiles {
+		filePath := path.Clean(file.Path)
+		for _, reservedPath := range reserved {
+			if filePath == reservedPath || strings.HasPrefix(filePath, reservedPath+"/") {
+				return errors.Errorf("cannot write %q from user data: %q is reserved for juju", file.Path, reservedPath)
+			}
+		}
+	}
+
+	packages := set.NewStrings(cfg.Packages()...)
+	for _, pack := range userData.Packages {
+		if !packages.Contains(pack) {
+			cfg.AddPackage(pack)
+			packages.Add(pack)
+		}
+	}
+	for _, cmd := range userData.BootCmds {
+		cfg.AddBootCmd(cmd)
+	}
+	for _, file := range userData.WriteFiles {
+		mode, err := file.mode()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		cfg.AddRunTextFile(path.Clean(file.Path), file.Content, mode)
+	}
+	for _, cmd := range userData.RunCmds {
+		cfg.AddRunCmd(cmd)
+	}
+	return nil
+}
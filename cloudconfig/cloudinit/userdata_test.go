@@ -0,0 +1,106 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudinit_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/cloudconfig/cloudinit"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type userDataSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&userDataSuite{})
+
+const testUserData = `
+#cloud-config
+packages: [nagios-nrpe-server]
+bootcmd:
+- echo booting
+runcmd:
+- service nagios-nrpe-server restart
+write_files:
+- path: /etc/nagios/nrpe.d/juju.cfg
+  content: allowed_hosts=10.0.0.1
+  permissions: "0600"
+`
+
+func (*userDataSuite) TestParseUserData(c *gc.C) {
+	userData, err := cloudinit.ParseUserData(testUserData)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(userData, jc.DeepEquals, &cloudinit.UserData{
+		Packages: []string{"nagios-nrpe-server"},
+		BootCmds: []string{"echo booting"},
+		RunCmds:  []string{"service nagios-nrpe-server restart"},
+		WriteFiles: []cloudinit.UserDataFile{{
+			Path:        "/etc/nagios/nrpe.d/juju.cfg",
+			Content:     "allowed_hosts=10.0.0.1",
+			Permissions: "0600",
+		}},
+	})
+}
+
+func (*userDataSuite) TestParseUserDataErrors(c *gc.C) {
+	for i, test := range []struct {
+		data string
+		err  string
+	}{{
+		data: "packages: [a\n",
+		err:  "cannot parse cloud-init user data: .*",
+	}, {
+		data: "output: {all: '| tee -a /tmp/log'}\nusers: []\n",
+		err:  `cloud-init directive "output" in user data not supported`,
+	}, {
+		data: "write_files:\n- path: etc/motd\n  content: hi\n",
+		err:  `relative path "etc/motd" in user data not valid`,
+	}, {
+		data: "write_files:\n- path: /etc/motd\n  permissions: rw\n",
+		err:  `permissions "rw" for "/etc/motd" not valid`,
+	}} {
+		c.Logf("test %d: %q", i, test.data)
+		_, err := cloudinit.ParseUserData(test.data)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (*userDataSuite) TestAddUserData(c *gc.C) {
+	cfg, err := cloudinit.New("trusty")
+	c.Assert(err, jc.ErrorIsNil)
+	cfg.AddPackage("nagios-nrpe-server")
+	cfg.AddRunCmd("juju-owned")
+	cfg.AddBootCmd("juju-boot")
+
+	userData, err := cloudinit.ParseUserData(testUserData)
+	c.Assert(err, jc.ErrorIsNil)
+	err = cloudinit.AddUserData(cfg, userData, "/var/lib/juju")
+	c.Assert(err, jc.ErrorIsNil)
+
+	c.Assert(cfg.Packages(), jc.DeepEquals, []string{"nagios-nrpe-server"})
+	c.Assert(cfg.BootCmds(), jc.DeepEquals, []string{"juju-boot", "echo booting"})
+	c.Assert(cfg.RunCmds(), jc.DeepEquals, []string{
+		"juju-owned",
+		"install -D -m 600 /dev/null '/etc/nagios/nrpe.d/juju.cfg'",
+		`printf '%s\n' 'allowed_hosts=10.0.0.1' > '/etc/nagios/nrpe.d/juju.cfg'`,
+		"service nagios-nrpe-server restart",
+	})
+}
+
+func (*userDataSuite) TestAddUserDataReservedPath(c *gc.C) {
+	cfg, err := cloudinit.New("trusty")
+	c.Assert(err, jc.ErrorIsNil)
+	userData, err := cloudinit.ParseUserData(`
+runcmd: [echo hi]
+write_files:
+- path: /var/lib/juju/../juju/nonce.txt
+  content: bogus
+`)
+	c.Assert(err, jc.ErrorIsNil)
+	err = cloudinit.AddUserData(cfg, userData, "/var/log/juju", "/var/lib/juju")
+	c.Assert(err, gc.ErrorMatches, `cannot write "/var/lib/juju/../juju/nonce.txt" from user data: "/var/lib/juju" is reserved for juju`)
+	c.Assert(cfg.RunCmds(), gc.HasLen, 0)
+}
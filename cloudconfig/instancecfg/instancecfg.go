@@ -175,6 +175,16 @@ type InstanceConfig struct {
 	// instances. If enabled, the OS will perform any upgrades
 	// available as part of its provisioning.
 	EnableOSUpgrade bool
+
+	// CloudInitUserData mirrors the cloudinit-userdata environment
+	// setting, a cloud-config document whose directives are added
+	// to the generated cloud-init configuration.
+	CloudInitUserData string
+
+	// MachineCloudInitUserData holds the cloud-init user data given
+	// when the machine was added. It is applied after the
+	// environment's CloudInitUserData.
+	MachineCloudInitUserData string
 }
 
 func (cfg *InstanceConfig) agentInfo() service.AgentInfo {
@@ -463,6 +473,7 @@ func PopulateInstanceConfig(icfg *InstanceConfig,
 	preferIPv6 bool,
 	enableOSRefreshUpdates bool,
 	enableOSUpgrade bool,
+	cloudInitUserData string,
 ) error {
 	if authorizedKeys == "" {
 		return fmt.Errorf("environment configuration has no authorized-keys")
@@ -480,6 +491,7 @@ func PopulateInstanceConfig(icfg *InstanceConfig,
 	icfg.PreferIPv6 = preferIPv6
 	icfg.EnableOSRefreshUpdate = enableOSRefreshUpdates
 	icfg.EnableOSUpgrade = enableOSUpgrade
+	icfg.CloudInitUserData = cloudInitUserData
 	return nil
 }
 
@@ -507,6 +519,7 @@ func FinishInstanceConfig(icfg *InstanceConfig, cfg *config.Config) (err error)
 		cfg.PreferIPv6(),
 		cfg.EnableOSRefreshUpdate(),
 		cfg.EnableOSUpgrade(),
+		cfg.CloudInitUserData(),
 	); err != nil {
 		return errors.Trace(err)
 	}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 
 	"github.com/juju/errors"
+	"github.com/juju/loggo"
 	"github.com/juju/names"
 	"github.com/juju/utils"
 
@@ -17,6 +18,8 @@ import (
 	"github.com/juju/juju/version"
 )
 
+var logger = loggo.GetLogger("juju.cloudconfig")
+
 const (
 	// fileSchemePrefix is the prefix for file:// URLs.
 	fileSchemePrefix = "file://"
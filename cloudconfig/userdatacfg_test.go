@@ -1190,6 +1190,42 @@ func (s *cloudinitSuite) testAptMirror(c *gc.C, cfg *config.Config, expect strin
 	//c.Assert(ok, gc.Equals, expect != "")
 }
 
+func (s *cloudinitSuite) TestCloudInitUserData(c *gc.C) {
+	environConfig := minimalConfig(c)
+	environConfig, err := environConfig.Apply(map[string]interface{}{
+		"cloudinit-userdata": "packages: [nagios-nrpe-server]\nruncmd: [echo environ]\n",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	instanceCfg := s.createInstanceConfig(c, environConfig)
+	instanceCfg.MachineCloudInitUserData = "bootcmd: [echo boot]\nruncmd: [echo machine]\n"
+	cloudcfg, err := cloudinit.New("quantal")
+	c.Assert(err, jc.ErrorIsNil)
+	udata, err := cloudconfig.NewUserdataConfig(instanceCfg, cloudcfg)
+	c.Assert(err, jc.ErrorIsNil)
+	err = udata.Configure()
+	c.Assert(err, jc.ErrorIsNil)
+
+	c.Assert(set.NewStrings(cloudcfg.Packages()...).Contains("nagios-nrpe-server"), jc.IsTrue)
+	c.Assert(cloudcfg.BootCmds(), jc.DeepEquals, []string{"echo boot"})
+	// User commands run after the machine agent is started; only
+	// the removal of the tools tarball follows them.
+	cmds := cloudcfg.RunCmds()
+	c.Assert(len(cmds) > 3, jc.IsTrue)
+	c.Assert(cmds[len(cmds)-3:len(cmds)-1], jc.DeepEquals, []string{"echo environ", "echo machine"})
+	c.Assert(strings.HasPrefix(cmds[len(cmds)-1], "rm $bin/tools.tar.gz"), jc.IsTrue)
+}
+
+func (s *cloudinitSuite) TestCloudInitUserDataReservedPath(c *gc.C) {
+	instanceCfg := s.createInstanceConfig(c, minimalConfig(c))
+	instanceCfg.MachineCloudInitUserData = "write_files:\n- path: /var/lib/juju/agents/machine-42/agent.conf\n  content: bogus\n"
+	cloudcfg, err := cloudinit.New("quantal")
+	c.Assert(err, jc.ErrorIsNil)
+	udata, err := cloudconfig.NewUserdataConfig(instanceCfg, cloudcfg)
+	c.Assert(err, jc.ErrorIsNil)
+	err = udata.Configure()
+	c.Assert(err, gc.ErrorMatches, `cannot write "/var/lib/juju/agents/machine-42/agent.conf" from user data: "/var/lib/juju" is reserved for juju`)
+}
+
 var serverCert = []byte(`
 SERVER CERT
 -----BEGIN CERTIFICATE-----
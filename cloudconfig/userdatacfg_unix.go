@@ -336,7 +336,35 @@ func (w *unixConfigure) ConfigureJuju() error {
 		)
 	}
 
-	return w.addMachineAgentToBoot()
+	if err := w.addMachineAgentToBoot(); err != nil {
+		return errors.Trace(err)
+	}
+	return w.addUserData()
+}
+
+// addUserData adds the cloud-init user data of the environment and
+// then of the machine itself, after all of Juju's own directives.
+// User data may not write files where Juju keeps its own.
+func (w *unixConfigure) addUserData() error {
+	reserved := []string{
+		w.icfg.DataDir,
+		w.icfg.LogDir,
+		upstart.CleanShutdownJobPath,
+		systemd.CleanShutdownServicePath,
+	}
+	for _, data := range []string{w.icfg.CloudInitUserData, w.icfg.MachineCloudInitUserData} {
+		if data == "" {
+			continue
+		}
+		userData, err := cloudinit.ParseUserData(data)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := cloudinit.AddUserData(w.conf, userData, reserved...); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
 }
 
 // toolsDownloadCommand takes a curl command minus the source URL,
@@ -112,6 +112,10 @@ func (w *windowsConfigure) ConfigureJuju() error {
 		return errors.Errorf("bootstrapping is not supported on windows")
 	}
 
+	if w.icfg.CloudInitUserData != "" || w.icfg.MachineCloudInitUserData != "" {
+		logger.Warningf("ignoring cloud-init user data for windows machine %s", w.icfg.MachineId)
+	}
+
 	machineTag := names.NewMachineTag(w.icfg.MachineId)
 	_, err = w.addAgentInfo(machineTag)
 	if err != nil {
@@ -35,6 +35,7 @@ func NewSuperCommand() cmd.Command {
 	actionCmd.Register(envcmd.Wrap(&DefinedCommand{}))
 	actionCmd.Register(envcmd.Wrap(&DoCommand{}))
 	actionCmd.Register(envcmd.Wrap(&FetchCommand{}))
+	actionCmd.Register(newScheduleCommand())
 	actionCmd.Register(envcmd.Wrap(&StatusCommand{}))
 	return actionCmd
 }
@@ -78,6 +79,28 @@ type APIClient interface {
 	// FindActionTagsByPrefix takes a list of string prefixes and finds
 	// corresponding ActionTags that match that prefix.
 	FindActionTagsByPrefix(params.FindTags) (params.FindTagsResults, error)
+
+	// AddSchedules adds schedules on which actions are queued, returning
+	// each schedule as stored or an error.
+	AddSchedules(params.ActionSchedules) (params.ActionScheduleResults, error)
+
+	// ListSchedules returns all the action schedules in the environment.
+	ListSchedules() (params.ActionSchedules, error)
+
+	// RemoveSchedules removes the action schedules with the given ids.
+	RemoveSchedules(params.ActionScheduleIds) (params.ErrorResults, error)
+
+	// ScheduleRuns returns the record of the runs of each of the action
+	// schedules with the given ids.
+	ScheduleRuns(params.ActionScheduleIds) (params.ActionScheduleRunsResults, error)
+
+	// EnqueueBatches runs actions across the units of services,
+	// returning each batch as enqueued or an error.
+	EnqueueBatches(params.ActionBatches) (params.ActionBatchResults, error)
+
+	// Batches returns the action batches with the given ids, with the
+	// progress of each on its units.
+	Batches(params.ActionBatchIds) (params.ActionBatchResults, error)
 }
 
 // ActionCommandBase is the base type for action sub-commands.
@@ -48,6 +48,7 @@ func (s *ActionCommandSuite) checkHelpSubCommands(c *gc.C, ctx *cmd.Context) {
 		{"do", "queue an action for execution"},
 		{"fetch", "show results of an action by ID"},
 		{"help", "show help on a command or other topic"},
+		{"schedule", "manage schedules on which actions are queued"},
 		{"status", "show results of all actions filtered by optional ID prefix"},
 	}
 
@@ -20,21 +20,31 @@ import (
 var keyRule = regexp.MustCompile("^[a-z0-9](?:[a-z0-9-]*[a-z0-9])?$")
 
 // DoCommand enqueues an Action for running on the given unit with given
-// params
+// params, or on the units of the given service as a batch.
 type DoCommand struct {
 	ActionCommandBase
-	unitTag      names.UnitTag
-	actionName   string
-	paramsYAML   cmd.FileVar
-	parseStrings bool
-	out          cmd.Output
-	args         [][]string
+	unitTag       names.UnitTag
+	serviceTag    names.ServiceTag
+	actionName    string
+	paramsYAML    cmd.FileVar
+	parseStrings  bool
+	maxConcurrent int
+	leaderOnly    bool
+	out           cmd.Output
+	args          [][]string
 }
 
 const doDoc = `
 Queue an Action for execution on a given unit, with a given set of params.
 Displays the ID of the Action for use with 'juju kill', 'juju status', etc.
 
+If a service is given instead of a unit, the Action is run on each of the
+service's units as a single batch, and the ID of the batch is displayed for
+use with 'juju action status --batch'. The --max-concurrent flag limits how
+many of the units run the Action at once; once the Action fails or is
+cancelled on any unit, it is not queued on any more of them. With
+--leader-only, the Action is run on the service's leader alone.
+
 Params are validated according to the charm for the unit's service.  The 
 valid params can be seen using "juju action defined <service> --schema".
 Params may be in a yaml file which is passed with the --params flag, or they
@@ -97,6 +107,11 @@ $ juju action do sleeper/0 pause time=1000
 $ juju action do sleeper/0 pause --string-args time=1000
 ...
 The value for the "time" param will be the string literal "1000".
+
+$ juju action do mysql backup --max-concurrent 2
+Action batch queued with id: "1"
+
+$ juju action status --batch 1
 `
 
 // actionNameRule describes the format an action name must match to be valid.
@@ -107,12 +122,14 @@ func (c *DoCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
 	f.Var(&c.paramsYAML, "params", "path to yaml-formatted params file")
 	f.BoolVar(&c.parseStrings, "string-args", false, "use raw string values of CLI args")
+	f.IntVar(&c.maxConcurrent, "max-concurrent", 0, "maximum number of a service's units to run the action on at once")
+	f.BoolVar(&c.leaderOnly, "leader-only", false, "run the action on the service's leader only")
 }
 
 func (c *DoCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "do",
-		Args:    "<unit> <action name> [key.key.key...=value]",
+		Args:    "<unit or service> <action name> [key.key.key...=value]",
 		Purpose: "queue an action for execution",
 		Doc:     doDoc,
 	}
@@ -122,43 +139,61 @@ func (c *DoCommand) Info() *cmd.Info {
 func (c *DoCommand) Init(args []string) error {
 	switch len(args) {
 	case 0:
-		return errors.New("no unit specified")
+		return errors.New("no unit or service specified")
 	case 1:
 		return errors.New("no action specified")
 	default:
-		// Grab and verify the unit and action names.
-		unitName := args[0]
-		if !names.IsValidUnit(unitName) {
-			return errors.Errorf("invalid unit name %q", unitName)
+		// Grab and verify the unit or service and action names.
+		target := args[0]
+		switch {
+		case names.IsValidUnit(target):
+			if c.maxConcurrent != 0 || c.leaderOnly {
+				return errors.New("--max-concurrent and --leader-only can only be used with a service")
+			}
+			c.unitTag = names.NewUnitTag(target)
+		case names.IsValidService(target):
+			if c.maxConcurrent < 0 {
+				return errors.Errorf("invalid --max-concurrent %d", c.maxConcurrent)
+			}
+			c.serviceTag = names.NewServiceTag(target)
+		default:
+			return errors.Errorf("invalid unit or service name %q", target)
 		}
 		actionName := args[1]
 		if valid := actionNameRule.MatchString(actionName); !valid {
 			return fmt.Errorf("invalid action name %q", actionName)
 		}
-		c.unitTag = names.NewUnitTag(unitName)
 		c.actionName = actionName
 		if len(args) == 2 {
 			return nil
 		}
 		// Parse CLI key-value args if they exist.
-		c.args = make([][]string, 0)
-		for _, arg := range args[2:] {
-			thisArg := strings.SplitN(arg, "=", 2)
-			if len(thisArg) != 2 {
-				return fmt.Errorf("argument %q must be of the form key...=value", arg)
-			}
-			keySlice := strings.Split(thisArg[0], ".")
-			// check each key for validity
-			for _, key := range keySlice {
-				if valid := keyRule.MatchString(key); !valid {
-					return fmt.Errorf("key %q must start and end with lowercase alphanumeric, and contain only lowercase alphanumeric and hyphens", key)
-				}
+		var err error
+		c.args, err = parseKeyValueArgs(args[2:])
+		return err
+	}
+}
+
+// parseKeyValueArgs parses arguments of the form key.key.key...=value,
+// returning each as a slice of its keys followed by its value.
+func parseKeyValueArgs(args []string) ([][]string, error) {
+	result := make([][]string, 0)
+	for _, arg := range args {
+		thisArg := strings.SplitN(arg, "=", 2)
+		if len(thisArg) != 2 {
+			return nil, fmt.Errorf("argument %q must be of the form key...=value", arg)
+		}
+		keySlice := strings.Split(thisArg[0], ".")
+		// check each key for validity
+		for _, key := range keySlice {
+			if valid := keyRule.MatchString(key); !valid {
+				return nil, fmt.Errorf("key %q must start and end with lowercase alphanumeric, and contain only lowercase alphanumeric and hyphens", key)
 			}
-			// c.args={..., [key, key, key, key, value]}
-			c.args = append(c.args, append(keySlice, thisArg[1]))
 		}
-		return nil
+		// result={..., [key, key, key, key, value]}
+		result = append(result, append(keySlice, thisArg[1]))
 	}
+	return result, nil
 }
 
 func (c *DoCommand) Run(ctx *cmd.Context) error {
@@ -168,27 +203,104 @@ func (c *DoCommand) Run(ctx *cmd.Context) error {
 	}
 	defer api.Close()
 
+	actionParams, err := buildActionParams(ctx, c.paramsYAML, c.args, c.parseStrings)
+	if err != nil {
+		return err
+	}
+
+	if c.serviceTag.Id() != "" {
+		return c.enqueueBatch(ctx, api, actionParams)
+	}
+
+	actionParam := params.Actions{
+		Actions: []params.Action{{
+			Receiver:   c.unitTag.String(),
+			Name:       c.actionName,
+			Parameters: actionParams,
+		}},
+	}
+
+	results, err := api.Enqueue(actionParam)
+	if err != nil {
+		return err
+	}
+	if len(results.Results) != 1 {
+		return errors.New("illegal number of results returned")
+	}
+
+	result := results.Results[0]
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.Action == nil {
+		return errors.New("action failed to enqueue")
+	}
+
+	tag, err := names.ParseActionTag(result.Action.Tag)
+	if err != nil {
+		return err
+	}
+
+	output := map[string]string{"Action queued with id": tag.Id()}
+	return c.out.Write(ctx, output)
+}
+
+// enqueueBatch runs the action across the units of the service as a
+// batch, and writes out the id of the batch.
+func (c *DoCommand) enqueueBatch(ctx *cmd.Context, api APIClient, actionParams map[string]interface{}) error {
+	results, err := api.EnqueueBatches(params.ActionBatches{
+		Batches: []params.ActionBatch{{
+			Service:       c.serviceTag.String(),
+			Name:          c.actionName,
+			Parameters:    actionParams,
+			MaxConcurrent: c.maxConcurrent,
+			LeaderOnly:    c.leaderOnly,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	if len(results.Results) != 1 {
+		return errors.New("illegal number of results returned")
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.Batch == nil {
+		return errors.New("action batch failed to enqueue")
+	}
+	output := map[string]string{"Action batch queued with id": result.Batch.Id}
+	return c.out.Write(ctx, output)
+}
+
+// buildActionParams returns the parameters for an action, read from the
+// yaml params file if one was given and overridden by the explicit
+// key...=value args, which are parsed as yaml unless parseStrings is set.
+func buildActionParams(ctx *cmd.Context, paramsYAML cmd.FileVar, args [][]string, parseStrings bool) (map[string]interface{}, error) {
 	actionParams := map[string]interface{}{}
 
-	if c.paramsYAML.Path != "" {
-		b, err := c.paramsYAML.Read(ctx)
+	if paramsYAML.Path != "" {
+		b, err := paramsYAML.Read(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		err = yaml.Unmarshal(b, &actionParams)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		conformantParams, err := conform(actionParams)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		betterParams, ok := conformantParams.(map[string]interface{})
 		if !ok {
-			return errors.New("params must contain a YAML map with string keys")
+			return nil, errors.New("params must contain a YAML map with string keys")
 		}
 
 		actionParams = betterParams
@@ -196,15 +308,15 @@ func (c *DoCommand) Run(ctx *cmd.Context) error {
 
 	// If we had explicit args {..., [key, key, key, key, value], ...}
 	// then iterate and set params ..., key.key.key.key=value, ...
-	for _, argSlice := range c.args {
+	for _, argSlice := range args {
 		valueIndex := len(argSlice) - 1
 		keys := argSlice[:valueIndex]
 		value := argSlice[valueIndex]
 		cleansedValue := interface{}(value)
-		if !c.parseStrings {
+		if !parseStrings {
 			err := yaml.Unmarshal([]byte(value), &cleansedValue)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 		// Insert the value in the map.
@@ -213,45 +325,12 @@ func (c *DoCommand) Run(ctx *cmd.Context) error {
 
 	conformantParams, err := conform(actionParams)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	typedConformantParams, ok := conformantParams.(map[string]interface{})
 	if !ok {
-		return errors.Errorf("params must be a map, got %T", typedConformantParams)
+		return nil, errors.Errorf("params must be a map, got %T", typedConformantParams)
 	}
-
-	actionParam := params.Actions{
-		Actions: []params.Action{{
-			Receiver:   c.unitTag.String(),
-			Name:       c.actionName,
-			Parameters: actionParams,
-		}},
-	}
-
-	results, err := api.Enqueue(actionParam)
-	if err != nil {
-		return err
-	}
-	if len(results.Results) != 1 {
-		return errors.New("illegal number of results returned")
-	}
-
-	result := results.Results[0]
-
-	if result.Error != nil {
-		return result.Error
-	}
-
-	if result.Action == nil {
-		return errors.New("action failed to enqueue")
-	}
-
-	tag, err := names.ParseActionTag(result.Action.Tag)
-	if err != nil {
-		return err
-	}
-
-	output := map[string]string{"Action queued with id": tag.Id()}
-	return c.out.Write(ctx, output)
+	return typedConformantParams, nil
 }
@@ -74,7 +74,7 @@ func (s *DoSuite) TestInit(c *gc.C) {
 	}{{
 		should:      "fail with missing args",
 		args:        []string{},
-		expectError: "no unit specified",
+		expectError: "no unit or service specified",
 	}, {
 		should:      "fail with no action specified",
 		args:        []string{validUnitId},
@@ -82,7 +82,7 @@ func (s *DoSuite) TestInit(c *gc.C) {
 	}, {
 		should:      "fail with invalid unit tag",
 		args:        []string{invalidUnitId, "valid-action-name"},
-		expectError: "invalid unit name \"something-strange-\"",
+		expectError: "invalid unit or service name \"something-strange-\"",
 	}, {
 		should:      "fail with invalid action name",
 		args:        []string{validUnitId, "BadName"},
@@ -398,3 +398,67 @@ func (s *DoSuite) TestRun(c *gc.C) {
 		}()
 	}
 }
+
+func (s *DoSuite) TestInitService(c *gc.C) {
+	s.subcommand = &action.DoCommand{}
+	err := testing.InitCommand(s.subcommand, []string{validServiceId, "backup", "--max-concurrent", "2", "--leader-only"})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(s.subcommand.ServiceTag(), gc.Equals, names.NewServiceTag(validServiceId))
+	c.Check(s.subcommand.UnitTag(), gc.Equals, names.UnitTag{})
+	c.Check(s.subcommand.ActionName(), gc.Equals, "backup")
+	c.Check(s.subcommand.MaxConcurrent(), gc.Equals, 2)
+	c.Check(s.subcommand.LeaderOnly(), jc.IsTrue)
+
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		args: []string{validUnitId, "backup", "--max-concurrent", "2"},
+		err:  "--max-concurrent and --leader-only can only be used with a service",
+	}, {
+		args: []string{validUnitId, "backup", "--leader-only"},
+		err:  "--max-concurrent and --leader-only can only be used with a service",
+	}, {
+		args: []string{validServiceId, "backup", "--max-concurrent", "-1"},
+		err:  "invalid --max-concurrent -1",
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		err := testing.InitCommand(&action.DoCommand{}, test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *DoSuite) TestRunService(c *gc.C) {
+	fakeClient := &fakeAPIClient{
+		batchResults: []params.ActionBatchResult{{
+			Batch: &params.ActionBatch{Id: "3"},
+		}},
+	}
+	restore := s.patchAPIClient(fakeClient)
+	defer restore()
+
+	ctx, err := testing.RunCommand(c, &action.DoCommand{}, validServiceId, "backup", "--max-concurrent", "2", "out=dump.bz2")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "Action batch queued with id: \"3\"\n")
+	c.Assert(fakeClient.enqueuedBatches, jc.DeepEquals, params.ActionBatches{
+		Batches: []params.ActionBatch{{
+			Service:       names.NewServiceTag(validServiceId).String(),
+			Name:          "backup",
+			Parameters:    map[string]interface{}{"out": "dump.bz2"},
+			MaxConcurrent: 2,
+		}},
+	})
+}
+
+func (s *DoSuite) TestRunServiceError(c *gc.C) {
+	fakeClient := &fakeAPIClient{
+		batchResults: []params.ActionBatchResult{{
+			Error: &params.Error{Message: `leader of service "mysql" not found`},
+		}},
+	}
+	restore := s.patchAPIClient(fakeClient)
+	defer restore()
+
+	_, err := testing.RunCommand(c, &action.DoCommand{}, validServiceId, "backup", "--leader-only")
+	c.Assert(err, gc.ErrorMatches, `leader of service "mysql" not found`)
+}
@@ -25,6 +25,18 @@ func (c *DoCommand) UnitTag() names.UnitTag {
 	return c.unitTag
 }
 
+func (c *DoCommand) ServiceTag() names.ServiceTag {
+	return c.serviceTag
+}
+
+func (c *DoCommand) MaxConcurrent() int {
+	return c.maxConcurrent
+}
+
+func (c *DoCommand) LeaderOnly() bool {
+	return c.leaderOnly
+}
+
 func (c *DoCommand) ActionName() string {
 	return c.actionName
 }
@@ -136,6 +136,14 @@ type fakeAPIClient struct {
 	actionsByReceivers []params.ActionsByReceiver
 	actionTagMatches   params.FindTagsResults
 	charmActions       *charm.Actions
+	addedSchedules     params.ActionSchedules
+	scheduleResults    []params.ActionScheduleResult
+	schedules          []params.ActionSchedule
+	removedSchedules   params.ActionScheduleIds
+	scheduleRuns       []params.ActionScheduleRunsResult
+	errorResults       []params.ErrorResult
+	enqueuedBatches    params.ActionBatches
+	batchResults       []params.ActionBatchResult
 	apiErr             error
 }
 
@@ -214,3 +222,30 @@ func (c *fakeAPIClient) Actions(args params.Entities) (params.ActionResults, err
 func (c *fakeAPIClient) FindActionTagsByPrefix(arg params.FindTags) (params.FindTagsResults, error) {
 	return c.actionTagMatches, c.apiErr
 }
+
+func (c *fakeAPIClient) AddSchedules(args params.ActionSchedules) (params.ActionScheduleResults, error) {
+	c.addedSchedules = args
+	return params.ActionScheduleResults{Results: c.scheduleResults}, c.apiErr
+}
+
+func (c *fakeAPIClient) ListSchedules() (params.ActionSchedules, error) {
+	return params.ActionSchedules{Schedules: c.schedules}, c.apiErr
+}
+
+func (c *fakeAPIClient) RemoveSchedules(args params.ActionScheduleIds) (params.ErrorResults, error) {
+	c.removedSchedules = args
+	return params.ErrorResults{Results: c.errorResults}, c.apiErr
+}
+
+func (c *fakeAPIClient) ScheduleRuns(args params.ActionScheduleIds) (params.ActionScheduleRunsResults, error) {
+	return params.ActionScheduleRunsResults{Results: c.scheduleRuns}, c.apiErr
+}
+
+func (c *fakeAPIClient) EnqueueBatches(args params.ActionBatches) (params.ActionBatchResults, error) {
+	c.enqueuedBatches = args
+	return params.ActionBatchResults{Results: c.batchResults}, c.apiErr
+}
+
+func (c *fakeAPIClient) Batches(args params.ActionBatchIds) (params.ActionBatchResults, error) {
+	return params.ActionBatchResults{Results: c.batchResults}, c.apiErr
+}
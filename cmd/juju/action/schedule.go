@@ -0,0 +1,404 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package action
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/utils/cron"
+)
+
+const scheduleDoc = `
+"juju action schedule" manages schedules on which actions are queued up
+automatically, either at times given by a cron expression or at a fixed
+interval. Each time a schedule runs, the action is queued on every unit it
+targets; the actions queued by each run are recorded, and can be shown with
+"juju action schedule runs".
+`
+
+// newScheduleCommand returns a super-command for managing action
+// schedules.
+func newScheduleCommand() cmd.Command {
+	scheduleCmd := cmd.NewSuperCommand(
+		cmd.SuperCommandParams{
+			Name:        "schedule",
+			Doc:         scheduleDoc,
+			UsagePrefix: "juju action",
+			Purpose:     "manage schedules on which actions are queued",
+		})
+	scheduleCmd.Register(envcmd.Wrap(&ScheduleAddCommand{}))
+	scheduleCmd.Register(envcmd.Wrap(&ScheduleListCommand{}))
+	scheduleCmd.Register(envcmd.Wrap(&ScheduleRemoveCommand{}))
+	scheduleCmd.Register(envcmd.Wrap(&ScheduleRunsCommand{}))
+	return scheduleCmd
+}
+
+// ScheduleAddCommand adds a schedule on which an action is queued.
+type ScheduleAddCommand struct {
+	ActionCommandBase
+	out          cmd.Output
+	receivers    []names.Tag
+	actionName   string
+	cron         string
+	every        time.Duration
+	paramsYAML   cmd.FileVar
+	parseStrings bool
+	args         [][]string
+}
+
+const scheduleAddDoc = `
+Add a schedule on which an action is queued on the given units, or on all
+units of the given services. Several targets may be separated by commas.
+Services are expanded to their units each time the schedule runs, so units
+added later are included.
+
+Exactly one of --cron and --every must be given. Cron expressions have the
+five fields "minute hour day-of-month month day-of-week", are interpreted
+in UTC, and may be one of @hourly, @daily, @weekly, @monthly or @yearly.
+Schedules run at most once a minute.
+
+Params are given as for "juju action do", and are validated against the
+charm when the schedule is added.
+
+Examples:
+
+$ juju action schedule add postgresql vacuum --cron "0 3 * * *"
+Schedule added with id: "1"
+Next run: 2015-07-02 03:00:00 +0000 UTC
+
+$ juju action schedule add haproxy/0,haproxy/1 rotate-certs --every 168h
+
+$ juju action schedule add mysql/0 backup --cron @daily out=nightly.tar.bz2
+`
+
+// SetFlags implements Command.SetFlags.
+func (c *ScheduleAddCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+	f.StringVar(&c.cron, "cron", "", "cron expression on which to queue the action")
+	f.DurationVar(&c.every, "every", 0, "interval at which to queue the action")
+	f.Var(&c.paramsYAML, "params", "path to yaml-formatted params file")
+	f.BoolVar(&c.parseStrings, "string-args", false, "use raw string values of CLI args")
+}
+
+// Info implements Command.Info.
+func (c *ScheduleAddCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "add",
+		Args:    "<unit or service>[,...] <action name> [key.key.key...=value]",
+		Purpose: "add a schedule on which an action is queued",
+		Doc:     scheduleAddDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *ScheduleAddCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return errors.New("no unit or service specified")
+	case 1:
+		return errors.New("no action specified")
+	}
+	for _, target := range strings.Split(args[0], ",") {
+		switch {
+		case names.IsValidUnit(target):
+			c.receivers = append(c.receivers, names.NewUnitTag(target))
+		case names.IsValidService(target):
+			c.receivers = append(c.receivers, names.NewServiceTag(target))
+		default:
+			return errors.Errorf("invalid unit or service name %q", target)
+		}
+	}
+	c.actionName = args[1]
+	if valid := actionNameRule.MatchString(c.actionName); !valid {
+		return errors.Errorf("invalid action name %q", c.actionName)
+	}
+	if (c.cron == "") == (c.every == 0) {
+		return errors.New("exactly one of --cron and --every must be specified")
+	}
+	if c.cron != "" {
+		if _, err := cron.Parse(c.cron); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if c.every < 0 {
+		return errors.Errorf("invalid interval %v", c.every)
+	}
+	var err error
+	c.args, err = parseKeyValueArgs(args[2:])
+	return err
+}
+
+// Run implements Command.Run.
+func (c *ScheduleAddCommand) Run(ctx *cmd.Context) error {
+	api, err := c.NewActionAPIClient()
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	actionParams, err := buildActionParams(ctx, c.paramsYAML, c.args, c.parseStrings)
+	if err != nil {
+		return err
+	}
+	receivers := make([]string, len(c.receivers))
+	for i, tag := range c.receivers {
+		receivers[i] = tag.String()
+	}
+	results, err := api.AddSchedules(params.ActionSchedules{
+		Schedules: []params.ActionSchedule{{
+			Receivers:  receivers,
+			Name:       c.actionName,
+			Parameters: actionParams,
+			Cron:       c.cron,
+			Interval:   c.every,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	if len(results.Results) != 1 {
+		return errors.New("illegal number of results returned")
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.Schedule == nil {
+		return errors.New("schedule failed to be added")
+	}
+	return c.out.Write(ctx, map[string]string{
+		"Schedule added with id": result.Schedule.Id,
+		"Next run":               result.Schedule.NextRun.String(),
+	})
+}
+
+// ScheduleListCommand lists the action schedules in the environment.
+type ScheduleListCommand struct {
+	ActionCommandBase
+	out cmd.Output
+}
+
+const scheduleListDoc = `
+List the schedules on which actions are queued in the environment.
+`
+
+// SetFlags implements Command.SetFlags.
+func (c *ScheduleListCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+}
+
+// Info implements Command.Info.
+func (c *ScheduleListCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list",
+		Purpose: "list action schedules",
+		Doc:     scheduleListDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *ScheduleListCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+// Run implements Command.Run.
+func (c *ScheduleListCommand) Run(ctx *cmd.Context) error {
+	api, err := c.NewActionAPIClient()
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	schedules, err := api.ListSchedules()
+	if err != nil {
+		return err
+	}
+	if len(schedules.Schedules) == 0 {
+		fmt.Fprintln(ctx.Stderr, "no action schedules found")
+		return nil
+	}
+	output := make(map[string]interface{})
+	for _, schedule := range schedules.Schedules {
+		output[schedule.Id] = formatActionSchedule(schedule)
+	}
+	return c.out.Write(ctx, output)
+}
+
+// formatActionSchedule removes empty values from the given schedule and
+// inserts the remaining ones in a map[string]interface{} for cmd.Output
+// to write in an easy-to-read format.
+func formatActionSchedule(schedule params.ActionSchedule) map[string]interface{} {
+	targets := make([]string, len(schedule.Receivers))
+	for i, receiver := range schedule.Receivers {
+		targets[i] = receiver
+		if tag, err := names.ParseTag(receiver); err == nil {
+			targets[i] = tag.Id()
+		}
+	}
+	response := map[string]interface{}{
+		"action":   schedule.Name,
+		"targets":  targets,
+		"next-run": schedule.NextRun.String(),
+	}
+	if schedule.Cron != "" {
+		response["cron"] = schedule.Cron
+	} else {
+		response["every"] = schedule.Interval.String()
+	}
+	if len(schedule.Parameters) != 0 {
+		response["params"] = schedule.Parameters
+	}
+	if !schedule.LastRun.IsZero() {
+		response["last-run"] = schedule.LastRun.String()
+	}
+	return response
+}
+
+// ScheduleRemoveCommand removes action schedules.
+type ScheduleRemoveCommand struct {
+	ActionCommandBase
+	ids []string
+}
+
+const scheduleRemoveDoc = `
+Remove the action schedules with the given ids. Actions already queued by
+the schedules are not affected.
+`
+
+// Info implements Command.Info.
+func (c *ScheduleRemoveCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "remove",
+		Args:    "<schedule id> [...]",
+		Purpose: "remove action schedules",
+		Doc:     scheduleRemoveDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *ScheduleRemoveCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no schedule id specified")
+	}
+	c.ids = args
+	return nil
+}
+
+// Run implements Command.Run.
+func (c *ScheduleRemoveCommand) Run(ctx *cmd.Context) error {
+	api, err := c.NewActionAPIClient()
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	results, err := api.RemoveSchedules(params.ActionScheduleIds{Ids: c.ids})
+	if err != nil {
+		return err
+	}
+	if len(results.Results) != len(c.ids) {
+		return errors.New("illegal number of results returned")
+	}
+	failed := false
+	for i, result := range results.Results {
+		if result.Error != nil {
+			fmt.Fprintf(ctx.Stderr, "cannot remove schedule %s: %v\n", c.ids[i], result.Error)
+			failed = true
+		}
+	}
+	if failed {
+		return cmd.ErrSilent
+	}
+	return nil
+}
+
+// ScheduleRunsCommand shows the record of an action schedule's runs.
+type ScheduleRunsCommand struct {
+	ActionCommandBase
+	out cmd.Output
+	id  string
+}
+
+const scheduleRunsDoc = `
+Show the record of the runs of an action schedule, newest first, with the
+ids of the actions queued by each run and any errors encountered queueing
+them. The results of the actions can be fetched with "juju action fetch".
+`
+
+// SetFlags implements Command.SetFlags.
+func (c *ScheduleRunsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+}
+
+// Info implements Command.Info.
+func (c *ScheduleRunsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "runs",
+		Args:    "<schedule id>",
+		Purpose: "show the runs of an action schedule",
+		Doc:     scheduleRunsDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *ScheduleRunsCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no schedule id specified")
+	}
+	c.id = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+// Run implements Command.Run.
+func (c *ScheduleRunsCommand) Run(ctx *cmd.Context) error {
+	api, err := c.NewActionAPIClient()
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	results, err := api.ScheduleRuns(params.ActionScheduleIds{Ids: []string{c.id}})
+	if err != nil {
+		return err
+	}
+	if len(results.Results) != 1 {
+		return errors.New("illegal number of results returned")
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return result.Error
+	}
+	if len(result.Runs) == 0 {
+		fmt.Fprintf(ctx.Stderr, "schedule %s has not run\n", c.id)
+		return nil
+	}
+	output := make([]map[string]interface{}, len(result.Runs))
+	for i, run := range result.Runs {
+		response := map[string]interface{}{"time": run.Time.String()}
+		if len(run.Actions) != 0 {
+			ids := make([]string, len(run.Actions))
+			for j, action := range run.Actions {
+				ids[j] = action
+				if tag, err := names.ParseActionTag(action); err == nil {
+					ids[j] = tag.Id()
+				}
+			}
+			response["actions"] = ids
+		}
+		if len(run.Errors) != 0 {
+			response["errors"] = run.Errors
+		}
+		output[i] = response
+	}
+	return c.out.Write(ctx, output)
+}
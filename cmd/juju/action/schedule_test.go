@@ -0,0 +1,187 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/juju/action"
+	"github.com/juju/juju/testing"
+)
+
+type ScheduleSuite struct {
+	BaseActionSuite
+	client  *fakeAPIClient
+	restore func()
+}
+
+var _ = gc.Suite(&ScheduleSuite{})
+
+func (s *ScheduleSuite) SetUpTest(c *gc.C) {
+	s.BaseActionSuite.SetUpTest(c)
+	s.client = &fakeAPIClient{}
+	s.restore = s.patchAPIClient(s.client)
+}
+
+func (s *ScheduleSuite) TearDownTest(c *gc.C) {
+	s.restore()
+}
+
+func (s *ScheduleSuite) TestAddInit(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{
+		{nil, "no unit or service specified"},
+		{[]string{"mysql"}, "no action specified"},
+		{[]string{"mysql/0,bad-", "backup", "--every", "1h"}, `invalid unit or service name "bad-"`},
+		{[]string{"mysql", "Backup", "--every", "1h"}, `invalid action name "Backup"`},
+		{[]string{"mysql", "backup"}, "exactly one of --cron and --every must be specified"},
+		{[]string{"mysql", "backup", "--every", "1h", "--cron", "@daily"}, "exactly one of --cron and --every must be specified"},
+		{[]string{"mysql", "backup", "--cron", "* * *"}, `cron expression "\* \* \*" must have 5 fields, not 3`},
+		{[]string{"mysql", "backup", "--cron", "@daily", "foo"}, `argument "foo" must be of the form key...=value`},
+	} {
+		c.Logf("test %d: %v", i, test.args)
+		_, err := testing.RunCommand(c, &action.ScheduleAddCommand{}, test.args...)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *ScheduleSuite) TestAdd(c *gc.C) {
+	next := time.Date(2015, 7, 2, 3, 0, 0, 0, time.UTC)
+	s.client.scheduleResults = []params.ActionScheduleResult{{
+		Schedule: &params.ActionSchedule{Id: "3", NextRun: next},
+	}}
+	ctx, err := testing.RunCommand(c, &action.ScheduleAddCommand{},
+		"mysql/0,wordpress", "backup", "--cron", "0 3 * * *", "out.kind=xz", "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.client.addedSchedules, jc.DeepEquals, params.ActionSchedules{
+		Schedules: []params.ActionSchedule{{
+			Receivers: []string{"unit-mysql-0", "service-wordpress"},
+			Name:      "backup",
+			Parameters: map[string]interface{}{
+				"out": map[string]interface{}{"kind": "xz"},
+			},
+			Cron: "0 3 * * *",
+		}},
+	})
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"Next run: 2015-07-02 03:00:00 +0000 UTC\n"+
+		"Schedule added with id: \"3\"\n")
+}
+
+func (s *ScheduleSuite) TestAddInterval(c *gc.C) {
+	s.client.scheduleResults = []params.ActionScheduleResult{{
+		Schedule: &params.ActionSchedule{Id: "4"},
+	}}
+	_, err := testing.RunCommand(c, &action.ScheduleAddCommand{}, "mysql", "backup", "--every", "6h")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.client.addedSchedules.Schedules, gc.HasLen, 1)
+	c.Assert(s.client.addedSchedules.Schedules[0].Interval, gc.Equals, 6*time.Hour)
+	c.Assert(s.client.addedSchedules.Schedules[0].Cron, gc.Equals, "")
+}
+
+func (s *ScheduleSuite) TestAddError(c *gc.C) {
+	s.client.scheduleResults = []params.ActionScheduleResult{{
+		Error: &params.Error{Message: `action "backup" not defined for "mysql"`},
+	}}
+	_, err := testing.RunCommand(c, &action.ScheduleAddCommand{}, "mysql", "backup", "--every", "6h")
+	c.Assert(err, gc.ErrorMatches, `action "backup" not defined for "mysql"`)
+}
+
+func (s *ScheduleSuite) TestList(c *gc.C) {
+	s.client.schedules = []params.ActionSchedule{{
+		Id:         "1",
+		Receivers:  []string{"service-postgresql"},
+		Name:       "vacuum",
+		Parameters: map[string]interface{}{"full": true},
+		Cron:       "0 3 * * *",
+		NextRun:    time.Date(2015, 7, 2, 3, 0, 0, 0, time.UTC),
+		LastRun:    time.Date(2015, 7, 1, 3, 0, 0, 0, time.UTC),
+	}, {
+		Id:        "2",
+		Receivers: []string{"unit-haproxy-0"},
+		Name:      "rotate-certs",
+		Interval:  168 * time.Hour,
+		NextRun:   time.Date(2015, 7, 8, 12, 0, 0, 0, time.UTC),
+	}}
+	ctx, err := testing.RunCommand(c, &action.ScheduleListCommand{}, "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `
+"1":
+  action: vacuum
+  cron: 0 3 * * *
+  last-run: 2015-07-01 03:00:00 +0000 UTC
+  next-run: 2015-07-02 03:00:00 +0000 UTC
+  params:
+    full: true
+  targets:
+  - postgresql
+"2":
+  action: rotate-certs
+  every: 168h0m0s
+  next-run: 2015-07-08 12:00:00 +0000 UTC
+  targets:
+  - haproxy/0
+`[1:])
+}
+
+func (s *ScheduleSuite) TestListEmpty(c *gc.C) {
+	ctx, err := testing.RunCommand(c, &action.ScheduleListCommand{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "")
+	c.Assert(testing.Stderr(ctx), gc.Equals, "no action schedules found\n")
+}
+
+func (s *ScheduleSuite) TestRemove(c *gc.C) {
+	s.client.errorResults = []params.ErrorResult{{}, {}}
+	_, err := testing.RunCommand(c, &action.ScheduleRemoveCommand{}, "1", "2")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.client.removedSchedules, jc.DeepEquals, params.ActionScheduleIds{Ids: []string{"1", "2"}})
+}
+
+func (s *ScheduleSuite) TestRemoveErrors(c *gc.C) {
+	_, err := testing.RunCommand(c, &action.ScheduleRemoveCommand{})
+	c.Assert(err, gc.ErrorMatches, "no schedule id specified")
+
+	s.client.errorResults = []params.ErrorResult{{Error: &params.Error{Message: "action schedule 1 not found"}}}
+	ctx, err := testing.RunCommand(c, &action.ScheduleRemoveCommand{}, "1")
+	c.Assert(err, gc.ErrorMatches, "cmd: error out silently")
+	c.Assert(testing.Stderr(ctx), gc.Equals, "cannot remove schedule 1: action schedule 1 not found\n")
+
+	s.client.apiErr = errors.New("boom")
+	_, err = testing.RunCommand(c, &action.ScheduleRemoveCommand{}, "1")
+	c.Assert(err, gc.ErrorMatches, "boom")
+}
+
+func (s *ScheduleSuite) TestRuns(c *gc.C) {
+	s.client.scheduleRuns = []params.ActionScheduleRunsResult{{
+		Runs: []params.ActionScheduleRun{{
+			ScheduleId: "1",
+			Time:       time.Date(2015, 7, 1, 3, 0, 0, 0, time.UTC),
+			Actions:    []string{validActionTagString},
+			Errors:     []string{`postgresql/1: unit "postgresql/1" not found`},
+		}},
+	}}
+	ctx, err := testing.RunCommand(c, &action.ScheduleRunsCommand{}, "1", "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"- actions:\n"+
+		"  - "+validActionId+"\n"+
+		"  errors:\n"+
+		"  - 'postgresql/1: unit \"postgresql/1\" not found'\n"+
+		"  time: 2015-07-01 03:00:00 +0000 UTC\n")
+}
+
+func (s *ScheduleSuite) TestRunsNone(c *gc.C) {
+	s.client.scheduleRuns = []params.ActionScheduleRunsResult{{}}
+	ctx, err := testing.RunCommand(c, &action.ScheduleRunsCommand{}, "1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stderr(ctx), gc.Equals, "schedule 1 has not run\n")
+}
@@ -17,15 +17,20 @@ type StatusCommand struct {
 	ActionCommandBase
 	out         cmd.Output
 	requestedId string
+	batchId     string
 }
 
 const statusDoc = `
 Show the status of Actions matching given ID, partial ID prefix, or all Actions if no ID is supplied.
+
+With --batch, show the status of the batch of Actions queued on a service's
+units by "juju action do <service>", and of the Action on each unit.
 `
 
 // Set up the output.
 func (c *StatusCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+	f.StringVar(&c.batchId, "batch", "", "show the status of the action batch with this ID")
 }
 
 func (c *StatusCommand) Info() *cmd.Info {
@@ -43,6 +48,9 @@ func (c *StatusCommand) Init(args []string) error {
 		c.requestedId = ""
 		return nil
 	case 1:
+		if c.batchId != "" {
+			return errors.New("cannot specify both an action ID and --batch")
+		}
 		c.requestedId = args[0]
 		return nil
 	default:
@@ -57,6 +65,10 @@ func (c *StatusCommand) Run(ctx *cmd.Context) error {
 	}
 	defer api.Close()
 
+	if c.batchId != "" {
+		return c.writeBatch(ctx, api)
+	}
+
 	actionTags, err := getActionTagsByPrefix(api, c.requestedId)
 	if err != nil {
 		return err
@@ -119,3 +131,69 @@ func resultToMap(result params.ActionResult) map[string]interface{} {
 	item["status"] = result.Status
 	return item
 }
+
+// writeBatch writes out the status of the requested action batch.
+func (c *StatusCommand) writeBatch(ctx *cmd.Context, api APIClient) error {
+	results, err := api.Batches(params.ActionBatchIds{Ids: []string{c.batchId}})
+	if err != nil {
+		return err
+	}
+	if len(results.Results) != 1 {
+		return errors.New("illegal number of results returned")
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.Batch == nil {
+		return errors.Errorf("action batch %q not found", c.batchId)
+	}
+	return c.out.Write(ctx, map[string]interface{}{"batch": batchToMap(*result.Batch)})
+}
+
+// batchToMap removes empty values from the given batch and inserts the
+// remaining ones in a map[string]interface{} for cmd.Output to write in
+// an easy-to-read format.
+func batchToMap(batch params.ActionBatch) map[string]interface{} {
+	item := map[string]interface{}{
+		"id":       batch.Id,
+		"service":  batch.Service,
+		"action":   batch.Name,
+		"status":   batch.Status,
+		"enqueued": batch.Enqueued.String(),
+	}
+	if tag, err := names.ParseServiceTag(batch.Service); err == nil {
+		item["service"] = tag.Id()
+	}
+	if batch.MaxConcurrent != 0 {
+		item["max-concurrent"] = batch.MaxConcurrent
+	}
+	if batch.LeaderOnly {
+		item["leader-only"] = true
+	}
+	if batch.Message != "" {
+		item["message"] = batch.Message
+	}
+	if !batch.Completed.IsZero() {
+		item["completed"] = batch.Completed.String()
+	}
+	units := []map[string]interface{}{}
+	for _, unit := range batch.Units {
+		unitItem := map[string]interface{}{
+			"unit":   unit.Unit,
+			"status": unit.Status,
+		}
+		if tag, err := names.ParseUnitTag(unit.Unit); err == nil {
+			unitItem["unit"] = tag.Id()
+		}
+		if unit.Action != "" {
+			unitItem["id"] = unit.Action
+			if tag, err := names.ParseActionTag(unit.Action); err == nil {
+				unitItem["id"] = tag.Id()
+			}
+		}
+		units = append(units, unitItem)
+	}
+	item["units"] = units
+	return item
+}
@@ -103,3 +103,64 @@ type statusTestCase struct {
 	tags        params.FindTagsResults
 	results     []params.ActionResult
 }
+
+func (s *StatusSuite) TestRunBatch(c *gc.C) {
+	fakeClient := &fakeAPIClient{
+		batchResults: []params.ActionBatchResult{{
+			Batch: &params.ActionBatch{
+				Id:            "3",
+				Service:       "service-mysql",
+				Name:          "backup",
+				MaxConcurrent: 1,
+				Status:        params.ActionFailed,
+				Message:       "action " + validActionId + " on unit mysql/0 failed",
+				Enqueued:      time.Date(2015, 7, 1, 3, 0, 0, 0, time.UTC),
+				Completed:     time.Date(2015, 7, 1, 3, 5, 0, 0, time.UTC),
+				Units: []params.ActionBatchUnit{{
+					Unit:   "unit-mysql-0",
+					Action: validActionTagString,
+					Status: params.ActionFailed,
+				}, {
+					Unit:   "unit-mysql-1",
+					Status: params.ActionBatchSkipped,
+				}},
+			},
+		}},
+	}
+	restore := s.patchAPIClient(fakeClient)
+	defer restore()
+
+	ctx, err := testing.RunCommand(c, &action.StatusCommand{}, "--batch", "3", "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"batch:\n"+
+		"  action: backup\n"+
+		"  completed: 2015-07-01 03:05:00 +0000 UTC\n"+
+		"  enqueued: 2015-07-01 03:00:00 +0000 UTC\n"+
+		"  id: \"3\"\n"+
+		"  max-concurrent: 1\n"+
+		"  message: action "+validActionId+" on unit mysql/0 failed\n"+
+		"  service: mysql\n"+
+		"  status: failed\n"+
+		"  units:\n"+
+		"  - id: "+validActionId+"\n"+
+		"    status: failed\n"+
+		"    unit: mysql/0\n"+
+		"  - status: skipped\n"+
+		"    unit: mysql/1\n")
+}
+
+func (s *StatusSuite) TestRunBatchErrors(c *gc.C) {
+	_, err := testing.RunCommand(c, &action.StatusCommand{}, "--batch", "3", "deadbeef")
+	c.Assert(err, gc.ErrorMatches, "cannot specify both an action ID and --batch")
+
+	fakeClient := &fakeAPIClient{
+		batchResults: []params.ActionBatchResult{{
+			Error: &params.Error{Message: "action batch 3 not found"},
+		}},
+	}
+	restore := s.patchAPIClient(fakeClient)
+	defer restore()
+	_, err = testing.RunCommand(c, &action.StatusCommand{}, "--batch", "3")
+	c.Assert(err, gc.ErrorMatches, "action batch 3 not found")
+}
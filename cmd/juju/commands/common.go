@@ -23,6 +23,7 @@ import (
 
 	"github.com/juju/juju/api"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/charmrepository"
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/config"
@@ -116,7 +117,9 @@ func environFromNameProductionFunc(
 // resolveCharmURL resolves the given charm URL string
 // by looking it up in the appropriate charm repository.
 // If it is a charm store charm URL, the given csParams will
-// be used to access the charm store repository.
+// be used to access the charm store repository, unless the
+// configuration names a controller charm repository, which
+// is used instead.
 // If it is a local charm URL, the local charm repository at
 // the given repoPath will be used. The given configuration
 // will be used to add any necessary attributes to the repo
@@ -129,11 +132,19 @@ func resolveCharmURL(curlStr string, csParams charmrepo.NewCharmStoreParams, rep
 	if err != nil {
 		return nil, nil, errors.Trace(err)
 	}
-	repo, err := charmrepo.InferRepository(ref, csParams, repoPath)
-	if err != nil {
-		return nil, nil, errors.Trace(err)
+	var repo charmrepo.Interface
+	if ref.Schema == "cs" && conf.CharmRepositoryURL() != "" {
+		repo, err = charmrepository.NewForEnviron(conf)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	} else {
+		repo, err = charmrepo.InferRepository(ref, csParams, repoPath)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		repo = config.SpecializeCharmRepo(repo, conf)
 	}
-	repo = config.SpecializeCharmRepo(repo, conf)
 	if ref.Series == "" {
 		if defaultSeries, ok := conf.DefaultSeries(); ok {
 			ref.Series = defaultSeries
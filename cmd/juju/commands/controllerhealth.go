@@ -0,0 +1,298 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/controllerhealth"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const controllerHealthDoc = `
+This command reports on the health of the state servers, and of the
+database they share. It must be run against the state server
+environment. For each state server it shows whether its agent is alive,
+how many API connections it is serving and how far its clock is from
+the database clock; for each member of the mongo replica set, its state
+and how far its replication lags behind the primary. It also shows the
+backlog of transactions and cleanups in the database, and the size of
+the log collection.
+
+Anything that looks wrong is listed under warnings.
+
+Examples:
+
+    juju controller-health
+    juju controller-health --format yaml
+
+See Also:
+    juju help ensure-availability
+`
+
+// Thresholds beyond which the controller-health command warns.
+const (
+	healthReportAgeWarning     = 5 * time.Minute
+	healthLagWarning           = 10 * time.Second
+	healthClockSkewWarning     = 5 * time.Second
+	healthPendingTxnsWarning   = 100
+	healthOldestCleanupWarning = time.Hour
+	healthLogSizeWarning       = 4 * humanize.GiByte
+)
+
+// ControllerHealthCommand reports on the health of the state servers.
+type ControllerHealthCommand struct {
+	envcmd.EnvCommandBase
+	out cmd.Output
+}
+
+// Info implements Command.Info.
+func (c *ControllerHealthCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "controller-health",
+		Purpose: "report on the health of the state servers",
+		Doc:     controllerHealthDoc,
+	}
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *ControllerHealthCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": formatControllerHealthTabular,
+	})
+}
+
+// Init implements Command.Init.
+func (c *ControllerHealthCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+// ControllerHealthAPI defines the API methods that the
+// controller-health command uses.
+type ControllerHealthAPI interface {
+	Report() (params.ControllerHealthReport, error)
+	Close() error
+}
+
+var getControllerHealthAPI = func(c *ControllerHealthCommand) (ControllerHealthAPI, error) {
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return controllerhealth.NewClient(root), nil
+}
+
+// Run implements Command.Run.
+func (c *ControllerHealthCommand) Run(ctx *cmd.Context) error {
+	client, err := getControllerHealthAPI(c)
+	if err != nil {
+		return fmt.Errorf(connectionError, c.ConnectionName(), err)
+	}
+	defer client.Close()
+
+	report, err := client.Report()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return c.out.Write(ctx, formatControllerHealth(report, time.Now()))
+}
+
+type controllerHealth struct {
+	StateServers    []stateServerHealth      `json:"state-servers" yaml:"state-servers"`
+	ReplicaSet      []replicaSetMemberHealth `json:"replica-set,omitempty" yaml:"replica-set,omitempty"`
+	ReplicaSetError string                   `json:"replica-set-error,omitempty" yaml:"replica-set-error,omitempty"`
+	MaxClockSkew    string                   `json:"max-clock-skew" yaml:"max-clock-skew"`
+	Database        databaseHealth           `json:"database" yaml:"database"`
+	Warnings        []string                 `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+type stateServerHealth struct {
+	MachineId        string `json:"machine" yaml:"machine"`
+	Agent            string `json:"agent" yaml:"agent"`
+	APIConnections   int    `json:"api-connections" yaml:"api-connections"`
+	ClockOffset      string `json:"clock-offset,omitempty" yaml:"clock-offset,omitempty"`
+	ClockUncertainty string `json:"clock-uncertainty,omitempty" yaml:"clock-uncertainty,omitempty"`
+	LastReport       string `json:"last-report,omitempty" yaml:"last-report,omitempty"`
+}
+
+type replicaSetMemberHealth struct {
+	Id        int    `json:"id" yaml:"id"`
+	Address   string `json:"address" yaml:"address"`
+	MachineId string `json:"machine,omitempty" yaml:"machine,omitempty"`
+	State     string `json:"state" yaml:"state"`
+	Healthy   bool   `json:"healthy" yaml:"healthy"`
+	Lag       string `json:"lag" yaml:"lag"`
+}
+
+type databaseHealth struct {
+	PendingTransactions int    `json:"pending-transactions" yaml:"pending-transactions"`
+	PendingCleanups     int    `json:"pending-cleanups" yaml:"pending-cleanups"`
+	OldestCleanup       string `json:"oldest-cleanup,omitempty" yaml:"oldest-cleanup,omitempty"`
+	LogCount            int    `json:"log-count" yaml:"log-count"`
+	LogSize             string `json:"log-size" yaml:"log-size"`
+}
+
+// formatControllerHealth converts a controller health report into the
+// form in which it is displayed, noting anything that looks wrong as of
+// the given time.
+func formatControllerHealth(report params.ControllerHealthReport, now time.Time) controllerHealth {
+	var out controllerHealth
+	warn := func(format string, args ...interface{}) {
+		out.Warnings = append(out.Warnings, fmt.Sprintf(format, args...))
+	}
+	for _, s := range report.StateServers {
+		health := stateServerHealth{
+			MachineId:      s.MachineId,
+			Agent:          "down",
+			APIConnections: s.APIConnections,
+		}
+		if s.AgentAlive {
+			health.Agent = "alive"
+		} else {
+			warn("agent for state server machine %s is down", s.MachineId)
+		}
+		if s.ReportedAt.IsZero() {
+			warn("API server on machine %s has not reported", s.MachineId)
+		} else {
+			health.ClockOffset = formatOffset(s.ClockOffset)
+			health.ClockUncertainty = s.ClockUncertainty.String()
+			health.LastReport = formatStatusTime(&s.ReportedAt, true)
+			if age := now.Sub(s.ReportedAt); age > healthReportAgeWarning {
+				warn("API server on machine %s last reported %s ago", s.MachineId, roundDuration(age))
+			}
+		}
+		out.StateServers = append(out.StateServers, health)
+	}
+	if report.MaxClockSkew > healthClockSkewWarning {
+		warn("state server clocks differ by %s", report.MaxClockSkew)
+	}
+	out.MaxClockSkew = report.MaxClockSkew.String()
+
+	out.ReplicaSetError = report.ReplicaSetError
+	if report.ReplicaSetError != "" {
+		warn("cannot get replica set status: %s", report.ReplicaSetError)
+	}
+	for _, m := range report.ReplicaSet {
+		out.ReplicaSet = append(out.ReplicaSet, replicaSetMemberHealth{
+			Id:        m.Id,
+			Address:   m.Address,
+			MachineId: m.MachineId,
+			State:     m.State,
+			Healthy:   m.Healthy,
+			Lag:       m.Lag.String(),
+		})
+		switch {
+		case !m.Healthy:
+			warn("replica set member %s is unhealthy", m.Address)
+		case m.State != "PRIMARY" && m.State != "SECONDARY":
+			warn("replica set member %s is %s", m.Address, m.State)
+		case m.Lag > healthLagWarning:
+			warn("replica set member %s lags %s behind the primary", m.Address, m.Lag)
+		}
+	}
+
+	out.Database = databaseHealth{
+		PendingTransactions: report.PendingTransactions,
+		PendingCleanups:     report.PendingCleanups,
+		LogCount:            report.LogCount,
+		LogSize:             humanize.IBytes(uint64(report.LogSize)),
+	}
+	if report.PendingTransactions > healthPendingTxnsWarning {
+		warn("%d transactions are pending", report.PendingTransactions)
+	}
+	if report.OldestCleanup != nil {
+		out.Database.OldestCleanup = formatStatusTime(report.OldestCleanup, true)
+		if age := now.Sub(*report.OldestCleanup); age > healthOldestCleanupWarning {
+			warn("oldest pending cleanup was scheduled %s ago", roundDuration(age))
+		}
+	}
+	if report.LogSize > healthLogSizeWarning {
+		warn("log collection holds %s", out.Database.LogSize)
+	}
+	return out
+}
+
+// formatOffset returns a clock offset with an explicit sign.
+func formatOffset(offset time.Duration) string {
+	if offset < 0 {
+		return offset.String()
+	}
+	return "+" + offset.String()
+}
+
+// roundDuration rounds a duration to the second, for display.
+func roundDuration(d time.Duration) time.Duration {
+	return (d + time.Second/2) / time.Second * time.Second
+}
+
+// formatControllerHealthTabular returns a tabular summary of a
+// controller health report.
+func formatControllerHealthTabular(value interface{}) ([]byte, error) {
+	health, ok := value.(controllerHealth)
+	if !ok {
+		return nil, errors.Errorf("expected value of type %T, got %T", health, value)
+	}
+	var out bytes.Buffer
+	tw := tabwriter.NewWriter(&out, 0, 1, 1, ' ', 0)
+	p := func(values ...string) {
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	p("[State Servers]")
+	p("MACHINE", "AGENT", "API-CONNECTIONS", "CLOCK-OFFSET", "LAST-REPORT")
+	for _, s := range health.StateServers {
+		offset := s.ClockOffset
+		if offset != "" {
+			offset += "±" + s.ClockUncertainty
+		}
+		p(s.MachineId, s.Agent, strconv.Itoa(s.APIConnections), offset, s.LastReport)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(&out)
+	p("[Replica Set]")
+	if health.ReplicaSetError != "" {
+		p("ERROR:", health.ReplicaSetError)
+	} else {
+		p("ID", "ADDRESS", "MACHINE", "STATE", "HEALTHY", "LAG")
+		for _, m := range health.ReplicaSet {
+			p(strconv.Itoa(m.Id), m.Address, m.MachineId, m.State, strconv.FormatBool(m.Healthy), m.Lag)
+		}
+	}
+	tw.Flush()
+
+	fmt.Fprintln(&out)
+	p("[Database]")
+	p("MAX-CLOCK-SKEW:", health.MaxClockSkew)
+	p("PENDING-TRANSACTIONS:", strconv.Itoa(health.Database.PendingTransactions))
+	p("PENDING-CLEANUPS:", strconv.Itoa(health.Database.PendingCleanups))
+	if health.Database.OldestCleanup != "" {
+		p("OLDEST-CLEANUP:", health.Database.OldestCleanup)
+	}
+	p("LOG-COUNT:", strconv.Itoa(health.Database.LogCount))
+	p("LOG-SIZE:", health.Database.LogSize)
+	tw.Flush()
+
+	if len(health.Warnings) > 0 {
+		fmt.Fprintln(&out)
+		p("[Warnings]")
+		for _, warning := range health.Warnings {
+			p(warning)
+		}
+		tw.Flush()
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+)
+
+type ControllerHealthSuite struct {
+	testing.FakeJujuHomeSuite
+	mock *mockControllerHealthAPI
+}
+
+var _ = gc.Suite(&ControllerHealthSuite{})
+
+func (s *ControllerHealthSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.mock = &mockControllerHealthAPI{}
+	s.PatchValue(&getControllerHealthAPI, func(_ *ControllerHealthCommand) (ControllerHealthAPI, error) {
+		return s.mock, nil
+	})
+}
+
+func (s *ControllerHealthSuite) healthyReport(reportedAt time.Time) params.ControllerHealthReport {
+	return params.ControllerHealthReport{
+		StateServers: []params.StateServerHealth{{
+			MachineId:        "0",
+			AgentAlive:       true,
+			APIConnections:   3,
+			ClockOffset:      20 * time.Millisecond,
+			ClockUncertainty: time.Millisecond,
+			ReportedAt:       reportedAt,
+		}},
+		ReplicaSet: []params.ReplicaSetMemberHealth{{
+			Id:        1,
+			Address:   "10.0.0.1:37017",
+			MachineId: "0",
+			State:     "PRIMARY",
+			Healthy:   true,
+		}},
+		PendingTransactions: 2,
+		LogCount:            1000,
+		LogSize:             2048,
+	}
+}
+
+func (s *ControllerHealthSuite) TestInit(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&ControllerHealthCommand{}), "extra")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+}
+
+func (s *ControllerHealthSuite) TestYaml(c *gc.C) {
+	reportedAt := time.Now()
+	s.mock.report = s.healthyReport(reportedAt)
+	context, err := testing.RunCommand(c, envcmd.Wrap(&ControllerHealthCommand{}), "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"state-servers:\n"+
+		"- machine: \"0\"\n"+
+		"  agent: alive\n"+
+		"  api-connections: 3\n"+
+		"  clock-offset: +20ms\n"+
+		"  clock-uncertainty: 1ms\n"+
+		"  last-report: "+formatStatusTime(&reportedAt, true)+"\n"+
+		"replica-set:\n"+
+		"- id: 1\n"+
+		"  address: 10.0.0.1:37017\n"+
+		"  machine: \"0\"\n"+
+		"  state: PRIMARY\n"+
+		"  healthy: true\n"+
+		"  lag: 0s\n"+
+		"max-clock-skew: 0s\n"+
+		"database:\n"+
+		"  pending-transactions: 2\n"+
+		"  pending-cleanups: 0\n"+
+		"  log-count: 1000\n"+
+		"  log-size: 2.0KiB\n")
+}
+
+func (s *ControllerHealthSuite) TestTabular(c *gc.C) {
+	reportedAt := time.Now()
+	s.mock.report = s.healthyReport(reportedAt)
+	s.mock.report.StateServers = append(s.mock.report.StateServers, params.StateServerHealth{
+		MachineId: "1",
+	})
+	s.mock.report.ReplicaSet = nil
+	s.mock.report.ReplicaSetError = "no reachable servers"
+	context, err := testing.RunCommand(c, envcmd.Wrap(&ControllerHealthCommand{}))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"[State Servers]\n"+
+		"MACHINE AGENT API-CONNECTIONS CLOCK-OFFSET LAST-REPORT\n"+
+		"0       alive 3               +20ms±1ms    "+formatStatusTime(&reportedAt, true)+"\n"+
+		"1       down  0                            \n"+
+		"\n"+
+		"[Replica Set]\n"+
+		"ERROR: no reachable servers\n"+
+		"\n"+
+		"[Database]\n"+
+		"MAX-CLOCK-SKEW:       0s\n"+
+		"PENDING-TRANSACTIONS: 2\n"+
+		"PENDING-CLEANUPS:     0\n"+
+		"LOG-COUNT:            1000\n"+
+		"LOG-SIZE:             2.0KiB\n"+
+		"\n"+
+		"[Warnings]\n"+
+		"agent for state server machine 1 is down\n"+
+		"API server on machine 1 has not reported\n"+
+		"cannot get replica set status: no reachable servers\n")
+}
+
+func (s *ControllerHealthSuite) TestReportError(c *gc.C) {
+	s.mock.err = errors.New("boom")
+	_, err := testing.RunCommand(c, envcmd.Wrap(&ControllerHealthCommand{}))
+	c.Assert(err, gc.ErrorMatches, "boom")
+}
+
+func (s *ControllerHealthSuite) TestFormatHealthy(c *gc.C) {
+	now := time.Now()
+	health := formatControllerHealth(s.healthyReport(now), now)
+	c.Assert(health.Warnings, gc.HasLen, 0)
+}
+
+func (s *ControllerHealthSuite) TestFormatWarnings(c *gc.C) {
+	now := time.Now()
+	oldestCleanup := now.Add(-2 * time.Hour)
+	report := params.ControllerHealthReport{
+		StateServers: []params.StateServerHealth{{
+			MachineId:  "0",
+			AgentAlive: true,
+			ReportedAt: now.Add(-10 * time.Minute),
+		}},
+		MaxClockSkew: 6 * time.Second,
+		ReplicaSet: []params.ReplicaSetMemberHealth{{
+			Id:      1,
+			Address: "10.0.0.1:37017",
+			State:   "PRIMARY",
+			Healthy: true,
+		}, {
+			Id:      2,
+			Address: "10.0.0.2:37017",
+			State:   "SECONDARY",
+			Healthy: true,
+			Lag:     time.Minute,
+		}, {
+			Id:      3,
+			Address: "10.0.0.3:37017",
+			State:   "RECOVERING",
+			Healthy: true,
+		}, {
+			Id:      4,
+			Address: "10.0.0.4:37017",
+			State:   "DOWN",
+		}},
+		PendingTransactions: 101,
+		PendingCleanups:     1,
+		OldestCleanup:       &oldestCleanup,
+		LogSize:             5 << 30,
+	}
+	health := formatControllerHealth(report, now)
+	c.Assert(health.Warnings, jc.DeepEquals, []string{
+		"API server on machine 0 last reported 10m0s ago",
+		"state server clocks differ by 6s",
+		"replica set member 10.0.0.2:37017 lags 1m0s behind the primary",
+		"replica set member 10.0.0.3:37017 is RECOVERING",
+		"replica set member 10.0.0.4:37017 is unhealthy",
+		"101 transactions are pending",
+		"oldest pending cleanup was scheduled 2h0m0s ago",
+		"log collection holds 5.0GiB",
+	})
+}
+
+type mockControllerHealthAPI struct {
+	report params.ControllerHealthReport
+	err    error
+}
+
+func (m *mockControllerHealthAPI) Report() (params.ControllerHealthReport, error) {
+	return m.report, m.err
+}
+
+func (*mockControllerHealthAPI) Close() error {
+	return nil
+}
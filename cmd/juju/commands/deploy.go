@@ -43,6 +43,10 @@ type DeployCommand struct {
 	// Storage is a map of storage constraints, keyed on the storage name
 	// defined in charm storage metadata.
 	Storage map[string]storage.Constraints
+
+	// Bindings maps charm endpoint names to the spaces their relation
+	// addresses are taken from.
+	Bindings map[string]string
 }
 
 const deployDoc = `
@@ -113,6 +117,14 @@ to configure on it. The --networks argument instructs juju to add all the
 networks specified with it to all new machines deployed to host units of
 the service. Not supported on all providers.
 
+Relations on a charm endpoint can be bound to a network space with the
+--bind argument, which may be repeated:
+
+   juju deploy mysql --bind db=storage --bind monitors=admin
+   (units of mysql will use their address in the "storage" space for
+    relations on the db endpoint, and their address in the "admin"
+    space for relations on the monitors endpoint)
+
 See Also:
    juju help constraints
    juju help set-constraints
@@ -138,6 +150,7 @@ func (c *DeployCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.StringVar(&c.Networks, "networks", "", "bind the service to specific networks")
 	f.StringVar(&c.RepoPath, "repository", os.Getenv(osenv.JujuRepositoryEnvKey), "local charm repository")
 	f.Var(storageFlag{&c.Storage}, "storage", "charm storage constraints")
+	f.Var(bindFlag{&c.Bindings}, "bind", "bind a charm endpoint to a network space")
 }
 
 func (c *DeployCommand) Init(args []string) error {
@@ -248,9 +261,13 @@ func (c *DeployCommand) Run(ctx *cmd.Context) error {
 		}
 	}
 
-	// If storage is specified, we attempt to use a new API on the service facade.
-	if len(c.Storage) > 0 {
+	// If storage or endpoint bindings are specified, we attempt to use
+	// a new API on the service facade.
+	if len(c.Storage) > 0 || len(c.Bindings) > 0 {
 		notSupported := errors.New("cannot deploy charms with storage: not supported by the API server")
+		if len(c.Bindings) > 0 {
+			notSupported = errors.New("cannot deploy charms with --bind: not supported by the API server")
+		}
 		serviceClient, err := c.newServiceAPIClient()
 		if err != nil {
 			return notSupported
@@ -265,6 +282,7 @@ func (c *DeployCommand) Run(ctx *cmd.Context) error {
 			c.ToMachineSpec,
 			requestedNetworks,
 			c.Storage,
+			c.Bindings,
 		)
 		if params.IsCodeNotImplemented(err) {
 			return notSupported
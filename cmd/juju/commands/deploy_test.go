@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"strings"
 
 	"github.com/juju/errors"
@@ -86,6 +87,12 @@ var initErrorTests = []struct {
 	}, {
 		args: []string{"craziness", "burble1", "--constraints", "gibber=plop"},
 		err:  `invalid value "gibber=plop" for flag --constraints: unknown constraint "gibber"`,
+	}, {
+		args: []string{"craziness", "burble1", "--bind", "db"},
+		err:  `invalid value "db" for flag --bind: expected <endpoint>=<space>`,
+	}, {
+		args: []string{"craziness", "burble1", "--bind", "db=Bad_Space"},
+		err:  `invalid value "db=Bad_Space" for flag --bind: "Bad_Space" is not a valid space name`,
 	},
 }
 
@@ -154,6 +161,31 @@ func (s *DeploySuite) TestCharmBundle(c *gc.C) {
 	s.AssertService(c, "some-service-name", curl, 1, 0)
 }
 
+func (s *DeploySuite) TestCharmRepository(c *gc.C) {
+	s.PatchValue(&charmrepo.CacheDir, c.MkDir())
+	archive := testcharms.Repo.CharmArchive(c.MkDir(), "dummy")
+	f, err := os.Open(archive.Path)
+	c.Assert(err, jc.ErrorIsNil)
+	defer f.Close()
+	hash, size, err := utils.ReadSHA256(f)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = f.Seek(0, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	curl := charm.MustParseURL("cs:trusty/dummy-3")
+	_, err = s.State.PublishRepositoryCharm(curl, f, size, hash, s.AdminUserTag(c))
+	c.Assert(err, jc.ErrorIsNil)
+	repositoryURL := "https://" + s.APIInfo(c).Addrs[0] + "/charmrepository"
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{
+		"charm-repository-url":              repositoryURL,
+		"charm-repository-anonymous-access": true,
+	}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = runDeploy(c, "cs:dummy")
+	c.Assert(err, jc.ErrorIsNil)
+	s.AssertService(c, "dummy", curl, 1, 0)
+}
+
 func (s *DeploySuite) TestSubordinateCharm(c *gc.C) {
 	testcharms.Repo.CharmArchivePath(s.SeriesPath, "logging")
 	err := runDeploy(c, "local:logging")
@@ -219,6 +251,23 @@ func (s *DeploySuite) TestNetworks(c *gc.C) {
 	c.Assert(cons, jc.DeepEquals, constraints.MustParse("mem=2G cpu-cores=2 networks=net1,net0,^net3,^net4"))
 }
 
+func (s *DeploySuite) TestBind(c *gc.C) {
+	_, err := s.State.AddSpace("storage", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	testcharms.Repo.CharmArchivePath(s.SeriesPath, "wordpress")
+	err = runDeploy(c, "local:wordpress", "--bind", "db=storage")
+	c.Assert(err, jc.ErrorIsNil)
+	curl := charm.MustParseURL("local:trusty/wordpress-3")
+	service, _ := s.AssertService(c, "wordpress", curl, 1, 0)
+	c.Assert(service.EndpointBindings(), jc.DeepEquals, map[string]string{"db": "storage"})
+}
+
+func (s *DeploySuite) TestBindUnknownSpace(c *gc.C) {
+	testcharms.Repo.CharmArchivePath(s.SeriesPath, "wordpress")
+	err := runDeploy(c, "local:wordpress", "--bind", "db=storage")
+	c.Assert(err, gc.ErrorMatches, `cannot set endpoint bindings for service "wordpress": space "storage" not found`)
+}
+
 // TODO(wallyworld) - add another test that deploy with storage fails for older environments
 // (need deploy client to be refactored to use API stub)
 func (s *DeploySuite) TestStorage(c *gc.C) {
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/juju/errors"
+	"github.com/juju/names"
 
 	"github.com/juju/juju/storage"
 )
@@ -41,3 +42,32 @@ func (f storageFlag) String() string {
 	}
 	return strings.Join(strs, " ")
 }
+
+type bindFlag struct {
+	bindings *map[string]string
+}
+
+// Set implements gnuflag.Value.Set.
+func (f bindFlag) Set(s string) error {
+	fields := strings.SplitN(s, "=", 2)
+	if len(fields) < 2 || fields[0] == "" {
+		return errors.New("expected <endpoint>=<space>")
+	}
+	if !names.IsValidSpace(fields[1]) {
+		return errors.Errorf("%q is not a valid space name", fields[1])
+	}
+	if *f.bindings == nil {
+		*f.bindings = make(map[string]string)
+	}
+	(*f.bindings)[fields[0]] = fields[1]
+	return nil
+}
+
+// Set implements gnuflag.Value.String.
+func (f bindFlag) String() string {
+	strs := make([]string, 0, len(*f.bindings))
+	for endpoint, space := range *f.bindings {
+		strs = append(strs, fmt.Sprintf("%s=%s", endpoint, space))
+	}
+	return strings.Join(strs, " ")
+}
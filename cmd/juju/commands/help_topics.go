@@ -394,6 +394,13 @@ networks
    network. Positive network constraints do not imply the networks will be enabled,
    use the --networks argument for that, just that they could be enabled.
 
+spaces
+   Spaces defines the list of network spaces (see "juju help space") the machine
+   must have an address in. Multiple spaces must be delimited by a comma. Existing
+   machines are only chosen if they have an address in a subnet of every listed
+   space; new machines are started in a zone with a subnet in those spaces, where
+   the provider supports it. Example: spaces=storage,db
+
 instance-type
    Instance-type is the provider-specific name of a type of machine to deploy,
    for example m1.small on EC2 or A4 on Azure.  Specifying this constraint may
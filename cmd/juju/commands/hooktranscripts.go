@@ -0,0 +1,211 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/hooktranscripts"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/worker/uniter/runner/transcript"
+)
+
+const hookTranscriptsDoc = `
+This command fetches the transcripts of the most recent hook and action
+executions of a unit. Transcripts are only recorded while the environment
+setting hook-transcripts is true:
+
+    juju set-env hook-transcripts=true
+
+Each transcript holds the environment the hook ran in, every hook tool it
+invoked together with the output and exit code it was given, and the exit
+code and duration of the hook itself. Values that may be secret are
+redacted before a transcript is recorded: charm configuration, relation
+and leader settings, action parameters and results, and proxy
+credentials. Replaying a transcript supplies "<redacted>" in their place.
+
+Without a transcript id, a summary of the unit's transcripts is shown,
+newest first. With an id, that transcript is shown in full. A transcript
+written out in json format can be replayed on the unit's machine, against
+the charm directory, with:
+
+    jujud replay-hook <transcript-file>
+
+which runs the hook with the recorded environment and answers its hook
+tool calls with the recorded responses.
+
+Examples:
+
+    # Summarise the transcripts recorded for mysql/0.
+    juju hook-transcripts mysql/0
+
+    # Save transcript 42 for replay.
+    juju hook-transcripts mysql/0 42 --format json -o transcript.json
+`
+
+// HookTranscriptsCommand fetches the hook transcripts recorded for a
+// unit.
+type HookTranscriptsCommand struct {
+	envcmd.EnvCommandBase
+	out      cmd.Output
+	unitName string
+	id       string
+}
+
+// Info implements Command.Info.
+func (c *HookTranscriptsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "hook-transcripts",
+		Args:    "<unit> [<transcript id>]",
+		Purpose: "fetch the transcripts of a unit's recent hook executions",
+		Doc:     hookTranscriptsDoc,
+	}
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *HookTranscriptsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"json":    cmd.FormatJson,
+		"tabular": formatHookTranscriptsTabular,
+	})
+}
+
+// Init implements Command.Init.
+func (c *HookTranscriptsCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return errors.New("no unit specified")
+	case 1:
+	case 2:
+		c.id = args[1]
+		if _, err := strconv.Atoi(c.id); err != nil {
+			return errors.Errorf("invalid transcript id %q", c.id)
+		}
+	default:
+		return cmd.CheckEmpty(args[2:])
+	}
+	c.unitName = args[0]
+	if !names.IsValidUnit(c.unitName) {
+		return errors.Errorf("invalid unit name %q", c.unitName)
+	}
+	return nil
+}
+
+// HookTranscriptsAPI defines the API methods that the hook-transcripts
+// command uses.
+type HookTranscriptsAPI interface {
+	HookTranscripts(unitName string) ([]params.HookTranscript, error)
+	Close() error
+}
+
+var getHookTranscriptsAPI = func(c *HookTranscriptsCommand) (HookTranscriptsAPI, error) {
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return hooktranscripts.NewClient(root), nil
+}
+
+// Run implements Command.Run.
+func (c *HookTranscriptsCommand) Run(ctx *cmd.Context) error {
+	client, err := getHookTranscriptsAPI(c)
+	if err != nil {
+		return fmt.Errorf(connectionError, c.ConnectionName(), err)
+	}
+	defer client.Close()
+
+	results, err := client.HookTranscripts(c.unitName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	transcripts := make([]transcript.Transcript, len(results))
+	for i, result := range results {
+		transcripts[i] = transcriptFromParams(result)
+	}
+	if c.id != "" {
+		for _, t := range transcripts {
+			if t.Id == c.id {
+				return c.out.Write(ctx, t)
+			}
+		}
+		return errors.NotFoundf("transcript %s for unit %q", c.id, c.unitName)
+	}
+	if len(transcripts) == 0 {
+		fmt.Fprintf(ctx.Stderr, "no transcripts recorded for unit %q\n", c.unitName)
+		return nil
+	}
+	return c.out.Write(ctx, transcripts)
+}
+
+func transcriptFromParams(t params.HookTranscript) transcript.Transcript {
+	calls := make([]transcript.ToolCall, len(t.Calls))
+	for i, call := range t.Calls {
+		calls[i] = transcript.ToolCall{
+			Command:   call.Command,
+			Args:      call.Args,
+			Stdin:     call.Stdin,
+			Stdout:    call.Stdout,
+			Stderr:    call.Stderr,
+			Code:      call.Code,
+			Truncated: call.Truncated,
+		}
+	}
+	return transcript.Transcript{
+		Id:       t.Id,
+		Unit:     t.Unit,
+		Kind:     transcript.Kind(t.Kind),
+		Name:     t.Name,
+		Env:      t.Env,
+		Calls:    calls,
+		ExitCode: t.ExitCode,
+		Error:    t.Error,
+		Started:  t.Started,
+		Duration: t.Duration,
+	}
+}
+
+// formatHookTranscriptsTabular returns a tabular summary of a list of
+// transcripts, or a readable rendering of a single transcript.
+func formatHookTranscriptsTabular(value interface{}) ([]byte, error) {
+	var out bytes.Buffer
+	tw := tabwriter.NewWriter(&out, 0, 1, 1, ' ', 0)
+	p := func(values ...string) {
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	switch value := value.(type) {
+	case []transcript.Transcript:
+		p("ID", "KIND", "NAME", "STARTED", "DURATION", "EXIT", "CALLS")
+		for _, t := range value {
+			p(t.Id, string(t.Kind), t.Name, formatStatusTime(&t.Started, true),
+				t.Duration.String(), strconv.Itoa(t.ExitCode), strconv.Itoa(len(t.Calls)))
+		}
+	case transcript.Transcript:
+		p("ID:", value.Id)
+		p("UNIT:", value.Unit)
+		p(strings.ToUpper(string(value.Kind))+":", value.Name)
+		p("STARTED:", formatStatusTime(&value.Started, true))
+		p("DURATION:", value.Duration.String())
+		p("EXIT:", strconv.Itoa(value.ExitCode))
+		tw.Flush()
+		fmt.Fprintln(&out)
+		p("CODE", "CALL")
+		for _, call := range value.Calls {
+			p(strconv.Itoa(call.Code), strings.Join(append([]string{call.Command}, call.Args...), " "))
+		}
+	default:
+		return nil, errors.Errorf("expected transcripts, got %T", value)
+	}
+	tw.Flush()
+	return out.Bytes(), nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/uniter/runner/transcript"
+)
+
+type HookTranscriptsSuite struct {
+	testing.FakeJujuHomeSuite
+	mock *mockHookTranscriptsAPI
+}
+
+var _ = gc.Suite(&HookTranscriptsSuite{})
+
+func (s *HookTranscriptsSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	started := time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC)
+	s.mock = &mockHookTranscriptsAPI{
+		transcripts: []params.HookTranscript{{
+			Id:   "12",
+			Unit: "mysql/0",
+			Kind: "hook",
+			Name: "config-changed",
+			Env:  []string{"JUJU_UNIT_NAME=mysql/0"},
+			Calls: []params.HookToolCall{{
+				Command: "config-get",
+				Args:    []string{"--format", "json"},
+				Stdout:  `{"port": 80}`,
+			}, {
+				Command: "status-set",
+				Args:    []string{"bogus"},
+				Stderr:  "error: invalid status\n",
+				Code:    2,
+			}},
+			ExitCode: 1,
+			Error:    "exit status 1",
+			Started:  started.Add(time.Minute),
+			Duration: 1500 * time.Millisecond,
+		}, {
+			Id:       "7",
+			Unit:     "mysql/0",
+			Kind:     "action",
+			Name:     "backup",
+			Started:  started,
+			Duration: 2 * time.Second,
+		}},
+	}
+	s.PatchValue(&getHookTranscriptsAPI, func(_ *HookTranscriptsCommand) (HookTranscriptsAPI, error) {
+		return s.mock, nil
+	})
+}
+
+func (s *HookTranscriptsSuite) TestInit(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{
+		{nil, "no unit specified"},
+		{[]string{"mysql"}, `invalid unit name "mysql"`},
+		{[]string{"mysql/0", "latest"}, `invalid transcript id "latest"`},
+		{[]string{"mysql/0", "1", "2"}, `unrecognized args: \["2"\]`},
+	} {
+		c.Logf("test %d: %v", i, test.args)
+		_, err := testing.RunCommand(c, envcmd.Wrap(&HookTranscriptsCommand{}), test.args...)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *HookTranscriptsSuite) TestSummary(c *gc.C) {
+	context, err := testing.RunCommand(c, envcmd.Wrap(&HookTranscriptsCommand{}), "mysql/0")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mock.unitName, gc.Equals, "mysql/0")
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"ID KIND   NAME           STARTED              DURATION EXIT CALLS\n"+
+		"12 hook   config-changed 2015-07-01 12:01:00Z 1.5s     1    2\n"+
+		"7  action backup         2015-07-01 12:00:00Z 2s       0    0\n")
+}
+
+func (s *HookTranscriptsSuite) TestSingleTabular(c *gc.C) {
+	context, err := testing.RunCommand(c, envcmd.Wrap(&HookTranscriptsCommand{}), "mysql/0", "12")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"ID:       12\n"+
+		"UNIT:     mysql/0\n"+
+		"HOOK:     config-changed\n"+
+		"STARTED:  2015-07-01 12:01:00Z\n"+
+		"DURATION: 1.5s\n"+
+		"EXIT:     1\n"+
+		"\n"+
+		"CODE CALL\n"+
+		"0    config-get --format json\n"+
+		"2    status-set bogus\n")
+}
+
+func (s *HookTranscriptsSuite) TestSingleJSONRoundTrips(c *gc.C) {
+	context, err := testing.RunCommand(c, envcmd.Wrap(&HookTranscriptsCommand{}), "mysql/0", "12", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	var t transcript.Transcript
+	err = json.Unmarshal([]byte(testing.Stdout(context)), &t)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t, jc.DeepEquals, transcriptFromParams(s.mock.transcripts[0]))
+}
+
+func (s *HookTranscriptsSuite) TestSingleNotFound(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&HookTranscriptsCommand{}), "mysql/0", "99")
+	c.Assert(err, gc.ErrorMatches, `transcript 99 for unit "mysql/0" not found`)
+}
+
+func (s *HookTranscriptsSuite) TestNoTranscripts(c *gc.C) {
+	s.mock.transcripts = nil
+	context, err := testing.RunCommand(c, envcmd.Wrap(&HookTranscriptsCommand{}), "mysql/0")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, "")
+	c.Assert(testing.Stderr(context), gc.Equals, "no transcripts recorded for unit \"mysql/0\"\n")
+}
+
+func (s *HookTranscriptsSuite) TestError(c *gc.C) {
+	s.mock.err = errors.New("boom")
+	_, err := testing.RunCommand(c, envcmd.Wrap(&HookTranscriptsCommand{}), "mysql/0")
+	c.Assert(err, gc.ErrorMatches, "boom")
+}
+
+type mockHookTranscriptsAPI struct {
+	transcripts []params.HookTranscript
+	unitName    string
+	err         error
+}
+
+func (m *mockHookTranscriptsAPI) HookTranscripts(unitName string) ([]params.HookTranscript, error) {
+	m.unitName = unitName
+	return m.transcripts, m.err
+}
+
+func (*mockHookTranscriptsAPI) Close() error {
+	return nil
+}
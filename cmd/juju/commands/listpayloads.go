@@ -0,0 +1,149 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/payloads"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const listPayloadsDoc = `
+This command will report on the workload payloads (processes, containers,
+...) that charms have registered for their units using the payload-register
+hook tool.
+
+Payloads may be filtered by supplying one or more patterns. A payload is
+listed if any pattern matches its name, type, id, status, unit, machine,
+service or one of its labels. Patterns may contain shell-style wildcards.
+
+Examples:
+
+    # List all payloads.
+    juju list-payloads
+
+    # List the docker payloads of the mysql service.
+    juju list-payloads mysql docker
+
+    # List the stopped payloads on machine 1.
+    juju list-payloads 1 stopped
+`
+
+// ListPayloadsCommand shows the workload payloads registered in the
+// environment.
+type ListPayloadsCommand struct {
+	envcmd.EnvCommandBase
+	out      cmd.Output
+	patterns []string
+}
+
+// Info implements Command.Info.
+func (c *ListPayloadsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list-payloads",
+		Args:    "[pattern ...]",
+		Purpose: "display workload payloads registered by units",
+		Doc:     listPayloadsDoc,
+	}
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *ListPayloadsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": formatPayloadsTabular,
+	})
+}
+
+// Init implements Command.Init.
+func (c *ListPayloadsCommand) Init(args []string) error {
+	c.patterns = args
+	return nil
+}
+
+// ListPayloadsAPI defines the payloads API methods that the
+// list-payloads command uses.
+type ListPayloadsAPI interface {
+	ListPayloads(patterns ...string) ([]params.Payload, error)
+	Close() error
+}
+
+var getListPayloadsAPI = func(c *ListPayloadsCommand) (ListPayloadsAPI, error) {
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return payloads.NewClient(root), nil
+}
+
+// payloadInfo defines the serialization behaviour of a payload.
+type payloadInfo struct {
+	Unit    string   `yaml:"unit" json:"unit"`
+	Machine string   `yaml:"machine,omitempty" json:"machine,omitempty"`
+	Name    string   `yaml:"name" json:"name"`
+	Type    string   `yaml:"type" json:"type"`
+	ID      string   `yaml:"id" json:"id"`
+	Status  string   `yaml:"status" json:"status"`
+	Labels  []string `yaml:"labels,omitempty" json:"labels,omitempty"`
+}
+
+// Run implements Command.Run.
+func (c *ListPayloadsCommand) Run(ctx *cmd.Context) error {
+	client, err := getListPayloadsAPI(c)
+	if err != nil {
+		return fmt.Errorf(connectionError, c.ConnectionName(), err)
+	}
+	defer client.Close()
+
+	results, err := client.ListPayloads(c.patterns...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(results) == 0 {
+		fmt.Fprintf(ctx.Stderr, "no payloads found\n")
+		return nil
+	}
+	infos := make([]payloadInfo, len(results))
+	for i, p := range results {
+		infos[i] = payloadInfo{
+			Unit:    p.Unit,
+			Machine: p.Machine,
+			Name:    p.Name,
+			Type:    p.Type,
+			ID:      p.ID,
+			Status:  p.Status,
+			Labels:  p.Labels,
+		}
+	}
+	return c.out.Write(ctx, infos)
+}
+
+// formatPayloadsTabular returns a tabular summary of payloads.
+func formatPayloadsTabular(value interface{}) ([]byte, error) {
+	infos, ok := value.([]payloadInfo)
+	if !ok {
+		return nil, errors.Errorf("expected value of type %T, got %T", infos, value)
+	}
+	var out bytes.Buffer
+	tw := tabwriter.NewWriter(&out, 0, 1, 1, ' ', 0)
+	p := func(values ...string) {
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	p("UNIT", "MACHINE", "NAME", "TYPE", "ID", "STATUS", "LABELS")
+	for _, info := range infos {
+		p(info.Unit, info.Machine, info.Name, info.Type, info.ID, info.Status, strings.Join(info.Labels, " "))
+	}
+	tw.Flush()
+	return out.Bytes(), nil
+}
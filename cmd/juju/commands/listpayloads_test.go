@@ -0,0 +1,107 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+)
+
+type ListPayloadsSuite struct {
+	testing.FakeJujuHomeSuite
+	mock *mockListPayloadsAPI
+}
+
+var _ = gc.Suite(&ListPayloadsSuite{})
+
+func (s *ListPayloadsSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.mock = &mockListPayloadsAPI{
+		payloads: []params.Payload{{
+			Name:    "web",
+			Type:    "docker",
+			ID:      "abc123",
+			Status:  "running",
+			Labels:  []string{"frontend", "blue"},
+			Unit:    "wordpress/0",
+			Machine: "1",
+		}, {
+			Name:    "db",
+			Type:    "process",
+			ID:      "4242",
+			Status:  "stopped",
+			Unit:    "mysql/0",
+			Machine: "2",
+		}},
+	}
+	s.PatchValue(&getListPayloadsAPI, func(_ *ListPayloadsCommand) (ListPayloadsAPI, error) {
+		return s.mock, nil
+	})
+}
+
+func (s *ListPayloadsSuite) TestTabular(c *gc.C) {
+	context, err := testing.RunCommand(c, envcmd.Wrap(&ListPayloadsCommand{}))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"UNIT        MACHINE NAME TYPE    ID     STATUS  LABELS\n"+
+		"wordpress/0 1       web  docker  abc123 running frontend blue\n"+
+		"mysql/0     2       db   process 4242   stopped \n")
+	c.Assert(s.mock.patterns, gc.HasLen, 0)
+}
+
+func (s *ListPayloadsSuite) TestYaml(c *gc.C) {
+	context, err := testing.RunCommand(c, envcmd.Wrap(&ListPayloadsCommand{}), "--format", "yaml", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mock.patterns, jc.DeepEquals, []string{"mysql"})
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"- unit: wordpress/0\n"+
+		"  machine: \"1\"\n"+
+		"  name: web\n"+
+		"  type: docker\n"+
+		"  id: abc123\n"+
+		"  status: running\n"+
+		"  labels:\n"+
+		"  - frontend\n"+
+		"  - blue\n"+
+		"- unit: mysql/0\n"+
+		"  machine: \"2\"\n"+
+		"  name: db\n"+
+		"  type: process\n"+
+		"  id: \"4242\"\n"+
+		"  status: stopped\n")
+}
+
+func (s *ListPayloadsSuite) TestNoPayloads(c *gc.C) {
+	s.mock.payloads = nil
+	context, err := testing.RunCommand(c, envcmd.Wrap(&ListPayloadsCommand{}), "nothing")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, "")
+	c.Assert(testing.Stderr(context), gc.Equals, "no payloads found\n")
+}
+
+func (s *ListPayloadsSuite) TestError(c *gc.C) {
+	s.mock.err = errors.New("boom")
+	_, err := testing.RunCommand(c, envcmd.Wrap(&ListPayloadsCommand{}))
+	c.Assert(err, gc.ErrorMatches, "boom")
+}
+
+type mockListPayloadsAPI struct {
+	payloads []params.Payload
+	patterns []string
+	err      error
+}
+
+func (m *mockListPayloadsAPI) ListPayloads(patterns ...string) ([]params.Payload, error) {
+	m.patterns = patterns
+	return m.payloads, m.err
+}
+
+func (*mockListPayloadsAPI) Close() error {
+	return nil
+}
@@ -17,10 +17,13 @@ import (
 	"github.com/juju/juju/cmd/juju/block"
 	"github.com/juju/juju/cmd/juju/cachedimages"
 	"github.com/juju/juju/cmd/juju/common"
+	"github.com/juju/juju/cmd/juju/controller"
 	"github.com/juju/juju/cmd/juju/environment"
 	"github.com/juju/juju/cmd/juju/machine"
 	"github.com/juju/juju/cmd/juju/service"
+	"github.com/juju/juju/cmd/juju/space"
 	"github.com/juju/juju/cmd/juju/storage"
+	"github.com/juju/juju/cmd/juju/subnet"
 	"github.com/juju/juju/cmd/juju/user"
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/juju"
@@ -128,6 +131,7 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&EndpointCommand{}))
 	r.Register(wrapEnvCommand(&APIInfoCommand{}))
 	r.Register(wrapEnvCommand(&StatusHistoryCommand{}))
+	r.Register(wrapEnvCommand(&ListPayloadsCommand{}))
 
 	// Error resolution and debugging commands.
 	r.Register(wrapEnvCommand(&RunCommand{}))
@@ -136,6 +140,7 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&ResolvedCommand{}))
 	r.Register(wrapEnvCommand(&DebugLogCommand{}))
 	r.Register(wrapEnvCommand(&DebugHooksCommand{}))
+	r.Register(wrapEnvCommand(&HookTranscriptsCommand{}))
 
 	// Configuration commands.
 	r.Register(&InitCommand{})
@@ -148,6 +153,7 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&UnexposeCommand{}))
 	r.Register(wrapEnvCommand(&UpgradeJujuCommand{}))
 	r.Register(wrapEnvCommand(&UpgradeCharmCommand{}))
+	r.Register(wrapEnvCommand(&PushResourceCommand{}))
 
 	// Charm publishing commands.
 	r.Register(wrapEnvCommand(&PublishCommand{}))
@@ -189,6 +195,9 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 
 	// Manage state server availability
 	r.Register(wrapEnvCommand(&EnsureAvailabilityCommand{}))
+	r.Register(wrapEnvCommand(&ControllerHealthCommand{}))
+	r.Register(wrapEnvCommand(&RotateCertificatesCommand{}))
+	r.Register(controller.NewSuperCommand())
 
 	// Manage and control services
 	r.Register(service.NewSuperCommand())
@@ -203,6 +212,10 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 
 	// Manage storage
 	r.Register(storage.NewSuperCommand())
+
+	// Manage network spaces and subnets
+	r.Register(space.NewSuperCommand())
+	r.Register(subnet.NewSuperCommand())
 }
 
 // envCmdWrapper is a struct that wraps an environment command and lets us handle
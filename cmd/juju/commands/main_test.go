@@ -200,6 +200,8 @@ var commandNames = []string{
 	"block",
 	"bootstrap",
 	"cached-images",
+	"controller",
+	"controller-health",
 	"debug-hooks",
 	"debug-log",
 	"deploy",
@@ -219,15 +221,19 @@ var commandNames = []string{
 	"get-environment",
 	"help",
 	"help-tool",
+	"hook-transcripts",
 	"init",
+	"list-payloads",
 	"machine",
 	"publish",
+	"push-resource",
 	"remove-machine",  // alias for destroy-machine
 	"remove-relation", // alias for destroy-relation
 	"remove-service",  // alias for destroy-service
 	"remove-unit",     // alias for destroy-unit
 	"resolved",
 	"retry-provisioning",
+	"rotate-certificates",
 	"run",
 	"scp",
 	"service",
@@ -235,11 +241,13 @@ var commandNames = []string{
 	"set-constraints",
 	"set-env", // alias for set-environment
 	"set-environment",
+	"space",
 	"ssh",
 	"stat", // alias for status
 	"status",
 	"status-history",
 	"storage",
+	"subnet",
 	"switch",
 	"sync-tools",
 	"terminate-machine", // alias for destroy-machine
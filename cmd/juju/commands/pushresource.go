@@ -0,0 +1,122 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/api"
+	"github.com/juju/juju/api/resources"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/block"
+	"github.com/juju/juju/resource"
+)
+
+const pushResourceDoc = `
+Uploads a new revision of a resource declared in the metadata of a
+service's charm. Units of the service are notified of the new revision
+through the config-changed hook, and can fetch it with the resource-get
+hook tool.
+
+Example:
+
+    juju push-resource java jdk ./jdk-8u60-linux-x64.tar.gz
+`
+
+// PushResourceCommand uploads a charm resource for a service.
+type PushResourceCommand struct {
+	envcmd.EnvCommandBase
+	ServiceName  string
+	ResourceName string
+	Filename     string
+}
+
+// Info implements Command.Info.
+func (c *PushResourceCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "push-resource",
+		Args:    "<service> <resource> <file>",
+		Purpose: "upload a charm resource for a service",
+		Doc:     pushResourceDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *PushResourceCommand) Init(args []string) error {
+	if len(args) < 3 {
+		return errors.New("expected a service name, a resource name and a file")
+	}
+	c.ServiceName, c.ResourceName, c.Filename = args[0], args[1], args[2]
+	if !names.IsValidService(c.ServiceName) {
+		return errors.Errorf("invalid service name %q", c.ServiceName)
+	}
+	if !resource.IsValidName(c.ResourceName) {
+		return errors.Errorf("invalid resource name %q", c.ResourceName)
+	}
+	return cmd.CheckEmpty(args[3:])
+}
+
+// PushResourceAPI defines the resources API methods that the
+// push-resource command uses.
+type PushResourceAPI interface {
+	Upload(service, name string, content io.Reader, size int64, sha256 string) (params.Resource, error)
+	Close() error
+}
+
+// resourcesAPI adds a Close method to a resources client.
+type resourcesAPI struct {
+	*resources.Client
+	root *api.State
+}
+
+func (r resourcesAPI) Close() error {
+	return r.root.Close()
+}
+
+var getPushResourceAPI = func(c *PushResourceCommand) (PushResourceAPI, error) {
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return resourcesAPI{resources.NewClient(root), root}, nil
+}
+
+// Run implements Command.Run.
+func (c *PushResourceCommand) Run(ctx *cmd.Context) error {
+	f, err := os.Open(ctx.AbsPath(c.Filename))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, f)
+	if err != nil {
+		return errors.Annotatef(err, "cannot read %q", c.Filename)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return errors.Trace(err)
+	}
+
+	client, err := getPushResourceAPI(c)
+	if err != nil {
+		return fmt.Errorf(connectionError, c.ConnectionName(), err)
+	}
+	defer client.Close()
+
+	result, err := client.Upload(c.ServiceName, c.ResourceName, f, size, hex.EncodeToString(hasher.Sum(nil)))
+	if err != nil {
+		return block.ProcessBlockedError(err, block.BlockChange)
+	}
+	ctx.Infof("uploaded revision %d of resource %q for service %q", result.Revision, result.Name, result.Service)
+	return nil
+}
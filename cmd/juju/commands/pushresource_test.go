@@ -0,0 +1,113 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+)
+
+type PushResourceSuite struct {
+	testing.FakeJujuHomeSuite
+	mock *mockPushResourceAPI
+}
+
+var _ = gc.Suite(&PushResourceSuite{})
+
+func (s *PushResourceSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.mock = &mockPushResourceAPI{}
+	s.PatchValue(&getPushResourceAPI, func(_ *PushResourceCommand) (PushResourceAPI, error) {
+		return s.mock, nil
+	})
+}
+
+func (s *PushResourceSuite) TestInit(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"java", "jdk", "jdk.tar.gz"}, ""},
+		{[]string{"java", "jdk"}, "expected a service name, a resource name and a file"},
+		{[]string{"java/0", "jdk", "jdk.tar.gz"}, `invalid service name "java/0"`},
+		{[]string{"java", "JDK", "jdk.tar.gz"}, `invalid resource name "JDK"`},
+		{[]string{"java", "jdk", "jdk.tar.gz", "extra"}, `unrecognized args: \["extra"\]`},
+	} {
+		c.Logf("test %d: %v", i, t.args)
+		err := testing.InitCommand(envcmd.Wrap(&PushResourceCommand{}), t.args)
+		if t.err == "" {
+			c.Check(err, jc.ErrorIsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, t.err)
+		}
+	}
+}
+
+func (s *PushResourceSuite) TestPushResource(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "jdk.tar.gz")
+	err := ioutil.WriteFile(path, []byte("java"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+
+	context, err := testing.RunCommand(c, envcmd.Wrap(&PushResourceCommand{}), "java", "jdk", path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mock.service, gc.Equals, "java")
+	c.Assert(s.mock.name, gc.Equals, "jdk")
+	c.Assert(s.mock.content, gc.Equals, "java")
+	c.Assert(s.mock.size, gc.Equals, int64(4))
+	c.Assert(s.mock.sha256, gc.Equals, "38a0963a6364b09ad867aa9a66c6d009673c21e182015461da236ec361877f77")
+	c.Assert(s.mock.closed, jc.IsTrue)
+	c.Assert(testing.Stderr(context), gc.Equals, "uploaded revision 3 of resource \"jdk\" for service \"java\"\n")
+}
+
+func (s *PushResourceSuite) TestPushResourceMissingFile(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&PushResourceCommand{}), "java", "jdk", filepath.Join(c.MkDir(), "missing"))
+	c.Assert(err, gc.ErrorMatches, "open .*missing: no such file or directory")
+	c.Assert(s.mock.service, gc.Equals, "")
+}
+
+func (s *PushResourceSuite) TestPushResourceError(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "jdk.tar.gz")
+	err := ioutil.WriteFile(path, []byte("java"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	s.mock.err = errors.New("boom")
+
+	_, err = testing.RunCommand(c, envcmd.Wrap(&PushResourceCommand{}), "java", "jdk", path)
+	c.Assert(err, gc.ErrorMatches, "boom")
+}
+
+type mockPushResourceAPI struct {
+	service string
+	name    string
+	content string
+	size    int64
+	sha256  string
+	closed  bool
+	err     error
+}
+
+func (m *mockPushResourceAPI) Upload(service, name string, content io.Reader, size int64, sha256 string) (params.Resource, error) {
+	if m.err != nil {
+		return params.Resource{}, m.err
+	}
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return params.Resource{}, err
+	}
+	m.service, m.name, m.content, m.size, m.sha256 = service, name, string(data), size, sha256
+	return params.Resource{Service: service, Name: name, Revision: 3}, nil
+}
+
+func (m *mockPushResourceAPI) Close() error {
+	m.closed = true
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/certificatemanager"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/block"
+)
+
+const rotateCertificatesDoc = `
+This command replaces the CA that signs the certificates of the state
+servers, for example because it is about to expire or has been
+compromised. It must be run against the state server environment, and
+affects every environment hosted by the state servers. The rotation takes
+three steps, and each run of the command performs the next one:
+
+ 1. A new CA is generated, and every agent is told to trust both the old
+    and the new CA.
+ 2. The state servers reissue their certificates, signed by the new CA,
+    and restart their databases to use them.
+ 3. The old CA is retired, and agents trust only the new one.
+
+Each subsequent run fails until every agent has fetched the CA
+certificates trusted by the previous step and, before the old CA is
+retired, until every state server has reissued its certificate; it
+names the agents and state servers holding it up. Use --force to
+advance regardless, for example when an agent is down and will not come
+back; such agents will need their CA certificate updated by hand.
+"juju status" shows the trusted CA certificates, their expiry and the
+phase of the rotation. Each run also updates the CA certificates trusted
+by this client's connection information.
+
+Examples:
+
+    # Start rotating the CA.
+    juju rotate-certificates
+
+    # Advance the rotation even though some agents are unreachable.
+    juju rotate-certificates --force
+
+See Also:
+    juju help status
+`
+
+// RotateCertificatesCommand starts or advances a rotation of the CA
+// that signs the certificates of the state servers.
+type RotateCertificatesCommand struct {
+	envcmd.EnvCommandBase
+	force bool
+}
+
+// Info implements Command.Info.
+func (c *RotateCertificatesCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "rotate-certificates",
+		Purpose: "rotate the CA that signs the state server certificates",
+		Doc:     rotateCertificatesDoc,
+	}
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *RotateCertificatesCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.force, "force", false, "advance the rotation even if some agents have not fetched the trusted CA certificates")
+}
+
+// Init implements Command.Init.
+func (c *RotateCertificatesCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+// RotateCertificatesAPI defines the API methods that the
+// rotate-certificates command uses.
+type RotateCertificatesAPI interface {
+	RotateCertificates(force bool) (params.RotateCertificatesResult, error)
+	Close() error
+}
+
+var getRotateCertificatesAPI = func(c *RotateCertificatesCommand) (RotateCertificatesAPI, error) {
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return certificatemanager.NewClient(root), nil
+}
+
+// rotationMessages describes what each phase of a CA rotation means
+// for the user.
+var rotationMessages = map[string]string{
+	"trusting": "" +
+		"New CA generated; agents will now trust both the old and the new CA.\n" +
+		"Run rotate-certificates again once every agent has been updated.",
+	"reissuing": "" +
+		"State servers are reissuing their certificates with the new CA.\n" +
+		"Run rotate-certificates again to retire the old CA once they have done so.",
+	"finished": "" +
+		"Old CA retired; certificate rotation complete.",
+}
+
+// Run implements Command.Run.
+func (c *RotateCertificatesCommand) Run(ctx *cmd.Context) error {
+	client, err := getRotateCertificatesAPI(c)
+	if err != nil {
+		return fmt.Errorf(connectionError, c.ConnectionName(), err)
+	}
+	defer client.Close()
+
+	result, err := client.RotateCertificates(c.force)
+	if err != nil {
+		return block.ProcessBlockedError(err, block.BlockChange)
+	}
+	if err := c.updateCACert(result.TrustedCACert); err != nil {
+		return errors.Annotate(err, "cannot update connection information")
+	}
+	message, ok := rotationMessages[result.Phase]
+	if !ok {
+		message = fmt.Sprintf("CA rotation is now %s.", result.Phase)
+	}
+	ctx.Infof("%s", message)
+	return nil
+}
+
+// updateCACert records the CA certificates that this client trusts
+// when connecting to the environment.
+func (c *RotateCertificatesCommand) updateCACert(caCert string) error {
+	if caCert == "" {
+		return nil
+	}
+	endpoint, err := c.ConnectionEndpoint(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	endpoint.CACert = caCert
+	writer, err := c.ConnectionWriter()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	writer.SetAPIEndpoint(endpoint)
+	return writer.Write()
+}
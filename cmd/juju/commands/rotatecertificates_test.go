@@ -0,0 +1,135 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/environs/configstore"
+	"github.com/juju/juju/juju/osenv"
+	"github.com/juju/juju/testing"
+)
+
+type RotateCertificatesSuite struct {
+	testing.FakeJujuHomeSuite
+	store configstore.Storage
+	mock  *mockRotateCertificatesAPI
+}
+
+var _ = gc.Suite(&RotateCertificatesSuite{})
+
+func (s *RotateCertificatesSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.store = configstore.NewMem()
+	s.PatchValue(&configstore.Default, func() (configstore.Storage, error) {
+		return s.store, nil
+	})
+	s.PatchEnvironment(osenv.JujuEnvEnvKey, "testing")
+	info := s.store.CreateInfo("testing")
+	info.SetAPIEndpoint(configstore.APIEndpoint{
+		Addresses:   []string{"127.0.0.1:12345"},
+		CACert:      testing.CACert,
+		EnvironUUID: "env-uuid",
+	})
+	err := info.Write()
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.mock = &mockRotateCertificatesAPI{}
+	s.PatchValue(&getRotateCertificatesAPI, func(_ *RotateCertificatesCommand) (RotateCertificatesAPI, error) {
+		return s.mock, nil
+	})
+}
+
+func (s *RotateCertificatesSuite) TestInit(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&RotateCertificatesCommand{}), "extra")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+}
+
+func (s *RotateCertificatesSuite) TestRotateCertificates(c *gc.C) {
+	s.mock.result = params.RotateCertificatesResult{
+		Phase:         "trusting",
+		TrustedCACert: testing.CACert + "new-ca-cert",
+	}
+	context, err := testing.RunCommand(c, envcmd.Wrap(&RotateCertificatesCommand{}))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mock.called, jc.IsTrue)
+	c.Assert(s.mock.force, jc.IsFalse)
+	c.Assert(testing.Stderr(context), gc.Equals, ""+
+		"New CA generated; agents will now trust both the old and the new CA.\n"+
+		"Run rotate-certificates again once every agent has been updated.\n")
+
+	// The client now trusts both CAs.
+	info, err := s.store.ReadInfo("testing")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.APIEndpoint().CACert, gc.Equals, testing.CACert+"new-ca-cert")
+	c.Assert(info.APIEndpoint().Addresses, jc.DeepEquals, []string{"127.0.0.1:12345"})
+}
+
+func (s *RotateCertificatesSuite) TestRotateCertificatesForce(c *gc.C) {
+	s.mock.result = params.RotateCertificatesResult{
+		Phase:         "reissuing",
+		TrustedCACert: testing.CACert + "new-ca-cert",
+	}
+	_, err := testing.RunCommand(c, envcmd.Wrap(&RotateCertificatesCommand{}), "--force")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mock.called, jc.IsTrue)
+	c.Assert(s.mock.force, jc.IsTrue)
+}
+
+func (s *RotateCertificatesSuite) TestRotateCertificatesFinished(c *gc.C) {
+	s.mock.result = params.RotateCertificatesResult{
+		Phase:         "finished",
+		TrustedCACert: "new-ca-cert",
+	}
+	context, err := testing.RunCommand(c, envcmd.Wrap(&RotateCertificatesCommand{}))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stderr(context), gc.Equals, "Old CA retired; certificate rotation complete.\n")
+	info, err := s.store.ReadInfo("testing")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.APIEndpoint().CACert, gc.Equals, "new-ca-cert")
+}
+
+func (s *RotateCertificatesSuite) TestRotateCertificatesError(c *gc.C) {
+	s.mock.err = errors.New("boom")
+	_, err := testing.RunCommand(c, envcmd.Wrap(&RotateCertificatesCommand{}))
+	c.Assert(err, gc.ErrorMatches, "boom")
+	info, err := s.store.ReadInfo("testing")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.APIEndpoint().CACert, gc.Equals, testing.CACert)
+}
+
+func (s *RotateCertificatesSuite) TestRotateCertificatesBlocked(c *gc.C) {
+	s.mock.err = common.ErrOperationBlocked("TestRotateCertificatesBlocked")
+	_, err := testing.RunCommand(c, envcmd.Wrap(&RotateCertificatesCommand{}))
+	c.Assert(err, gc.ErrorMatches, cmd.ErrSilent.Error())
+
+	// msg is logged
+	stripped := strings.Replace(c.GetTestLog(), "\n", "", -1)
+	c.Check(stripped, gc.Matches, ".*TestRotateCertificatesBlocked.*")
+}
+
+type mockRotateCertificatesAPI struct {
+	result params.RotateCertificatesResult
+	called bool
+	force  bool
+	err    error
+}
+
+func (m *mockRotateCertificatesAPI) RotateCertificates(force bool) (params.RotateCertificatesResult, error) {
+	m.called = true
+	m.force = force
+	return m.result, m.err
+}
+
+func (*mockRotateCertificatesAPI) Close() error {
+	return nil
+}
@@ -4,9 +4,12 @@
 package commands
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
+	"sort"
 	"strings"
+	"text/tabwriter"
 	"time"
 	"unicode/utf8"
 
@@ -22,13 +25,19 @@ import (
 // RunCommand is responsible for running arbitrary commands on remote machines.
 type RunCommand struct {
 	envcmd.EnvCommandBase
-	out      cmd.Output
-	all      bool
-	timeout  time.Duration
-	machines []string
-	services []string
-	units    []string
-	commands string
+	out           cmd.Output
+	all           bool
+	timeout       time.Duration
+	machines      []string
+	services      []string
+	units         []string
+	commands      string
+	maxConcurrent int
+	stream        bool
+	onlyFailed    bool
+	aggregateCode bool
+	background    bool
+	attach        string
 }
 
 const runDoc = `
@@ -57,6 +66,29 @@ the unit.
 in the environment.  If you specify --all you cannot provide additional
 targets.
 
+The commands are run on all the targets in parallel, or on no more than
+--max-concurrent of them at once.
+
+With --stream, the result from each target is shown soon after the target
+finishes, rather than once all of them have finished: the commands are run
+as a job on the server, whose new results are fetched every second.
+Streamed results are always written to stdout. In the tabular format, the
+TARGETS column of streamed results is 20 characters wide, so rows for
+longer target names do not line up. With --only-failed, only results from
+targets on which the commands returned a non-zero code or could not be run
+are shown.
+
+The tabular format shows one row for each distinct result, listing all the
+targets that gave it, so that the odd ones out stand out.
+
+With --aggregate-exit-code, juju run exits with the highest return code of
+any target, or with 1 if the commands could not be run on some target.
+
+With --background, the commands are started as a job on the server and juju
+run prints the id of the job and exits. Jobs are also used with --stream.
+Use --attach with the id of a job to see its results, including those that
+arrived while detached; no commands or targets may be given with --attach.
+
 `
 
 func (c *RunCommand) Info() *cmd.Info {
@@ -69,15 +101,38 @@ func (c *RunCommand) Info() *cmd.Info {
 }
 
 func (c *RunCommand) SetFlags(f *gnuflag.FlagSet) {
-	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+	c.out.AddFlags(f, "smart", map[string]cmd.Formatter{
+		"smart":   cmd.FormatSmart,
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": formatRunTabular,
+	})
 	f.BoolVar(&c.all, "all", false, "run the commands on all the machines")
 	f.DurationVar(&c.timeout, "timeout", 5*time.Minute, "how long to wait before the remote command is considered to have failed")
 	f.Var(cmd.NewStringsValue(nil, &c.machines), "machine", "one or more machine ids")
 	f.Var(cmd.NewStringsValue(nil, &c.services), "service", "one or more service names")
 	f.Var(cmd.NewStringsValue(nil, &c.units), "unit", "one or more unit ids")
+	f.IntVar(&c.maxConcurrent, "max-concurrent", 0, "maximum number of targets to run the commands on at once")
+	f.BoolVar(&c.stream, "stream", false, "show the result from each target as soon as it finishes")
+	f.BoolVar(&c.onlyFailed, "only-failed", false, "show only the results from targets that failed")
+	f.BoolVar(&c.aggregateCode, "aggregate-exit-code", false, "exit with the highest return code of any target")
+	f.BoolVar(&c.background, "background", false, "run the commands as a background job and print its id")
+	f.StringVar(&c.attach, "attach", "", "show the results of the background job with this id")
 }
 
 func (c *RunCommand) Init(args []string) error {
+	if c.maxConcurrent < 0 {
+		return fmt.Errorf("invalid --max-concurrent %d", c.maxConcurrent)
+	}
+	if c.attach != "" {
+		if c.all || len(c.machines) != 0 || len(c.services) != 0 || len(c.units) != 0 {
+			return fmt.Errorf("You cannot specify --attach and targets")
+		}
+		if c.background || c.maxConcurrent != 0 {
+			return fmt.Errorf("You cannot specify --attach with --background or --max-concurrent")
+		}
+		return cmd.CheckEmpty(args)
+	}
 	if len(args) == 0 {
 		return fmt.Errorf("no commands specified")
 	}
@@ -179,28 +234,47 @@ func (c *RunCommand) Run(ctx *cmd.Context) error {
 	}
 	defer client.Close()
 
+	// RunOnAllMachines takes no limit on concurrency, so a limited run
+	// on all machines is done as a job.
+	if c.stream || c.background || c.attach != "" || (c.all && c.maxConcurrent != 0) {
+		return c.runJob(ctx, client)
+	}
+
 	var runResults []params.RunResult
 	if c.all {
 		runResults, err = client.RunOnAllMachines(c.commands, c.timeout)
 	} else {
-		params := params.RunParams{
-			Commands: c.commands,
-			Timeout:  c.timeout,
-			Machines: c.machines,
-			Services: c.services,
-			Units:    c.units,
-		}
-		runResults, err = client.Run(params)
+		runResults, err = client.Run(c.runParams())
 	}
 
 	if err != nil {
 		return block.ProcessBlockedError(err, block.BlockChange)
 	}
+	return c.writeResults(ctx, runResults)
+}
+
+// runParams returns the parameters with which to run the commands.
+func (c *RunCommand) runParams() params.RunParams {
+	return params.RunParams{
+		Commands:      c.commands,
+		Timeout:       c.timeout,
+		Machines:      c.machines,
+		Services:      c.services,
+		Units:         c.units,
+		MaxConcurrent: c.maxConcurrent,
+	}
+}
 
+// writeResults writes out the results of running the commands once all
+// the targets have finished.
+func (c *RunCommand) writeResults(ctx *cmd.Context, runResults []params.RunResult) error {
 	// If we are just dealing with one result, AND we are using the smart
 	// format, then pretend we were running it locally.
 	if len(runResults) == 1 && c.out.Name() == "smart" {
 		result := runResults[0]
+		if c.onlyFailed && !runFailed(result) {
+			return nil
+		}
 		ctx.Stdout.Write(result.Stdout)
 		ctx.Stderr.Write(result.Stderr)
 		if result.Error != "" {
@@ -213,10 +287,251 @@ func (c *RunCommand) Run(ctx *cmd.Context) error {
 		return nil
 	}
 
-	c.out.Write(ctx, ConvertRunResults(runResults))
+	shown := c.filterResults(runResults)
+	var value interface{} = shown
+	if c.out.Name() != "tabular" {
+		value = ConvertRunResults(shown)
+	}
+	if err := c.out.Write(ctx, value); err != nil {
+		return err
+	}
+	return c.exitCode(runResults)
+}
+
+// runJob runs the commands as a background job on the server, or
+// attaches to an existing job, and shows its results.
+func (c *RunCommand) runJob(ctx *cmd.Context, client RunClient) error {
+	id := c.attach
+	if id == "" {
+		var err error
+		runParams := c.runParams()
+		if c.all {
+			id, err = client.StartRunOnAllMachines(runParams)
+		} else {
+			id, err = client.StartRun(runParams)
+		}
+		if err != nil {
+			return block.ProcessBlockedError(err, block.BlockChange)
+		}
+		fmt.Fprintf(ctx.Stderr, "Run job %s started; use \"juju run --attach %s\" to see its results\n", id, id)
+		if c.background {
+			fmt.Fprintln(ctx.Stdout, id)
+			return nil
+		}
+	}
+
+	var runResults []params.RunResult
+	headerWritten := false
+	err := watchRunJob(client, id, func(result params.RunResult) error {
+		runResults = append(runResults, result)
+		if !c.stream || !c.showResult(result) {
+			return nil
+		}
+		output, err := c.formatStreamed(result, !headerWritten)
+		if err != nil {
+			return err
+		}
+		headerWritten = true
+		_, err = ctx.Stdout.Write(output)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !c.stream {
+		sort.Sort(runResultsByMachine(runResults))
+		return c.writeResults(ctx, runResults)
+	}
+	return c.exitCode(runResults)
+}
+
+// runJobPollInterval holds how often the results of a run job are
+// fetched while waiting for it to finish.
+var runJobPollInterval = time.Second
+
+// watchRunJob calls report with each of the results of the run job with
+// the given id, in the order in which they arrive, until the job is done.
+func watchRunJob(client RunClient, id string, report func(params.RunResult) error) error {
+	seen := 0
+	for {
+		job, err := client.RunJobResults(id, seen)
+		if err != nil {
+			return err
+		}
+		for _, result := range job.Results {
+			if err := report(result); err != nil {
+				return err
+			}
+		}
+		seen += len(job.Results)
+		if job.Error != "" {
+			return fmt.Errorf("run job %s failed: %s", id, job.Error)
+		}
+		if job.Done {
+			return nil
+		}
+		<-time.After(runJobPollInterval)
+	}
+}
+
+// formatStreamed formats a single result for writing out as soon as it
+// arrives. The formatted results can be concatenated: yaml results form
+// a list, and json results are written one to a line.
+func (c *RunCommand) formatStreamed(result params.RunResult, first bool) ([]byte, error) {
+	var output []byte
+	var err error
+	switch c.out.Name() {
+	case "tabular":
+		output, err = formatRunTable([]params.RunResult{result}, first, false)
+	case "json":
+		output, err = cmd.FormatJson(ConvertRunResults([]params.RunResult{result}).([]interface{})[0])
+		output = append(output, '\n')
+	default:
+		output, err = cmd.FormatYaml(ConvertRunResults([]params.RunResult{result}))
+	}
+	return output, err
+}
+
+// filterResults returns the results that should be shown.
+func (c *RunCommand) filterResults(runResults []params.RunResult) []params.RunResult {
+	shown := []params.RunResult{}
+	for _, result := range runResults {
+		if c.showResult(result) {
+			shown = append(shown, result)
+		}
+	}
+	return shown
+}
+
+func (c *RunCommand) showResult(result params.RunResult) bool {
+	return !c.onlyFailed || runFailed(result)
+}
+
+// exitCode returns the error with which juju run should exit, given the
+// results from all the targets.
+func (c *RunCommand) exitCode(runResults []params.RunResult) error {
+	if !c.aggregateCode {
+		return nil
+	}
+	code := 0
+	for _, result := range runResults {
+		if result.Code > code {
+			code = result.Code
+		} else if result.Error != "" && code == 0 {
+			code = 1
+		}
+	}
+	if code != 0 {
+		return cmd.NewRcPassthroughError(code)
+	}
 	return nil
 }
 
+// runFailed returns whether the commands failed on the result's target.
+func runFailed(result params.RunResult) bool {
+	return result.Code != 0 || result.Error != ""
+}
+
+// runResultTarget returns the name of the unit or machine that gave the
+// result.
+func runResultTarget(result params.RunResult) string {
+	if result.UnitId != "" {
+		return result.UnitId
+	}
+	return result.MachineId
+}
+
+// formatRunTabular returns a table of the given run results, with one
+// row for each distinct result.
+func formatRunTabular(value interface{}) ([]byte, error) {
+	runResults, ok := value.([]params.RunResult)
+	if !ok {
+		return nil, fmt.Errorf("expected value of type %T, got %T", runResults, value)
+	}
+	return formatRunTable(runResults, true, true)
+}
+
+// streamedTargetsWidth holds the width of the TARGETS column of tables
+// written a row at a time, for which the width of later rows cannot be
+// known.
+const streamedTargetsWidth = 20
+
+// formatRunTable returns a table of the given run results, with a header
+// if requested. If group is set, the targets that gave identical results
+// share a row. Otherwise the table is taken to be one of a series written
+// a row at a time, and the TARGETS column is given a fixed width so that
+// the rows of the series line up unless a target name is wider.
+func formatRunTable(runResults []params.RunResult, header, group bool) ([]byte, error) {
+	type row struct {
+		targets []string
+		result  params.RunResult
+	}
+	var rows []*row
+	byOutput := make(map[string]*row)
+	for _, result := range runResults {
+		key := fmt.Sprintf("%d\x00%s\x00%s\x00%s", result.Code, result.Error, result.Stdout, result.Stderr)
+		if r, ok := byOutput[key]; ok && group {
+			r.targets = append(r.targets, runResultTarget(result))
+			continue
+		}
+		r := &row{targets: []string{runResultTarget(result)}, result: result}
+		byOutput[key] = r
+		rows = append(rows, r)
+	}
+
+	var out bytes.Buffer
+	const (
+		// To format things into columns.
+		minwidth = 0
+		tabwidth = 1
+		padding  = 2
+		padchar  = ' '
+		flags    = 0
+	)
+	tw := tabwriter.NewWriter(&out, minwidth, tabwidth, padding, padchar, flags)
+	// The header is always laid out, so that rows streamed without it
+	// line up with it; tabwriter only sizes columns within one table,
+	// so streamed tables are padded to a fixed width.
+	targetsHeader := "TARGETS"
+	if !group {
+		targetsHeader = fmt.Sprintf("%-*s", streamedTargetsWidth, targetsHeader)
+	}
+	fmt.Fprintf(tw, "%s\tCODE\tOUTPUT\n", targetsHeader)
+	for _, r := range rows {
+		code := fmt.Sprint(r.result.Code)
+		var lines []string
+		if r.result.Error != "" {
+			code = "-"
+			lines = append(lines, "error: "+r.result.Error)
+		}
+		for _, output := range [][]byte{r.result.Stdout, r.result.Stderr} {
+			if text, _ := encodeBytes(output); strings.TrimSpace(text) != "" {
+				lines = append(lines, strings.Split(strings.TrimRight(text, "\n"), "\n")...)
+			}
+		}
+		if len(lines) == 0 {
+			lines = []string{""}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", strings.Join(r.targets, ","), code, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(tw, "\t\t%s\n", line)
+		}
+	}
+	tw.Flush()
+	if !header {
+		out.ReadBytes('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// runResultsByMachine sorts run results by machine id, as the API server
+// does for results it returns all at once.
+type runResultsByMachine []params.RunResult
+
+func (a runResultsByMachine) Len() int           { return len(a) }
+func (a runResultsByMachine) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a runResultsByMachine) Less(i, j int) bool { return a[i].MachineId < a[j].MachineId }
+
 // In order to be able to easily mock out the API side for testing,
 // the API client is got using a function.
 
@@ -224,6 +539,9 @@ type RunClient interface {
 	Close() error
 	RunOnAllMachines(commands string, timeout time.Duration) ([]params.RunResult, error)
 	Run(run params.RunParams) ([]params.RunResult, error)
+	StartRun(run params.RunParams) (string, error)
+	StartRunOnAllMachines(run params.RunParams) (string, error)
+	RunJobResults(id string, skip int) (params.RunJobResults, error)
 }
 
 // Here we need the signature to be correct for the interface.
@@ -375,6 +375,156 @@ func (s *RunSuite) TestSingleResponse(c *gc.C) {
 	}
 }
 
+func (*RunSuite) TestJobArgParsing(c *gc.C) {
+	for i, test := range []struct {
+		message  string
+		args     []string
+		errMatch string
+	}{{
+		message:  "negative max concurrent",
+		args:     []string{"--max-concurrent=-1", "--all", "hostname"},
+		errMatch: "invalid --max-concurrent -1",
+	}, {
+		message:  "attach with targets",
+		args:     []string{"--attach=1", "--machine=0"},
+		errMatch: "You cannot specify --attach and targets",
+	}, {
+		message:  "attach with background",
+		args:     []string{"--attach=1", "--background"},
+		errMatch: "You cannot specify --attach with --background or --max-concurrent",
+	}, {
+		message:  "attach with commands",
+		args:     []string{"--attach=1", "hostname"},
+		errMatch: `unrecognized args: \["hostname"\]`,
+	}, {
+		message: "attach",
+		args:    []string{"--attach=1"},
+	}} {
+		c.Log(fmt.Sprintf("%v: %s", i, test.message))
+		testing.TestInit(c, envcmd.Wrap(&RunCommand{}), test.args, test.errMatch)
+	}
+}
+
+func (s *RunSuite) setupFailingMachines() *mockRunAPI {
+	mock := s.setupMockAPI()
+	mock.setMachinesAlive("0", "1", "2", "3")
+	mock.setResponse("0", mockResponse{stdout: "ok\n", machineId: "0"})
+	mock.setResponse("1", mockResponse{stdout: "ok\n", machineId: "1"})
+	mock.setResponse("2", mockResponse{stderr: "disk full\n", code: 2, machineId: "2"})
+	return mock
+}
+
+func (s *RunSuite) TestOnlyFailed(c *gc.C) {
+	s.setupFailingMachines()
+	expected, err := cmd.FormatJson(ConvertRunResults([]params.RunResult{
+		makeRunResult(mockResponse{stderr: "disk full\n", code: 2, machineId: "2"}),
+		makeRunResult(mockResponse{error: "command timed out", machineId: "3"}),
+	}))
+	c.Assert(err, jc.ErrorIsNil)
+
+	context, err := testing.RunCommand(c, &RunCommand{}, "--format=json", "--only-failed", "--all", "df")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(testing.Stdout(context), gc.Equals, string(expected)+"\n")
+}
+
+func (s *RunSuite) TestOnlyFailedSingleResponse(c *gc.C) {
+	mock := s.setupMockAPI()
+	mock.setMachinesAlive("0")
+	mock.setResponse("0", mockResponse{stdout: "ok\n", machineId: "0"})
+
+	context, err := testing.RunCommand(c, &RunCommand{}, "--only-failed", "--all", "df")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(testing.Stdout(context), gc.Equals, "")
+}
+
+func (s *RunSuite) TestTabular(c *gc.C) {
+	s.setupFailingMachines()
+	context, err := testing.RunCommand(c, &RunCommand{}, "--format=tabular", "--all", "df")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(testing.Stdout(context), gc.Equals, ""+
+		"TARGETS  CODE  OUTPUT\n"+
+		"0,1      0     ok\n"+
+		"2        2     disk full\n"+
+		"3        -     error: command timed out\n"+
+		"\n")
+}
+
+func (s *RunSuite) TestAggregateExitCode(c *gc.C) {
+	s.setupFailingMachines()
+	_, err := testing.RunCommand(c, &RunCommand{}, "--format=json", "--aggregate-exit-code", "--all", "df")
+	c.Check(err, gc.ErrorMatches, "subprocess encountered error code 2")
+
+	mock := s.setupMockAPI()
+	mock.setMachinesAlive("0", "1")
+	mock.setResponse("0", mockResponse{stdout: "ok\n", machineId: "0"})
+	_, err = testing.RunCommand(c, &RunCommand{}, "--format=json", "--aggregate-exit-code", "--all", "df")
+	c.Check(err, gc.ErrorMatches, "subprocess encountered error code 1")
+
+	mock.setResponse("1", mockResponse{stdout: "ok\n", machineId: "1"})
+	_, err = testing.RunCommand(c, &RunCommand{}, "--format=json", "--aggregate-exit-code", "--all", "df")
+	c.Check(err, jc.ErrorIsNil)
+}
+
+func (s *RunSuite) TestStream(c *gc.C) {
+	s.PatchValue(&runJobPollInterval, time.Duration(0))
+	mock := s.setupFailingMachines()
+	context, err := testing.RunCommand(c, &RunCommand{}, "--format=json", "--stream", "--only-failed", "--max-concurrent=2", "--all", "df")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(mock.jobParams, jc.DeepEquals, []params.RunParams{{
+		Commands:      "df",
+		Timeout:       5 * time.Minute,
+		MaxConcurrent: 2,
+	}})
+	c.Check(mock.jobQueries, jc.DeepEquals, []int{0, 1, 2, 3})
+	c.Check(testing.Stdout(context), gc.Equals, ""+
+		`{"MachineId":"2","ReturnCode":2,"Stderr":"disk full\n","Stdout":""}`+"\n"+
+		`{"Error":"command timed out","MachineId":"3","Stdout":""}`+"\n")
+	c.Check(testing.Stderr(context), gc.Equals, `Run job 1 started; use "juju run --attach 1" to see its results`+"\n")
+}
+
+func (s *RunSuite) TestStreamTabular(c *gc.C) {
+	s.PatchValue(&runJobPollInterval, time.Duration(0))
+	s.setupFailingMachines()
+	context, err := testing.RunCommand(c, &RunCommand{}, "--format=tabular", "--stream", "--machine=0,2", "df")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(testing.Stdout(context), gc.Equals, ""+
+		"TARGETS               CODE  OUTPUT\n"+
+		"0                     0     ok\n"+
+		"2                     2     disk full\n")
+}
+
+func (s *RunSuite) TestBackgroundAndAttach(c *gc.C) {
+	s.PatchValue(&runJobPollInterval, time.Duration(0))
+	mock := s.setupFailingMachines()
+	context, err := testing.RunCommand(c, &RunCommand{}, "--background", "--machine=1,2", "df")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(testing.Stdout(context), gc.Equals, "1\n")
+	c.Check(mock.jobQueries, gc.HasLen, 0)
+
+	context, err = testing.RunCommand(c, &RunCommand{}, "--attach=1", "--format=tabular", "--aggregate-exit-code")
+	c.Check(err, gc.ErrorMatches, "subprocess encountered error code 2")
+	c.Check(testing.Stdout(context), gc.Equals, ""+
+		"TARGETS  CODE  OUTPUT\n"+
+		"1        0     ok\n"+
+		"2        2     disk full\n"+
+		"\n")
+	c.Check(testing.Stderr(context), gc.Equals, "")
+
+	_, err = testing.RunCommand(c, &RunCommand{}, "--attach=42")
+	c.Check(err, gc.ErrorMatches, "run job 42 not found")
+}
+
+func (s *RunSuite) TestAttachFailedJob(c *gc.C) {
+	s.PatchValue(&runJobPollInterval, time.Duration(0))
+	mock := s.setupFailingMachines()
+	_, err := testing.RunCommand(c, &RunCommand{}, "--background", "--machine=1,2", "df")
+	c.Assert(err, jc.ErrorIsNil)
+	mock.jobErrors = map[string]string{"1": "interrupted by API server restart"}
+
+	_, err = testing.RunCommand(c, &RunCommand{}, "--attach=1")
+	c.Check(err, gc.ErrorMatches, "run job 1 failed: interrupted by API server restart")
+}
+
 func (s *RunSuite) setupMockAPI() *mockRunAPI {
 	mock := &mockRunAPI{}
 	s.PatchValue(&getRunAPIClient, func(_ *RunCommand) (RunClient, error) {
@@ -391,6 +541,12 @@ type mockRunAPI struct {
 	machines  map[string]bool
 	responses map[string]params.RunResult
 	block     bool
+	// jobs holds the results of each run job, which are returned
+	// one at a time by RunJobResults.
+	jobs       map[string][]params.RunResult
+	jobErrors  map[string]string
+	jobParams  []params.RunParams
+	jobQueries []int
 }
 
 type mockResponse struct {
@@ -484,3 +640,42 @@ func (m *mockRunAPI) Run(runParams params.RunParams) ([]params.RunResult, error)
 
 	return result, nil
 }
+
+func (m *mockRunAPI) startJob(results []params.RunResult, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	if m.jobs == nil {
+		m.jobs = make(map[string][]params.RunResult)
+	}
+	id := fmt.Sprint(len(m.jobs) + 1)
+	m.jobs[id] = results
+	return id, nil
+}
+
+func (m *mockRunAPI) StartRun(runParams params.RunParams) (string, error) {
+	m.jobParams = append(m.jobParams, runParams)
+	return m.startJob(m.Run(runParams))
+}
+
+func (m *mockRunAPI) StartRunOnAllMachines(runParams params.RunParams) (string, error) {
+	m.jobParams = append(m.jobParams, runParams)
+	return m.startJob(m.RunOnAllMachines(runParams.Commands, runParams.Timeout))
+}
+
+func (m *mockRunAPI) RunJobResults(id string, skip int) (params.RunJobResults, error) {
+	m.jobQueries = append(m.jobQueries, skip)
+	results, found := m.jobs[id]
+	if !found {
+		return params.RunJobResults{}, fmt.Errorf("run job %s not found", id)
+	}
+	job := params.RunJobResults{Id: id, Targets: len(results)}
+	if skip < len(results) {
+		job.Results = results[skip : skip+1]
+	}
+	job.Done = skip+len(job.Results) == len(results)
+	if job.Done {
+		job.Error = m.jobErrors[id]
+	}
+	return job, nil
+}
@@ -4,10 +4,12 @@
 package commands
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
@@ -24,9 +26,13 @@ import (
 
 type StatusCommand struct {
 	envcmd.EnvCommandBase
-	out      cmd.Output
-	patterns []string
-	isoTime  bool
+	out       cmd.Output
+	patterns  []string
+	isoTime   bool
+	watch     bool
+	statuses  []string
+	machines  []string
+	relations []string
 }
 
 var statusDoc = `
@@ -46,8 +52,11 @@ There are a number of ways to format the status output:
            - Services: NAME, EXPOSED, CHARM
            - Units: ID, STATE, VERSION, MACHINE, PORTS, PUBLIC-ADDRESS
              - Also displays subordinate units.
-- yaml (DEFAULT): Displays information on machines, services, and units
-                  in the yaml format.
+           - Certificates: SUBJECT, EXPIRES, MESSAGE
+             - Only displayed while a CA rotation is in progress or a
+               CA certificate expires within 30 days.
+- yaml (DEFAULT): Displays information on machines, services, units and
+                  the expiry of the CA certificates in the yaml format.
 
 Service or unit names may be specified to filter the status to only those
 services and units that match, along with the related machines, services
@@ -58,6 +67,31 @@ will be displayed.
 Wildcards ('*') may be specified in service/unit names to match any sequence
 of characters. For example, 'nova-*' will match any service whose name begins
 with 'nova-': 'nova-compute', 'nova-volume', etc.
+
+The status can be further restricted to the units, and the machines and
+services they use, that match all of the following filters; each takes
+a comma separated list of values:
+
+    --status      the workload or agent status of the unit or of any of
+                  its subordinates (e.g. --status=error,blocked); machines
+                  with a matching status are shown too
+    --machine     the machine the unit is assigned to, or that hosts the
+                  container it is assigned to (e.g. --machine=1,2)
+    --relation    a relation the unit's service takes part in, given as a
+                  service:relation endpoint or as a relation or interface
+                  name (e.g. --relation=mysql:db,juju-info)
+
+With --watch, the status is shown again, in place, whenever anything in
+the environment changes, until the command is interrupted. Changes to
+machines, services and units already shown are applied to the status
+as they are reported; other changes, and any change when filtering by
+pattern or --status, have the status fetched again, at most once every
+2 seconds.
+
+Examples:
+
+    juju status --status=error,blocked
+    juju status --watch --format=tabular --machine=3
 `
 
 func (c *StatusCommand) Info() *cmd.Info {
@@ -72,6 +106,10 @@ func (c *StatusCommand) Info() *cmd.Info {
 
 func (c *StatusCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.BoolVar(&c.isoTime, "utc", false, "display time as UTC in RFC3339 format")
+	f.BoolVar(&c.watch, "watch", false, "show the status again whenever it changes")
+	f.Var((*cmd.StringsValue)(&c.statuses), "status", "only show units and machines with one of these statuses")
+	f.Var((*cmd.StringsValue)(&c.machines), "machine", "only show units on these machines")
+	f.Var((*cmd.StringsValue)(&c.relations), "relation", "only show units of services in these relations")
 
 	defaultFormat := "yaml"
 	if c.CompatVersion() > 1 {
@@ -112,7 +150,8 @@ Error details:
 `
 
 type statusAPI interface {
-	Status(patterns []string) (*api.Status, error)
+	FilteredStatus(args params.StatusParams) (*api.Status, error)
+	WatchAll() (*api.AllWatcher, error)
 	Close() error
 }
 
@@ -120,6 +159,34 @@ var newApiClientForStatus = func(c *StatusCommand) (statusAPI, error) {
 	return c.NewAPIClient()
 }
 
+// statusWatcher is the part of the AllWatcher used by status --watch.
+type statusWatcher interface {
+	Next() ([]multiwatcher.Delta, error)
+	Stop() error
+}
+
+var newStatusWatcher = func(apiclient statusAPI) (statusWatcher, error) {
+	watcher, err := apiclient.WatchAll()
+	if err != nil {
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// clearScreen moves the cursor home and clears the terminal, so that
+// status --watch redraws the status in place.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// statusRefreshInterval is the minimum time between fetches of the
+// status by status --watch, for changes that cannot be applied to the
+// status already fetched, so that a busy environment does not have
+// the API server build the full status for every change.
+var statusRefreshInterval = 2 * time.Second
+
+// statusWatchSleep is used by status --watch to wait before fetching
+// the status again.
+var statusWatchSleep = time.Sleep
+
 func (c *StatusCommand) Run(ctx *cmd.Context) error {
 
 	apiclient, err := newApiClientForStatus(c)
@@ -128,27 +195,95 @@ func (c *StatusCommand) Run(ctx *cmd.Context) error {
 	}
 	defer apiclient.Close()
 
-	status, err := apiclient.Status(c.patterns)
+	if c.watch {
+		return c.watchStatus(ctx, apiclient)
+	}
+	return c.writeStatus(ctx, apiclient)
+}
+
+// writeStatus fetches the status and writes it to the context.
+func (c *StatusCommand) writeStatus(ctx *cmd.Context, apiclient statusAPI) error {
+	status, err := c.fetchStatus(ctx, apiclient)
+	if err != nil {
+		return err
+	}
+	result := newStatusFormatter(status, c.CompatVersion(), c.isoTime).format()
+	return c.out.Write(ctx, result)
+}
+
+// fetchStatus fetches the status from the API server, writing any
+// error to the context if some status was still returned.
+func (c *StatusCommand) fetchStatus(ctx *cmd.Context, apiclient statusAPI) (*api.Status, error) {
+	status, err := apiclient.FilteredStatus(params.StatusParams{
+		Patterns:  c.patterns,
+		Statuses:  c.statuses,
+		Machines:  c.machines,
+		Relations: c.relations,
+	})
 	if err != nil {
 		if status == nil {
 			// Status call completely failed, there is nothing to report
-			return err
+			return nil, err
 		}
 		// Display any error, but continue to print status if some was returned
 		fmt.Fprintf(ctx.Stderr, "%v\n", err)
 	} else if status == nil {
-		return errors.Errorf("unable to obtain the current status")
+		return nil, errors.Errorf("unable to obtain the current status")
 	}
+	return status, nil
+}
 
-	result := newStatusFormatter(status, c.CompatVersion(), c.isoTime).format()
-	return c.out.Write(ctx, result)
+// watchStatus writes the status, and writes it again in place of the
+// last one each time the environment changes in a way that shows in
+// the output. The changes reported by the watcher are applied to the
+// status last fetched where possible; otherwise the status is fetched
+// again, at most once every statusRefreshInterval.
+func (c *StatusCommand) watchStatus(ctx *cmd.Context, apiclient statusAPI) error {
+	watcher, err := newStatusWatcher(apiclient)
+	if err != nil {
+		return errors.Annotate(err, "cannot watch environment")
+	}
+	defer watcher.Stop()
+
+	model := newStatusModel(len(c.patterns) > 0 || len(c.statuses) > 0)
+	var last []byte
+	var fetched time.Time
+	for {
+		if model.status == nil {
+			if wait := statusRefreshInterval - time.Since(fetched); wait > 0 {
+				statusWatchSleep(wait)
+			}
+			fetched = time.Now()
+			if model.status, err = c.fetchStatus(ctx, apiclient); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		var out bytes.Buffer
+		snapshot := *ctx
+		snapshot.Stdout = &out
+		result := newStatusFormatter(model.status, c.CompatVersion(), c.isoTime).format()
+		if err := c.out.Write(&snapshot, result); err != nil {
+			return errors.Trace(err)
+		}
+		if !bytes.Equal(out.Bytes(), last) {
+			fmt.Fprint(ctx.Stdout, clearScreen)
+			ctx.Stdout.Write(out.Bytes())
+			last = out.Bytes()
+		}
+		deltas, err := watcher.Next()
+		if err != nil {
+			return errors.Annotate(err, "cannot watch environment")
+		}
+		model.apply(deltas)
+	}
 }
 
 type formattedStatus struct {
-	Environment string                   `json:"environment"`
-	Machines    map[string]machineStatus `json:"machines"`
-	Services    map[string]serviceStatus `json:"services"`
-	Networks    map[string]networkStatus `json:"networks,omitempty" yaml:",omitempty"`
+	Environment  string                   `json:"environment"`
+	Machines     map[string]machineStatus `json:"machines"`
+	Services     map[string]serviceStatus `json:"services"`
+	Networks     map[string]networkStatus `json:"networks,omitempty" yaml:",omitempty"`
+	Certificates *certificatesStatus      `json:"certificates,omitempty" yaml:",omitempty"`
 }
 
 type errorStatus struct {
@@ -197,6 +332,7 @@ func (s machineStatus) GetYAML() (tag string, value interface{}) {
 type serviceStatus struct {
 	Err           error                 `json:"-" yaml:",omitempty"`
 	Charm         string                `json:"charm" yaml:"charm"`
+	CharmSigner   string                `json:"charm-signer,omitempty" yaml:"charm-signer,omitempty"`
 	CanUpgradeTo  string                `json:"can-upgrade-to,omitempty" yaml:"can-upgrade-to,omitempty"`
 	Exposed       bool                  `json:"exposed" yaml:"exposed"`
 	Life          string                `json:"life,omitempty" yaml:"life,omitempty"`
@@ -242,6 +378,15 @@ type unitStatus struct {
 	OpenedPorts   []string              `json:"open-ports,omitempty" yaml:"open-ports,omitempty"`
 	PublicAddress string                `json:"public-address,omitempty" yaml:"public-address,omitempty"`
 	Subordinates  map[string]unitStatus `json:"subordinates,omitempty" yaml:"subordinates,omitempty"`
+
+	Payloads map[string]payloadStatus `json:"payloads,omitempty" yaml:"payloads,omitempty"`
+}
+
+type payloadStatus struct {
+	Type   string   `json:"type" yaml:"type"`
+	ID     string   `json:"id" yaml:"id"`
+	Status string   `json:"status" yaml:"status"`
+	Labels []string `json:"labels,omitempty" yaml:"labels,omitempty"`
 }
 
 type statusInfoContents struct {
@@ -295,6 +440,17 @@ type networkStatus struct {
 
 type networkStatusNoMarshal networkStatus
 
+type certificatesStatus struct {
+	CA       []caCertificateStatus `json:"ca" yaml:"ca"`
+	Rotation string                `json:"rotation,omitempty" yaml:"rotation,omitempty"`
+}
+
+type caCertificateStatus struct {
+	Subject string `json:"subject" yaml:"subject"`
+	Expires string `json:"expires" yaml:"expires"`
+	Warning string `json:"warning,omitempty" yaml:"warning,omitempty"`
+}
+
 func (n networkStatus) MarshalJSON() ([]byte, error) {
 	if n.Err != nil {
 		return json.Marshal(errorStatus{n.Err.Error()})
@@ -352,6 +508,7 @@ func (sf *statusFormatter) format() formattedStatus {
 		}
 		out.Networks[k] = sf.formatNetwork(n)
 	}
+	out.Certificates = sf.formatCertificates(sf.status.Certificates, time.Now())
 	return out
 }
 
@@ -411,6 +568,7 @@ func (sf *statusFormatter) formatService(name string, service api.ServiceStatus)
 	out := serviceStatus{
 		Err:           service.Err,
 		Charm:         service.Charm,
+		CharmSigner:   service.CharmSigner,
 		Exposed:       service.Exposed,
 		Life:          service.Life,
 		Relations:     service.Relations,
@@ -471,6 +629,17 @@ func (sf *statusFormatter) formatUnit(unit api.UnitStatus, serviceName string) u
 	for k, m := range unit.Subordinates {
 		out.Subordinates[k] = sf.formatUnit(m, serviceName)
 	}
+	if len(unit.Payloads) > 0 {
+		out.Payloads = make(map[string]payloadStatus)
+		for _, p := range unit.Payloads {
+			out.Payloads[p.Name] = payloadStatus{
+				Type:   p.Type,
+				ID:     p.ID,
+				Status: p.Status,
+				Labels: p.Labels,
+			}
+		}
+	}
 	return out
 }
 
@@ -528,6 +697,46 @@ func (sf *statusFormatter) formatNetwork(network api.NetworkStatus) networkStatu
 	}
 }
 
+// caExpiryWarningDays holds how many days before a CA certificate
+// expires status starts warning about it.
+const caExpiryWarningDays = 30
+
+func (sf *statusFormatter) formatCertificates(certificates api.CertificatesStatus, now time.Time) *certificatesStatus {
+	if len(certificates.CACertificates) == 0 {
+		// The API server does not report certificates.
+		return nil
+	}
+	out := &certificatesStatus{
+		Rotation: certificates.RotationPhase,
+	}
+	for _, caCert := range certificates.CACertificates {
+		out.CA = append(out.CA, caCertificateStatus{
+			Subject: caCert.Subject,
+			Expires: formatStatusTime(&caCert.Expiry, sf.isoTime),
+			Warning: caExpiryWarning(caCert.Expiry, now),
+		})
+	}
+	return out
+}
+
+// caExpiryWarning returns a warning if the CA certificate with the
+// given expiry has expired or is about to, and "" otherwise.
+func caExpiryWarning(expiry, now time.Time) string {
+	remaining := expiry.Sub(now)
+	days := int(remaining.Hours() / 24)
+	switch {
+	case remaining <= 0:
+		return "expired; run juju rotate-certificates"
+	case days < 1:
+		return "expires in less than a day; run juju rotate-certificates"
+	case days == 1:
+		return "expires in 1 day; run juju rotate-certificates"
+	case days <= caExpiryWarningDays:
+		return fmt.Sprintf("expires in %d days; run juju rotate-certificates", days)
+	}
+	return ""
+}
+
 func makeHAStatus(hasVote, wantsVote bool) string {
 	var s string
 	switch {
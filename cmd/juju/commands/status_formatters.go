@@ -165,9 +165,36 @@ func FormatTabular(value interface{}) ([]byte, error) {
 	}
 	tw.Flush()
 
+	// Certificates are only shown when they need attention.
+	if certs := fs.Certificates; certs != nil && certificatesNeedAttention(certs) {
+		p("\n[Certificates]")
+		p("SUBJECT\tEXPIRES\tMESSAGE")
+		for _, caCert := range certs.CA {
+			p(caCert.Subject, caCert.Expires, caCert.Warning)
+		}
+		tw.Flush()
+		if certs.Rotation != "" {
+			fmt.Fprintf(&out, "CA rotation in progress: %s\n", certs.Rotation)
+		}
+	}
+
 	return out.Bytes(), nil
 }
 
+// certificatesNeedAttention reports whether a CA rotation is in
+// progress or any CA certificate is close to expiry.
+func certificatesNeedAttention(certs *certificatesStatus) bool {
+	if certs.Rotation != "" {
+		return true
+	}
+	for _, caCert := range certs.CA {
+		if caCert.Warning != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // FormatSummary returns a summary of the current environment
 // including the following information:
 // - Headers:
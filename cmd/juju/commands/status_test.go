@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/juju/cmd"
+	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 	"gopkg.in/juju/charm.v5"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/juju/juju/api"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cert"
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs"
@@ -2180,6 +2182,72 @@ var statusTests = []testCase{
 				},
 			},
 		},
+	), test(
+		"unit with registered payloads",
+		addMachine{machineId: "0", job: state.JobManageEnviron},
+		setAddresses{"0", network.NewAddresses("dummyenv-0.dns")},
+		startAliveMachine{"0"},
+		setMachineStatus{"0", state.StatusStarted, ""},
+		addMachine{machineId: "1", job: state.JobHostUnits},
+		setAddresses{"1", network.NewAddresses("dummyenv-1.dns")},
+		startAliveMachine{"1"},
+		setMachineStatus{"1", state.StatusStarted, ""},
+		addCharm{"mysql"},
+		addService{name: "mysql", charm: "mysql"},
+		setServiceExposed{"mysql", true},
+		addAliveUnit{"mysql", "1"},
+		setUnitCharmURL{"mysql/0", "cs:quantal/mysql-1"},
+		registerPayload{"mysql/0", state.PayloadInfo{
+			Name:   "db",
+			Type:   "docker",
+			ID:     "abc123",
+			Status: state.PayloadRunning,
+			Labels: []string{"primary"},
+		}},
+
+		expect{
+			"payloads are shown for the unit",
+			M{
+				"environment": "dummyenv",
+				"machines": M{
+					"0": machine0,
+					"1": machine1,
+				},
+				"services": M{
+					"mysql": M{
+						"charm":   "cs:quantal/mysql-1",
+						"exposed": true,
+						"service-status": M{
+							"current": "active",
+							"since":   "01 Apr 15 01:23+10:00",
+						},
+						"units": M{
+							"mysql/0": M{
+								"machine":     "1",
+								"agent-state": "started",
+								"workload-status": M{
+									"current": "active",
+									"since":   "01 Apr 15 01:23+10:00",
+								},
+								"agent-status": M{
+									"current": "idle",
+									"since":   "01 Apr 15 01:23+10:00",
+								},
+								"public-address": "dummyenv-1.dns",
+								"payloads": M{
+									"db": M{
+										"type":   "docker",
+										"id":     "abc123",
+										"status": "running",
+										"labels": L{"primary"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	),
 }
 
@@ -2468,6 +2536,18 @@ func (sus setUnitStatus) step(c *gc.C, ctx *context) {
 	c.Assert(err, jc.ErrorIsNil)
 }
 
+type registerPayload struct {
+	unitName string
+	payload  state.PayloadInfo
+}
+
+func (rp registerPayload) step(c *gc.C, ctx *context) {
+	u, err := ctx.st.Unit(rp.unitName)
+	c.Assert(err, jc.ErrorIsNil)
+	err = u.RegisterPayload(rp.payload)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
 type setAgentStatus struct {
 	unitName   string
 	status     state.Status
@@ -2662,6 +2742,10 @@ func (e scopedExpect) step(c *gc.C, ctx *context) {
 		out := substituteFakeSinceTime(c, stdout, ctx.expectIsoTime)
 		err = format.unmarshal(out, &actual)
 		c.Assert(err, jc.ErrorIsNil)
+		// The expiry of the test CA depends on when it was generated,
+		// so certificates are checked by TestStatusCertificates.
+		c.Assert(actual["certificates"], gc.NotNil)
+		delete(actual, "certificates")
 		c.Assert(actual, jc.DeepEquals, expected)
 	}
 }
@@ -2684,7 +2768,8 @@ func (s *StatusSuite) TestStatusAllFormats(c *gc.C) {
 
 type fakeApiClient struct {
 	statusReturn *api.Status
-	patternsUsed []string
+	statusArgs   params.StatusParams
+	statusCalls  int
 	closeCalled  bool
 }
 
@@ -2694,11 +2779,16 @@ func newFakeApiClient(statusReturn *api.Status) fakeApiClient {
 	}
 }
 
-func (a *fakeApiClient) Status(patterns []string) (*api.Status, error) {
-	a.patternsUsed = patterns
+func (a *fakeApiClient) FilteredStatus(args params.StatusParams) (*api.Status, error) {
+	a.statusArgs = args
+	a.statusCalls++
 	return a.statusReturn, nil
 }
 
+func (a *fakeApiClient) WatchAll() (*api.AllWatcher, error) {
+	return nil, errors.NotImplementedf("WatchAll")
+}
+
 func (a *fakeApiClient) Close() error {
 	a.closeCalled = true
 	return nil
@@ -3117,6 +3207,115 @@ func (s *StatusSuite) TestFormatTabularHookActionName(c *gc.C) {
 	)
 }
 
+func (s *StatusSuite) TestStatusCertificates(c *gc.C) {
+	ctx := s.newContext(c)
+	defer s.resetContext(c, ctx)
+	expiry := time.Now().AddDate(0, 0, 10).Add(12 * time.Hour)
+	newCACert, newCAKey, err := cert.NewCA("new", expiry)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ctx.st.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, jc.ErrorIsNil)
+
+	code, stdout, stderr := runStatus(c, "--format", "yaml", "--utc")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(string(stderr), gc.Equals, "")
+	var actual struct {
+		Certificates certificatesStatus
+	}
+	err = goyaml.Unmarshal(stdout, &actual)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(actual.Certificates, jc.DeepEquals, certificatesStatus{
+		CA: []caCertificateStatus{{
+			Subject: coretesting.CACertX509.Subject.CommonName,
+			Expires: coretesting.CACertX509.NotAfter.UTC().Format("2006-01-02 15:04:05Z"),
+		}, {
+			Subject: `juju-generated CA for environment "new"`,
+			Expires: expiry.UTC().Format("2006-01-02 15:04:05Z"),
+			Warning: "expires in 10 days; run juju rotate-certificates",
+		}},
+		Rotation: "trusting",
+	})
+}
+
+func (s *StatusSuite) TestFormatTabularCertificates(c *gc.C) {
+	status := formattedStatus{
+		Certificates: &certificatesStatus{
+			CA: []caCertificateStatus{{
+				Subject: "old-ca",
+				Expires: "2015-10-01 00:00:00Z",
+				Warning: "expires in 3 days; run juju rotate-certificates",
+			}, {
+				Subject: "new-ca",
+				Expires: "2025-09-28 00:00:00Z",
+			}},
+			Rotation: "trusting",
+		},
+	}
+	out, err := FormatTabular(status)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(
+		string(out),
+		gc.Equals,
+		"[Services] \n"+
+			"NAME       STATUS EXPOSED CHARM \n"+
+			"\n"+
+			"[Units] \n"+
+			"ID      STATE VERSION MACHINE PORTS PUBLIC-ADDRESS \n"+
+			"\n"+
+			"[Machines] \n"+
+			"ID         STATE VERSION DNS INS-ID SERIES HARDWARE \n"+
+			"\n"+
+			"[Certificates] \n"+
+			"SUBJECT        EXPIRES              MESSAGE                                         \n"+
+			"old-ca         2015-10-01 00:00:00Z expires in 3 days; run juju rotate-certificates \n"+
+			"new-ca         2025-09-28 00:00:00Z                                                 \n"+
+			"CA rotation in progress: trusting\n",
+	)
+}
+
+func (s *StatusSuite) TestFormatTabularCertificatesNotShownWhenHealthy(c *gc.C) {
+	status := formattedStatus{
+		Certificates: &certificatesStatus{
+			CA: []caCertificateStatus{{
+				Subject: "ca",
+				Expires: "2025-09-28 00:00:00Z",
+			}},
+		},
+	}
+	out, err := FormatTabular(status)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(out), gc.Not(jc.Contains), "[Certificates]")
+}
+
+func (s *StatusSuite) TestCAExpiryWarning(c *gc.C) {
+	now := time.Date(2015, 9, 1, 0, 0, 0, 0, time.UTC)
+	for i, test := range []struct {
+		expiry  time.Time
+		warning string
+	}{{
+		expiry:  now.AddDate(1, 0, 0),
+		warning: "",
+	}, {
+		expiry:  now.AddDate(0, 0, 31),
+		warning: "",
+	}, {
+		expiry:  now.AddDate(0, 0, 30),
+		warning: "expires in 30 days; run juju rotate-certificates",
+	}, {
+		expiry:  now.AddDate(0, 0, 1),
+		warning: "expires in 1 day; run juju rotate-certificates",
+	}, {
+		expiry:  now.Add(time.Hour),
+		warning: "expires in less than a day; run juju rotate-certificates",
+	}, {
+		expiry:  now,
+		warning: "expired; run juju rotate-certificates",
+	}} {
+		c.Logf("test %d: %v", i, test.expiry)
+		c.Check(caExpiryWarning(test.expiry, now), gc.Equals, test.warning)
+	}
+}
+
 func (s *StatusSuite) TestStatusWithNilStatusApi(c *gc.C) {
 	ctx := s.newContext(c)
 	defer s.resetContext(c, ctx)
@@ -3132,8 +3331,8 @@ func (s *StatusSuite) TestStatusWithNilStatusApi(c *gc.C) {
 	}
 
 	client := fakeApiClient{}
-	var status = client.Status
-	s.PatchValue(&status, func(_ []string) (*api.Status, error) {
+	var status = client.FilteredStatus
+	s.PatchValue(&status, func(_ params.StatusParams) (*api.Status, error) {
 		return nil, nil
 	})
 	s.PatchValue(&newApiClientForStatus, func(_ *StatusCommand) (statusAPI, error) {
@@ -3445,6 +3644,223 @@ func (s *StatusSuite) TestFilterMultipleHeterogenousPatterns(c *gc.C) {
 	c.Assert(string(stdout), gc.Equals, expected[1:])
 }
 
+// Scenario: One unit is in an errored state and user filters on status
+func (s *StatusSuite) TestFilterByStatusFlag(c *gc.C) {
+	ctx := s.FilteringTestSetup(c)
+	defer s.resetContext(c, ctx)
+
+	// Given unit 1 of the "logging" service has an error
+	setAgentStatus{"logging/1", state.StatusError, "mock error", nil}.step(c, ctx)
+	// When I run juju status --format oneline --status error,blocked
+	_, stdout, stderr := runStatus(c, "--format", "oneline", "--status", "error,blocked")
+	c.Assert(stderr, gc.IsNil)
+	// Then I should receive output prefixed with:
+	const expected = `
+
+- mysql/0: dummyenv-2.dns (started)
+  - logging/1: dummyenv-2.dns (error)
+`
+
+	c.Assert(string(stdout), gc.Equals, expected[1:])
+}
+
+func (s *StatusSuite) TestFilterByInvalidStatusFlag(c *gc.C) {
+	ctx := s.FilteringTestSetup(c)
+	defer s.resetContext(c, ctx)
+
+	code, _, stderr := runStatus(c, "--format", "oneline", "--status", "bogus")
+	c.Check(code, gc.Equals, 1)
+	c.Check(string(stderr), gc.Equals, "error: could not filter status: status \"bogus\" not valid\n")
+}
+
+// Scenario: User filters to the units on a machine
+func (s *StatusSuite) TestFilterByMachineFlag(c *gc.C) {
+	ctx := s.FilteringTestSetup(c)
+	defer s.resetContext(c, ctx)
+
+	// When I run juju status --format yaml --machine 1
+	_, stdout, stderr := runStatus(c, "--format", "yaml", "--machine", "1")
+	c.Assert(stderr, gc.IsNil)
+	// Then only machine 1 and the units on it are shown
+	var status map[string]interface{}
+	err := goyaml.Unmarshal(stdout, &status)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(status["machines"], gc.HasLen, 1)
+	c.Assert(status["machines"].(map[interface{}]interface{})["1"], gc.NotNil)
+	services := status["services"].(map[interface{}]interface{})
+	c.Assert(services, gc.HasLen, 2)
+	c.Assert(services["wordpress"], gc.NotNil)
+	c.Assert(services["logging"], gc.NotNil)
+}
+
+// Scenario: User filters to the services in a relation
+func (s *StatusSuite) TestFilterByRelationFlag(c *gc.C) {
+	ctx := s.FilteringTestSetup(c)
+	defer s.resetContext(c, ctx)
+
+	// Given an unrelated "varnish" service has a unit on machine "3"
+	steps := []stepper{
+		addMachine{machineId: "3", job: state.JobHostUnits},
+		startAliveMachine{"3"},
+		setMachineStatus{"3", state.StatusStarted, ""},
+		setAddresses{"3", network.NewAddresses("dummyenv-3.dns")},
+		addCharm{"varnish"},
+		addService{name: "varnish", charm: "varnish"},
+		addAliveUnit{"varnish", "3"},
+		setAgentStatus{"varnish/0", state.StatusIdle, "", nil},
+		setUnitStatus{"varnish/0", state.StatusActive, "", nil},
+	}
+	ctx.run(c, steps)
+	// When I run juju status --format oneline --relation mysql:server
+	_, stdout, stderr := runStatus(c, "--format", "oneline", "--relation", "mysql:server")
+	c.Assert(stderr, gc.IsNil)
+	// Then I should receive output prefixed with:
+	const expected = `
+
+- mysql/0: dummyenv-2.dns (started)
+  - logging/1: dummyenv-2.dns (started)
+- wordpress/0: dummyenv-1.dns (started)
+  - logging/0: dummyenv-1.dns (started)
+`
+
+	c.Assert(string(stdout), gc.Equals, expected[1:])
+}
+
+func (s *StatusSuite) TestFilterFlagsPassedToAPI(c *gc.C) {
+	client := newFakeApiClient(&api.Status{EnvironmentName: "dummyenv"})
+	s.PatchValue(&newApiClientForStatus, func(_ *StatusCommand) (statusAPI, error) {
+		return &client, nil
+	})
+	code, _, _ := runStatus(c,
+		"--status", "error,blocked", "--machine", "1", "--relation", "mysql:db,juju-info", "wordpress",
+	)
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(client.statusArgs, jc.DeepEquals, params.StatusParams{
+		Patterns:  []string{"wordpress"},
+		Statuses:  []string{"error", "blocked"},
+		Machines:  []string{"1"},
+		Relations: []string{"mysql:db", "juju-info"},
+	})
+}
+
+type fakeStatusWatcher struct {
+	deltas  [][]multiwatcher.Delta
+	stopped bool
+}
+
+func (w *fakeStatusWatcher) Next() ([]multiwatcher.Delta, error) {
+	if len(w.deltas) == 0 {
+		return nil, errors.New("watcher stopped")
+	}
+	deltas := w.deltas[0]
+	w.deltas = w.deltas[1:]
+	return deltas, nil
+}
+
+func (w *fakeStatusWatcher) Stop() error {
+	w.stopped = true
+	return nil
+}
+
+func watchTestStatus(machineStatus params.Status, info string, machineIds ...string) *api.Status {
+	status := &api.Status{
+		EnvironmentName: "dummyenv",
+		Machines:        make(map[string]api.MachineStatus),
+	}
+	for _, id := range machineIds {
+		status.Machines[id] = api.MachineStatus{
+			Id:             id,
+			Agent:          api.AgentStatus{Status: machineStatus, Info: info},
+			AgentState:     machineStatus,
+			AgentStateInfo: info,
+			InstanceId:     instance.Id("i-" + id),
+			Series:         "trusty",
+		}
+	}
+	return status
+}
+
+func (s *StatusSuite) TestWatch(c *gc.C) {
+	client := newFakeApiClient(watchTestStatus(params.StatusStarted, "", "0"))
+	s.PatchValue(&newApiClientForStatus, func(_ *StatusCommand) (statusAPI, error) {
+		return &client, nil
+	})
+	_, first, _ := runStatus(c, "--format", "yaml")
+	client.statusReturn = watchTestStatus(params.StatusError, "boom", "0")
+	_, second, _ := runStatus(c, "--format", "yaml")
+	client.statusReturn = watchTestStatus(params.StatusStarted, "", "0", "1")
+	_, third, _ := runStatus(c, "--format", "yaml")
+
+	machineInfo := func(id string, status multiwatcher.Status, info string) *multiwatcher.MachineInfo {
+		return &multiwatcher.MachineInfo{
+			Id:         id,
+			InstanceId: "i-" + id,
+			Status:     status,
+			StatusInfo: info,
+			Life:       "alive",
+			Series:     "trusty",
+		}
+	}
+	// The first changes are the environment as it was when the
+	// watcher started, which the fetched status already shows. A
+	// change to a machine shown is applied to the status, but a new
+	// machine has the status fetched again.
+	watcher := &fakeStatusWatcher{
+		deltas: [][]multiwatcher.Delta{
+			{{Entity: machineInfo("0", multiwatcher.Status("started"), "")}},
+			{{Entity: &multiwatcher.AnnotationInfo{Tag: "machine-0"}}},
+			{{Entity: machineInfo("0", multiwatcher.Status("error"), "boom")}},
+			{{Entity: machineInfo("1", multiwatcher.Status("started"), "")}},
+		},
+	}
+	s.PatchValue(&newStatusWatcher, func(statusAPI) (statusWatcher, error) {
+		return watcher, nil
+	})
+	// The status is not fetched again until the refresh interval
+	// has passed.
+	var waits []time.Duration
+	s.PatchValue(&statusWatchSleep, func(d time.Duration) {
+		waits = append(waits, d)
+		client.statusReturn = watchTestStatus(params.StatusStarted, "", "0", "1")
+	})
+
+	client.statusReturn = watchTestStatus(params.StatusStarted, "", "0")
+	client.statusCalls = 0
+	code, stdout, stderr := runStatus(c, "--format", "yaml", "--watch")
+	c.Check(code, gc.Equals, 1)
+	c.Check(string(stderr), gc.Equals, "error: cannot watch environment: watcher stopped\n")
+	c.Check(string(stdout), gc.Equals, clearScreen+string(first)+clearScreen+string(second)+clearScreen+string(third))
+	c.Check(watcher.stopped, jc.IsTrue)
+	c.Check(client.closeCalled, jc.IsTrue)
+	c.Check(client.statusCalls, gc.Equals, 2)
+	c.Assert(waits, gc.HasLen, 1)
+	c.Check(waits[0] > 0 && waits[0] <= statusRefreshInterval, jc.IsTrue)
+}
+
+func (s *StatusSuite) TestWatchFilteredByStatus(c *gc.C) {
+	client := newFakeApiClient(watchTestStatus(params.StatusStarted, "", "0"))
+	s.PatchValue(&newApiClientForStatus, func(_ *StatusCommand) (statusAPI, error) {
+		return &client, nil
+	})
+	// Whether a machine matches the status filter can change with
+	// any change to it, so the status is always fetched again.
+	machine := &multiwatcher.MachineInfo{Id: "0", InstanceId: "i-0", Life: "alive"}
+	watcher := &fakeStatusWatcher{
+		deltas: [][]multiwatcher.Delta{
+			{{Entity: machine}},
+			{{Entity: machine}},
+		},
+	}
+	s.PatchValue(&newStatusWatcher, func(statusAPI) (statusWatcher, error) {
+		return watcher, nil
+	})
+	s.PatchValue(&statusWatchSleep, func(time.Duration) {})
+
+	code, _, _ := runStatus(c, "--format", "yaml", "--watch", "--status", "started")
+	c.Check(code, gc.Equals, 1)
+	c.Check(client.statusCalls, gc.Equals, 2)
+}
+
 // TestSummaryStatusWithUnresolvableDns is result of bug# 1410320.
 func (s *StatusSuite) TestSummaryStatusWithUnresolvableDns(c *gc.C) {
 	formatter := &summaryFormatter{}
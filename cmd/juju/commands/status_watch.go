@@ -0,0 +1,254 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"strings"
+
+	"github.com/juju/juju/api"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/multiwatcher"
+)
+
+// statusModel holds the status shown by status --watch. It starts out
+// as the status fetched from the API server and is then kept up to
+// date by applying the changes reported by the AllWatcher, so that
+// most changes are shown without fetching the status again.
+type statusModel struct {
+	// status holds the status to show. It is nil when the status
+	// must be fetched again.
+	status *api.Status
+
+	// filtered records whether the status is filtered by pattern or
+	// by status. Whether an entity matches those filters can change
+	// with any change to it, so only the API server can tell what
+	// to show.
+	filtered bool
+
+	// seen holds the last reported info of each entity. The first
+	// changes reported are the entities as they were when the
+	// watcher started, which the fetched status already shows.
+	seen map[multiwatcher.EntityId]multiwatcher.EntityInfo
+}
+
+func newStatusModel(filtered bool) *statusModel {
+	return &statusModel{filtered: filtered}
+}
+
+// apply applies the given changes to the status. If any of them
+// cannot be applied - an entity was added or removed, a relation or
+// the placement of a unit changed, or the status is filtered by
+// pattern or by status - the status is discarded so that it is
+// fetched again.
+func (m *statusModel) apply(deltas []multiwatcher.Delta) {
+	if m.seen == nil {
+		m.seen = make(map[multiwatcher.EntityId]multiwatcher.EntityInfo)
+		for _, delta := range deltas {
+			m.seen[delta.Entity.EntityId()] = delta.Entity
+		}
+		return
+	}
+	for _, delta := range deltas {
+		id := delta.Entity.EntityId()
+		prev := m.seen[id]
+		if delta.Removed {
+			delete(m.seen, id)
+		} else {
+			m.seen[id] = delta.Entity
+		}
+		if m.status != nil && !m.applyDelta(delta, prev) {
+			m.status = nil
+		}
+	}
+}
+
+// applyDelta applies a single change, given the previously reported
+// info of the entity, if any. It returns false if the change cannot
+// be applied.
+func (m *statusModel) applyDelta(delta multiwatcher.Delta, prev multiwatcher.EntityInfo) bool {
+	switch delta.Entity.(type) {
+	case *multiwatcher.MachineInfo, *multiwatcher.ServiceInfo, *multiwatcher.UnitInfo:
+	case *multiwatcher.RelationInfo:
+		// Relations do not change once added, but adding or
+		// removing one changes the services related and can
+		// change the units shown.
+		return prev != nil && !delta.Removed
+	default:
+		// Other entities are not shown in the status.
+		return true
+	}
+	if delta.Removed || prev == nil || m.filtered {
+		return false
+	}
+	switch info := delta.Entity.(type) {
+	case *multiwatcher.MachineInfo:
+		prevInfo := prev.(*multiwatcher.MachineInfo)
+		return updateMachine(m.status.Machines, info.Id, func(status *api.MachineStatus) {
+			applyMachineInfo(status, info, prevInfo)
+		})
+	case *multiwatcher.ServiceInfo:
+		if info.CharmURL != prev.(*multiwatcher.ServiceInfo).CharmURL {
+			// The charm of each unit is shown only where it
+			// differs from the service's.
+			return false
+		}
+		status, ok := m.status.Services[info.Name]
+		if !ok {
+			return false
+		}
+		applyServiceInfo(&status, info)
+		m.status.Services[info.Name] = status
+		return true
+	case *multiwatcher.UnitInfo:
+		prevInfo := prev.(*multiwatcher.UnitInfo)
+		if info.MachineId != prevInfo.MachineId {
+			return false
+		}
+		return updateUnit(m.status.Services, info.Name, func(status *api.UnitStatus, serviceCharm string) {
+			applyUnitInfo(status, info, prevInfo, serviceCharm)
+		})
+	}
+	return true
+}
+
+// updateMachine calls update with the status of the machine with the
+// given id, looking for containers within their hosts. It returns
+// false if the machine is not in the status.
+func updateMachine(machines map[string]api.MachineStatus, id string, update func(*api.MachineStatus)) bool {
+	for machineId, status := range machines {
+		if machineId == id {
+			update(&status)
+			machines[machineId] = status
+			return true
+		}
+		if strings.HasPrefix(id, machineId+"/") {
+			return updateMachine(status.Containers, id, update)
+		}
+	}
+	return false
+}
+
+// updateUnit calls update with the status of the named unit, looking
+// for subordinates within their principals, and with the charm of the
+// service the unit is shown in. It returns false if the unit is not in
+// the status.
+func updateUnit(services map[string]api.ServiceStatus, name string, update func(*api.UnitStatus, string)) bool {
+	for _, service := range services {
+		for unitName, status := range service.Units {
+			if unitName == name {
+				update(&status, service.Charm)
+				service.Units[unitName] = status
+				return true
+			}
+			if sub, ok := status.Subordinates[name]; ok {
+				update(&sub, service.Charm)
+				status.Subordinates[name] = sub
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyMachineInfo updates the status of a machine as the API server
+// reports it. The agent status is only updated when it has changed, as
+// the API server reports a machine whose agent is down as such, which
+// the watcher does not.
+func applyMachineInfo(status *api.MachineStatus, info, prev *multiwatcher.MachineInfo) {
+	life := lifeString(info.Life)
+	status.Agent.Life = life
+	status.Life = life
+	status.Series = info.Series
+	status.Jobs = info.Jobs
+	status.HasVote = info.HasVote
+	status.WantsVote = info.WantsVote
+	if info.HardwareCharacteristics != nil {
+		status.Hardware = info.HardwareCharacteristics.String()
+	}
+	if info.Status != prev.Status || info.StatusInfo != prev.StatusInfo || status.AgentState == "" {
+		status.Agent.Status = params.Status(info.Status)
+		status.Agent.Info = info.StatusInfo
+		status.AgentState = status.Agent.Status
+		status.AgentStateInfo = status.Agent.Info
+	}
+	if info.InstanceId == "" {
+		status.InstanceId = "pending"
+		// Unprovisioned machines are shown without an agent state.
+		status.AgentState = ""
+		return
+	}
+	status.InstanceId = instance.Id(info.InstanceId)
+	status.DNSName = network.SelectPublicAddress(info.Addresses)
+}
+
+// applyServiceInfo updates the status of a service as the API server
+// reports it.
+func applyServiceInfo(status *api.ServiceStatus, info *multiwatcher.ServiceInfo) {
+	status.Exposed = info.Exposed
+	status.Life = lifeString(info.Life)
+	if !info.Subordinate {
+		status.Status.Status = params.Status(info.Status.Current)
+		status.Status.Info = info.Status.Message
+		status.Status.Data = info.Status.Data
+		status.Status.Since = info.Status.Since
+	}
+}
+
+// applyUnitInfo updates the status of a unit as the API server reports
+// it. As for machines, the agent and workload status are only updated
+// when they have changed, as the API server reports a unit whose agent
+// is not communicating as lost, which the watcher does not.
+func applyUnitInfo(status *api.UnitStatus, info, prev *multiwatcher.UnitInfo, serviceCharm string) {
+	life := lifeString(info.Life)
+	status.UnitAgent.Life = life
+	status.Life = life
+	status.PublicAddress = info.PublicAddress
+	status.OpenedPorts = nil
+	for _, portRange := range info.PortRanges {
+		status.OpenedPorts = append(status.OpenedPorts, portRange.String())
+	}
+	status.Charm = ""
+	if serviceCharm != "" && info.CharmURL != "" && info.CharmURL != serviceCharm {
+		status.Charm = info.CharmURL
+	}
+	if statusInfoEqual(info.AgentStatus, prev.AgentStatus) && statusInfoEqual(info.WorkloadStatus, prev.WorkloadStatus) {
+		return
+	}
+	status.UnitAgent.Status = params.Status(info.AgentStatus.Current)
+	status.UnitAgent.Info = info.AgentStatus.Message
+	status.UnitAgent.Since = info.AgentStatus.Since
+	status.Workload.Status = params.Status(info.WorkloadStatus.Current)
+	status.Workload.Info = info.WorkloadStatus.Message
+	status.Workload.Since = info.WorkloadStatus.Since
+	status.Workload.Data = nil
+	if relationId, ok := info.WorkloadStatus.Data["relation-id"]; ok {
+		status.Workload.Data = map[string]interface{}{"relation-id": relationId}
+	}
+	legacyState, _ := state.TranslateToLegacyAgentState(
+		state.Status(status.UnitAgent.Status),
+		state.Status(status.Workload.Status),
+		status.Workload.Info,
+	)
+	status.AgentState = params.Status(legacyState)
+	status.AgentStateInfo = ""
+	if status.AgentState == params.StatusError {
+		status.AgentStateInfo = status.Workload.Info
+	}
+}
+
+func statusInfoEqual(a, b multiwatcher.StatusInfo) bool {
+	return a.Current == b.Current && a.Message == b.Message
+}
+
+// lifeString returns the life of an entity as the API server reports
+// it, which omits the usual alive.
+func lifeString(life multiwatcher.Life) string {
+	if life == "alive" {
+		return ""
+	}
+	return string(life)
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
@@ -24,43 +26,62 @@ type StatusHistoryCommand struct {
 	outputContent string
 	backlogSize   int
 	isoTime       bool
-	unitName      string
+	entityName    string
+	fromValue     string
+	toValue       string
+	from          *time.Time
+	to            *time.Time
 }
 
 var statusHistoryDoc = `
 This command will report the history of status changes for
-a given unit.
-The statuses for the unit workload and/or agent are available.
+a given unit, service, machine or relation.
+Relations are named by their key, for example "wordpress:db mysql:server".
+
 -type supports:
     agent: will show statuses for the unit's agent
     workload: will show statuses for the unit's workload
-    combined: will show agent and workload statuses combined
+    combined: will show all statuses of the entity combined
  and sorted by time of occurence.
+    service: will show statuses set for the service itself
+    machine: will show statuses for the machine's agent
+    instance: will show statuses of the machine's provider instance
+    relation: will show when the relation was joined and broken
+
+For a service, the agent, workload and combined types merge the
+statuses of all its units into a single timeline.
+
+--from and --to restrict the history to a time range. They accept
+a duration relative to now (e.g. 2h means two hours ago), a date
+(2006-01-02) or a time in RFC3339 format. Use -n 0 to show all
+statuses in the range.
 `
 
 func (c *StatusHistoryCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "status-history",
-		Args:    "[-n N] <unit>",
-		Purpose: "output past statuses for a unit",
+		Args:    "[-n N] [--from <time>] [--to <time>] <unit | service | machine | relation>",
+		Purpose: "output past statuses for a unit, service, machine or relation",
 		Doc:     statusHistoryDoc,
 	}
 }
 
 func (c *StatusHistoryCommand) SetFlags(f *gnuflag.FlagSet) {
-	f.StringVar(&c.outputContent, "type", "combined", "type of statuses to be displayed [agent|workload|combined].")
+	f.StringVar(&c.outputContent, "type", "combined", "type of statuses to be displayed [agent|workload|combined|service|machine|instance|relation].")
 	f.IntVar(&c.backlogSize, "n", 20, "size of logs backlog.")
 	f.BoolVar(&c.isoTime, "utc", false, "display time as UTC in RFC3339 format")
+	f.StringVar(&c.fromValue, "from", "", "only show statuses set at or after this time")
+	f.StringVar(&c.toValue, "to", "", "only show statuses set before this time")
 }
 
 func (c *StatusHistoryCommand) Init(args []string) error {
 	switch {
 	case len(args) > 1:
-		return errors.Errorf("unexpected arguments after unit name.")
+		return errors.Errorf("unexpected arguments after entity name.")
 	case len(args) == 0:
-		return errors.Errorf("unit name is missing.")
+		return errors.Errorf("entity name is missing.")
 	default:
-		c.unitName = args[0]
+		c.entityName = args[0]
 	}
 	// If use of ISO time not specified on command line,
 	// check env var.
@@ -73,26 +94,72 @@ func (c *StatusHistoryCommand) Init(args []string) error {
 			}
 		}
 	}
+	if c.backlogSize < 0 {
+		return errors.Errorf("invalid history size: %d", c.backlogSize)
+	}
+	now := time.Now()
+	var err error
+	if c.from, err = parseHistoryTime(c.fromValue, now); err != nil {
+		return errors.Annotate(err, "invalid --from value")
+	}
+	if c.to, err = parseHistoryTime(c.toValue, now); err != nil {
+		return errors.Annotate(err, "invalid --to value")
+	}
+	if c.from != nil && c.to != nil && !c.from.Before(*c.to) {
+		return errors.Errorf("--from must be earlier than --to")
+	}
 	kind := params.HistoryKind(c.outputContent)
 	switch kind {
-	case params.KindCombined, params.KindAgent, params.KindWorkload:
+	case params.KindCombined, params.KindAgent, params.KindWorkload,
+		params.KindService, params.KindMachine, params.KindInstance, params.KindRelation:
 		return nil
 
 	}
 	return errors.Errorf("unexpected status type %q", c.outputContent)
 }
 
+// parseHistoryTime parses value as a duration before now, a date or
+// an RFC3339 time. An empty value yields nil.
+func parseHistoryTime(value string, now time.Time) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return nil, errors.Errorf("negative duration %q", value)
+		}
+		t := now.Add(-d)
+		return &t, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, errors.Errorf("expected a duration, date or RFC3339 time, got %q", value)
+}
+
+// StatusHistoryAPI defines the API methods used by the status-history
+// command.
+type StatusHistoryAPI interface {
+	StatusHistory(args params.StatusHistory) (*api.StatusHistory, error)
+	UnitStatusHistory(kind params.HistoryKind, unitName string, size int) (*api.UnitStatusHistory, error)
+	Close() error
+}
+
+var getStatusHistoryAPI = func(c *StatusHistoryCommand) (StatusHistoryAPI, error) {
+	return c.NewAPIClient()
+}
+
 func (c *StatusHistoryCommand) Run(ctx *cmd.Context) error {
-	apiclient, err := c.NewAPIClient()
+	apiclient, err := getStatusHistoryAPI(c)
 	if err != nil {
 		return fmt.Errorf(connectionError, c.ConnectionName(), err)
 	}
 	defer apiclient.Close()
-	var statuses *api.UnitStatusHistory
-	kind := params.HistoryKind(c.outputContent)
-	statuses, err = apiclient.UnitStatusHistory(kind, c.unitName, c.backlogSize)
+	statuses, err := c.statusHistory(apiclient)
 	if err != nil {
-		if len(statuses.Statuses) == 0 {
+		if statuses == nil || len(statuses.Statuses) == 0 {
 			return errors.Trace(err)
 		}
 		// Display any error, but continue to print status if some was returned
@@ -100,20 +167,82 @@ func (c *StatusHistoryCommand) Run(ctx *cmd.Context) error {
 	} else if len(statuses.Statuses) == 0 {
 		return errors.Errorf("no status history available")
 	}
+
+	// Only show which entity a status belongs to when there is more
+	// than one, as in the merged timeline of a service's units.
+	showEntity := false
+	for _, v := range statuses.Statuses {
+		if v.Entity != statuses.Statuses[0].Entity {
+			showEntity = true
+			break
+		}
+	}
 	table := [][]string{{"TIME", "TYPE", "STATUS", "MESSAGE"}}
-	lengths := []int{1, 1, 1, 1}
+	if showEntity {
+		table[0] = append([]string{"ENTITY"}, table[0]...)
+	}
+	lengths := make([]int, len(table[0]))
 	for _, v := range statuses.Statuses {
 		fields := []string{formatStatusTime(v.Since, c.isoTime), string(v.Kind), string(v.Status), v.Info}
-		for k, v := range fields {
+		if showEntity {
+			fields = append([]string{v.Entity}, fields...)
+		}
+		table = append(table, fields)
+	}
+	for _, row := range table {
+		for k, v := range row {
 			if len(v) > lengths[k] {
 				lengths[k] = len(v)
 			}
 		}
-		table = append(table, fields)
 	}
-	f := fmt.Sprintf("%%-%ds\t%%-%ds\t%%-%ds\t%%-%ds\n", lengths[0], lengths[1], lengths[2], lengths[3])
-	for _, v := range table {
-		fmt.Printf(f, v[0], v[1], v[2], v[3])
+	for _, row := range table {
+		cells := make([]string, len(row))
+		for k, v := range row {
+			cells[k] = fmt.Sprintf("%-*s", lengths[k], v)
+		}
+		fmt.Fprintln(ctx.Stdout, strings.Join(cells, "\t"))
 	}
 	return nil
 }
+
+// statusHistory fetches the requested history. Servers that predate
+// the StatusHistory call can still report the unit kinds without a
+// time range.
+func (c *StatusHistoryCommand) statusHistory(apiclient StatusHistoryAPI) (*api.StatusHistory, error) {
+	kind := params.HistoryKind(c.outputContent)
+	statuses, err := apiclient.StatusHistory(params.StatusHistory{
+		Kind: kind,
+		Name: c.entityName,
+		Size: c.backlogSize,
+		From: c.from,
+		To:   c.to,
+	})
+	if !errors.IsNotImplemented(err) {
+		return statuses, err
+	}
+	switch kind {
+	case params.KindCombined, params.KindAgent, params.KindWorkload:
+	default:
+		return statuses, err
+	}
+	if c.from != nil || c.to != nil {
+		return statuses, errors.Errorf("the API server does not support status history time ranges")
+	}
+	unitStatuses, err := apiclient.UnitStatusHistory(kind, c.entityName, c.backlogSize)
+	statuses = &api.StatusHistory{}
+	if unitStatuses == nil {
+		return statuses, err
+	}
+	for _, v := range unitStatuses.Statuses {
+		statuses.Statuses = append(statuses.Statuses, api.HistoricalStatus{
+			Entity: c.entityName,
+			Kind:   v.Kind,
+			Status: v.Status,
+			Info:   v.Info,
+			Data:   v.Data,
+			Since:  v.Since,
+		})
+	}
+	return statuses, err
+}
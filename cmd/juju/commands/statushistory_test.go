@@ -0,0 +1,182 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/api"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+)
+
+type StatusHistorySuite struct {
+	testing.FakeJujuHomeSuite
+	mock *mockStatusHistoryAPI
+}
+
+var _ = gc.Suite(&StatusHistorySuite{})
+
+func (s *StatusHistorySuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.mock = &mockStatusHistoryAPI{}
+	s.PatchValue(&getStatusHistoryAPI, func(_ *StatusHistoryCommand) (StatusHistoryAPI, error) {
+		return s.mock, nil
+	})
+}
+
+func (s *StatusHistorySuite) TestInitErrors(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  `entity name is missing.`,
+	}, {
+		args: []string{"mysql/0", "extra"},
+		err:  `unexpected arguments after entity name.`,
+	}, {
+		args: []string{"--type", "bogus", "mysql/0"},
+		err:  `unexpected status type "bogus"`,
+	}, {
+		args: []string{"-n", "-1", "mysql/0"},
+		err:  `invalid history size: -1`,
+	}, {
+		args: []string{"--from", "yesterday", "mysql/0"},
+		err:  `invalid --from value: expected a duration, date or RFC3339 time, got "yesterday"`,
+	}, {
+		args: []string{"--to", "-1h", "mysql/0"},
+		err:  `invalid --to value: negative duration "-1h"`,
+	}, {
+		args: []string{"--from", "1h", "--to", "2h", "mysql/0"},
+		err:  `--from must be earlier than --to`,
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		_, err := testing.RunCommand(c, envcmd.Wrap(&StatusHistoryCommand{}), test.args...)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *StatusHistorySuite) TestParseHistoryTime(c *gc.C) {
+	now := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+	t, err := parseHistoryTime("", now)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t, gc.IsNil)
+
+	t, err = parseHistoryTime("90m", now)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(*t, gc.Equals, time.Date(2015, 6, 1, 10, 30, 0, 0, time.UTC))
+
+	t, err = parseHistoryTime("2015-05-30", now)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(*t, gc.Equals, time.Date(2015, 5, 30, 0, 0, 0, 0, time.UTC))
+
+	t, err = parseHistoryTime("2015-05-30T08:15:00Z", now)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(*t, gc.Equals, time.Date(2015, 5, 30, 8, 15, 0, 0, time.UTC))
+}
+
+func (s *StatusHistorySuite) TestArgsPassedToAPI(c *gc.C) {
+	s.mock.statuses = []api.HistoricalStatus{s.status("0", params.KindInstance, "running", "", 0)}
+	_, err := testing.RunCommand(c, envcmd.Wrap(&StatusHistoryCommand{}),
+		"--type", "instance", "-n", "0", "--from", "2015-05-30", "--to", "2015-05-31T00:00:00Z", "0")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mock.args.Kind, gc.Equals, params.KindInstance)
+	c.Assert(s.mock.args.Name, gc.Equals, "0")
+	c.Assert(s.mock.args.Size, gc.Equals, 0)
+	c.Assert(*s.mock.args.From, gc.Equals, time.Date(2015, 5, 30, 0, 0, 0, 0, time.UTC))
+	c.Assert(*s.mock.args.To, gc.Equals, time.Date(2015, 5, 31, 0, 0, 0, 0, time.UTC))
+}
+
+func (s *StatusHistorySuite) TestSingleEntity(c *gc.C) {
+	s.mock.statuses = []api.HistoricalStatus{
+		s.status("0", params.KindMachine, params.StatusPending, "", 0),
+		s.status("0", params.KindMachine, params.StatusStarted, "", 1),
+	}
+	context, err := testing.RunCommand(c, envcmd.Wrap(&StatusHistoryCommand{}), "--utc", "0")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"TIME                \tTYPE   \tSTATUS \tMESSAGE\n"+
+		"2015-06-01 12:00:00Z\tmachine\tpending\t       \n"+
+		"2015-06-01 12:00:01Z\tmachine\tstarted\t       \n")
+}
+
+func (s *StatusHistorySuite) TestMergedTimelineShowsEntity(c *gc.C) {
+	s.mock.statuses = []api.HistoricalStatus{
+		s.status("mysql/0", params.KindWorkload, params.StatusActive, "ready", 0),
+		s.status("mysql/1", params.KindWorkload, params.StatusBlocked, "no db", 1),
+	}
+	context, err := testing.RunCommand(c, envcmd.Wrap(&StatusHistoryCommand{}), "--utc", "--type", "workload", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"ENTITY \tTIME                \tTYPE    \tSTATUS \tMESSAGE\n"+
+		"mysql/0\t2015-06-01 12:00:00Z\tworkload\tactive \tready  \n"+
+		"mysql/1\t2015-06-01 12:00:01Z\tworkload\tblocked\tno db  \n")
+}
+
+func (s *StatusHistorySuite) TestNoHistory(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&StatusHistoryCommand{}), "mysql/0")
+	c.Assert(err, gc.ErrorMatches, "no status history available")
+}
+
+func (s *StatusHistorySuite) TestFallbackToUnitStatusHistory(c *gc.C) {
+	s.mock.err = errors.NotImplementedf("StatusHistory")
+	since := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.mock.unitStatuses = []api.AgentStatus{{
+		Status: params.StatusIdle,
+		Kind:   params.KindAgent,
+		Since:  &since,
+	}}
+	context, err := testing.RunCommand(c, envcmd.Wrap(&StatusHistoryCommand{}), "--utc", "--type", "agent", "mysql/0")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mock.unitArgs, jc.DeepEquals, []interface{}{params.KindAgent, "mysql/0", 20})
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"TIME                \tTYPE \tSTATUS\tMESSAGE\n"+
+		"2015-06-01 12:00:00Z\tagent\tidle  \t       \n")
+}
+
+func (s *StatusHistorySuite) TestNoFallbackWithTimeRange(c *gc.C) {
+	s.mock.err = errors.NotImplementedf("StatusHistory")
+	_, err := testing.RunCommand(c, envcmd.Wrap(&StatusHistoryCommand{}), "--from", "1h", "mysql/0")
+	c.Assert(err, gc.ErrorMatches, "the API server does not support status history time ranges")
+	c.Assert(s.mock.unitArgs, gc.IsNil)
+}
+
+func (s *StatusHistorySuite) status(entity string, kind params.HistoryKind, status params.Status, info string, offset int) api.HistoricalStatus {
+	since := time.Date(2015, 6, 1, 12, 0, offset, 0, time.UTC)
+	return api.HistoricalStatus{
+		Entity: entity,
+		Kind:   kind,
+		Status: status,
+		Info:   info,
+		Since:  &since,
+	}
+}
+
+type mockStatusHistoryAPI struct {
+	args         params.StatusHistory
+	statuses     []api.HistoricalStatus
+	err          error
+	unitArgs     []interface{}
+	unitStatuses []api.AgentStatus
+}
+
+func (m *mockStatusHistoryAPI) StatusHistory(args params.StatusHistory) (*api.StatusHistory, error) {
+	m.args = args
+	return &api.StatusHistory{Statuses: m.statuses}, m.err
+}
+
+func (m *mockStatusHistoryAPI) UnitStatusHistory(kind params.HistoryKind, unitName string, size int) (*api.UnitStatusHistory, error) {
+	m.unitArgs = []interface{}{kind, unitName, size}
+	return &api.UnitStatusHistory{Statuses: m.unitStatuses}, nil
+}
+
+func (*mockStatusHistoryAPI) Close() error {
+	return nil
+}
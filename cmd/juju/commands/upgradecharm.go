@@ -26,6 +26,7 @@ type UpgradeCharmCommand struct {
 	RepoPath    string // defaults to JUJU_REPOSITORY
 	SwitchURL   string
 	Revision    int // defaults to -1 (latest)
+	Preview     bool
 }
 
 const upgradeCharmDoc = `
@@ -64,6 +65,14 @@ would specify revision number 5 of the wordpress charm.
 Use of the --force flag is not generally recommended; units upgraded while in an
 error state will not have upgrade-charm hooks executed, and may cause unexpected
 behavior.
+
+The --preview flag shows what the upgrade would change, without changing the
+service: config options and defaults that are added, removed or changed,
+changes to relation endpoints and storage, and diffs of the charm's hooks and
+actions. Problems that would cause the upgrade to be refused, such as the new
+charm not implementing a relation the service takes part in, are listed first.
+Previewing an upgrade adds the new charm to the environment, so that it can be
+compared with the current one.
 `
 
 func (c *UpgradeCharmCommand) Info() *cmd.Info {
@@ -80,6 +89,7 @@ func (c *UpgradeCharmCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.StringVar(&c.RepoPath, "repository", os.Getenv("JUJU_REPOSITORY"), "local charm repository path")
 	f.StringVar(&c.SwitchURL, "switch", "", "crossgrade to a different charm")
 	f.IntVar(&c.Revision, "revision", -1, "explicit revision of current charm")
+	f.BoolVar(&c.Preview, "preview", false, "show the changes the upgrade would make, without upgrading")
 }
 
 func (c *UpgradeCharmCommand) Init(args []string) error {
@@ -158,5 +168,8 @@ func (c *UpgradeCharmCommand) Run(ctx *cmd.Context) error {
 		return block.ProcessBlockedError(err, block.BlockChange)
 	}
 
+	if c.Preview {
+		return c.preview(ctx, client, oldURL, addedURL)
+	}
 	return block.ProcessBlockedError(client.ServiceSetCharm(c.ServiceName, addedURL.String(), c.Force), block.BlockChange)
 }
@@ -271,6 +271,64 @@ func (s *UpgradeCharmSuccessSuite) TestSwitch(c *gc.C) {
 	s.assertLocalRevision(c, 42, myriakPath)
 }
 
+var myriakPreviewMeta = []byte(`
+name: myriak
+summary: "K/V storage engine"
+description: "Scalable K/V Store in Erlang with Clocks :-)"
+provides:
+  endpoint:
+    interface: http
+  admin:
+    interface: https
+`)
+
+func (s *UpgradeCharmSuccessSuite) TestPreview(c *gc.C) {
+	myriakPath := testcharms.Repo.RenamedClonedDirPath(s.SeriesPath, "riak", "myriak")
+	err := ioutil.WriteFile(path.Join(myriakPath, "metadata.yaml"), myriakPreviewMeta, 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	err = os.Mkdir(path.Join(myriakPath, "hooks"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ioutil.WriteFile(path.Join(myriakPath, "hooks", "install"), []byte("#!/bin/sh\necho install\n"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&UpgradeCharmCommand{}), "riak", "--switch=local:myriak", "--preview")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `
+Upgrading service "riak" from local:trusty/riak-7 to local:trusty/myriak-7:
+
+Problems (the upgrade would be refused):
+    would break relation "ring"
+
+Relations:
+    provides "admin" interface changed from http to https
+    removed peers "ring" (interface riak)
+
+Hooks and actions:
+    --- /dev/null
+    +++ local:trusty/myriak-7/hooks/install
+    @@ -0,0 +1,2 @@
+    +#!/bin/sh
+    +echo install
+`[1:])
+
+	// The service is left unchanged.
+	err = s.riak.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	curl, _ := s.riak.CharmURL()
+	c.Assert(curl.String(), gc.Equals, "local:trusty/riak-7")
+}
+
+func (s *UpgradeCharmSuccessSuite) TestPreviewNoChanges(c *gc.C) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&UpgradeCharmCommand{}), "riak", "--preview")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `
+Upgrading service "riak" from local:trusty/riak-7 to local:trusty/riak-8:
+
+No changes.
+`[1:])
+	s.assertLocalRevision(c, 7, s.path)
+}
+
 type UpgradeCharmCharmStoreSuite struct {
 	charmStoreSuite
 }
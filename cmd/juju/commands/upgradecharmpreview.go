@@ -0,0 +1,599 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"gopkg.in/juju/charm.v5"
+
+	"github.com/juju/juju/api"
+)
+
+// maxDiffCells bounds the size of the table used to compute a line diff,
+// so that comparing very large files cannot exhaust the client's memory.
+const maxDiffCells = 4 * 1024 * 1024
+
+// diffContext is the number of unchanged lines shown around each change
+// in a file diff.
+const diffContext = 3
+
+// previewCharm is a charm archive fetched from the API server, together
+// with the contents of the files that a preview compares.
+type previewCharm struct {
+	url     *charm.URL
+	archive *charm.CharmArchive
+	files   map[string][]byte
+}
+
+// preview reports the differences between the service's current charm
+// and the charm it would be upgraded to, without changing the service.
+func (c *UpgradeCharmCommand) preview(ctx *cmd.Context, client *api.Client, oldURL, newURL *charm.URL) error {
+	tempDir, err := ioutil.TempDir("", "upgrade-charm-preview")
+	if err != nil {
+		return errors.Annotate(err, "cannot create temp directory")
+	}
+	defer os.RemoveAll(tempDir)
+	oldCharm, err := fetchPreviewCharm(client, oldURL, filepath.Join(tempDir, "old.charm"))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	newCharm, err := fetchPreviewCharm(client, newURL, filepath.Join(tempDir, "new.charm"))
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	status, err := client.Status([]string{c.ServiceName})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var relations []string
+	if serviceStatus, ok := status.Services[c.ServiceName]; ok {
+		for name := range serviceStatus.Relations {
+			relations = append(relations, name)
+		}
+	}
+	sort.Strings(relations)
+
+	fmt.Fprintf(ctx.Stdout, "Upgrading service %q from %s to %s:\n", c.ServiceName, oldURL, newURL)
+	sections := []struct {
+		title string
+		lines []string
+	}{
+		{"Problems (the upgrade would be refused)", upgradeProblems(oldCharm, newCharm, relations)},
+		{"Config", diffConfig(oldCharm.archive.Config(), newCharm.archive.Config())},
+		{"Relations", diffRelations(oldCharm.archive.Meta(), newCharm.archive.Meta())},
+		{"Storage", diffStorage(oldCharm.archive.Meta(), newCharm.archive.Meta())},
+		{"Hooks and actions", diffFiles(oldCharm, newCharm)},
+	}
+	changed := false
+	for _, section := range sections {
+		if len(section.lines) == 0 {
+			continue
+		}
+		changed = true
+		fmt.Fprintf(ctx.Stdout, "\n%s:\n", section.title)
+		for _, line := range section.lines {
+			fmt.Fprintf(ctx.Stdout, "    %s\n", line)
+		}
+	}
+	if !changed {
+		fmt.Fprintf(ctx.Stdout, "\nNo changes.\n")
+	}
+	return nil
+}
+
+// fetchPreviewCharm downloads the archive of the given charm from the
+// API server to archivePath, and reads it.
+func fetchPreviewCharm(client *api.Client, curl *charm.URL, archivePath string) (*previewCharm, error) {
+	reader, err := client.OpenCharm(curl)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer reader.Close()
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot create charm archive file")
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, reader); err != nil {
+		return nil, errors.Annotatef(err, "cannot download charm %q", curl)
+	}
+	if err := f.Close(); err != nil {
+		return nil, errors.Annotatef(err, "cannot download charm %q", curl)
+	}
+	archive, err := charm.ReadCharmArchive(archivePath)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot read charm %q", curl)
+	}
+	files, err := readPreviewFiles(archivePath)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot read charm %q", curl)
+	}
+	return &previewCharm{
+		url:     curl,
+		archive: archive,
+		files:   files,
+	}, nil
+}
+
+// readPreviewFiles returns the contents of the hooks and actions held
+// in the charm archive at archivePath, keyed by path.
+func readPreviewFiles(archivePath string) (map[string][]byte, error) {
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer zipReader.Close()
+	files := make(map[string][]byte)
+	for _, file := range zipReader.File {
+		name := path.Clean(file.Name)
+		if file.FileInfo().IsDir() || !isPreviewFile(name) {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		data, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		files[name] = data
+	}
+	return files, nil
+}
+
+func isPreviewFile(name string) bool {
+	return name == "actions.yaml" ||
+		strings.HasPrefix(name, "hooks/") ||
+		strings.HasPrefix(name, "actions/")
+}
+
+// upgradeProblems returns the reasons, if any, for which the API server
+// would refuse to upgrade a service participating in the given relations
+// from oldCharm to newCharm. The checks made here mirror those made by
+// state.Service.SetCharm.
+func upgradeProblems(oldCharm, newCharm *previewCharm, relations []string) []string {
+	var problems []string
+	oldMeta, newMeta := oldCharm.archive.Meta(), newCharm.archive.Meta()
+	if oldMeta.Subordinate != newMeta.Subordinate {
+		problems = append(problems, "cannot change a service's subordinacy")
+	}
+	if oldCharm.url.Series != newCharm.url.Series {
+		problems = append(problems, "cannot change a service's series")
+	}
+	for _, name := range relations {
+		rel, ok := findRelation(oldMeta, name)
+		if !ok {
+			// Implicit relations are implemented by every charm.
+			continue
+		}
+		if !rel.ImplementedBy(newCharm.archive) {
+			problems = append(problems, fmt.Sprintf("would break relation %q", name))
+		}
+	}
+	return append(problems, storageUpgradeProblems(oldMeta, newMeta)...)
+}
+
+func findRelation(meta *charm.Meta, name string) (charm.Relation, bool) {
+	for _, relations := range []map[string]charm.Relation{meta.Provides, meta.Requires, meta.Peers} {
+		if rel, ok := relations[name]; ok {
+			return rel, true
+		}
+	}
+	return charm.Relation{}, false
+}
+
+// storageUpgradeProblems mirrors state.Service.checkStorageUpgrade.
+func storageUpgradeProblems(oldMeta, newMeta *charm.Meta) []string {
+	var problems []string
+	for _, name := range sortedKeys(oldMeta.Storage) {
+		if _, ok := newMeta.Storage[name]; !ok {
+			problems = append(problems, fmt.Sprintf("storage %q removed", name))
+		}
+	}
+	less := func(a, b int) bool {
+		return a != -1 && (b == -1 || a < b)
+	}
+	for _, name := range sortedKeys(newMeta.Storage) {
+		newStorage := newMeta.Storage[name]
+		oldStorage, ok := oldMeta.Storage[name]
+		if !ok {
+			if newStorage.CountMin > 0 {
+				problems = append(problems, fmt.Sprintf("required storage %q added", name))
+			}
+			continue
+		}
+		if newStorage.Type != oldStorage.Type {
+			problems = append(problems, fmt.Sprintf(
+				"existing storage %q type changed from %q to %q",
+				name, oldStorage.Type, newStorage.Type,
+			))
+		}
+		if newStorage.Shared != oldStorage.Shared {
+			problems = append(problems, fmt.Sprintf(
+				"existing storage %q shared changed from %v to %v",
+				name, oldStorage.Shared, newStorage.Shared,
+			))
+		}
+		if newStorage.ReadOnly != oldStorage.ReadOnly {
+			problems = append(problems, fmt.Sprintf(
+				"existing storage %q read-only changed from %v to %v",
+				name, oldStorage.ReadOnly, newStorage.ReadOnly,
+			))
+		}
+		if newStorage.Location != oldStorage.Location {
+			problems = append(problems, fmt.Sprintf(
+				"existing storage %q location changed from %q to %q",
+				name, oldStorage.Location, newStorage.Location,
+			))
+		}
+		if newStorage.CountMin > oldStorage.CountMin {
+			problems = append(problems, fmt.Sprintf(
+				"existing storage %q range contracted: min increased from %d to %d",
+				name, oldStorage.CountMin, newStorage.CountMin,
+			))
+		}
+		if less(newStorage.CountMax, oldStorage.CountMax) {
+			problems = append(problems, fmt.Sprintf(
+				"existing storage %q range contracted: max decreased from %s to %d",
+				name, formatCountMax(oldStorage.CountMax), newStorage.CountMax,
+			))
+		}
+		if oldStorage.Location != "" && oldStorage.CountMax == 1 && newStorage.CountMax != 1 {
+			problems = append(problems, fmt.Sprintf(
+				"existing storage %q with location changed from singleton to multiple",
+				name,
+			))
+		}
+	}
+	return problems
+}
+
+// diffConfig describes the changes between two charm configs. Values
+// set for options that are removed, or whose type changes, are discarded
+// by an upgrade.
+func diffConfig(oldConfig, newConfig *charm.Config) []string {
+	var lines []string
+	for _, name := range sortedKeys(oldConfig.Options) {
+		if _, ok := newConfig.Options[name]; !ok {
+			lines = append(lines, fmt.Sprintf("removed %q; any value set will be discarded", name))
+		}
+	}
+	for _, name := range sortedKeys(newConfig.Options) {
+		newOption := newConfig.Options[name]
+		oldOption, ok := oldConfig.Options[name]
+		if !ok {
+			lines = append(lines, fmt.Sprintf(
+				"added %q (%s, default %s)",
+				name, newOption.Type, formatDefault(newOption.Default),
+			))
+			continue
+		}
+		if newOption.Type != oldOption.Type {
+			lines = append(lines, fmt.Sprintf(
+				"%q type changed from %s to %s; any value set will be discarded",
+				name, oldOption.Type, newOption.Type,
+			))
+		}
+		if !reflect.DeepEqual(newOption.Default, oldOption.Default) {
+			lines = append(lines, fmt.Sprintf(
+				"%q default changed from %s to %s",
+				name, formatDefault(oldOption.Default), formatDefault(newOption.Default),
+			))
+		}
+		if newOption.Description != oldOption.Description {
+			lines = append(lines, fmt.Sprintf("%q description changed", name))
+		}
+	}
+	return lines
+}
+
+func formatDefault(value interface{}) string {
+	if value == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%#v", value)
+}
+
+// diffRelations describes the changes between the relation endpoints
+// of two charms.
+func diffRelations(oldMeta, newMeta *charm.Meta) []string {
+	var lines []string
+	roles := []struct {
+		name     string
+		old, new map[string]charm.Relation
+	}{
+		{"provides", oldMeta.Provides, newMeta.Provides},
+		{"requires", oldMeta.Requires, newMeta.Requires},
+		{"peers", oldMeta.Peers, newMeta.Peers},
+	}
+	for _, role := range roles {
+		for _, name := range sortedKeys(role.old) {
+			if _, ok := role.new[name]; !ok {
+				lines = append(lines, fmt.Sprintf(
+					"removed %s %q (interface %s)",
+					role.name, name, role.old[name].Interface,
+				))
+			}
+		}
+		for _, name := range sortedKeys(role.new) {
+			newRel := role.new[name]
+			oldRel, ok := role.old[name]
+			if !ok {
+				lines = append(lines, fmt.Sprintf(
+					"added %s %q (interface %s)",
+					role.name, name, newRel.Interface,
+				))
+				continue
+			}
+			if newRel.Interface != oldRel.Interface {
+				lines = append(lines, fmt.Sprintf(
+					"%s %q interface changed from %s to %s",
+					role.name, name, oldRel.Interface, newRel.Interface,
+				))
+			}
+			if newRel.Scope != oldRel.Scope {
+				lines = append(lines, fmt.Sprintf(
+					"%s %q scope changed from %s to %s",
+					role.name, name, oldRel.Scope, newRel.Scope,
+				))
+			}
+			if newRel.Limit != oldRel.Limit {
+				lines = append(lines, fmt.Sprintf(
+					"%s %q limit changed from %d to %d",
+					role.name, name, oldRel.Limit, newRel.Limit,
+				))
+			}
+			if newRel.Optional != oldRel.Optional {
+				lines = append(lines, fmt.Sprintf(
+					"%s %q optional changed from %v to %v",
+					role.name, name, oldRel.Optional, newRel.Optional,
+				))
+			}
+		}
+	}
+	return lines
+}
+
+// diffStorage describes the changes between the storage declared by two
+// charms.
+func diffStorage(oldMeta, newMeta *charm.Meta) []string {
+	var lines []string
+	for _, name := range sortedKeys(oldMeta.Storage) {
+		if _, ok := newMeta.Storage[name]; !ok {
+			lines = append(lines, fmt.Sprintf("removed %q (%s)", name, oldMeta.Storage[name].Type))
+		}
+	}
+	for _, name := range sortedKeys(newMeta.Storage) {
+		newStorage := newMeta.Storage[name]
+		oldStorage, ok := oldMeta.Storage[name]
+		if !ok {
+			lines = append(lines, fmt.Sprintf(
+				"added %q (%s, count %d-%s)",
+				name, newStorage.Type, newStorage.CountMin, formatCountMax(newStorage.CountMax),
+			))
+			continue
+		}
+		if !reflect.DeepEqual(newStorage, oldStorage) {
+			lines = append(lines, fmt.Sprintf(
+				"changed %q (%s, count %d-%s, was %s, count %d-%s)",
+				name,
+				newStorage.Type, newStorage.CountMin, formatCountMax(newStorage.CountMax),
+				oldStorage.Type, oldStorage.CountMin, formatCountMax(oldStorage.CountMax),
+			))
+		}
+	}
+	return lines
+}
+
+func formatCountMax(countMax int) string {
+	if countMax == -1 {
+		return "<unbounded>"
+	}
+	return fmt.Sprint(countMax)
+}
+
+// diffFiles returns unified diffs of the hooks and actions that differ
+// between two charms.
+func diffFiles(oldCharm, newCharm *previewCharm) []string {
+	names := make(map[string]bool)
+	for name := range oldCharm.files {
+		names[name] = true
+	}
+	for name := range newCharm.files {
+		names[name] = true
+	}
+	var lines []string
+	for _, name := range sortedKeys(names) {
+		oldData, inOld := oldCharm.files[name]
+		newData, inNew := newCharm.files[name]
+		if inOld && inNew && string(oldData) == string(newData) {
+			continue
+		}
+		oldName, newName := oldCharm.url.String()+"/"+name, newCharm.url.String()+"/"+name
+		if !inOld {
+			oldName = "/dev/null"
+		}
+		if !inNew {
+			newName = "/dev/null"
+		}
+		if isBinary(oldData) || isBinary(newData) {
+			lines = append(lines, fmt.Sprintf("binary files %s and %s differ", oldName, newName))
+			continue
+		}
+		oldLines, newLines := splitLines(oldData), splitLines(newData)
+		if len(oldLines)*len(newLines) > maxDiffCells {
+			lines = append(lines, fmt.Sprintf("files %s and %s differ (too large to compare)", oldName, newName))
+			continue
+		}
+		lines = append(lines, "--- "+oldName, "+++ "+newName)
+		lines = append(lines, unifiedDiff(oldLines, newLines, diffContext)...)
+	}
+	return lines
+}
+
+func isBinary(data []byte) bool {
+	return !utf8.Valid(data) || strings.IndexByte(string(data), 0) != -1
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// diffLine is a single line of a line diff. The op is ' ' for a line
+// common to both inputs, '-' for a line only in the old input, and '+'
+// for a line only in the new input.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines returns the shortest sequence of line insertions and
+// deletions that transforms a into b, found via their longest common
+// subsequence.
+func diffLines(a, b []string) []diffLine {
+	// lcs[i][j] holds the length of the longest common subsequence
+	// of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var result []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, diffLine{'-', a[i]})
+			i++
+		default:
+			result = append(result, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, diffLine{'+', b[j]})
+	}
+	return result
+}
+
+// unifiedDiff returns the differences between a and b in unified diff
+// format, with the given number of lines of context around each change.
+func unifiedDiff(a, b []string, context int) []string {
+	diff := diffLines(a, b)
+	var lines []string
+	// oldLine and newLine hold the line numbers, counting from 1, in a
+	// and b of the entry at index i of diff.
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(diff); {
+		if diff[i].op == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+		// Found a change; extend the hunk backwards over its leading
+		// context, and forwards until more than twice the context of
+		// unchanged lines separate it from the next change.
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		hunkOld, hunkNew := oldLine-(i-start), newLine-(i-start)
+		end, unchanged := i, 0
+		for ; end < len(diff) && unchanged <= 2*context; end++ {
+			if diff[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		if unchanged > context {
+			end -= unchanged - context
+		}
+		var oldCount, newCount int
+		var body []string
+		for _, line := range diff[start:end] {
+			if line.op != '+' {
+				oldCount++
+			}
+			if line.op != '-' {
+				newCount++
+			}
+			body = append(body, string(line.op)+line.text)
+		}
+		lines = append(lines, fmt.Sprintf("@@ -%s +%s @@",
+			hunkRange(hunkOld, oldCount), hunkRange(hunkNew, newCount)))
+		lines = append(lines, body...)
+		for _, line := range diff[i:end] {
+			if line.op != '+' {
+				oldLine++
+			}
+			if line.op != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+	return lines
+}
+
+// hunkRange formats the start and length of one side of a unified diff
+// hunk. An empty range is reported as starting on the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// sortedKeys returns the keys of the given map, which must have string
+// keys, in sorted order.
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,170 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package commands
+
+import (
+	"strings"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5"
+
+	"github.com/juju/juju/testing"
+)
+
+type UpgradeCharmPreviewSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&UpgradeCharmPreviewSuite{})
+
+var unifiedDiffTests = []struct {
+	about  string
+	old    string
+	new    string
+	expect []string
+}{{
+	about: "identical",
+	old:   "a\nb\nc",
+	new:   "a\nb\nc",
+}, {
+	about: "added file",
+	new:   "a\nb",
+	expect: []string{
+		"@@ -0,0 +1,2 @@",
+		"+a",
+		"+b",
+	},
+}, {
+	about: "removed file",
+	old:   "a",
+	expect: []string{
+		"@@ -1 +0,0 @@",
+		"-a",
+	},
+}, {
+	about: "changed line with context",
+	old:   "1\n2\n3\n4\n5\n6\n7\n8\n9",
+	new:   "1\n2\n3\n4\nfive\n6\n7\n8\n9",
+	expect: []string{
+		"@@ -2,7 +2,7 @@",
+		" 2",
+		" 3",
+		" 4",
+		"-5",
+		"+five",
+		" 6",
+		" 7",
+		" 8",
+	},
+}, {
+	about: "nearby changes share a hunk",
+	old:   "1\n2\n3\n4\n5\n6\n7\n8",
+	new:   "one\n2\n3\n4\n5\n6\n7\neight",
+	expect: []string{
+		"@@ -1,8 +1,8 @@",
+		"-1",
+		"+one",
+		" 2",
+		" 3",
+		" 4",
+		" 5",
+		" 6",
+		" 7",
+		"-8",
+		"+eight",
+	},
+}, {
+	about: "distant changes are separate hunks",
+	old:   "1\n2\n3\n4\n5\n6\n7\n8\n9\n10",
+	new:   "one\n2\n3\n4\n5\n6\n7\n8\n9\nten",
+	expect: []string{
+		"@@ -1,4 +1,4 @@",
+		"-1",
+		"+one",
+		" 2",
+		" 3",
+		" 4",
+		"@@ -7,4 +7,4 @@",
+		" 7",
+		" 8",
+		" 9",
+		"-10",
+		"+ten",
+	},
+}}
+
+func (s *UpgradeCharmPreviewSuite) TestUnifiedDiff(c *gc.C) {
+	for i, test := range unifiedDiffTests {
+		c.Logf("test %d: %s", i, test.about)
+		diff := unifiedDiff(splitLines([]byte(test.old)), splitLines([]byte(test.new)), diffContext)
+		c.Check(diff, jc.DeepEquals, test.expect)
+	}
+}
+
+func (s *UpgradeCharmPreviewSuite) TestDiffConfig(c *gc.C) {
+	oldConfig, err := charm.ReadConfig(strings.NewReader(`
+options:
+  title: {type: string, default: "My Title", description: "the title"}
+  port: {type: int, default: 80, description: "the port"}
+  debug: {type: boolean, description: "debug output"}
+`))
+	c.Assert(err, jc.ErrorIsNil)
+	newConfig, err := charm.ReadConfig(strings.NewReader(`
+options:
+  title: {type: string, default: "Your Title", description: "the title"}
+  port: {type: string, default: "80", description: "the port"}
+  verbose: {type: boolean, default: false, description: "verbose output"}
+`))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(diffConfig(oldConfig, newConfig), jc.DeepEquals, []string{
+		`removed "debug"; any value set will be discarded`,
+		`"port" type changed from int to string; any value set will be discarded`,
+		`"port" default changed from 80 to "80"`,
+		`"title" default changed from "My Title" to "Your Title"`,
+		`added "verbose" (boolean, default false)`,
+	})
+}
+
+func (s *UpgradeCharmPreviewSuite) TestStorageUpgradeProblems(c *gc.C) {
+	oldMeta, err := charm.ReadMeta(strings.NewReader(`
+name: storer
+summary: storer
+description: storer
+storage:
+  data:
+    type: filesystem
+    location: /srv/data
+  logs:
+    type: block
+    multiple:
+      range: 1-
+  cache:
+    type: filesystem
+`))
+	c.Assert(err, jc.ErrorIsNil)
+	newMeta, err := charm.ReadMeta(strings.NewReader(`
+name: storer
+summary: storer
+description: storer
+storage:
+  data:
+    type: filesystem
+    location: /srv/data
+    read-only: true
+  logs:
+    type: block
+    multiple:
+      range: 1-5
+  scratch:
+    type: filesystem
+`))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(storageUpgradeProblems(oldMeta, newMeta), jc.DeepEquals, []string{
+		`storage "cache" removed`,
+		`existing storage "data" read-only changed from false to true`,
+		`existing storage "logs" range contracted: max decreased from <unbounded> to 5`,
+		`required storage "scratch" added`,
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+// ConfigRevisionInfo defines the serialization behaviour of a recorded
+// change to the environment config or to a service's config.
+type ConfigRevisionInfo struct {
+	Revision int                `yaml:"revision" json:"revision"`
+	Time     string             `yaml:"time" json:"time"`
+	Author   string             `yaml:"author,omitempty" json:"author,omitempty"`
+	Changes  []ConfigChangeInfo `yaml:"changes" json:"changes"`
+}
+
+// ConfigChangeInfo defines the serialization behaviour of a single
+// change to a config setting.
+type ConfigChangeInfo struct {
+	Type     string      `yaml:"type" json:"type"`
+	Key      string      `yaml:"key" json:"key"`
+	OldValue interface{} `yaml:"old-value,omitempty" json:"old-value,omitempty"`
+	NewValue interface{} `yaml:"new-value,omitempty" json:"new-value,omitempty"`
+}
+
+// ConfigHistoryInfo returns the serializable form of the given config
+// revisions.
+func ConfigHistoryInfo(revisions []params.ConfigRevision) []ConfigRevisionInfo {
+	info := make([]ConfigRevisionInfo, len(revisions))
+	for i, rev := range revisions {
+		changes := make([]ConfigChangeInfo, len(rev.Changes))
+		for j, change := range rev.Changes {
+			changes[j] = ConfigChangeInfo{
+				Type:     string(change.Type),
+				Key:      change.Key,
+				OldValue: change.OldValue,
+				NewValue: change.NewValue,
+			}
+		}
+		info[i] = ConfigRevisionInfo{
+			Revision: rev.Revision,
+			Time:     rev.Time.Format(time.RFC3339),
+			Author:   rev.Author,
+			Changes:  changes,
+		}
+	}
+	return info
+}
+
+// FormatConfigHistoryTabular writes config revisions as a table, with
+// each changed setting on its own line.
+func FormatConfigHistoryTabular(value interface{}) ([]byte, error) {
+	revisions, ok := value.([]ConfigRevisionInfo)
+	if !ok {
+		return nil, errors.Errorf("expected value of type %T, got %T", revisions, value)
+	}
+	var out bytes.Buffer
+	const (
+		// To format things into columns.
+		minwidth = 0
+		tabwidth = 1
+		padding  = 2
+		padchar  = ' '
+		flags    = 0
+	)
+	tw := tabwriter.NewWriter(&out, minwidth, tabwidth, padding, padchar, flags)
+	fmt.Fprintf(tw, "REVISION\tTIME\tAUTHOR\tCHANGES\n")
+	for _, rev := range revisions {
+		author := rev.Author
+		if author == "" {
+			author = "-"
+		}
+		for i, change := range rev.Changes {
+			if i == 0 {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", rev.Revision, rev.Time, author, formatConfigChange(change))
+			} else {
+				fmt.Fprintf(tw, "\t\t\t%s\n", formatConfigChange(change))
+			}
+		}
+	}
+	tw.Flush()
+	return out.Bytes(), nil
+}
+
+func formatConfigChange(change ConfigChangeInfo) string {
+	switch params.ConfigChangeType(change.Type) {
+	case params.ConfigSettingAdded:
+		return fmt.Sprintf("%s=%v", change.Key, change.NewValue)
+	case params.ConfigSettingDeleted:
+		return fmt.Sprintf("%s unset (was %v)", change.Key, change.OldValue)
+	}
+	return fmt.Sprintf("%s=%v (was %v)", change.Key, change.NewValue, change.OldValue)
+}
@@ -0,0 +1,30 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package controller
+
+import (
+	"github.com/juju/cmd"
+
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const controllerCommandDoc = `
+"juju controller" is used to maintain the controller, the state server
+hosting the current environment and any other environments.
+`
+
+const controllerCommandPurpose = "maintain the controller"
+
+// NewSuperCommand creates the controller supercommand and registers the
+// subcommands that it supports.
+func NewSuperCommand() cmd.Command {
+	controllercmd := cmd.NewSuperCommand(cmd.SuperCommandParams{
+		Name:        "controller",
+		Doc:         controllerCommandDoc,
+		UsagePrefix: "juju",
+		Purpose:     controllerCommandPurpose,
+	})
+	controllercmd.Register(envcmd.Wrap(&GCCommand{}))
+	return controllercmd
+}
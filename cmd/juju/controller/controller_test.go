@@ -0,0 +1,30 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package controller_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/cmd/juju/controller"
+	"github.com/juju/juju/testing"
+)
+
+type controllerSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&controllerSuite{})
+
+var expectedControllerCommandNames = []string{
+	"gc",
+	"help",
+}
+
+func (s *controllerSuite) TestHelp(c *gc.C) {
+	ctx, err := testing.RunCommand(c, controller.NewSuperCommand(), "--help")
+	c.Assert(err, jc.ErrorIsNil)
+	namesFound := testing.ExtractCommandsFromHelpOutput(ctx)
+	c.Assert(namesFound, gc.DeepEquals, expectedControllerCommandNames)
+}
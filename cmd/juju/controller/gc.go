@@ -0,0 +1,199 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/blobgc"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const gcCommandDoc = `
+Remove charm archives, tools tarballs and cached container images that are
+no longer used from the controller's storage.
+
+A charm revision is unused when no service or unit refers to it; a tools
+version when no agent runs it and it is not the version the environment is
+upgrading to; and a cached image when no container of that kind and series
+exists. Blobs are only removed once they have been seen unused for longer
+than the grace period, which defaults to 24 hours, so that a charm or tools
+version just uploaded for a deployment or upgrade is not removed before it
+is used. The controller also collects unused blobs periodically.
+
+With --dry-run, the unused blobs are listed without being removed, and
+without starting their grace period.
+
+This command may only be run by the owner of the controller's environment.
+
+Examples:
+    juju controller gc --dry-run
+    juju controller gc --grace-period 1h
+`
+
+// GCCommand removes unused blobs from controller storage.
+type GCCommand struct {
+	envcmd.EnvCommandBase
+	out         cmd.Output
+	DryRun      bool
+	GracePeriod time.Duration
+}
+
+// GCAPI defines the API methods that the gc command uses.
+type GCAPI interface {
+	CollectUnusedBlobs(gracePeriod time.Duration, dryRun bool) ([]params.UnusedBlob, error)
+	Close() error
+}
+
+var getGCAPI = func(c *GCCommand) (GCAPI, error) {
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return blobgc.NewClient(root), nil
+}
+
+// BlobInfo defines the serialization behaviour of an unused blob.
+type BlobInfo struct {
+	Environment string `yaml:"environment" json:"environment"`
+	Kind        string `yaml:"kind" json:"kind"`
+	Name        string `yaml:"name" json:"name"`
+	Size        int64  `yaml:"size" json:"size"`
+	UnusedSince string `yaml:"unused-since" json:"unused-since"`
+	Status      string `yaml:"status" json:"status"`
+}
+
+const (
+	blobRemoved     = "removed"
+	blobWouldRemove = "would remove"
+	blobKept        = "kept"
+)
+
+// Info implements Command.Info.
+func (c *GCCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "gc",
+		Purpose: "remove unused blobs from controller storage",
+		Doc:     strings.TrimSpace(gcCommandDoc),
+	}
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *GCCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.DryRun, "dry-run", false, "list unused blobs without removing them")
+	f.DurationVar(&c.GracePeriod, "grace-period", 0, "how long a blob must be unused before it is removed (default 24h)")
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": formatBlobsTabular,
+	})
+}
+
+// Init implements Command.Init.
+func (c *GCCommand) Init(args []string) error {
+	if c.GracePeriod < 0 {
+		return errors.New("grace period must not be negative")
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// Run implements Command.Run.
+func (c *GCCommand) Run(ctx *cmd.Context) error {
+	client, err := getGCAPI(c)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	blobs, err := client.CollectUnusedBlobs(c.GracePeriod, c.DryRun)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(blobs) == 0 {
+		ctx.Infof("no unused blobs found")
+		return nil
+	}
+	var removed, removedSize int64
+	info := make([]BlobInfo, len(blobs))
+	for i, blob := range blobs {
+		info[i] = BlobInfo{
+			Environment: blob.EnvUUID,
+			Kind:        blob.Kind,
+			Name:        blob.Name,
+			Size:        blob.Size,
+			UnusedSince: blob.UnusedSince.Format(time.RFC3339),
+			Status:      blobKept,
+		}
+		switch {
+		case blob.Removed:
+			info[i].Status = blobRemoved
+			removed++
+			removedSize += blob.Size
+		case blob.Expired && c.DryRun:
+			info[i].Status = blobWouldRemove
+			removed++
+			removedSize += blob.Size
+		}
+	}
+	if err := c.out.Write(ctx, info); err != nil {
+		return err
+	}
+	verb := "removed"
+	if c.DryRun {
+		verb = "would remove"
+	}
+	ctx.Infof("%s %d of %d unused blobs (%s)", verb, removed, len(blobs), formatSize(removedSize))
+	return nil
+}
+
+// formatSize returns the given number of bytes in human readable form.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	value := float64(size)
+	suffix := ""
+	for _, s := range []string{"KiB", "MiB", "GiB", "TiB"} {
+		value /= unit
+		suffix = s
+		if value < unit {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f%s", value, suffix)
+}
+
+func formatBlobsTabular(value interface{}) ([]byte, error) {
+	blobs, ok := value.([]BlobInfo)
+	if !ok {
+		return nil, errors.Errorf("expected value of type %T, got %T", blobs, value)
+	}
+	var out bytes.Buffer
+	const (
+		// To format things into columns.
+		minwidth = 0
+		tabwidth = 1
+		padding  = 2
+		padchar  = ' '
+		flags    = 0
+	)
+	tw := tabwriter.NewWriter(&out, minwidth, tabwidth, padding, padchar, flags)
+	fmt.Fprintf(tw, "ENVIRONMENT\tKIND\tNAME\tSIZE\tUNUSED-SINCE\tSTATUS\n")
+	for _, blob := range blobs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			blob.Environment, blob.Kind, blob.Name, formatSize(blob.Size), blob.UnusedSince, blob.Status)
+	}
+	tw.Flush()
+	return out.Bytes(), nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package controller_test
+
+import (
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/controller"
+	"github.com/juju/juju/testing"
+)
+
+type gcCommandSuite struct {
+	testing.FakeJujuHomeSuite
+	mockAPI *fakeGCAPI
+}
+
+var _ = gc.Suite(&gcCommandSuite{})
+
+type fakeGCAPI struct {
+	gracePeriod time.Duration
+	dryRun      bool
+	blobs       []params.UnusedBlob
+	err         error
+}
+
+func (*fakeGCAPI) Close() error {
+	return nil
+}
+
+func (f *fakeGCAPI) CollectUnusedBlobs(gracePeriod time.Duration, dryRun bool) ([]params.UnusedBlob, error) {
+	f.gracePeriod = gracePeriod
+	f.dryRun = dryRun
+	return f.blobs, f.err
+}
+
+var unusedSince = time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (s *gcCommandSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.mockAPI = &fakeGCAPI{
+		blobs: []params.UnusedBlob{{
+			EnvUUID:     "env-uuid",
+			Kind:        "charm",
+			Name:        "cs:trusty/mysql-1",
+			Size:        2048,
+			UnusedSince: unusedSince,
+			Expired:     true,
+			Removed:     true,
+		}, {
+			EnvUUID:     "env-uuid",
+			Kind:        "tools",
+			Name:        "1.25.0-trusty-amd64",
+			Size:        100,
+			UnusedSince: unusedSince,
+		}},
+	}
+	s.PatchValue(controller.GetGCAPI, func(*controller.GCCommand) (controller.GCAPI, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func runGCCommand(c *gc.C, args ...string) (*cmd.Context, error) {
+	return testing.RunCommand(c, envcmd.Wrap(&controller.GCCommand{}), args...)
+}
+
+func (s *gcCommandSuite) TestInit(c *gc.C) {
+	_, err := runGCCommand(c, "foo")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["foo"\]`)
+	_, err = runGCCommand(c, "--grace-period", "-1h")
+	c.Assert(err, gc.ErrorMatches, "grace period must not be negative")
+}
+
+func (s *gcCommandSuite) TestGC(c *gc.C) {
+	ctx, err := runGCCommand(c, "--grace-period", "1h")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mockAPI.gracePeriod, gc.Equals, time.Hour)
+	c.Assert(s.mockAPI.dryRun, jc.IsFalse)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"ENVIRONMENT  KIND   NAME                 SIZE    UNUSED-SINCE          STATUS\n"+
+		"env-uuid     charm  cs:trusty/mysql-1    2.0KiB  2015-01-01T00:00:00Z  removed\n"+
+		"env-uuid     tools  1.25.0-trusty-amd64  100B    2015-01-01T00:00:00Z  kept\n")
+	c.Assert(testing.Stderr(ctx), gc.Equals, "removed 1 of 2 unused blobs (2.0KiB)\n")
+}
+
+func (s *gcCommandSuite) TestGCDryRun(c *gc.C) {
+	s.mockAPI.blobs[0].Removed = false
+	ctx, err := runGCCommand(c, "--dry-run", "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mockAPI.gracePeriod, gc.Equals, time.Duration(0))
+	c.Assert(s.mockAPI.dryRun, jc.IsTrue)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `
+- environment: env-uuid
+  kind: charm
+  name: cs:trusty/mysql-1
+  size: 2048
+  unused-since: "2015-01-01T00:00:00Z"
+  status: would remove
+- environment: env-uuid
+  kind: tools
+  name: 1.25.0-trusty-amd64
+  size: 100
+  unused-since: "2015-01-01T00:00:00Z"
+  status: kept
+`[1:])
+	c.Assert(testing.Stderr(ctx), gc.Equals, "would remove 1 of 2 unused blobs (2.0KiB)\n")
+}
+
+func (s *gcCommandSuite) TestGCNothingUnused(c *gc.C) {
+	s.mockAPI.blobs = nil
+	ctx, err := runGCCommand(c)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "")
+	c.Assert(testing.Stderr(ctx), gc.Equals, "no unused blobs found\n")
+}
+
+func (s *gcCommandSuite) TestGCError(c *gc.C) {
+	s.mockAPI.err = errors.New("permission denied")
+	_, err := runGCCommand(c)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
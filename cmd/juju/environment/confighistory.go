@@ -0,0 +1,156 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environment
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/confighistory"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/block"
+	"github.com/juju/juju/cmd/juju/common"
+)
+
+// ConfigHistoryAPI defines the API methods that the environment config
+// history commands use.
+type ConfigHistoryAPI interface {
+	Close() error
+	EnvironConfigHistory() ([]params.ConfigRevision, error)
+	RollbackEnvironConfig(revision int) error
+}
+
+// configHistoryCommandBase is a common base for the environment config
+// history commands.
+type configHistoryCommandBase struct {
+	envcmd.EnvCommandBase
+	api ConfigHistoryAPI
+}
+
+func (c *configHistoryCommandBase) getAPI() (ConfigHistoryAPI, error) {
+	if c.api != nil {
+		return c.api, nil
+	}
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, err
+	}
+	return confighistory.NewClient(root), nil
+}
+
+const configHistoryDoc = `
+Show the recorded changes to the environment configuration, newest first,
+with the user that made each change. Each change is given a revision
+number, which may be passed to "juju environment config-rollback" to
+restore the configuration as it was after that change. Only the most
+recent 50 changes are kept.
+
+Examples:
+    juju environment config-history
+    juju environment config-history --format yaml
+
+See Also:
+    juju environment config-rollback
+    juju environment set
+    juju environment unset
+`
+
+// ConfigHistoryCommand shows the changes made to the environment
+// config.
+type ConfigHistoryCommand struct {
+	configHistoryCommandBase
+	out cmd.Output
+}
+
+func (c *ConfigHistoryCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "config-history",
+		Purpose: "show changes made to the environment configuration",
+		Doc:     strings.TrimSpace(configHistoryDoc),
+	}
+}
+
+func (c *ConfigHistoryCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": common.FormatConfigHistoryTabular,
+	})
+}
+
+func (c *ConfigHistoryCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+func (c *ConfigHistoryCommand) Run(ctx *cmd.Context) error {
+	client, err := c.getAPI()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	revisions, err := client.EnvironConfigHistory()
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		ctx.Infof("no environment configuration changes recorded")
+		return nil
+	}
+	return c.out.Write(ctx, common.ConfigHistoryInfo(revisions))
+}
+
+const configRollbackDoc = `
+Restore the environment configuration to the settings it had after the
+given revision, as shown by "juju environment config-history". The
+rollback is itself recorded as a new revision. The agent version is not
+changed; use "juju upgrade-juju" for that.
+
+Examples:
+    juju environment config-rollback 12
+
+See Also:
+    juju environment config-history
+`
+
+// ConfigRollbackCommand restores an earlier revision of the
+// environment config.
+type ConfigRollbackCommand struct {
+	configHistoryCommandBase
+	Revision int
+}
+
+func (c *ConfigRollbackCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "config-rollback",
+		Args:    "<revision>",
+		Purpose: "restore an earlier environment configuration",
+		Doc:     strings.TrimSpace(configRollbackDoc),
+	}
+}
+
+func (c *ConfigRollbackCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no revision specified")
+	}
+	revision, err := strconv.Atoi(args[0])
+	if err != nil || revision < 1 {
+		return errors.Errorf("invalid revision %q", args[0])
+	}
+	c.Revision = revision
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *ConfigRollbackCommand) Run(ctx *cmd.Context) error {
+	client, err := c.getAPI()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return block.ProcessBlockedError(client.RollbackEnvironConfig(c.Revision), block.BlockChange)
+}
@@ -0,0 +1,154 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environment_test
+
+import (
+	"time"
+
+	"github.com/juju/cmd"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/environment"
+	"github.com/juju/juju/testing"
+)
+
+type configHistorySuite struct {
+	testing.FakeJujuHomeSuite
+	fake *fakeConfigHistoryAPI
+}
+
+var _ = gc.Suite(&configHistorySuite{})
+
+func (s *configHistorySuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.fake = &fakeConfigHistoryAPI{
+		revisions: []params.ConfigRevision{{
+			Revision: 2,
+			Changes: []params.ConfigChange{{
+				Type:     params.ConfigSettingModified,
+				Key:      "http-proxy",
+				OldValue: "http://proxy",
+				NewValue: "http://other-proxy",
+			}, {
+				Type:     params.ConfigSettingDeleted,
+				Key:      "no-proxy",
+				OldValue: "localhost",
+			}},
+			Author: "bob",
+			Time:   time.Date(2015, 7, 1, 12, 30, 0, 0, time.UTC),
+		}, {
+			Revision: 1,
+			Changes: []params.ConfigChange{{
+				Type:     params.ConfigSettingAdded,
+				Key:      "http-proxy",
+				NewValue: "http://proxy",
+			}},
+			Time: time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC),
+		}},
+	}
+}
+
+func (s *configHistorySuite) runHistory(c *gc.C, args ...string) (*cmd.Context, error) {
+	command := environment.NewConfigHistoryCommand(s.fake)
+	return testing.RunCommand(c, envcmd.Wrap(command), args...)
+}
+
+func (s *configHistorySuite) runRollback(c *gc.C, args ...string) (*cmd.Context, error) {
+	command := environment.NewConfigRollbackCommand(s.fake)
+	return testing.RunCommand(c, envcmd.Wrap(command), args...)
+}
+
+func (s *configHistorySuite) TestHistory(c *gc.C) {
+	context, err := s.runHistory(c)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"REVISION  TIME                  AUTHOR  CHANGES\n"+
+		"2         2015-07-01T12:30:00Z  bob     http-proxy=http://other-proxy (was http://proxy)\n"+
+		"                                        no-proxy unset (was localhost)\n"+
+		"1         2015-07-01T12:00:00Z  -       http-proxy=http://proxy\n")
+}
+
+func (s *configHistorySuite) TestHistoryYaml(c *gc.C) {
+	s.fake.revisions = s.fake.revisions[1:]
+	context, err := s.runHistory(c, "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"- revision: 1\n"+
+		"  time: \"2015-07-01T12:00:00Z\"\n"+
+		"  changes:\n"+
+		"  - type: added\n"+
+		"    key: http-proxy\n"+
+		"    new-value: http://proxy\n")
+}
+
+func (s *configHistorySuite) TestHistoryEmpty(c *gc.C) {
+	s.fake.revisions = nil
+	context, err := s.runHistory(c)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, "")
+	c.Assert(testing.Stderr(context), gc.Equals, "no environment configuration changes recorded\n")
+}
+
+func (s *configHistorySuite) TestRollbackInit(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		err: "no revision specified",
+	}, {
+		args: []string{"two"},
+		err:  `invalid revision "two"`,
+	}, {
+		args: []string{"0"},
+		err:  `invalid revision "0"`,
+	}, {
+		args: []string{"1", "2"},
+		err:  `unrecognized args: \["2"\]`,
+	}, {
+		args: []string{"1"},
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		err := testing.InitCommand(&environment.ConfigRollbackCommand{}, test.args)
+		if test.err == "" {
+			c.Check(err, jc.ErrorIsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (s *configHistorySuite) TestRollback(c *gc.C) {
+	_, err := s.runRollback(c, "1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.fake.rollback, gc.Equals, 1)
+}
+
+func (s *configHistorySuite) TestBlockRollback(c *gc.C) {
+	s.fake.err = &params.Error{Code: params.CodeOperationBlocked}
+	_, err := s.runRollback(c, "1")
+	c.Assert(err, gc.Equals, cmd.ErrSilent)
+	c.Check(c.GetTestLog(), jc.Contains, "To unblock changes")
+}
+
+type fakeConfigHistoryAPI struct {
+	revisions []params.ConfigRevision
+	rollback  int
+	err       error
+}
+
+func (f *fakeConfigHistoryAPI) Close() error {
+	return nil
+}
+
+func (f *fakeConfigHistoryAPI) EnvironConfigHistory() ([]params.ConfigRevision, error) {
+	return f.revisions, nil
+}
+
+func (f *fakeConfigHistoryAPI) RollbackEnvironConfig(revision int) error {
+	f.rollback = revision
+	return f.err
+}
@@ -0,0 +1,172 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environment
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/environmentmanager"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/block"
+	"github.com/juju/juju/environs/configstore"
+)
+
+// DestroyCommand calls the API to destroy a hosted environment.
+type DestroyCommand struct {
+	envcmd.EnvCommandBase
+	api DestroyEnvironmentAPI
+	// These attributes are exported only for testing purposes.
+	Name      string
+	AssumeYes bool
+	NoWait    bool
+}
+
+const destroyEnvHelpDoc = `
+This command will destroy an environment hosted by the current Juju
+Environment Server, along with all of its services and machines. The
+environment's machines are stopped by the server, and the environment is
+removed once they have all gone; progress is reported until then, unless
+--no-wait is specified.
+
+The environment to destroy is named as it was when it was created with
+"juju environment create", and must not be the current environment; use -e
+to select another environment on the same server. The state server
+environment itself must be destroyed with "juju destroy-environment".
+`
+
+const destroyHostedEnvMsg = `
+WARNING! this command will destroy the %q environment
+This includes all machines, services, data and other resources.
+
+Continue [y/N]? `[1:]
+
+// destroyPollInterval is the time between checks on the progress of an
+// environment's destruction.
+var destroyPollInterval = 5 * time.Second
+
+func (c *DestroyCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "destroy",
+		Args:    "<name>",
+		Purpose: "destroy an environment hosted by the Juju Environment Server",
+		Doc:     strings.TrimSpace(destroyEnvHelpDoc),
+	}
+}
+
+func (c *DestroyCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.AssumeYes, "y", false, "do not ask for confirmation")
+	f.BoolVar(&c.AssumeYes, "yes", false, "")
+	f.BoolVar(&c.NoWait, "no-wait", false, "do not wait for the environment to be removed")
+}
+
+func (c *DestroyCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("environment name is required")
+	}
+	c.Name = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+type DestroyEnvironmentAPI interface {
+	Close() error
+	DestroyEnvironment(uuid string) error
+	EnvironmentStatus(uuid string) (params.EnvironmentStatus, error)
+}
+
+func (c *DestroyCommand) getAPI() (DestroyEnvironmentAPI, error) {
+	if c.api != nil {
+		return c.api, nil
+	}
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, err
+	}
+	return environmentmanager.NewClient(root), nil
+}
+
+func (c *DestroyCommand) Run(ctx *cmd.Context) error {
+	if c.Name == c.ConnectionName() {
+		return errors.Errorf("cannot destroy the current environment %q, use -e to select another environment on the same server", c.Name)
+	}
+	store, err := configstore.Default()
+	if err != nil {
+		return errors.Annotate(err, "cannot open environment info storage")
+	}
+	info, err := store.ReadInfo(c.Name)
+	if err != nil {
+		return errors.Annotate(err, "cannot read environment info")
+	}
+	uuid := info.APIEndpoint().EnvironUUID
+	if uuid == "" {
+		return errors.Errorf("environment %q has no UUID", c.Name)
+	}
+
+	if !c.AssumeYes {
+		fmt.Fprintf(ctx.Stdout, destroyHostedEnvMsg, c.Name)
+		scanner := bufio.NewScanner(ctx.Stdin)
+		scanner.Scan()
+		err := scanner.Err()
+		if err != nil && err != io.EOF {
+			return errors.Annotate(err, "environment destruction aborted")
+		}
+		answer := strings.ToLower(scanner.Text())
+		if answer != "y" && answer != "yes" {
+			return errors.New("environment destruction aborted")
+		}
+	}
+
+	client, err := c.getAPI()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.DestroyEnvironment(uuid); err != nil {
+		if params.IsCodeNotFound(err) {
+			ctx.Infof("environment %q not found, removing config file", c.Name)
+			return info.Destroy()
+		}
+		return block.ProcessBlockedError(err, block.BlockDestroy)
+	}
+	if c.NoWait {
+		ctx.Infof("environment %q is being destroyed", c.Name)
+		return info.Destroy()
+	}
+	if err := waitForRemoval(ctx, client, c.Name, uuid); err != nil {
+		return errors.Trace(err)
+	}
+	ctx.Infof("environment %q destroyed", c.Name)
+	return info.Destroy()
+}
+
+// waitForRemoval reports the progress of the destruction of the
+// environment until it has been removed.
+func waitForRemoval(ctx *cmd.Context, client DestroyEnvironmentAPI, name, uuid string) error {
+	var last params.EnvironmentStatus
+	for first := true; ; first = false {
+		status, err := client.EnvironmentStatus(uuid)
+		if params.IsCodeNotFound(err) {
+			return nil
+		} else if err != nil {
+			return errors.Annotate(err, "cannot get environment status")
+		}
+		if first || status != last {
+			ctx.Infof(
+				"waiting for environment %q: %d machine(s), %d service(s) remaining",
+				name, status.MachineCount, status.ServiceCount,
+			)
+			last = status
+		}
+		time.Sleep(destroyPollInterval)
+	}
+}
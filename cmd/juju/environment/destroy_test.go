@@ -0,0 +1,198 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environment_test
+
+import (
+	"bytes"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/environment"
+	"github.com/juju/juju/environs/configstore"
+	"github.com/juju/juju/feature"
+	"github.com/juju/juju/testing"
+)
+
+const hostedUUID = "deadbeef-0bad-400d-8000-4b1d0d06f00d"
+
+type destroySuite struct {
+	testing.FakeJujuHomeSuite
+	fake  *fakeDestroyClient
+	store configstore.Storage
+}
+
+var _ = gc.Suite(&destroySuite{})
+
+func (s *destroySuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.SetFeatureFlags(feature.JES)
+	s.PatchValue(environment.DestroyPollInterval, 0)
+	s.fake = &fakeDestroyClient{}
+	store := configstore.Default
+	s.AddCleanup(func(*gc.C) {
+		configstore.Default = store
+	})
+	s.store = configstore.NewMem()
+	configstore.Default = func() (configstore.Storage, error) {
+		return s.store, nil
+	}
+	// Set up the current environment and a hosted environment on
+	// the same server.
+	for name, uuid := range map[string]string{
+		"test-master": "fake-server-uuid",
+		"hosted":      hostedUUID,
+	} {
+		info := s.store.CreateInfo(name)
+		info.SetAPIEndpoint(configstore.APIEndpoint{
+			Addresses:   []string{"localhost"},
+			CACert:      testing.CACert,
+			EnvironUUID: uuid,
+			ServerUUID:  "fake-server-uuid",
+		})
+		info.SetAPICredentials(configstore.APICredentials{User: "bob", Password: "sekrit"})
+		err := info.Write()
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	err := envcmd.WriteCurrentEnvironment("test-master")
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *destroySuite) run(c *gc.C, args ...string) (*cmd.Context, error) {
+	command := environment.NewDestroyCommand(s.fake)
+	return testing.RunCommand(c, envcmd.Wrap(command), args...)
+}
+
+func (s *destroySuite) assertInfoRemoved(c *gc.C, removed bool) {
+	_, err := s.store.ReadInfo("hosted")
+	if removed {
+		c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	} else {
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *destroySuite) TestInit(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+		name string
+	}{{
+		err: "environment name is required",
+	}, {
+		args: []string{"hosted"},
+		name: "hosted",
+	}, {
+		args: []string{"hosted", "extra"},
+		err:  `unrecognized args: \["extra"\]`,
+	}} {
+		c.Logf("test %d", i)
+		command := environment.NewDestroyCommand(nil)
+		err := testing.InitCommand(command, test.args)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(command.Name, gc.Equals, test.name)
+	}
+}
+
+func (s *destroySuite) TestDestroyCurrentEnvironmentRefused(c *gc.C) {
+	_, err := s.run(c, "test-master", "-y")
+	c.Assert(err, gc.ErrorMatches, `cannot destroy the current environment "test-master", use -e to select another environment on the same server`)
+	c.Assert(s.fake.destroyed, gc.Equals, "")
+}
+
+func (s *destroySuite) TestDestroyAborted(c *gc.C) {
+	ctx := testing.Context(c)
+	ctx.Stdin = bytes.NewBufferString("n\n")
+	command := envcmd.Wrap(environment.NewDestroyCommand(s.fake))
+	err := testing.InitCommand(command, []string{"hosted"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = command.Run(ctx)
+	c.Assert(err, gc.ErrorMatches, "environment destruction aborted")
+	c.Assert(testing.Stdout(ctx), gc.Matches, `(?s)WARNING! this command will destroy the "hosted" environment.*`)
+	c.Assert(s.fake.destroyed, gc.Equals, "")
+	s.assertInfoRemoved(c, false)
+}
+
+func (s *destroySuite) TestDestroyWaitsForRemoval(c *gc.C) {
+	s.fake.statuses = []params.EnvironmentStatus{
+		{Life: params.Dying, MachineCount: 2, ServiceCount: 1},
+		{Life: params.Dying, MachineCount: 2, ServiceCount: 1},
+		{Life: params.Dying, MachineCount: 1},
+	}
+	ctx, err := s.run(c, "hosted", "-y")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.fake.destroyed, gc.Equals, hostedUUID)
+	c.Assert(testing.Stderr(ctx), gc.Equals, `
+waiting for environment "hosted": 2 machine(s), 1 service(s) remaining
+waiting for environment "hosted": 1 machine(s), 0 service(s) remaining
+environment "hosted" destroyed
+`[1:])
+	s.assertInfoRemoved(c, true)
+}
+
+func (s *destroySuite) TestDestroyNoWait(c *gc.C) {
+	s.fake.statuses = []params.EnvironmentStatus{{Life: params.Dying, MachineCount: 2}}
+	ctx, err := s.run(c, "hosted", "-y", "--no-wait")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.fake.destroyed, gc.Equals, hostedUUID)
+	c.Assert(testing.Stderr(ctx), gc.Equals, "environment \"hosted\" is being destroyed\n")
+	c.Assert(s.fake.statuses, gc.HasLen, 1)
+	s.assertInfoRemoved(c, true)
+}
+
+func (s *destroySuite) TestDestroyBlocked(c *gc.C) {
+	s.fake.err = common.ErrOperationBlocked("TestDestroyBlocked")
+	_, err := s.run(c, "hosted", "-y")
+	c.Assert(err, gc.Equals, cmd.ErrSilent)
+	s.assertInfoRemoved(c, false)
+}
+
+func (s *destroySuite) TestDestroyNotFoundRemovesInfo(c *gc.C) {
+	s.fake.err = &params.Error{Code: params.CodeNotFound, Message: "environment not found"}
+	_, err := s.run(c, "hosted", "-y")
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertInfoRemoved(c, true)
+}
+
+// fakeDestroyClient is used to mock out the behavior of the real
+// environment manager API.
+type fakeDestroyClient struct {
+	destroyed string
+	err       error
+	// statuses are returned by successive EnvironmentStatus calls;
+	// once exhausted, the environment is reported as not found.
+	statuses []params.EnvironmentStatus
+}
+
+var _ environment.DestroyEnvironmentAPI = (*fakeDestroyClient)(nil)
+
+func (*fakeDestroyClient) Close() error {
+	return nil
+}
+
+func (f *fakeDestroyClient) DestroyEnvironment(uuid string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.destroyed = uuid
+	return nil
+}
+
+func (f *fakeDestroyClient) EnvironmentStatus(uuid string) (params.EnvironmentStatus, error) {
+	if len(f.statuses) == 0 {
+		return params.EnvironmentStatus{}, &params.Error{Code: params.CodeNotFound, Message: "environment not found"}
+	}
+	status := f.statuses[0]
+	f.statuses = f.statuses[1:]
+	return status, nil
+}
@@ -30,15 +30,20 @@ func NewSuperCommand() cmd.Command {
 	environmentCmd.Register(envcmd.Wrap(&GetCommand{}))
 	environmentCmd.Register(envcmd.Wrap(&SetCommand{}))
 	environmentCmd.Register(envcmd.Wrap(&UnsetCommand{}))
+	environmentCmd.Register(envcmd.Wrap(&ConfigHistoryCommand{}))
+	environmentCmd.Register(envcmd.Wrap(&ConfigRollbackCommand{}))
 	environmentCmd.Register(&JenvCommand{})
 	environmentCmd.Register(envcmd.Wrap(&RetryProvisioningCommand{}))
 	environmentCmd.Register(envcmd.Wrap(&EnvSetConstraintsCommand{}))
 	environmentCmd.Register(envcmd.Wrap(&EnvGetConstraintsCommand{}))
+	environmentCmd.Register(NewQuotaCommand())
 
 	if featureflag.Enabled(feature.JES) {
 		environmentCmd.Register(envcmd.Wrap(&ShareCommand{}))
 		environmentCmd.Register(envcmd.Wrap(&UnshareCommand{}))
 		environmentCmd.Register(envcmd.Wrap(&CreateCommand{}))
+		environmentCmd.Register(envcmd.Wrap(&DestroyCommand{}))
+		environmentCmd.Register(envcmd.Wrap(&MigrateCommand{}))
 		environmentCmd.Register(envcmd.Wrap(&UsersCommand{}))
 	}
 	return environmentCmd
@@ -24,11 +24,16 @@ type EnvironmentCommandSuite struct {
 var _ = gc.Suite(&EnvironmentCommandSuite{})
 
 var expectedCommmandNames = []string{
+	"config-history",
+	"config-rollback",
 	"create",
+	"destroy",
 	"get",
 	"get-constraints",
 	"help",
 	"jenv",
+	"migrate",
+	"quota",
 	"retry-provisioning",
 	"set",
 	"set-constraints",
@@ -48,7 +53,7 @@ func (s *EnvironmentCommandSuite) TestHelpCommands(c *gc.C) {
 
 	// Remove "share" for the first test because the feature is not
 	// enabled.
-	devFeatures := set.NewStrings("create", "share", "unshare", "users")
+	devFeatures := set.NewStrings("create", "destroy", "migrate", "share", "unshare", "users")
 
 	// Remove features behind dev_flag for the first test since they are not
 	// enabled.
@@ -6,6 +6,7 @@ package environment
 var (
 	SetConfigSpecialCaseDefaults = setConfigSpecialCaseDefaults
 	UserCurrent                  = &userCurrent
+	DestroyPollInterval          = &destroyPollInterval
 )
 
 // NewGetCommand returns a GetCommand with the api provided as specified.
@@ -63,3 +64,46 @@ func NewUsersCommand(api UsersAPI) *UsersCommand {
 		api: api,
 	}
 }
+
+// NewDestroyCommand returns a DestroyCommand with the api provided as specified.
+func NewDestroyCommand(api DestroyEnvironmentAPI) *DestroyCommand {
+	return &DestroyCommand{
+		api: api,
+	}
+}
+
+// NewMigrateCommand returns a MigrateCommand with the apis provided as specified.
+func NewMigrateCommand(source MigrateSourceAPI, target MigrateTargetAPI) *MigrateCommand {
+	return &MigrateCommand{
+		sourceAPI: source,
+		targetAPI: target,
+	}
+}
+
+// NewQuotaShowCommand returns a QuotaShowCommand with the api provided as specified.
+func NewQuotaShowCommand(api QuotaAPI) *QuotaShowCommand {
+	return &QuotaShowCommand{
+		quotaCommandBase: quotaCommandBase{api: api},
+	}
+}
+
+// NewQuotaSetCommand returns a QuotaSetCommand with the api provided as specified.
+func NewQuotaSetCommand(api QuotaAPI) *QuotaSetCommand {
+	return &QuotaSetCommand{
+		quotaCommandBase: quotaCommandBase{api: api},
+	}
+}
+
+// NewConfigHistoryCommand returns a ConfigHistoryCommand with the api provided as specified.
+func NewConfigHistoryCommand(api ConfigHistoryAPI) *ConfigHistoryCommand {
+	return &ConfigHistoryCommand{
+		configHistoryCommandBase: configHistoryCommandBase{api: api},
+	}
+}
+
+// NewConfigRollbackCommand returns a ConfigRollbackCommand with the api provided as specified.
+func NewConfigRollbackCommand(api ConfigHistoryAPI) *ConfigRollbackCommand {
+	return &ConfigRollbackCommand{
+		configHistoryCommandBase: configHistoryCommandBase{api: api},
+	}
+}
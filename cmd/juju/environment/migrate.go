@@ -0,0 +1,271 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environment
+
+import (
+	"io"
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api"
+	"github.com/juju/juju/api/environmentmanager"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/block"
+	"github.com/juju/juju/environs/configstore"
+	"github.com/juju/juju/juju"
+)
+
+// MigrateCommand moves a hosted environment to another Juju
+// Environment Server.
+type MigrateCommand struct {
+	envcmd.EnvCommandBase
+	sourceAPI MigrateSourceAPI
+	targetAPI MigrateTargetAPI
+	// These attributes are exported only for testing purposes.
+	Name string
+	To   string
+}
+
+const migrateEnvHelpDoc = `
+This command moves an environment hosted by the current Juju Environment
+Server to another one, named by the state server environment given with
+--to. The environment's documents, charms, resources and tools are
+copied to the new server, and the environment's agents are then told to
+connect to it; its machines and services keep running throughout.
+
+While the environment is being copied, the current server refuses any
+changes to it, so that none are lost. Its agents are told to trust the
+new server's CA certificate before they are sent there, so the two
+servers need not share one. The new server must be running the same or
+a newer version of Juju. If it refuses the environment, or the migration
+cannot be completed, the environment is left on the current server and
+changes to it are allowed again.
+
+The environment is named as it was when it was created with "juju
+environment create". Once it has moved, the local connection details
+for the environment are updated to refer to the new server.
+
+Examples:
+    juju environment migrate -e old-server myenv --to new-server
+`
+
+func (c *MigrateCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "migrate",
+		Args:    "<name> --to <state server environment>",
+		Purpose: "move an environment to another Juju Environment Server",
+		Doc:     strings.TrimSpace(migrateEnvHelpDoc),
+	}
+}
+
+func (c *MigrateCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.To, "to", "", "the state server environment of the server to move to")
+}
+
+func (c *MigrateCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("environment name is required")
+	}
+	if c.To == "" {
+		return errors.New("--to must specify the state server environment to move to")
+	}
+	c.Name = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+// MigrateSourceAPI defines the methods of the current server's API
+// used to migrate an environment.
+type MigrateSourceAPI interface {
+	Close() error
+	ExportEnvironment(uuid, targetCACert string) (params.EnvironmentExport, error)
+	AbortEnvironmentExport(uuid string) error
+	SetEnvironmentMigrated(uuid string, servers [][]params.HostPort) error
+	OpenBlob(path string) (io.ReadCloser, int64, error)
+	OpenTools(tools params.ExportedTools) (io.ReadCloser, error)
+}
+
+// MigrateTargetAPI defines the methods of the new server's API used
+// to migrate an environment.
+type MigrateTargetAPI interface {
+	Close() error
+	ImportEnvironment(export params.EnvironmentExport) error
+	AddBlob(uuid, path string, content io.Reader, size int64) error
+	AddTools(uuid string, tools params.ExportedTools, content io.Reader) error
+	CompleteEnvironmentImport(uuid string) ([][]params.HostPort, error)
+	AbortEnvironmentImport(uuid string) error
+}
+
+// migrateAPI combines the environment manager and migration clients,
+// and adds a Close method.
+type migrateAPI struct {
+	*environmentmanager.Client
+	*environmentmanager.MigrationClient
+	root *api.State
+}
+
+func (m migrateAPI) Close() error {
+	return m.root.Close()
+}
+
+func newMigrateAPI(root *api.State) migrateAPI {
+	return migrateAPI{
+		Client:          environmentmanager.NewClient(root),
+		MigrationClient: environmentmanager.NewMigrationClient(root),
+		root:            root,
+	}
+}
+
+func (c *MigrateCommand) getSourceAPI() (MigrateSourceAPI, error) {
+	if c.sourceAPI != nil {
+		return c.sourceAPI, nil
+	}
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, err
+	}
+	return newMigrateAPI(root), nil
+}
+
+func (c *MigrateCommand) getTargetAPI() (MigrateTargetAPI, error) {
+	if c.targetAPI != nil {
+		return c.targetAPI, nil
+	}
+	root, err := juju.NewAPIFromName(c.To)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot connect to %q", c.To)
+	}
+	return newMigrateAPI(root), nil
+}
+
+func (c *MigrateCommand) Run(ctx *cmd.Context) error {
+	store, err := configstore.Default()
+	if err != nil {
+		return errors.Annotate(err, "cannot open environment info storage")
+	}
+	info, err := store.ReadInfo(c.Name)
+	if err != nil {
+		return errors.Annotate(err, "cannot read environment info")
+	}
+	endpoint := info.APIEndpoint()
+	uuid := endpoint.EnvironUUID
+	if uuid == "" {
+		return errors.Errorf("environment %q has no UUID", c.Name)
+	}
+	targetInfo, err := store.ReadInfo(c.To)
+	if err != nil {
+		return errors.Annotatef(err, "cannot read environment info for %q", c.To)
+	}
+	targetEndpoint := targetInfo.APIEndpoint()
+	if targetEndpoint.ServerUUID != "" && targetEndpoint.ServerUUID == endpoint.ServerUUID {
+		return errors.Errorf("environment %q is already hosted by %q", c.Name, c.To)
+	}
+
+	source, err := c.getSourceAPI()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := c.getTargetAPI()
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	ctx.Infof("exporting environment %q", c.Name)
+	export, err := source.ExportEnvironment(uuid, targetEndpoint.CACert)
+	if err != nil {
+		if params.IsCodeOperationBlocked(err) {
+			return block.ProcessBlockedError(err, block.BlockChange)
+		}
+		return errors.Annotate(err, "cannot export environment")
+	}
+	ctx.Infof("importing environment %q into %q", c.Name, c.To)
+	if err := target.ImportEnvironment(export); err != nil {
+		err = errors.Annotatef(err, "%q refused environment %q", c.To, c.Name)
+		if abortErr := source.AbortEnvironmentExport(uuid); abortErr != nil {
+			return errors.Annotatef(abortErr, "cannot abort export after migration failed: %v", err)
+		}
+		return err
+	}
+	servers, err := c.copyEnvironment(ctx, source, target, uuid, export)
+	if err == nil {
+		err = source.SetEnvironmentMigrated(uuid, servers)
+	}
+	if err != nil {
+		ctx.Infof("rolling back import of environment %q into %q", c.Name, c.To)
+		if abortErr := target.AbortEnvironmentImport(uuid); abortErr != nil {
+			return errors.Annotatef(abortErr, "cannot roll back import after migration failed: %v", err)
+		}
+		if abortErr := source.AbortEnvironmentExport(uuid); abortErr != nil {
+			return errors.Annotatef(abortErr, "cannot abort export after migration failed: %v", err)
+		}
+		return errors.Annotate(err, "cannot complete migration")
+	}
+
+	// The environment's agents now connect to the new server, and so
+	// should we.
+	endpoint.Addresses = targetEndpoint.Addresses
+	endpoint.Hostnames = targetEndpoint.Hostnames
+	endpoint.CACert = targetEndpoint.CACert
+	endpoint.ServerUUID = targetEndpoint.ServerUUID
+	info.SetAPIEndpoint(endpoint)
+	if err := info.Write(); err != nil {
+		return errors.Annotate(err, "cannot update environment info")
+	}
+	ctx.Infof("environment %q migrated to %q", c.Name, c.To)
+	return nil
+}
+
+// copyEnvironment copies the charm archives, resources and tools of
+// the exported environment to the new server, one at a time, and
+// returns the addresses of the new server once it has accepted the
+// environment.
+func (c *MigrateCommand) copyEnvironment(
+	ctx *cmd.Context,
+	source MigrateSourceAPI,
+	target MigrateTargetAPI,
+	uuid string,
+	export params.EnvironmentExport,
+) ([][]params.HostPort, error) {
+	ctx.Infof("copying %d charms, %d resources and %d tools",
+		len(export.Charms), len(export.Resources), len(export.Tools))
+	paths := append(append([]string{}, export.Charms...), export.Resources...)
+	for _, path := range paths {
+		if err := copyBlob(source, target, uuid, path); err != nil {
+			return nil, errors.Annotatef(err, "cannot copy %q", path)
+		}
+	}
+	for _, tools := range export.Tools {
+		if err := copyTools(source, target, uuid, tools); err != nil {
+			return nil, errors.Annotatef(err, "cannot copy %v tools", tools.Version)
+		}
+	}
+	servers, err := target.CompleteEnvironmentImport(uuid)
+	if err != nil {
+		return nil, errors.Annotatef(err, "%q refused environment %q", c.To, c.Name)
+	}
+	return servers, nil
+}
+
+func copyBlob(source MigrateSourceAPI, target MigrateTargetAPI, uuid, path string) error {
+	content, size, err := source.OpenBlob(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer content.Close()
+	return target.AddBlob(uuid, path, content, size)
+}
+
+func copyTools(source MigrateSourceAPI, target MigrateTargetAPI, uuid string, tools params.ExportedTools) error {
+	content, err := source.OpenTools(tools)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer content.Close()
+	return target.AddTools(uuid, tools, content)
+}
@@ -0,0 +1,339 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environment_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/environment"
+	"github.com/juju/juju/environs/configstore"
+	"github.com/juju/juju/feature"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
+)
+
+type migrateSuite struct {
+	testing.FakeJujuHomeSuite
+	source *fakeMigrateSource
+	target *fakeMigrateTarget
+	store  configstore.Storage
+}
+
+var _ = gc.Suite(&migrateSuite{})
+
+var targetServers = [][]params.HostPort{
+	params.FromNetworkHostPorts(network.NewHostPorts(17070, "10.0.0.1")),
+}
+
+var exportedTools = params.ExportedTools{
+	Version: version.MustParseBinary("1.25.0-trusty-amd64"),
+	Size:    5,
+	SHA256:  "fake-sha256",
+}
+
+func (s *migrateSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.SetFeatureFlags(feature.JES)
+	s.source = &fakeMigrateSource{
+		export: params.EnvironmentExport{
+			Owner:     "user-bob@local",
+			Charms:    []string{"charms/wordpress"},
+			Resources: []string{"resources/wordpress/data"},
+			Tools:     []params.ExportedTools{exportedTools},
+		},
+		blobs: map[string]string{
+			"charms/wordpress":         "charm",
+			"resources/wordpress/data": "resource",
+			exportedTools.SHA256:       "tools",
+		},
+	}
+	s.target = &fakeMigrateTarget{
+		servers: targetServers,
+		blobs:   make(map[string]string),
+	}
+	store := configstore.Default
+	s.AddCleanup(func(*gc.C) {
+		configstore.Default = store
+	})
+	s.store = configstore.NewMem()
+	configstore.Default = func() (configstore.Storage, error) {
+		return s.store, nil
+	}
+	// Set up the current environment and a hosted environment on one
+	// server, and the state server environment of another.
+	for name, endpoint := range map[string]configstore.APIEndpoint{
+		"test-master": {
+			Addresses:   []string{"old-server"},
+			EnvironUUID: "fake-server-uuid",
+			ServerUUID:  "fake-server-uuid",
+		},
+		"hosted": {
+			Addresses:   []string{"old-server"},
+			EnvironUUID: hostedUUID,
+			ServerUUID:  "fake-server-uuid",
+		},
+		"new-master": {
+			Addresses:   []string{"new-server"},
+			EnvironUUID: "new-server-uuid",
+			ServerUUID:  "new-server-uuid",
+		},
+	} {
+		endpoint.CACert = testing.CACert
+		if name == "new-master" {
+			endpoint.CACert = testing.OtherCACert
+		}
+		info := s.store.CreateInfo(name)
+		info.SetAPIEndpoint(endpoint)
+		info.SetAPICredentials(configstore.APICredentials{User: "bob", Password: "sekrit"})
+		err := info.Write()
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	err := envcmd.WriteCurrentEnvironment("test-master")
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *migrateSuite) run(c *gc.C, args ...string) (*cmd.Context, error) {
+	command := environment.NewMigrateCommand(s.source, s.target)
+	return testing.RunCommand(c, envcmd.Wrap(command), args...)
+}
+
+func (s *migrateSuite) assertServer(c *gc.C, addr, serverUUID string) {
+	info, err := s.store.ReadInfo("hosted")
+	c.Assert(err, jc.ErrorIsNil)
+	endpoint := info.APIEndpoint()
+	c.Assert(endpoint.Addresses, jc.DeepEquals, []string{addr})
+	c.Assert(endpoint.EnvironUUID, gc.Equals, hostedUUID)
+	c.Assert(endpoint.ServerUUID, gc.Equals, serverUUID)
+}
+
+func (s *migrateSuite) TestInit(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+		name string
+		to   string
+	}{{
+		err: "environment name is required",
+	}, {
+		args: []string{"hosted"},
+		err:  "--to must specify the state server environment to move to",
+	}, {
+		args: []string{"hosted", "--to", "new-master"},
+		name: "hosted",
+		to:   "new-master",
+	}, {
+		args: []string{"hosted", "extra", "--to", "new-master"},
+		err:  `unrecognized args: \["extra"\]`,
+	}} {
+		c.Logf("test %d", i)
+		command := environment.NewMigrateCommand(nil, nil)
+		err := testing.InitCommand(command, test.args)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(command.Name, gc.Equals, test.name)
+		c.Check(command.To, gc.Equals, test.to)
+	}
+}
+
+func (s *migrateSuite) TestMigrate(c *gc.C) {
+	ctx, err := s.run(c, "hosted", "--to", "new-master")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.source.targetCACert, gc.Equals, testing.OtherCACert)
+	c.Assert(s.target.imported, jc.DeepEquals, &s.source.export)
+	c.Assert(s.target.blobs, jc.DeepEquals, map[string]string{
+		"charms/wordpress":         "charm",
+		"resources/wordpress/data": "resource",
+		"1.25.0-trusty-amd64":      "tools",
+	})
+	c.Assert(s.target.completed, gc.Equals, hostedUUID)
+	c.Assert(s.source.migratedTo, jc.DeepEquals, targetServers)
+	c.Assert(s.target.aborted, gc.Equals, "")
+	c.Assert(s.source.aborted, gc.Equals, "")
+	c.Assert(testing.Stderr(ctx), gc.Equals, `
+exporting environment "hosted"
+importing environment "hosted" into "new-master"
+copying 1 charms, 1 resources and 1 tools
+environment "hosted" migrated to "new-master"
+`[1:])
+	s.assertServer(c, "new-server", "new-server-uuid")
+}
+
+func (s *migrateSuite) TestMigrateToSameServer(c *gc.C) {
+	_, err := s.run(c, "hosted", "--to", "test-master")
+	c.Assert(err, gc.ErrorMatches, `environment "hosted" is already hosted by "test-master"`)
+	c.Assert(s.target.imported, gc.IsNil)
+}
+
+func (s *migrateSuite) TestMigrateBlocked(c *gc.C) {
+	s.source.exportErr = common.ErrOperationBlocked("TestMigrateBlocked")
+	_, err := s.run(c, "hosted", "--to", "new-master")
+	c.Assert(err, gc.Equals, cmd.ErrSilent)
+	c.Assert(s.target.imported, gc.IsNil)
+	s.assertServer(c, "old-server", "fake-server-uuid")
+}
+
+func (s *migrateSuite) TestMigrateRefusedByTarget(c *gc.C) {
+	s.target.importErr = errors.New("environment agent version 1.26.0 is newer than the state server's 1.25.0")
+	_, err := s.run(c, "hosted", "--to", "new-master")
+	c.Assert(err, gc.ErrorMatches, `"new-master" refused environment "hosted": environment agent version .*`)
+	c.Assert(s.source.migratedTo, gc.IsNil)
+	c.Assert(s.source.aborted, gc.Equals, hostedUUID)
+	c.Assert(s.target.aborted, gc.Equals, "")
+	s.assertServer(c, "old-server", "fake-server-uuid")
+}
+
+func (s *migrateSuite) TestMigrateCopyFails(c *gc.C) {
+	s.target.addErr = errors.New("boom")
+	ctx, err := s.run(c, "hosted", "--to", "new-master")
+	c.Assert(err, gc.ErrorMatches, `cannot complete migration: cannot copy "charms/wordpress": boom`)
+	c.Assert(s.target.completed, gc.Equals, "")
+	c.Assert(s.source.migratedTo, gc.IsNil)
+	c.Assert(s.target.aborted, gc.Equals, hostedUUID)
+	c.Assert(s.source.aborted, gc.Equals, hostedUUID)
+	c.Assert(testing.Stderr(ctx), jc.Contains, `rolling back import of environment "hosted" into "new-master"`)
+	s.assertServer(c, "old-server", "fake-server-uuid")
+}
+
+func (s *migrateSuite) TestMigrateRollsBack(c *gc.C) {
+	s.source.migratedErr = errors.New("boom")
+	ctx, err := s.run(c, "hosted", "--to", "new-master")
+	c.Assert(err, gc.ErrorMatches, "cannot complete migration: boom")
+	c.Assert(s.target.aborted, gc.Equals, hostedUUID)
+	c.Assert(s.source.aborted, gc.Equals, hostedUUID)
+	c.Assert(testing.Stderr(ctx), jc.Contains, `rolling back import of environment "hosted" into "new-master"`)
+	s.assertServer(c, "old-server", "fake-server-uuid")
+}
+
+func (s *migrateSuite) TestMigrateRollBackFails(c *gc.C) {
+	s.source.migratedErr = errors.New("boom")
+	s.target.abortErr = errors.New("bang")
+	_, err := s.run(c, "hosted", "--to", "new-master")
+	c.Assert(err, gc.ErrorMatches, "cannot roll back import after migration failed: boom: bang")
+	s.assertServer(c, "old-server", "fake-server-uuid")
+}
+
+// fakeMigrateSource is used to mock out the API of the server the
+// environment is migrated from.
+type fakeMigrateSource struct {
+	export       params.EnvironmentExport
+	exportErr    error
+	targetCACert string
+	blobs        map[string]string
+	aborted      string
+	migratedTo   [][]params.HostPort
+	migratedErr  error
+}
+
+var _ environment.MigrateSourceAPI = (*fakeMigrateSource)(nil)
+
+func (*fakeMigrateSource) Close() error {
+	return nil
+}
+
+func (f *fakeMigrateSource) ExportEnvironment(uuid, targetCACert string) (params.EnvironmentExport, error) {
+	if f.exportErr != nil {
+		return params.EnvironmentExport{}, f.exportErr
+	}
+	f.targetCACert = targetCACert
+	return f.export, nil
+}
+
+func (f *fakeMigrateSource) AbortEnvironmentExport(uuid string) error {
+	f.aborted = uuid
+	return nil
+}
+
+func (f *fakeMigrateSource) OpenBlob(path string) (io.ReadCloser, int64, error) {
+	content, ok := f.blobs[path]
+	if !ok {
+		return nil, 0, errors.NotFoundf("%q", path)
+	}
+	return ioutil.NopCloser(bytes.NewBufferString(content)), int64(len(content)), nil
+}
+
+func (f *fakeMigrateSource) OpenTools(tools params.ExportedTools) (io.ReadCloser, error) {
+	r, _, err := f.OpenBlob(tools.SHA256)
+	return r, err
+}
+
+func (f *fakeMigrateSource) SetEnvironmentMigrated(uuid string, servers [][]params.HostPort) error {
+	if f.migratedErr != nil {
+		return f.migratedErr
+	}
+	f.migratedTo = servers
+	return nil
+}
+
+// fakeMigrateTarget is used to mock out the API of the server the
+// environment is migrated to.
+type fakeMigrateTarget struct {
+	servers   [][]params.HostPort
+	imported  *params.EnvironmentExport
+	importErr error
+	blobs     map[string]string
+	addErr    error
+	completed string
+	aborted   string
+	abortErr  error
+}
+
+var _ environment.MigrateTargetAPI = (*fakeMigrateTarget)(nil)
+
+func (*fakeMigrateTarget) Close() error {
+	return nil
+}
+
+func (f *fakeMigrateTarget) ImportEnvironment(export params.EnvironmentExport) error {
+	if f.importErr != nil {
+		return f.importErr
+	}
+	f.imported = &export
+	return nil
+}
+
+func (f *fakeMigrateTarget) AddBlob(uuid, path string, content io.Reader, size int64) error {
+	if f.addErr != nil {
+		return f.addErr
+	}
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return errors.Errorf("expected %d bytes, got %d", size, len(data))
+	}
+	f.blobs[path] = string(data)
+	return nil
+}
+
+func (f *fakeMigrateTarget) AddTools(uuid string, tools params.ExportedTools, content io.Reader) error {
+	return f.AddBlob(uuid, tools.Version.String(), content, tools.Size)
+}
+
+func (f *fakeMigrateTarget) CompleteEnvironmentImport(uuid string) ([][]params.HostPort, error) {
+	f.completed = uuid
+	return f.servers, nil
+}
+
+func (f *fakeMigrateTarget) AbortEnvironmentImport(uuid string) error {
+	if f.abortErr != nil {
+		return f.abortErr
+	}
+	f.aborted = uuid
+	return nil
+}
@@ -0,0 +1,362 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environment
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils/keyvalues"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/quota"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/block"
+)
+
+const quotaCommandDoc = `
+"juju environment quota" is used to manage the resource quotas placed on
+environments and on the users that own them. A quota limits the number of
+machines and units, and the storage, that may be added to an environment
+(or to all of a user's environments), and may restrict new machines to
+particular instance types. Only the administrator of the Juju Environment
+Server may set quotas; anyone with access to an environment may see its
+quota.
+`
+
+// NewQuotaCommand creates the quota supercommand and registers the
+// subcommands that it supports.
+func NewQuotaCommand() cmd.Command {
+	quotacmd := cmd.NewSuperCommand(cmd.SuperCommandParams{
+		Name:        "quota",
+		Doc:         quotaCommandDoc,
+		UsagePrefix: "juju environment",
+		Purpose:     "manage resource quotas",
+	})
+	quotacmd.Register(envcmd.Wrap(&QuotaShowCommand{}))
+	quotacmd.Register(envcmd.Wrap(&QuotaSetCommand{}))
+	return quotacmd
+}
+
+// QuotaAPI defines the API methods that the quota commands use.
+type QuotaAPI interface {
+	Close() error
+	EnvironQuota() (params.QuotaResult, error)
+	SetEnvironQuota(quota params.Quota) error
+	OwnerQuota(owner names.UserTag) (params.QuotaResult, error)
+	SetOwnerQuota(owner names.UserTag, quota params.Quota) error
+}
+
+// quotaCommandBase is a common base for the quota commands.
+type quotaCommandBase struct {
+	envcmd.EnvCommandBase
+	api   QuotaAPI
+	Owner string
+}
+
+func (c *quotaCommandBase) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.Owner, "owner", "", "the user whose quota to manage, instead of the environment's")
+}
+
+func (c *quotaCommandBase) Init() error {
+	if c.Owner != "" && !names.IsValidUser(c.Owner) {
+		return errors.Errorf("invalid username: %q", c.Owner)
+	}
+	return nil
+}
+
+func (c *quotaCommandBase) getAPI() (QuotaAPI, error) {
+	if c.api != nil {
+		return c.api, nil
+	}
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, err
+	}
+	return quota.NewClient(root), nil
+}
+
+func (c *quotaCommandBase) getQuota(client QuotaAPI) (params.QuotaResult, error) {
+	if c.Owner != "" {
+		return client.OwnerQuota(names.NewUserTag(c.Owner))
+	}
+	return client.EnvironQuota()
+}
+
+const quotaShowDoc = `
+Show the quota placed on the current environment, and the resources the
+environment uses. With --owner, the quota placed on all the environments
+owned by the given user is shown instead, with the resources they use
+between them.
+
+Only top level machines that do not run a state server, and principal
+units, are counted against a quota. Storage is counted by the size
+requested for each storage instance.
+
+Examples:
+    juju environment quota show
+    juju environment quota show --owner bob
+
+See Also:
+    juju environment quota set
+`
+
+// QuotaShowCommand shows a quota and the resources counted against it.
+type QuotaShowCommand struct {
+	quotaCommandBase
+	out cmd.Output
+}
+
+// QuotaInfo defines the serialization behaviour of a quota and the
+// resources counted against it.
+type QuotaInfo struct {
+	Machines      QuotaValue `yaml:"machines" json:"machines"`
+	Units         QuotaValue `yaml:"units" json:"units"`
+	StorageGiB    QuotaValue `yaml:"storage-gib" json:"storage-gib"`
+	InstanceTypes []string   `yaml:"instance-types,omitempty" json:"instance-types,omitempty"`
+}
+
+// QuotaValue holds the usage and limit of a single resource.
+type QuotaValue struct {
+	Used  string `yaml:"used" json:"used"`
+	Limit string `yaml:"limit" json:"limit"`
+}
+
+func (c *QuotaShowCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "show",
+		Purpose: "show a resource quota and usage",
+		Doc:     strings.TrimSpace(quotaShowDoc),
+	}
+}
+
+func (c *QuotaShowCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.quotaCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": formatQuotaTabular,
+	})
+}
+
+func (c *QuotaShowCommand) Init(args []string) error {
+	if err := c.quotaCommandBase.Init(); err != nil {
+		return err
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *QuotaShowCommand) Run(ctx *cmd.Context) error {
+	client, err := c.getAPI()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	result, err := c.getQuota(client)
+	if err != nil {
+		return err
+	}
+	q, usage := result.Quota, result.Usage
+	info := QuotaInfo{
+		Machines:      QuotaValue{fmt.Sprint(usage.Machines), formatIntLimit(q.MaxMachines)},
+		Units:         QuotaValue{fmt.Sprint(usage.Units), formatIntLimit(q.MaxUnits)},
+		StorageGiB:    QuotaValue{formatStorageGiB(usage.StorageMiB), "unlimited"},
+		InstanceTypes: q.InstanceTypes,
+	}
+	if q.MaxStorageGiB != nil {
+		info.StorageGiB.Limit = fmt.Sprint(*q.MaxStorageGiB)
+	}
+	return c.out.Write(ctx, info)
+}
+
+func formatIntLimit(limit *int) string {
+	if limit == nil {
+		return "unlimited"
+	}
+	return fmt.Sprint(*limit)
+}
+
+func formatStorageGiB(mib uint64) string {
+	return strconv.FormatFloat(float64(mib)/1024, 'f', -1, 64)
+}
+
+func formatQuotaTabular(value interface{}) ([]byte, error) {
+	info, ok := value.(QuotaInfo)
+	if !ok {
+		return nil, errors.Errorf("expected value of type %T, got %T", info, value)
+	}
+	var out bytes.Buffer
+	const (
+		// To format things into columns.
+		minwidth = 0
+		tabwidth = 1
+		padding  = 2
+		padchar  = ' '
+		flags    = 0
+	)
+	tw := tabwriter.NewWriter(&out, minwidth, tabwidth, padding, padchar, flags)
+	fmt.Fprintf(tw, "RESOURCE\tUSED\tLIMIT\n")
+	fmt.Fprintf(tw, "machines\t%s\t%s\n", info.Machines.Used, info.Machines.Limit)
+	fmt.Fprintf(tw, "units\t%s\t%s\n", info.Units.Used, info.Units.Limit)
+	fmt.Fprintf(tw, "storage (GiB)\t%s\t%s\n", info.StorageGiB.Used, info.StorageGiB.Limit)
+	instanceTypes := strings.Join(info.InstanceTypes, ",")
+	if instanceTypes == "" {
+		instanceTypes = "any"
+	}
+	fmt.Fprintf(tw, "instance types\t\t%s\n", instanceTypes)
+	tw.Flush()
+	return out.Bytes(), nil
+}
+
+const quotaSetDoc = `
+Change the quota placed on the current environment or, with --owner, on
+all the environments owned by the given user. Limits not given are left
+unchanged; a limit is removed by setting it to "unlimited", or for
+instance-types, to the empty string. Existing resources are unaffected if
+a limit is lowered below the current usage, but no more may be added.
+
+The following limits may be set:
+    max-machines      the number of machines
+    max-units         the number of units
+    max-storage-gib   the total size of storage, in GiB
+    instance-types    comma-separated list of the instance types that new
+                      machines may use; new machines must then be given an
+                      instance-type constraint naming one of them
+
+Examples:
+    juju environment quota set max-machines=10 max-units=20
+    juju environment quota set --owner bob max-storage-gib=500
+    juju environment quota set instance-types=m1.small,m1.medium
+    juju environment quota set max-units=unlimited
+
+See Also:
+    juju environment quota show
+`
+
+// QuotaSetCommand changes a quota.
+type QuotaSetCommand struct {
+	quotaCommandBase
+	Values map[string]string
+}
+
+var quotaKeys = []string{"max-machines", "max-units", "max-storage-gib", "instance-types"}
+
+func (c *QuotaSetCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "set",
+		Args:    "<limit>=<value> ...",
+		Purpose: "change a resource quota",
+		Doc:     strings.TrimSpace(quotaSetDoc),
+	}
+}
+
+func (c *QuotaSetCommand) Init(args []string) error {
+	if err := c.quotaCommandBase.Init(); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("no limits specified")
+	}
+	values, err := keyvalues.Parse(args, true)
+	if err != nil {
+		return err
+	}
+	for key := range values {
+		known := false
+		for _, quotaKey := range quotaKeys {
+			known = known || key == quotaKey
+		}
+		if !known {
+			return errors.Errorf("unknown limit %q", key)
+		}
+	}
+	// Check the values now, so they are known to be valid when
+	// applied in Run.
+	if err := applyQuotaValues(&params.Quota{}, values); err != nil {
+		return err
+	}
+	c.Values = values
+	return nil
+}
+
+// applyQuotaValues updates the given quota with the given limits.
+func applyQuotaValues(q *params.Quota, values map[string]string) error {
+	parseLimit := func(key string) (*uint64, error) {
+		value := values[key]
+		if value == "unlimited" {
+			return nil, nil
+		}
+		n, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return nil, errors.Errorf("%s must be a non-negative number or \"unlimited\", not %q", key, value)
+		}
+		return &n, nil
+	}
+	intLimit := func(limit *uint64) *int {
+		if limit == nil {
+			return nil
+		}
+		n := int(*limit)
+		return &n
+	}
+	for key := range values {
+		switch key {
+		case "max-machines":
+			limit, err := parseLimit(key)
+			if err != nil {
+				return err
+			}
+			q.MaxMachines = intLimit(limit)
+		case "max-units":
+			limit, err := parseLimit(key)
+			if err != nil {
+				return err
+			}
+			q.MaxUnits = intLimit(limit)
+		case "max-storage-gib":
+			limit, err := parseLimit(key)
+			if err != nil {
+				return err
+			}
+			q.MaxStorageGiB = limit
+		case "instance-types":
+			q.InstanceTypes = nil
+			for _, instanceType := range strings.Split(values[key], ",") {
+				if instanceType = strings.TrimSpace(instanceType); instanceType != "" {
+					q.InstanceTypes = append(q.InstanceTypes, instanceType)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *QuotaSetCommand) Run(ctx *cmd.Context) error {
+	client, err := c.getAPI()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	result, err := c.getQuota(client)
+	if err != nil {
+		return err
+	}
+	q := result.Quota
+	if err := applyQuotaValues(&q, c.Values); err != nil {
+		return err
+	}
+	if c.Owner != "" {
+		err = client.SetOwnerQuota(names.NewUserTag(c.Owner), q)
+	} else {
+		err = client.SetEnvironQuota(q)
+	}
+	return block.ProcessBlockedError(err, block.BlockChange)
+}
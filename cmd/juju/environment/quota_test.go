@@ -0,0 +1,176 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environment_test
+
+import (
+	"github.com/juju/cmd"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/environment"
+	"github.com/juju/juju/testing"
+)
+
+type quotaSuite struct {
+	testing.FakeJujuHomeSuite
+	fake *fakeQuotaAPI
+}
+
+var _ = gc.Suite(&quotaSuite{})
+
+func (s *quotaSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	maxUnits := 10
+	s.fake = &fakeQuotaAPI{
+		result: params.QuotaResult{
+			Quota: params.Quota{
+				MaxUnits:      &maxUnits,
+				InstanceTypes: []string{"m1.small", "m1.medium"},
+			},
+			Usage: params.QuotaUsage{
+				Machines:   2,
+				Units:      3,
+				StorageMiB: 1536,
+			},
+		},
+	}
+}
+
+func (s *quotaSuite) runShow(c *gc.C, args ...string) (*cmd.Context, error) {
+	command := environment.NewQuotaShowCommand(s.fake)
+	return testing.RunCommand(c, envcmd.Wrap(command), args...)
+}
+
+func (s *quotaSuite) runSet(c *gc.C, args ...string) (*cmd.Context, error) {
+	command := environment.NewQuotaSetCommand(s.fake)
+	return testing.RunCommand(c, envcmd.Wrap(command), args...)
+}
+
+func (s *quotaSuite) TestShow(c *gc.C) {
+	context, err := s.runShow(c)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.fake.owner, gc.IsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"RESOURCE        USED  LIMIT\n"+
+		"machines        2     unlimited\n"+
+		"units           3     10\n"+
+		"storage (GiB)   1.5   unlimited\n"+
+		"instance types        m1.small,m1.medium\n")
+}
+
+func (s *quotaSuite) TestShowOwnerYaml(c *gc.C) {
+	context, err := s.runShow(c, "--owner", "bob", "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.fake.owner, gc.NotNil)
+	c.Assert(*s.fake.owner, gc.Equals, names.NewUserTag("bob"))
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"machines:\n"+
+		"  used: \"2\"\n"+
+		"  limit: unlimited\n"+
+		"units:\n"+
+		"  used: \"3\"\n"+
+		"  limit: \"10\"\n"+
+		"storage-gib:\n"+
+		"  used: \"1.5\"\n"+
+		"  limit: unlimited\n"+
+		"instance-types:\n"+
+		"- m1.small\n"+
+		"- m1.medium\n")
+}
+
+func (s *quotaSuite) TestShowInvalidOwner(c *gc.C) {
+	_, err := s.runShow(c, "--owner", "not valid/0")
+	c.Assert(err, gc.ErrorMatches, `invalid username: "not valid/0"`)
+}
+
+func (s *quotaSuite) TestSetInit(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		err: "no limits specified",
+	}, {
+		args: []string{"max-widgets=1"},
+		err:  `unknown limit "max-widgets"`,
+	}, {
+		args: []string{"max-units=-1"},
+		err:  `max-units must be a non-negative number or "unlimited", not "-1"`,
+	}, {
+		args: []string{"max-units"},
+		err:  `expected "key=value", got "max-units"`,
+	}, {
+		args: []string{"max-units=1", "instance-types="},
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		err := testing.InitCommand(&environment.QuotaSetCommand{}, test.args)
+		if test.err == "" {
+			c.Check(err, jc.ErrorIsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (s *quotaSuite) TestSet(c *gc.C) {
+	_, err := s.runSet(c, "max-machines=5", "max-units=unlimited", "max-storage-gib=100")
+	c.Assert(err, jc.ErrorIsNil)
+	maxMachines := 5
+	maxStorage := uint64(100)
+	// Limits not given are left unchanged.
+	c.Assert(s.fake.set, jc.DeepEquals, &params.Quota{
+		MaxMachines:   &maxMachines,
+		MaxStorageGiB: &maxStorage,
+		InstanceTypes: []string{"m1.small", "m1.medium"},
+	})
+	c.Assert(s.fake.owner, gc.IsNil)
+}
+
+func (s *quotaSuite) TestSetOwnerInstanceTypes(c *gc.C) {
+	_, err := s.runSet(c, "--owner", "bob", "instance-types=")
+	c.Assert(err, jc.ErrorIsNil)
+	maxUnits := 10
+	c.Assert(s.fake.set, jc.DeepEquals, &params.Quota{MaxUnits: &maxUnits})
+	c.Assert(*s.fake.owner, gc.Equals, names.NewUserTag("bob"))
+}
+
+func (s *quotaSuite) TestBlockSet(c *gc.C) {
+	s.fake.err = &params.Error{Code: params.CodeOperationBlocked}
+	_, err := s.runSet(c, "max-units=1")
+	c.Assert(err, gc.Equals, cmd.ErrSilent)
+	c.Check(c.GetTestLog(), jc.Contains, "To unblock changes")
+}
+
+type fakeQuotaAPI struct {
+	result params.QuotaResult
+	owner  *names.UserTag
+	set    *params.Quota
+	err    error
+}
+
+func (f *fakeQuotaAPI) Close() error {
+	return nil
+}
+
+func (f *fakeQuotaAPI) EnvironQuota() (params.QuotaResult, error) {
+	return f.result, nil
+}
+
+func (f *fakeQuotaAPI) SetEnvironQuota(quota params.Quota) error {
+	f.set = &quota
+	return f.err
+}
+
+func (f *fakeQuotaAPI) OwnerQuota(owner names.UserTag) (params.QuotaResult, error) {
+	f.owner = &owner
+	return f.result, nil
+}
+
+func (f *fakeQuotaAPI) SetOwnerQuota(owner names.UserTag, quota params.Quota) error {
+	f.owner = &owner
+	f.set = &quota
+	return f.err
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/juju/juju/api/machinemanager"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cloudconfig/cloudinit"
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/cmd/juju/block"
 	"github.com/juju/juju/constraints"
@@ -62,6 +63,28 @@ information about how to allocate the machine. For example, one can direct the
 MAAS provider to acquire a particular node by specifying its hostname with
 "--to". For more information on placement directives, see "juju help placement".
 
+Extra cloud-init directives for the new machines may be given in a
+cloud-config YAML file with "--cloudinit-userdata". Its packages, bootcmd,
+runcmd and write_files directives are added to those Juju generates, after
+any set with the cloudinit-userdata environment setting. This is not
+supported for manually provisioned machines.
+
+Many existing machines may be manually provisioned at once by listing them
+in a YAML inventory file given with "--inventory". Hosts are provisioned in
+parallel, without prompting, so each login must be able to use sudo without
+a password. A failure to provision one host does not stop the others, and
+the outcome for each host is reported once all have finished. For example:
+
+   user: admin                  # default ssh login
+   key: ~/.ssh/rack.pem         # default ssh private key
+   hosts:
+   - host: 10.10.0.3
+     tags: [rack1, ssd]         # recorded on the machine
+     zone: rack1                # recorded on the machine
+   - host: 10.10.0.4
+     user: ubuntu
+     key: ~/.ssh/other.pem
+
 Examples:
    juju machine add                      (starts a new machine)
    juju machine add -n 2                 (starts 2 new machines)
@@ -71,6 +94,8 @@ Examples:
    juju machine add --constraints mem=8G (starts a machine with at least 8GB RAM)
    juju machine add ssh:user@10.10.0.3   (manually provisions a machine with ssh)
    juju machine add zone=us-east-1a
+   juju machine add --cloudinit-userdata monitoring.yaml
+   juju machine add --inventory hosts.yaml (manually provisions the listed hosts)
 
 See Also:
    juju help constraints
@@ -105,12 +130,16 @@ type AddCommand struct {
 	NumMachines int
 	// Disks describes disks that are to be attached to the machine.
 	Disks []storage.Constraints
+	// UserData is a file holding cloud-init user data for the machine.
+	UserData cmd.FileVar
+	// Inventory is a file listing hosts to be manually provisioned.
+	Inventory cmd.FileVar
 }
 
 func (c *AddCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "add",
-		Args:    "[<container>:machine | <container> | ssh:[user@]host | placement]",
+		Args:    "[<container>:machine | <container> | ssh:[user@]host | placement | --inventory <file>]",
 		Purpose: "start a new, empty machine and optionally a container, or add a container to a machine",
 		Doc:     addMachineDoc,
 	}
@@ -121,6 +150,8 @@ func (c *AddCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.IntVar(&c.NumMachines, "n", 1, "The number of machines to add")
 	f.Var(constraints.ConstraintsValue{Target: &c.Constraints}, "constraints", "additional machine constraints")
 	f.Var(disksFlag{&c.Disks}, "disks", "constraints for disks to attach to the machine")
+	f.Var(&c.UserData, "cloudinit-userdata", "path to a cloud-config file with extra cloud-init directives")
+	f.Var(&c.Inventory, "inventory", "path to a YAML file listing hosts to manually provision")
 }
 
 func (c *AddCommand) Init(args []string) error {
@@ -142,6 +173,17 @@ func (c *AddCommand) Init(args []string) error {
 	if c.NumMachines > 1 && c.Placement != nil && c.Placement.Directive != "" {
 		return fmt.Errorf("cannot use -n when specifying a placement directive")
 	}
+	if c.UserData.Path != "" && c.Placement != nil && c.Placement.Scope == "ssh" {
+		return fmt.Errorf("cannot use --cloudinit-userdata with manual provisioning")
+	}
+	if c.Inventory.Path != "" {
+		if c.Placement != nil {
+			return fmt.Errorf("cannot use --inventory with a placement directive")
+		}
+		if c.NumMachines != 1 || c.Series != "" || !constraints.IsEmpty(&c.Constraints) || len(c.Disks) > 0 || c.UserData.Path != "" {
+			return fmt.Errorf("cannot use --inventory with -n, --series, --constraints, --disks or --cloudinit-userdata")
+		}
+	}
 	return nil
 }
 
@@ -212,6 +254,22 @@ func (c *AddCommand) Run(ctx *cmd.Context) error {
 		return err
 	}
 
+	if c.Inventory.Path != "" {
+		data, err := c.Inventory.Read(ctx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		hosts, err := parseInventory(data)
+		if err != nil {
+			return errors.Annotatef(err, "invalid inventory %q", c.Inventory.Path)
+		}
+		logger.Infof("manual provisioning of %d hosts", len(hosts))
+		return provisionInventory(ctx, client, hosts, &params.UpdateBehavior{
+			config.EnableOSRefreshUpdate(),
+			config.EnableOSUpgrade(),
+		})
+	}
+
 	if c.Placement != nil && c.Placement.Scope == "ssh" {
 		logger.Infof("manual provisioning")
 		args := manual.ProvisionMachineArgs{
@@ -242,6 +300,18 @@ func (c *AddCommand) Run(ctx *cmd.Context) error {
 		return fmt.Errorf("machine-id cannot be specified when adding machines")
 	}
 
+	var userData string
+	if c.UserData.Path != "" {
+		data, err := c.UserData.Read(ctx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if _, err := cloudinit.ParseUserData(string(data)); err != nil {
+			return errors.Annotatef(err, "invalid cloud-init user data in %q", c.UserData.Path)
+		}
+		userData = string(data)
+	}
+
 	jobs := []multiwatcher.MachineJob{multiwatcher.JobHostUnits}
 
 	envVersion, err := envcmd.GetEnvironmentVersion(client)
@@ -263,11 +333,12 @@ func (c *AddCommand) Run(ctx *cmd.Context) error {
 	}
 
 	machineParams := params.AddMachineParams{
-		Placement:   c.Placement,
-		Series:      c.Series,
-		Constraints: c.Constraints,
-		Jobs:        jobs,
-		Disks:       c.Disks,
+		Placement:         c.Placement,
+		Series:            c.Series,
+		Constraints:       c.Constraints,
+		Jobs:              jobs,
+		Disks:             c.Disks,
+		CloudInitUserData: userData,
 	}
 	machines := make([]params.AddMachineParams, c.NumMachines)
 	for i := 0; i < c.NumMachines; i++ {
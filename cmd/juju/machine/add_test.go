@@ -4,8 +4,11 @@
 package machine_test
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
@@ -93,6 +96,15 @@ func (s *AddMachineSuite) TestInit(c *gc.C) {
 			args:      []string{"something:special"},
 			count:     1,
 			placement: "something:special",
+		}, {
+			args:        []string{"--cloudinit-userdata", "userdata.yaml", "ssh:user@10.10.0.3"},
+			errorString: `cannot use --cloudinit-userdata with manual provisioning`,
+		}, {
+			args:        []string{"--inventory", "hosts.yaml", "ssh:user@10.10.0.3"},
+			errorString: `cannot use --inventory with a placement directive`,
+		}, {
+			args:        []string{"--inventory", "hosts.yaml", "-n", "2"},
+			errorString: `cannot use --inventory with -n, --series, --constraints, --disks or --cloudinit-userdata`,
 		},
 	} {
 		c.Logf("test %d", i)
@@ -220,6 +232,134 @@ func (s *AddMachineSuite) TestAddMachineWithDisksUnsupported(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "cannot add machines with disks: not supported by the API server")
 }
 
+func (s *AddMachineSuite) TestAddMachineWithUserData(c *gc.C) {
+	userData := "packages: [htop]\nruncmd: [echo hello]\n"
+	path := filepath.Join(c.MkDir(), "userdata.yaml")
+	err := ioutil.WriteFile(path, []byte(userData), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.run(c, "--cloudinit-userdata", path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.fakeAddMachine.args, gc.HasLen, 1)
+	c.Assert(s.fakeAddMachine.args[0].CloudInitUserData, gc.Equals, userData)
+}
+
+func (s *AddMachineSuite) TestAddMachineWithInvalidUserData(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "userdata.yaml")
+	err := ioutil.WriteFile(path, []byte("users: [bob]\n"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.run(c, "--cloudinit-userdata", path)
+	c.Assert(err, gc.ErrorMatches, `invalid cloud-init user data in ".*userdata.yaml": cloud-init directive "users" in user data not supported`)
+	c.Assert(s.fakeAddMachine.args, gc.HasLen, 0)
+}
+
+const testInventory = `
+user: admin
+key: /keys/rack.pem
+hosts:
+- host: 10.10.0.3
+  tags: [rack1, ssd]
+  zone: rack1
+- host: 10.10.0.4
+- host: 10.10.0.5
+  user: ubuntu
+  key: /keys/other.pem
+`
+
+func (s *AddMachineSuite) writeInventory(c *gc.C, content string) string {
+	path := filepath.Join(c.MkDir(), "hosts.yaml")
+	err := ioutil.WriteFile(path, []byte(content), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	return path
+}
+
+func (s *AddMachineSuite) TestAddMachineInventory(c *gc.C) {
+	var mu sync.Mutex
+	provisioned := make(map[string]manual.ProvisionMachineArgs)
+	s.PatchValue(machine.ManualProvisioner, func(args manual.ProvisionMachineArgs) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		provisioned[args.Host] = args
+		switch args.Host {
+		case "admin@10.10.0.3":
+			return "1", nil
+		case "ubuntu@10.10.0.5":
+			return "2", nil
+		}
+		return "", errors.New("no route to host")
+	})
+	context, err := s.run(c, "--inventory", s.writeInventory(c, testInventory))
+	c.Assert(err, gc.ErrorMatches, "failed to provision 1 of 3 hosts")
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"10.10.0.3: created machine 1\n"+
+		"10.10.0.4: failed: no route to host\n"+
+		"10.10.0.5: created machine 2\n",
+	)
+
+	c.Assert(provisioned, gc.HasLen, 3)
+	args := provisioned["admin@10.10.0.3"]
+	c.Assert(args.IdentityFile, gc.Equals, "/keys/rack.pem")
+	c.Assert(args.Tags, jc.DeepEquals, []string{"rack1", "ssd"})
+	c.Assert(args.Zone, gc.Equals, "rack1")
+	c.Assert(args.Client, gc.Equals, s.fakeAddMachine)
+	args = provisioned["admin@10.10.0.4"]
+	c.Assert(args.IdentityFile, gc.Equals, "/keys/rack.pem")
+	c.Assert(args.Tags, gc.HasLen, 0)
+	args = provisioned["ubuntu@10.10.0.5"]
+	c.Assert(args.IdentityFile, gc.Equals, "/keys/other.pem")
+}
+
+func (s *AddMachineSuite) TestAddMachineInventoryBadKeyPath(c *gc.C) {
+	var mu sync.Mutex
+	var provisioned []string
+	s.PatchValue(machine.ManualProvisioner, func(args manual.ProvisionMachineArgs) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		provisioned = append(provisioned, args.Host)
+		return "1", nil
+	})
+	inventory := "" +
+		"hosts:\n" +
+		"- host: 10.10.0.3\n" +
+		"  key: ~no-such-user-for-juju-tests/key.pem\n" +
+		"- host: 10.10.0.4\n"
+	context, err := s.run(c, "--inventory", s.writeInventory(c, inventory))
+	c.Assert(err, gc.ErrorMatches, "failed to provision 1 of 2 hosts")
+	c.Assert(testing.Stdout(context), gc.Matches, ""+
+		`10.10.0.3: failed: cannot read key path "~no-such-user-for-juju-tests/key.pem": .*\n`+
+		"10.10.0.4: created machine 1\n",
+	)
+	c.Assert(provisioned, jc.DeepEquals, []string{"10.10.0.4"})
+}
+
+func (s *AddMachineSuite) TestAddMachineInvalidInventory(c *gc.C) {
+	s.PatchValue(machine.ManualProvisioner, func(args manual.ProvisionMachineArgs) (string, error) {
+		c.Fatalf("unexpected provisioning of %q", args.Host)
+		return "", nil
+	})
+	for i, test := range []struct {
+		content string
+		err     string
+	}{{
+		content: "hosts: []\n",
+		err:     "inventory lists no hosts",
+	}, {
+		content: "hosts:\n- user: ubuntu\n",
+		err:     "inventory host 1 has no address",
+	}, {
+		content: "hosts:\n- host: ubuntu@10.10.0.3\n",
+		err:     `inventory host "ubuntu@10.10.0.3": specify the login with user, not in the address`,
+	}, {
+		content: "hosts:\n- host: 10.10.0.3\n- host: 10.10.0.3\n",
+		err:     `inventory host "10.10.0.3" listed more than once`,
+	}} {
+		c.Logf("test %d", i)
+		_, err := s.run(c, "--inventory", s.writeInventory(c, test.content))
+		c.Check(err, gc.ErrorMatches, `invalid inventory ".*hosts.yaml": `+test.err)
+	}
+}
+
 type fakeAddMachineAPI struct {
 	successOrder []bool
 	currentOp    int
@@ -0,0 +1,157 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package machine
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/utils"
+	"github.com/juju/utils/set"
+	"gopkg.in/yaml.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs/manual"
+)
+
+// maxParallelProvisioning is the maximum number of hosts
+// in an inventory that are provisioned at the same time.
+var maxParallelProvisioning = 10
+
+// inventory holds the hosts listed in an inventory file, along with
+// the ssh login and key used for hosts that do not specify their own.
+type inventory struct {
+	User  string          `yaml:"user,omitempty"`
+	Key   string          `yaml:"key,omitempty"`
+	Hosts []inventoryHost `yaml:"hosts"`
+}
+
+// inventoryHost describes a host to be manually provisioned.
+type inventoryHost struct {
+	Host string   `yaml:"host"`
+	User string   `yaml:"user,omitempty"`
+	Key  string   `yaml:"key,omitempty"`
+	Tags []string `yaml:"tags,omitempty"`
+	Zone string   `yaml:"zone,omitempty"`
+}
+
+// sshHost returns the [user@]host used to log in to the host.
+func (h inventoryHost) sshHost() string {
+	if h.User == "" {
+		return h.Host
+	}
+	return h.User + "@" + h.Host
+}
+
+// parseInventory parses the YAML inventory in data, returning the
+// hosts it lists with the inventory's default login and key applied.
+func parseInventory(data []byte) ([]inventoryHost, error) {
+	var inv inventory
+	if err := yaml.Unmarshal(data, &inv); err != nil {
+		return nil, errors.Annotate(err, "cannot parse inventory")
+	}
+	if len(inv.Hosts) == 0 {
+		return nil, errors.New("inventory lists no hosts")
+	}
+	seen := make(set.Strings)
+	hosts := make([]inventoryHost, len(inv.Hosts))
+	for i, host := range inv.Hosts {
+		if host.Host == "" {
+			return nil, errors.Errorf("inventory host %d has no address", i+1)
+		}
+		if strings.Contains(host.Host, "@") {
+			return nil, errors.Errorf("inventory host %q: specify the login with user, not in the address", host.Host)
+		}
+		if seen.Contains(host.Host) {
+			return nil, errors.Errorf("inventory host %q listed more than once", host.Host)
+		}
+		seen.Add(host.Host)
+		if host.User == "" {
+			host.User = inv.User
+		}
+		if host.Key == "" {
+			host.Key = inv.Key
+		}
+		hosts[i] = host
+	}
+	return hosts, nil
+}
+
+// inventoryResult records the outcome of provisioning an inventory host.
+type inventoryResult struct {
+	host      string
+	machineId string
+	err       error
+}
+
+// provisionInventory manually provisions the given hosts in parallel.
+// A failure to provision one host does not stop the others; the
+// outcome for each host is written to ctx once all have finished.
+func provisionInventory(
+	ctx *cmd.Context, client manual.ProvisioningClientAPI, hosts []inventoryHost, updateBehavior *params.UpdateBehavior,
+) error {
+	results := make([]inventoryResult, len(hosts))
+	limit := make(chan struct{}, maxParallelProvisioning)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		key := host.Key
+		if key != "" {
+			path, err := utils.NormalizePath(key)
+			if err != nil {
+				results[i] = inventoryResult{
+					host: host.Host,
+					err:  errors.Annotatef(err, "cannot read key path %q", key),
+				}
+				continue
+			}
+			key = ctx.AbsPath(path)
+		}
+		// Provisioning runs unattended, so there is nobody to answer
+		// sudo prompts and progress is only reported on failure.
+		args := manual.ProvisionMachineArgs{
+			Host:           host.sshHost(),
+			Client:         client,
+			Stdin:          strings.NewReader(""),
+			IdentityFile:   key,
+			Tags:           host.Tags,
+			Zone:           host.Zone,
+			UpdateBehavior: updateBehavior,
+		}
+		wg.Add(1)
+		go func(i int, host string, args manual.ProvisionMachineArgs) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			var progress bytes.Buffer
+			args.Stdout = &progress
+			args.Stderr = &progress
+			logger.Infof("provisioning %s", host)
+			machineId, err := manualProvisioner(args)
+			if err != nil {
+				logger.Debugf("provisioning output for %s:\n%s", host, progress.String())
+			}
+			results[i] = inventoryResult{host: host, machineId: machineId, err: err}
+		}(i, host.Host, args)
+	}
+	wg.Wait()
+
+	var failed int
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			fmt.Fprintf(ctx.Stdout, "%s: failed: %v\n", result.host, result.err)
+			continue
+		}
+		fmt.Fprintf(ctx.Stdout, "%s: created machine %v\n", result.host, result.machineId)
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to provision %d of %d hosts", failed, len(results))
+	}
+	return nil
+}
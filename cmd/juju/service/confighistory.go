@@ -0,0 +1,178 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package service
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/api/confighistory"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/block"
+	"github.com/juju/juju/cmd/juju/common"
+)
+
+// ConfigHistoryAPI defines the API methods that the service config
+// history commands use.
+type ConfigHistoryAPI interface {
+	Close() error
+	ServiceConfigHistory(service string) ([]params.ConfigRevision, error)
+	RollbackServiceConfig(service string, revision int) error
+}
+
+// configHistoryCommandBase is a common base for the service config
+// history commands.
+type configHistoryCommandBase struct {
+	envcmd.EnvCommandBase
+	api         ConfigHistoryAPI
+	ServiceName string
+}
+
+func (c *configHistoryCommandBase) initService(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, errors.New("no service name specified")
+	}
+	if !names.IsValidService(args[0]) {
+		return nil, errors.Errorf("invalid service name %q", args[0])
+	}
+	c.ServiceName = args[0]
+	return args[1:], nil
+}
+
+func (c *configHistoryCommandBase) getAPI() (ConfigHistoryAPI, error) {
+	if c.api != nil {
+		return c.api, nil
+	}
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, err
+	}
+	return confighistory.NewClient(root), nil
+}
+
+const configHistoryDoc = `
+Show the recorded changes to the configuration of the specified service,
+newest first, with the user that made each change. Each change is given a
+revision number, which may be passed to "juju service config-rollback" to
+restore the configuration as it was after that change. Only the most
+recent 50 changes are kept for each service.
+
+Examples:
+    juju service config-history mysql
+    juju service config-history --format yaml mysql
+
+See Also:
+    juju service config-rollback
+    juju service set
+    juju service unset
+`
+
+// ConfigHistoryCommand shows the changes made to a service's config.
+type ConfigHistoryCommand struct {
+	configHistoryCommandBase
+	out cmd.Output
+}
+
+func (c *ConfigHistoryCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "config-history",
+		Args:    "<service>",
+		Purpose: "show changes made to a service's configuration",
+		Doc:     strings.TrimSpace(configHistoryDoc),
+	}
+}
+
+func (c *ConfigHistoryCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": common.FormatConfigHistoryTabular,
+	})
+}
+
+func (c *ConfigHistoryCommand) Init(args []string) error {
+	args, err := c.initService(args)
+	if err != nil {
+		return err
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *ConfigHistoryCommand) Run(ctx *cmd.Context) error {
+	client, err := c.getAPI()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	revisions, err := client.ServiceConfigHistory(c.ServiceName)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		ctx.Infof("no configuration changes recorded for service %q", c.ServiceName)
+		return nil
+	}
+	return c.out.Write(ctx, common.ConfigHistoryInfo(revisions))
+}
+
+const configRollbackDoc = `
+Restore the configuration of the specified service to the settings it had
+after the given revision, as shown by "juju service config-history". The
+rollback is itself recorded as a new revision. The settings must be valid
+for the service's current charm.
+
+Examples:
+    juju service config-rollback mysql 3
+
+See Also:
+    juju service config-history
+`
+
+// ConfigRollbackCommand restores an earlier revision of a service's
+// config.
+type ConfigRollbackCommand struct {
+	configHistoryCommandBase
+	Revision int
+}
+
+func (c *ConfigRollbackCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "config-rollback",
+		Args:    "<service> <revision>",
+		Purpose: "restore an earlier service configuration",
+		Doc:     strings.TrimSpace(configRollbackDoc),
+	}
+}
+
+func (c *ConfigRollbackCommand) Init(args []string) error {
+	args, err := c.initService(args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("no revision specified")
+	}
+	revision, err := strconv.Atoi(args[0])
+	if err != nil || revision < 1 {
+		return errors.Errorf("invalid revision %q", args[0])
+	}
+	c.Revision = revision
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *ConfigRollbackCommand) Run(ctx *cmd.Context) error {
+	client, err := c.getAPI()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	err = client.RollbackServiceConfig(c.ServiceName, c.Revision)
+	return block.ProcessBlockedError(err, block.BlockChange)
+}
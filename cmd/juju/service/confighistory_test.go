@@ -0,0 +1,137 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package service_test
+
+import (
+	"time"
+
+	"github.com/juju/cmd"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/service"
+	"github.com/juju/juju/testing"
+)
+
+type configHistorySuite struct {
+	testing.FakeJujuHomeSuite
+	fake *fakeConfigHistoryAPI
+}
+
+var _ = gc.Suite(&configHistorySuite{})
+
+func (s *configHistorySuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.fake = &fakeConfigHistoryAPI{
+		revisions: []params.ConfigRevision{{
+			Revision: 1,
+			Changes: []params.ConfigChange{{
+				Type:     params.ConfigSettingAdded,
+				Key:      "outlook",
+				NewValue: "grim",
+			}},
+			Author: "bob",
+			Time:   time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC),
+		}},
+	}
+}
+
+func (s *configHistorySuite) runHistory(c *gc.C, args ...string) (*cmd.Context, error) {
+	command := service.NewConfigHistoryCommand(s.fake)
+	return testing.RunCommand(c, envcmd.Wrap(command), args...)
+}
+
+func (s *configHistorySuite) runRollback(c *gc.C, args ...string) (*cmd.Context, error) {
+	command := service.NewConfigRollbackCommand(s.fake)
+	return testing.RunCommand(c, envcmd.Wrap(command), args...)
+}
+
+func (s *configHistorySuite) TestHistoryInit(c *gc.C) {
+	err := testing.InitCommand(&service.ConfigHistoryCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, "no service name specified")
+	err = testing.InitCommand(&service.ConfigHistoryCommand{}, []string{"mysql/0"})
+	c.Assert(err, gc.ErrorMatches, `invalid service name "mysql/0"`)
+}
+
+func (s *configHistorySuite) TestHistory(c *gc.C) {
+	context, err := s.runHistory(c, "dummy")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.fake.service, gc.Equals, "dummy")
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"REVISION  TIME                  AUTHOR  CHANGES\n"+
+		"1         2015-07-01T12:00:00Z  bob     outlook=grim\n")
+}
+
+func (s *configHistorySuite) TestHistoryEmpty(c *gc.C) {
+	s.fake.revisions = nil
+	context, err := s.runHistory(c, "dummy")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stderr(context), gc.Equals, "no configuration changes recorded for service \"dummy\"\n")
+}
+
+func (s *configHistorySuite) TestRollbackInit(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		err: "no service name specified",
+	}, {
+		args: []string{"dummy"},
+		err:  "no revision specified",
+	}, {
+		args: []string{"dummy", "two"},
+		err:  `invalid revision "two"`,
+	}, {
+		args: []string{"dummy", "1", "2"},
+		err:  `unrecognized args: \["2"\]`,
+	}, {
+		args: []string{"dummy", "1"},
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		err := testing.InitCommand(&service.ConfigRollbackCommand{}, test.args)
+		if test.err == "" {
+			c.Check(err, jc.ErrorIsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (s *configHistorySuite) TestRollback(c *gc.C) {
+	_, err := s.runRollback(c, "dummy", "1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.fake.service, gc.Equals, "dummy")
+	c.Assert(s.fake.rollback, gc.Equals, 1)
+}
+
+func (s *configHistorySuite) TestBlockRollback(c *gc.C) {
+	s.fake.err = &params.Error{Code: params.CodeOperationBlocked}
+	_, err := s.runRollback(c, "dummy", "1")
+	c.Assert(err, gc.Equals, cmd.ErrSilent)
+	c.Check(c.GetTestLog(), jc.Contains, "To unblock changes")
+}
+
+type fakeConfigHistoryAPI struct {
+	revisions []params.ConfigRevision
+	service   string
+	rollback  int
+	err       error
+}
+
+func (f *fakeConfigHistoryAPI) Close() error {
+	return nil
+}
+
+func (f *fakeConfigHistoryAPI) ServiceConfigHistory(service string) ([]params.ConfigRevision, error) {
+	f.service = service
+	return f.revisions, nil
+}
+
+func (f *fakeConfigHistoryAPI) RollbackServiceConfig(service string, revision int) error {
+	f.service = service
+	f.rollback = revision
+	return f.err
+}
@@ -30,3 +30,17 @@ func NewAddUnitCommand(api ServiceAddUnitAPI) *AddUnitCommand {
 		api: api,
 	}
 }
+
+// NewConfigHistoryCommand returns a ConfigHistoryCommand with the api provided as specified.
+func NewConfigHistoryCommand(api ConfigHistoryAPI) *ConfigHistoryCommand {
+	return &ConfigHistoryCommand{
+		configHistoryCommandBase: configHistoryCommandBase{api: api},
+	}
+}
+
+// NewConfigRollbackCommand returns a ConfigRollbackCommand with the api provided as specified.
+func NewConfigRollbackCommand(api ConfigHistoryAPI) *ConfigRollbackCommand {
+	return &ConfigRollbackCommand{
+		configHistoryCommandBase: configHistoryCommandBase{api: api},
+	}
+}
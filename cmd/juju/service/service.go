@@ -32,6 +32,8 @@ func NewSuperCommand() cmd.Command {
 	environmentCmd.Register(envcmd.Wrap(&GetCommand{}))
 	environmentCmd.Register(envcmd.Wrap(&SetCommand{}))
 	environmentCmd.Register(envcmd.Wrap(&UnsetCommand{}))
+	environmentCmd.Register(envcmd.Wrap(&ConfigHistoryCommand{}))
+	environmentCmd.Register(envcmd.Wrap(&ConfigRollbackCommand{}))
 
 	return environmentCmd
 }
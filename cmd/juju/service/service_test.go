@@ -21,6 +21,8 @@ var _ = gc.Suite(&ServiceCommandSuite{})
 
 var expectedCommmandNames = []string{
 	"add-unit",
+	"config-history",
+	"config-rollback",
 	"get",
 	"get-constraints",
 	"help",
@@ -0,0 +1,94 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package space
+
+import (
+	"net"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/cmd/juju/block"
+)
+
+const CreateCommandDoc = `
+Create a network space, optionally containing existing subnets.
+
+Subnets are given by CIDR and must already be known to Juju (see
+"juju subnet add"); each subnet can be in at most one space.
+
+Examples:
+   juju space create db
+   juju space create storage 10.1.0.0/16 10.2.0.0/16
+`
+
+// CreateCommand creates a network space.
+type CreateCommand struct {
+	SpaceCommandBase
+	Name  string
+	CIDRs []string
+}
+
+// Info implements Command.Info.
+func (c *CreateCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "create",
+		Args:    "<name> [<CIDR> ...]",
+		Purpose: "create a network space",
+		Doc:     CreateCommandDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *CreateCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("space name is required")
+	}
+	if !names.IsValidSpace(args[0]) {
+		return errors.Errorf("%q is not a valid space name", args[0])
+	}
+	c.Name = args[0]
+	seen := make(map[string]bool)
+	for _, cidr := range args[1:] {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return errors.Errorf("%q is not a valid CIDR", cidr)
+		}
+		if seen[cidr] {
+			return errors.Errorf("duplicate subnet %q specified", cidr)
+		}
+		seen[cidr] = true
+		c.CIDRs = append(c.CIDRs, cidr)
+	}
+	return nil
+}
+
+// Run implements Command.Run.
+func (c *CreateCommand) Run(ctx *cmd.Context) error {
+	api, err := getCreateAPI(c)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	if err := api.CreateSpace(c.Name, c.CIDRs); err != nil {
+		return block.ProcessBlockedError(err, block.BlockChange)
+	}
+	ctx.Infof("created space %q", c.Name)
+	return nil
+}
+
+var (
+	getCreateAPI = (*CreateCommand).getCreateAPI
+)
+
+// CreateAPI defines the API methods that the space create command uses.
+type CreateAPI interface {
+	Close() error
+	CreateSpace(name string, subnetCIDRs []string) error
+}
+
+func (c *CreateCommand) getCreateAPI() (CreateAPI, error) {
+	return c.NewSpacesAPI()
+}
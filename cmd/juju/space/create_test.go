@@ -0,0 +1,88 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package space_test
+
+import (
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/space"
+	"github.com/juju/juju/testing"
+)
+
+type CreateSuite struct {
+	BaseSpaceSuite
+	mockAPI *mockCreateAPI
+}
+
+var _ = gc.Suite(&CreateSuite{})
+
+func (s *CreateSuite) SetUpTest(c *gc.C) {
+	s.BaseSpaceSuite.SetUpTest(c)
+
+	s.mockAPI = &mockCreateAPI{}
+	s.PatchValue(space.GetCreateAPI, func(c *space.CreateCommand) (space.CreateAPI, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func runCreate(c *gc.C, args ...string) (*cmd.Context, error) {
+	return testing.RunCommand(c, envcmd.Wrap(&space.CreateCommand{}), args...)
+}
+
+func (s *CreateSuite) TestInitErrors(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  "space name is required",
+	}, {
+		args: []string{"Bad_Name"},
+		err:  `"Bad_Name" is not a valid space name`,
+	}, {
+		args: []string{"db", "10.0.0.0"},
+		err:  `"10.0.0.0" is not a valid CIDR`,
+	}, {
+		args: []string{"db", "10.0.0.0/24", "10.0.0.0/24"},
+		err:  `duplicate subnet "10.0.0.0/24" specified`,
+	}} {
+		c.Logf("test %d: %v", i, t.args)
+		err := testing.InitCommand(envcmd.Wrap(&space.CreateCommand{}), t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *CreateSuite) TestCreate(c *gc.C) {
+	ctx, err := runCreate(c, "storage", "10.1.0.0/16", "10.2.0.0/16")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mockAPI.name, gc.Equals, "storage")
+	c.Assert(s.mockAPI.cidrs, jc.DeepEquals, []string{"10.1.0.0/16", "10.2.0.0/16"})
+	c.Assert(testing.Stderr(ctx), gc.Equals, "created space \"storage\"\n")
+}
+
+func (s *CreateSuite) TestCreateError(c *gc.C) {
+	s.mockAPI.err = errors.New("boom")
+	_, err := runCreate(c, "storage")
+	c.Assert(err, gc.ErrorMatches, "boom")
+}
+
+type mockCreateAPI struct {
+	name  string
+	cidrs []string
+	err   error
+}
+
+func (m *mockCreateAPI) CreateSpace(name string, subnetCIDRs []string) error {
+	m.name = name
+	m.cidrs = subnetCIDRs
+	return m.err
+}
+
+func (m *mockCreateAPI) Close() error {
+	return nil
+}
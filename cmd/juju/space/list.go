@@ -0,0 +1,107 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package space
+
+import (
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+const ListCommandDoc = `
+List the network spaces of the environment, along with the subnets
+in each of them.
+
+options:
+-e, --environment (= "")
+   juju environment to operate in
+-o, --output (= "")
+   specify an output file
+--format (= yaml)
+   specify output format (json|yaml)
+`
+
+// ListCommand lists network spaces.
+type ListCommand struct {
+	SpaceCommandBase
+	out cmd.Output
+}
+
+// Info implements Command.Info.
+func (c *ListCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list",
+		Purpose: "list network spaces",
+		Doc:     ListCommandDoc,
+	}
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *ListCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.SpaceCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+// Run implements Command.Run.
+func (c *ListCommand) Run(ctx *cmd.Context) error {
+	api, err := getListAPI(c)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	spaces, err := api.ListSpaces()
+	if err != nil {
+		return err
+	}
+	if len(spaces) == 0 {
+		return nil
+	}
+	return c.out.Write(ctx, formatSpaces(spaces))
+}
+
+// SubnetInfo defines the serialization behaviour of a subnet in a space.
+type SubnetInfo struct {
+	ProviderId string `yaml:"provider-id,omitempty" json:"provider-id,omitempty"`
+	VLANTag    int    `yaml:"vlan-tag,omitempty" json:"vlan-tag,omitempty"`
+	Zone       string `yaml:"zone,omitempty" json:"zone,omitempty"`
+	Life       string `yaml:"life,omitempty" json:"life,omitempty"`
+}
+
+// formatSpaces returns the spaces keyed on name, each holding its
+// subnets keyed on CIDR.
+func formatSpaces(spaces []params.Space) map[string]map[string]SubnetInfo {
+	output := make(map[string]map[string]SubnetInfo)
+	for _, space := range spaces {
+		subnets := make(map[string]SubnetInfo)
+		for _, subnet := range space.Subnets {
+			subnets[subnet.CIDR] = SubnetInfo{
+				ProviderId: subnet.ProviderId,
+				VLANTag:    subnet.VLANTag,
+				Zone:       subnet.Zone,
+				Life:       string(subnet.Life),
+			}
+		}
+		output[space.Name] = subnets
+	}
+	return output
+}
+
+var (
+	getListAPI = (*ListCommand).getListAPI
+)
+
+// ListAPI defines the API methods that the space list command uses.
+type ListAPI interface {
+	Close() error
+	ListSpaces() ([]params.Space, error)
+}
+
+func (c *ListCommand) getListAPI() (ListAPI, error) {
+	return c.NewSpacesAPI()
+}
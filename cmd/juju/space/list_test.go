@@ -0,0 +1,91 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package space_test
+
+import (
+	"github.com/juju/cmd"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/space"
+	"github.com/juju/juju/testing"
+)
+
+type ListSuite struct {
+	BaseSpaceSuite
+	mockAPI *mockListAPI
+}
+
+var _ = gc.Suite(&ListSuite{})
+
+func (s *ListSuite) SetUpTest(c *gc.C) {
+	s.BaseSpaceSuite.SetUpTest(c)
+
+	s.mockAPI = &mockListAPI{}
+	s.PatchValue(space.GetListAPI, func(c *space.ListCommand) (space.ListAPI, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func runList(c *gc.C, args ...string) (*cmd.Context, error) {
+	return testing.RunCommand(c, envcmd.Wrap(&space.ListCommand{}), args...)
+}
+
+func (s *ListSuite) TestListEmpty(c *gc.C) {
+	ctx, err := runList(c)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "")
+}
+
+func (s *ListSuite) TestListYAML(c *gc.C) {
+	s.mockAPI.spaces = []params.Space{{
+		Name: "apps",
+	}, {
+		Name: "storage",
+		Subnets: []params.Subnet{{
+			CIDR:       "10.1.0.0/16",
+			ProviderId: "subnet-1",
+			Zone:       "zone1",
+			SpaceName:  "storage",
+			Life:       params.Alive,
+		}},
+	}}
+	ctx, err := runList(c)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `
+apps: {}
+storage:
+  10.1.0.0/16:
+    provider-id: subnet-1
+    zone: zone1
+    life: alive
+`[1:])
+}
+
+func (s *ListSuite) TestListJSON(c *gc.C) {
+	s.mockAPI.spaces = []params.Space{{
+		Name: "storage",
+		Subnets: []params.Subnet{{
+			CIDR: "10.1.0.0/16",
+			Life: params.Alive,
+		}},
+	}}
+	ctx, err := runList(c, "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `{"storage":{"10.1.0.0/16":{"life":"alive"}}}`+"\n")
+}
+
+type mockListAPI struct {
+	spaces []params.Space
+}
+
+func (m *mockListAPI) ListSpaces() ([]params.Space, error) {
+	return m.spaces, nil
+}
+
+func (m *mockListAPI) Close() error {
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package subnet
+
+import (
+	"net"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/juju/block"
+)
+
+const AddCommandDoc = `
+Add a subnet to an existing network space.
+
+The subnet is given by CIDR, and may optionally be associated with an
+availability zone. The provider-specific id of the subnet, if known,
+allows the provisioner to start machines directly in it when the
+"spaces" constraint is used.
+
+Examples:
+   juju subnet add 10.1.0.0/16 storage
+   juju subnet add 10.1.0.0/16 storage us-east-1a --provider-id subnet-1234
+`
+
+// AddCommand adds a subnet to a network space.
+type AddCommand struct {
+	SubnetCommandBase
+	CIDR       string
+	Space      string
+	Zone       string
+	ProviderId string
+	VLANTag    int
+}
+
+// Info implements Command.Info.
+func (c *AddCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "add",
+		Args:    "<CIDR> <space> [<zone>]",
+		Purpose: "add a subnet to a network space",
+		Doc:     AddCommandDoc,
+	}
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *AddCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.SubnetCommandBase.SetFlags(f)
+	f.StringVar(&c.ProviderId, "provider-id", "", "provider-specific subnet id")
+	f.IntVar(&c.VLANTag, "vlan-tag", 0, "VLAN tag of the subnet (1-4094)")
+}
+
+// Init implements Command.Init.
+func (c *AddCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return errors.New("subnet CIDR is required")
+	case 1:
+		return errors.New("space name is required")
+	case 2, 3:
+	default:
+		return cmd.CheckEmpty(args[3:])
+	}
+	if _, _, err := net.ParseCIDR(args[0]); err != nil {
+		return errors.Errorf("%q is not a valid CIDR", args[0])
+	}
+	c.CIDR = args[0]
+	if !names.IsValidSpace(args[1]) {
+		return errors.Errorf("%q is not a valid space name", args[1])
+	}
+	c.Space = args[1]
+	if len(args) == 3 {
+		c.Zone = args[2]
+	}
+	if c.VLANTag < 0 || c.VLANTag > 4094 {
+		return errors.Errorf("invalid --vlan-tag %d: must be between 0 and 4094", c.VLANTag)
+	}
+	return nil
+}
+
+// Run implements Command.Run.
+func (c *AddCommand) Run(ctx *cmd.Context) error {
+	api, err := getAddAPI(c)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	err = api.AddSubnet(params.Subnet{
+		CIDR:       c.CIDR,
+		ProviderId: c.ProviderId,
+		VLANTag:    c.VLANTag,
+		Zone:       c.Zone,
+		SpaceName:  c.Space,
+	})
+	if err != nil {
+		return block.ProcessBlockedError(err, block.BlockChange)
+	}
+	ctx.Infof("added subnet %q to space %q", c.CIDR, c.Space)
+	return nil
+}
+
+var (
+	getAddAPI = (*AddCommand).getAddAPI
+)
+
+// AddAPI defines the API methods that the subnet add command uses.
+type AddAPI interface {
+	Close() error
+	AddSubnet(subnet params.Subnet) error
+}
+
+func (c *AddCommand) getAddAPI() (AddAPI, error) {
+	return c.NewSubnetsAPI()
+}
@@ -0,0 +1,98 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package subnet_test
+
+import (
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/subnet"
+	"github.com/juju/juju/testing"
+)
+
+type AddSuite struct {
+	BaseSubnetSuite
+	mockAPI *mockAddAPI
+}
+
+var _ = gc.Suite(&AddSuite{})
+
+func (s *AddSuite) SetUpTest(c *gc.C) {
+	s.BaseSubnetSuite.SetUpTest(c)
+
+	s.mockAPI = &mockAddAPI{}
+	s.PatchValue(subnet.GetAddAPI, func(c *subnet.AddCommand) (subnet.AddAPI, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func runAdd(c *gc.C, args ...string) (*cmd.Context, error) {
+	return testing.RunCommand(c, envcmd.Wrap(&subnet.AddCommand{}), args...)
+}
+
+func (s *AddSuite) TestInitErrors(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  "subnet CIDR is required",
+	}, {
+		args: []string{"10.0.0.0/24"},
+		err:  "space name is required",
+	}, {
+		args: []string{"10.0.0.0", "db"},
+		err:  `"10.0.0.0" is not a valid CIDR`,
+	}, {
+		args: []string{"10.0.0.0/24", "Bad_Name"},
+		err:  `"Bad_Name" is not a valid space name`,
+	}, {
+		args: []string{"10.0.0.0/24", "db", "zone1", "extra"},
+		err:  `unrecognized args: \["extra"\]`,
+	}, {
+		args: []string{"10.0.0.0/24", "db", "--vlan-tag", "5000"},
+		err:  `invalid --vlan-tag 5000: must be between 0 and 4094`,
+	}} {
+		c.Logf("test %d: %v", i, t.args)
+		err := testing.InitCommand(envcmd.Wrap(&subnet.AddCommand{}), t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *AddSuite) TestAdd(c *gc.C) {
+	ctx, err := runAdd(c, "10.1.0.0/16", "storage", "zone1", "--provider-id", "subnet-1", "--vlan-tag", "42")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mockAPI.subnet, jc.DeepEquals, params.Subnet{
+		CIDR:       "10.1.0.0/16",
+		ProviderId: "subnet-1",
+		VLANTag:    42,
+		Zone:       "zone1",
+		SpaceName:  "storage",
+	})
+	c.Assert(testing.Stderr(ctx), gc.Equals, "added subnet \"10.1.0.0/16\" to space \"storage\"\n")
+}
+
+func (s *AddSuite) TestAddError(c *gc.C) {
+	s.mockAPI.err = errors.New("boom")
+	_, err := runAdd(c, "10.1.0.0/16", "storage")
+	c.Assert(err, gc.ErrorMatches, "boom")
+}
+
+type mockAddAPI struct {
+	subnet params.Subnet
+	err    error
+}
+
+func (m *mockAddAPI) AddSubnet(subnet params.Subnet) error {
+	m.subnet = subnet
+	return m.err
+}
+
+func (m *mockAddAPI) Close() error {
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package subnet
+
+import (
+	"github.com/juju/cmd"
+
+	"github.com/juju/juju/api/subnets"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const subnetCmdDoc = `
+"juju subnet" is used to manage the subnets known to the Juju
+environment. Each subnet belongs to a network space (see "juju help
+space").
+`
+
+const subnetCmdPurpose = "manage subnets"
+
+// Command is the top-level command wrapping all subnet functionality.
+type Command struct {
+	cmd.SuperCommand
+}
+
+// NewSuperCommand creates the subnet supercommand and registers the
+// subcommands that it supports.
+func NewSuperCommand() cmd.Command {
+	subnetcmd := Command{
+		SuperCommand: *cmd.NewSuperCommand(
+			cmd.SuperCommandParams{
+				Name:        "subnet",
+				Doc:         subnetCmdDoc,
+				UsagePrefix: "juju",
+				Purpose:     subnetCmdPurpose,
+			})}
+	subnetcmd.Register(envcmd.Wrap(&AddCommand{}))
+	return &subnetcmd
+}
+
+// SubnetCommandBase is a helper base structure that has a method to get
+// the subnets client.
+type SubnetCommandBase struct {
+	envcmd.EnvCommandBase
+}
+
+// NewSubnetsAPI returns a subnets api for the root api endpoint that the
+// environment command returns.
+func (c *SubnetCommandBase) NewSubnetsAPI() (*subnets.Client, error) {
+	root, err := c.NewAPIRoot()
+	if err != nil {
+		return nil, err
+	}
+	return subnets.NewClient(root), nil
+}
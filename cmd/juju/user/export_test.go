@@ -18,6 +18,8 @@ var (
 	GetConnectionCredentials = &getConnectionCredentials
 	// disable and enable
 	GetDisableUserAPI = &getDisableUserAPI
+	// tokens
+	GetTokenAPI = &getTokenAPI
 )
 
 // DisenableCommand is used for testing both Disable and Enable user commands.
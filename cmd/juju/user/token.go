@@ -0,0 +1,294 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package user
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/block"
+)
+
+const tokenCommandDoc = `
+"juju user token" is used to manage API tokens. An API token is used in
+place of the password of the user that created it, to log in to the
+environment it was created in. Tokens can be given an expiry time and
+restricted to particular API facades, and can be revoked at any time,
+which makes them better suited to scripts and CI jobs than passwords.
+`
+
+const tokenCommandPurpose = "manage API tokens"
+
+// NewTokenCommand creates the token supercommand and registers the
+// subcommands that it supports.
+func NewTokenCommand() cmd.Command {
+	tokencmd := cmd.NewSuperCommand(cmd.SuperCommandParams{
+		Name:        "token",
+		Doc:         tokenCommandDoc,
+		UsagePrefix: "juju user",
+		Purpose:     tokenCommandPurpose,
+	})
+	tokencmd.Register(envcmd.Wrap(&TokenCreateCommand{}))
+	tokencmd.Register(envcmd.Wrap(&TokenListCommand{}))
+	tokencmd.Register(envcmd.Wrap(&TokenRevokeCommand{}))
+	return tokencmd
+}
+
+// TokenAPI defines the API methods that the token commands use.
+type TokenAPI interface {
+	CreateAPIToken(name string, facades []string, expires *time.Time) (string, error)
+	ListAPITokens() ([]params.APIToken, error)
+	RevokeAPIToken(name string) error
+	Close() error
+}
+
+// TokenCommandBase is a common base for the token commands.
+type TokenCommandBase struct {
+	UserCommandBase
+}
+
+func (c *TokenCommandBase) getTokenAPI() (TokenAPI, error) {
+	return c.NewUserManagerClient()
+}
+
+var getTokenAPI = (*TokenCommandBase).getTokenAPI
+
+const tokenCreateDoc = `
+Create an API token for the current user in the current environment. The
+credentials printed are used in place of the user's password, for example
+as the password in a .jenv file, and cannot be shown again.
+
+Examples:
+  # Create a token that can use any facade, and never expires
+  juju user token create ci
+
+  # Create a token that expires in a day, restricted to the Client facade
+  juju user token create deploy --facades Client --expires 24h
+
+See Also:
+  juju user token list
+  juju user token revoke
+`
+
+// TokenCreateCommand creates an API token.
+type TokenCreateCommand struct {
+	TokenCommandBase
+	Name    string
+	Facades []string
+	Expires time.Duration
+	facades string
+}
+
+// Info implements Command.Info.
+func (c *TokenCreateCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "create",
+		Args:    "<name>",
+		Purpose: "create an API token",
+		Doc:     tokenCreateDoc,
+	}
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *TokenCreateCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.facades, "facades", "", "comma-separated list of the facades the token may use")
+	f.DurationVar(&c.Expires, "expires", 0, "how long the token may be used for (e.g. 24h); by default it never expires")
+}
+
+// Init implements Command.Init.
+func (c *TokenCreateCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no token name supplied")
+	}
+	c.Name = args[0]
+	if c.Expires < 0 {
+		return errors.New("expiry time must be positive")
+	}
+	c.Facades = nil
+	for _, facade := range strings.Split(c.facades, ",") {
+		if facade = strings.TrimSpace(facade); facade != "" {
+			c.Facades = append(c.Facades, facade)
+		}
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+// Run implements Command.Run.
+func (c *TokenCreateCommand) Run(ctx *cmd.Context) error {
+	client, err := getTokenAPI(&c.TokenCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	var expires *time.Time
+	if c.Expires > 0 {
+		t := time.Now().Add(c.Expires).UTC()
+		expires = &t
+	}
+	credentials, err := client.CreateAPIToken(c.Name, c.Facades, expires)
+	if err != nil {
+		return block.ProcessBlockedError(err, block.BlockChange)
+	}
+	fmt.Fprintln(ctx.Stdout, credentials)
+	ctx.Infof("Token %q created; use the credentials above in place of your password", c.Name)
+	return nil
+}
+
+const tokenListDoc = `
+List the current user's API tokens in the current environment.
+
+See Also:
+  juju user token create
+`
+
+// TokenListCommand lists API tokens.
+type TokenListCommand struct {
+	TokenCommandBase
+	out cmd.Output
+}
+
+// TokenInfo defines the serialization behaviour of API token
+// information.
+type TokenInfo struct {
+	Name    string   `yaml:"name" json:"name"`
+	Facades []string `yaml:"facades,omitempty" json:"facades,omitempty"`
+	Created string   `yaml:"created" json:"created"`
+	Expires string   `yaml:"expires" json:"expires"`
+}
+
+// Info implements Command.Info.
+func (c *TokenListCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list",
+		Purpose: "shows the current user's API tokens",
+		Doc:     tokenListDoc,
+	}
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *TokenListCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": formatTokensTabular,
+	})
+}
+
+// Init implements Command.Init.
+func (c *TokenListCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+// Run implements Command.Run.
+func (c *TokenListCommand) Run(ctx *cmd.Context) error {
+	client, err := getTokenAPI(&c.TokenCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	tokens, err := client.ListAPITokens()
+	if err != nil {
+		return err
+	}
+	output := []TokenInfo{}
+	for _, token := range tokens {
+		info := TokenInfo{
+			Name:    token.Name,
+			Facades: token.Facades,
+			Created: token.Created.UTC().Format(time.RFC3339),
+			Expires: "never",
+		}
+		if token.Expires != nil {
+			info.Expires = token.Expires.UTC().Format(time.RFC3339)
+		}
+		output = append(output, info)
+	}
+	return c.out.Write(ctx, output)
+}
+
+func formatTokensTabular(value interface{}) ([]byte, error) {
+	tokens, ok := value.([]TokenInfo)
+	if !ok {
+		return nil, errors.Errorf("expected value of type %T, got %T", tokens, value)
+	}
+	var out bytes.Buffer
+	const (
+		// To format things into columns.
+		minwidth = 0
+		tabwidth = 1
+		padding  = 2
+		padchar  = ' '
+		flags    = 0
+	)
+	tw := tabwriter.NewWriter(&out, minwidth, tabwidth, padding, padchar, flags)
+	fmt.Fprintf(tw, "NAME\tFACADES\tCREATED\tEXPIRES\n")
+	for _, token := range tokens {
+		facades := strings.Join(token.Facades, ",")
+		if facades == "" {
+			facades = "all"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", token.Name, facades, token.Created, token.Expires)
+	}
+	tw.Flush()
+	return out.Bytes(), nil
+}
+
+const tokenRevokeDoc = `
+Revoke one of the current user's API tokens, so that it can no longer be
+used to log in.
+
+Examples:
+  juju user token revoke ci
+
+See Also:
+  juju user token list
+`
+
+// TokenRevokeCommand revokes an API token.
+type TokenRevokeCommand struct {
+	TokenCommandBase
+	Name string
+}
+
+// Info implements Command.Info.
+func (c *TokenRevokeCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "revoke",
+		Args:    "<name>",
+		Purpose: "revoke an API token",
+		Doc:     tokenRevokeDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *TokenRevokeCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no token name supplied")
+	}
+	c.Name = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+// Run implements Command.Run.
+func (c *TokenRevokeCommand) Run(ctx *cmd.Context) error {
+	client, err := getTokenAPI(&c.TokenCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.RevokeAPIToken(c.Name); err != nil {
+		return block.ProcessBlockedError(err, block.BlockRemove)
+	}
+	ctx.Infof("Token %q revoked", c.Name)
+	return nil
+}
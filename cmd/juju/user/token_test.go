@@ -0,0 +1,176 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package user_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/cmd/juju/user"
+	"github.com/juju/juju/testing"
+)
+
+type TokenCommandSuite struct {
+	BaseSuite
+	mock *mockTokenAPI
+}
+
+var _ = gc.Suite(&TokenCommandSuite{})
+
+func (s *TokenCommandSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.mock = &mockTokenAPI{}
+	s.PatchValue(user.GetTokenAPI, func(*user.TokenCommandBase) (user.TokenAPI, error) {
+		return s.mock, nil
+	})
+}
+
+func (s *TokenCommandSuite) TestHelp(c *gc.C) {
+	ctx, err := testing.RunCommand(c, user.NewTokenCommand(), "--help")
+	c.Assert(err, jc.ErrorIsNil)
+	namesFound := testing.ExtractCommandsFromHelpOutput(ctx)
+	c.Assert(namesFound, gc.DeepEquals, []string{"create", "help", "list", "revoke"})
+}
+
+func (s *TokenCommandSuite) TestCreateInit(c *gc.C) {
+	for i, test := range []struct {
+		args     []string
+		errMatch string
+		name     string
+		facades  []string
+		expires  time.Duration
+	}{{
+		errMatch: "no token name supplied",
+	}, {
+		args:     []string{"ci", "extra"},
+		errMatch: `unrecognized args: \["extra"\]`,
+	}, {
+		args:     []string{"ci", "--expires", "-1h"},
+		errMatch: "expiry time must be positive",
+	}, {
+		args: []string{"ci"},
+		name: "ci",
+	}, {
+		args:    []string{"ci", "--facades", "Client, UserManager", "--expires", "24h"},
+		name:    "ci",
+		facades: []string{"Client", "UserManager"},
+		expires: 24 * time.Hour,
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		command := &user.TokenCreateCommand{}
+		err := testing.InitCommand(command, test.args)
+		if test.errMatch != "" {
+			c.Check(err, gc.ErrorMatches, test.errMatch)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(command.Name, gc.Equals, test.name)
+		c.Check(command.Facades, jc.DeepEquals, test.facades)
+		c.Check(command.Expires, gc.Equals, test.expires)
+	}
+}
+
+func (s *TokenCommandSuite) TestCreate(c *gc.C) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&user.TokenCreateCommand{}), "ci", "--facades", "Client", "--expires", "1h")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "token:ci:secret\n")
+	c.Assert(testing.Stderr(ctx), gc.Equals, "Token \"ci\" created; use the credentials above in place of your password\n")
+	c.Assert(s.mock.created, gc.Equals, "ci")
+	c.Assert(s.mock.facades, jc.DeepEquals, []string{"Client"})
+	c.Assert(s.mock.expires, gc.NotNil)
+	c.Assert(s.mock.expires.Sub(time.Now()) > 59*time.Minute, jc.IsTrue)
+}
+
+func (s *TokenCommandSuite) TestCreateNoExpiry(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&user.TokenCreateCommand{}), "ci")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.mock.expires, gc.IsNil)
+	c.Assert(s.mock.facades, gc.IsNil)
+}
+
+func (s *TokenCommandSuite) TestCreateError(c *gc.C) {
+	s.mock.err = errors.New("boom")
+	_, err := testing.RunCommand(c, envcmd.Wrap(&user.TokenCreateCommand{}), "ci")
+	c.Assert(err, gc.ErrorMatches, "boom")
+}
+
+func (s *TokenCommandSuite) TestList(c *gc.C) {
+	expires := time.Date(2015, 8, 1, 12, 0, 0, 0, time.UTC)
+	s.mock.tokens = []params.APIToken{{
+		Name:    "ci",
+		Created: time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC),
+	}, {
+		Name:    "deploy",
+		Facades: []string{"Client", "UserManager"},
+		Created: time.Date(2015, 7, 2, 12, 0, 0, 0, time.UTC),
+		Expires: &expires,
+	}}
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&user.TokenListCommand{}))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"NAME    FACADES             CREATED               EXPIRES\n"+
+		"ci      all                 2015-07-01T12:00:00Z  never\n"+
+		"deploy  Client,UserManager  2015-07-02T12:00:00Z  2015-08-01T12:00:00Z\n"+
+		"\n")
+
+	ctx, err = testing.RunCommand(c, envcmd.Wrap(&user.TokenListCommand{}), "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"- name: ci\n"+
+		"  created: 2015-07-01T12:00:00Z\n"+
+		"  expires: never\n"+
+		"- name: deploy\n"+
+		"  facades:\n"+
+		"  - Client\n"+
+		"  - UserManager\n"+
+		"  created: 2015-07-02T12:00:00Z\n"+
+		"  expires: 2015-08-01T12:00:00Z\n")
+}
+
+func (s *TokenCommandSuite) TestRevoke(c *gc.C) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&user.TokenRevokeCommand{}), "ci")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(testing.Stderr(ctx), gc.Equals, "Token \"ci\" revoked\n")
+	c.Assert(s.mock.revoked, gc.Equals, "ci")
+}
+
+func (s *TokenCommandSuite) TestRevokeInit(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&user.TokenRevokeCommand{}))
+	c.Assert(err, gc.ErrorMatches, "no token name supplied")
+}
+
+type mockTokenAPI struct {
+	created string
+	facades []string
+	expires *time.Time
+	revoked string
+	tokens  []params.APIToken
+	err     error
+}
+
+func (m *mockTokenAPI) CreateAPIToken(name string, facades []string, expires *time.Time) (string, error) {
+	m.created, m.facades, m.expires = name, facades, expires
+	if m.err != nil {
+		return "", m.err
+	}
+	return "token:" + name + ":secret", nil
+}
+
+func (m *mockTokenAPI) ListAPITokens() ([]params.APIToken, error) {
+	return m.tokens, m.err
+}
+
+func (m *mockTokenAPI) RevokeAPIToken(name string) error {
+	m.revoked = name
+	return m.err
+}
+
+func (*mockTokenAPI) Close() error {
+	return nil
+}
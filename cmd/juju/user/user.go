@@ -40,6 +40,7 @@ func NewSuperCommand() cmd.Command {
 	usercmd.Register(envcmd.Wrap(&DisableCommand{}))
 	usercmd.Register(envcmd.Wrap(&EnableCommand{}))
 	usercmd.Register(envcmd.Wrap(&ListCommand{}))
+	usercmd.Register(NewTokenCommand())
 	return usercmd
 }
 
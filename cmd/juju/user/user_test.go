@@ -29,6 +29,7 @@ var expectedUserCommmandNames = []string{
 	"help",
 	"info",
 	"list",
+	"token",
 }
 
 func (s *UserCommandSuite) TestHelp(c *gc.C) {
@@ -62,6 +62,8 @@ type AgentConf interface {
 	CurrentConfig() agent.Config
 	// SetAPIHostPorts satisfies worker/apiaddressupdater/APIAddressSetter.
 	SetAPIHostPorts(servers [][]network.HostPort) error
+	// SetCACert satisfies worker/cacertupdater/CACertSetter.
+	SetCACert(caCert string) error
 	// SetStateServingInfo satisfies worker/certupdater/SetStateServingInfo.
 	SetStateServingInfo(info params.StateServingInfo) error
 	// DataDir returns the directory where this agent should store its data.
@@ -146,6 +148,14 @@ func (a *agentConf) SetAPIHostPorts(servers [][]network.HostPort) error {
 	})
 }
 
+// SetCACert satisfies worker/cacertupdater/CACertSetter.
+func (a *agentConf) SetCACert(caCert string) error {
+	return a.ChangeConfig(func(c agent.ConfigSetter) error {
+		c.SetCACert(caCert)
+		return nil
+	})
+}
+
 // SetStateServingInfo satisfies worker/certupdater/SetStateServingInfo.
 func (a *agentConf) SetStateServingInfo(info params.StateServingInfo) error {
 	return a.ChangeConfig(func(c agent.ConfigSetter) error {
@@ -233,7 +243,7 @@ func OpenAPIState(agentConfig agent.Config, a Agent) (_ *api.State, _ *apiagent.
 		// Reconnect to the API with the new password.
 		st.Close()
 		info.Password = newPassword
-		st, err = apiOpen(info, api.DialOpts{})
+		st, err = apiOpen(info, agentDialOpts)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -266,13 +276,20 @@ func OpenAPIStateUsingInfo(info *api.Info, a Agent, oldPassword string) (*api.St
 	return st, err
 }
 
+// agentDialOpts holds the options with which agents open the API.
+//
+// We let the API dial fail immediately because the runner's loop
+// outside the caller of openAPIState will keep on retrying. If we
+// block for ages here, then the worker that's calling this cannot be
+// interrupted. Logins refused by a busy API server are retried for a
+// little while though, waiting as long as the server asks, so that
+// agents reconnecting together spread out their logins.
+var agentDialOpts = api.DialOpts{
+	LoginRetryTimeout: time.Minute,
+}
+
 func openAPIStateUsingInfo(info *api.Info, a Agent, oldPassword string) (*api.State, bool, error) {
-	// We let the API dial fail immediately because the
-	// runner's loop outside the caller of openAPIState will
-	// keep on retrying. If we block for ages here,
-	// then the worker that's calling this cannot
-	// be interrupted.
-	st, err := apiOpen(info, api.DialOpts{})
+	st, err := apiOpen(info, agentDialOpts)
 	usedOldPassword := false
 	if params.IsCodeUnauthorized(err) {
 		// We've perhaps used the wrong password, so
@@ -281,13 +298,13 @@ func openAPIStateUsingInfo(info *api.Info, a Agent, oldPassword string) (*api.St
 		info = &infoCopy
 		info.Password = oldPassword
 		usedOldPassword = true
-		st, err = apiOpen(info, api.DialOpts{})
+		st, err = apiOpen(info, agentDialOpts)
 	}
 	// The provisioner may take some time to record the agent's
 	// machine instance ID, so wait until it does so.
 	if params.IsCodeNotProvisioned(err) {
 		for a := checkProvisionedStrategy.Start(); a.Next(); {
-			st, err = apiOpen(info, api.DialOpts{})
+			st, err = apiOpen(info, agentDialOpts)
 			if !params.IsCodeNotProvisioned(err) {
 				break
 			}
@@ -36,7 +36,10 @@ import (
 	apideployer "github.com/juju/juju/api/deployer"
 	"github.com/juju/juju/api/metricsmanager"
 	apiupgrader "github.com/juju/juju/api/upgrader"
+	apiwatcher "github.com/juju/juju/api/watcher"
 	"github.com/juju/juju/apiserver"
+	"github.com/juju/juju/apiserver/authentication"
+	"github.com/juju/juju/apiserver/authentication/ldap"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/cert"
 	"github.com/juju/juju/cmd/jujud/reboot"
@@ -62,9 +65,12 @@ import (
 	coretools "github.com/juju/juju/tools"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/actionscheduler"
 	"github.com/juju/juju/worker/addresser"
 	"github.com/juju/juju/worker/apiaddressupdater"
 	"github.com/juju/juju/worker/authenticationworker"
+	"github.com/juju/juju/worker/blobgc"
+	"github.com/juju/juju/worker/cacertupdater"
 	"github.com/juju/juju/worker/certupdater"
 	"github.com/juju/juju/worker/charmrevisionworker"
 	"github.com/juju/juju/worker/cleaner"
@@ -92,6 +98,7 @@ import (
 	"github.com/juju/juju/worker/storageprovisioner"
 	"github.com/juju/juju/worker/terminationworker"
 	"github.com/juju/juju/worker/txnpruner"
+	"github.com/juju/juju/worker/undertaker"
 	"github.com/juju/juju/worker/upgrader"
 )
 
@@ -324,6 +331,14 @@ type MachineAgent struct {
 	apiStateUpgrader APIStateUpgrader
 }
 
+// SetCACert satisfies worker/cacertupdater/CACertSetter.
+func (a *MachineAgent) SetCACert(caCert string) error {
+	return a.ChangeConfig(func(c agent.ConfigSetter) error {
+		c.SetCACert(caCert)
+		return nil
+	})
+}
+
 func (a *MachineAgent) getUpgrader(st *api.State) APIStateUpgrader {
 	if a.apiStateUpgrader != nil {
 		return a.apiStateUpgrader
@@ -745,6 +760,9 @@ func (a *MachineAgent) postUpgradeAPIWorker(
 	runner.StartWorker("apiaddressupdater", func() (worker.Worker, error) {
 		return apiaddressupdater.NewAPIAddressUpdater(st.Machiner(), a.apiAddressSetter), nil
 	})
+	runner.StartWorker("cacertupdater", func() (worker.Worker, error) {
+		return cacertupdater.NewCACertUpdater(st.Agent(), a), nil
+	})
 	runner.StartWorker("logger", func() (worker.Worker, error) {
 		return workerlogger.NewLogger(st.Logger(), agentConfig), nil
 	})
@@ -1024,8 +1042,22 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 			})
 			certChangedChan := make(chan params.StateServingInfo, 1)
 			runner.StartWorker("apiserver", a.apiserverWorkerStarter(st, certChangedChan))
+			a.startWorkerAfterUpgrade(runner, "userdirectoryconfig", func() (worker.Worker, error) {
+				return worker.NewNotifyWorker(&userDirectoryConfigHandler{
+					st: st,
+					restart: func() error {
+						if err := runner.StopWorker("apiserver"); err != nil {
+							return errors.Trace(err)
+						}
+						return runner.StartWorker("apiserver", a.apiserverWorkerStarter(st, certChangedChan))
+					},
+				}), nil
+			})
 			var stateServingSetter certupdater.StateServingInfoSetter = func(info params.StateServingInfo, done <-chan struct{}) error {
-				return a.ChangeConfig(func(config agent.ConfigSetter) error {
+				var caChanged bool
+				err := a.ChangeConfig(func(config agent.ConfigSetter) error {
+					oldInfo, _ := config.StateServingInfo()
+					caChanged = oldInfo.CAPrivateKey != info.CAPrivateKey
 					config.SetStateServingInfo(info)
 					logger.Infof("update apiserver worker with new certificate")
 					select {
@@ -1035,9 +1067,19 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 						return nil
 					}
 				})
+				if err != nil || !caChanged {
+					return err
+				}
+				// The certificate was reissued by a new CA, which agents
+				// will soon trust exclusively, so mongo must serve it too.
+				logger.Infof("restarting mongo with certificate signed by new CA")
+				if err := mongo.UpdateSSLKey(agentConfig.DataDir(), info.Cert, info.PrivateKey); err != nil {
+					return errors.Trace(err)
+				}
+				return mongo.RestartService(agentConfig.Value(agent.Namespace))
 			}
 			a.startWorkerAfterUpgrade(runner, "certupdater", func() (worker.Worker, error) {
-				return newCertificateUpdater(m, agentConfig, st, stateServingSetter, certChangedChan), nil
+				return newCertificateUpdater(m, agentConfig, st, st, stateServingSetter, certChangedChan), nil
 			})
 
 			if featureflag.Enabled(feature.DbLog) {
@@ -1052,6 +1094,9 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 			a.startWorkerAfterUpgrade(singularRunner, "txnpruner", func() (worker.Worker, error) {
 				return txnpruner.New(st, time.Hour*2), nil
 			})
+			a.startWorkerAfterUpgrade(singularRunner, "blobgc", func() (worker.Worker, error) {
+				return blobgc.New(st, time.Hour*6, state.DefaultBlobGracePeriod), nil
+			})
 
 		case state.JobManageStateDeprecated:
 			// Legacy environments may set this, but we ignore it.
@@ -1125,6 +1170,16 @@ func (a *MachineAgent) startEnvWorkers(
 	singularRunner.StartWorker("addresserworker", func() (worker.Worker, error) {
 		return addresser.NewWorker(st)
 	})
+	singularRunner.StartWorker("actionscheduler", func() (worker.Worker, error) {
+		return actionscheduler.NewActionScheduler(st), nil
+	})
+	if envUUID != ssSt.EnvironUUID() {
+		// Hosted environments are removed from state by the
+		// undertaker once they have been destroyed.
+		singularRunner.StartWorker("undertaker", func() (worker.Worker, error) {
+			return undertaker.NewUndertaker(st, 10*time.Second), nil
+		})
+	}
 
 	// Start workers that use an API connection.
 	singularRunner.StartWorker("environ-provisioner", func() (worker.Worker, error) {
@@ -1208,22 +1263,150 @@ func (a *MachineAgent) newApiserverWorker(st *state.State, certChanged chan para
 	dataDir := agentConfig.DataDir()
 	logDir := agentConfig.LogDir()
 
+	userDirectory, err := newUserDirectory(st)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	limits, err := apiServerLimits(agentConfig)
+	if err != nil {
+		return nil, &cmdutil.FatalError{err.Error()}
+	}
+
 	endpoint := net.JoinHostPort("", strconv.Itoa(info.APIPort))
 	listener, err := net.Listen("tcp", endpoint)
 	if err != nil {
 		return nil, err
 	}
 	return apiserver.NewServer(st, listener, apiserver.ServerConfig{
-		Cert:        cert,
-		Key:         key,
-		Tag:         tag,
-		DataDir:     dataDir,
-		LogDir:      logDir,
-		Validator:   a.limitLogins,
-		CertChanged: certChanged,
+		Cert:          cert,
+		Key:           key,
+		Tag:           tag,
+		DataDir:       dataDir,
+		LogDir:        logDir,
+		Validator:     a.limitLogins,
+		CertChanged:   certChanged,
+		UserDirectory: userDirectory,
+		Limits:        limits,
 	})
 }
 
+// apiServerLimits returns the limits on the load that clients may
+// place on the API server, as specified in the agent configuration.
+// Limits that are not specified are left as zero, for the API server
+// to use its defaults.
+func apiServerLimits(agentConfig agent.Config) (apiserver.ServerLimits, error) {
+	var limits apiserver.ServerLimits
+	if value := agentConfig.Value(agent.APIMaxConcurrentLogins); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return limits, errors.Errorf("invalid maximum concurrent API logins: %q", value)
+		}
+		limits.MaxConcurrentLogins = n
+	}
+	if value := agentConfig.Value(agent.APILoginRetryDelay); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return limits, errors.Errorf("invalid API login retry delay: %q", value)
+		}
+		limits.LoginRetryDelay = d
+	}
+	var err error
+	if limits.UserRequests, err = apiRequestLimit(agentConfig, agent.APIUserRequestRate, agent.APIUserRequestBurst); err != nil {
+		return limits, errors.Annotate(err, "invalid user API request limit")
+	}
+	if limits.AgentRequests, err = apiRequestLimit(agentConfig, agent.APIAgentRequestRate, agent.APIAgentRequestBurst); err != nil {
+		return limits, errors.Annotate(err, "invalid agent API request limit")
+	}
+	return limits, nil
+}
+
+// apiRequestLimit returns the API request limit specified by the
+// given rate and burst keys in the agent configuration.
+func apiRequestLimit(agentConfig agent.Config, rateKey, burstKey string) (apiserver.RequestLimit, error) {
+	var limit apiserver.RequestLimit
+	if value := agentConfig.Value(rateKey); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 {
+			return limit, errors.Errorf("invalid rate %q", value)
+		}
+		limit.Rate = rate
+	}
+	if value := agentConfig.Value(burstKey); value != "" {
+		burst, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || burst < 0 {
+			return limit, errors.Errorf("invalid burst %q", value)
+		}
+		limit.Burst = burst
+	}
+	return limit, nil
+}
+
+// newUserDirectory returns the directory the API server authenticates
+// users against, as configured in the ldap-config setting of the state
+// server environment, or nil if there is no such setting. An invalid
+// setting is logged, and no directory used, so that it can be fixed
+// with the users held in state.
+func newUserDirectory(st *state.State) (authentication.Directory, error) {
+	envConfig, err := st.EnvironConfig()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read environment config")
+	}
+	ldapConfig := envConfig.LDAPConfig()
+	if ldapConfig == "" {
+		return nil, nil
+	}
+	config, err := ldap.ParseConfig(ldapConfig)
+	if err != nil {
+		logger.Errorf("not authenticating users against LDAP: %v", err)
+		return nil, nil
+	}
+	dir, err := ldap.New(config)
+	if err != nil {
+		logger.Errorf("not authenticating users against LDAP: invalid LDAP configuration: %v", err)
+		return nil, nil
+	}
+	logger.Infof("authenticating users against LDAP directory at %v", dir)
+	return dir, nil
+}
+
+// userDirectoryConfigHandler restarts the API server when the ldap-config
+// setting of the state server environment changes, so that users are
+// authenticated against the newly configured directory.
+type userDirectoryConfigHandler struct {
+	st      *state.State
+	current string
+	restart func() error
+}
+
+// SetUp is part of the worker.NotifyWatchHandler interface.
+func (h *userDirectoryConfigHandler) SetUp() (apiwatcher.NotifyWatcher, error) {
+	envConfig, err := h.st.EnvironConfig()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	h.current = envConfig.LDAPConfig()
+	return h.st.WatchForEnvironConfigChanges(), nil
+}
+
+// Handle is part of the worker.NotifyWatchHandler interface.
+func (h *userDirectoryConfigHandler) Handle(_ <-chan struct{}) error {
+	envConfig, err := h.st.EnvironConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if ldapConfig := envConfig.LDAPConfig(); ldapConfig != h.current {
+		h.current = ldapConfig
+		logger.Infof("LDAP configuration changed; restarting API server")
+		return h.restart()
+	}
+	return nil
+}
+
+// TearDown is part of the worker.NotifyWatchHandler interface.
+func (h *userDirectoryConfigHandler) TearDown() error {
+	return nil
+}
+
 // limitLogins is called by the API server for each login attempt.
 // it returns an error if upgrades or restore are running.
 func (a *MachineAgent) limitLogins(req params.LoginRequest) error {
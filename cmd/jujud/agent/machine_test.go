@@ -37,6 +37,7 @@ import (
 	apimetricsmanager "github.com/juju/juju/api/metricsmanager"
 	apinetworker "github.com/juju/juju/api/networker"
 	apirsyslog "github.com/juju/juju/api/rsyslog"
+	"github.com/juju/juju/apiserver"
 	charmtesting "github.com/juju/juju/apiserver/charmrevisionupdater/testing"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/cert"
@@ -244,6 +245,7 @@ var perEnvSingularWorkers = []string{
 	"cleaner",
 	"minunitsworker",
 	"addresserworker",
+	"actionscheduler",
 	"environ-provisioner",
 	"charm-revision-updater",
 	"instancepoller",
@@ -743,6 +745,16 @@ func (s *MachineSuite) TestManageEnvironRunsStatusHistoryPruner(c *gc.C) {
 	runner.waitForWorker(c, "statushistorypruner")
 }
 
+func (s *MachineSuite) TestManageEnvironRunsBlobGC(c *gc.C) {
+	m, _, _ := s.primeAgent(c, version.Current, state.JobManageEnviron)
+	a := s.newAgent(c, m)
+	defer func() { c.Check(a.Stop(), jc.ErrorIsNil) }()
+	go func() { c.Check(a.Run(nil), jc.ErrorIsNil) }()
+
+	runner := s.singularRecord.nextRunner(c)
+	runner.waitForWorker(c, "blobgc")
+}
+
 func (s *MachineSuite) TestManageEnvironCallsUseMultipleCPUs(c *gc.C) {
 	// If it has been enabled, the JobManageEnviron agent should call utils.UseMultipleCPUs
 	usefulVersion := version.Current
@@ -1414,7 +1426,7 @@ func (s *MachineSuite) TestMachineAgentRunsEnvironStorageWorker(c *gc.C) {
 func (s *MachineSuite) TestMachineAgentRunsCertificateUpdateWorkerForStateServer(c *gc.C) {
 	started := make(chan struct{})
 	newUpdater := func(certupdater.AddressWatcher, certupdater.StateServingInfoGetter, certupdater.EnvironConfigGetter,
-		certupdater.StateServingInfoSetter, chan params.StateServingInfo,
+		certupdater.CAGetter, certupdater.StateServingInfoSetter, chan params.StateServingInfo,
 	) worker.Worker {
 		close(started)
 		return worker.NewNoOpWorker()
@@ -1438,7 +1450,7 @@ func (s *MachineSuite) TestMachineAgentRunsCertificateUpdateWorkerForStateServer
 func (s *MachineSuite) TestMachineAgentDoesNotRunsCertificateUpdateWorkerForNonStateServer(c *gc.C) {
 	started := make(chan struct{})
 	newUpdater := func(certupdater.AddressWatcher, certupdater.StateServingInfoGetter, certupdater.EnvironConfigGetter,
-		certupdater.StateServingInfoSetter, chan params.StateServingInfo,
+		certupdater.CAGetter, certupdater.StateServingInfoSetter, chan params.StateServingInfo,
 	) worker.Worker {
 		close(started)
 		return worker.NewNoOpWorker()
@@ -1497,7 +1509,7 @@ func (s *MachineSuite) TestCertificateUpdateWorkerUpdatesCertificate(c *gc.C) {
 func (s *MachineSuite) TestCertificateDNSUpdated(c *gc.C) {
 	// Disable the certificate work so it doesn't update the certificate.
 	newUpdater := func(certupdater.AddressWatcher, certupdater.StateServingInfoGetter, certupdater.EnvironConfigGetter,
-		certupdater.StateServingInfoSetter, chan params.StateServingInfo,
+		certupdater.CAGetter, certupdater.StateServingInfoSetter, chan params.StateServingInfo,
 	) worker.Worker {
 		return worker.NewNoOpWorker()
 	}
@@ -1736,6 +1748,8 @@ func (s *MachineSuite) TestNewEnvironmentStartsNewWorkers(c *gc.C) {
 	expectedWorkers, closer := s.setUpAgent(c)
 	defer closer()
 
+	// Hosted environments also run the undertaker.
+	expectedWorkers = append(expectedWorkers, "undertaker")
 	r1 := s.singularRecord.nextRunner(c)
 	workers := r1.waitForWorker(c, "firewaller")
 	c.Assert(workers, jc.SameContents, expectedWorkers)
@@ -2032,10 +2046,56 @@ func (s *shouldWriteProxyFilesSuite) TestAll(c *gc.C) {
 	}
 }
 
+type apiServerLimitsSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&apiServerLimitsSuite{})
+
+func (s *apiServerLimitsSuite) TestDefaults(c *gc.C) {
+	limits, err := apiServerLimits(&mockAgentConfig{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(limits, jc.DeepEquals, apiserver.ServerLimits{})
+}
+
+func (s *apiServerLimitsSuite) TestConfigured(c *gc.C) {
+	limits, err := apiServerLimits(&mockAgentConfig{values: map[string]string{
+		agent.APIMaxConcurrentLogins: "50",
+		agent.APILoginRetryDelay:     "10s",
+		agent.APIUserRequestRate:     "20",
+		agent.APIUserRequestBurst:    "100",
+		agent.APIAgentRequestRate:    "0.5",
+	}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(limits, jc.DeepEquals, apiserver.ServerLimits{
+		MaxConcurrentLogins: 50,
+		LoginRetryDelay:     10 * time.Second,
+		UserRequests:        apiserver.RequestLimit{Rate: 20, Burst: 100},
+		AgentRequests:       apiserver.RequestLimit{Rate: 0.5},
+	})
+}
+
+func (s *apiServerLimitsSuite) TestInvalid(c *gc.C) {
+	for i, test := range []struct {
+		key, value string
+		err        string
+	}{
+		{agent.APIMaxConcurrentLogins, "lots", `invalid maximum concurrent API logins: "lots"`},
+		{agent.APILoginRetryDelay, "-1s", `invalid API login retry delay: "-1s"`},
+		{agent.APIUserRequestRate, "fast", `invalid user API request limit: invalid rate "fast"`},
+		{agent.APIAgentRequestBurst, "1.5", `invalid agent API request limit: invalid burst "1.5"`},
+	} {
+		c.Logf("test %d: %s=%s", i, test.key, test.value)
+		_, err := apiServerLimits(&mockAgentConfig{values: map[string]string{test.key: test.value}})
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
 type mockAgentConfig struct {
 	agent.Config
 	providerType string
 	tag          names.Tag
+	values       map[string]string
 }
 
 func (m *mockAgentConfig) Tag() names.Tag {
@@ -2046,7 +2106,7 @@ func (m *mockAgentConfig) Value(key string) string {
 	if key == agent.ProviderType {
 		return m.providerType
 	}
-	return ""
+	return m.values[key]
 }
 
 type singularRunnerRecord struct {
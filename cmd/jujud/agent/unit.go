@@ -26,6 +26,7 @@ import (
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/apiaddressupdater"
+	"github.com/juju/juju/worker/cacertupdater"
 	workerlogger "github.com/juju/juju/worker/logger"
 	"github.com/juju/juju/worker/proxyupdater"
 	"github.com/juju/juju/worker/rsyslog"
@@ -234,6 +235,9 @@ func (a *UnitAgent) APIWorkers() (_ worker.Worker, err error) {
 		}
 		return apiaddressupdater.NewAPIAddressUpdater(uniterFacade, a), nil
 	})
+	runner.StartWorker("cacertupdater", func() (worker.Worker, error) {
+		return cacertupdater.NewCACertUpdater(st.Agent(), a), nil
+	})
 	runner.StartWorker("rsyslog", func() (worker.Worker, error) {
 		return cmdutil.NewRsyslogConfigWorker(st.Rsyslog(), agentConfig, rsyslog.RsyslogModeForwarding)
 	})
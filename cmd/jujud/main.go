@@ -131,6 +131,7 @@ func jujuDMain(args []string, ctx *cmd.Context) (code int, err error) {
 	jujud.Register(agentcmd.NewMachineAgentCmd(ctx, machineAgentFactory, agentConf, agentConf))
 
 	jujud.Register(agentcmd.NewUnitAgent(ctx))
+	jujud.Register(&ReplayHookCommand{})
 
 	code = cmd.Main(jujud, ctx, args[1:])
 	return code, nil
@@ -0,0 +1,185 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	cmdutil "github.com/juju/juju/cmd/jujud/util"
+	"github.com/juju/juju/version"
+	"github.com/juju/juju/worker/uniter"
+	"github.com/juju/juju/worker/uniter/runner"
+	"github.com/juju/juju/worker/uniter/runner/transcript"
+)
+
+const replayHookDoc = `
+Replay a hook transcript fetched with "juju hook-transcripts".
+
+The recorded hook is run from the unit's charm directory, in the recorded
+environment. Every hook tool it invokes is answered with the response that
+was recorded, rather than being executed, so the hook sees exactly what it
+saw when it originally ran; no changes are made to the environment through
+hook tools. Once the hook completes, any points at which it behaved
+differently from the recording are reported.
+
+The transcript must be in json format. By default the charm and
+hook tools of the unit that recorded the transcript are used; use
+--charm-dir to replay against a modified copy of the charm.
+`
+
+// ReplayHookCommand replays a recorded hook transcript.
+type ReplayHookCommand struct {
+	cmd.CommandBase
+	transcriptFile string
+	charmDir       string
+	toolsDir       string
+}
+
+// Info returns usage information for the command.
+func (c *ReplayHookCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "replay-hook",
+		Args:    "<transcript-file>",
+		Purpose: "replay a recorded hook execution",
+		Doc:     replayHookDoc,
+	}
+}
+
+func (c *ReplayHookCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.charmDir, "charm-dir", "", "the charm directory to run the hook from")
+	f.StringVar(&c.toolsDir, "tools-dir", "", "the directory holding the hook tools")
+}
+
+func (c *ReplayHookCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing transcript file")
+	}
+	c.transcriptFile, args = args[0], args[1:]
+	return cmd.CheckEmpty(args)
+}
+
+func (c *ReplayHookCommand) Run(ctx *cmd.Context) error {
+	t, err := readTranscript(ctx.AbsPath(c.transcriptFile))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	paths, err := c.replayPaths(t)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer os.RemoveAll(paths.tempDir)
+
+	// Acquire the uniter hook execution lock so that the replayed hook
+	// does not run concurrently with any real one.
+	lock, err := cmdutil.HookExecutionLock(cmdutil.DataDir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := lock.Lock("replay-hook"); err != nil {
+		return errors.Trace(err)
+	}
+	defer lock.Unlock()
+
+	result, err := runner.Replay(t, paths, ctx.Stdout, ctx.Stderr)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(result.Divergences) == 0 {
+		fmt.Fprintf(ctx.Stderr, "replayed %s %q as recorded\n", t.Kind, t.Name)
+	} else {
+		fmt.Fprintf(ctx.Stderr, "replayed %s %q with %d divergence(s):\n", t.Kind, t.Name, len(result.Divergences))
+		for _, divergence := range result.Divergences {
+			fmt.Fprintf(ctx.Stderr, "  %s\n", divergence)
+		}
+	}
+	return cmd.NewRcPassthroughError(result.ExitCode)
+}
+
+// readTranscript reads a transcript in json format from the named file.
+func readTranscript(path string) (*transcript.Transcript, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var t transcript.Transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, errors.Annotatef(err, "cannot parse transcript %q", path)
+	}
+	if t.Name == "" {
+		return nil, errors.Errorf("transcript %q does not record a hook", path)
+	}
+	return &t, nil
+}
+
+func (c *ReplayHookCommand) replayPaths(t *transcript.Transcript) (*replayPaths, error) {
+	charmDir, toolsDir := c.charmDir, c.toolsDir
+	if charmDir == "" || toolsDir == "" {
+		if !names.IsValidUnit(t.Unit) {
+			return nil, errors.Errorf("transcript does not name a unit; --charm-dir and --tools-dir must be specified")
+		}
+		unitPaths := uniter.NewPaths(cmdutil.DataDir, names.NewUnitTag(t.Unit))
+		if charmDir == "" {
+			charmDir = unitPaths.State.CharmDir
+		}
+		if toolsDir == "" {
+			toolsDir = unitPaths.ToolsDir
+		}
+	}
+	tempDir, err := ioutil.TempDir("", "replay-hook")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	socket := filepath.Join(tempDir, "replay.socket")
+	if version.Current.OS == version.Windows {
+		socket = fmt.Sprintf(`\\.\pipe\replay-hook-%d`, os.Getpid())
+	}
+	return &replayPaths{
+		charmDir: charmDir,
+		toolsDir: toolsDir,
+		socket:   socket,
+		tempDir:  tempDir,
+	}, nil
+}
+
+// replayPaths implements runner.Paths for a replayed hook.
+type replayPaths struct {
+	charmDir string
+	toolsDir string
+	socket   string
+	tempDir  string
+}
+
+// GetToolsDir is part of the runner.Paths interface.
+func (p *replayPaths) GetToolsDir() string {
+	return p.toolsDir
+}
+
+// GetCharmDir is part of the runner.Paths interface.
+func (p *replayPaths) GetCharmDir() string {
+	return p.charmDir
+}
+
+// GetJujucSocket is part of the runner.Paths interface.
+func (p *replayPaths) GetJujucSocket() string {
+	return p.socket
+}
+
+// GetMetricsSpoolDir is part of the runner.Paths interface.
+func (p *replayPaths) GetMetricsSpoolDir() string {
+	return p.tempDir
+}
+
+// GetResourcesDir is part of the runner.Paths interface.
+func (p *replayPaths) GetResourcesDir() string {
+	return filepath.Join(p.tempDir, "resources")
+}
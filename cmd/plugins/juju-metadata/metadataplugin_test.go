@@ -31,6 +31,7 @@ var metadataCommandNames = []string{
 	"generate-image",
 	"generate-tools",
 	"help",
+	"mirror",
 	"sign",
 	"validate-images",
 	"validate-tools",
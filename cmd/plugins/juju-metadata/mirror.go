@@ -0,0 +1,340 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/utils"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/environs/filestorage"
+	"github.com/juju/juju/environs/imagemetadata"
+	"github.com/juju/juju/environs/simplestreams"
+	"github.com/juju/juju/environs/storage"
+	envtools "github.com/juju/juju/environs/tools"
+	"github.com/juju/juju/juju/arch"
+	"github.com/juju/juju/version"
+)
+
+var mirrorMetadataDoc = `
+mirror copies tools and image simplestreams metadata, along with the tools
+tarballs it refers to, from a source simplestreams datasource into a local
+directory tree. The resulting tree can be served over HTTP, or copied to a
+host without internet access, and used as the tools-metadata-url and
+image-metadata-url of an environment.
+
+Tools and image metadata are fetched for the series, architectures and stream
+requested. By default the official sources are used, and tools and images are
+mirrored for all supported series and architectures in the "released" stream.
+
+Only metadata signed with the official Juju and Ubuntu cloud image keys is
+mirrored, unless --allow-unsigned is specified; without a signature there is
+nothing to show that the metadata, and the tools checksums it holds, are
+genuine. The size and SHA-256 checksum of every tools tarball is verified
+after it is downloaded.
+
+Running mirror again against the same directory refreshes it incrementally:
+tarballs already present with the expected checksum are not downloaded again,
+and the new metadata is merged with what is already there.
+
+If a keyring file is specified with -k, the metadata written is also inline
+signed using the private key it contains, as done by "juju metadata sign".
+
+Examples:
+
+  - mirror released tools and images for trusty amd64:
+
+   juju metadata mirror -d <workingdir> --series trusty --arch amd64
+
+  - mirror proposed tools only, from an unsigned private source, and sign
+    the result:
+
+   juju metadata mirror -d <workingdir> --stream proposed --only tools \
+       --tools-source http://mirror.example.com/tools --allow-unsigned \
+       -k key.asc
+`
+
+const (
+	mirrorTools  = "tools"
+	mirrorImages = "images"
+)
+
+// MirrorMetadataCommand is used to mirror simplestreams tools and image
+// metadata into a local directory.
+type MirrorMetadataCommand struct {
+	cmd.CommandBase
+	dir         string
+	toolsSource string
+	imageSource string
+	stream      string
+	series      []string
+	arches      []string
+	only        string
+	keyFile     string
+	passphrase  string
+
+	allowUnsigned bool
+}
+
+func (c *MirrorMetadataCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "mirror",
+		Purpose: "mirror simplestreams tools and image metadata into a local directory",
+		Doc:     mirrorMetadataDoc,
+	}
+}
+
+func (c *MirrorMetadataCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.CommandBase.SetFlags(f)
+	f.StringVar(&c.dir, "d", "", "local directory in which to store the mirror")
+	f.StringVar(&c.toolsSource, "tools-source", envtools.DefaultBaseURL, "URL or directory of the source tools metadata")
+	f.StringVar(&c.imageSource, "image-source", imagemetadata.DefaultBaseURL, "URL or directory of the source image metadata")
+	f.StringVar(&c.stream, "stream", envtools.ReleasedStream, "simplestreams stream to mirror")
+	f.Var((*cmd.StringsValue)(&c.series), "series", "only mirror these series (comma separated)")
+	f.Var((*cmd.StringsValue)(&c.arches), "arch", "only mirror these architectures (comma separated)")
+	f.StringVar(&c.only, "only", "", `only mirror "tools" or "images"`)
+	f.StringVar(&c.keyFile, "k", "", "file containing the amored private signing key")
+	f.StringVar(&c.passphrase, "p", "", "passphrase used to decrypt the private key")
+	f.BoolVar(&c.allowUnsigned, "allow-unsigned", false, "mirror source metadata that is not signed")
+}
+
+func (c *MirrorMetadataCommand) Init(args []string) error {
+	if c.dir == "" {
+		return fmt.Errorf("directory must be specified")
+	}
+	switch c.only {
+	case "", mirrorTools, mirrorImages:
+	default:
+		return fmt.Errorf("--only must be %q or %q, not %q", mirrorTools, mirrorImages, c.only)
+	}
+	for _, series := range c.series {
+		if _, err := version.SeriesVersion(series); err != nil {
+			return err
+		}
+	}
+	for _, a := range c.arches {
+		if !arch.IsSupportedArch(a) {
+			return fmt.Errorf("%q is not a supported architecture", a)
+		}
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *MirrorMetadataCommand) Run(context *cmd.Context) error {
+	loggo.RegisterWriter("mirrormetadata", cmd.NewCommandLogWriter("juju.plugins.metadata", context.Stdout, context.Stderr), loggo.INFO)
+	defer loggo.RemoveWriter("mirrormetadata")
+	var keyData []byte
+	if c.keyFile != "" {
+		var err error
+		if keyData, err = ioutil.ReadFile(context.AbsPath(c.keyFile)); err != nil {
+			return err
+		}
+	}
+	dir := context.AbsPath(c.dir)
+	stor, err := filestorage.NewFileStorageWriter(dir)
+	if err != nil {
+		return err
+	}
+	if c.only != mirrorImages {
+		if err := c.mirrorTools(dir, stor); err != nil {
+			return errors.Annotate(err, "cannot mirror tools")
+		}
+	}
+	if c.only != mirrorTools {
+		if err := c.mirrorImages(stor); err != nil {
+			return errors.Annotate(err, "cannot mirror image metadata")
+		}
+	}
+	if keyData != nil {
+		return process(dir, string(keyData), c.passphrase)
+	}
+	return nil
+}
+
+// lookupParams returns the simplestreams lookup parameters
+// for the series, architectures and stream being mirrored.
+func (c *MirrorMetadataCommand) lookupParams() simplestreams.LookupParams {
+	params := simplestreams.LookupParams{
+		Series: c.series,
+		Arches: c.arches,
+		Stream: c.stream,
+	}
+	if len(params.Series) == 0 {
+		params.Series = version.SupportedSeries()
+	}
+	if len(params.Arches) == 0 {
+		params.Arches = arch.AllSupportedArches
+	}
+	return params
+}
+
+// mirrorTools fetches the tools metadata matching the command's
+// parameters, downloads the tarballs it refers to into dir, and
+// merges the metadata with any already in stor.
+func (c *MirrorMetadataCommand) mirrorTools(dir string, stor storage.Storage) error {
+	sourceURL, err := envtools.ToolsURL(c.toolsSource)
+	if err != nil {
+		return err
+	}
+	logger.Infof("mirroring %q tools from %s", c.stream, sourceURL)
+	source := simplestreams.NewURLDataSource("mirror source", sourceURL, utils.VerifySSLHostnames)
+	cons := envtools.NewGeneralToolsConstraint(-1, -1, c.lookupParams())
+	metadata, _, err := envtools.Fetch([]simplestreams.DataSource{source}, cons, !c.allowUnsigned)
+	if errors.IsNotFound(err) && !c.allowUnsigned {
+		return c.noSignedMetadataError("tools")
+	} else if err != nil {
+		return err
+	}
+	if len(metadata) == 0 {
+		return errors.NotFoundf("%q tools", c.stream)
+	}
+	fetched := make(map[string]bool)
+	for _, md := range metadata {
+		if err := mirrorToolsTarball(dir, md); err != nil {
+			return err
+		}
+		fetched[md.Path] = true
+	}
+
+	existing, err := envtools.ReadAllMetadata(stor)
+	if err != nil {
+		return err
+	}
+	// Metadata fetched from the source replaces any existing
+	// metadata for the same tarballs, which may be out of date.
+	var kept []*envtools.ToolsMetadata
+	for _, md := range existing[c.stream] {
+		if !fetched[md.Path] {
+			kept = append(kept, md)
+		}
+	}
+	merged, err := envtools.MergeMetadata(metadata, kept)
+	if err != nil {
+		return err
+	}
+	existing[c.stream] = merged
+	return envtools.WriteMetadata(stor, existing, []string{c.stream}, envtools.DoNotWriteMirrors)
+}
+
+// mirrorToolsTarball downloads the tools tarball described by md into
+// the tools directory below dir, unless a copy with the expected size
+// and checksum is already there.
+func mirrorToolsTarball(dir string, md *envtools.ToolsMetadata) error {
+	cleanPath := path.Clean(md.Path)
+	if path.IsAbs(cleanPath) || cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+		return errors.NotValidf("tools path %q", md.Path)
+	}
+	target := filepath.Join(dir, storage.BaseToolsPath, filepath.FromSlash(cleanPath))
+	if hash, size, err := utils.ReadFileSHA256(target); err == nil && size == md.Size && hash == md.SHA256 {
+		logger.Infof("%s is up to date", md.Path)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	logger.Infof("downloading %s", md.FullPath)
+	resp, err := utils.GetHTTPClient(utils.VerifySSLHostnames).Get(md.FullPath)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("cannot download %s: %s", md.FullPath, resp.Status)
+	}
+	f, err := ioutil.TempFile(filepath.Dir(target), "mirror-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	sha256hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, sha256hash), resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Annotatef(err, "cannot download %s", md.FullPath)
+	}
+	hash := fmt.Sprintf("%x", sha256hash.Sum(nil))
+	if size != md.Size || hash != md.SHA256 {
+		return errors.Errorf(
+			"checksum mismatch for %s: expected size %d, sha256 %s; got size %d, sha256 %s",
+			md.Path, md.Size, md.SHA256, size, hash,
+		)
+	}
+	return os.Rename(f.Name(), target)
+}
+
+// mirrorImages fetches the image metadata matching the command's
+// parameters and merges it with any already in stor.
+func (c *MirrorMetadataCommand) mirrorImages(stor storage.Storage) error {
+	sourceURL, err := imagemetadata.ImageMetadataURL(c.imageSource, c.stream)
+	if err != nil {
+		return err
+	}
+	logger.Infof("mirroring %q image metadata from %s", c.stream, sourceURL)
+	source := simplestreams.NewURLDataSource("mirror source", sourceURL, utils.VerifySSLHostnames)
+	params := c.lookupParams()
+	var found bool
+	for _, series := range params.Series {
+		seriesParams := params
+		seriesParams.Series = []string{series}
+		cons := imagemetadata.NewImageConstraint(seriesParams)
+		metadata, _, err := imagemetadata.Fetch([]simplestreams.DataSource{source}, cons, !c.allowUnsigned)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if len(metadata) == 0 {
+			continue
+		}
+		found = true
+
+		// Metadata is written one cloud region at a time.
+		var cloudSpecs []simplestreams.CloudSpec
+		byCloudSpec := make(map[simplestreams.CloudSpec][]*imagemetadata.ImageMetadata)
+		for _, im := range metadata {
+			cloudSpec := simplestreams.CloudSpec{Region: im.RegionName, Endpoint: im.Endpoint}
+			if _, ok := byCloudSpec[cloudSpec]; !ok {
+				cloudSpecs = append(cloudSpecs, cloudSpec)
+			}
+			byCloudSpec[cloudSpec] = append(byCloudSpec[cloudSpec], im)
+		}
+		for _, cloudSpec := range cloudSpecs {
+			logger.Infof("writing %s image metadata for region %q", series, cloudSpec.Region)
+			cloudSpec := cloudSpec
+			if err := imagemetadata.MergeAndWriteMetadata(series, byCloudSpec[cloudSpec], &cloudSpec, stor); err != nil {
+				return err
+			}
+		}
+	}
+	if !found {
+		if !c.allowUnsigned {
+			return c.noSignedMetadataError("image metadata")
+		}
+		return errors.NotFoundf("%q image metadata", c.stream)
+	}
+	return nil
+}
+
+// noSignedMetadataError returns the error reported when no signed
+// metadata of the given kind can be found in the source.
+func (c *MirrorMetadataCommand) noSignedMetadataError(kind string) error {
+	return errors.NewNotFound(nil, fmt.Sprintf(
+		"no signed %q %s found (use --allow-unsigned to mirror unsigned metadata)", c.stream, kind,
+	))
+}
@@ -0,0 +1,213 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/loggo"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/environs/filestorage"
+	"github.com/juju/juju/environs/imagemetadata"
+	imagetesting "github.com/juju/juju/environs/imagemetadata/testing"
+	"github.com/juju/juju/environs/simplestreams"
+	sstesting "github.com/juju/juju/environs/simplestreams/testing"
+	toolstesting "github.com/juju/juju/environs/tools/testing"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type MirrorMetadataSuite struct {
+	coretesting.FakeJujuHomeSuite
+	sourceDir string
+	server    *httptest.Server
+	targetDir string
+}
+
+var _ = gc.Suite(&MirrorMetadataSuite{})
+
+func (s *MirrorMetadataSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.AddCleanup(func(*gc.C) {
+		loggo.ResetLoggers()
+	})
+	loggo.GetLogger("").SetLogLevel(loggo.INFO)
+
+	// The source datasource is a local HTTP server
+	// serving tools and image metadata from a directory.
+	s.sourceDir = c.MkDir()
+	toolstesting.MakeToolsWithCheckSum(c, s.sourceDir, "released", []string{
+		"1.12.0-precise-amd64",
+		"1.12.0-precise-i386",
+		"1.12.0-raring-amd64",
+	})
+	stor, err := filestorage.NewFileStorageWriter(s.sourceDir)
+	c.Assert(err, jc.ErrorIsNil)
+	err = imagemetadata.MergeAndWriteMetadata("precise", []*imagemetadata.ImageMetadata{{
+		Id:       "ami-1234",
+		Arch:     "amd64",
+		VirtType: "pv",
+	}}, &simplestreams.CloudSpec{Region: "region", Endpoint: "endpoint"}, stor)
+	c.Assert(err, jc.ErrorIsNil)
+	s.server = httptest.NewServer(http.FileServer(http.Dir(s.sourceDir)))
+	s.AddCleanup(func(*gc.C) {
+		s.server.Close()
+	})
+	s.targetDir = c.MkDir()
+}
+
+func (s *MirrorMetadataSuite) runMirror(c *gc.C, args ...string) (string, error) {
+	args = append([]string{
+		"-d", s.targetDir,
+		"--tools-source", s.server.URL + "/tools",
+		"--image-source", s.server.URL + "/images",
+		"--allow-unsigned",
+	}, args...)
+	ctx, err := coretesting.RunCommand(c, &MirrorMetadataCommand{}, args...)
+	return coretesting.Stdout(ctx), err
+}
+
+func (s *MirrorMetadataSuite) toolsPath(name string) string {
+	return filepath.Join(s.targetDir, "tools", "released", name)
+}
+
+func (s *MirrorMetadataSuite) TestMirror(c *gc.C) {
+	_, err := s.runMirror(c, "--series", "precise", "--arch", "amd64")
+	c.Assert(err, jc.ErrorIsNil)
+
+	data, err := ioutil.ReadFile(s.toolsPath("juju-1.12.0-precise-amd64.tgz"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "1.12.0-precise-amd64")
+	_, err = os.Stat(s.toolsPath("juju-1.12.0-precise-i386.tgz"))
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+	_, err = os.Stat(s.toolsPath("juju-1.12.0-raring-amd64.tgz"))
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+
+	metadata := toolstesting.ParseMetadataFromDir(c, s.targetDir, "released", false)
+	c.Assert(metadata, gc.HasLen, 1)
+	size, sha256 := toolstesting.SHA256sum(c, s.toolsPath("juju-1.12.0-precise-amd64.tgz"))
+	c.Assert(metadata[0].Path, gc.Equals, "released/juju-1.12.0-precise-amd64.tgz")
+	c.Assert(metadata[0].Size, gc.Equals, size)
+	c.Assert(metadata[0].SHA256, gc.Equals, sha256)
+
+	images := imagetesting.ParseMetadataFromDir(c, s.targetDir)
+	c.Assert(images, gc.HasLen, 1)
+	c.Assert(images[0].Id, gc.Equals, "ami-1234")
+	c.Assert(images[0].Arch, gc.Equals, "amd64")
+}
+
+func (s *MirrorMetadataSuite) TestMirrorOnlyTools(c *gc.C) {
+	_, err := s.runMirror(c, "--series", "precise", "--only", "tools")
+	c.Assert(err, jc.ErrorIsNil)
+
+	metadata := toolstesting.ParseMetadataFromDir(c, s.targetDir, "released", false)
+	c.Assert(metadata, gc.HasLen, 2)
+	_, err = os.Stat(filepath.Join(s.targetDir, "images"))
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
+
+func (s *MirrorMetadataSuite) TestMirrorIncremental(c *gc.C) {
+	_, err := s.runMirror(c, "--series", "precise", "--arch", "amd64", "--only", "tools")
+	c.Assert(err, jc.ErrorIsNil)
+
+	// A second run skips tarballs that are already up to date.
+	out, err := s.runMirror(c, "--series", "precise", "--only", "tools")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(out, gc.Matches, `(?s).*released/juju-1\.12\.0-precise-amd64\.tgz is up to date.*`)
+	c.Assert(out, gc.Matches, `(?s).*downloading .*/juju-1\.12\.0-precise-i386\.tgz.*`)
+	metadata := toolstesting.ParseMetadataFromDir(c, s.targetDir, "released", false)
+	c.Assert(metadata, gc.HasLen, 2)
+
+	// A tarball that no longer matches its checksum is downloaded again.
+	err = ioutil.WriteFile(s.toolsPath("juju-1.12.0-precise-amd64.tgz"), []byte("corrupt"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	out, err = s.runMirror(c, "--series", "precise", "--arch", "amd64", "--only", "tools")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(out, gc.Matches, `(?s).*downloading .*/juju-1\.12\.0-precise-amd64\.tgz.*`)
+	data, err := ioutil.ReadFile(s.toolsPath("juju-1.12.0-precise-amd64.tgz"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "1.12.0-precise-amd64")
+}
+
+func (s *MirrorMetadataSuite) TestMirrorChecksumMismatch(c *gc.C) {
+	err := ioutil.WriteFile(
+		filepath.Join(s.sourceDir, "tools", "released", "juju-1.12.0-precise-amd64.tgz"), []byte("tampered"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.runMirror(c, "--series", "precise", "--arch", "amd64")
+	c.Assert(err, gc.ErrorMatches, `cannot mirror tools: checksum mismatch for released/juju-1\.12\.0-precise-amd64\.tgz: .*`)
+	_, err = os.Stat(s.toolsPath("juju-1.12.0-precise-amd64.tgz"))
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
+
+func (s *MirrorMetadataSuite) TestMirrorSigned(c *gc.C) {
+	keyFile := filepath.Join(c.MkDir(), "privatekey.asc")
+	err := ioutil.WriteFile(keyFile, []byte(sstesting.SignedMetadataPrivateKey), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.runMirror(c, "--series", "precise", "-k", keyFile, "-p", sstesting.PrivateKeyPassphrase)
+	c.Assert(err, jc.ErrorIsNil)
+	for _, name := range []string{
+		"tools/streams/v1/index2.sjson",
+		"tools/streams/v1/com.ubuntu.juju-released-tools.sjson",
+		"images/streams/v1/index.sjson",
+	} {
+		r, err := os.Open(filepath.Join(s.targetDir, filepath.FromSlash(name)))
+		c.Assert(err, jc.ErrorIsNil)
+		_, err = simplestreams.DecodeCheckSignature(r, sstesting.SignedMetadataPublicKey)
+		r.Close()
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *MirrorMetadataSuite) TestMirrorNoMatchingTools(c *gc.C) {
+	_, err := s.runMirror(c, "--series", "trusty", "--only", "tools")
+	c.Assert(err, gc.ErrorMatches, `cannot mirror tools: "released" tools not found`)
+}
+
+func (s *MirrorMetadataSuite) TestMirrorRequiresSignedMetadata(c *gc.C) {
+	for _, only := range []string{"tools", "images"} {
+		_, err := coretesting.RunCommand(c, &MirrorMetadataCommand{},
+			"-d", s.targetDir,
+			"--tools-source", s.server.URL+"/tools",
+			"--image-source", s.server.URL+"/images",
+			"--series", "precise",
+			"--only", only,
+		)
+		c.Check(err, gc.ErrorMatches, `cannot mirror .*: no signed "released" .* found \(use --allow-unsigned to mirror unsigned metadata\)`)
+	}
+	_, err := os.Stat(s.toolsPath("juju-1.12.0-precise-amd64.tgz"))
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
+
+func (s *MirrorMetadataSuite) TestMirrorErrors(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  "directory must be specified",
+	}, {
+		args: []string{"-d", "foo", "--only", "charms"},
+		err:  `--only must be "tools" or "images", not "charms"`,
+	}, {
+		args: []string{"-d", "foo", "--series", "bogus"},
+		err:  `.*unknown version for series: "bogus"`,
+	}, {
+		args: []string{"-d", "foo", "--arch", "z80"},
+		err:  `"z80" is not a supported architecture`,
+	}, {
+		args: []string{"-d", "foo", "extra"},
+		err:  `unrecognized args: \["extra"\]`,
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		_, err := coretesting.RunCommand(c, &MirrorMetadataCommand{}, test.args...)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
@@ -29,6 +29,7 @@ const (
 	Tags         = "tags"
 	InstanceType = "instance-type"
 	Networks     = "networks"
+	Spaces       = "spaces"
 )
 
 // Value describes a user's requirements of the hardware on which units
@@ -78,6 +79,10 @@ type Value struct {
 	// negative values are accepted, and the difference is the latter
 	// have a "^" prefix to the name.
 	Networks *[]string `json:"networks,omitempty" yaml:"networks,omitempty"`
+
+	// Spaces, if not nil, holds a list of juju space names in each of
+	// which the machine must have a network interface.
+	Spaces *[]string `json:"spaces,omitempty" yaml:"spaces,omitempty"`
 }
 
 // fieldNames records a mapping from the constraint tag to struct field name.
@@ -151,6 +156,11 @@ func (v *Value) HaveNetworks() bool {
 	return v.Networks != nil && len(*v.Networks) > 0
 }
 
+// HaveSpaces returns whether any spaces constraints were specified.
+func (v *Value) HaveSpaces() bool {
+	return v.Spaces != nil && len(*v.Spaces) > 0
+}
+
 // String expresses a constraints.Value in the language in which it was specified.
 func (v Value) String() string {
 	var strs []string
@@ -191,6 +201,10 @@ func (v Value) String() string {
 		s := strings.Join(*v.Networks, ",")
 		strs = append(strs, "networks="+s)
 	}
+	if v.Spaces != nil {
+		s := strings.Join(*v.Spaces, ",")
+		strs = append(strs, "spaces="+s)
+	}
 	return strings.Join(strs, " ")
 }
 
@@ -330,6 +344,8 @@ func (v *Value) setRaw(raw string) error {
 		err = v.setInstanceType(str)
 	case Networks:
 		err = v.setNetworks(str)
+	case Spaces:
+		err = v.setSpaces(str)
 	default:
 		return fmt.Errorf("unknown constraint %q", name)
 	}
@@ -376,6 +392,12 @@ func (v *Value) SetYAML(tag string, value interface{}) bool {
 			if err == nil {
 				err = v.validateNetworks(networks)
 			}
+		case Spaces:
+			var spaces *[]string
+			spaces, err = parseYamlStrings("spaces", val)
+			if err == nil {
+				err = v.validateSpaces(spaces)
+			}
 		default:
 			return false
 		}
@@ -494,6 +516,26 @@ func (v *Value) validateNetworks(networks *[]string) error {
 	return nil
 }
 
+func (v *Value) setSpaces(str string) error {
+	if v.Spaces != nil {
+		return fmt.Errorf("already set")
+	}
+	return v.validateSpaces(parseCommaDelimited(str))
+}
+
+func (v *Value) validateSpaces(spaces *[]string) error {
+	if spaces == nil {
+		return nil
+	}
+	for _, name := range *spaces {
+		if !names.IsValidSpace(name) {
+			return fmt.Errorf("%q is not a valid space name", name)
+		}
+	}
+	v.Spaces = spaces
+	return nil
+}
+
 func parseUint64(str string) (*uint64, error) {
 	var value uint64
 	if str != "" {
@@ -525,7 +567,8 @@ func parseSize(str string) (*uint64, error) {
 }
 
 // parseCommaDelimited returns the items in the value s. We expect the
-// tags to be comma delimited strings. It is used for tags and networks.
+// tags to be comma delimited strings. It is used for tags, networks
+// and spaces.
 func parseCommaDelimited(s string) *[]string {
 	if s == "" {
 		return &[]string{}
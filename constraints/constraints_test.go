@@ -280,6 +280,26 @@ var parseConstraintsTests = []struct {
 		args:    []string{"networks="},
 	},
 
+	// spaces
+	{
+		summary: "single space",
+		args:    []string{"spaces=db"},
+	}, {
+		summary: "multiple spaces",
+		args:    []string{"spaces=db,storage-net"},
+	}, {
+		summary: "no spaces",
+		args:    []string{"spaces="},
+	}, {
+		summary: "invalid space",
+		args:    []string{"spaces=Db"},
+		err:     `bad "spaces" constraint: "Db" is not a valid space name`,
+	}, {
+		summary: "double set spaces together",
+		args:    []string{"spaces=db spaces=web"},
+		err:     `bad "spaces" constraint: already set`,
+	},
+
 	// instance type
 	{
 		summary: "set instance type",
@@ -389,6 +409,16 @@ func (s *ConstraintsSuite) TestInvalidNetworks(c *gc.C) {
 	}
 }
 
+func (s *ConstraintsSuite) TestHaveSpaces(c *gc.C) {
+	con := constraints.MustParse("spaces=db,storage")
+	c.Check(con.HaveSpaces(), jc.IsTrue)
+	c.Check(*con.Spaces, jc.DeepEquals, []string{"db", "storage"})
+	con = constraints.MustParse("spaces=")
+	c.Check(con.HaveSpaces(), jc.IsFalse)
+	con = constraints.MustParse("mem=4G")
+	c.Check(con.HaveSpaces(), jc.IsFalse)
+}
+
 func (s *ConstraintsSuite) TestIsEmpty(c *gc.C) {
 	con := constraints.Value{}
 	c.Check(&con, jc.Satisfies, constraints.IsEmpty)
@@ -400,6 +430,8 @@ func (s *ConstraintsSuite) TestIsEmpty(c *gc.C) {
 	c.Check(&con, gc.Not(jc.Satisfies), constraints.IsEmpty)
 	con = constraints.MustParse("networks=")
 	c.Check(&con, gc.Not(jc.Satisfies), constraints.IsEmpty)
+	con = constraints.MustParse("spaces=")
+	c.Check(&con, gc.Not(jc.Satisfies), constraints.IsEmpty)
 	con = constraints.MustParse("mem=")
 	c.Check(&con, gc.Not(jc.Satisfies), constraints.IsEmpty)
 	con = constraints.MustParse("arch=")
@@ -459,6 +491,9 @@ var constraintsRoundtripTests = []roundTrip{
 	{"Networks1", constraints.Value{Networks: nil}},
 	{"Networks2", constraints.Value{Networks: &[]string{}}},
 	{"Networks3", constraints.Value{Networks: &[]string{"net1", "^net2"}}},
+	{"Spaces1", constraints.Value{Spaces: nil}},
+	{"Spaces2", constraints.Value{Spaces: &[]string{}}},
+	{"Spaces3", constraints.Value{Spaces: &[]string{"db", "storage"}}},
 	{"InstanceType1", constraints.Value{InstanceType: strp("")}},
 	{"InstanceType2", constraints.Value{InstanceType: strp("foo")}},
 	{"All", constraints.Value{
@@ -470,6 +505,7 @@ var constraintsRoundtripTests = []roundTrip{
 		RootDisk:     uint64p(24000000000),
 		Tags:         &[]string{"foo", "bar"},
 		Networks:     &[]string{"net1", "^net2"},
+		Spaces:       &[]string{"db"},
 		InstanceType: strp("foo"),
 	}},
 }
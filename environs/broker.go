@@ -49,6 +49,13 @@ type StartInstanceParams struct {
 	// NetworkInfo is an optional list of network interface details,
 	// necessary to configure on the instance.
 	NetworkInfo []network.InterfaceInfo
+
+	// SubnetsToZones is an optional map of provider subnet ids to
+	// the availability zones they are in. If not empty, the instance
+	// must be started in one of those subnets, as the machine is
+	// constrained to have a network interface in the spaces they
+	// are part of.
+	SubnetsToZones map[network.Id][]string
 }
 
 // StartInstanceResult holds the result of an
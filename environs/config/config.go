@@ -6,6 +6,7 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -20,8 +21,11 @@ import (
 	"gopkg.in/juju/charm.v5"
 	"gopkg.in/juju/charm.v5/charmrepo"
 	"gopkg.in/juju/environschema.v1"
+	goyaml "gopkg.in/yaml.v1"
 
 	"github.com/juju/juju/cert"
+	"github.com/juju/juju/charmsig"
+	"github.com/juju/juju/cloudconfig/cloudinit"
 	"github.com/juju/juju/environs/tags"
 	"github.com/juju/juju/juju/osenv"
 	"github.com/juju/juju/version"
@@ -171,6 +175,45 @@ const (
 	// interfaces created for LXC containers. See also bug #1442257.
 	LXCDefaultMTU = "lxc-default-mtu"
 
+	// HookTranscriptsKey, when true, causes unit agents to record a
+	// transcript of every hook they run, for later inspection with
+	// juju hook-transcripts. Values that may be secret, such as charm
+	// configuration and relation settings, are redacted.
+	HookTranscriptsKey = "hook-transcripts"
+
+	// CloudInitUserDataKey holds a cloud-config YAML document whose
+	// packages, bootcmd, runcmd and write_files directives are added
+	// to the cloud-init configuration of every provisioned machine.
+	CloudInitUserDataKey = "cloudinit-userdata"
+
+	// CharmSigningKeysKey holds the armored OpenPGP public keys trusted
+	// to sign charms. When set, only charms signed by one of the keys
+	// may be added to the environment.
+	CharmSigningKeysKey = "charm-signing-keys"
+
+	// LDAPConfigKey holds the YAML configuration of the LDAP directory
+	// against which the API servers authenticate users. It is only
+	// read from the state server environment's config, and the bind
+	// password in it is redacted wherever the config is reported.
+	LDAPConfigKey = "ldap-config"
+
+	// CharmRepositoryURLKey holds the URL of the controller charm
+	// repository against which cs: charm URLs are resolved, in place
+	// of the charm store.
+	CharmRepositoryURLKey = "charm-repository-url"
+
+	// CharmRepositoryAnonymousAccessKey, when true, allows anyone to
+	// list and download the charms published in the controller charm
+	// repository without authenticating. It is only read from the
+	// state server environment's config.
+	CharmRepositoryAnonymousAccessKey = "charm-repository-anonymous-access"
+
+	// StatusHistoryMaxAgeKey holds the age, as a duration such as
+	// "168h", beyond which status history entries are pruned however
+	// few there are for an entity. If empty, entries are only pruned
+	// by number.
+	StatusHistoryMaxAgeKey = "status-history-max-age"
+
 	//
 	// Deprecated Settings Attributes
 	//
@@ -629,6 +672,43 @@ func Validate(cfg, old *Config) error {
 		}
 	}
 
+	// Ensure the cloud-init user data only uses supported directives.
+	if userData := cfg.CloudInitUserData(); userData != "" {
+		if _, err := cloudinit.ParseUserData(userData); err != nil {
+			return errors.Annotatef(err, "invalid %s", CloudInitUserDataKey)
+		}
+	}
+
+	// Ensure the trusted charm signing keys can be read.
+	if keys := cfg.CharmSigningKeys(); keys != "" {
+		if _, err := charmsig.ReadKeyRing(keys); err != nil {
+			return errors.Annotatef(err, "invalid %s", CharmSigningKeysKey)
+		}
+	}
+
+	// Ensure the LDAP configuration is a YAML mapping. Its contents
+	// are checked by the API servers that use it.
+	if ldapConfig := cfg.LDAPConfig(); ldapConfig != "" {
+		var attrs map[string]interface{}
+		if err := goyaml.Unmarshal([]byte(ldapConfig), &attrs); err != nil {
+			return errors.Annotatef(err, "invalid %s", LDAPConfigKey)
+		}
+	}
+
+	// Ensure the charm repository URL is an absolute URL.
+	if repoURL := cfg.CharmRepositoryURL(); repoURL != "" {
+		if u, err := url.Parse(repoURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.Errorf("invalid %s: %q is not an absolute URL", CharmRepositoryURLKey, repoURL)
+		}
+	}
+
+	// Ensure the status history age limit is a positive duration.
+	if maxAge := cfg.asString(StatusHistoryMaxAgeKey); maxAge != "" {
+		if d, err := time.ParseDuration(maxAge); err != nil || d <= 0 {
+			return errors.Errorf("invalid %s: %q is not a positive duration", StatusHistoryMaxAgeKey, maxAge)
+		}
+	}
+
 	// Check LXCDefaultMTU is a positive integer, when set.
 	if lxcDefaultMTU, ok := cfg.LXCDefaultMTU(); ok && lxcDefaultMTU < 0 {
 		return errors.Errorf("%s: expected positive integer, got %v", LXCDefaultMTU, lxcDefaultMTU)
@@ -1137,6 +1217,102 @@ func (c *Config) DisableNetworkManagement() (bool, bool) {
 	return v, ok
 }
 
+// HookTranscripts reports whether unit agents should record a
+// transcript of each hook execution.
+func (c *Config) HookTranscripts() bool {
+	v, _ := c.defined[HookTranscriptsKey].(bool)
+	return v
+}
+
+// CloudInitUserData returns the cloud-init user data to be added to
+// the configuration of every provisioned machine, or the empty string
+// if none is set.
+func (c *Config) CloudInitUserData() string {
+	return c.asString(CloudInitUserDataKey)
+}
+
+// CharmSigningKeys returns the armored OpenPGP public keys trusted to
+// sign charms, or the empty string if charms need not be signed.
+func (c *Config) CharmSigningKeys() string {
+	return c.asString(CharmSigningKeysKey)
+}
+
+// LDAPConfig returns the YAML configuration of the LDAP directory
+// against which users are authenticated, or the empty string if users
+// are only authenticated with passwords held in state.
+func (c *Config) LDAPConfig() string {
+	return c.asString(LDAPConfigKey)
+}
+
+// redactedValue replaces secrets in environment configuration
+// returned outside the state servers.
+const redactedValue = "not available"
+
+// RedactSecrets returns a copy of the given environment configuration
+// attributes in which the secrets that must not leave the state
+// servers are replaced. Such a copy must not be used to set the
+// configuration.
+func RedactSecrets(attrs map[string]interface{}) map[string]interface{} {
+	if attrs == nil {
+		return nil
+	}
+	redacted := make(map[string]interface{}, len(attrs))
+	for key, value := range attrs {
+		redacted[key] = RedactSecret(key, value)
+	}
+	return redacted
+}
+
+// RedactSecret returns the value of the given environment configuration
+// attribute with any secret in it replaced. Only the bind password held
+// in the ldap-config setting is replaced; the rest of the LDAP
+// configuration is left as it is.
+func RedactSecret(key string, value interface{}) interface{} {
+	if key != LDAPConfigKey {
+		return value
+	}
+	ldapConfig, ok := value.(string)
+	if !ok || ldapConfig == "" {
+		return value
+	}
+	var attrs map[string]interface{}
+	if err := goyaml.Unmarshal([]byte(ldapConfig), &attrs); err != nil {
+		return redactedValue
+	}
+	if password, ok := attrs["bind-password"]; !ok || password == "" {
+		return value
+	}
+	attrs["bind-password"] = redactedValue
+	data, err := goyaml.Marshal(attrs)
+	if err != nil {
+		return redactedValue
+	}
+	return string(data)
+}
+
+// CharmRepositoryURL returns the URL of the controller charm repository
+// against which cs: charm URLs are resolved, or the empty string if
+// the charm store is used.
+func (c *Config) CharmRepositoryURL() string {
+	return c.asString(CharmRepositoryURLKey)
+}
+
+// StatusHistoryMaxAge returns the age beyond which status history
+// entries are pruned, or zero if they are only pruned by number.
+func (c *Config) StatusHistoryMaxAge() time.Duration {
+	// The value is checked in Validate.
+	d, _ := time.ParseDuration(c.asString(StatusHistoryMaxAgeKey))
+	return d
+}
+
+// CharmRepositoryAnonymousAccess returns whether anyone may list and
+// download the charms published in the controller charm repository
+// without authenticating.
+func (c *Config) CharmRepositoryAnonymousAccess() bool {
+	v, _ := c.defined[CharmRepositoryAnonymousAccessKey].(bool)
+	return v
+}
+
 // StorageDefaultBlockSource returns the default block storage
 // source for the environment.
 func (c *Config) StorageDefaultBlockSource() (string, bool) {
@@ -1261,6 +1437,14 @@ var alwaysOptional = schema.Defaults{
 	SetNumaControlPolicyKey:      DefaultNumaControlPolicy,
 	AllowLXCLoopMounts:           false,
 	ResourceTagsKey:              schema.Omit,
+	HookTranscriptsKey:           schema.Omit,
+	CloudInitUserDataKey:         schema.Omit,
+	CharmSigningKeysKey:          schema.Omit,
+	LDAPConfigKey:                schema.Omit,
+	CharmRepositoryURLKey:        schema.Omit,
+
+	CharmRepositoryAnonymousAccessKey: schema.Omit,
+	StatusHistoryMaxAgeKey:            schema.Omit,
 
 	// Storage related config.
 	// Environ providers will specify their own defaults.
@@ -1623,6 +1807,26 @@ var configSchema = environschema.Fields{
 		Description: "Path to file containing CA private key",
 		Type:        environschema.Tstring,
 	},
+	CharmRepositoryAnonymousAccessKey: {
+		Description: "Whether anyone may list and download the charms published in the controller charm repository without authenticating; only read from the state server environment",
+		Type:        environschema.Tbool,
+		Group:       environschema.EnvironGroup,
+	},
+	CharmRepositoryURLKey: {
+		Description: "The URL of a controller charm repository against which cs: charm URLs are resolved, in place of the charm store. Credentials for the repository, as a user tag and password, may be given in the URL",
+		Type:        environschema.Tstring,
+		Group:       environschema.EnvironGroup,
+	},
+	CharmSigningKeysKey: {
+		Description: "Armored OpenPGP public keys trusted to sign charms; when set, unsigned charms and charms not signed by one of the keys are rejected",
+		Type:        environschema.Tstring,
+		Group:       environschema.EnvironGroup,
+	},
+	CloudInitUserDataKey: {
+		Description: "A cloud-config YAML document whose packages, bootcmd, runcmd and write_files directives are added to the cloud-init configuration of every provisioned machine",
+		Type:        environschema.Tstring,
+		Group:       environschema.EnvironGroup,
+	},
 	"default-series": {
 		Description: "The default series of Ubuntu to use for deploying charms",
 		Type:        environschema.Tstring,
@@ -1687,11 +1891,22 @@ global or per instance security groups.`,
 		Type:        environschema.Tstring,
 		Group:       environschema.EnvironGroup,
 	},
+	HookTranscriptsKey: {
+		Description: `Whether unit agents should record a transcript of every hook they run, including the hook environment and all hook tool invocations. Charm configuration, relation and leader settings, action parameters and results, and proxy credentials are redacted, but hook tools may still expose secrets in other ways, so transcripts should be treated as sensitive. (default false)`,
+		Type:        environschema.Tbool,
+		Group:       environschema.EnvironGroup,
+	},
 	"image-stream": {
 		Description: `The simplestreams stream used to identify which image ids to search when starting an instance.`,
 		Type:        environschema.Tstring,
 		Group:       environschema.EnvironGroup,
 	},
+	LDAPConfigKey: {
+		Description: "The YAML configuration of an LDAP directory against which users are authenticated; only read from the state server environment. Its bind-password is not shown outside the state servers",
+		Type:        environschema.Tstring,
+		Secret:      true,
+		Group:       environschema.EnvironGroup,
+	},
 	"logging-config": {
 		Description: `The configuration string to use when configuring Juju agent logging (see http://godoc.org/github.com/juju/loggo#ParseConfigurationString for details)`,
 		Type:        environschema.Tstring,
@@ -1781,6 +1996,11 @@ global or per instance security groups.`,
 		Type:        environschema.Tbool,
 		Group:       environschema.EnvironGroup,
 	},
+	StatusHistoryMaxAgeKey: {
+		Description: `The age, as a duration such as "168h", beyond which status history entries are pruned however few there are for an entity; if empty, entries are only pruned by number`,
+		Type:        environschema.Tstring,
+		Group:       environschema.EnvironGroup,
+	},
 	StorageDefaultBlockSourceKey: {
 		Description: "The default block storage source for the environment",
 		Type:        environschema.Tstring,
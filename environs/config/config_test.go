@@ -257,6 +257,84 @@ var configTests = []configTest{
 			"lxc-default-mtu": -42,
 		},
 		err: `lxc-default-mtu: expected positive integer, got -42`,
+	}, {
+		about:       "cloud-init user data set",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":               "my-type",
+			"name":               "my-name",
+			"cloudinit-userdata": "packages: [htop]\nruncmd: [echo hello]\n",
+		},
+	}, {
+		about:       "cloud-init user data with unsupported directive",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":               "my-type",
+			"name":               "my-name",
+			"cloudinit-userdata": "ssh_authorized_keys: [bogus]\n",
+		},
+		err: `invalid cloudinit-userdata: cloud-init directive "ssh_authorized_keys" in user data not supported`,
+	}, {
+		about:       "LDAP configuration set",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":        "my-type",
+			"name":        "my-name",
+			"ldap-config": "url: ldap://ldap.example.com\nuser-base-dn: ou=people,dc=example,dc=com\n",
+		},
+	}, {
+		about:       "LDAP configuration not a mapping",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":        "my-type",
+			"name":        "my-name",
+			"ldap-config": "- ldap://ldap.example.com\n",
+		},
+		err: `invalid ldap-config: .*`,
+	}, {
+		about:       "Invalid charm signing keys",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":               "my-type",
+			"name":               "my-name",
+			"charm-signing-keys": "not a key",
+		},
+		err: `invalid charm-signing-keys: cannot parse public keys: .*`,
+	}, {
+		about:       "Charm repository URL",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                              "my-type",
+			"name":                              "my-name",
+			"charm-repository-url":              "https://10.0.0.1:17070/charmrepository",
+			"charm-repository-anonymous-access": true,
+		},
+	}, {
+		about:       "Status history max age",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                   "my-type",
+			"name":                   "my-name",
+			"status-history-max-age": "168h",
+		},
+	}, {
+		about:       "Invalid status history max age",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                   "my-type",
+			"name":                   "my-name",
+			"status-history-max-age": "-1h",
+		},
+		err: `invalid status-history-max-age: "-1h" is not a positive duration`,
+	}, {
+		about:       "Invalid charm repository URL",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                 "my-type",
+			"name":                 "my-name",
+			"charm-repository-url": "charmrepository",
+		},
+		err: `invalid charm-repository-url: "charmrepository" is not an absolute URL`,
 	}, {
 		about:       "CA cert & key from path",
 		useDefaults: config.UseDefaults,
@@ -405,6 +483,23 @@ var configTests = []configTest{
 			"name": "my-name",
 			"disable-network-management": true,
 		},
+	}, {
+		about:       "Invalid hook-transcripts flag",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":             "my-type",
+			"name":             "my-name",
+			"hook-transcripts": "invalid",
+		},
+		err: `hook-transcripts: expected bool, got string\("invalid"\)`,
+	}, {
+		about:       "hook-transcripts on",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":             "my-type",
+			"name":             "my-name",
+			"hook-transcripts": true,
+		},
 	}, {
 		about:       "set-numa-control-policy on",
 		useDefaults: config.UseDefaults,
@@ -1186,6 +1281,9 @@ func (test configTest) check(c *gc.C, home *gitjujutesting.FakeHome) {
 	testmode, _ := test.attrs["test-mode"].(bool)
 	c.Assert(cfg.TestMode(), gc.Equals, testmode)
 
+	hookTranscripts, _ := test.attrs["hook-transcripts"].(bool)
+	c.Assert(cfg.HookTranscripts(), gc.Equals, hookTranscripts)
+
 	series, _ := test.attrs["default-series"].(string)
 	if defaultSeries, ok := cfg.DefaultSeries(); ok {
 		c.Assert(defaultSeries, gc.Equals, series)
@@ -1345,6 +1443,26 @@ func (test configTest) check(c *gc.C, home *gitjujutesting.FakeHome) {
 		c.Assert(useLxcCloneAufs, jc.IsFalse)
 	}
 
+	userData, _ := test.attrs["cloudinit-userdata"].(string)
+	c.Assert(cfg.CloudInitUserData(), gc.Equals, userData)
+	ldapConfig, _ := test.attrs["ldap-config"].(string)
+	c.Assert(cfg.LDAPConfig(), gc.Equals, ldapConfig)
+
+	signingKeys, _ := test.attrs["charm-signing-keys"].(string)
+	c.Assert(cfg.CharmSigningKeys(), gc.Equals, signingKeys)
+
+	repositoryURL, _ := test.attrs["charm-repository-url"].(string)
+	c.Assert(cfg.CharmRepositoryURL(), gc.Equals, repositoryURL)
+	anonymousAccess, _ := test.attrs["charm-repository-anonymous-access"].(bool)
+	c.Assert(cfg.CharmRepositoryAnonymousAccess(), gc.Equals, anonymousAccess)
+
+	var maxAge time.Duration
+	if v, ok := test.attrs["status-history-max-age"].(string); ok {
+		maxAge, err = time.ParseDuration(v)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	c.Assert(cfg.StatusHistoryMaxAge(), gc.Equals, maxAge)
+
 	resourceTags, cfgHasResourceTags := cfg.ResourceTags()
 	if _, ok := test.attrs["resource-tags"]; ok {
 		c.Assert(cfgHasResourceTags, jc.IsTrue)
@@ -1927,6 +2045,55 @@ func (s *specializedCharmRepo) WithTestMode() charmrepo.Interface {
 	return s
 }
 
+func (s *ConfigSuite) TestRedactSecrets(c *gc.C) {
+	attrs := map[string]interface{}{
+		"name":        "my-name",
+		"ldap-config": "bind-dn: cn=juju\nbind-password: sekrit\nurl: ldap://ldap.example.com\n",
+	}
+	redacted := config.RedactSecrets(attrs)
+	c.Assert(redacted, jc.DeepEquals, map[string]interface{}{
+		"name":        "my-name",
+		"ldap-config": "bind-dn: cn=juju\nbind-password: not available\nurl: ldap://ldap.example.com\n",
+	})
+	// The given attributes are left as they were.
+	c.Assert(attrs["ldap-config"], gc.Equals, "bind-dn: cn=juju\nbind-password: sekrit\nurl: ldap://ldap.example.com\n")
+}
+
+func (s *ConfigSuite) TestRedactSecret(c *gc.C) {
+	for i, test := range []struct {
+		key      string
+		value    interface{}
+		expected interface{}
+	}{{
+		key:      "name",
+		value:    "bind-password: sekrit\n",
+		expected: "bind-password: sekrit\n",
+	}, {
+		key:      "ldap-config",
+		value:    "url: ldap://ldap.example.com\n",
+		expected: "url: ldap://ldap.example.com\n",
+	}, {
+		key:      "ldap-config",
+		value:    "",
+		expected: "",
+	}, {
+		key:      "ldap-config",
+		value:    nil,
+		expected: nil,
+	}, {
+		key:      "ldap-config",
+		value:    "bind-password: sekrit\n",
+		expected: "bind-password: not available\n",
+	}, {
+		key:      "ldap-config",
+		value:    "- bind-password: sekrit\n",
+		expected: "not available",
+	}} {
+		c.Logf("test %d: %s=%v", i, test.key, test.value)
+		c.Check(config.RedactSecret(test.key, test.value), gc.Equals, test.expected)
+	}
+}
+
 func (s *ConfigSuite) TestLastestLtsSeriesFallback(c *gc.C) {
 	config.ResetCachedLtsSeries()
 	s.PatchValue(config.DistroLtsSeries, func() (string, error) {
@@ -136,6 +136,13 @@ func detectSeriesAndHardwareCharacteristics(host string) (hc instance.HardwareCh
 // stdin and stdout will be used for remote sudo prompts,
 // if the ubuntu user must be created/updated.
 func InitUbuntuUser(host, login, authorizedKeys string, stdin io.Reader, stdout io.Writer) error {
+	return initUbuntuUser(host, login, "", authorizedKeys, stdin, stdout)
+}
+
+// initUbuntuUser is InitUbuntuUser, additionally trying the
+// private key in identityFile, if specified, when logging
+// in to the host as login.
+func initUbuntuUser(host, login, identityFile, authorizedKeys string, stdin io.Reader, stdout io.Writer) error {
 	logger.Infof("initialising %q, user %q", host, login)
 
 	// To avoid unnecessary prompting for the specified login,
@@ -160,6 +167,9 @@ func InitUbuntuUser(host, login, authorizedKeys string, stdin io.Reader, stdout
 	var options ssh.Options
 	options.AllowPasswordAuthentication()
 	options.EnablePTY()
+	if identityFile != "" {
+		options.SetIdentities(identityFile)
+	}
 	cmd = ssh.Command(host, []string{"sudo", "/bin/bash -c " + utils.ShQuote(script)}, &options)
 	var stderr bytes.Buffer
 	cmd.Stdin = stdin
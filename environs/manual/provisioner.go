@@ -59,6 +59,19 @@ type ProvisionMachineArgs struct {
 	// Stderr is required to present machine provisioning progress to the user.
 	Stderr io.Writer
 
+	// IdentityFile optionally holds the path of a private key to try,
+	// along with the default identities, when logging in to the host
+	// to initialise the ubuntu user.
+	IdentityFile string
+
+	// Tags optionally holds tags to record in the machine's
+	// hardware characteristics.
+	Tags []string
+
+	// Zone optionally holds an availability zone to record
+	// in the machine's hardware characteristics.
+	Zone string
+
 	*params.UpdateBehavior
 }
 
@@ -89,7 +102,7 @@ func ProvisionMachine(args ProvisionMachineArgs) (machineId string, err error) {
 	// ubuntu user's authorized_keys.
 	user, hostname := splitUserHost(args.Host)
 	authorizedKeys, err := config.ReadAuthorizedKeys("")
-	if err := InitUbuntuUser(hostname, user, authorizedKeys, args.Stdin, args.Stdout); err != nil {
+	if err := initUbuntuUser(hostname, user, args.IdentityFile, authorizedKeys, args.Stdin, args.Stdout); err != nil {
 		return "", err
 	}
 
@@ -97,6 +110,14 @@ func ProvisionMachine(args ProvisionMachineArgs) (machineId string, err error) {
 	if err != nil {
 		return "", err
 	}
+	if len(args.Tags) > 0 {
+		tags := append([]string(nil), args.Tags...)
+		machineParams.HardwareCharacteristics.Tags = &tags
+	}
+	if args.Zone != "" {
+		zone := args.Zone
+		machineParams.HardwareCharacteristics.AvailabilityZone = &zone
+	}
 
 	// Inform Juju that the machine exists.
 	machineId, err = recordMachineInState(args.Client, *machineParams)
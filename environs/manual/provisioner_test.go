@@ -144,6 +144,29 @@ func (s *provisionerSuite) TestFinishInstancConfig(c *gc.C) {
 	c.Check(icfg.MongoInfo.Addrs, gc.DeepEquals, stateInfo.Addrs)
 }
 
+func (s *provisionerSuite) TestProvisionMachineTagsAndZone(c *gc.C) {
+	defer fakeSSH{
+		Series:         coretesting.FakeDefaultSeries,
+		Arch:           "amd64",
+		InitUbuntuUser: true,
+	}.install(c).Restore()
+
+	args := s.getArgs(c)
+	args.Tags = []string{"rack1", "ssd"}
+	args.Zone = "rack1"
+	machineId, err := manual.ProvisionMachine(args)
+	c.Assert(err, jc.ErrorIsNil)
+
+	m, err := s.State.Machine(machineId)
+	c.Assert(err, jc.ErrorIsNil)
+	hc, err := m.HardwareCharacteristics()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(hc.Tags, gc.NotNil)
+	c.Assert(*hc.Tags, jc.DeepEquals, []string{"rack1", "ssd"})
+	c.Assert(hc.AvailabilityZone, gc.NotNil)
+	c.Assert(*hc.AvailabilityZone, gc.Equals, "rack1")
+}
+
 func (s *provisionerSuite) TestProvisioningScript(c *gc.C) {
 	const series = coretesting.FakeDefaultSeries
 	const arch = "amd64"
@@ -35,6 +35,9 @@ type DeployServiceParams struct {
 	// Networks holds a list of networks to required to start on boot.
 	Networks []string
 	Storage  map[string]storage.Constraints
+	// EndpointBindings maps charm endpoint names to the spaces whose
+	// addresses are used for relations on those endpoints.
+	EndpointBindings map[string]string
 }
 
 // DeployService takes a charm and various parameters and deploys it.
@@ -76,12 +79,13 @@ func DeployService(st *state.State, args DeployServiceParams) (*state.Service, e
 			return nil, fmt.Errorf("cannot deploy with networks: not suppored by the environment")
 		}
 	}
-	service, err := st.AddService(
+	service, err := st.AddServiceWithEndpointBindings(
 		args.ServiceName,
 		args.ServiceOwner,
 		args.Charm,
 		args.Networks,
 		stateStorageConstraints(args.Storage),
+		args.EndpointBindings,
 	)
 	if err != nil {
 		return nil, err
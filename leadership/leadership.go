@@ -41,6 +41,19 @@ func (m *Manager) Leader(sid, uid string) (bool, error) {
 	return tok.Id == uid, nil
 }
 
+// ServiceLeader returns the id of the unit that is currently the leader
+// for the given service ID. It returns an error satisfying
+// errors.IsNotFound if the service has no leader.
+func (m *Manager) ServiceLeader(sid string) (string, error) {
+	tok, err := m.leaseMgr.RetrieveLease(leadershipNamespace(sid))
+	if errors.IsNotFound(err) {
+		return "", errors.NotFoundf("leader of service %q", sid)
+	} else if err != nil {
+		return "", err
+	}
+	return tok.Id, nil
+}
+
 // ClaimLeadership implements the LeadershipManager interface.
 func (m *Manager) ClaimLeadership(sid, uid string, duration time.Duration) error {
 
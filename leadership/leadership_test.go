@@ -107,6 +107,24 @@ func (s *leadershipSuite) leader(c *gc.C, result lease.Token, resultErr error) (
 	return leader, err
 }
 
+func (s *leadershipSuite) TestServiceLeader(c *gc.C) {
+	stub := &leaseStub{
+		RetrieveLeaseFn: func(namespace string) (lease.Token, error) {
+			c.Check(namespace, gc.Equals, leadershipNamespace(StubServiceNm))
+			return lease.Token{Namespace: namespace, Id: StubUnitNm}, nil
+		},
+	}
+	leader, err := NewLeadershipManager(stub).ServiceLeader(StubServiceNm)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(leader, gc.Equals, StubUnitNm)
+}
+
+func (s *leadershipSuite) TestServiceLeaderNone(c *gc.C) {
+	_, err := NewLeadershipManager(&leaseStub{}).ServiceLeader(StubServiceNm)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(err, gc.ErrorMatches, `leader of service "stub-service" not found`)
+}
+
 func (s *leadershipSuite) TestClaimLeadershipTranslation(c *gc.C) {
 
 	numStubCalls := 0
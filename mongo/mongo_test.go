@@ -409,6 +409,16 @@ func (s *MongoSuite) TestRemoveService(c *gc.C) {
 	s.data.CheckCallNames(c, "Stop", "Remove")
 }
 
+func (s *MongoSuite) TestRestartService(c *gc.C) {
+	namespace := "namespace"
+	s.data.SetStatus(mongo.ServiceName(namespace), "running")
+
+	err := mongo.RestartService(namespace)
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.data.CheckCallNames(c, "Stop", "Start")
+}
+
 func (s *MongoSuite) TestQuantalAptAddRepo(c *gc.C) {
 	dir := c.MkDir()
 	// patch manager.RunCommandWithRetry for repository addition:
@@ -94,6 +94,22 @@ func RemoveService(namespace string) error {
 	return nil
 }
 
+// RestartService restarts the mongoDB init service on this machine, so
+// that mongod picks up a new SSL key.
+func RestartService(namespace string) error {
+	svc, err := discoverService(ServiceName(namespace))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := svc.Stop(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := svc.Start(); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
 // ServiceName returns the name of the init service config for mongo using
 // the given namespace.
 func ServiceName(namespace string) string {
@@ -6,6 +6,7 @@ package ec2
 import (
 	"fmt"
 	"net"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -427,6 +428,26 @@ func (*environ) MaintainInstance(args environs.StartInstanceParams) error {
 	return nil
 }
 
+// subnetsByZone returns, for each availability zone of the given
+// subnets, the id of the subnet to start instances in that zone in.
+// Where a zone has several subnets, the first by id is used.
+func subnetsByZone(subnetsToZones map[network.Id][]string) map[string]string {
+	subnetIds := make([]string, 0, len(subnetsToZones))
+	for subnetId := range subnetsToZones {
+		subnetIds = append(subnetIds, string(subnetId))
+	}
+	sort.Strings(subnetIds)
+	zoneSubnets := make(map[string]string)
+	for _, subnetId := range subnetIds {
+		for _, zone := range subnetsToZones[network.Id(subnetId)] {
+			if _, ok := zoneSubnets[zone]; !ok {
+				zoneSubnets[zone] = subnetId
+			}
+		}
+	}
+	return zoneSubnets
+}
+
 // resourceName returns the string to use for a resource's Name tag,
 // to help users identify Juju-managed resources in the AWS console.
 func resourceName(tag names.Tag, envName string) string {
@@ -481,6 +502,23 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (_ *environs.
 		}
 	}
 
+	// If the instance must be started in particular subnets, only
+	// the availability zones those subnets are in can be used.
+	var zoneSubnets map[string]string
+	if len(args.SubnetsToZones) > 0 {
+		zoneSubnets = subnetsByZone(args.SubnetsToZones)
+		var subnetZones []string
+		for _, zone := range availabilityZones {
+			if _, ok := zoneSubnets[zone]; ok {
+				subnetZones = append(subnetZones, zone)
+			}
+		}
+		if len(subnetZones) == 0 {
+			return nil, errors.Errorf("none of the availability zones %v has a subnet in the required spaces", availabilityZones)
+		}
+		availabilityZones = subnetZones
+	}
+
 	if args.InstanceConfig.HasNetworks() {
 		return nil, errors.New("starting instances with networks is not supported yet")
 	}
@@ -532,6 +570,7 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (_ *environs.
 	for _, availZone := range availabilityZones {
 		instResp, err = runInstances(e.ec2(), &ec2.RunInstances{
 			AvailZone:           availZone,
+			SubnetId:            zoneSubnets[availZone],
 			ImageId:             spec.Image.Id,
 			MinCount:            1,
 			MaxCount:            1,
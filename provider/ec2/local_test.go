@@ -581,6 +581,41 @@ func (t *localServerSuite) testStartInstanceAvailZoneOneConstrained(c *gc.C, run
 	c.Check(*hwc.AvailabilityZone, gc.Equals, "az2")
 }
 
+func (t *localServerSuite) TestStartInstanceSubnetsToZones(c *gc.C) {
+	env := t.Prepare(c)
+	err := bootstrap.Bootstrap(envtesting.BootstrapContext(c), env, bootstrap.BootstrapParams{})
+	c.Assert(err, jc.ErrorIsNil)
+
+	mock := mockAvailabilityZoneAllocations{
+		result: []common.AvailabilityZoneInstances{
+			{ZoneName: "az1"}, {ZoneName: "az2"}, {ZoneName: "az3"},
+		},
+	}
+	t.PatchValue(ec2.AvailabilityZoneAllocations, mock.AvailabilityZoneAllocations)
+
+	var azArgs, subnetArgs []string
+	t.PatchValue(ec2.RunInstances, func(e *amzec2.EC2, ri *amzec2.RunInstances) (*amzec2.RunInstancesResp, error) {
+		azArgs = append(azArgs, ri.AvailZone)
+		subnetArgs = append(subnetArgs, ri.SubnetId)
+		return nil, azConstrainedErr
+	})
+	params := environs.StartInstanceParams{
+		SubnetsToZones: map[network.Id][]string{
+			"subnet-3":  {"az3"},
+			"subnet-2b": {"az2"},
+			"subnet-2a": {"az2"},
+		},
+	}
+	_, err = testing.StartInstanceWithParams(env, "1", params, nil)
+	c.Assert(err, gc.ErrorMatches, "cannot run instances: .*")
+	c.Assert(azArgs, gc.DeepEquals, []string{"az2", "az3"})
+	c.Assert(subnetArgs, gc.DeepEquals, []string{"subnet-2a", "subnet-3"})
+
+	params.SubnetsToZones = map[network.Id][]string{"subnet-4": {"az4"}}
+	_, err = testing.StartInstanceWithParams(env, "1", params, nil)
+	c.Assert(err, gc.ErrorMatches, `none of the availability zones \[az1 az2 az3\] has a subnet in the required spaces`)
+}
+
 func (t *localServerSuite) TestAddresses(c *gc.C) {
 	env := t.Prepare(c)
 	err := bootstrap.Bootstrap(envtesting.BootstrapContext(c), env, bootstrap.BootstrapParams{})
@@ -0,0 +1,121 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package resource defines the resources a charm may declare in its
+// metadata: named binary blobs, such as vendor tarballs, that are
+// uploaded by the operator and fetched by the charm's units instead
+// of being downloaded from the internet.
+package resource
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/juju/errors"
+	goyaml "gopkg.in/yaml.v1"
+)
+
+// TypeFile is the type of a resource delivered to units as a single
+// file. It is currently the only supported type.
+const TypeFile = "file"
+
+// Meta describes a resource declared in a charm's metadata.
+type Meta struct {
+	// Name identifies the resource within the charm.
+	Name string
+
+	// Type is the kind of resource; see TypeFile.
+	Type string
+
+	// Filename is the name of the file the resource is written to
+	// on the unit.
+	Filename string
+
+	// Description describes the resource's purpose.
+	Description string
+}
+
+var validName = regexp.MustCompile("^[a-z][a-z0-9]*(-[a-z0-9]+)*$")
+
+// IsValidName returns whether name is a valid resource name.
+func IsValidName(name string) bool {
+	return validName.MatchString(name)
+}
+
+// ParseMeta parses the "resources" section of the given charm
+// metadata.yaml content. Resources are declared as, for example:
+//
+//	resources:
+//	  jdk:
+//	    type: file
+//	    filename: jdk.tar.gz
+//	    description: The Java development kit.
+//
+// The type defaults to "file" and the filename to the resource name.
+func ParseMeta(metadata []byte) (map[string]Meta, error) {
+	var raw struct {
+		Resources map[string]struct {
+			Type        string `yaml:"type"`
+			Filename    string `yaml:"filename"`
+			Description string `yaml:"description"`
+		} `yaml:"resources"`
+	}
+	if err := goyaml.Unmarshal(metadata, &raw); err != nil {
+		return nil, errors.Annotate(err, "cannot parse charm metadata")
+	}
+	result := make(map[string]Meta)
+	for name, r := range raw.Resources {
+		if !IsValidName(name) {
+			return nil, errors.NotValidf("resource name %q", name)
+		}
+		meta := Meta{
+			Name:        name,
+			Type:        r.Type,
+			Filename:    r.Filename,
+			Description: r.Description,
+		}
+		if meta.Type == "" {
+			meta.Type = TypeFile
+		}
+		if meta.Type != TypeFile {
+			return nil, errors.NotValidf("resource %q with type %q", name, meta.Type)
+		}
+		if meta.Filename == "" {
+			meta.Filename = name
+		}
+		if strings.ContainsAny(meta.Filename, `/\`) || meta.Filename == "." || meta.Filename == ".." {
+			return nil, errors.NotValidf("resource %q with filename %q", name, meta.Filename)
+		}
+		result[name] = meta
+	}
+	return result, nil
+}
+
+// ReadArchiveMeta returns the resources declared in the metadata of
+// the charm archive at the given path.
+func ReadArchiveMeta(archivePath string) (map[string]Meta, error) {
+	zipr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot open charm archive")
+	}
+	defer zipr.Close()
+	for _, f := range zipr.File {
+		if path.Clean(f.Name) != "metadata.yaml" {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, errors.Annotate(err, "cannot read charm metadata")
+		}
+		defer r.Close()
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, errors.Annotate(err, "cannot read charm metadata")
+		}
+		return ParseMeta(data)
+	}
+	return nil, errors.NotFoundf("charm metadata")
+}
@@ -0,0 +1,95 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package resource_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/resource"
+)
+
+type resourceSuite struct{}
+
+var _ = gc.Suite(&resourceSuite{})
+
+func (*resourceSuite) TestParseMeta(c *gc.C) {
+	resources, err := resource.ParseMeta([]byte(`
+name: java
+summary: java
+description: java
+resources:
+  jdk:
+    type: file
+    filename: jdk.tar.gz
+    description: The Java development kit.
+  licence-key: {}
+`))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resources, jc.DeepEquals, map[string]resource.Meta{
+		"jdk": {
+			Name:        "jdk",
+			Type:        resource.TypeFile,
+			Filename:    "jdk.tar.gz",
+			Description: "The Java development kit.",
+		},
+		"licence-key": {
+			Name:     "licence-key",
+			Type:     resource.TypeFile,
+			Filename: "licence-key",
+		},
+	})
+}
+
+func (*resourceSuite) TestParseMetaNoResources(c *gc.C) {
+	resources, err := resource.ParseMeta([]byte("name: java\n"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resources, gc.HasLen, 0)
+}
+
+func (*resourceSuite) TestParseMetaInvalid(c *gc.C) {
+	for i, test := range []struct {
+		metadata string
+		err      string
+	}{{
+		metadata: "resources:\n  Bad_Name: {}\n",
+		err:      `resource name "Bad_Name" not valid`,
+	}, {
+		metadata: "resources:\n  jdk: {type: docker}\n",
+		err:      `resource "jdk" with type "docker" not valid`,
+	}, {
+		metadata: "resources:\n  jdk: {filename: ../jdk.tar.gz}\n",
+		err:      `resource "jdk" with filename "../jdk.tar.gz" not valid`,
+	}, {
+		metadata: "resources: [jdk]\n",
+		err:      `cannot parse charm metadata: .*`,
+	}} {
+		c.Logf("test %d", i)
+		_, err := resource.ParseMeta([]byte(test.metadata))
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (*resourceSuite) TestReadArchiveMeta(c *gc.C) {
+	archivePath := filepath.Join(c.MkDir(), "charm.zip")
+	f, err := os.Create(archivePath)
+	c.Assert(err, jc.ErrorIsNil)
+	zipw := zip.NewWriter(f)
+	w, err := zipw.Create("metadata.yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = w.Write([]byte("name: java\nresources:\n  jdk: {filename: jdk.tar.gz}\n"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(zipw.Close(), jc.ErrorIsNil)
+	c.Assert(f.Close(), jc.ErrorIsNil)
+
+	resources, err := resource.ReadArchiveMeta(archivePath)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resources, jc.DeepEquals, map[string]resource.Meta{
+		"jdk": {Name: "jdk", Type: resource.TypeFile, Filename: "jdk.tar.gz"},
+	})
+}
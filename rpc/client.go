@@ -23,6 +23,7 @@ type Call struct {
 type RequestError struct {
 	Message string
 	Code    string
+	Info    map[string]interface{}
 }
 
 func (e *RequestError) Error() string {
@@ -37,6 +38,10 @@ func (e *RequestError) ErrorCode() string {
 	return e.Code
 }
 
+func (e *RequestError) ErrorInfo() map[string]interface{} {
+	return e.Info
+}
+
 func (conn *Conn) send(call *Call) {
 	conn.sending.Lock()
 	defer conn.sending.Unlock()
@@ -111,6 +116,7 @@ func (conn *Conn) handleResponse(hdr *Header) error {
 		call.Error = &RequestError{
 			Message: hdr.Error,
 			Code:    hdr.ErrorCode,
+			Info:    hdr.ErrorInfo,
 		}
 		err = conn.readBody(nil, false)
 		if conn.notifier != nil {
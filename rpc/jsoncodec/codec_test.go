@@ -54,6 +54,15 @@ var readTests = []struct {
 		ErrorCode: "a code",
 	},
 	expectBody: new(map[string]interface{}),
+}, {
+	msg: `{"RequestId": 2, "Error": "an error", "ErrorCode": "a code", "ErrorInfo": {"key": "value"}}`,
+	expectHdr: rpc.Header{
+		RequestId: 2,
+		Error:     "an error",
+		ErrorCode: "a code",
+		ErrorInfo: map[string]interface{}{"key": "value"},
+	},
+	expectBody: new(map[string]interface{}),
 }, {
 	msg: `{"RequestId": 3, "Response": {"X": "result"}}`,
 	expectHdr: rpc.Header{
@@ -237,6 +246,14 @@ var writeTests = []struct {
 		ErrorCode: "a code",
 	},
 	expect: `{"RequestId": 2, "Error": "an error", "ErrorCode": "a code"}`,
+}, {
+	hdr: &rpc.Header{
+		RequestId: 2,
+		Error:     "an error",
+		ErrorCode: "a code",
+		ErrorInfo: map[string]interface{}{"key": "value"},
+	},
+	expect: `{"RequestId": 2, "Error": "an error", "ErrorCode": "a code", "ErrorInfo": {"key": "value"}}`,
 }, {
 	hdr: &rpc.Header{
 		RequestId: 3,
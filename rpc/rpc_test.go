@@ -773,6 +773,29 @@ func (*rpcSuite) TestErrorCode(c *gc.C) {
 	c.Assert(err.(rpc.ErrorCoder).ErrorCode(), gc.Equals, "code")
 }
 
+type infoError struct {
+	codedError
+	info map[string]interface{}
+}
+
+func (e *infoError) ErrorInfo() map[string]interface{} {
+	return e.info
+}
+
+func (*rpcSuite) TestErrorInfo(c *gc.C) {
+	root := &Root{
+		errorInst: &ErrorMethods{&infoError{
+			codedError{"message", "code"},
+			map[string]interface{}{"key": "value"},
+		}},
+	}
+	client, srvDone, _, _ := newRPCClientServer(c, root, nil, false)
+	defer closeClient(c, client, srvDone)
+	err := client.Call(rpc.Request{"ErrorMethods", 0, "", "Call"}, nil, nil)
+	c.Assert(err, gc.ErrorMatches, `request error: message \(code\)`)
+	c.Assert(err.(rpc.ErrorInfoer).ErrorInfo(), jc.DeepEquals, map[string]interface{}{"key": "value"})
+}
+
 func (*rpcSuite) TestTransformErrors(c *gc.C) {
 	root := &Root{
 		errorInst: &ErrorMethods{&codedError{"message", "code"}},
@@ -62,6 +62,10 @@ type Header struct {
 
 	// ErrorCode holds the code of the error, if any.
 	ErrorCode string
+
+	// ErrorInfo holds additional information about the error,
+	// if any.
+	ErrorInfo map[string]interface{}
 }
 
 // Request represents an RPC to be performed, absent its parameters.
@@ -357,6 +361,13 @@ type ErrorCoder interface {
 	ErrorCode() string
 }
 
+// ErrorInfoer represents an error that carries additional
+// information, such as a hint as to when a failed request may
+// be retried, that is sent to the client along with the error.
+type ErrorInfoer interface {
+	ErrorInfo() map[string]interface{}
+}
+
 // MethodFinder represents a type that can be used to lookup a Method and place
 // calls on that method.
 type MethodFinder interface {
@@ -499,6 +510,9 @@ func (conn *Conn) writeErrorResponse(reqHdr *Header, err error, startTime time.T
 	} else {
 		hdr.ErrorCode = ""
 	}
+	if err, ok := err.(ErrorInfoer); ok {
+		hdr.ErrorInfo = err.ErrorInfo()
+	}
 	hdr.Error = err.Error()
 	if conn.notifier != nil {
 		conn.notifier.ServerReply(reqHdr.Request, hdr, struct{}{}, time.Since(startTime))
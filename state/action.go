@@ -99,6 +99,10 @@ type actionDoc struct {
 
 	// Results are the structured results from the action.
 	Results map[string]interface{} `bson:"results"`
+
+	// Batch holds the id of the action batch that enqueued the
+	// action, if any.
+	Batch string `bson:"batch,omitempty"`
 }
 
 // Action represents an instruction to do some "action" and is expected
@@ -157,6 +161,12 @@ func (a *Action) Results() (map[string]interface{}, string) {
 	return a.doc.Results, a.doc.Message
 }
 
+// Batch returns the id of the action batch that enqueued the action, or
+// the empty string if it was enqueued directly.
+func (a *Action) Batch() string {
+	return a.doc.Batch
+}
+
 // ValidateTag should be called before calls to Tag() or ActionTag(). It verifies
 // that the Action can produce a valid Tag.
 func (a *Action) ValidateTag() bool {
@@ -236,6 +246,13 @@ func (a *Action) removeAndLog(finalStatus ActionStatus, results map[string]inter
 	if err != nil {
 		return nil, err
 	}
+	if a.doc.Batch != "" {
+		// The action has finished whether or not its batch can be
+		// advanced, so failure to do so is not reported to the caller.
+		if err := a.st.advanceActionBatch(a.doc.Batch); err != nil {
+			actionLogger.Errorf("cannot advance action batch %s: %v", a.doc.Batch, err)
+		}
+	}
 	return a.st.Action(a.Id())
 }
 
@@ -340,27 +357,11 @@ func (st *State) EnqueueAction(receiver names.Tag, actionName string, payload ma
 		return nil, errors.Trace(err)
 	}
 
-	doc, ndoc, err := newActionDoc(st, receiver, actionName, payload)
+	doc, ops, err := st.enqueueActionOps(receiver, actionName, payload)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 
-	ops := []txn.Op{{
-		C:      receiverCollectionName,
-		Id:     receiverId,
-		Assert: notDeadDoc,
-	}, {
-		C:      actionsC,
-		Id:     doc.DocId,
-		Assert: txn.DocMissing,
-		Insert: doc,
-	}, {
-		C:      actionNotificationsC,
-		Id:     ndoc.DocId,
-		Assert: txn.DocMissing,
-		Insert: ndoc,
-	}}
-
 	buildTxn := func(attempt int) ([]txn.Op, error) {
 		if notDead, err := isNotDead(st, receiverCollectionName, receiverId); err != nil {
 			return nil, err
@@ -377,6 +378,35 @@ func (st *State) EnqueueAction(receiver names.Tag, actionName string, payload ma
 	return nil, err
 }
 
+// enqueueActionOps returns the document of a new action with the given
+// name and payload, and the operations needed to enqueue it on the
+// receiver, which must not be dead.
+func (st *State) enqueueActionOps(receiver names.Tag, actionName string, payload map[string]interface{}) (actionDoc, []txn.Op, error) {
+	receiverCollectionName, receiverId, err := st.tagToCollectionAndId(receiver)
+	if err != nil {
+		return actionDoc{}, nil, errors.Trace(err)
+	}
+	doc, ndoc, err := newActionDoc(st, receiver, actionName, payload)
+	if err != nil {
+		return actionDoc{}, nil, errors.Trace(err)
+	}
+	return doc, []txn.Op{{
+		C:      receiverCollectionName,
+		Id:     receiverId,
+		Assert: notDeadDoc,
+	}, {
+		C:      actionsC,
+		Id:     doc.DocId,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}, {
+		C:      actionNotificationsC,
+		Id:     ndoc.DocId,
+		Assert: txn.DocMissing,
+		Insert: ndoc,
+	}}, nil
+}
+
 // matchingActions finds actions that match ActionReceiver.
 func (st *State) matchingActions(ar ActionReceiver) ([]*Action, error) {
 	return st.matchingActionsByReceiverId(ar.Tag().Id())
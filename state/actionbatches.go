@@ -0,0 +1,490 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jujutxn "github.com/juju/txn"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// ActionBatchParams holds the details of an action to be run across
+// the units of a service.
+type ActionBatchParams struct {
+	// Service is the name of the service whose units run the action.
+	Service string
+
+	// Units holds the names of the units on which the action is run,
+	// in order. If empty, the action is run on every alive unit of
+	// the service, in order of unit number.
+	Units []string
+
+	// Name is the name of the action to run.
+	Name string
+
+	// Parameters holds the parameters the action is run with.
+	Parameters map[string]interface{}
+
+	// MaxConcurrent, if non-zero, limits the number of the batch's
+	// actions that may be enqueued or running at once.
+	MaxConcurrent int
+
+	// LeaderOnly records that the action is run on the service
+	// leader alone. It is informational; Units must already hold
+	// just the leader.
+	LeaderOnly bool
+}
+
+// actionBatchDoc records an action run across the units of a service.
+type actionBatchDoc struct {
+	DocID         string                 `bson:"_id"`
+	EnvUUID       string                 `bson:"env-uuid"`
+	Seq           int                    `bson:"seq"`
+	Service       string                 `bson:"service"`
+	Units         []string               `bson:"units"`
+	Name          string                 `bson:"name"`
+	Parameters    map[string]interface{} `bson:"parameters"`
+	MaxConcurrent int                    `bson:"maxconcurrent,omitempty"`
+	LeaderOnly    bool                   `bson:"leaderonly,omitempty"`
+
+	// Actions holds the ids of the actions enqueued so far, one for
+	// each of the leading units in Units. Its length is used to
+	// ensure that each unit's action is enqueued only once.
+	Actions []string `bson:"actions"`
+
+	Status    ActionStatus `bson:"status"`
+	Message   string       `bson:"message,omitempty"`
+	Enqueued  time.Time    `bson:"enqueued"`
+	Completed time.Time    `bson:"completed"`
+}
+
+// ActionBatch represents an action run across the units of a service as
+// a single operation. The batch's actions are enqueued in turn, no more
+// than MaxConcurrent at once, and no more are enqueued once one of them
+// fails or is cancelled.
+//
+// The status of a batch is ActionRunning until it has finished. It is
+// then ActionCompleted if every action completed, or ActionFailed if it
+// was stopped early.
+type ActionBatch struct {
+	st  *State
+	doc actionBatchDoc
+}
+
+func newActionBatch(st *State, doc *actionBatchDoc) *ActionBatch {
+	return &ActionBatch{st: st, doc: *doc}
+}
+
+// actionBatchGlobalKey returns the global database key for the action
+// batch with the given id.
+func actionBatchGlobalKey(id string) string {
+	return "ab#" + id
+}
+
+// Id returns the id of the batch, which is unique within the
+// environment.
+func (b *ActionBatch) Id() string {
+	return strconv.Itoa(b.doc.Seq)
+}
+
+// Service returns the name of the service whose units run the action.
+func (b *ActionBatch) Service() string {
+	return b.doc.Service
+}
+
+// Units returns the names of the units on which the action is run, in
+// the order in which it is enqueued on them.
+func (b *ActionBatch) Units() []string {
+	return b.doc.Units
+}
+
+// Name returns the name of the action that is run.
+func (b *ActionBatch) Name() string {
+	return b.doc.Name
+}
+
+// Parameters returns the parameters the action is run with.
+func (b *ActionBatch) Parameters() map[string]interface{} {
+	return b.doc.Parameters
+}
+
+// MaxConcurrent returns the limit on the number of the batch's actions
+// that may be enqueued or running at once, or zero if there is none.
+func (b *ActionBatch) MaxConcurrent() int {
+	return b.doc.MaxConcurrent
+}
+
+// LeaderOnly returns whether the action is run on the service leader
+// alone.
+func (b *ActionBatch) LeaderOnly() bool {
+	return b.doc.LeaderOnly
+}
+
+// Status returns the aggregate status of the batch.
+func (b *ActionBatch) Status() ActionStatus {
+	return b.doc.Status
+}
+
+// Message returns the reason the batch was stopped, if it failed.
+func (b *ActionBatch) Message() string {
+	return b.doc.Message
+}
+
+// Enqueued returns the time the batch was added.
+func (b *ActionBatch) Enqueued() time.Time {
+	return b.doc.Enqueued.UTC()
+}
+
+// Completed returns the time the batch finished, or the zero time if it
+// is still running.
+func (b *ActionBatch) Completed() time.Time {
+	if b.doc.Completed.IsZero() {
+		return time.Time{}
+	}
+	return b.doc.Completed.UTC()
+}
+
+// Actions returns the actions enqueued by the batch so far, in the same
+// order as the units returned by Units.
+func (b *ActionBatch) Actions() ([]*Action, error) {
+	actions, err := b.st.batchActions(b.Id())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	result := make([]*Action, 0, len(b.doc.Actions))
+	for _, id := range b.doc.Actions {
+		action, ok := actions[id]
+		if !ok {
+			return nil, errors.NotFoundf("action %q", id)
+		}
+		result = append(result, action)
+	}
+	return result, nil
+}
+
+// Refresh refreshes the contents of the batch from the underlying
+// state.
+func (b *ActionBatch) Refresh() error {
+	batches, closer := b.st.getCollection(actionBatchesC)
+	defer closer()
+
+	var doc actionBatchDoc
+	err := batches.FindId(b.doc.DocID).One(&doc)
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("action batch %s", b.Id())
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot refresh action batch %s", b.Id())
+	}
+	b.doc = doc
+	return nil
+}
+
+// AddActionBatch adds a batch that runs the described action across the
+// units of a service, and enqueues the first of its actions.
+func (st *State) AddActionBatch(p ActionBatchParams) (_ *ActionBatch, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add action batch")
+	if p.Name == "" {
+		return nil, errors.NotValidf("missing action name")
+	}
+	if p.MaxConcurrent < 0 {
+		return nil, errors.NotValidf("max concurrent %d", p.MaxConcurrent)
+	}
+	if p.Parameters == nil {
+		p.Parameters = map[string]interface{}{}
+	}
+	service, err := st.Service(p.Service)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := st.validateReceiverAction(service.Tag(), p.Name, p.Parameters); err != nil {
+		return nil, errors.Trace(err)
+	}
+	units := p.Units
+	if len(units) == 0 {
+		if units, err = aliveUnitNames(service); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(units) == 0 {
+			return nil, errors.Errorf("service %q has no units", p.Service)
+		}
+	}
+	for _, unit := range units {
+		if serviceName, err := names.UnitService(unit); err != nil {
+			return nil, errors.Trace(err)
+		} else if serviceName != p.Service {
+			return nil, errors.NotValidf("unit %q of service %q", unit, serviceName)
+		}
+	}
+	seq, err := st.sequence("actionbatch")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	id := strconv.Itoa(seq)
+	doc := &actionBatchDoc{
+		DocID:         st.docID(actionBatchGlobalKey(id)),
+		EnvUUID:       st.EnvironUUID(),
+		Seq:           seq,
+		Service:       p.Service,
+		Units:         units,
+		Name:          p.Name,
+		Parameters:    p.Parameters,
+		MaxConcurrent: p.MaxConcurrent,
+		LeaderOnly:    p.LeaderOnly,
+		Actions:       []string{},
+		Status:        ActionRunning,
+		Enqueued:      nowToTheSecond(),
+	}
+	ops := []txn.Op{{
+		C:      actionBatchesC,
+		Id:     doc.DocID,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	if err := st.runTransaction(ops); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := st.advanceActionBatch(id); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return st.ActionBatch(id)
+}
+
+// aliveUnitNames returns the names of the alive units of the service,
+// in order of unit number.
+func aliveUnitNames(service *Service) ([]string, error) {
+	units, err := service.AllUnits()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var result []string
+	for _, unit := range units {
+		if unit.Life() == Alive {
+			result = append(result, unit.Name())
+		}
+	}
+	sort.Sort(unitNamesByNumber(result))
+	return result, nil
+}
+
+type unitNamesByNumber []string
+
+func (u unitNamesByNumber) Len() int      { return len(u) }
+func (u unitNamesByNumber) Swap(i, j int) { u[i], u[j] = u[j], u[i] }
+func (u unitNamesByNumber) Less(i, j int) bool {
+	return unitNumber(u[i]) < unitNumber(u[j])
+}
+
+func unitNumber(name string) int {
+	n, _ := strconv.Atoi(name[strings.LastIndex(name, "/")+1:])
+	return n
+}
+
+// ActionBatch returns the action batch with the given id.
+func (st *State) ActionBatch(id string) (*ActionBatch, error) {
+	batches, closer := st.getCollection(actionBatchesC)
+	defer closer()
+
+	var doc actionBatchDoc
+	err := batches.FindId(st.docID(actionBatchGlobalKey(id))).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("action batch %s", id)
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot get action batch %s", id)
+	}
+	return newActionBatch(st, &doc), nil
+}
+
+// batchActions returns the actions enqueued by the batch with the given
+// id, keyed by action id.
+func (st *State) batchActions(id string) (map[string]*Action, error) {
+	actions, closer := st.getCollection(actionsC)
+	defer closer()
+
+	result := make(map[string]*Action)
+	var doc actionDoc
+	iter := actions.Find(bson.D{{"batch", id}}).Iter()
+	for iter.Next(&doc) {
+		action := newAction(st, doc)
+		result[action.Id()] = action
+	}
+	if err := iter.Close(); err != nil {
+		return nil, errors.Annotatef(err, "cannot get actions of action batch %s", id)
+	}
+	return result, nil
+}
+
+// advanceActionBatch brings the batch with the given id up to date with
+// its actions: it enqueues as many further actions as the batch allows,
+// and records the batch as finished once it has nothing left to do.
+func (st *State) advanceActionBatch(id string) error {
+	batch, err := st.ActionBatch(id)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := batch.Refresh(); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if batch.doc.Status != ActionRunning {
+			return nil, jujutxn.ErrNoOperations
+		}
+		return batch.advanceOps()
+	}
+	if err := st.run(buildTxn); err != nil && err != jujutxn.ErrNoOperations {
+		return errors.Annotatef(err, "cannot advance action batch %s", id)
+	}
+	return nil
+}
+
+// advanceOps returns the operations that enqueue the batch's next
+// actions, or that record it as finished.
+func (b *ActionBatch) advanceOps() ([]txn.Op, error) {
+	actions, err := b.Actions()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	inFlight := 0
+	var failure string
+	for i, action := range actions {
+		switch action.Status() {
+		case ActionPending, ActionRunning:
+			// An action on a unit that is dead or removed will
+			// never finish, so the batch stops rather than wait.
+			unit, err := b.st.Unit(b.doc.Units[i])
+			if errors.IsNotFound(err) {
+				if failure == "" {
+					failure = fmt.Sprintf("action %s on unit %s not finished: unit removed", action.Id(), b.doc.Units[i])
+				}
+				continue
+			} else if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if unit.Life() == Dead {
+				if failure == "" {
+					failure = fmt.Sprintf("action %s on unit %s not finished: unit is dead", action.Id(), b.doc.Units[i])
+				}
+				continue
+			}
+			inFlight++
+		case ActionFailed, ActionCancelled:
+			if failure == "" {
+				failure = fmt.Sprintf("action %s on unit %s %s", action.Id(), b.doc.Units[i], action.Status())
+			}
+		}
+	}
+	assert := bson.D{
+		{"status", ActionRunning},
+		{"actions", bson.D{{"$size", len(b.doc.Actions)}}},
+	}
+	if failure != "" {
+		return b.finishOps(assert, ActionFailed, failure), nil
+	}
+	remaining := b.doc.Units[len(b.doc.Actions):]
+	if len(remaining) == 0 {
+		if inFlight > 0 {
+			return nil, jujutxn.ErrNoOperations
+		}
+		return b.finishOps(assert, ActionCompleted, ""), nil
+	}
+	next := remaining
+	if b.doc.MaxConcurrent > 0 {
+		free := b.doc.MaxConcurrent - inFlight
+		if free <= 0 {
+			return nil, jujutxn.ErrNoOperations
+		}
+		if free < len(next) {
+			next = next[:free]
+		}
+	}
+	var ops []txn.Op
+	ids := append([]string(nil), b.doc.Actions...)
+	for _, unitName := range next {
+		unitOps, actionId, err := b.enqueueOps(unitName)
+		if err != nil {
+			return b.finishOps(assert, ActionFailed, err.Error()), nil
+		}
+		ops = append(ops, unitOps...)
+		ids = append(ids, actionId)
+	}
+	return append(ops, txn.Op{
+		C:      actionBatchesC,
+		Id:     b.doc.DocID,
+		Assert: assert,
+		Update: bson.D{{"$set", bson.D{{"actions", ids}}}},
+	}), nil
+}
+
+// finishOps returns the operations that record the batch as finished
+// with the given status.
+func (b *ActionBatch) finishOps(assert bson.D, status ActionStatus, message string) []txn.Op {
+	return []txn.Op{{
+		C:      actionBatchesC,
+		Id:     b.doc.DocID,
+		Assert: assert,
+		Update: bson.D{{"$set", bson.D{
+			{"status", status},
+			{"message", message},
+			{"completed", nowToTheSecond()},
+		}}},
+	}}
+}
+
+// enqueueOps returns the operations that enqueue the batch's action on
+// the named unit, and the id of the action.
+func (b *ActionBatch) enqueueOps(unitName string) ([]txn.Op, string, error) {
+	unit, err := b.st.Unit(unitName)
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	if unit.Life() == Dead {
+		return nil, "", errors.Errorf("unit %q is dead", unitName)
+	}
+	specs, err := unit.ActionSpecs()
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	spec, ok := specs[b.doc.Name]
+	if !ok {
+		return nil, "", errors.Errorf("action %q not defined on unit %q", b.doc.Name, unitName)
+	}
+	parameters := copyParameters(b.doc.Parameters)
+	if err := spec.ValidateParams(parameters); err != nil {
+		return nil, "", errors.Annotatef(err, "unit %q", unitName)
+	}
+	if parameters, err = spec.InsertDefaults(parameters); err != nil {
+		return nil, "", errors.Annotatef(err, "unit %q", unitName)
+	}
+	doc, ndoc, err := newActionDoc(b.st, unit.Tag(), b.doc.Name, parameters)
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	doc.Batch = b.Id()
+	ops := []txn.Op{{
+		C:      unitsC,
+		Id:     unit.doc.DocID,
+		Assert: notDeadDoc,
+	}, {
+		C:      actionsC,
+		Id:     doc.DocId,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}, {
+		C:      actionNotificationsC,
+		Id:     ndoc.DocId,
+		Assert: txn.DocMissing,
+		Insert: ndoc,
+	}}
+	return ops, b.st.localID(doc.DocId), nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/state"
+)
+
+type ActionBatchesSuite struct {
+	ConnSuite
+	service *state.Service
+	units   []*state.Unit
+}
+
+var _ = gc.Suite(&ActionBatchesSuite{})
+
+func (s *ActionBatchesSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.service = s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	curl, _ := s.service.CharmURL()
+	s.units = nil
+	for i := 0; i < 3; i++ {
+		unit, err := s.service.AddUnit()
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(unit.SetCharmURL(curl), jc.ErrorIsNil)
+		s.units = append(s.units, unit)
+	}
+}
+
+func (s *ActionBatchesSuite) addBatch(c *gc.C, maxConcurrent int) *state.ActionBatch {
+	batch, err := s.State.AddActionBatch(state.ActionBatchParams{
+		Service:       "dummy",
+		Name:          "snapshot",
+		Parameters:    map[string]interface{}{"outfile": "nightly.bz2"},
+		MaxConcurrent: maxConcurrent,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	return batch
+}
+
+func (s *ActionBatchesSuite) assertActions(c *gc.C, batch *state.ActionBatch, statuses ...state.ActionStatus) []*state.Action {
+	c.Assert(batch.Refresh(), jc.ErrorIsNil)
+	actions, err := batch.Actions()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(actions, gc.HasLen, len(statuses))
+	for i, action := range actions {
+		c.Check(action.Receiver(), gc.Equals, s.units[i].Name())
+		c.Check(action.Status(), gc.Equals, statuses[i])
+		c.Check(action.Batch(), gc.Equals, batch.Id())
+	}
+	return actions
+}
+
+func (s *ActionBatchesSuite) TestAddActionBatch(c *gc.C) {
+	batch := s.addBatch(c, 0)
+	c.Assert(batch.Service(), gc.Equals, "dummy")
+	c.Assert(batch.Units(), jc.DeepEquals, []string{"dummy/0", "dummy/1", "dummy/2"})
+	c.Assert(batch.Name(), gc.Equals, "snapshot")
+	c.Assert(batch.Status(), gc.Equals, state.ActionRunning)
+	c.Assert(batch.Completed().IsZero(), jc.IsTrue)
+
+	actions := s.assertActions(c, batch, state.ActionPending, state.ActionPending, state.ActionPending)
+	c.Assert(actions[0].Parameters(), jc.DeepEquals, map[string]interface{}{"outfile": "nightly.bz2"})
+
+	fetched, err := s.State.ActionBatch(batch.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fetched.Units(), jc.DeepEquals, batch.Units())
+}
+
+func (s *ActionBatchesSuite) TestAddActionBatchUnits(c *gc.C) {
+	batch, err := s.State.AddActionBatch(state.ActionBatchParams{
+		Service:    "dummy",
+		Units:      []string{"dummy/1"},
+		Name:       "snapshot",
+		LeaderOnly: true,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(batch.Units(), jc.DeepEquals, []string{"dummy/1"})
+	c.Assert(batch.LeaderOnly(), jc.IsTrue)
+	actions, err := batch.Actions()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(actions, gc.HasLen, 1)
+	c.Assert(actions[0].Receiver(), gc.Equals, "dummy/1")
+	c.Assert(actions[0].Parameters(), jc.DeepEquals, map[string]interface{}{"outfile": "foo.bz2"})
+}
+
+func (s *ActionBatchesSuite) TestAddActionBatchInvalid(c *gc.C) {
+	for i, test := range []struct {
+		params state.ActionBatchParams
+		err    string
+	}{{
+		params: state.ActionBatchParams{Service: "dummy"},
+		err:    "cannot add action batch: missing action name not valid",
+	}, {
+		params: state.ActionBatchParams{Service: "dummy", Name: "snapshot", MaxConcurrent: -1},
+		err:    "cannot add action batch: max concurrent -1 not valid",
+	}, {
+		params: state.ActionBatchParams{Service: "mysql", Name: "snapshot"},
+		err:    `cannot add action batch: service "mysql" not found`,
+	}, {
+		params: state.ActionBatchParams{Service: "dummy", Name: "backup"},
+		err:    `cannot add action batch: action "backup" not defined for "dummy"`,
+	}, {
+		params: state.ActionBatchParams{Service: "dummy", Name: "snapshot", Units: []string{"mysql/0"}},
+		err:    `cannot add action batch: unit "mysql/0" of service "mysql" not valid`,
+	}} {
+		c.Logf("test %d", i)
+		_, err := s.State.AddActionBatch(test.params)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *ActionBatchesSuite) TestMaxConcurrent(c *gc.C) {
+	batch := s.addBatch(c, 2)
+	actions := s.assertActions(c, batch, state.ActionPending, state.ActionPending)
+
+	_, err := actions[0].Begin()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertActions(c, batch, state.ActionRunning, state.ActionPending)
+
+	_, err = actions[0].Finish(state.ActionResults{Status: state.ActionCompleted})
+	c.Assert(err, jc.ErrorIsNil)
+	actions = s.assertActions(c, batch, state.ActionCompleted, state.ActionPending, state.ActionPending)
+	c.Assert(batch.Status(), gc.Equals, state.ActionRunning)
+
+	for _, action := range actions[1:] {
+		_, err = action.Finish(state.ActionResults{Status: state.ActionCompleted})
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	s.assertActions(c, batch, state.ActionCompleted, state.ActionCompleted, state.ActionCompleted)
+	c.Assert(batch.Status(), gc.Equals, state.ActionCompleted)
+	c.Assert(batch.Completed().IsZero(), jc.IsFalse)
+}
+
+func (s *ActionBatchesSuite) TestStopOnFailure(c *gc.C) {
+	batch := s.addBatch(c, 1)
+	actions := s.assertActions(c, batch, state.ActionPending)
+
+	_, err := actions[0].Finish(state.ActionResults{Status: state.ActionFailed, Message: "disk full"})
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertActions(c, batch, state.ActionFailed)
+	c.Assert(batch.Status(), gc.Equals, state.ActionFailed)
+	c.Assert(batch.Message(), gc.Equals, "action "+actions[0].Id()+" on unit dummy/0 failed")
+}
+
+func (s *ActionBatchesSuite) TestStopOnCancel(c *gc.C) {
+	batch := s.addBatch(c, 1)
+	actions := s.assertActions(c, batch, state.ActionPending)
+
+	_, err := s.units[0].CancelAction(actions[0])
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertActions(c, batch, state.ActionCancelled)
+	c.Assert(batch.Status(), gc.Equals, state.ActionFailed)
+}
+
+func (s *ActionBatchesSuite) TestStopOnDeadUnit(c *gc.C) {
+	batch := s.addBatch(c, 1)
+	actions := s.assertActions(c, batch, state.ActionPending)
+	c.Assert(s.units[1].EnsureDead(), jc.ErrorIsNil)
+
+	_, err := actions[0].Finish(state.ActionResults{Status: state.ActionCompleted})
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertActions(c, batch, state.ActionCompleted)
+	c.Assert(batch.Status(), gc.Equals, state.ActionFailed)
+	c.Assert(batch.Message(), gc.Equals, `unit "dummy/1" is dead`)
+}
+
+func (s *ActionBatchesSuite) TestStopOnRemovedUnit(c *gc.C) {
+	// The unit has already finished an action of its own, which
+	// must not stop the batch's action from being cancelled.
+	own, err := s.units[0].AddAction("snapshot", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = own.Finish(state.ActionResults{Status: state.ActionCompleted})
+	c.Assert(err, jc.ErrorIsNil)
+
+	batch := s.addBatch(c, 1)
+	actions := s.assertActions(c, batch, state.ActionPending)
+	c.Assert(s.units[0].EnsureDead(), jc.ErrorIsNil)
+	c.Assert(s.units[0].Remove(), jc.ErrorIsNil)
+	c.Assert(s.State.Cleanup(), jc.ErrorIsNil)
+
+	action, err := s.State.Action(actions[0].Id())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(action.Status(), gc.Equals, state.ActionCancelled)
+	c.Assert(batch.Refresh(), jc.ErrorIsNil)
+	c.Assert(batch.Status(), gc.Equals, state.ActionFailed)
+	c.Assert(batch.Message(), gc.Equals, "action "+actions[0].Id()+" on unit dummy/0 cancelled")
+}
+
+func (s *ActionBatchesSuite) TestStopOnActionOfRemovedUnit(c *gc.C) {
+	batch := s.addBatch(c, 2)
+	actions := s.assertActions(c, batch, state.ActionPending, state.ActionPending)
+	c.Assert(s.units[1].EnsureDead(), jc.ErrorIsNil)
+	c.Assert(s.units[1].Remove(), jc.ErrorIsNil)
+
+	// The batch stops when next advanced, without waiting for the
+	// removed unit's action to be cancelled.
+	_, err := actions[0].Finish(state.ActionResults{Status: state.ActionCompleted})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(batch.Refresh(), jc.ErrorIsNil)
+	c.Assert(batch.Status(), gc.Equals, state.ActionFailed)
+	c.Assert(batch.Message(), gc.Equals, "action "+actions[1].Id()+" on unit dummy/1 not finished: unit removed")
+}
+
+func (s *ActionBatchesSuite) TestActionBatchNotFound(c *gc.C) {
+	_, err := s.State.ActionBatch("42")
+	c.Assert(err, gc.ErrorMatches, "action batch 42 not found")
+}
@@ -0,0 +1,575 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jujutxn "github.com/juju/txn"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/utils/cron"
+)
+
+// MinActionScheduleInterval is the shortest interval at which an action
+// schedule may run.
+const MinActionScheduleInterval = time.Minute
+
+// MaxActionScheduleRuns is the number of runs recorded for each action
+// schedule. When a schedule runs, the records of its oldest runs are
+// removed so that no more than MaxActionScheduleRuns are kept.
+const MaxActionScheduleRuns = 20
+
+// ActionScheduleParams holds the details of an action schedule to be
+// added to the environment.
+type ActionScheduleParams struct {
+	// Receivers holds the tags of the units on which the action will
+	// be run. A service tag stands for every unit of the service at
+	// the time the schedule runs.
+	Receivers []names.Tag
+
+	// Name is the name of the action to run.
+	Name string
+
+	// Parameters holds the parameters the action is run with.
+	Parameters map[string]interface{}
+
+	// Cron, if set, holds a cron expression describing when the
+	// action is run, in UTC. Exactly one of Cron and Interval must
+	// be set.
+	Cron string
+
+	// Interval, if set, holds the period between runs of the action.
+	Interval time.Duration
+}
+
+// actionScheduleDoc records a schedule on which an action is enqueued.
+type actionScheduleDoc struct {
+	DocID      string                 `bson:"_id"`
+	EnvUUID    string                 `bson:"env-uuid"`
+	Seq        int                    `bson:"seq"`
+	Receivers  []string               `bson:"receivers"`
+	Name       string                 `bson:"name"`
+	Parameters map[string]interface{} `bson:"parameters"`
+	Cron       string                 `bson:"cron,omitempty"`
+	Interval   int64                  `bson:"interval,omitempty"`
+	Created    time.Time              `bson:"created"`
+	NextRun    time.Time              `bson:"nextrun"`
+	LastRun    time.Time              `bson:"lastrun"`
+
+	// Runs counts the times the schedule has run. It is used to
+	// ensure that each scheduled run happens only once.
+	Runs int `bson:"runs"`
+}
+
+// actionScheduleRunDoc records the actions enqueued by a single run of
+// an action schedule.
+type actionScheduleRunDoc struct {
+	DocID      string    `bson:"_id"`
+	EnvUUID    string    `bson:"env-uuid"`
+	ScheduleId string    `bson:"scheduleid"`
+	Run        int       `bson:"run"`
+	Time       time.Time `bson:"time"`
+	Actions    []string  `bson:"actions,omitempty"`
+	Errors     []string  `bson:"errors,omitempty"`
+}
+
+// ActionScheduleRun records the actions enqueued by a single run of an
+// action schedule.
+type ActionScheduleRun struct {
+	// ScheduleId identifies the schedule that ran.
+	ScheduleId string
+
+	// Time is the time at which the schedule ran.
+	Time time.Time
+
+	// Actions holds the tags of the actions that were enqueued.
+	Actions []names.ActionTag
+
+	// Errors holds a description of each receiver on which the
+	// action could not be enqueued.
+	Errors []string
+}
+
+func (doc actionScheduleRunDoc) run() ActionScheduleRun {
+	actions := make([]names.ActionTag, len(doc.Actions))
+	for i, id := range doc.Actions {
+		actions[i] = names.NewActionTag(id)
+	}
+	return ActionScheduleRun{
+		ScheduleId: doc.ScheduleId,
+		Time:       doc.Time.UTC(),
+		Actions:    actions,
+		Errors:     doc.Errors,
+	}
+}
+
+// ActionSchedule represents a schedule on which an action is enqueued
+// on a set of units.
+type ActionSchedule struct {
+	st  *State
+	doc actionScheduleDoc
+}
+
+func newActionSchedule(st *State, doc *actionScheduleDoc) *ActionSchedule {
+	return &ActionSchedule{st: st, doc: *doc}
+}
+
+// actionScheduleGlobalKey returns the global database key for the
+// action schedule with the given id.
+func actionScheduleGlobalKey(id string) string {
+	return "as#" + id
+}
+
+// actionScheduleRunGlobalKey returns the global database key for the
+// given run of the action schedule with the given id.
+func actionScheduleRunGlobalKey(id string, run int) string {
+	return fmt.Sprintf("%s#run#%d", actionScheduleGlobalKey(id), run)
+}
+
+// Id returns the id of the schedule, which is unique within the
+// environment.
+func (s *ActionSchedule) Id() string {
+	return strconv.Itoa(s.doc.Seq)
+}
+
+// Receivers returns the tags of the units and services on which the
+// action is run.
+func (s *ActionSchedule) Receivers() []names.Tag {
+	tags := make([]names.Tag, 0, len(s.doc.Receivers))
+	for _, receiver := range s.doc.Receivers {
+		tag, err := names.ParseTag(receiver)
+		if err != nil {
+			logger.Warningf("action schedule %s has invalid receiver %q", s.Id(), receiver)
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// Name returns the name of the action that is run.
+func (s *ActionSchedule) Name() string {
+	return s.doc.Name
+}
+
+// Parameters returns the parameters the action is run with.
+func (s *ActionSchedule) Parameters() map[string]interface{} {
+	return s.doc.Parameters
+}
+
+// Cron returns the cron expression on which the schedule runs, if any.
+func (s *ActionSchedule) Cron() string {
+	return s.doc.Cron
+}
+
+// Interval returns the interval at which the schedule runs, if it has
+// no cron expression.
+func (s *ActionSchedule) Interval() time.Duration {
+	return time.Duration(s.doc.Interval)
+}
+
+// Created returns the time at which the schedule was added.
+func (s *ActionSchedule) Created() time.Time {
+	return s.doc.Created.UTC()
+}
+
+// NextRun returns the time at which the schedule is next due to run.
+func (s *ActionSchedule) NextRun() time.Time {
+	return s.doc.NextRun.UTC()
+}
+
+// LastRun returns the time at which the schedule last ran, or the
+// zero time if it has never run.
+func (s *ActionSchedule) LastRun() time.Time {
+	if s.doc.LastRun.IsZero() {
+		return time.Time{}
+	}
+	return s.doc.LastRun.UTC()
+}
+
+// Refresh refreshes the contents of the schedule from the underlying
+// state. It returns an error that satisfies errors.IsNotFound if the
+// schedule has been removed.
+func (s *ActionSchedule) Refresh() error {
+	schedules, closer := s.st.getCollection(actionSchedulesC)
+	defer closer()
+
+	var doc actionScheduleDoc
+	err := schedules.FindId(s.doc.DocID).One(&doc)
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("action schedule %s", s.Id())
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot refresh action schedule %s", s.Id())
+	}
+	s.doc = doc
+	return nil
+}
+
+// nextRunAfter returns the first time after t at which the schedule
+// should run.
+func nextRunAfter(doc *actionScheduleDoc, t time.Time) (time.Time, error) {
+	if doc.Cron != "" {
+		schedule, err := cron.Parse(doc.Cron)
+		if err != nil {
+			return time.Time{}, errors.Trace(err)
+		}
+		next := schedule.Next(t.UTC())
+		if next.IsZero() {
+			return time.Time{}, errors.NotValidf("cron expression %q that never fires", doc.Cron)
+		}
+		return next, nil
+	}
+	interval := time.Duration(doc.Interval)
+	if interval < MinActionScheduleInterval {
+		return time.Time{}, errors.NotValidf("interval %v", interval)
+	}
+	// Keep to the original cadence, skipping any runs that were
+	// missed, rather than drifting by however late this run is.
+	next := doc.NextRun
+	if next.IsZero() {
+		next = t
+	}
+	for !next.After(t) {
+		next = next.Add(interval)
+	}
+	return next.UTC(), nil
+}
+
+// AddActionSchedule adds a schedule on which the described action will
+// be enqueued on each of the receivers.
+func (st *State) AddActionSchedule(p ActionScheduleParams) (_ *ActionSchedule, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add action schedule")
+	if p.Name == "" {
+		return nil, errors.NotValidf("missing action name")
+	}
+	if len(p.Receivers) == 0 {
+		return nil, errors.NotValidf("missing receivers")
+	}
+	if (p.Cron == "") == (p.Interval == 0) {
+		return nil, errors.NotValidf("schedule without exactly one of cron expression and interval")
+	}
+	if p.Parameters == nil {
+		p.Parameters = map[string]interface{}{}
+	}
+	receivers := make([]string, len(p.Receivers))
+	for i, receiver := range p.Receivers {
+		if err := st.validateReceiverAction(receiver, p.Name, p.Parameters); err != nil {
+			return nil, errors.Trace(err)
+		}
+		receivers[i] = receiver.String()
+	}
+	seq, err := st.sequence("actionschedule")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	id := strconv.Itoa(seq)
+	now := time.Now().UTC().Truncate(time.Second)
+	doc := &actionScheduleDoc{
+		DocID:      st.docID(actionScheduleGlobalKey(id)),
+		EnvUUID:    st.EnvironUUID(),
+		Seq:        seq,
+		Receivers:  receivers,
+		Name:       p.Name,
+		Parameters: p.Parameters,
+		Cron:       p.Cron,
+		Interval:   int64(p.Interval),
+		Created:    now,
+	}
+	if doc.NextRun, err = nextRunAfter(doc, now); err != nil {
+		return nil, errors.Trace(err)
+	}
+	ops := []txn.Op{{
+		C:      actionSchedulesC,
+		Id:     doc.DocID,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	if err := st.runTransaction(ops); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return newActionSchedule(st, doc), nil
+}
+
+// validateReceiverAction checks that the named action is defined by the
+// charm of the receiver, and that the parameters are valid for it.
+func (st *State) validateReceiverAction(receiver names.Tag, name string, parameters map[string]interface{}) error {
+	var specs ActionSpecsByName
+	switch tag := receiver.(type) {
+	case names.UnitTag:
+		unit, err := st.Unit(tag.Id())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if specs, err = unit.ActionSpecs(); err != nil {
+			return errors.Trace(err)
+		}
+	case names.ServiceTag:
+		service, err := st.Service(tag.Id())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		ch, _, err := service.Charm()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if actions := ch.Actions(); actions != nil {
+			specs = actions.ActionSpecs
+		}
+	default:
+		return errors.NotValidf("action receiver %q", receiver)
+	}
+	spec, ok := specs[name]
+	if !ok {
+		return errors.Errorf("action %q not defined for %q", name, receiver.Id())
+	}
+	return errors.Trace(spec.ValidateParams(parameters))
+}
+
+// ActionSchedule returns the action schedule with the given id.
+func (st *State) ActionSchedule(id string) (*ActionSchedule, error) {
+	schedules, closer := st.getCollection(actionSchedulesC)
+	defer closer()
+
+	var doc actionScheduleDoc
+	err := schedules.FindId(st.docID(actionScheduleGlobalKey(id))).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("action schedule %s", id)
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot get action schedule %s", id)
+	}
+	return newActionSchedule(st, &doc), nil
+}
+
+// ActionSchedules returns all the action schedules in the environment,
+// oldest first.
+func (st *State) ActionSchedules() ([]*ActionSchedule, error) {
+	schedules, closer := st.getCollection(actionSchedulesC)
+	defer closer()
+
+	var docs []actionScheduleDoc
+	if err := schedules.Find(nil).Sort("seq").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get action schedules")
+	}
+	result := make([]*ActionSchedule, len(docs))
+	for i := range docs {
+		result[i] = newActionSchedule(st, &docs[i])
+	}
+	return result, nil
+}
+
+// RemoveActionSchedule removes the action schedule with the given id.
+// The record of its runs is removed by a later cleanup. Actions already
+// enqueued by the schedule are not affected.
+func (st *State) RemoveActionSchedule(id string) error {
+	schedule, err := st.ActionSchedule(id)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ops := []txn.Op{{
+		C:      actionSchedulesC,
+		Id:     schedule.doc.DocID,
+		Assert: txn.DocExists,
+		Remove: true,
+	}, st.newCleanupOp(cleanupActionScheduleRuns, id)}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.NotFoundf("action schedule %s", id)
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot remove action schedule %s", id)
+	}
+	return nil
+}
+
+// cleanupActionScheduleRuns removes the record of the runs of a removed
+// action schedule.
+func (st *State) cleanupActionScheduleRuns(id string) error {
+	ops, err := st.expiredActionScheduleRunOps(id, 0)
+	if err != nil {
+		return errors.Annotatef(err, "cannot remove runs of action schedule %s", id)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	return errors.Annotatef(st.runTransaction(ops), "cannot remove runs of action schedule %s", id)
+}
+
+// expiredActionScheduleRunOps returns the operations needed to remove
+// the records of all but the newest keep runs of the action schedule
+// with the given id.
+func (st *State) expiredActionScheduleRunOps(id string, keep int) ([]txn.Op, error) {
+	runs, closer := st.getCollection(actionScheduleRunsC)
+	defer closer()
+
+	var docs []actionScheduleRunDoc
+	err := runs.Find(bson.D{{"scheduleid", id}}).Sort("-run").Skip(keep).Select(bson.D{{"_id", 1}}).All(&docs)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ops := make([]txn.Op, len(docs))
+	for i, doc := range docs {
+		ops[i] = txn.Op{
+			C:      actionScheduleRunsC,
+			Id:     doc.DocID,
+			Remove: true,
+		}
+	}
+	return ops, nil
+}
+
+// Runs returns the record of the schedule's runs, newest first.
+func (s *ActionSchedule) Runs() ([]ActionScheduleRun, error) {
+	runs, closer := s.st.getCollection(actionScheduleRunsC)
+	defer closer()
+
+	var docs []actionScheduleRunDoc
+	if err := runs.Find(bson.D{{"scheduleid", s.Id()}}).Sort("-run").All(&docs); err != nil {
+		return nil, errors.Annotatef(err, "cannot get runs of action schedule %s", s.Id())
+	}
+	result := make([]ActionScheduleRun, len(docs))
+	for i, doc := range docs {
+		result[i] = doc.run()
+	}
+	return result, nil
+}
+
+// RunIfDue enqueues the schedule's action on each of its receivers if
+// the schedule is due to run at the given time, and records the run.
+// It returns nil if the schedule is not due. Each scheduled run is made
+// at most once, even if RunIfDue is called concurrently: the actions
+// are enqueued in the same transaction that claims the run. Only the
+// newest MaxActionScheduleRuns runs of the schedule are kept.
+func (s *ActionSchedule) RunIfDue(now time.Time) (*ActionScheduleRun, error) {
+	now = now.UTC()
+	var doc *actionScheduleRunDoc
+	var next time.Time
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := s.Refresh(); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if s.doc.NextRun.After(now) {
+			return nil, jujutxn.ErrNoOperations
+		}
+		var err error
+		if next, err = nextRunAfter(&s.doc, now); err != nil {
+			return nil, errors.Trace(err)
+		}
+		claimed := s.doc.Runs + 1
+		doc = &actionScheduleRunDoc{
+			DocID:      s.st.docID(actionScheduleRunGlobalKey(s.Id(), claimed)),
+			EnvUUID:    s.st.EnvironUUID(),
+			ScheduleId: s.Id(),
+			Run:        claimed,
+			Time:       now,
+		}
+		ops := []txn.Op{{
+			C:      actionSchedulesC,
+			Id:     s.doc.DocID,
+			Assert: bson.D{{"runs", s.doc.Runs}},
+			Update: bson.D{
+				{"$set", bson.D{{"nextrun", next}, {"lastrun", now}}},
+				{"$inc", bson.D{{"runs", 1}}},
+			},
+		}}
+		for _, unit := range s.receiverUnits(doc) {
+			action, actionOps, err := unit.addActionOps(s.doc.Name, copyParameters(s.doc.Parameters))
+			if err != nil {
+				doc.Errors = append(doc.Errors, fmt.Sprintf("%s: %v", unit.Name(), err))
+				continue
+			}
+			doc.Actions = append(doc.Actions, s.st.localID(action.DocId))
+			ops = append(ops, actionOps...)
+		}
+		ops = append(ops, txn.Op{
+			C:      actionScheduleRunsC,
+			Id:     doc.DocID,
+			Assert: txn.DocMissing,
+			Insert: doc,
+		})
+		expiredOps, err := s.st.expiredActionScheduleRunOps(s.Id(), MaxActionScheduleRuns-1)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return append(ops, expiredOps...), nil
+	}
+	if err := s.st.run(buildTxn); err == jujutxn.ErrNoOperations {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot run action schedule %s", s.Id())
+	}
+	s.doc.Runs = doc.Run
+	s.doc.NextRun = next
+	s.doc.LastRun = now
+	run := doc.run()
+	return &run, nil
+}
+
+// receiverUnits returns the units on which the schedule's action should
+// be enqueued, recording in doc any receivers that cannot be found.
+func (s *ActionSchedule) receiverUnits(doc *actionScheduleRunDoc) []*Unit {
+	var units []*Unit
+	for _, receiver := range s.Receivers() {
+		switch tag := receiver.(type) {
+		case names.UnitTag:
+			unit, err := s.st.Unit(tag.Id())
+			if err != nil {
+				doc.Errors = append(doc.Errors, fmt.Sprintf("%s: %v", tag.Id(), err))
+				continue
+			}
+			units = append(units, unit)
+		case names.ServiceTag:
+			service, err := s.st.Service(tag.Id())
+			if err != nil {
+				doc.Errors = append(doc.Errors, fmt.Sprintf("%s: %v", tag.Id(), err))
+				continue
+			}
+			serviceUnits, err := service.AllUnits()
+			if err != nil {
+				doc.Errors = append(doc.Errors, fmt.Sprintf("%s: %v", tag.Id(), err))
+				continue
+			}
+			for _, unit := range serviceUnits {
+				if unit.Life() == Alive {
+					units = append(units, unit)
+				}
+			}
+		}
+	}
+	return units
+}
+
+// copyParameters returns a deep copy of the given action parameters, so
+// that the defaults inserted for one unit do not leak into another's.
+// Nested documents read from mongo are converted back to plain maps so
+// that they validate against the action's schema.
+func copyParameters(parameters map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(parameters))
+	for key, value := range parameters {
+		result[key] = copyParameterValue(value)
+	}
+	return result
+}
+
+func copyParameterValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case bson.M:
+		return copyParameters(value)
+	case map[string]interface{}:
+		return copyParameters(value)
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			result[i] = copyParameterValue(item)
+		}
+		return result
+	}
+	return value
+}
@@ -0,0 +1,306 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/state"
+)
+
+type ActionSchedulesSuite struct {
+	ConnSuite
+	service *state.Service
+	unit    *state.Unit
+	unit2   *state.Unit
+}
+
+var _ = gc.Suite(&ActionSchedulesSuite{})
+
+func (s *ActionSchedulesSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.service = s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	curl, _ := s.service.CharmURL()
+	var err error
+	s.unit, err = s.service.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.unit.SetCharmURL(curl), jc.ErrorIsNil)
+	s.unit2, err = s.service.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.unit2.SetCharmURL(curl), jc.ErrorIsNil)
+}
+
+func (s *ActionSchedulesSuite) addSchedule(c *gc.C, receivers ...names.Tag) *state.ActionSchedule {
+	schedule, err := s.State.AddActionSchedule(state.ActionScheduleParams{
+		Receivers:  receivers,
+		Name:       "snapshot",
+		Parameters: map[string]interface{}{"outfile": "nightly.bz2"},
+		Cron:       "0 3 * * *",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	return schedule
+}
+
+func (s *ActionSchedulesSuite) TestAddActionSchedule(c *gc.C) {
+	before := time.Now().UTC().Truncate(time.Second)
+	schedule := s.addSchedule(c, s.unit.Tag())
+	c.Assert(schedule.Receivers(), jc.DeepEquals, []names.Tag{s.unit.Tag()})
+	c.Assert(schedule.Name(), gc.Equals, "snapshot")
+	c.Assert(schedule.Parameters(), jc.DeepEquals, map[string]interface{}{"outfile": "nightly.bz2"})
+	c.Assert(schedule.Cron(), gc.Equals, "0 3 * * *")
+	c.Assert(schedule.Interval(), gc.Equals, time.Duration(0))
+	c.Assert(schedule.Created().Before(before), jc.IsFalse)
+	c.Assert(schedule.LastRun().IsZero(), jc.IsTrue)
+
+	next := schedule.NextRun()
+	c.Assert(next.After(schedule.Created()), jc.IsTrue)
+	c.Assert(next.Hour(), gc.Equals, 3)
+	c.Assert(next.Minute(), gc.Equals, 0)
+
+	fetched, err := s.State.ActionSchedule(schedule.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fetched.Name(), gc.Equals, "snapshot")
+	c.Assert(fetched.NextRun(), gc.DeepEquals, next)
+}
+
+func (s *ActionSchedulesSuite) TestAddActionScheduleInterval(c *gc.C) {
+	schedule, err := s.State.AddActionSchedule(state.ActionScheduleParams{
+		Receivers: []names.Tag{s.service.Tag()},
+		Name:      "snapshot",
+		Interval:  time.Hour,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(schedule.Interval(), gc.Equals, time.Hour)
+	c.Assert(schedule.NextRun(), gc.DeepEquals, schedule.Created().Add(time.Hour))
+}
+
+func (s *ActionSchedulesSuite) TestAddActionScheduleInvalid(c *gc.C) {
+	for i, test := range []struct {
+		params state.ActionScheduleParams
+		err    string
+	}{{
+		params: state.ActionScheduleParams{Receivers: []names.Tag{s.unit.Tag()}, Cron: "@daily"},
+		err:    "cannot add action schedule: missing action name not valid",
+	}, {
+		params: state.ActionScheduleParams{Name: "snapshot", Cron: "@daily"},
+		err:    "cannot add action schedule: missing receivers not valid",
+	}, {
+		params: state.ActionScheduleParams{Receivers: []names.Tag{s.unit.Tag()}, Name: "snapshot"},
+		err:    "cannot add action schedule: schedule without exactly one of cron expression and interval not valid",
+	}, {
+		params: state.ActionScheduleParams{Receivers: []names.Tag{s.unit.Tag()}, Name: "snapshot", Cron: "@daily", Interval: time.Hour},
+		err:    "cannot add action schedule: schedule without exactly one of cron expression and interval not valid",
+	}, {
+		params: state.ActionScheduleParams{Receivers: []names.Tag{s.unit.Tag()}, Name: "snapshot", Interval: time.Second},
+		err:    "cannot add action schedule: interval 1s not valid",
+	}, {
+		params: state.ActionScheduleParams{Receivers: []names.Tag{s.unit.Tag()}, Name: "snapshot", Cron: "61 * * * *"},
+		err:    `cannot add action schedule: invalid minute "61": value 61 out of range 0-59`,
+	}, {
+		params: state.ActionScheduleParams{Receivers: []names.Tag{s.unit.Tag()}, Name: "snapshot", Cron: "0 0 30 2 *"},
+		err:    `cannot add action schedule: cron expression "0 0 30 2 \*" that never fires not valid`,
+	}, {
+		params: state.ActionScheduleParams{Receivers: []names.Tag{s.unit.Tag()}, Name: "vacuum", Cron: "@daily"},
+		err:    `cannot add action schedule: action "vacuum" not defined for "dummy/0"`,
+	}, {
+		params: state.ActionScheduleParams{Receivers: []names.Tag{names.NewServiceTag("missing")}, Name: "snapshot", Cron: "@daily"},
+		err:    `cannot add action schedule: service "missing" not found`,
+	}, {
+		params: state.ActionScheduleParams{Receivers: []names.Tag{names.NewMachineTag("0")}, Name: "snapshot", Cron: "@daily"},
+		err:    `cannot add action schedule: action receiver "machine-0" not valid`,
+	}, {
+		params: state.ActionScheduleParams{
+			Receivers:  []names.Tag{s.unit.Tag()},
+			Name:       "snapshot",
+			Parameters: map[string]interface{}{"outfile": 5},
+			Cron:       "@daily",
+		},
+		err: `cannot add action schedule: validation failed: .*`,
+	}} {
+		c.Logf("test %d", i)
+		_, err := s.State.AddActionSchedule(test.params)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+	schedules, err := s.State.ActionSchedules()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(schedules, gc.HasLen, 0)
+}
+
+func (s *ActionSchedulesSuite) TestActionSchedules(c *gc.C) {
+	first := s.addSchedule(c, s.unit.Tag())
+	second := s.addSchedule(c, s.service.Tag())
+	schedules, err := s.State.ActionSchedules()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(schedules, gc.HasLen, 2)
+	c.Assert(schedules[0].Id(), gc.Equals, first.Id())
+	c.Assert(schedules[1].Id(), gc.Equals, second.Id())
+}
+
+func (s *ActionSchedulesSuite) TestActionScheduleNotFound(c *gc.C) {
+	_, err := s.State.ActionSchedule("42")
+	c.Assert(err, gc.ErrorMatches, "action schedule 42 not found")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ActionSchedulesSuite) TestRunIfDueNotDue(c *gc.C) {
+	schedule := s.addSchedule(c, s.unit.Tag())
+	run, err := schedule.RunIfDue(schedule.NextRun().Add(-time.Second))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(run, gc.IsNil)
+	s.assertNoActions(c, s.unit)
+}
+
+func (s *ActionSchedulesSuite) TestRunIfDueEnqueuesActions(c *gc.C) {
+	schedule := s.addSchedule(c, s.service.Tag())
+	due := schedule.NextRun()
+	run, err := schedule.RunIfDue(due)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(run, gc.NotNil)
+	c.Assert(run.ScheduleId, gc.Equals, schedule.Id())
+	c.Assert(run.Time, gc.DeepEquals, due)
+	c.Assert(run.Errors, gc.HasLen, 0)
+	c.Assert(run.Actions, gc.HasLen, 2)
+
+	for _, unit := range []*state.Unit{s.unit, s.unit2} {
+		actions, err := unit.PendingActions()
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(actions, gc.HasLen, 1)
+		c.Check(actions[0].Name(), gc.Equals, "snapshot")
+		c.Check(actions[0].Parameters(), jc.DeepEquals, map[string]interface{}{"outfile": "nightly.bz2"})
+	}
+
+	c.Assert(schedule.LastRun(), gc.DeepEquals, due)
+	c.Assert(schedule.NextRun(), gc.DeepEquals, due.AddDate(0, 0, 1))
+	runs, err := schedule.Runs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(runs, jc.DeepEquals, []state.ActionScheduleRun{*run})
+}
+
+func (s *ActionSchedulesSuite) TestRunIfDueRunsOnce(c *gc.C) {
+	schedule := s.addSchedule(c, s.unit.Tag())
+	due := schedule.NextRun()
+	stale, err := s.State.ActionSchedule(schedule.Id())
+	c.Assert(err, jc.ErrorIsNil)
+
+	run, err := schedule.RunIfDue(due)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(run, gc.NotNil)
+
+	// A second runner with an out of date view of the schedule
+	// finds that the run has already been made.
+	run, err = stale.RunIfDue(due)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(run, gc.IsNil)
+
+	actions, err := s.unit.PendingActions()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(actions, gc.HasLen, 1)
+}
+
+func (s *ActionSchedulesSuite) TestRunIfDueRecordsErrors(c *gc.C) {
+	schedule := s.addSchedule(c, s.unit.Tag(), s.unit2.Tag())
+	c.Assert(s.unit2.EnsureDead(), jc.ErrorIsNil)
+	c.Assert(s.unit2.Remove(), jc.ErrorIsNil)
+
+	run, err := schedule.RunIfDue(schedule.NextRun())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(run.Actions, gc.HasLen, 1)
+	c.Assert(run.Errors, jc.DeepEquals, []string{`dummy/1: unit "dummy/1" not found`})
+}
+
+func (s *ActionSchedulesSuite) TestRunIfDueSkipsMissedIntervals(c *gc.C) {
+	schedule, err := s.State.AddActionSchedule(state.ActionScheduleParams{
+		Receivers: []names.Tag{s.unit.Tag()},
+		Name:      "snapshot",
+		Interval:  time.Hour,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	due := schedule.NextRun()
+	run, err := schedule.RunIfDue(due.Add(150 * time.Minute))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(run, gc.NotNil)
+	c.Assert(schedule.NextRun(), gc.DeepEquals, due.Add(3*time.Hour))
+}
+
+func (s *ActionSchedulesSuite) TestRunIfDueKeepsNewestRuns(c *gc.C) {
+	schedule, err := s.State.AddActionSchedule(state.ActionScheduleParams{
+		Receivers: []names.Tag{s.unit.Tag()},
+		Name:      "snapshot",
+		Interval:  time.Hour,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	for i := 0; i < state.MaxActionScheduleRuns+2; i++ {
+		run, err := schedule.RunIfDue(schedule.NextRun())
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(run, gc.NotNil)
+	}
+	runs, err := schedule.Runs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(runs, gc.HasLen, state.MaxActionScheduleRuns)
+	c.Assert(runs[0].Time, gc.DeepEquals, schedule.LastRun())
+}
+
+func (s *ActionSchedulesSuite) TestRunIfDueEnqueuesNothingWhenAlreadyRun(c *gc.C) {
+	schedule := s.addSchedule(c, s.unit.Tag())
+	due := schedule.NextRun()
+	stale, err := s.State.ActionSchedule(schedule.Id())
+	c.Assert(err, jc.ErrorIsNil)
+
+	// The run is claimed by another runner just before the stale
+	// runner's transaction is applied, so its actions are discarded
+	// along with the claim.
+	defer state.SetBeforeHooks(c, s.State, func() {
+		run, err := schedule.RunIfDue(due)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(run, gc.NotNil)
+	}).Check()
+
+	run, err := stale.RunIfDue(due)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(run, gc.IsNil)
+
+	actions, err := s.unit.PendingActions()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(actions, gc.HasLen, 1)
+}
+
+func (s *ActionSchedulesSuite) TestRemoveActionSchedule(c *gc.C) {
+	schedule := s.addSchedule(c, s.unit.Tag())
+	_, err := schedule.RunIfDue(schedule.NextRun())
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.State.RemoveActionSchedule(schedule.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.ActionSchedule(schedule.Id())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// The runs are removed by a cleanup.
+	runs, err := schedule.Runs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(runs, gc.HasLen, 1)
+	err = s.State.Cleanup()
+	c.Assert(err, jc.ErrorIsNil)
+	runs, err = schedule.Runs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(runs, gc.HasLen, 0)
+
+	// Actions already enqueued are left alone.
+	actions, err := s.unit.PendingActions()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(actions, gc.HasLen, 1)
+
+	err = s.State.RemoveActionSchedule(schedule.Id())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ActionSchedulesSuite) assertNoActions(c *gc.C, unit *state.Unit) {
+	actions, err := unit.PendingActions()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(actions, gc.HasLen, 0)
+}
@@ -87,6 +87,10 @@ type MachineTemplate struct {
 	// with the machine.
 	Placement string
 
+	// CloudInitUserData holds cloud-init user data to be added to the
+	// configuration generated when provisioning the machine.
+	CloudInitUserData string
+
 	// principals holds the principal units that will
 	// associated with the machine.
 	principals []string
@@ -179,6 +183,11 @@ func (st *State) AddMachines(templates ...MachineTemplate) (_ []*Machine, err er
 		ms = append(ms, newMachine(st, mdoc))
 		ops = append(ops, addOps...)
 	}
+	quotaOps, err := st.quotaOps(machinesQuotaDelta(1, mdocs...))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ops = append(ops, quotaOps...)
 	ssOps, err := st.maintainStateServersOps(mdocs, nil)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -433,23 +442,29 @@ func (st *State) addMachineInsideNewMachineOps(template, parentTemplate MachineT
 		// Create a containers reference document for the container itself.
 		st.insertNewContainerRefOp(parentDoc.Id, mdoc.Id),
 	)
+	quotaOps, err := st.quotaOps(machinesQuotaDelta(1, parentDoc))
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	prereqOps = append(prereqOps, quotaOps...)
 	return mdoc, append(prereqOps, parentOp, machineOp), nil
 }
 
 func (st *State) machineDocForTemplate(template MachineTemplate, id string) *machineDoc {
 	return &machineDoc{
-		DocID:      st.docID(id),
-		Id:         id,
-		EnvUUID:    st.EnvironUUID(),
-		Series:     template.Series,
-		Jobs:       template.Jobs,
-		Clean:      !template.Dirty,
-		Principals: template.principals,
-		Life:       Alive,
-		Nonce:      template.Nonce,
-		Addresses:  fromNetworkAddresses(template.Addresses),
-		NoVote:     template.NoVote,
-		Placement:  template.Placement,
+		DocID:             st.docID(id),
+		Id:                id,
+		EnvUUID:           st.EnvironUUID(),
+		Series:            template.Series,
+		Jobs:              template.Jobs,
+		Clean:             !template.Dirty,
+		Principals:        template.principals,
+		Life:              Alive,
+		Nonce:             template.Nonce,
+		Addresses:         fromNetworkAddresses(template.Addresses),
+		NoVote:            template.NoVote,
+		Placement:         template.Placement,
+		CloudInitUserData: template.CloudInitUserData,
 	}
 }
 
@@ -112,7 +112,7 @@ func (st *State) SetAPIHostPorts(netHostsPorts [][]network.HostPort) error {
 		APIHostPorts: fromNetworkHostsPorts(netHostsPorts),
 	}
 	buildTxn := func(attempt int) ([]txn.Op, error) {
-		existing, err := st.APIHostPorts()
+		existing, err := st.stateServerAPIHostPorts()
 		if err != nil {
 			return nil, err
 		}
@@ -138,7 +138,25 @@ func (st *State) SetAPIHostPorts(netHostsPorts [][]network.HostPort) error {
 }
 
 // APIHostPorts returns the API addresses as set by SetAPIHostPorts.
+// If the environment has been migrated to another state server, the
+// addresses of that state server are returned instead, so that the
+// environment's agents will connect to it.
 func (st *State) APIHostPorts() ([][]network.HostPort, error) {
+	env, err := st.Environment()
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, errors.Trace(err)
+	}
+	if env != nil {
+		if migratedTo := env.MigratedTo(); migratedTo != nil {
+			return migratedTo, nil
+		}
+	}
+	return st.stateServerAPIHostPorts()
+}
+
+// stateServerAPIHostPorts returns the API addresses of this state
+// server, as set by SetAPIHostPorts.
+func (st *State) stateServerAPIHostPorts() ([][]network.HostPort, error) {
 	var doc apiHostPortsDoc
 	stateServers, closer := st.getCollection(stateServersC)
 	defer closer()
@@ -0,0 +1,247 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// apiTokenPrefix starts the credentials with which a user logs in
+// using an API token, which take the form "token:<name>:<secret>".
+const apiTokenPrefix = "token:"
+
+var validAPITokenName = regexp.MustCompile("^[a-z0-9][a-z0-9.-]*$")
+
+// APIToken represents a named token with which a user can log in to the
+// API of an environment in place of their password. Only a hash of the
+// token's secret is stored.
+type APIToken struct {
+	st  *State
+	doc apiTokenDoc
+}
+
+type apiTokenDoc struct {
+	DocID      string     `bson:"_id"`
+	EnvUUID    string     `bson:"env-uuid"`
+	Owner      string     `bson:"owner"`
+	Name       string     `bson:"name"`
+	SecretHash string     `bson:"secrethash"`
+	SecretSalt string     `bson:"secretsalt"`
+	Facades    []string   `bson:"facades,omitempty"`
+	Created    time.Time  `bson:"created"`
+	Expires    *time.Time `bson:"expires,omitempty"`
+}
+
+// apiTokenKey returns the key identifying the named token of the user
+// within an environment.
+func apiTokenKey(owner names.UserTag, name string) string {
+	return fmt.Sprintf("%s#%s", strings.ToLower(owner.Username()), name)
+}
+
+// APITokenCredentials returns the credentials with which a user logs in
+// using the token with the given name and secret.
+func APITokenCredentials(name, secret string) string {
+	return apiTokenPrefix + name + ":" + secret
+}
+
+// ParseAPITokenCredentials returns the name and secret of the token
+// held in the given login credentials, and whether they hold a token
+// at all.
+func ParseAPITokenCredentials(credentials string) (name, secret string, ok bool) {
+	if !strings.HasPrefix(credentials, apiTokenPrefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(credentials, apiTokenPrefix), ":", 2)
+	if len(parts) != 2 || !validAPITokenName.MatchString(parts[0]) || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// AddAPIToken creates a token with which the owner can log in to the
+// API of the environment. If facades is not empty, connections made
+// with the token may only use the named facades. If expires is not nil,
+// the token cannot be used after that time. The new token is returned
+// along with the credentials that hold its secret, which are not
+// stored and cannot be retrieved later.
+func (st *State) AddAPIToken(owner names.UserTag, name string, facades []string, expires *time.Time) (_ *APIToken, credentials string, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add API token %q for user %q", name, owner.Name())
+	if !validAPITokenName.MatchString(name) {
+		return nil, "", errors.NotValidf("token name %q", name)
+	}
+	if expires != nil && !expires.After(nowToTheSecond()) {
+		return nil, "", errors.NotValidf("expiry time in the past")
+	}
+	user, err := st.User(owner)
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	secret, err := utils.RandomPassword()
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	salt, err := utils.RandomSalt()
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	key := apiTokenKey(owner, name)
+	doc := apiTokenDoc{
+		DocID:      st.docID(key),
+		EnvUUID:    st.EnvironUUID(),
+		Owner:      owner.Username(),
+		Name:       name,
+		SecretHash: utils.UserPasswordHash(secret, salt),
+		SecretSalt: salt,
+		Facades:    facades,
+		Created:    nowToTheSecond(),
+	}
+	if expires != nil {
+		utc := expires.UTC()
+		doc.Expires = &utc
+	}
+	ops := []txn.Op{{
+		C:      usersC,
+		Id:     user.doc.DocID,
+		Assert: txn.DocExists,
+	}, {
+		C:      apiTokensC,
+		Id:     doc.DocID,
+		Assert: txn.DocMissing,
+		Insert: &doc,
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		if _, err := st.APIToken(owner, name); err == nil {
+			return nil, "", errors.AlreadyExistsf("API token %q", name)
+		}
+		return nil, "", errors.NotFoundf("user %q", owner.Name())
+	} else if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	return &APIToken{st: st, doc: doc}, APITokenCredentials(name, secret), nil
+}
+
+// APIToken returns the owner's token with the given name.
+func (st *State) APIToken(owner names.UserTag, name string) (*APIToken, error) {
+	coll, closer := st.getCollection(apiTokensC)
+	defer closer()
+
+	var doc apiTokenDoc
+	err := coll.FindId(apiTokenKey(owner, name)).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("API token %q for user %q", name, owner.Name())
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot get API token %q", name)
+	}
+	return newAPIToken(st, doc), nil
+}
+
+// APITokens returns the owner's tokens in the environment, sorted by
+// name.
+func (st *State) APITokens(owner names.UserTag) ([]*APIToken, error) {
+	coll, closer := st.getCollection(apiTokensC)
+	defer closer()
+
+	var docs []apiTokenDoc
+	err := coll.Find(bson.D{{"owner", owner.Username()}}).Sort("name").All(&docs)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get API tokens for user %q", owner.Name())
+	}
+	tokens := make([]*APIToken, len(docs))
+	for i, doc := range docs {
+		tokens[i] = newAPIToken(st, doc)
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken removes the owner's token with the given name, so that
+// it can no longer be used to log in.
+func (st *State) RevokeAPIToken(owner names.UserTag, name string) error {
+	ops := []txn.Op{{
+		C:      apiTokensC,
+		Id:     st.docID(apiTokenKey(owner, name)),
+		Assert: txn.DocExists,
+		Remove: true,
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.NotFoundf("API token %q for user %q", name, owner.Name())
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot revoke API token %q", name)
+	}
+	return nil
+}
+
+func newAPIToken(st *State, doc apiTokenDoc) *APIToken {
+	// Times are inserted as UTC, but read out as local time.
+	doc.Created = doc.Created.UTC()
+	if doc.Expires != nil {
+		utc := doc.Expires.UTC()
+		doc.Expires = &utc
+	}
+	return &APIToken{st: st, doc: doc}
+}
+
+// Name returns the name of the token.
+func (t *APIToken) Name() string {
+	return t.doc.Name
+}
+
+// Owner returns the tag of the user that owns the token.
+func (t *APIToken) Owner() names.UserTag {
+	return names.NewUserTag(t.doc.Owner)
+}
+
+// Facades returns the names of the facades that connections made with
+// the token may use. If it is empty, all facades may be used.
+func (t *APIToken) Facades() []string {
+	return t.doc.Facades
+}
+
+// AllowsFacade returns whether connections made with the token may use
+// the named facade.
+func (t *APIToken) AllowsFacade(facade string) bool {
+	if len(t.doc.Facades) == 0 {
+		return true
+	}
+	for _, name := range t.doc.Facades {
+		if name == facade {
+			return true
+		}
+	}
+	return false
+}
+
+// Created returns the time at which the token was created.
+func (t *APIToken) Created() time.Time {
+	return t.doc.Created
+}
+
+// Expires returns the time after which the token cannot be used, or nil
+// if it never expires.
+func (t *APIToken) Expires() *time.Time {
+	return t.doc.Expires
+}
+
+// Expired returns whether the token has expired.
+func (t *APIToken) Expired() bool {
+	return t.doc.Expires != nil && !t.doc.Expires.After(time.Now())
+}
+
+// SecretValid returns whether the given secret is the token's secret,
+// and the token has not expired.
+func (t *APIToken) SecretValid(secret string) bool {
+	if t.Expired() {
+		return false
+	}
+	return utils.UserPasswordHash(secret, t.doc.SecretSalt) == t.doc.SecretHash
+}
@@ -0,0 +1,175 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing/factory"
+)
+
+type APITokenSuite struct {
+	ConnSuite
+	owner names.UserTag
+}
+
+var _ = gc.Suite(&APITokenSuite{})
+
+func (s *APITokenSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.owner = s.Factory.MakeUser(c, &factory.UserParams{Name: "bob"}).UserTag()
+}
+
+func (s *APITokenSuite) TestAddAPIToken(c *gc.C) {
+	expires := time.Now().Add(time.Hour).Round(time.Second)
+	token, credentials, err := s.State.AddAPIToken(s.owner, "ci", []string{"Client"}, &expires)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(token.Name(), gc.Equals, "ci")
+	c.Assert(token.Owner(), gc.Equals, s.owner)
+	c.Assert(token.Facades(), jc.DeepEquals, []string{"Client"})
+	c.Assert(token.Expires().Equal(expires), jc.IsTrue)
+	c.Assert(token.Expired(), jc.IsFalse)
+
+	name, secret, ok := state.ParseAPITokenCredentials(credentials)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(name, gc.Equals, "ci")
+
+	token, err = s.State.APIToken(s.owner, "ci")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(token.SecretValid(secret), jc.IsTrue)
+	c.Assert(token.SecretValid("wrong"), jc.IsFalse)
+	c.Assert(token.Expires().Equal(expires), jc.IsTrue)
+	c.Assert(token.AllowsFacade("Client"), jc.IsTrue)
+	c.Assert(token.AllowsFacade("UserManager"), jc.IsFalse)
+}
+
+func (s *APITokenSuite) TestAddAPITokenNoExpiryOrFacades(c *gc.C) {
+	_, credentials, err := s.State.AddAPIToken(s.owner, "ci", nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	_, secret, ok := state.ParseAPITokenCredentials(credentials)
+	c.Assert(ok, jc.IsTrue)
+
+	token, err := s.State.APIToken(s.owner, "ci")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(token.Expires(), gc.IsNil)
+	c.Assert(token.AllowsFacade("UserManager"), jc.IsTrue)
+	c.Assert(token.SecretValid(secret), jc.IsTrue)
+}
+
+func (s *APITokenSuite) TestAddAPITokenDuplicate(c *gc.C) {
+	_, _, err := s.State.AddAPIToken(s.owner, "ci", nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	_, _, err = s.State.AddAPIToken(s.owner, "ci", nil, nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add API token "ci" for user "bob": API token "ci" already exists`)
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+
+	// Other users may use the same name.
+	other := s.Factory.MakeUser(c, nil).UserTag()
+	_, _, err = s.State.AddAPIToken(other, "ci", nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *APITokenSuite) TestAddAPITokenInvalid(c *gc.C) {
+	_, _, err := s.State.AddAPIToken(s.owner, "Bad:Name", nil, nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add API token "Bad:Name" for user "bob": token name "Bad:Name" not valid`)
+
+	past := time.Now().Add(-time.Hour)
+	_, _, err = s.State.AddAPIToken(s.owner, "ci", nil, &past)
+	c.Assert(err, gc.ErrorMatches, `cannot add API token "ci" for user "bob": expiry time in the past not valid`)
+
+	_, _, err = s.State.AddAPIToken(names.NewLocalUserTag("nobody"), "ci", nil, nil)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *APITokenSuite) TestExpiredAPIToken(c *gc.C) {
+	expires := time.Now().Add(time.Hour)
+	_, credentials, err := s.State.AddAPIToken(s.owner, "ci", nil, &expires)
+	c.Assert(err, jc.ErrorIsNil)
+	_, secret, _ := state.ParseAPITokenCredentials(credentials)
+
+	// Move the expiry time into the past.
+	err = state.RunTransaction(s.State, []txn.Op{{
+		C:      "apitokens",
+		Id:     state.DocID(s.State, "bob@local#ci"),
+		Update: bson.D{{"$set", bson.D{{"expires", time.Now().Add(-time.Minute)}}}},
+	}})
+	c.Assert(err, jc.ErrorIsNil)
+
+	token, err := s.State.APIToken(s.owner, "ci")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(token.Expired(), jc.IsTrue)
+	c.Assert(token.SecretValid(secret), jc.IsFalse)
+}
+
+func (s *APITokenSuite) TestAPITokens(c *gc.C) {
+	for _, name := range []string{"deploy", "ci"} {
+		_, _, err := s.State.AddAPIToken(s.owner, name, nil, nil)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	other := s.Factory.MakeUser(c, nil).UserTag()
+	_, _, err := s.State.AddAPIToken(other, "other", nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	tokens, err := s.State.APITokens(s.owner)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(tokens, gc.HasLen, 2)
+	c.Assert(tokens[0].Name(), gc.Equals, "ci")
+	c.Assert(tokens[1].Name(), gc.Equals, "deploy")
+}
+
+func (s *APITokenSuite) TestAPITokensScopedToEnvironment(c *gc.C) {
+	_, _, err := s.State.AddAPIToken(s.owner, "ci", nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	st := s.Factory.MakeEnvironment(c, nil)
+	defer st.Close()
+	_, err = st.APIToken(s.owner, "ci")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	tokens, err := st.APITokens(s.owner)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(tokens, gc.HasLen, 0)
+}
+
+func (s *APITokenSuite) TestRevokeAPIToken(c *gc.C) {
+	_, _, err := s.State.AddAPIToken(s.owner, "ci", nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.State.RevokeAPIToken(s.owner, "ci")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.APIToken(s.owner, "ci")
+	c.Assert(err, gc.ErrorMatches, `API token "ci" for user "bob" not found`)
+
+	err = s.State.RevokeAPIToken(s.owner, "ci")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *APITokenSuite) TestParseAPITokenCredentials(c *gc.C) {
+	for i, test := range []struct {
+		credentials string
+		name        string
+		secret      string
+		ok          bool
+	}{
+		{"token:ci:s3cret", "ci", "s3cret", true},
+		{"token:ci:s3:cret", "ci", "s3:cret", true},
+		{"password", "", "", false},
+		{"token:ci", "", "", false},
+		{"token:ci:", "", "", false},
+		{"token:Bad Name:secret", "", "", false},
+	} {
+		c.Logf("test %d: %q", i, test.credentials)
+		name, secret, ok := state.ParseAPITokenCredentials(test.credentials)
+		c.Check(name, gc.Equals, test.name)
+		c.Check(secret, gc.Equals, test.secret)
+		c.Check(ok, gc.Equals, test.ok)
+	}
+}
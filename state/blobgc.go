@@ -0,0 +1,496 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"gopkg.in/juju/charm.v5"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/state/imagestorage"
+	"github.com/juju/juju/state/storage"
+	"github.com/juju/juju/tools"
+	"github.com/juju/juju/version"
+)
+
+// BlobKind identifies the kind of content held in blob storage.
+type BlobKind string
+
+const (
+	// CharmBlob is a charm archive.
+	CharmBlob BlobKind = "charm"
+
+	// ToolsBlob is an agent tools tarball.
+	ToolsBlob BlobKind = "tools"
+
+	// ImageBlob is a cached container image.
+	ImageBlob BlobKind = "image"
+)
+
+// DefaultBlobGracePeriod is the time for which a blob must have been
+// unused before it is removed, unless another period is requested.
+const DefaultBlobGracePeriod = 24 * time.Hour
+
+// UnusedBlob describes a charm archive, tools tarball or container
+// image in an environment's blob storage that is no longer used.
+type UnusedBlob struct {
+	// EnvUUID identifies the environment the blob belongs to.
+	EnvUUID string
+
+	// Kind is the kind of the blob.
+	Kind BlobKind
+
+	// Name identifies the blob within its kind and environment: a
+	// charm URL, a tools version, or an image's kind, series and
+	// architecture.
+	Name string
+
+	// Size is the size of the blob in bytes.
+	Size int64
+
+	// UnusedSince is the time the blob was first found to be unused.
+	UnusedSince time.Time
+
+	// Expired is true if the blob has been unused for longer than the
+	// grace period, and so is due to be removed.
+	Expired bool
+
+	// Removed is true if the blob was removed.
+	Removed bool
+}
+
+// unusedBlob holds an UnusedBlob along with the means of removing it.
+type unusedBlob struct {
+	UnusedBlob
+	remove func() error
+}
+
+// errBlobInUse is returned when a blob being removed was found to be
+// in use again.
+var errBlobInUse = errors.New("blob is in use")
+
+// unusedBlobDoc records when a blob was first found to be unused.
+type unusedBlobDoc struct {
+	DocID       string    `bson:"_id"`
+	EnvUUID     string    `bson:"env-uuid"`
+	Kind        BlobKind  `bson:"kind"`
+	Name        string    `bson:"name"`
+	UnusedSince time.Time `bson:"unused-since"`
+}
+
+func unusedBlobKey(kind BlobKind, name string) string {
+	return fmt.Sprintf("%s#%s", kind, name)
+}
+
+// CollectUnusedBlobs finds the charm archives, tools tarballs and
+// container images in the blob storage of every environment that are
+// no longer used. Charms are unused once no service or unit refers to
+// them; tools once no agent is running them and the environment's
+// agent-version is different; and images once no container of their
+// kind runs their series.
+//
+// Blobs are removed once they have been unused for longer than the
+// given grace period, which guards against the removal of content that
+// has just been uploaded and is about to be used. If dryRun is true,
+// nothing is recorded or removed, and the blobs that would be removed
+// are reported as expired.
+func (st *State) CollectUnusedBlobs(gracePeriod time.Duration, dryRun bool) ([]UnusedBlob, error) {
+	environments, closer := st.getCollection(environmentsC)
+	var envDocs []environmentDoc
+	err := environments.Find(nil).Select(bson.D{{"_id", 1}}).All(&envDocs)
+	closer()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot get environments")
+	}
+	var result []UnusedBlob
+	for _, envDoc := range envDocs {
+		envSt, err := st.ForEnviron(names.NewEnvironTag(envDoc.UUID))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		blobs, err := envSt.collectUnusedBlobs(gracePeriod, dryRun)
+		envSt.Close()
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot collect unused blobs of environment %q", envDoc.UUID)
+		}
+		result = append(result, blobs...)
+	}
+	return result, nil
+}
+
+// collectUnusedBlobs finds, records and removes the unused blobs of
+// the environment, as described for CollectUnusedBlobs.
+func (st *State) collectUnusedBlobs(gracePeriod time.Duration, dryRun bool) ([]UnusedBlob, error) {
+	candidates, err := st.unusedBlobs()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	coll, closer := st.getCollection(unusedBlobsC)
+	var docs []unusedBlobDoc
+	err = coll.Find(nil).All(&docs)
+	closer()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read unused blobs")
+	}
+	known := make(map[string]unusedBlobDoc)
+	for _, doc := range docs {
+		known[unusedBlobKey(doc.Kind, doc.Name)] = doc
+	}
+
+	now := nowToTheSecond()
+	var ops []txn.Op
+	result := make([]UnusedBlob, len(candidates))
+	for i, candidate := range candidates {
+		blob := candidate.UnusedBlob
+		key := unusedBlobKey(blob.Kind, blob.Name)
+		if doc, ok := known[key]; ok {
+			blob.UnusedSince = doc.UnusedSince
+			delete(known, key)
+		} else {
+			blob.UnusedSince = now
+			ops = append(ops, txn.Op{
+				C:      unusedBlobsC,
+				Id:     st.docID(key),
+				Assert: txn.DocMissing,
+				Insert: &unusedBlobDoc{
+					DocID:       st.docID(key),
+					EnvUUID:     st.EnvironUUID(),
+					Kind:        blob.Kind,
+					Name:        blob.Name,
+					UnusedSince: now,
+				},
+			})
+		}
+		blob.Expired = !blob.UnusedSince.Add(gracePeriod).After(now)
+		result[i] = blob
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	// Blobs that are in use again, or that have been removed, are
+	// forgotten.
+	for _, doc := range known {
+		ops = append(ops, txn.Op{
+			C:      unusedBlobsC,
+			Id:     doc.DocID,
+			Remove: true,
+		})
+	}
+	if len(ops) > 0 {
+		if err := st.runTransaction(ops); err == txn.ErrAborted {
+			return nil, errors.New("unused blobs changed concurrently")
+		} else if err != nil {
+			return nil, errors.Annotate(err, "cannot record unused blobs")
+		}
+	}
+	for i, candidate := range candidates {
+		blob := &result[i]
+		if !blob.Expired {
+			continue
+		}
+		logger.Infof("removing %s %q of environment %s, unused since %v", blob.Kind, blob.Name, blob.EnvUUID, blob.UnusedSince)
+		err := candidate.remove()
+		if err == errBlobInUse {
+			logger.Infof("not removing %s %q: in use again", blob.Kind, blob.Name)
+			continue
+		} else if err != nil {
+			return nil, errors.Annotatef(err, "cannot remove %s %q", blob.Kind, blob.Name)
+		}
+		blob.Removed = true
+	}
+	return result, nil
+}
+
+// unusedBlobs returns the unused blobs of the environment, sorted by
+// kind and name.
+func (st *State) unusedBlobs() ([]unusedBlob, error) {
+	var result []unusedBlob
+	for _, find := range []func() ([]unusedBlob, error){
+		st.unusedCharms,
+		st.unusedTools,
+		st.unusedImages,
+	} {
+		blobs, err := find()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		sort.Sort(unusedBlobsByName(blobs))
+		result = append(result, blobs...)
+	}
+	return result, nil
+}
+
+type unusedBlobsByName []unusedBlob
+
+func (b unusedBlobsByName) Len() int           { return len(b) }
+func (b unusedBlobsByName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b unusedBlobsByName) Less(i, j int) bool { return b[i].Name < b[j].Name }
+
+// usedCharmURLs returns the charm URLs of all services and units, and
+// the ids of the service documents.
+func (st *State) usedCharmURLs() (map[string]bool, []string, error) {
+	used := make(map[string]bool)
+	var serviceIds []string
+	for _, name := range []string{servicesC, unitsC} {
+		coll, closer := st.getCollection(name)
+		var docs []struct {
+			DocID    string     `bson:"_id"`
+			CharmURL *charm.URL `bson:"charmurl"`
+		}
+		err := coll.Find(nil).Select(bson.D{{"_id", 1}, {"charmurl", 1}}).All(&docs)
+		closer()
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "cannot read %s", name)
+		}
+		for _, doc := range docs {
+			if doc.CharmURL != nil {
+				used[doc.CharmURL.String()] = true
+			}
+			if name == servicesC {
+				serviceIds = append(serviceIds, doc.DocID)
+			}
+		}
+	}
+	return used, serviceIds, nil
+}
+
+// unusedCharms returns the uploaded charms not used by any service
+// or unit. Placeholders and charms pending upload have no archive, and
+// so are never returned.
+func (st *State) unusedCharms() ([]unusedBlob, error) {
+	used, serviceIds, err := st.usedCharmURLs()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	charms, closer := st.getCollection(charmsC)
+	var docs []charmDoc
+	err = charms.Find(bson.D{
+		{"placeholder", bson.D{{"$ne", true}}},
+		{"pendingupload", bson.D{{"$ne", true}}},
+	}).All(&docs)
+	closer()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read charms")
+	}
+	stor := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
+	var result []unusedBlob
+	for _, doc := range docs {
+		doc := doc
+		if used[doc.URL.String()] || doc.StoragePath == "" {
+			continue
+		}
+		var size int64
+		if r, length, err := stor.Get(doc.StoragePath); err == nil {
+			r.Close()
+			size = length
+		} else if !errors.IsNotFound(err) {
+			return nil, errors.Annotatef(err, "cannot read archive of charm %q", doc.URL)
+		}
+		result = append(result, unusedBlob{
+			UnusedBlob: UnusedBlob{
+				EnvUUID: st.EnvironUUID(),
+				Kind:    CharmBlob,
+				Name:    doc.URL.String(),
+				Size:    size,
+			},
+			remove: func() error {
+				return st.removeUnusedCharm(&doc, serviceIds)
+			},
+		})
+	}
+	return result, nil
+}
+
+// removeUnusedCharm removes the charm document and archive, so long
+// as none of the given services has been changed to use the charm.
+func (st *State) removeUnusedCharm(doc *charmDoc, serviceIds []string) error {
+	ops := []txn.Op{{
+		C:      charmsC,
+		Id:     doc.DocID,
+		Assert: bson.D{{"storagepath", doc.StoragePath}},
+		Remove: true,
+	}}
+	for _, id := range serviceIds {
+		ops = append(ops, txn.Op{
+			C:      servicesC,
+			Id:     id,
+			Assert: bson.D{{"charmurl", bson.D{{"$ne", doc.URL}}}},
+		})
+	}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return errBlobInUse
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	stor := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
+	if err := stor.Remove(doc.StoragePath); err != nil && !errors.IsNotFound(err) {
+		return errors.Annotate(err, "cannot remove charm archive")
+	}
+	return nil
+}
+
+// usedTools returns the versions of the tools that machine and unit
+// agents are running, and the environment's agent-version.
+func (st *State) usedTools() (map[version.Binary]bool, version.Number, error) {
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return nil, version.Number{}, errors.Trace(err)
+	}
+	agentVersion, _ := cfg.AgentVersion()
+	used := make(map[version.Binary]bool)
+	for _, name := range []string{machinesC, unitsC} {
+		coll, closer := st.getCollection(name)
+		var docs []struct {
+			Tools *tools.Tools `bson:"tools"`
+		}
+		err := coll.Find(nil).Select(bson.D{{"tools", 1}}).All(&docs)
+		closer()
+		if err != nil {
+			return nil, version.Number{}, errors.Annotatef(err, "cannot read %s", name)
+		}
+		for _, doc := range docs {
+			if doc.Tools != nil {
+				used[doc.Tools.Version] = true
+			}
+		}
+	}
+	return used, agentVersion, nil
+}
+
+// unusedTools returns the stored tools that no machine or unit agent
+// is running, and whose version is not the environment's
+// agent-version.
+func (st *State) unusedTools() ([]unusedBlob, error) {
+	used, agentVersion, err := st.usedTools()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	toolsStorage, err := st.ToolsStorage()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer toolsStorage.Close()
+	all, err := toolsStorage.AllMetadata()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read tools metadata")
+	}
+	var result []unusedBlob
+	for _, metadata := range all {
+		v := metadata.Version
+		if used[v] || v.Number == agentVersion {
+			continue
+		}
+		result = append(result, unusedBlob{
+			UnusedBlob: UnusedBlob{
+				EnvUUID: st.EnvironUUID(),
+				Kind:    ToolsBlob,
+				Name:    v.String(),
+				Size:    metadata.Size,
+			},
+			remove: func() error {
+				return st.removeUnusedTools(v)
+			},
+		})
+	}
+	return result, nil
+}
+
+// removeUnusedTools removes the tools with the given version, so long
+// as no agent has started running them and the environment has not
+// been set to upgrade to them since they were found to be unused. The
+// tools are not stored in documents that a transaction can assert on,
+// so the agents are checked again instead.
+func (st *State) removeUnusedTools(v version.Binary) error {
+	used, agentVersion, err := st.usedTools()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if used[v] || v.Number == agentVersion {
+		return errBlobInUse
+	}
+	toolsStorage, err := st.ToolsStorage()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer toolsStorage.Close()
+	if err := toolsStorage.RemoveTools(v); err != nil && !errors.IsNotFound(err) {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// usedImages returns the container kinds and series, as "kind/series",
+// that containers are running.
+func (st *State) usedImages() (map[string]bool, error) {
+	machines, closer := st.getCollection(machinesC)
+	var docs []struct {
+		Series        string `bson:"series"`
+		ContainerType string `bson:"containertype"`
+	}
+	err := machines.Find(bson.D{{"containertype", bson.D{{"$ne", ""}}}}).Select(bson.D{
+		{"series", 1}, {"containertype", 1},
+	}).All(&docs)
+	closer()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read machines")
+	}
+	used := make(map[string]bool)
+	for _, doc := range docs {
+		used[doc.ContainerType+"/"+doc.Series] = true
+	}
+	return used, nil
+}
+
+// unusedImages returns the cached container images whose series no
+// container of their kind is running.
+func (st *State) unusedImages() ([]unusedBlob, error) {
+	used, err := st.usedImages()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	images, err := st.ImageStorage().ListImages(imagestorage.ImageFilter{})
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read image metadata")
+	}
+	var result []unusedBlob
+	for _, metadata := range images {
+		metadata := metadata
+		if used[metadata.Kind+"/"+metadata.Series] {
+			continue
+		}
+		result = append(result, unusedBlob{
+			UnusedBlob: UnusedBlob{
+				EnvUUID: st.EnvironUUID(),
+				Kind:    ImageBlob,
+				Name:    fmt.Sprintf("%s/%s/%s", metadata.Kind, metadata.Series, metadata.Arch),
+				Size:    metadata.Size,
+			},
+			remove: func() error {
+				return st.removeUnusedImage(metadata)
+			},
+		})
+	}
+	return result, nil
+}
+
+// removeUnusedImage removes the given image, so long as no container
+// of its kind and series has been added since it was found to be
+// unused. As for tools, the containers are checked again.
+func (st *State) removeUnusedImage(metadata *imagestorage.Metadata) error {
+	used, err := st.usedImages()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if used[metadata.Kind+"/"+metadata.Series] {
+		return errBlobInUse
+	}
+	return st.ImageStorage().DeleteImage(metadata)
+}
@@ -0,0 +1,210 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/imagestorage"
+	"github.com/juju/juju/state/toolstorage"
+	"github.com/juju/juju/version"
+)
+
+type BlobGCSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&BlobGCSuite{})
+
+func (s *BlobGCSuite) collect(c *gc.C, gracePeriod time.Duration, dryRun bool) map[string]state.UnusedBlob {
+	blobs, err := s.State.CollectUnusedBlobs(gracePeriod, dryRun)
+	c.Assert(err, jc.ErrorIsNil)
+	result := make(map[string]state.UnusedBlob)
+	for _, blob := range blobs {
+		c.Check(blob.EnvUUID, gc.Equals, s.State.EnvironUUID())
+		result[string(blob.Kind)+" "+blob.Name] = blob
+	}
+	return result
+}
+
+func (s *BlobGCSuite) TestUnusedCharms(c *gc.C) {
+	wordpress := s.AddTestingCharm(c, "wordpress")
+	s.AddTestingService(c, "wordpress", wordpress)
+	mysql := s.AddTestingCharm(c, "mysql")
+
+	blobs := s.collect(c, time.Hour, false)
+	c.Assert(blobs, gc.HasLen, 1)
+	blob := blobs["charm "+mysql.String()]
+	c.Assert(blob.Kind, gc.Equals, state.CharmBlob)
+	c.Assert(blob.Expired, jc.IsFalse)
+	c.Assert(blob.Removed, jc.IsFalse)
+	_, err := s.State.Charm(mysql.URL())
+	c.Assert(err, jc.ErrorIsNil)
+
+	blobs = s.collect(c, 0, false)
+	blob = blobs["charm "+mysql.String()]
+	c.Assert(blob.Expired, jc.IsTrue)
+	c.Assert(blob.Removed, jc.IsTrue)
+	_, err = s.State.Charm(mysql.URL())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = s.State.Charm(wordpress.URL())
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *BlobGCSuite) TestGracePeriodStartsWhenFirstUnused(c *gc.C) {
+	mysql := s.AddTestingCharm(c, "mysql")
+	first := s.collect(c, time.Hour, false)["charm "+mysql.String()]
+	c.Assert(first.UnusedSince.IsZero(), jc.IsFalse)
+
+	second := s.collect(c, time.Hour, false)["charm "+mysql.String()]
+	c.Assert(second.UnusedSince.Equal(first.UnusedSince), jc.IsTrue)
+	c.Assert(second.Removed, jc.IsFalse)
+}
+
+func (s *BlobGCSuite) TestUsedAgainIsForgotten(c *gc.C) {
+	mysql := s.AddTestingCharm(c, "mysql")
+	s.collect(c, time.Hour, false)
+
+	service := s.AddTestingService(c, "mysql", mysql)
+	c.Assert(s.collect(c, 0, false), gc.HasLen, 0)
+
+	err := service.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	blob := s.collect(c, time.Hour, false)["charm "+mysql.String()]
+	c.Assert(blob.Removed, jc.IsFalse)
+	c.Assert(blob.Expired, jc.IsFalse)
+}
+
+func (s *BlobGCSuite) TestDryRun(c *gc.C) {
+	mysql := s.AddTestingCharm(c, "mysql")
+	blob := s.collect(c, 0, true)["charm "+mysql.String()]
+	c.Assert(blob.Expired, jc.IsTrue)
+	c.Assert(blob.Removed, jc.IsFalse)
+	_, err := s.State.Charm(mysql.URL())
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Nothing was recorded, so the grace period has not started.
+	blob = s.collect(c, time.Hour, false)["charm "+mysql.String()]
+	c.Assert(blob.Expired, jc.IsFalse)
+}
+
+func (s *BlobGCSuite) TestUnusedTools(c *gc.C) {
+	storage, err := s.State.ToolsStorage()
+	c.Assert(err, jc.ErrorIsNil)
+	defer storage.Close()
+
+	current := version.Current
+	old := current
+	old.Major--
+	for _, v := range []version.Binary{current, old} {
+		err := storage.AddTools(strings.NewReader("tools"), toolstorage.Metadata{Version: v, Size: 5, SHA256: "hash"})
+		c.Assert(err, jc.ErrorIsNil)
+	}
+
+	blobs := s.collect(c, 0, false)
+	c.Assert(blobs, gc.HasLen, 1)
+	blob := blobs["tools "+old.String()]
+	c.Assert(blob.Size, gc.Equals, int64(5))
+	c.Assert(blob.Removed, jc.IsTrue)
+	_, err = storage.Metadata(old)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = storage.Metadata(current)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *BlobGCSuite) TestToolsUsedAgainNotRemoved(c *gc.C) {
+	storage, err := s.State.ToolsStorage()
+	c.Assert(err, jc.ErrorIsNil)
+	defer storage.Close()
+	old := version.Current
+	old.Major--
+	err = storage.AddTools(strings.NewReader("tools"), toolstorage.Metadata{Version: old, Size: 5, SHA256: "hash"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	// An agent starts running the tools after they were found to be
+	// unused, but before they are removed.
+	defer state.SetBeforeHooks(c, s.State, func() {
+		machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, jc.ErrorIsNil)
+		err = machine.SetAgentVersion(old)
+		c.Assert(err, jc.ErrorIsNil)
+	}).Check()
+	blobs, err := state.CollectEnvironUnusedBlobs(s.State, 0, false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(blobs, gc.HasLen, 1)
+	c.Assert(blobs[0].Expired, jc.IsTrue)
+	c.Assert(blobs[0].Removed, jc.IsFalse)
+	_, err = storage.Metadata(old)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *BlobGCSuite) TestUnusedImages(c *gc.C) {
+	host, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, host.Id(), instance.LXC)
+	c.Assert(err, jc.ErrorIsNil)
+
+	storage := s.State.ImageStorage()
+	for _, series := range []string{"quantal", "precise"} {
+		err := storage.AddImage(strings.NewReader("image"), &imagestorage.Metadata{
+			EnvUUID: s.State.EnvironUUID(),
+			Kind:    "lxc",
+			Series:  series,
+			Arch:    "amd64",
+			Size:    5,
+			SHA256:  "hash",
+		})
+		c.Assert(err, jc.ErrorIsNil)
+	}
+
+	blobs := s.collect(c, 0, false)
+	c.Assert(blobs, gc.HasLen, 1)
+	c.Assert(blobs["image lxc/precise/amd64"].Removed, jc.IsTrue)
+	images, err := storage.ListImages(imagestorage.ImageFilter{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(images, gc.HasLen, 1)
+	c.Assert(images[0].Series, gc.Equals, "quantal")
+}
+
+func (s *BlobGCSuite) TestImageUsedAgainNotRemoved(c *gc.C) {
+	storage := s.State.ImageStorage()
+	err := storage.AddImage(strings.NewReader("image"), &imagestorage.Metadata{
+		EnvUUID: s.State.EnvironUUID(),
+		Kind:    "lxc",
+		Series:  "precise",
+		Arch:    "amd64",
+		Size:    5,
+		SHA256:  "hash",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	// A container of the image's series is added after the image was
+	// found to be unused, but before it is removed.
+	defer state.SetBeforeHooks(c, s.State, func() {
+		host, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, jc.ErrorIsNil)
+		_, err = s.State.AddMachineInsideMachine(state.MachineTemplate{
+			Series: "precise",
+			Jobs:   []state.MachineJob{state.JobHostUnits},
+		}, host.Id(), instance.LXC)
+		c.Assert(err, jc.ErrorIsNil)
+	}).Check()
+	blobs, err := state.CollectEnvironUnusedBlobs(s.State, 0, false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(blobs, gc.HasLen, 1)
+	c.Assert(blobs[0].Removed, jc.IsFalse)
+	images, err := storage.ListImages(imagestorage.ImageFilter{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(images, gc.HasLen, 1)
+}
@@ -0,0 +1,525 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils/set"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/cert"
+)
+
+// caRotationKey identifies the document in the stateServers collection
+// that records a CA rotation in progress.
+const caRotationKey = "caRotation"
+
+// caRotationAcksKey identifies the document in the stateServers
+// collection that records the agents that have fetched the CA
+// certificates trusted during a CA rotation. It is kept apart from the
+// rotation document so that recording an agent does not notify the
+// watchers of the trusted CA certificates.
+const caRotationAcksKey = "caRotationAcks"
+
+// CARotationPhase describes how far a CA rotation has progressed.
+type CARotationPhase string
+
+const (
+	// CARotationTrusting is the first phase of a CA rotation, in which
+	// agents are given a bundle holding both the old and the new CA
+	// certificates to trust.
+	CARotationTrusting CARotationPhase = "trusting"
+
+	// CARotationReissuing is the second phase of a CA rotation, in
+	// which the state servers reissue their certificates, signed by
+	// the new CA. Agents continue to trust both CAs.
+	CARotationReissuing CARotationPhase = "reissuing"
+
+	// CARotationFinished is reported once the old CA has been retired
+	// and the new CA certificate has replaced it in the configuration
+	// of every environment.
+	CARotationFinished CARotationPhase = "finished"
+)
+
+// CARotation records the progress of a rotation of the CA that signs
+// the certificates of the state servers.
+type CARotation struct {
+	doc caRotationDoc
+}
+
+// caRotationAcksDoc records, as "<env-uuid>:<agent-tag>", the agents
+// that have fetched the CA certificates trusted during a CA rotation,
+// and the ids of the state server machines that have reissued their
+// certificates with the new CA.
+type caRotationAcksDoc struct {
+	DocID    string   `bson:"_id"`
+	Agents   []string `bson:"agents"`
+	Reissued []string `bson:"reissued"`
+}
+
+type caRotationDoc struct {
+	DocID           string          `bson:"_id"`
+	Phase           CARotationPhase `bson:"phase"`
+	OldCACert       string          `bson:"oldcacert"`
+	NewCACert       string          `bson:"newcacert"`
+	NewCAPrivateKey string          `bson:"newcaprivatekey"`
+	Started         time.Time       `bson:"started"`
+}
+
+// Phase returns how far the rotation has progressed.
+func (r *CARotation) Phase() CARotationPhase {
+	return r.doc.Phase
+}
+
+// OldCACert returns the PEM-encoded CA certificate being retired.
+func (r *CARotation) OldCACert() string {
+	return r.doc.OldCACert
+}
+
+// NewCACert returns the PEM-encoded CA certificate replacing the old
+// one.
+func (r *CARotation) NewCACert() string {
+	return r.doc.NewCACert
+}
+
+// NewCAPrivateKey returns the PEM-encoded private key of the new CA.
+func (r *CARotation) NewCAPrivateKey() string {
+	return r.doc.NewCAPrivateKey
+}
+
+// Started returns the time at which the rotation was started.
+func (r *CARotation) Started() time.Time {
+	return r.doc.Started
+}
+
+// TrustedCACert returns the PEM-encoded bundle of CA certificates that
+// agents must trust while the rotation is in progress.
+func (r *CARotation) TrustedCACert() string {
+	return r.doc.OldCACert + r.doc.NewCACert
+}
+
+// StartCARotation starts replacing the CA that signs the certificates
+// of the state servers with the given CA certificate and private key.
+// Agents are first told to trust both the old and the new CA; call
+// AdvanceCARotation to move on once they have all done so.
+func (st *State) StartCARotation(newCACert, newCAPrivateKey string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot start CA rotation")
+	if _, _, err := cert.ParseCertAndKey(newCACert, newCAPrivateKey); err != nil {
+		return errors.NotValidf("CA certificate and key (%v)", err)
+	}
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	oldCACert, ok := cfg.CACert()
+	if !ok {
+		return errors.New("environment has no CA certificate")
+	}
+	ops := []txn.Op{{
+		C:      stateServersC,
+		Id:     caRotationKey,
+		Assert: txn.DocMissing,
+		Insert: &caRotationDoc{
+			Phase:           CARotationTrusting,
+			OldCACert:       oldCACert,
+			NewCACert:       newCACert,
+			NewCAPrivateKey: newCAPrivateKey,
+			Started:         nowToTheSecond(),
+		},
+	}, {
+		C:      stateServersC,
+		Id:     caRotationAcksKey,
+		Assert: txn.DocMissing,
+		Insert: &caRotationAcksDoc{Agents: []string{}},
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.AlreadyExistsf("CA rotation")
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// CARotation returns the CA rotation in progress, or an error
+// satisfying errors.IsNotFound if there is none.
+func (st *State) CARotation() (*CARotation, error) {
+	stateServers, closer := st.getCollection(stateServersC)
+	defer closer()
+
+	var doc caRotationDoc
+	err := stateServers.FindId(caRotationKey).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("CA rotation")
+	} else if err != nil {
+		return nil, errors.Annotate(err, "cannot get CA rotation")
+	}
+	return &CARotation{doc}, nil
+}
+
+// AdvanceCARotation moves the CA rotation in progress on to its next
+// phase, and returns that phase. When it advances past
+// CARotationReissuing, the new CA certificate replaces the old one in
+// the configuration of every environment, the new CA private key is
+// stored in the state serving info, and the rotation is finished.
+//
+// Unless force is true, the rotation is only advanced once the agent
+// of every machine and unit that is not dead, in every environment,
+// has fetched the CA certificates trusted during the rotation; and it
+// is only finished once every state server has reissued its
+// certificate with the new CA, so that none is left serving one that
+// agents no longer trust.
+func (st *State) AdvanceCARotation(force bool) (_ CARotationPhase, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot advance CA rotation")
+	rotation, err := st.CARotation()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if !force {
+		pending, err := st.caRotationPendingAgents()
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		var notReissued []string
+		if rotation.Phase() == CARotationReissuing {
+			notReissued, err = st.caRotationNotReissued()
+			if err != nil {
+				return "", errors.Trace(err)
+			}
+		}
+		if len(pending) > 0 || len(notReissued) > 0 {
+			return "", &CARotationPendingError{
+				Agents:       pending,
+				StateServers: notReissued,
+			}
+		}
+	}
+	switch rotation.Phase() {
+	case CARotationTrusting:
+		ops := []txn.Op{{
+			C:      stateServersC,
+			Id:     caRotationKey,
+			Assert: bson.D{{"phase", CARotationTrusting}},
+			Update: bson.D{{"$set", bson.D{{"phase", CARotationReissuing}}}},
+		}}
+		if err := st.runTransaction(ops); err == txn.ErrAborted {
+			return "", errors.New("CA rotation changed concurrently")
+		} else if err != nil {
+			return "", errors.Trace(err)
+		}
+		return CARotationReissuing, nil
+	case CARotationReissuing:
+		if err := st.finishCARotation(rotation); err != nil {
+			return "", errors.Trace(err)
+		}
+		return CARotationFinished, nil
+	}
+	return "", errors.Errorf("unknown CA rotation phase %q", rotation.Phase())
+}
+
+// finishCARotation retires the old CA of the given rotation.
+func (st *State) finishCARotation(rotation *CARotation) error {
+	environments, closer := st.getCollection(environmentsC)
+	var envDocs []environmentDoc
+	err := environments.Find(nil).Select(bson.D{{"_id", 1}}).All(&envDocs)
+	closer()
+	if err != nil {
+		return errors.Annotate(err, "cannot get environments")
+	}
+	// Settings documents may only be updated by a State for their own
+	// environment, so each environment's config is updated in turn.
+	// Doing so more than once is harmless, so a failure part way
+	// through is fixed by advancing the rotation again.
+	attrs := map[string]interface{}{"ca-cert": rotation.NewCACert()}
+	for _, envDoc := range envDocs {
+		envSt, err := st.ForEnviron(names.NewEnvironTag(envDoc.UUID))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		err = envSt.UpdateEnvironConfig(attrs, nil, nil)
+		envSt.Close()
+		if err != nil {
+			return errors.Annotatef(err, "cannot update config of environment %q", envDoc.UUID)
+		}
+	}
+	ops := []txn.Op{{
+		C:      stateServersC,
+		Id:     caRotationKey,
+		Assert: bson.D{{"phase", CARotationReissuing}},
+		Remove: true,
+	}, {
+		C:      stateServersC,
+		Id:     caRotationAcksKey,
+		Remove: true,
+	}, {
+		C:      stateServersC,
+		Id:     stateServingInfoKey,
+		Update: bson.D{{"$set", bson.D{{"caprivatekey", rotation.NewCAPrivateKey()}}}},
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.New("CA rotation changed concurrently")
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// CARotationPendingError is returned by AdvanceCARotation when agents
+// have yet to fetch the CA certificates trusted during the rotation,
+// or state servers have yet to reissue their certificates.
+type CARotationPendingError struct {
+	// Agents holds the tags of the agents, qualified by the UUIDs of
+	// their environments, as "<env-uuid>:<agent-tag>".
+	Agents []string
+
+	// StateServers holds the ids of the state server machines that
+	// have not reissued their certificates with the new CA.
+	StateServers []string
+}
+
+func (e *CARotationPendingError) Error() string {
+	var msgs []string
+	if len(e.Agents) > 0 {
+		msgs = append(msgs, fmt.Sprintf(
+			"%d agents have not fetched the new CA certificate: %s", len(e.Agents), summarize(e.Agents),
+		))
+	}
+	if len(e.StateServers) > 0 {
+		msgs = append(msgs, fmt.Sprintf(
+			"%d state servers have not reissued their certificates: %s", len(e.StateServers), summarize(e.StateServers),
+		))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// summarize returns the first few of the given items, separated by
+// commas, followed by an ellipsis if any were left out.
+func summarize(items []string) string {
+	const maxShown = 5
+	if len(items) <= maxShown {
+		return strings.Join(items, ", ")
+	}
+	return strings.Join(items[:maxShown], ", ") + ", ..."
+}
+
+// IsCARotationPendingError reports whether or not the error is a
+// CARotationPendingError.
+func IsCARotationPendingError(err error) bool {
+	_, ok := errors.Cause(err).(*CARotationPendingError)
+	return ok
+}
+
+// caRotationAgentKey returns the key that identifies the agent with
+// the given tag in the environment with the given UUID, as recorded in
+// a caRotationAcksDoc.
+func caRotationAgentKey(envUUID string, agent names.Tag) string {
+	return envUUID + ":" + agent.String()
+}
+
+// AcknowledgeTrustedCACert records that the agent with the given tag
+// has fetched the given CA certificates. If a CA rotation is in
+// progress and they include its new CA certificate, the agent no
+// longer holds up AdvanceCARotation.
+func (st *State) AcknowledgeTrustedCACert(agent names.Tag, caCert string) error {
+	rotation, err := st.CARotation()
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	if !strings.Contains(caCert, rotation.NewCACert()) {
+		return nil
+	}
+	stateServers, closer := st.getCollection(stateServersC)
+	defer closer()
+	key := caRotationAgentKey(st.EnvironUUID(), agent)
+	n, err := stateServers.Find(bson.D{{"_id", caRotationAcksKey}, {"agents", key}}).Count()
+	if err != nil {
+		return errors.Annotate(err, "cannot read CA rotation")
+	}
+	if n > 0 {
+		return nil
+	}
+	ops := []txn.Op{{
+		C:      stateServersC,
+		Id:     caRotationAcksKey,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$addToSet", bson.D{{"agents", key}}}},
+	}}
+	if err := st.runTransaction(ops); err != nil && err != txn.ErrAborted {
+		// An aborted transaction means the rotation has finished.
+		return errors.Annotatef(err, "cannot record trusted CA certificate of %s", agent)
+	}
+	return nil
+}
+
+// AcknowledgeReissuedCert records that the state server machine with
+// the given id now serves a certificate signed by the CA with the
+// given certificate. If a CA rotation is reissuing certificates with
+// that CA, the machine no longer holds up AdvanceCARotation.
+func (st *State) AcknowledgeReissuedCert(machineId, caCert string) error {
+	rotation, err := st.CARotation()
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	if rotation.Phase() != CARotationReissuing || rotation.NewCACert() != caCert {
+		return nil
+	}
+	ops := []txn.Op{{
+		C:  stateServersC,
+		Id: caRotationKey,
+		Assert: bson.D{
+			{"phase", CARotationReissuing},
+			{"newcacert", caCert},
+		},
+	}, {
+		C:      stateServersC,
+		Id:     caRotationAcksKey,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$addToSet", bson.D{{"reissued", machineId}}}},
+	}}
+	if err := st.runTransaction(ops); err != nil && err != txn.ErrAborted {
+		// An aborted transaction means the rotation has moved on.
+		return errors.Annotatef(err, "cannot record reissued certificate of machine %s", machineId)
+	}
+	return nil
+}
+
+// caRotationNotReissued returns the ids of the state server machines
+// that have not reissued their certificates with the new CA.
+func (st *State) caRotationNotReissued() ([]string, error) {
+	info, err := st.StateServerInfo()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	stateServers, closer := st.getCollection(stateServersC)
+	defer closer()
+	var acks caRotationAcksDoc
+	err = stateServers.FindId(caRotationAcksKey).One(&acks)
+	if err != nil && err != mgo.ErrNotFound {
+		return nil, errors.Annotate(err, "cannot read CA rotation")
+	}
+	reissued := set.NewStrings(acks.Reissued...)
+	var pending []string
+	for _, id := range info.MachineIds {
+		if !reissued.Contains(id) {
+			pending = append(pending, id)
+		}
+	}
+	sort.Strings(pending)
+	return pending, nil
+}
+
+// caRotationPendingAgents returns the agents, as "<env-uuid>:<agent-tag>",
+// of the machines and units in every environment that are not dead and
+// have not fetched the CA certificates trusted during the rotation.
+func (st *State) caRotationPendingAgents() ([]string, error) {
+	stateServers, closer := st.getCollection(stateServersC)
+	defer closer()
+	var acks caRotationAcksDoc
+	err := stateServers.FindId(caRotationAcksKey).One(&acks)
+	if err != nil && err != mgo.ErrNotFound {
+		return nil, errors.Annotate(err, "cannot read CA rotation")
+	}
+	acked := make(map[string]bool)
+	for _, key := range acks.Agents {
+		acked[key] = true
+	}
+
+	notDead := bson.D{{"life", bson.D{{"$ne", Dead}}}}
+	var pending []string
+	machines, closer := st.getRawCollection(machinesC)
+	defer closer()
+	var mdocs []machineDoc
+	err = machines.Find(notDead).Select(bson.D{{"env-uuid", 1}, {"machineid", 1}}).All(&mdocs)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read machines")
+	}
+	for _, doc := range mdocs {
+		key := caRotationAgentKey(doc.EnvUUID, names.NewMachineTag(doc.Id))
+		if !acked[key] {
+			pending = append(pending, key)
+		}
+	}
+	units, closer := st.getRawCollection(unitsC)
+	defer closer()
+	var udocs []unitDoc
+	err = units.Find(notDead).Select(bson.D{{"env-uuid", 1}, {"name", 1}}).All(&udocs)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read units")
+	}
+	for _, doc := range udocs {
+		key := caRotationAgentKey(doc.EnvUUID, names.NewUnitTag(doc.Name))
+		if !acked[key] {
+			pending = append(pending, key)
+		}
+	}
+	sort.Strings(pending)
+	return pending, nil
+}
+
+// ReissuingCA returns the PEM-encoded certificate and private key of
+// the new CA while a CA rotation is reissuing the certificates of the
+// state servers. The returned ok is false at any other time.
+func (st *State) ReissuingCA() (caCert, caPrivateKey string, ok bool, err error) {
+	rotation, err := st.CARotation()
+	if errors.IsNotFound(err) {
+		return "", "", false, nil
+	} else if err != nil {
+		return "", "", false, errors.Trace(err)
+	}
+	if rotation.Phase() != CARotationReissuing {
+		return "", "", false, nil
+	}
+	return rotation.NewCACert(), rotation.NewCAPrivateKey(), true, nil
+}
+
+// TrustedCACert returns the PEM-encoded CA certificates that agents
+// must trust when connecting to the state servers. While the CA is
+// being rotated, it holds both the old and the new CA certificates.
+// Once an environment has started being exported to another state
+// server, its agents also trust that state server's CA.
+func (st *State) TrustedCACert() (string, error) {
+	env, err := st.Environment()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	rotation, err := st.CARotation()
+	if err == nil {
+		return rotation.TrustedCACert() + env.doc.MigrationCACert, nil
+	} else if !errors.IsNotFound(err) {
+		return "", errors.Trace(err)
+	}
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	caCert, _ := cfg.CACert()
+	return caCert + env.doc.MigrationCACert, nil
+}
+
+// WatchTrustedCACert returns a NotifyWatcher that notifies when the CA
+// certificates returned by TrustedCACert may have changed.
+func (st *State) WatchTrustedCACert() NotifyWatcher {
+	return newDocWatcher(st, []docKey{
+		{stateServersC, caRotationKey},
+		{settingsC, st.docID(environGlobalKey)},
+		{environmentsC, st.EnvironUUID()},
+	})
+}
+
+// WatchCARotation returns a NotifyWatcher that notifies when a CA
+// rotation starts, advances or finishes.
+func (st *State) WatchCARotation() NotifyWatcher {
+	return newEntityWatcher(st, stateServersC, caRotationKey)
+}
@@ -0,0 +1,256 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+type CARotationSuite struct {
+	ConnSuite
+	newCACert       string
+	newCAPrivateKey string
+}
+
+var _ = gc.Suite(&CARotationSuite{})
+
+func (s *CARotationSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.newCACert, s.newCAPrivateKey, err = cert.NewCA("testenv", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *CARotationSuite) TestStartCARotation(c *gc.C) {
+	_, err := s.State.CARotation()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	err = s.State.StartCARotation(s.newCACert, s.newCAPrivateKey)
+	c.Assert(err, jc.ErrorIsNil)
+
+	rotation, err := s.State.CARotation()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(rotation.Phase(), gc.Equals, state.CARotationTrusting)
+	c.Assert(rotation.OldCACert(), gc.Equals, testing.CACert)
+	c.Assert(rotation.NewCACert(), gc.Equals, s.newCACert)
+	c.Assert(rotation.NewCAPrivateKey(), gc.Equals, s.newCAPrivateKey)
+	c.Assert(rotation.Started().IsZero(), jc.IsFalse)
+
+	trusted, err := s.State.TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(trusted, gc.Equals, testing.CACert+s.newCACert)
+}
+
+func (s *CARotationSuite) TestStartCARotationTwice(c *gc.C) {
+	err := s.State.StartCARotation(s.newCACert, s.newCAPrivateKey)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.StartCARotation(s.newCACert, s.newCAPrivateKey)
+	c.Assert(err, gc.ErrorMatches, "cannot start CA rotation: CA rotation already exists")
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+}
+
+func (s *CARotationSuite) TestStartCARotationInvalid(c *gc.C) {
+	err := s.State.StartCARotation(s.newCACert, testing.CAKey)
+	c.Assert(err, gc.ErrorMatches, "cannot start CA rotation: CA certificate and key .* not valid")
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+}
+
+func (s *CARotationSuite) TestTrustedCACertWithoutRotation(c *gc.C) {
+	trusted, err := s.State.TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(trusted, gc.Equals, testing.CACert)
+}
+
+func (s *CARotationSuite) TestAdvanceCARotation(c *gc.C) {
+	err := s.State.SetStateServingInfo(state.StateServingInfo{
+		StatePort:    1,
+		APIPort:      2,
+		Cert:         testing.ServerCert,
+		PrivateKey:   testing.ServerKey,
+		CAPrivateKey: testing.CAKey,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	otherSt := s.factory.MakeEnvironment(c, nil)
+	defer otherSt.Close()
+
+	_, err = s.State.AdvanceCARotation(false)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	err = s.State.StartCARotation(s.newCACert, s.newCAPrivateKey)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, _, ok, err := s.State.ReissuingCA()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ok, jc.IsFalse)
+
+	phase, err := s.State.AdvanceCARotation(false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(phase, gc.Equals, state.CARotationReissuing)
+	caCert, caPrivateKey, ok, err := s.State.ReissuingCA()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(caCert, gc.Equals, s.newCACert)
+	c.Assert(caPrivateKey, gc.Equals, s.newCAPrivateKey)
+	rotation, err := s.State.CARotation()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(rotation.Phase(), gc.Equals, state.CARotationReissuing)
+
+	phase, err = s.State.AdvanceCARotation(false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(phase, gc.Equals, state.CARotationFinished)
+	_, _, ok, err = s.State.ReissuingCA()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ok, jc.IsFalse)
+	_, err = s.State.CARotation()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	for _, st := range []*state.State{s.State, otherSt} {
+		cfg, err := st.EnvironConfig()
+		c.Assert(err, jc.ErrorIsNil)
+		caCert, _ := cfg.CACert()
+		c.Assert(caCert, gc.Equals, s.newCACert)
+		trusted, err := st.TrustedCACert()
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(trusted, gc.Equals, s.newCACert)
+	}
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.CAPrivateKey, gc.Equals, s.newCAPrivateKey)
+}
+
+func (s *CARotationSuite) TestAdvanceCARotationWaitsForAgents(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	otherSt := s.factory.MakeEnvironment(c, nil)
+	defer otherSt.Close()
+	u := factory.NewFactory(otherSt).MakeUnit(c, nil)
+	err = s.State.StartCARotation(s.newCACert, s.newCAPrivateKey)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Neither agent has fetched the new CA certificate.
+	otherMachineId, err := u.AssignedMachineId()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AdvanceCARotation(false)
+	c.Assert(err, gc.ErrorMatches, "cannot advance CA rotation: 3 agents have not fetched the new CA certificate: .*")
+	c.Assert(err, jc.Satisfies, state.IsCARotationPendingError)
+	c.Assert(errors.Cause(err).(*state.CARotationPendingError).Agents, jc.SameContents, []string{
+		s.State.EnvironUUID() + ":" + m.Tag().String(),
+		otherSt.EnvironUUID() + ":" + names.NewMachineTag(otherMachineId).String(),
+		otherSt.EnvironUUID() + ":" + u.Tag().String(),
+	})
+
+	// Fetching certificates that do not include the new CA does
+	// not count.
+	err = s.State.AcknowledgeTrustedCACert(m.Tag(), testing.CACert)
+	c.Assert(err, jc.ErrorIsNil)
+	trusted, err := s.State.TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.AcknowledgeTrustedCACert(m.Tag(), trusted)
+	c.Assert(err, jc.ErrorIsNil)
+	err = otherSt.AcknowledgeTrustedCACert(names.NewMachineTag(otherMachineId), trusted)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AdvanceCARotation(false)
+	c.Assert(err, gc.ErrorMatches, "cannot advance CA rotation: 1 agents have not fetched the new CA certificate: "+otherSt.EnvironUUID()+":"+u.Tag().String())
+
+	err = otherSt.AcknowledgeTrustedCACert(u.Tag(), trusted)
+	c.Assert(err, jc.ErrorIsNil)
+	phase, err := s.State.AdvanceCARotation(false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(phase, gc.Equals, state.CARotationReissuing)
+}
+
+func (s *CARotationSuite) TestAdvanceCARotationWaitsForReissue(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.StartCARotation(s.newCACert, s.newCAPrivateKey)
+	c.Assert(err, jc.ErrorIsNil)
+	trusted, err := s.State.TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.AcknowledgeTrustedCACert(m.Tag(), trusted)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Certificates reissued before the rotation reaches the
+	// reissuing phase do not count.
+	err = s.State.AcknowledgeReissuedCert(m.Id(), s.newCACert)
+	c.Assert(err, jc.ErrorIsNil)
+	phase, err := s.State.AdvanceCARotation(false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(phase, gc.Equals, state.CARotationReissuing)
+
+	_, err = s.State.AdvanceCARotation(false)
+	c.Assert(err, gc.ErrorMatches, "cannot advance CA rotation: 1 state servers have not reissued their certificates: "+m.Id())
+	c.Assert(err, jc.Satisfies, state.IsCARotationPendingError)
+
+	// Nor do certificates signed by another CA.
+	err = s.State.AcknowledgeReissuedCert(m.Id(), testing.CACert)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AdvanceCARotation(false)
+	c.Assert(err, jc.Satisfies, state.IsCARotationPendingError)
+
+	err = s.State.AcknowledgeReissuedCert(m.Id(), s.newCACert)
+	c.Assert(err, jc.ErrorIsNil)
+	phase, err = s.State.AdvanceCARotation(false)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(phase, gc.Equals, state.CARotationFinished)
+}
+
+func (s *CARotationSuite) TestAdvanceCARotationForce(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.StartCARotation(s.newCACert, s.newCAPrivateKey)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.State.AdvanceCARotation(false)
+	c.Assert(err, jc.Satisfies, state.IsCARotationPendingError)
+	phase, err := s.State.AdvanceCARotation(true)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(phase, gc.Equals, state.CARotationReissuing)
+}
+
+func (s *CARotationSuite) TestWatchTrustedCACert(c *gc.C) {
+	w := s.State.WatchTrustedCACert()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	err := s.State.StartCARotation(s.newCACert, s.newCAPrivateKey)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{"ca-cert": s.newCACert}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
+func (s *CARotationSuite) TestWatchCARotation(c *gc.C) {
+	w := s.State.WatchCARotation()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	err := s.State.StartCARotation(s.newCACert, s.newCAPrivateKey)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+
+	_, err = s.State.AdvanceCARotation(false)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
@@ -44,6 +44,10 @@ type charmDoc struct {
 	StoragePath   string `bson:"storagepath"`
 	PendingUpload bool   `bson:"pendingupload"`
 	Placeholder   bool   `bson:"placeholder"`
+
+	// Signer identifies the owner of the key that signed the charm
+	// archive, if its signature was verified when it was added.
+	Signer string `bson:"signer,omitempty"`
 }
 
 // insertCharmOps returns the txn operations necessary to insert the supplied
@@ -102,7 +106,7 @@ func insertAnyCharmOps(cdoc *charmDoc) ([]txn.Op, error) {
 // document with the supplied data, so long as the supplied assert still holds
 // true.
 func updateCharmOps(
-	st *State, ch charm.Charm, curl *charm.URL, storagePath, bundleSha256 string, assert bson.D,
+	st *State, ch charm.Charm, curl *charm.URL, storagePath, bundleSha256, signer string, assert bson.D,
 ) ([]txn.Op, error) {
 
 	updateFields := bson.D{{"$set", bson.D{
@@ -114,6 +118,7 @@ func updateCharmOps(
 		{"bundlesha256", bundleSha256},
 		{"pendingupload", false},
 		{"placeholder", false},
+		{"signer", signer},
 	}}}
 	return []txn.Op{{
 		C:      charmsC,
@@ -280,6 +285,12 @@ func (c *Charm) BundleSha256() string {
 	return c.doc.BundleSha256
 }
 
+// Signer returns the identity of the owner of the key that signed the
+// charm archive, or the empty string if no signature was verified.
+func (c *Charm) Signer() string {
+	return c.doc.Signer
+}
+
 // IsUploaded returns whether the charm has been uploaded to the
 // environment storage.
 func (c *Charm) IsUploaded() bool {
@@ -0,0 +1,218 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils"
+	"gopkg.in/juju/charm.v5"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/state/storage"
+)
+
+// RepositoryCharm holds information about a charm archive published in
+// the controller's charm repository, from which environments without
+// access to the charm store may add cs: charms.
+type RepositoryCharm struct {
+	// URL is the cs: URL under which the archive was published.
+	URL *charm.URL
+
+	// Size is the size of the archive in bytes.
+	Size int64
+
+	// SHA256 is the hex-encoded SHA-256 hash of the archive.
+	SHA256 string
+
+	// Published is the time the archive was published.
+	Published time.Time
+
+	// PublishedBy is the name of the user that published the archive.
+	PublishedBy string
+
+	storagePath string
+}
+
+// repositoryCharmDoc records a charm archive published in the
+// controller's charm repository. The repository is shared by all
+// environments, so the documents are not environment specific.
+type repositoryCharmDoc struct {
+	DocID       string    `bson:"_id"`
+	User        string    `bson:"user"`
+	Name        string    `bson:"name"`
+	Series      string    `bson:"series"`
+	Revision    int       `bson:"revision"`
+	Size        int64     `bson:"size"`
+	SHA256      string    `bson:"sha256"`
+	StoragePath string    `bson:"storagepath"`
+	Published   time.Time `bson:"published"`
+	PublishedBy string    `bson:"publishedby"`
+}
+
+func (doc *repositoryCharmDoc) repositoryCharm() RepositoryCharm {
+	return RepositoryCharm{
+		URL: &charm.URL{
+			Schema:   "cs",
+			User:     doc.User,
+			Name:     doc.Name,
+			Series:   doc.Series,
+			Revision: doc.Revision,
+		},
+		Size:        doc.Size,
+		SHA256:      doc.SHA256,
+		Published:   doc.Published,
+		PublishedBy: doc.PublishedBy,
+		storagePath: doc.StoragePath,
+	}
+}
+
+// validateRepositoryCharmURL returns an error if the given URL cannot
+// identify a charm archive in the repository.
+func validateRepositoryCharmURL(curl *charm.URL) error {
+	if curl.Schema != "cs" {
+		return errors.NotValidf("charm URL %q with schema other than cs", curl)
+	}
+	if curl.Revision < 0 {
+		return errors.NotValidf("charm URL %q without revision", curl)
+	}
+	return nil
+}
+
+// repositoryStorage returns the storage holding the repository's charm
+// archives: that of the state server environment.
+func (st *State) repositoryStorage() storage.Storage {
+	return storage.NewStorage(st.serverTag.Id(), st.MongoSession())
+}
+
+// PublishRepositoryCharm adds a charm archive to the controller's charm
+// repository under the given cs: URL, which must include a revision.
+// The archive is read from r, which must supply exactly size bytes
+// whose SHA-256 hash matches the given hex-encoded hash. A published
+// revision cannot be replaced; an AlreadyExists error is returned if
+// the URL is already taken.
+func (st *State) PublishRepositoryCharm(curl *charm.URL, r io.Reader, size int64, hash string, publishedBy names.UserTag) (_ RepositoryCharm, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot publish charm %q", curl)
+	if err := validateRepositoryCharmURL(curl); err != nil {
+		return RepositoryCharm{}, errors.Trace(err)
+	}
+
+	uuid, err := utils.NewUUID()
+	if err != nil {
+		return RepositoryCharm{}, errors.Trace(err)
+	}
+	storagePath := fmt.Sprintf("charmrepository/%s-%s", curl, uuid)
+	stor := st.repositoryStorage()
+	hasher := sha256.New()
+	if err := stor.Put(storagePath, io.TeeReader(r, hasher), size); err != nil {
+		return RepositoryCharm{}, errors.Annotate(err, "cannot store charm archive")
+	}
+	removeArchive := true
+	defer func() {
+		if removeArchive {
+			if err := stor.Remove(storagePath); err != nil {
+				logger.Errorf("cannot remove archive of charm %q: %v", curl, err)
+			}
+		}
+	}()
+	if got := hex.EncodeToString(hasher.Sum(nil)); got != hash {
+		return RepositoryCharm{}, errors.Errorf("SHA-256 hash mismatch: expected %q, got %q", hash, got)
+	}
+
+	doc := &repositoryCharmDoc{
+		DocID:       curl.String(),
+		User:        curl.User,
+		Name:        curl.Name,
+		Series:      curl.Series,
+		Revision:    curl.Revision,
+		Size:        size,
+		SHA256:      hash,
+		StoragePath: storagePath,
+		Published:   nowToTheSecond(),
+		PublishedBy: publishedBy.Name(),
+	}
+	ops := []txn.Op{{
+		C:      repositoryCharmsC,
+		Id:     doc.DocID,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return RepositoryCharm{}, errors.AlreadyExistsf("charm")
+	} else if err != nil {
+		return RepositoryCharm{}, errors.Trace(err)
+	}
+	removeArchive = false
+	return doc.repositoryCharm(), nil
+}
+
+// RepositoryCharm returns the charm archive published in the
+// controller's charm repository under the given URL.
+func (st *State) RepositoryCharm(curl *charm.URL) (RepositoryCharm, error) {
+	if err := validateRepositoryCharmURL(curl); err != nil {
+		return RepositoryCharm{}, errors.Trace(err)
+	}
+	coll, closer := st.getRawCollection(repositoryCharmsC)
+	defer closer()
+
+	var doc repositoryCharmDoc
+	err := coll.FindId(curl.String()).One(&doc)
+	if err == mgo.ErrNotFound {
+		return RepositoryCharm{}, errors.NotFoundf("charm %q in repository", curl)
+	} else if err != nil {
+		return RepositoryCharm{}, errors.Annotatef(err, "cannot get charm %q from repository", curl)
+	}
+	return doc.repositoryCharm(), nil
+}
+
+// RepositoryCharmRevisions returns the charm archives published in the
+// controller's charm repository that match the given reference, which
+// may leave out the series and revision, sorted by series and revision.
+func (st *State) RepositoryCharmRevisions(ref *charm.Reference) ([]RepositoryCharm, error) {
+	if ref.Schema != "cs" {
+		return nil, errors.NotValidf("charm reference %q with schema other than cs", ref)
+	}
+	query := bson.D{{"user", ref.User}, {"name", ref.Name}}
+	if ref.Series != "" {
+		query = append(query, bson.DocElem{"series", ref.Series})
+	}
+	if ref.Revision >= 0 {
+		query = append(query, bson.DocElem{"revision", ref.Revision})
+	}
+	coll, closer := st.getRawCollection(repositoryCharmsC)
+	defer closer()
+
+	var docs []repositoryCharmDoc
+	if err := coll.Find(query).Sort("series", "revision").All(&docs); err != nil {
+		return nil, errors.Annotatef(err, "cannot get revisions of charm %q from repository", ref)
+	}
+	charms := make([]RepositoryCharm, len(docs))
+	for i, doc := range docs {
+		charms[i] = doc.repositoryCharm()
+	}
+	return charms, nil
+}
+
+// OpenRepositoryCharm returns the charm archive published in the
+// controller's charm repository under the given URL, and a reader for
+// its content. The caller is responsible for closing the reader.
+func (st *State) OpenRepositoryCharm(curl *charm.URL) (RepositoryCharm, io.ReadCloser, error) {
+	ch, err := st.RepositoryCharm(curl)
+	if err != nil {
+		return RepositoryCharm{}, nil, errors.Trace(err)
+	}
+	r, _, err := st.repositoryStorage().Get(ch.storagePath)
+	if err != nil {
+		return RepositoryCharm{}, nil, errors.Annotatef(err, "cannot read archive of charm %q", curl)
+	}
+	return ch, r, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5"
+
+	"github.com/juju/juju/state"
+)
+
+type CharmRepositorySuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&CharmRepositorySuite{})
+
+func (s *CharmRepositorySuite) publish(c *gc.C, url, content string) state.RepositoryCharm {
+	ch, err := s.State.PublishRepositoryCharm(charm.MustParseURL(url), strings.NewReader(content), int64(len(content)), sha256Hex(content), s.Owner)
+	c.Assert(err, jc.ErrorIsNil)
+	return ch
+}
+
+func (s *CharmRepositorySuite) TestPublish(c *gc.C) {
+	ch := s.publish(c, "cs:quantal/mysql-1", "archive")
+	c.Assert(ch.URL.String(), gc.Equals, "cs:quantal/mysql-1")
+	c.Assert(ch.Size, gc.Equals, int64(7))
+	c.Assert(ch.SHA256, gc.Equals, sha256Hex("archive"))
+	c.Assert(ch.PublishedBy, gc.Equals, s.Owner.Name())
+	c.Assert(ch.Published.IsZero(), jc.IsFalse)
+
+	ch, r, err := s.State.OpenRepositoryCharm(charm.MustParseURL("cs:quantal/mysql-1"))
+	c.Assert(err, jc.ErrorIsNil)
+	defer r.Close()
+	c.Assert(ch.SHA256, gc.Equals, sha256Hex("archive"))
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "archive")
+}
+
+func (s *CharmRepositorySuite) TestPublishInvalidURL(c *gc.C) {
+	for _, url := range []string{"local:quantal/mysql-1", "cs:quantal/mysql"} {
+		_, err := s.State.PublishRepositoryCharm(charm.MustParseURL(url), strings.NewReader("archive"), 7, sha256Hex("archive"), s.Owner)
+		c.Check(err, jc.Satisfies, errors.IsNotValid)
+	}
+}
+
+func (s *CharmRepositorySuite) TestPublishHashMismatch(c *gc.C) {
+	curl := charm.MustParseURL("cs:quantal/mysql-1")
+	_, err := s.State.PublishRepositoryCharm(curl, strings.NewReader("archive"), 7, sha256Hex("other"), s.Owner)
+	c.Assert(err, gc.ErrorMatches, `cannot publish charm "cs:quantal/mysql-1": SHA-256 hash mismatch: .*`)
+	_, err = s.State.RepositoryCharm(curl)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *CharmRepositorySuite) TestPublishExisting(c *gc.C) {
+	s.publish(c, "cs:quantal/mysql-1", "archive")
+	_, err := s.State.PublishRepositoryCharm(charm.MustParseURL("cs:quantal/mysql-1"), strings.NewReader("other"), 5, sha256Hex("other"), s.Owner)
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+
+	_, r, err := s.State.OpenRepositoryCharm(charm.MustParseURL("cs:quantal/mysql-1"))
+	c.Assert(err, jc.ErrorIsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "archive")
+}
+
+func (s *CharmRepositorySuite) TestRevisions(c *gc.C) {
+	s.publish(c, "cs:trusty/mysql-3", "a")
+	s.publish(c, "cs:quantal/mysql-2", "b")
+	s.publish(c, "cs:quantal/mysql-10", "c")
+	s.publish(c, "cs:quantal/wordpress-1", "d")
+	s.publish(c, "cs:~bob/quantal/mysql-1", "e")
+
+	for i, test := range []struct {
+		ref    string
+		expect []string
+	}{{
+		ref:    "cs:mysql",
+		expect: []string{"cs:quantal/mysql-2", "cs:quantal/mysql-10", "cs:trusty/mysql-3"},
+	}, {
+		ref:    "cs:quantal/mysql",
+		expect: []string{"cs:quantal/mysql-2", "cs:quantal/mysql-10"},
+	}, {
+		ref:    "cs:quantal/mysql-10",
+		expect: []string{"cs:quantal/mysql-10"},
+	}, {
+		ref:    "cs:~bob/mysql",
+		expect: []string{"cs:~bob/quantal/mysql-1"},
+	}, {
+		ref:    "cs:precise/mysql",
+		expect: []string{},
+	}} {
+		c.Logf("test %d: %s", i, test.ref)
+		charms, err := s.State.RepositoryCharmRevisions(charm.MustParseReference(test.ref))
+		c.Assert(err, jc.ErrorIsNil)
+		urls := make([]string, len(charms))
+		for i, ch := range charms {
+			urls[i] = ch.URL.String()
+		}
+		c.Check(urls, jc.DeepEquals, test.expect)
+	}
+}
+
+func (s *CharmRepositorySuite) TestSharedByEnvironments(c *gc.C) {
+	s.publish(c, "cs:quantal/mysql-1", "archive")
+
+	st := s.Factory.MakeEnvironment(c, nil)
+	defer st.Close()
+	ch, r, err := st.OpenRepositoryCharm(charm.MustParseURL("cs:quantal/mysql-1"))
+	c.Assert(err, jc.ErrorIsNil)
+	defer r.Close()
+	c.Assert(ch.Size, gc.Equals, int64(7))
+}
@@ -23,11 +23,15 @@ const (
 	cleanupDyingUnit                     cleanupKind = "dyingUnit"
 	cleanupRemovedUnit                   cleanupKind = "removedUnit"
 	cleanupServicesForDyingEnvironment   cleanupKind = "services"
+	cleanupMachinesForDyingEnvironment   cleanupKind = "machines"
 	cleanupDyingMachine                  cleanupKind = "dyingMachine"
 	cleanupForceDestroyedMachine         cleanupKind = "machine"
 	cleanupAttachmentsForDyingStorage    cleanupKind = "storageAttachments"
 	cleanupAttachmentsForDyingVolume     cleanupKind = "volumeAttachments"
 	cleanupAttachmentsForDyingFilesystem cleanupKind = "filesystemAttachments"
+	cleanupServiceResources              cleanupKind = "serviceResources"
+	cleanupServiceConfigHistory          cleanupKind = "serviceConfigHistory"
+	cleanupActionScheduleRuns            cleanupKind = "actionScheduleRuns"
 )
 
 // cleanupDoc represents a potentially large set of documents that should be
@@ -89,6 +93,8 @@ func (st *State) Cleanup() (err error) {
 			err = st.cleanupRemovedUnit(doc.Prefix)
 		case cleanupServicesForDyingEnvironment:
 			err = st.cleanupServicesForDyingEnvironment()
+		case cleanupMachinesForDyingEnvironment:
+			err = st.cleanupMachinesForDyingEnvironment()
 		case cleanupDyingMachine:
 			err = st.cleanupDyingMachine(doc.Prefix)
 		case cleanupForceDestroyedMachine:
@@ -99,6 +105,12 @@ func (st *State) Cleanup() (err error) {
 			err = st.cleanupAttachmentsForDyingVolume(doc.Prefix)
 		case cleanupAttachmentsForDyingFilesystem:
 			err = st.cleanupAttachmentsForDyingFilesystem(doc.Prefix)
+		case cleanupServiceResources:
+			err = st.cleanupServiceResources(doc.Prefix)
+		case cleanupServiceConfigHistory:
+			err = st.cleanupServiceConfigHistory(doc.Prefix)
+		case cleanupActionScheduleRuns:
+			err = st.cleanupActionScheduleRuns(doc.Prefix)
 		default:
 			err = fmt.Errorf("unknown cleanup kind %q", doc.Kind)
 		}
@@ -158,6 +170,32 @@ func (st *State) cleanupServicesForDyingEnvironment() (err error) {
 	return nil
 }
 
+// cleanupMachinesForDyingEnvironment forcibly destroys all non-manager
+// machines. It's expected to be used when a hosted environment is
+// destroyed, so that the provisioner stops their instances and removes
+// them from state.
+func (st *State) cleanupMachinesForDyingEnvironment() (err error) {
+	// This won't miss machines, because a Dying environment cannot have
+	// machines added to it.
+	machines, err := st.AllMachines()
+	if err != nil {
+		return err
+	}
+	for _, m := range machines {
+		if m.IsManager() || m.Life() == Dead {
+			continue
+		}
+		if _, isContainer := m.ParentId(); isContainer {
+			// Containers are destroyed along with their host.
+			continue
+		}
+		if err := m.ForceDestroy(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // cleanupUnitsForDyingService sets all units with the given prefix to Dying,
 // if they are not already Dying or Dead. It's expected to be used when a
 // service is destroyed.
@@ -242,11 +280,21 @@ func (st *State) cleanupRemovedUnit(unitId string) error {
 
 	cancelled := ActionResults{Status: ActionCancelled, Message: "unit removed"}
 	for _, action := range actions {
+		// Finished actions cannot be cancelled, and must not stop
+		// the unit's unfinished ones from being cancelled.
+		switch action.Status() {
+		case ActionPending, ActionRunning:
+		default:
+			continue
+		}
 		if _, err = action.Finish(cancelled); err != nil {
 			return err
 		}
 	}
-	return nil
+	if err := st.cleanupPayloadsForRemovedUnit(unitId); err != nil {
+		return err
+	}
+	return st.cleanupHookTranscriptsForRemovedUnit(unitId)
 }
 
 // cleanupDyingMachine marks resources owned by the machine as dying, to ensure
@@ -115,6 +115,43 @@ func (s *CleanupSuite) TestCleanupEnvironmentServices(c *gc.C) {
 	s.assertDoesNotNeedCleanup(c)
 }
 
+func (s *CleanupSuite) TestCleanupHostedEnvironmentMachines(c *gc.C) {
+	st := s.factory.MakeEnvironment(c, nil)
+	defer st.Close()
+	machine, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	container, err := st.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, machine.Id(), instance.LXC)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Destroy the hosted environment; both services and machines
+	// are scheduled for cleanup.
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	needsCleanup, err := st.NeedsCleanup()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(needsCleanup, jc.IsTrue)
+
+	// The first pass force-destroys the machine, the second
+	// runs the queued machine cleanup.
+	for i := 0; i < 2; i++ {
+		err = st.Cleanup()
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	needsCleanup, err = st.NeedsCleanup()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(needsCleanup, jc.IsFalse)
+
+	// The machine and its container are Dead, ready for
+	// removal by the provisioner.
+	assertLife(c, machine, state.Dead)
+	assertLife(c, container, state.Dead)
+}
+
 func (s *CleanupSuite) TestCleanupRelationSettings(c *gc.C) {
 	// Create a relation with a unit in scope.
 	pr := NewPeerRelation(c, s.State, s.Owner)
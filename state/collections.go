@@ -49,18 +49,24 @@ func getCollectionFromDB(db *mgo.Database, name, envUUID string) mongo.Collectio
 // environments. Automatic environment filtering will be applied to
 // these collections.
 var multiEnvCollections = set.NewStrings(
+	actionBatchesC,
 	actionNotificationsC,
+	actionScheduleRunsC,
+	actionSchedulesC,
 	actionsC,
 	annotationsC,
+	apiTokensC,
 	blockDevicesC,
 	blocksC,
 	charmsC,
 	cleanupsC,
+	configHistoryC,
 	constraintsC,
 	containerRefsC,
 	envUsersC,
 	filesystemsC,
 	filesystemAttachmentsC,
+	hookTranscriptsC,
 	instanceDataC,
 	ipaddressesC,
 	machinesC,
@@ -69,14 +75,18 @@ var multiEnvCollections = set.NewStrings(
 	networkInterfacesC,
 	networksC,
 	openedPortsC,
+	payloadsC,
 	rebootC,
 	relationScopesC,
 	relationsC,
+	resourcesC,
 	requestedNetworksC,
+	runJobsC,
 	sequenceC,
 	servicesC,
 	settingsC,
 	settingsrefsC,
+	spacesC,
 	statusesC,
 	statusesHistoryC,
 	storageAttachmentsC,
@@ -84,6 +94,7 @@ var multiEnvCollections = set.NewStrings(
 	storageInstancesC,
 	subnetsC,
 	unitsC,
+	unusedBlobsC,
 	volumesC,
 	volumeAttachmentsC,
 )
@@ -0,0 +1,320 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils/set"
+	"gopkg.in/juju/charm.v5"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// MaxConfigRevisions is the number of config revisions kept for the
+// environment and for each service; older revisions are discarded as
+// new ones are recorded.
+const MaxConfigRevisions = 50
+
+// ConfigRevision records a change made to the environment config or
+// to a service's charm config settings.
+type ConfigRevision struct {
+	// Revision identifies the change; revisions increase with each
+	// change to the same config.
+	Revision int
+
+	// Settings holds the config settings as they were after the
+	// change was made.
+	Settings map[string]interface{}
+
+	// Changes holds the individual settings changed, sorted by key.
+	Changes []ItemChange
+
+	// Author is the name of the user that made the change, or empty
+	// if the change was not made on behalf of a user.
+	Author string
+
+	// Time is when the change was made.
+	Time time.Time
+}
+
+// itemChangeDoc records a single settings change within a
+// configRevisionDoc.
+type itemChangeDoc struct {
+	Type     int         `bson:"type"`
+	Key      string      `bson:"key"`
+	OldValue interface{} `bson:"old,omitempty"`
+	NewValue interface{} `bson:"new,omitempty"`
+}
+
+// configRevisionDoc records a config revision.
+type configRevisionDoc struct {
+	DocID    string                 `bson:"_id"`
+	EnvUUID  string                 `bson:"env-uuid"`
+	Key      string                 `bson:"key"`
+	Revision int                    `bson:"revision"`
+	Settings map[string]interface{} `bson:"settings"`
+	Changes  []itemChangeDoc        `bson:"changes"`
+	Author   string                 `bson:"author,omitempty"`
+	Time     time.Time              `bson:"time"`
+}
+
+func (doc configRevisionDoc) revision() ConfigRevision {
+	changes := make([]ItemChange, len(doc.Changes))
+	for i, change := range doc.Changes {
+		changes[i] = ItemChange{
+			Type:     change.Type,
+			Key:      unescapeReplacer.Replace(change.Key),
+			OldValue: change.OldValue,
+			NewValue: change.NewValue,
+		}
+	}
+	return ConfigRevision{
+		Revision: doc.Revision,
+		Settings: copyMap(doc.Settings, unescapeReplacer.Replace),
+		Changes:  changes,
+		Author:   doc.Author,
+		Time:     doc.Time.UTC(),
+	}
+}
+
+// configRevisionGlobalKey returns the global database key for the
+// revision of the config of the entity with the given global key.
+func configRevisionGlobalKey(globalKey string, revision int) string {
+	return fmt.Sprintf("%s#confighistory#%d", globalKey, revision)
+}
+
+// writeSettingsWithHistory writes the changes made to settings, which
+// hold the config of the entity with the given global key, recording
+// them in the entity's config history in the same transaction.
+func (st *State) writeSettingsWithHistory(settings *Settings, globalKey, author string) ([]ItemChange, error) {
+	return settings.write(func(changes []ItemChange) ([]txn.Op, error) {
+		ops, err := st.addConfigRevisionOps(globalKey, settings.Map(), changes, author)
+		return ops, errors.Annotate(err, "cannot record config history")
+	})
+}
+
+// addConfigRevisionOps returns the operations needed to record a change
+// to the config of the entity with the given global key, discarding the
+// entity's oldest revisions so that no more than MaxConfigRevisions are
+// kept.
+func (st *State) addConfigRevisionOps(globalKey string, settings map[string]interface{}, changes []ItemChange, author string) ([]txn.Op, error) {
+	seq, err := st.sequence("confighistory#" + globalKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	revision := seq + 1
+	changeDocs := make([]itemChangeDoc, len(changes))
+	for i, change := range changes {
+		changeDocs[i] = itemChangeDoc{
+			Type:     change.Type,
+			Key:      escapeReplacer.Replace(change.Key),
+			OldValue: change.OldValue,
+			NewValue: change.NewValue,
+		}
+	}
+	docID := st.docID(configRevisionGlobalKey(globalKey, revision))
+	doc := &configRevisionDoc{
+		DocID:    docID,
+		EnvUUID:  st.EnvironUUID(),
+		Key:      globalKey,
+		Revision: revision,
+		Settings: copyMap(settings, escapeReplacer.Replace),
+		Changes:  changeDocs,
+		Author:   author,
+		Time:     nowToTheSecond(),
+	}
+	ops := []txn.Op{{
+		C:      configHistoryC,
+		Id:     docID,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	expiredOps, err := st.expiredConfigRevisionOps(globalKey, MaxConfigRevisions-1)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return append(ops, expiredOps...), nil
+}
+
+// expiredConfigRevisionOps returns the operations needed to remove all
+// but the newest keep revisions of the config of the entity with the
+// given global key.
+func (st *State) expiredConfigRevisionOps(globalKey string, keep int) ([]txn.Op, error) {
+	coll, closer := st.getCollection(configHistoryC)
+	defer closer()
+
+	var docs []configRevisionDoc
+	err := coll.Find(bson.D{{"key", globalKey}}).Sort("-revision").Skip(keep).Select(bson.D{{"_id", 1}}).All(&docs)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ops := make([]txn.Op, len(docs))
+	for i, doc := range docs {
+		ops[i] = txn.Op{
+			C:      configHistoryC,
+			Id:     doc.DocID,
+			Remove: true,
+		}
+	}
+	return ops, nil
+}
+
+// configHistory returns the recorded revisions of the config of the
+// entity with the given global key, newest first.
+func (st *State) configHistory(globalKey string) ([]ConfigRevision, error) {
+	coll, closer := st.getCollection(configHistoryC)
+	defer closer()
+
+	var docs []configRevisionDoc
+	if err := coll.Find(bson.D{{"key", globalKey}}).Sort("-revision").All(&docs); err != nil {
+		return nil, errors.Trace(err)
+	}
+	revisions := make([]ConfigRevision, len(docs))
+	for i, doc := range docs {
+		revisions[i] = doc.revision()
+	}
+	return revisions, nil
+}
+
+// configRevision returns the given revision of the config of the
+// entity with the given global key.
+func (st *State) configRevision(globalKey string, revision int) (ConfigRevision, error) {
+	coll, closer := st.getCollection(configHistoryC)
+	defer closer()
+
+	var doc configRevisionDoc
+	err := coll.FindId(configRevisionGlobalKey(globalKey, revision)).One(&doc)
+	if err == mgo.ErrNotFound {
+		return ConfigRevision{}, errors.NotFoundf("config revision %d", revision)
+	} else if err != nil {
+		return ConfigRevision{}, errors.Trace(err)
+	}
+	return doc.revision(), nil
+}
+
+// EnvironConfigHistory returns the recorded revisions of the
+// environment config, newest first.
+func (st *State) EnvironConfigHistory() ([]ConfigRevision, error) {
+	revisions, err := st.configHistory(environGlobalKey)
+	return revisions, errors.Annotate(err, "cannot get environment config history")
+}
+
+// EnvironConfigRevision returns the given recorded revision of the
+// environment config.
+func (st *State) EnvironConfigRevision(revision int) (ConfigRevision, error) {
+	return st.configRevision(environGlobalKey, revision)
+}
+
+// rollbackExcludedKeys holds the environment config keys that are
+// managed by the controller rather than set by users, and so are never
+// changed by RollbackEnvironConfig.
+var rollbackExcludedKeys = set.NewStrings(
+	"agent-version",
+	"ca-cert",
+	"ca-private-key",
+	"admin-secret",
+	"uuid",
+	"name",
+	"type",
+	"state-port",
+	"api-port",
+	"syslog-port",
+)
+
+// RollbackEnvironConfig restores the environment config to the settings
+// it had after the given revision, recording the change as a new
+// revision made by author. Keys managed by the controller, such as the
+// agent version and CA certificate, are never changed.
+func (st *State) RollbackEnvironConfig(author names.UserTag, revision int) error {
+	rev, err := st.EnvironConfigRevision(revision)
+	if err != nil {
+		return errors.Annotate(err, "cannot roll back environment config")
+	}
+	settings, err := readSettings(st, environGlobalKey)
+	if err != nil {
+		return errors.Annotate(err, "cannot roll back environment config")
+	}
+	updateAttrs := make(map[string]interface{})
+	for key, value := range rev.Settings {
+		if !rollbackExcludedKeys.Contains(key) {
+			updateAttrs[key] = value
+		}
+	}
+	var removeAttrs []string
+	for _, key := range settings.Keys() {
+		if _, ok := rev.Settings[key]; !ok && !rollbackExcludedKeys.Contains(key) {
+			removeAttrs = append(removeAttrs, key)
+		}
+	}
+	err = st.updateEnvironConfig(author.Name(), updateAttrs, removeAttrs, nil)
+	return errors.Annotate(err, "cannot roll back environment config")
+}
+
+// ConfigHistory returns the recorded revisions of the service's charm
+// config settings, newest first.
+func (s *Service) ConfigHistory() ([]ConfigRevision, error) {
+	revisions, err := s.st.configHistory(s.globalKey())
+	return revisions, errors.Annotatef(err, "cannot get config history for service %q", s)
+}
+
+// ConfigRevision returns the given recorded revision of the service's
+// charm config settings.
+func (s *Service) ConfigRevision(revision int) (ConfigRevision, error) {
+	return s.st.configRevision(s.globalKey(), revision)
+}
+
+// RollbackConfig restores the service's charm config settings to those
+// it had after the given revision, recording the change as a new
+// revision made by author. The settings must be valid for the
+// service's current charm.
+func (s *Service) RollbackConfig(author names.UserTag, revision int) error {
+	rev, err := s.ConfigRevision(revision)
+	if err != nil {
+		return errors.Annotatef(err, "cannot roll back config for service %q", s)
+	}
+	current, err := s.ConfigSettings()
+	if err != nil {
+		return errors.Annotatef(err, "cannot roll back config for service %q", s)
+	}
+	changes := make(charm.Settings)
+	for key := range current {
+		changes[key] = nil
+	}
+	for key, value := range rev.Settings {
+		changes[key] = value
+	}
+	err = s.updateConfigSettings(author.Name(), changes)
+	return errors.Annotatef(err, "cannot roll back config for service %q", s)
+}
+
+// removeConfigHistoryOps returns the operations needed to schedule the
+// removal of the config history of the named service, if it has any.
+func removeConfigHistoryOps(st *State, serviceName string) []txn.Op {
+	coll, closer := st.getCollection(configHistoryC)
+	defer closer()
+
+	n, err := coll.Find(bson.D{{"key", serviceGlobalKey(serviceName)}}).Count()
+	if err == nil && n == 0 {
+		return nil
+	}
+	return []txn.Op{st.newCleanupOp(cleanupServiceConfigHistory, serviceName)}
+}
+
+// cleanupServiceConfigHistory removes the config history of a removed
+// service.
+func (st *State) cleanupServiceConfigHistory(serviceName string) error {
+	ops, err := st.expiredConfigRevisionOps(serviceGlobalKey(serviceName), 0)
+	if err != nil {
+		return errors.Annotatef(err, "cannot remove config history for service %q", serviceName)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	return errors.Annotatef(st.runTransaction(ops), "cannot remove config history for service %q", serviceName)
+}
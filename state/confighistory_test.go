@@ -0,0 +1,237 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/juju/charm.v5"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+)
+
+type ConfigHistorySuite struct {
+	ConnSuite
+	user  names.UserTag
+	charm *state.Charm
+}
+
+var _ = gc.Suite(&ConfigHistorySuite{})
+
+func (s *ConfigHistorySuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.user = s.Owner
+	s.charm = s.AddTestingCharm(c, "dummy")
+}
+
+func (s *ConfigHistorySuite) TestUpdateEnvironConfigRecordsHistory(c *gc.C) {
+	err := s.State.UpdateEnvironConfigAs(s.user, map[string]interface{}{"arbitrary-key": "shazam!"}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := s.State.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 1)
+	rev := history[0]
+	c.Assert(rev.Revision, gc.Equals, 1)
+	c.Assert(rev.Author, gc.Equals, s.user.Name())
+	c.Assert(rev.Time.IsZero(), jc.IsFalse)
+	c.Assert(rev.Changes, jc.DeepEquals, []state.ItemChange{{
+		Type:     state.ItemAdded,
+		Key:      "arbitrary-key",
+		NewValue: "shazam!",
+	}})
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(rev.Settings["arbitrary-key"], gc.Equals, "shazam!")
+	c.Assert(rev.Settings["name"], gc.Equals, cfg.Name())
+
+	found, err := s.State.EnvironConfigRevision(1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(found, jc.DeepEquals, rev)
+}
+
+func (s *ConfigHistorySuite) TestUpdateEnvironConfigWithoutAuthor(c *gc.C) {
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{"arbitrary-key": "shazam!"}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := s.State.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 1)
+	c.Assert(history[0].Author, gc.Equals, "")
+}
+
+func (s *ConfigHistorySuite) TestUnchangedEnvironConfigNotRecorded(c *gc.C) {
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.UpdateEnvironConfigAs(s.user, map[string]interface{}{"name": cfg.Name()}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := s.State.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 0)
+}
+
+func (s *ConfigHistorySuite) TestEnvironConfigHistoryRetention(c *gc.C) {
+	for i := 0; i < state.MaxConfigRevisions+2; i++ {
+		err := s.State.UpdateEnvironConfigAs(s.user, map[string]interface{}{"arbitrary-key": fmt.Sprint(i)}, nil, nil)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	history, err := s.State.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, state.MaxConfigRevisions)
+	c.Assert(history[0].Revision, gc.Equals, state.MaxConfigRevisions+2)
+	c.Assert(history[len(history)-1].Revision, gc.Equals, 3)
+
+	_, err = s.State.EnvironConfigRevision(2)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ConfigHistorySuite) TestRollbackEnvironConfig(c *gc.C) {
+	err := s.State.UpdateEnvironConfigAs(s.user, map[string]interface{}{"arbitrary-key": "one"}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.UpdateEnvironConfigAs(s.user, map[string]interface{}{
+		"arbitrary-key": "two",
+		"other-key":     "added",
+	}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	bob := names.NewUserTag("bob")
+	err = s.State.RollbackEnvironConfig(bob, 1)
+	c.Assert(err, jc.ErrorIsNil)
+
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	attrs := cfg.UnknownAttrs()
+	c.Assert(attrs["arbitrary-key"], gc.Equals, "one")
+	_, ok := attrs["other-key"]
+	c.Assert(ok, jc.IsFalse)
+
+	history, err := s.State.EnvironConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 3)
+	c.Assert(history[0].Revision, gc.Equals, 3)
+	c.Assert(history[0].Author, gc.Equals, "bob")
+	c.Assert(history[0].Changes, jc.DeepEquals, []state.ItemChange{{
+		Type:     state.ItemModified,
+		Key:      "arbitrary-key",
+		OldValue: "two",
+		NewValue: "one",
+	}, {
+		Type:     state.ItemDeleted,
+		Key:      "other-key",
+		OldValue: "added",
+	}})
+}
+
+func (s *ConfigHistorySuite) TestRollbackEnvironConfigKeepsAgentVersion(c *gc.C) {
+	err := s.State.UpdateEnvironConfigAs(s.user, map[string]interface{}{"arbitrary-key": "one"}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	agentVersion, ok := cfg.AgentVersion()
+	c.Assert(ok, jc.IsTrue)
+	agentVersion.Patch++
+	err = s.State.SetEnvironAgentVersion(agentVersion)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.State.RollbackEnvironConfig(s.user, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	cfg, err = s.State.EnvironConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	current, _ := cfg.AgentVersion()
+	c.Assert(current, gc.Equals, agentVersion)
+}
+
+func (s *ConfigHistorySuite) TestRollbackEnvironConfigKeepsCACert(c *gc.C) {
+	err := s.State.UpdateEnvironConfigAs(s.user, map[string]interface{}{"arbitrary-key": "one"}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{"ca-cert": testing.OtherCACert}, nil, nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.State.RollbackEnvironConfig(s.user, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	caCert, _ := cfg.CACert()
+	c.Assert(caCert, gc.Equals, testing.OtherCACert)
+}
+
+func (s *ConfigHistorySuite) TestRollbackEnvironConfigUnknownRevision(c *gc.C) {
+	err := s.State.RollbackEnvironConfig(s.user, 42)
+	c.Assert(err, gc.ErrorMatches, "cannot roll back environment config: config revision 42 not found")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ConfigHistorySuite) TestServiceConfigHistory(c *gc.C) {
+	svc := s.AddTestingService(c, "dummy", s.charm)
+	err := svc.UpdateConfigSettingsAs(s.user, charm.Settings{"outlook": "grim", "skill-level": int64(5)})
+	c.Assert(err, jc.ErrorIsNil)
+	err = svc.UpdateConfigSettingsAs(s.user, charm.Settings{"outlook": nil})
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := svc.ConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 2)
+	c.Assert(history[0].Revision, gc.Equals, 2)
+	c.Assert(history[0].Author, gc.Equals, s.user.Name())
+	c.Assert(history[0].Settings, jc.DeepEquals, map[string]interface{}{"skill-level": int64(5)})
+	c.Assert(history[0].Changes, jc.DeepEquals, []state.ItemChange{{
+		Type:     state.ItemDeleted,
+		Key:      "outlook",
+		OldValue: "grim",
+	}})
+	c.Assert(history[1].Revision, gc.Equals, 1)
+	c.Assert(history[1].Settings, jc.DeepEquals, map[string]interface{}{
+		"outlook":     "grim",
+		"skill-level": int64(5),
+	})
+
+	// Other services have their own history.
+	other := s.AddTestingService(c, "other", s.charm)
+	history, err = other.ConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 0)
+}
+
+func (s *ConfigHistorySuite) TestRollbackServiceConfig(c *gc.C) {
+	svc := s.AddTestingService(c, "dummy", s.charm)
+	err := svc.UpdateConfigSettingsAs(s.user, charm.Settings{"outlook": "grim"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = svc.UpdateConfigSettingsAs(s.user, charm.Settings{"outlook": "rosy", "title": "Ms"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = svc.RollbackConfig(s.user, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	settings, err := svc.ConfigSettings()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(settings, jc.DeepEquals, charm.Settings{"outlook": "grim"})
+
+	history, err := svc.ConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 3)
+	c.Assert(history[0].Revision, gc.Equals, 3)
+
+	err = svc.RollbackConfig(s.user, 7)
+	c.Assert(err, gc.ErrorMatches, `cannot roll back config for service "dummy": config revision 7 not found`)
+}
+
+func (s *ConfigHistorySuite) TestServiceConfigHistoryRemovedWithService(c *gc.C) {
+	svc := s.AddTestingService(c, "dummy", s.charm)
+	err := svc.UpdateConfigSettingsAs(s.user, charm.Settings{"outlook": "grim"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = svc.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.Cleanup()
+	c.Assert(err, jc.ErrorIsNil)
+
+	svc = s.AddTestingService(c, "dummy", s.charm)
+	history, err := svc.ConfigHistory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 0)
+}
@@ -0,0 +1,218 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/juju/juju/state/lease"
+)
+
+// APIServerReport holds what the API server running on a state server
+// last reported about itself.
+type APIServerReport struct {
+	// MachineId holds the id of the state server machine.
+	MachineId string
+
+	// Connections holds the number of API connections the server had
+	// open when it reported.
+	Connections int
+
+	// Clock records the API server's clock relative to the database
+	// clock; the local times of the skew are database times.
+	Clock lease.Skew
+}
+
+// Updated returns the database time at which the report was made.
+func (r APIServerReport) Updated() time.Time {
+	return r.Clock.ReadBefore
+}
+
+type apiServerReportDoc struct {
+	DocID       string    `bson:"_id"`
+	Connections int       `bson:"connections"`
+	LastWrite   time.Time `bson:"lastwrite"`
+	ReadAfter   time.Time `bson:"readafter"`
+	ReadBefore  time.Time `bson:"readbefore"`
+}
+
+// ReportAPIServer records the number of API connections open on the
+// API server running on the given state server machine, along with a
+// sample of its clock taken against the database clock.
+//
+// Reports are written outside of transactions, as they are made
+// frequently and each one replaces the last.
+func (st *State) ReportAPIServer(machineId string, connections int) error {
+	session := st.MongoSession().Copy()
+	defer session.Close()
+	readAfter, err := databaseTime(session)
+	if err != nil {
+		return errors.Annotate(err, "cannot read database clock")
+	}
+	lastWrite := time.Now()
+	readBefore, err := databaseTime(session)
+	if err != nil {
+		return errors.Annotate(err, "cannot read database clock")
+	}
+	reports, closer := st.getRawCollection(apiServerReportsC)
+	defer closer()
+	_, err = reports.With(session).UpsertId(machineId, &apiServerReportDoc{
+		DocID:       machineId,
+		Connections: connections,
+		LastWrite:   lastWrite,
+		ReadAfter:   readAfter,
+		ReadBefore:  readBefore,
+	})
+	if err != nil {
+		return errors.Annotatef(err, "cannot record report for API server on machine %s", machineId)
+	}
+	return nil
+}
+
+// APIServerReports returns the latest report made by each API server,
+// sorted by machine id.
+func (st *State) APIServerReports() ([]APIServerReport, error) {
+	reports, closer := st.getRawCollection(apiServerReportsC)
+	defer closer()
+	var docs []apiServerReportDoc
+	if err := reports.Find(nil).Sort("_id").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot read API server reports")
+	}
+	result := make([]APIServerReport, len(docs))
+	for i, doc := range docs {
+		result[i] = APIServerReport{
+			MachineId:   doc.DocID,
+			Connections: doc.Connections,
+			Clock: lease.Skew{
+				LastWrite:  doc.LastWrite,
+				ReadAfter:  doc.ReadAfter,
+				ReadBefore: doc.ReadBefore,
+			},
+		}
+	}
+	return result, nil
+}
+
+// databaseTime returns the current time according to the clock of the
+// database server the session is connected to.
+func databaseTime(session *mgo.Session) (time.Time, error) {
+	var isMaster struct {
+		LocalTime time.Time `bson:"localTime"`
+	}
+	if err := session.Run("isMaster", &isMaster); err != nil {
+		return time.Time{}, errors.Trace(err)
+	}
+	if isMaster.LocalTime.IsZero() {
+		return time.Time{}, errors.New("database server does not report its time")
+	}
+	return isMaster.LocalTime, nil
+}
+
+// DatabaseHealth holds information about the backlog of work recorded
+// in the database shared by all environments.
+type DatabaseHealth struct {
+	// PendingTransactions holds the number of transactions that have
+	// been neither applied nor aborted.
+	PendingTransactions int
+
+	// PendingCleanups holds the number of cleanups, across all
+	// environments, that have not yet been run.
+	PendingCleanups int
+
+	// OldestCleanup holds when the oldest pending cleanup was
+	// scheduled, or the zero time if there are none.
+	OldestCleanup time.Time
+
+	// LogCount and LogSize hold the number of log records stored in
+	// the database, and the space they take up in bytes.
+	LogCount int
+	LogSize  int64
+}
+
+// txnDone holds the lowest transaction state (as recorded by mgo/txn)
+// in which a transaction has been either aborted or applied.
+const txnDone = 5
+
+// DatabaseHealth returns information about the backlog of work
+// recorded in the database. Pending transactions are counted using the
+// index on transaction state, so the cost depends on the size of the
+// backlog rather than on the number of transactions ever run.
+func (st *State) DatabaseHealth() (DatabaseHealth, error) {
+	var health DatabaseHealth
+	txns, closer := st.getRawCollection(txnsC)
+	defer closer()
+	pending, err := txns.Find(bson.D{{"s", bson.D{{"$lt", txnDone}}}}).Count()
+	if err != nil {
+		return health, errors.Annotate(err, "cannot count pending transactions")
+	}
+	health.PendingTransactions = pending
+
+	if health.PendingCleanups, health.OldestCleanup, err = st.pendingCleanups(); err != nil {
+		return health, errors.Trace(err)
+	}
+
+	session := st.MongoSession().Copy()
+	defer session.Close()
+	var stats struct {
+		Count int   `bson:"count"`
+		Size  int64 `bson:"size"`
+	}
+	err = session.DB(logsDB).Run(bson.D{{"collStats", logsC}}, &stats)
+	if err != nil && !isNamespaceNotFound(err) {
+		return health, errors.Annotate(err, "cannot read log collection size")
+	}
+	health.LogCount = stats.Count
+	health.LogSize = stats.Size
+	return health, nil
+}
+
+// pendingCleanups returns the number of cleanup documents across all
+// environments, and when the oldest of them was created.
+func (st *State) pendingCleanups() (count int, oldest time.Time, err error) {
+	cleanups, closer := st.getRawCollection(cleanupsC)
+	defer closer()
+	var doc struct {
+		DocID string `bson:"_id"`
+	}
+	iter := cleanups.Find(nil).Select(bson.D{{"_id", 1}}).Iter()
+	defer closeIter(iter, &err, "reading cleanup document")
+	for iter.Next(&doc) {
+		count++
+		created, ok := cleanupCreated(doc.DocID)
+		if !ok {
+			continue
+		}
+		if oldest.IsZero() || created.Before(oldest) {
+			oldest = created
+		}
+	}
+	return count, oldest, nil
+}
+
+// cleanupCreated returns the time at which the cleanup document with
+// the given id was created, from the object id embedded in it.
+func cleanupCreated(docID string) (time.Time, bool) {
+	// Cleanup document ids hold the printed form of an object id,
+	// ObjectIdHex("..."), prefixed with the environment UUID.
+	start := strings.Index(docID, `ObjectIdHex("`)
+	if start < 0 {
+		return time.Time{}, false
+	}
+	hex := strings.TrimSuffix(docID[start+len(`ObjectIdHex("`):], `")`)
+	if !bson.IsObjectIdHex(hex) {
+		return time.Time{}, false
+	}
+	return bson.ObjectIdHex(hex).Time(), true
+}
+
+// isNamespaceNotFound returns whether the error was returned by a
+// command run on a collection that does not exist.
+func isNamespaceNotFound(err error) bool {
+	return strings.Contains(err.Error(), "ns not found")
+}
@@ -0,0 +1,108 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/state"
+)
+
+type ControllerHealthSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&ControllerHealthSuite{})
+
+func (s *ControllerHealthSuite) TestAPIServerReports(c *gc.C) {
+	reports, err := s.State.APIServerReports()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(reports, gc.HasLen, 0)
+
+	err = s.State.ReportAPIServer("1", 5)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.ReportAPIServer("0", 3)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.ReportAPIServer("0", 4)
+	c.Assert(err, jc.ErrorIsNil)
+
+	reports, err = s.State.APIServerReports()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(reports, gc.HasLen, 2)
+	c.Assert(reports[0].MachineId, gc.Equals, "0")
+	c.Assert(reports[0].Connections, gc.Equals, 4)
+	c.Assert(reports[1].MachineId, gc.Equals, "1")
+	c.Assert(reports[1].Connections, gc.Equals, 5)
+
+	// The test database runs on this machine, so the clocks agree.
+	for _, report := range reports {
+		c.Assert(report.Updated().IsZero(), jc.IsFalse)
+		offset, uncertainty := report.Clock.Offset()
+		c.Assert(offset-uncertainty < time.Second, jc.IsTrue)
+		c.Assert(offset+uncertainty > -time.Second, jc.IsTrue)
+	}
+}
+
+func (s *ControllerHealthSuite) TestDatabaseHealthCleanups(c *gc.C) {
+	health, err := s.State.DatabaseHealth()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(health.PendingTransactions, gc.Equals, 0)
+	c.Assert(health.PendingCleanups, gc.Equals, 0)
+	c.Assert(health.OldestCleanup.IsZero(), jc.IsTrue)
+
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	before := time.Now().Add(-time.Second)
+	err = machine.ForceDestroy()
+	c.Assert(err, jc.ErrorIsNil)
+
+	health, err = s.State.DatabaseHealth()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(health.PendingCleanups, gc.Equals, 1)
+	c.Assert(health.OldestCleanup.After(before), jc.IsTrue)
+
+	err = s.State.Cleanup()
+	c.Assert(err, jc.ErrorIsNil)
+	health, err = s.State.DatabaseHealth()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(health.PendingCleanups, gc.Equals, 0)
+}
+
+func (s *ControllerHealthSuite) TestTransactionStateIndexed(c *gc.C) {
+	// DatabaseHealth counts pending transactions by state, which must
+	// not need a scan of the whole transaction collection.
+	indexes, err := s.State.MongoSession().DB("juju").C("txns").Indexes()
+	c.Assert(err, jc.ErrorIsNil)
+	var keys []string
+	for _, index := range indexes {
+		keys = append(keys, strings.Join(index.Key, "-"))
+		if index.Name == "s_1" {
+			// It is built without blocking the opening of
+			// state when there are many transactions.
+			c.Check(index.Background, jc.IsTrue)
+		}
+	}
+	c.Assert(keys, jc.SameContents, []string{
+		"_id", // default index
+		"s",   // transaction state
+	})
+}
+
+func (s *ControllerHealthSuite) TestDatabaseHealthLogs(c *gc.C) {
+	logger := state.NewDbLogger(s.State, names.NewMachineTag("0"))
+	defer logger.Close()
+	err := logger.Log(time.Now(), "juju.test", "test.go:1", loggo.INFO, "hello")
+	c.Assert(err, jc.ErrorIsNil)
+
+	health, err := s.State.DatabaseHealth()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(health.LogCount, gc.Equals, 1)
+	c.Assert(health.LogSize > 0, jc.IsTrue)
+}
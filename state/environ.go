@@ -32,6 +32,19 @@ type environmentDoc struct {
 	Life       Life
 	Owner      string `bson:"owner"`
 	ServerUUID string `bson:"server-uuid"`
+
+	// MigratedTo holds the API addresses of the state server the
+	// environment has been migrated to, if any.
+	MigratedTo [][]hostPort `bson:"migrated-to,omitempty"`
+
+	// MigrationMode records the part the environment is playing in
+	// its migration to or from another state server, if any.
+	MigrationMode MigrationMode `bson:"migration-mode,omitempty"`
+
+	// MigrationCACert holds the CA certificate of the state server
+	// the environment is being exported to, which its agents trust
+	// in addition to their own.
+	MigrationCACert string `bson:"migration-ca-cert,omitempty"`
 }
 
 // StateServerEnvironment returns the environment that was bootstrapped.
@@ -313,20 +326,22 @@ func (e *Environment) startDestroy() error {
 }
 
 func (e *Environment) finishDestroy() error {
-	// We add a cleanup for services, but not for machines; machines are
-	// destroyed via the provider interface. The exception to this rule is
-	// manual machines; the API prevents destroy-environment from succeeding
-	// if any non-manager manual machines exist.
+	// In the state server environment we add a cleanup for services, but
+	// not for machines; machines are destroyed via the provider interface.
+	// The exception to this rule is manual machines; the API prevents
+	// destroy-environment from succeeding if any non-manager manual
+	// machines exist.
 	//
-	// We don't bother adding a cleanup for a non state server environment, as
-	// RemoveAllEnvironDocs() at the end of apiserver/client.Destroy() removes
-	// these documents for us.
-	var ops []txn.Op
-	if e.UUID() == e.doc.ServerUUID {
-		ops = []txn.Op{e.st.newCleanupOp(cleanupServicesForDyingEnvironment, "")}
-	} else {
-		ops = []txn.Op{decEnvironCountOp()}
-
+	// In a hosted environment there is no client to destroy the machines,
+	// so we add a cleanup for them as well; the environment's provisioner
+	// stops their instances, and the undertaker removes all the
+	// environment's documents once they are gone.
+	ops := []txn.Op{e.st.newCleanupOp(cleanupServicesForDyingEnvironment, "")}
+	if e.UUID() != e.doc.ServerUUID {
+		ops = append(ops,
+			e.st.newCleanupOp(cleanupMachinesForDyingEnvironment, ""),
+			decEnvironCountOp(),
+		)
 	}
 	return e.st.runTransaction(ops)
 }
@@ -409,6 +409,7 @@ func NewHistoricalStatusDoc(s StatusDoc, key string) *historicalStatusDoc {
 }
 
 var StatusHistory = statusHistory
+var FilteredStatusHistory = filteredStatusHistory
 var UpdateStatusHistory = updateStatusHistory
 
 func EraseUnitHistory(u *Unit) error {
@@ -422,3 +423,7 @@ func UnitGlobalKey(u *Unit) string {
 func UnitAgentGlobalKey(u *UnitAgent) string {
 	return u.globalKey()
 }
+
+// CollectEnvironUnusedBlobs collects the unused blobs of the given
+// state's environment only, so that its transaction hooks apply.
+var CollectEnvironUnusedBlobs = (*State).collectUnusedBlobs
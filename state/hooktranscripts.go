@@ -0,0 +1,262 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// MaxHookTranscriptsPerUnit is the number of hook transcripts kept for
+// each unit; older transcripts are discarded as new ones are added.
+const MaxHookTranscriptsPerUnit = 10
+
+// HookToolCall records a hook tool invocation made while a hook was
+// running, and the response it was given.
+type HookToolCall struct {
+	Command   string
+	Args      []string
+	Stdin     string
+	Stdout    string
+	Stderr    string
+	Code      int
+	Truncated bool
+}
+
+// HookTranscript records a single execution of a hook or action by a
+// unit, as reported by its agent.
+type HookTranscript struct {
+	// Id identifies the transcript within the environment; it is
+	// assigned when the transcript is added.
+	Id       string
+	Unit     string
+	Kind     string
+	Name     string
+	Env      []string
+	Calls    []HookToolCall
+	ExitCode int
+	Error    string
+	Started  time.Time
+	Duration time.Duration
+}
+
+// hookToolCallDoc records a single hook tool invocation within a
+// hookTranscriptDoc.
+type hookToolCallDoc struct {
+	Command   string   `bson:"command"`
+	Args      []string `bson:"args,omitempty"`
+	Stdin     string   `bson:"stdin,omitempty"`
+	Stdout    string   `bson:"stdout,omitempty"`
+	Stderr    string   `bson:"stderr,omitempty"`
+	Code      int      `bson:"code"`
+	Truncated bool     `bson:"truncated,omitempty"`
+}
+
+// hookTranscriptDoc records a hook transcript.
+type hookTranscriptDoc struct {
+	DocID    string            `bson:"_id"`
+	EnvUUID  string            `bson:"env-uuid"`
+	UnitID   string            `bson:"unitid"`
+	Seq      int               `bson:"seq"`
+	Kind     string            `bson:"kind"`
+	Name     string            `bson:"name"`
+	Env      []string          `bson:"env"`
+	Calls    []hookToolCallDoc `bson:"calls,omitempty"`
+	ExitCode int               `bson:"exitcode"`
+	Error    string            `bson:"error,omitempty"`
+	Started  time.Time         `bson:"started"`
+	Duration int64             `bson:"duration"`
+}
+
+func (doc hookTranscriptDoc) transcript() HookTranscript {
+	calls := make([]HookToolCall, len(doc.Calls))
+	for i, call := range doc.Calls {
+		calls[i] = HookToolCall{
+			Command:   call.Command,
+			Args:      call.Args,
+			Stdin:     call.Stdin,
+			Stdout:    call.Stdout,
+			Stderr:    call.Stderr,
+			Code:      call.Code,
+			Truncated: call.Truncated,
+		}
+	}
+	return HookTranscript{
+		Id:       strconv.Itoa(doc.Seq),
+		Unit:     doc.UnitID,
+		Kind:     doc.Kind,
+		Name:     doc.Name,
+		Env:      doc.Env,
+		Calls:    calls,
+		ExitCode: doc.ExitCode,
+		Error:    doc.Error,
+		Started:  doc.Started.UTC(),
+		Duration: time.Duration(doc.Duration),
+	}
+}
+
+// hookTranscriptGlobalKey returns the global database key for the
+// hook transcript of the given unit with the given sequence number.
+func hookTranscriptGlobalKey(unitName string, seq int) string {
+	return fmt.Sprintf("%s#transcript#%d", unitGlobalKey(unitName), seq)
+}
+
+// AddHookTranscript stores the supplied transcript for the unit,
+// discarding the unit's oldest transcripts so that no more than
+// MaxHookTranscriptsPerUnit are kept. Any Id and Unit set in t are
+// ignored.
+func (u *Unit) AddHookTranscript(t HookTranscript) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add hook transcript for unit %q", u.Name())
+	if t.Name == "" {
+		return errors.NotValidf("missing hook name")
+	}
+	seq, err := u.st.sequence("hooktranscript")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	calls := make([]hookToolCallDoc, len(t.Calls))
+	for i, call := range t.Calls {
+		calls[i] = hookToolCallDoc{
+			Command:   call.Command,
+			Args:      call.Args,
+			Stdin:     call.Stdin,
+			Stdout:    call.Stdout,
+			Stderr:    call.Stderr,
+			Code:      call.Code,
+			Truncated: call.Truncated,
+		}
+	}
+	docID := u.st.docID(hookTranscriptGlobalKey(u.Name(), seq))
+	doc := &hookTranscriptDoc{
+		DocID:    docID,
+		EnvUUID:  u.st.EnvironUUID(),
+		UnitID:   u.Name(),
+		Seq:      seq,
+		Kind:     t.Kind,
+		Name:     t.Name,
+		Env:      t.Env,
+		Calls:    calls,
+		ExitCode: t.ExitCode,
+		Error:    t.Error,
+		Started:  t.Started.UTC(),
+		Duration: int64(t.Duration),
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := u.Refresh(); errors.IsNotFound(err) {
+				return nil, errors.NotFoundf("unit")
+			} else if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if u.Life() == Dead {
+			return nil, errors.New("unit is dead")
+		}
+		ops := []txn.Op{{
+			C:      unitsC,
+			Id:     u.doc.DocID,
+			Assert: notDeadDoc,
+		}, {
+			C:      hookTranscriptsC,
+			Id:     docID,
+			Assert: txn.DocMissing,
+			Insert: doc,
+		}}
+		expiredOps, err := u.expiredHookTranscriptOps(MaxHookTranscriptsPerUnit - 1)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return append(ops, expiredOps...), nil
+	}
+	return u.st.run(buildTxn)
+}
+
+// expiredHookTranscriptOps returns the operations needed to remove all
+// but the newest keep transcripts of the unit.
+func (u *Unit) expiredHookTranscriptOps(keep int) ([]txn.Op, error) {
+	coll, closer := u.st.getCollection(hookTranscriptsC)
+	defer closer()
+
+	var docs []hookTranscriptDoc
+	err := coll.Find(bson.D{{"unitid", u.Name()}}).Sort("-seq").Skip(keep).Select(bson.D{{"_id", 1}}).All(&docs)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ops := make([]txn.Op, len(docs))
+	for i, doc := range docs {
+		ops[i] = txn.Op{
+			C:      hookTranscriptsC,
+			Id:     doc.DocID,
+			Remove: true,
+		}
+	}
+	return ops, nil
+}
+
+// HookTranscripts returns the transcripts stored for the unit, newest
+// first.
+func (u *Unit) HookTranscripts() ([]HookTranscript, error) {
+	coll, closer := u.st.getCollection(hookTranscriptsC)
+	defer closer()
+
+	var docs []hookTranscriptDoc
+	if err := coll.Find(bson.D{{"unitid", u.Name()}}).Sort("-seq").All(&docs); err != nil {
+		return nil, errors.Annotatef(err, "cannot get hook transcripts for unit %q", u.Name())
+	}
+	transcripts := make([]HookTranscript, len(docs))
+	for i, doc := range docs {
+		transcripts[i] = doc.transcript()
+	}
+	return transcripts, nil
+}
+
+// HookTranscript returns the unit's transcript with the given id.
+func (u *Unit) HookTranscript(id string) (HookTranscript, error) {
+	seq, err := strconv.Atoi(id)
+	if err != nil {
+		return HookTranscript{}, errors.NotValidf("hook transcript id %q", id)
+	}
+	coll, closer := u.st.getCollection(hookTranscriptsC)
+	defer closer()
+
+	var doc hookTranscriptDoc
+	err = coll.FindId(hookTranscriptGlobalKey(u.Name(), seq)).One(&doc)
+	if err == mgo.ErrNotFound {
+		return HookTranscript{}, errors.NotFoundf("hook transcript %q for unit %q", id, u.Name())
+	} else if err != nil {
+		return HookTranscript{}, errors.Annotatef(err, "cannot get hook transcript %q", id)
+	}
+	return doc.transcript(), nil
+}
+
+// cleanupHookTranscriptsForRemovedUnit removes any hook transcripts
+// left behind by a unit that has been removed from state.
+func (st *State) cleanupHookTranscriptsForRemovedUnit(unitName string) error {
+	coll, closer := st.getCollection(hookTranscriptsC)
+	defer closer()
+
+	var docs []hookTranscriptDoc
+	err := coll.Find(bson.D{{"unitid", unitName}}).Select(bson.D{{"_id", 1}}).All(&docs)
+	if err != nil {
+		return errors.Annotatef(err, "cannot remove hook transcripts for unit %q", unitName)
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	ops := make([]txn.Op, len(docs))
+	for i, doc := range docs {
+		ops[i] = txn.Op{
+			C:      hookTranscriptsC,
+			Id:     doc.DocID,
+			Remove: true,
+		}
+	}
+	return errors.Annotatef(st.runTransaction(ops), "cannot remove hook transcripts for unit %q", unitName)
+}
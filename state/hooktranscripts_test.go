@@ -0,0 +1,140 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/juju/juju/state"
+)
+
+type HookTranscriptsSuite struct {
+	ConnSuite
+	unit *state.Unit
+}
+
+var _ = gc.Suite(&HookTranscriptsSuite{})
+
+func (s *HookTranscriptsSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.unit = s.Factory.MakeUnit(c, nil)
+}
+
+func (s *HookTranscriptsSuite) newTranscript(name string) state.HookTranscript {
+	return state.HookTranscript{
+		Kind: "hook",
+		Name: name,
+		Env:  []string{"JUJU_UNIT_NAME=" + s.unit.Name()},
+		Calls: []state.HookToolCall{{
+			Command: "config-get",
+			Args:    []string{"--format", "json"},
+			Stdout:  `{"port": 80}`,
+		}, {
+			Command: "status-set",
+			Args:    []string{"bogus"},
+			Stderr:  "error: invalid status \"bogus\"\n",
+			Code:    2,
+		}},
+		ExitCode: 1,
+		Error:    "exit status 1",
+		Started:  time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC),
+		Duration: 3 * time.Second,
+	}
+}
+
+func (s *HookTranscriptsSuite) TestAddHookTranscript(c *gc.C) {
+	err := s.unit.AddHookTranscript(s.newTranscript("config-changed"))
+	c.Assert(err, jc.ErrorIsNil)
+
+	transcripts, err := s.unit.HookTranscripts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(transcripts, gc.HasLen, 1)
+	expected := s.newTranscript("config-changed")
+	expected.Id = transcripts[0].Id
+	expected.Unit = s.unit.Name()
+	c.Assert(transcripts[0], jc.DeepEquals, expected)
+
+	transcript, err := s.unit.HookTranscript(expected.Id)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(transcript, jc.DeepEquals, expected)
+}
+
+func (s *HookTranscriptsSuite) TestAddHookTranscriptIncludesEnvUUID(c *gc.C) {
+	err := s.unit.AddHookTranscript(s.newTranscript("install"))
+	c.Assert(err, jc.ErrorIsNil)
+
+	coll := s.MgoSuite.Session.DB("juju").C("hooktranscripts")
+	var docs []bson.M
+	err = coll.Find(nil).All(&docs)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(docs, gc.HasLen, 1)
+	c.Assert(docs[0]["env-uuid"], gc.Equals, s.State.EnvironUUID())
+}
+
+func (s *HookTranscriptsSuite) TestAddHookTranscriptInvalid(c *gc.C) {
+	err := s.unit.AddHookTranscript(state.HookTranscript{Kind: "hook"})
+	c.Assert(err, gc.ErrorMatches, `cannot add hook transcript for unit ".*": missing hook name not valid`)
+}
+
+func (s *HookTranscriptsSuite) TestAddHookTranscriptDeadUnit(c *gc.C) {
+	err := s.unit.EnsureDead()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.AddHookTranscript(s.newTranscript("stop"))
+	c.Assert(err, gc.ErrorMatches, `cannot add hook transcript for unit ".*": unit is dead`)
+}
+
+func (s *HookTranscriptsSuite) TestHookTranscriptsNewestFirstAndBounded(c *gc.C) {
+	total := state.MaxHookTranscriptsPerUnit + 3
+	for i := 0; i < total; i++ {
+		err := s.unit.AddHookTranscript(s.newTranscript(fmt.Sprintf("hook-%d", i)))
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	transcripts, err := s.unit.HookTranscripts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(transcripts, gc.HasLen, state.MaxHookTranscriptsPerUnit)
+	for i, t := range transcripts {
+		c.Check(t.Name, gc.Equals, fmt.Sprintf("hook-%d", total-1-i))
+	}
+}
+
+func (s *HookTranscriptsSuite) TestHookTranscriptsPerUnit(c *gc.C) {
+	service, err := s.unit.Service()
+	c.Assert(err, jc.ErrorIsNil)
+	other, err := service.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(other.AddHookTranscript(s.newTranscript("install")), jc.ErrorIsNil)
+
+	transcripts, err := s.unit.HookTranscripts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(transcripts, gc.HasLen, 0)
+
+	transcripts, err = other.HookTranscripts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(transcripts, gc.HasLen, 1)
+	_, err = s.unit.HookTranscript(transcripts[0].Id)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *HookTranscriptsSuite) TestHookTranscriptBadId(c *gc.C) {
+	_, err := s.unit.HookTranscript("foo")
+	c.Assert(err, gc.ErrorMatches, `hook transcript id "foo" not valid`)
+}
+
+func (s *HookTranscriptsSuite) TestHookTranscriptsRemovedWithUnit(c *gc.C) {
+	c.Assert(s.unit.AddHookTranscript(s.newTranscript("install")), jc.ErrorIsNil)
+	c.Assert(s.unit.EnsureDead(), jc.ErrorIsNil)
+	c.Assert(s.unit.Remove(), jc.ErrorIsNil)
+	c.Assert(s.State.Cleanup(), jc.ErrorIsNil)
+
+	coll := s.MgoSuite.Session.DB("juju").C("hooktranscripts")
+	count, err := coll.Count()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(count, gc.Equals, 0)
+}
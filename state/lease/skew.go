@@ -43,6 +43,21 @@ func (skew Skew) Latest(remote time.Time) (local time.Time) {
 	return skew.ReadBefore.Add(delta)
 }
 
+// Offset returns an estimate of how far the skewed writer's clock is ahead
+// of the local clock (or behind it, if negative), and the most by which
+// that estimate may be wrong. It's only meaningful if LastWrite was written
+// between ReadAfter and ReadBefore, as it is when the remote clock is
+// sampled directly; a write that was merely read later carries no
+// information about how long ago it happened.
+func (skew Skew) Offset() (offset, uncertainty time.Duration) {
+	if skew.isZero() {
+		return 0, 0
+	}
+	uncertainty = skew.ReadBefore.Sub(skew.ReadAfter) / 2
+	midpoint := skew.ReadAfter.Add(uncertainty)
+	return skew.LastWrite.Sub(midpoint), uncertainty
+}
+
 // isZero lets us shortcut Earliest and Latest when the skew represents a
 // perfect unskewed clock (such as for a local writer).
 func (skew Skew) isZero() bool {
@@ -28,6 +28,36 @@ func (s *SkewSuite) TestZero(c *gc.C) {
 	c.Check(skew.Latest(now), gc.Equals, now)
 }
 
+func (s *SkewSuite) TestZeroOffset(c *gc.C) {
+	offset, uncertainty := lease.Skew{}.Offset()
+	c.Check(offset, gc.Equals, time.Duration(0))
+	c.Check(uncertainty, gc.Equals, time.Duration(0))
+}
+
+func (s *SkewSuite) TestOffset(c *gc.C) {
+	now := time.Now()
+	oneSecondAgo := now.Add(-time.Second)
+	threeSecondsAgo := now.Add(-3 * time.Second)
+	sevenSecondsLater := now.Add(7 * time.Second)
+
+	// Between T-3 and T-1, the remote clock read T+7: it's 9 seconds
+	// ahead of ours, give or take a second.
+	skew := lease.Skew{
+		LastWrite:  sevenSecondsLater,
+		ReadAfter:  threeSecondsAgo,
+		ReadBefore: oneSecondAgo,
+	}
+	offset, uncertainty := skew.Offset()
+	c.Check(offset, gc.Equals, 9*time.Second)
+	c.Check(uncertainty, gc.Equals, time.Second)
+
+	// And the same, the other way round.
+	skew.LastWrite = now.Add(-11 * time.Second)
+	offset, uncertainty = skew.Offset()
+	c.Check(offset, gc.Equals, -9*time.Second)
+	c.Check(uncertainty, gc.Equals, time.Second)
+}
+
 func (s *SkewSuite) TestApparentPastWrite(c *gc.C) {
 	now := time.Now()
 	c.Logf("now: %s", now)
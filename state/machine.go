@@ -130,6 +130,9 @@ type machineDoc struct {
 	// Placement is the placement directive that should be used when provisioning
 	// an instance for the machine.
 	Placement string `bson:",omitempty"`
+	// CloudInitUserData holds cloud-init user data to be added to the
+	// configuration generated when provisioning the machine.
+	CloudInitUserData string `bson:",omitempty"`
 }
 
 func newMachine(st *State, doc *machineDoc) *Machine {
@@ -169,6 +172,12 @@ func (m *Machine) globalKey() string {
 	return machineGlobalKey(m.doc.Id)
 }
 
+// globalInstanceKey returns the global database key under which the
+// history of the machine's instance status is recorded.
+func (m *Machine) globalInstanceKey() string {
+	return m.globalKey() + "#instance"
+}
+
 // instanceData holds attributes relevant to a provisioned machine.
 type instanceData struct {
 	DocID      string      `bson:"_id"`
@@ -849,6 +858,11 @@ func (m *Machine) Remove() (err error) {
 	ops = append(ops, removeContainerRefOps(m.st, m.Id())...)
 	ops = append(ops, filesystemOps...)
 	ops = append(ops, volumeOps...)
+	quotaOps, err := m.st.quotaOps(machinesQuotaDelta(-1, &m.doc))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ops = append(ops, quotaOps...)
 	ipAddresses, err := m.st.AllocatedIPAddresses(m.Id())
 	if err != nil {
 		return errors.Trace(err)
@@ -957,6 +971,10 @@ func (m *Machine) InstanceStatus() (string, error) {
 func (m *Machine) SetInstanceStatus(status string) (err error) {
 	defer errors.DeferredAnnotatef(&err, "cannot set instance status for machine %q", m)
 
+	oldStatus, err := m.InstanceStatus()
+	if err != nil && !errors.IsNotProvisioned(err) {
+		return err
+	}
 	ops := []txn.Op{
 		{
 			C:      instanceDataC,
@@ -966,12 +984,26 @@ func (m *Machine) SetInstanceStatus(status string) (err error) {
 		},
 	}
 
-	if err = m.st.runTransaction(ops); err == nil {
-		return nil
-	} else if err != txn.ErrAborted {
+	if err = m.st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.NotProvisionedf("machine %v", m.Id())
+	} else if err != nil {
 		return err
 	}
-	return errors.NotProvisionedf("machine %v", m.Id())
+	// The instance status has no time of its own, so each new
+	// status is recorded in the history as it is set.
+	if status != oldStatus {
+		if err := recordStatusHistory(Status(status), "", m.globalInstanceKey(), m.st); err != nil {
+			logger.Errorf("could not record instance status history of machine %q: %v", m, err)
+		}
+	}
+	return nil
+}
+
+// InstanceStatusHistory returns a slice of StatusInfo items, most
+// recent first, representing the instance statuses set for this
+// machine, including the current one, that match filter.
+func (m *Machine) InstanceStatusHistory(filter StatusHistoryFilter) ([]StatusInfo, error) {
+	return filteredStatusHistory(filter, m.globalInstanceKey(), m.st)
 }
 
 // AvailabilityZone returns the provier-specific instance availability
@@ -1393,6 +1425,12 @@ func (m *Machine) String() string {
 	return m.doc.Id
 }
 
+// CloudInitUserData returns the cloud-init user data that was given
+// when the machine was added, if any.
+func (m *Machine) CloudInitUserData() string {
+	return m.doc.CloudInitUserData
+}
+
 // Placement returns the machine's Placement structure that should be used when
 // provisioning an instance for the machine.
 func (m *Machine) Placement() string {
@@ -1466,9 +1504,16 @@ func (m *Machine) Status() (StatusInfo, error) {
 
 // SetStatus sets the status of the machine.
 func (m *Machine) SetStatus(status Status, info string, data map[string]interface{}) error {
+	oldDoc, err := getStatus(m.st, m.globalKey())
+	if IsStatusNotFound(err) {
+		logger.Debugf("there is no state for %q yet", m.globalKey())
+	} else if err != nil {
+		logger.Debugf("cannot get state for %q yet", m.globalKey())
+	}
+
 	// If a machine is not yet provisioned, we allow its status
 	// to be set back to pending (when a retry is to occur).
-	_, err := m.InstanceId()
+	_, err = m.InstanceId()
 	allowPending := errors.IsNotProvisioned(err)
 	doc, err := newMachineStatusDoc(status, info, data, allowPending)
 	if err != nil {
@@ -1484,9 +1529,21 @@ func (m *Machine) SetStatus(status Status, info string, data map[string]interfac
 	if err = m.st.runTransaction(ops); err != nil {
 		return fmt.Errorf("cannot set status of machine %q: %v", m, onAbort(err, errNotAlive))
 	}
+
+	if oldDoc.Status != "" {
+		if err := updateStatusHistory(oldDoc, m.globalKey(), m.st); err != nil {
+			logger.Errorf("could not record status history before change to %q: %v", status, err)
+		}
+	}
 	return nil
 }
 
+// StatusHistory returns a slice of StatusInfo items, most recent
+// first, representing past statuses for this machine that match filter.
+func (m *Machine) StatusHistory(filter StatusHistoryFilter) ([]StatusInfo, error) {
+	return filteredStatusHistory(filter, m.globalKey(), m.st)
+}
+
 // Clean returns true if the machine does not have any deployed units or containers.
 func (m *Machine) Clean() bool {
 	return m.doc.Clean
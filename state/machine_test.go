@@ -1160,6 +1160,22 @@ func (s *MachineSuite) TestMachineSetInstanceStatus(c *gc.C) {
 	c.Assert(status, gc.DeepEquals, "ALIVE")
 }
 
+func (s *MachineSuite) TestMachineInstanceStatusHistory(c *gc.C) {
+	err := s.machine.SetProvisioned("umbrella/0", "fake_nonce", nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Only changes in the instance status are recorded.
+	for _, status := range []string{"ALIVE", "ALIVE", "DEAD"} {
+		err = s.machine.SetInstanceStatus(status)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	history, err := s.machine.InstanceStatusHistory(state.StatusHistoryFilter{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 2)
+	c.Check(history[0].Status, gc.Equals, state.Status("DEAD"))
+	c.Check(history[1].Status, gc.Equals, state.Status("ALIVE"))
+}
+
 func (s *MachineSuite) TestNotProvisionedMachineSetInstanceStatus(c *gc.C) {
 	err := s.machine.SetInstanceStatus("ALIVE")
 	c.Assert(err, gc.ErrorMatches, ".* not provisioned")
@@ -1689,6 +1705,24 @@ func (s *MachineSuite) TestConstraintsLifecycle(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `constraints not found`)
 }
 
+func (s *MachineSuite) TestMachineStatusHistory(c *gc.C) {
+	err := s.machine.SetStatus(state.StatusStarted, "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.machine.SetStatus(state.StatusStopped, "", nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := s.machine.StatusHistory(state.StatusHistoryFilter{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 2)
+	c.Check(history[0].Status, gc.Equals, state.StatusStarted)
+	c.Check(history[1].Status, gc.Equals, state.StatusPending)
+
+	history, err = s.machine.StatusHistory(state.StatusHistoryFilter{Size: 1})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 1)
+	c.Check(history[0].Status, gc.Equals, state.StatusStarted)
+}
+
 func (s *MachineSuite) TestGetSetStatusWhileAlive(c *gc.C) {
 	err := s.machine.SetStatus(state.StatusError, "", nil)
 	c.Assert(err, gc.ErrorMatches, `cannot set status "error" without info`)
@@ -0,0 +1,695 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"io"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state/storage"
+	"github.com/juju/juju/state/toolstorage"
+	"github.com/juju/juju/version"
+)
+
+// importBatchSize is the maximum number of documents inserted in
+// a single transaction when importing an environment.
+const importBatchSize = 500
+
+// MigrationMode describes the part an environment is playing in its
+// migration between state servers, if any.
+type MigrationMode string
+
+const (
+	// MigrationNone is the mode of an environment that is not being
+	// migrated.
+	MigrationNone MigrationMode = ""
+
+	// MigrationExporting is the mode of an environment that is being
+	// exported to another state server. No changes may be made to
+	// it until the migration is completed or aborted, so that none
+	// are lost.
+	MigrationExporting MigrationMode = "exporting"
+
+	// MigrationImporting is the mode of an environment that has been
+	// imported from another state server, but whose charm archives,
+	// resources and tools are still being copied. It cannot be used
+	// until the import is completed.
+	MigrationImporting MigrationMode = "importing"
+)
+
+// controllerConfigAttrs holds the environment config attributes that
+// are set by the state server hosting the environment, rather than by
+// the environment's users.
+var controllerConfigAttrs = []string{
+	"ca-cert",
+	"state-port",
+	"api-port",
+	"syslog-port",
+	"rsyslog-ca-cert",
+	"rsyslog-ca-key",
+}
+
+// EnvironmentExport holds the documents needed to recreate a hosted
+// environment in another state server: the environment's owner and
+// config, and the documents from every multi-environment collection.
+// The charm archives, charm resources and tools the environment uses
+// are too large to be held with the documents; they are identified by
+// their storage paths and versions, and copied separately with
+// OpenExportedBlob, OpenExportedTools, AddImportedBlob and
+// AddImportedTools.
+type EnvironmentExport struct {
+	Owner     string
+	Config    map[string]interface{}
+	Documents map[string][]bson.M
+	Charms    []string
+	Resources []string
+	Tools     []toolstorage.Metadata
+}
+
+// MigrationMode returns the part the environment is playing in its
+// migration between state servers, if any.
+func (e *Environment) MigrationMode() MigrationMode {
+	return e.doc.MigrationMode
+}
+
+// StartExport prepares the environment to be exported to the state
+// server whose CA certificate is given. From then on, no changes may
+// be made to the environment until AbortExport or SetMigrated is
+// called, and its agents are told to trust the new state server's CA
+// as well as their own, so that they can connect to it once the
+// environment has moved. Starting an export that is already in
+// progress is not an error.
+func (e *Environment) StartExport(targetCACert string) error {
+	if e.UUID() == e.ServerUUID() {
+		return errors.New("cannot migrate the state server environment")
+	}
+	if targetCACert == "" {
+		return errors.New("no target CA certificate specified")
+	}
+	ops := []txn.Op{{
+		C:  environmentsC,
+		Id: e.doc.UUID,
+		Assert: bson.D{
+			{"life", bson.D{{"$in", []interface{}{Alive, nil}}}},
+			{"migration-mode", bson.D{{"$in", []interface{}{MigrationExporting, nil}}}},
+		},
+		Update: bson.D{{"$set", bson.D{
+			{"migration-mode", MigrationExporting},
+			{"migration-ca-cert", targetCACert},
+		}}},
+	}}
+	if err := e.st.runTransaction(ops); err != nil {
+		if err == txn.ErrAborted {
+			err = errors.Errorf("environment %q is no longer alive, or is being imported", e.Name())
+		}
+		return errors.Annotate(err, "cannot start environment export")
+	}
+	e.doc.MigrationMode = MigrationExporting
+	e.doc.MigrationCACert = targetCACert
+	return nil
+}
+
+// AbortExport undoes StartExport, allowing the environment to be
+// changed again, when its migration cannot be completed.
+func (e *Environment) AbortExport() error {
+	ops := []txn.Op{{
+		C:      environmentsC,
+		Id:     e.doc.UUID,
+		Assert: bson.D{{"migration-mode", MigrationExporting}},
+		Update: bson.D{{"$unset", bson.D{
+			{"migration-mode", nil},
+			{"migration-ca-cert", nil},
+		}}},
+	}}
+	if err := e.st.runTransaction(ops); err != nil {
+		if err == txn.ErrAborted {
+			err = errors.Errorf("environment %q is not being exported", e.Name())
+		}
+		return errors.Annotate(err, "cannot abort environment export")
+	}
+	e.doc.MigrationMode = MigrationNone
+	e.doc.MigrationCACert = ""
+	return nil
+}
+
+// ExportEnvironment returns an export of the environment controlled by
+// this state instance, from which ImportEnvironment can recreate it in
+// another state server. The environment must have been prepared for
+// export with StartExport, so that no changes made after the export
+// are lost.
+func (st *State) ExportEnvironment() (*EnvironmentExport, error) {
+	env, err := st.Environment()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if env.UUID() == env.ServerUUID() {
+		return nil, errors.New("cannot export the state server environment")
+	}
+	if env.MigrationMode() != MigrationExporting {
+		return nil, errors.Errorf("environment %q is not being exported", env.Name())
+	}
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	export := &EnvironmentExport{
+		Owner:     env.Owner().Username(),
+		Config:    cfg.AllAttrs(),
+		Documents: make(map[string][]bson.M),
+	}
+	for collName := range multiEnvCollections {
+		docs, err := st.exportCollection(collName)
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot export %s", collName)
+		}
+		if len(docs) > 0 {
+			export.Documents[collName] = docs
+		}
+	}
+	if export.Charms, err = st.exportedCharms(); err != nil {
+		return nil, errors.Annotate(err, "cannot export charms")
+	}
+	if export.Resources, err = st.exportedResources(); err != nil {
+		return nil, errors.Annotate(err, "cannot export resources")
+	}
+	if export.Tools, err = st.exportedTools(cfg); err != nil {
+		return nil, errors.Annotate(err, "cannot export tools")
+	}
+	return export, nil
+}
+
+func (st *State) exportCollection(collName string) ([]bson.M, error) {
+	coll, closer := st.getCollection(collName)
+	defer closer()
+	var docs []bson.M
+	if err := coll.Find(nil).All(&docs); err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, doc := range docs {
+		// The transaction fields belong to the source database, and
+		// are recreated when the documents are inserted.
+		delete(doc, "txn-revno")
+		delete(doc, "txn-queue")
+	}
+	return docs, nil
+}
+
+// exportedCharms returns the storage paths of the environment's charm
+// archives.
+func (st *State) exportedCharms() ([]string, error) {
+	charms, err := st.AllCharms()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var paths []string
+	for _, ch := range charms {
+		if path := ch.StoragePath(); path != "" {
+			// Charms without a storage path are placeholders, or
+			// are still being uploaded.
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// exportedResources returns the storage paths of the content of the
+// environment's charm resources.
+func (st *State) exportedResources() ([]string, error) {
+	docs, err := st.allResourcesDocs()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var paths []string
+	for _, doc := range docs {
+		for _, r := range doc.Resources {
+			paths = append(paths, r.StoragePath)
+		}
+	}
+	return paths, nil
+}
+
+// exportedTools returns the metadata of the tools stored for the
+// environment's agent version; no other tools are needed to run the
+// environment's agents.
+func (st *State) exportedTools(cfg *config.Config) ([]toolstorage.Metadata, error) {
+	agentVersion, ok := cfg.AgentVersion()
+	if !ok {
+		return nil, errors.New("no agent version set in environment config")
+	}
+	stor, err := st.ToolsStorage()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer stor.Close()
+	all, err := stor.AllMetadata()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var result []toolstorage.Metadata
+	for _, metadata := range all {
+		if metadata.Version.Number == agentVersion {
+			result = append(result, metadata)
+		}
+	}
+	return result, nil
+}
+
+// OpenExportedBlob returns a reader for the charm archive or resource
+// content stored at the given path in an environment being exported,
+// and its size. The caller is responsible for closing the reader.
+func (st *State) OpenExportedBlob(path string) (io.ReadCloser, int64, error) {
+	if err := st.checkMigrationMode(MigrationExporting); err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	stor := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
+	r, size, err := stor.Get(path)
+	if err != nil {
+		return nil, 0, errors.Annotatef(err, "cannot read %q", path)
+	}
+	return r, size, nil
+}
+
+// OpenExportedTools returns the metadata and a reader for the tools
+// tarball with the given version in an environment being exported.
+// The caller is responsible for closing the reader.
+func (st *State) OpenExportedTools(vers version.Binary) (toolstorage.Metadata, io.ReadCloser, error) {
+	if err := st.checkMigrationMode(MigrationExporting); err != nil {
+		return toolstorage.Metadata{}, nil, errors.Trace(err)
+	}
+	stor, err := st.ToolsStorage()
+	if err != nil {
+		return toolstorage.Metadata{}, nil, errors.Trace(err)
+	}
+	defer stor.Close()
+	return stor.Tools(vers)
+}
+
+// checkMigrationMode returns an error if the environment controlled by
+// this state instance is not in the given migration mode.
+func (st *State) checkMigrationMode(mode MigrationMode) error {
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if env.MigrationMode() != mode {
+		return errors.Errorf("environment %q is not %s", env.Name(), mode)
+	}
+	return nil
+}
+
+// ImportEnvironment recreates an environment exported from another
+// state server as a hosted environment of this one. The import is
+// refused, and nothing is changed, if the environment already exists,
+// if its owner is not known, or if its agents could not work with this
+// state server: they must not be newer than the state server. The
+// environment's config is given this state server's CA certificate and
+// ports.
+//
+// The environment is created in the MigrationImporting mode: its
+// charm archives, resources and tools must be added with
+// AddImportedBlob and AddImportedTools, and CompleteImport called,
+// before it can be used. Environment and State instances for the
+// imported environment are returned.
+func (st *State) ImportEnvironment(export *EnvironmentExport) (_ *Environment, _ *State, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot import environment")
+
+	cfg, err := config.New(config.NoDefaults, export.Config)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	uuid, ok := cfg.UUID()
+	if !ok {
+		return nil, nil, errors.New("environment uuid was not supplied")
+	}
+	owner := names.NewUserTag(export.Owner)
+	if owner.IsLocal() {
+		if _, err := st.User(owner); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+	ssEnv, err := st.StateServerEnvironment()
+	if err != nil {
+		return nil, nil, errors.Annotate(err, "could not load state server environment")
+	}
+	ssCfg, err := ssEnv.Config()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if err := checkImportCompatible(cfg, ssCfg); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	envTag := names.NewEnvironTag(uuid)
+	if _, err := st.GetEnvironment(envTag); err == nil {
+		return nil, nil, errors.AlreadyExistsf("environment %q", uuid)
+	} else if !errors.IsNotFound(err) {
+		return nil, nil, errors.Trace(err)
+	}
+	if err := setControllerConfig(export.Documents, uuid, ssCfg); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+
+	newState, err := st.ForEnviron(envTag)
+	if err != nil {
+		return nil, nil, errors.Annotate(err, "could not create state for imported environment")
+	}
+	defer func() {
+		if err != nil {
+			newState.Close()
+		}
+	}()
+	imported, err := newState.importDocuments(uuid, export.Documents)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+
+	// Finally, the environment document itself is created, so the
+	// environment only becomes visible once its documents are all in
+	// place. Quotas are not exported; the environment's usage is
+	// counted afresh and added to that of its owner here.
+	usage, err := st.environUsage(uuid)
+	if err != nil {
+		newState.removeDocuments(imported)
+		return nil, nil, errors.Trace(err)
+	}
+	quotaOps, err := st.ownerQuotaUsageOps(owner, usageDelta(usage, 1))
+	if err != nil {
+		newState.removeDocuments(imported)
+		return nil, nil, errors.Trace(err)
+	}
+	createOp := createEnvironmentOp(newState, owner, cfg.Name(), uuid, ssEnv.UUID())
+	createOp.Insert.(*environmentDoc).MigrationMode = MigrationImporting
+	ops := []txn.Op{
+		createOp,
+		createUniqueOwnerEnvNameOp(owner, cfg.Name()),
+		incEnvironCountOp(),
+	}
+	ops = append(ops, quotaOps...)
+	if err := newState.runTransactionNoEnvAliveAssert(ops); err != nil {
+		newState.removeDocuments(imported)
+		if err == txn.ErrAborted {
+			err = errors.AlreadyExistsf("environment %q for %s", cfg.Name(), owner.Username())
+		}
+		return nil, nil, errors.Trace(err)
+	}
+	newEnv, err := newState.Environment()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return newEnv, newState, nil
+}
+
+// setControllerConfig replaces the state server's attributes in the
+// exported environment config document with those of this state
+// server, so that the environment's agents trust its CA and use its
+// ports.
+func setControllerConfig(documents map[string][]bson.M, uuid string, ssCfg *config.Config) error {
+	docID := uuid + ":" + environGlobalKey
+	ssAttrs := ssCfg.AllAttrs()
+	for _, doc := range documents[settingsC] {
+		if doc["_id"] != docID {
+			continue
+		}
+		for _, name := range controllerConfigAttrs {
+			if value, ok := ssAttrs[name]; ok {
+				doc[name] = value
+			} else {
+				delete(doc, name)
+			}
+		}
+		return nil
+	}
+	return errors.New("no environment config document exported")
+}
+
+// checkImportCompatible returns an error if the agents of an
+// environment with the given config could not be run by the state
+// server with the given config. The environment's CA certificate need
+// not match; the environment is given that of the state server.
+func checkImportCompatible(cfg, ssCfg *config.Config) error {
+	if cfg.Type() != ssCfg.Type() {
+		return errors.Errorf("environment type %q does not match state server type %q", cfg.Type(), ssCfg.Type())
+	}
+	agentVersion, ok := cfg.AgentVersion()
+	if !ok {
+		return errors.New("no agent version set in environment config")
+	}
+	ssVersion, _ := ssCfg.AgentVersion()
+	if agentVersion.Compare(ssVersion) > 0 {
+		return errors.Errorf("environment agent version %s is newer than the state server's %s", agentVersion, ssVersion)
+	}
+	return nil
+}
+
+// AddImportedBlob stores the charm archive or resource content with
+// the given storage path and size in an environment being imported.
+func (st *State) AddImportedBlob(path string, r io.Reader, size int64) error {
+	if err := st.checkMigrationMode(MigrationImporting); err != nil {
+		return errors.Trace(err)
+	}
+	stor := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
+	if err := stor.Put(path, r, size); err != nil {
+		return errors.Annotatef(err, "cannot store %q", path)
+	}
+	return nil
+}
+
+// AddImportedTools stores the tools tarball with the given metadata
+// in an environment being imported, unless it is already stored.
+func (st *State) AddImportedTools(r io.Reader, metadata toolstorage.Metadata) error {
+	if err := st.checkMigrationMode(MigrationImporting); err != nil {
+		return errors.Trace(err)
+	}
+	stor, err := st.ToolsStorage()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer stor.Close()
+	if _, err := stor.Metadata(metadata.Version); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return errors.Trace(err)
+	}
+	if err := stor.AddTools(r, metadata); err != nil {
+		return errors.Annotatef(err, "cannot store tools %s", metadata.Version)
+	}
+	return nil
+}
+
+// CompleteImport makes an environment imported by ImportEnvironment
+// usable, once its charm archives, resources and tools have been
+// added. It returns an error if any charm archive or resource is
+// missing; tools not stored by the state server are found elsewhere
+// when needed, as for any environment.
+func (st *State) CompleteImport() (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot complete environment import")
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if env.MigrationMode() != MigrationImporting {
+		return errors.Errorf("environment %q is not being imported", env.Name())
+	}
+	charms, err := st.exportedCharms()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	resources, err := st.exportedResources()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	stor := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
+	for _, path := range append(charms, resources...) {
+		r, _, err := stor.Get(path)
+		if err != nil {
+			return errors.Annotatef(err, "cannot find %q", path)
+		}
+		r.Close()
+	}
+	ops := []txn.Op{{
+		C:      environmentsC,
+		Id:     env.doc.UUID,
+		Assert: bson.D{{"migration-mode", MigrationImporting}},
+		Update: bson.D{{"$unset", bson.D{{"migration-mode", nil}}}},
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.Errorf("environment %q is not being imported", env.Name())
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func (st *State) removeBlobs(paths []string) {
+	stor := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
+	for _, path := range paths {
+		if err := stor.Remove(path); err != nil && !errors.IsNotFound(err) {
+			logger.Errorf("cannot remove %q: %v", path, err)
+		}
+	}
+}
+
+// importDocuments inserts the exported documents, in batches so that
+// no single transaction grows too large, and returns the operations
+// that inserted them. Every document must belong to the environment
+// with the given UUID, both by its env-uuid field and by the prefix of
+// its id; nothing is inserted unless all do. If any batch fails, the
+// documents inserted by earlier batches are removed again, leaving any
+// documents that were already present untouched.
+func (st *State) importDocuments(uuid string, documents map[string][]bson.M) ([]txn.Op, error) {
+	var ops []txn.Op
+	for collName, docs := range documents {
+		if !multiEnvCollections.Contains(collName) {
+			return nil, errors.Errorf("cannot import documents into %q", collName)
+		}
+		for _, doc := range docs {
+			if doc["env-uuid"] != uuid {
+				return nil, errors.Errorf("document %v in %q does not belong to environment %q", doc["_id"], collName, uuid)
+			}
+			if id, ok := doc["_id"].(string); !ok || !strings.HasPrefix(id, uuid+":") {
+				return nil, errors.Errorf("document %v in %q does not have an id in environment %q", doc["_id"], collName, uuid)
+			}
+			ops = append(ops, txn.Op{
+				C:      collName,
+				Id:     doc["_id"],
+				Assert: txn.DocMissing,
+				Insert: doc,
+			})
+		}
+	}
+	for inserted := 0; inserted < len(ops); {
+		n := len(ops) - inserted
+		if n > importBatchSize {
+			n = importBatchSize
+		}
+		if err := st.runRawTransaction(ops[inserted : inserted+n]); err != nil {
+			// A failed transaction changes nothing, so only the
+			// batches before it need removing.
+			st.removeDocuments(ops[:inserted])
+			if err == txn.ErrAborted {
+				return nil, errors.Errorf("environment documents already exist")
+			}
+			return nil, errors.Trace(err)
+		}
+		inserted += n
+	}
+	return ops, nil
+}
+
+// removeDocuments removes the documents inserted by the given
+// operations, as returned by importDocuments.
+func (st *State) removeDocuments(inserted []txn.Op) {
+	ops := make([]txn.Op, len(inserted))
+	for i, op := range inserted {
+		ops[i] = txn.Op{
+			C:      op.C,
+			Id:     op.Id,
+			Remove: true,
+		}
+	}
+	for len(ops) > 0 {
+		n := len(ops)
+		if n > importBatchSize {
+			n = importBatchSize
+		}
+		if err := st.runRawTransaction(ops[:n]); err != nil {
+			logger.Errorf("cannot remove imported documents: %v", err)
+		}
+		ops = ops[n:]
+	}
+}
+
+// RemoveImportedEnvironment undoes ImportEnvironment, removing the
+// environment controlled by this state instance along with all of its
+// documents, charm archives and resources. Unlike Destroy, it leaves the
+// environment's machines and services untouched, as they are still
+// managed by the state server the environment was exported from.
+func (st *State) RemoveImportedEnvironment() error {
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if env.UUID() == env.ServerUUID() {
+		return errors.New("cannot remove the state server environment")
+	}
+	charms, err := st.exportedCharms()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	resources, err := st.exportedResources()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if env.Life() == Alive {
+		if err := env.startDestroy(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := st.RemoveAllEnvironDocs(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := st.runTransaction([]txn.Op{decEnvironCountOp()}); err != nil {
+		return errors.Trace(err)
+	}
+	st.removeBlobs(append(charms, resources...))
+	return nil
+}
+
+// SetMigrated records that the environment, prepared for export with
+// StartExport, has been imported into another state server, whose API
+// servers are at the given addresses. The environment becomes Dead, so
+// its workers are stopped and it can no longer be changed, but its
+// agents are still allowed to connect: they are given the new
+// addresses by APIHostPorts, and so move to the new state server,
+// whose CA they already trust.
+func (e *Environment) SetMigrated(hostPorts [][]network.HostPort) error {
+	if e.UUID() == e.ServerUUID() {
+		return errors.New("cannot migrate the state server environment")
+	}
+	if len(hostPorts) == 0 {
+		return errors.New("no API addresses specified")
+	}
+	migratedTo := fromNetworkHostsPorts(hostPorts)
+	ops := []txn.Op{{
+		C:  environmentsC,
+		Id: e.doc.UUID,
+		Assert: bson.D{
+			{"life", bson.D{{"$in", []interface{}{Alive, nil}}}},
+			{"migration-mode", MigrationExporting},
+		},
+		Update: bson.D{
+			{"$set", bson.D{
+				{"life", Dead},
+				{"migrated-to", migratedTo},
+			}},
+			{"$unset", bson.D{{"migration-mode", nil}}},
+		},
+	}}
+	if err := e.st.runTransaction(ops); err != nil {
+		if err == txn.ErrAborted {
+			err = errors.Errorf("environment %q is not being exported", e.Name())
+		}
+		return errors.Annotate(err, "cannot set environment migrated")
+	}
+	e.doc.Life = Dead
+	e.doc.MigratedTo = migratedTo
+	e.doc.MigrationMode = MigrationNone
+	return nil
+}
+
+// MigratedTo returns the API addresses of the state server the
+// environment has been migrated to, or nil if it has not been
+// migrated.
+func (e *Environment) MigratedTo() [][]network.HostPort {
+	if len(e.doc.MigratedTo) == 0 {
+		return nil
+	}
+	return networkHostsPorts(e.doc.MigratedTo)
+}
@@ -0,0 +1,316 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/storage"
+	statetesting "github.com/juju/juju/state/testing"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+type MigrationSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&MigrationSuite{})
+
+// makeEnvironment returns a hosted environment with a machine and
+// a service whose charm archive is in storage.
+func (s *MigrationSuite) makeEnvironment(c *gc.C) *state.State {
+	st := s.factory.MakeEnvironment(c, nil)
+	f := factory.NewFactory(st)
+	f.MakeMachine(c, nil)
+	ch := f.MakeCharm(c, nil)
+	stor := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
+	err := stor.Put(ch.StoragePath(), bytes.NewReader([]byte("archive")), 7)
+	c.Assert(err, jc.ErrorIsNil)
+	f.MakeService(c, &factory.ServiceParams{Charm: ch})
+	return st
+}
+
+// startExport prepares the environment controlled by st for export.
+func (s *MigrationSuite) startExport(c *gc.C, st *state.State) *state.Environment {
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.StartExport(testing.OtherCACert)
+	c.Assert(err, jc.ErrorIsNil)
+	return env
+}
+
+// exportAndRemove exports the environment controlled by st, and then
+// removes it, as if it had been exported from another state server.
+func (s *MigrationSuite) exportAndRemove(c *gc.C, st *state.State) *state.EnvironmentExport {
+	s.startExport(c, st)
+	export, err := st.ExportEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = st.RemoveImportedEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	return export
+}
+
+func (s *MigrationSuite) TestExportImport(c *gc.C) {
+	st := s.makeEnvironment(c)
+	defer st.Close()
+	env := s.startExport(c, st)
+	export, err := st.ExportEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(export.Charms, gc.HasLen, 1)
+	c.Assert(export.Documents["machines"], gc.HasLen, 1)
+	c.Assert(export.Documents["services"], gc.HasLen, 1)
+
+	// The charm archive is copied separately from the documents.
+	r, size, err := st.OpenExportedBlob(export.Charms[0])
+	c.Assert(err, jc.ErrorIsNil)
+	archive, err := ioutil.ReadAll(r)
+	r.Close()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(size, gc.Equals, int64(7))
+
+	// Remove the environment, as if it had been exported from
+	// another state server, and import it again.
+	err = st.RemoveImportedEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.GetEnvironment(env.EnvironTag())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	imported, importedSt, err := s.State.ImportEnvironment(export)
+	c.Assert(err, jc.ErrorIsNil)
+	defer importedSt.Close()
+	c.Assert(imported.UUID(), gc.Equals, env.UUID())
+	c.Assert(imported.Name(), gc.Equals, env.Name())
+	c.Assert(imported.Owner(), gc.Equals, env.Owner())
+	c.Assert(imported.ServerUUID(), gc.Equals, s.State.EnvironUUID())
+	c.Assert(imported.Life(), gc.Equals, state.Alive)
+	c.Assert(imported.MigrationMode(), gc.Equals, state.MigrationImporting)
+
+	machines, err := importedSt.AllMachines()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(machines, gc.HasLen, 1)
+	services, err := importedSt.AllServices()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(services, gc.HasLen, 1)
+
+	// The import cannot be completed until the charm archive has
+	// been added.
+	err = importedSt.CompleteImport()
+	c.Assert(err, gc.ErrorMatches, `cannot complete environment import: cannot find ".*": .*`)
+	err = importedSt.AddImportedBlob(export.Charms[0], bytes.NewReader(archive), size)
+	c.Assert(err, jc.ErrorIsNil)
+	err = importedSt.CompleteImport()
+	c.Assert(err, jc.ErrorIsNil)
+	err = imported.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(imported.MigrationMode(), gc.Equals, state.MigrationNone)
+
+	ch, _, err := services[0].Charm()
+	c.Assert(err, jc.ErrorIsNil)
+	stor := storage.NewStorage(importedSt.EnvironUUID(), importedSt.MongoSession())
+	r, _, err = stor.Get(ch.StoragePath())
+	c.Assert(err, jc.ErrorIsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "archive")
+
+	// The imported environment is fully usable.
+	_, err = importedSt.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *MigrationSuite) TestExportRequiresStartExport(c *gc.C) {
+	st := s.makeEnvironment(c)
+	defer st.Close()
+	_, err := st.ExportEnvironment()
+	c.Assert(err, gc.ErrorMatches, `environment ".*" is not being exported`)
+	_, _, err = st.OpenExportedBlob("charms/foo")
+	c.Assert(err, gc.ErrorMatches, `environment ".*" is not exporting`)
+}
+
+func (s *MigrationSuite) TestStartAndAbortExport(c *gc.C) {
+	st := s.makeEnvironment(c)
+	defer st.Close()
+	env := s.startExport(c, st)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationExporting)
+	trusted, err := st.TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(trusted, gc.Equals, testing.CACert+testing.OtherCACert)
+
+	// Starting again is harmless.
+	err = env.StartExport(testing.OtherCACert)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = env.AbortExport()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationNone)
+	trusted, err = st.TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(trusted, gc.Equals, testing.CACert)
+
+	err = env.AbortExport()
+	c.Assert(err, gc.ErrorMatches, `cannot abort environment export: environment ".*" is not being exported`)
+}
+
+func (s *MigrationSuite) TestImportExistingEnvironment(c *gc.C) {
+	st := s.makeEnvironment(c)
+	defer st.Close()
+	s.startExport(c, st)
+	export, err := st.ExportEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	_, _, err = s.State.ImportEnvironment(export)
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+}
+
+func (s *MigrationSuite) TestImportReplacesCACert(c *gc.C) {
+	st := s.makeEnvironment(c)
+	defer st.Close()
+	export := s.exportAndRemove(c, st)
+	for _, doc := range export.Documents["settings"] {
+		if doc["_id"] == st.EnvironUUID()+":e" {
+			doc["ca-cert"] = testing.OtherCACert
+		}
+	}
+
+	_, importedSt, err := s.State.ImportEnvironment(export)
+	c.Assert(err, jc.ErrorIsNil)
+	defer importedSt.Close()
+	cfg, err := importedSt.EnvironConfig()
+	c.Assert(err, jc.ErrorIsNil)
+	caCert, _ := cfg.CACert()
+	c.Assert(caCert, gc.Equals, testing.CACert)
+}
+
+func (s *MigrationSuite) TestImportNewerAgentVersion(c *gc.C) {
+	st := s.makeEnvironment(c)
+	defer st.Close()
+	export := s.exportAndRemove(c, st)
+
+	export.Config["agent-version"] = "9.9.9"
+	_, _, err := s.State.ImportEnvironment(export)
+	c.Assert(err, gc.ErrorMatches, "cannot import environment: environment agent version 9.9.9 is newer than the state server's 1.2.3")
+	s.assertNotImported(c, st)
+}
+
+func (s *MigrationSuite) TestImportRejectsForeignIds(c *gc.C) {
+	st := s.makeEnvironment(c)
+	defer st.Close()
+	export := s.exportAndRemove(c, st)
+	machines := export.Documents["machines"]
+	c.Assert(machines, gc.Not(gc.HasLen), 0)
+	machines[0]["_id"] = s.State.EnvironUUID() + ":99"
+
+	_, _, err := s.State.ImportEnvironment(export)
+	c.Assert(err, gc.ErrorMatches, `cannot import environment: document .*:99 in "machines" does not have an id in environment ".*"`)
+	s.assertNotImported(c, st)
+	_, err = s.State.Machine("99")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *MigrationSuite) TestImportFailureKeepsExistingDocuments(c *gc.C) {
+	st := s.makeEnvironment(c)
+	defer st.Close()
+	export := s.exportAndRemove(c, st)
+
+	// A document already present in the target aborts the import,
+	// and must survive it.
+	existing := export.Documents["machines"][0]
+	machines := s.State.MongoSession().DB("juju").C("machines")
+	err := machines.Insert(existing)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, _, err = s.State.ImportEnvironment(export)
+	c.Assert(err, gc.ErrorMatches, "cannot import environment: environment documents already exist")
+	count, err := machines.FindId(existing["_id"]).Count()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(count, gc.Equals, 1)
+}
+
+func (s *MigrationSuite) TestAddImportedBlobNotImporting(c *gc.C) {
+	st := s.makeEnvironment(c)
+	defer st.Close()
+	err := st.AddImportedBlob("charms/foo", bytes.NewReader(nil), 0)
+	c.Assert(err, gc.ErrorMatches, `environment ".*" is not importing`)
+}
+
+func (s *MigrationSuite) assertNotImported(c *gc.C, st *state.State) {
+	_, err := s.State.GetEnvironment(st.EnvironTag())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	machines, err := st.AllMachines()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(machines, gc.HasLen, 0)
+}
+
+func (s *MigrationSuite) TestExportStateServerEnvironment(c *gc.C) {
+	_, err := s.State.ExportEnvironment()
+	c.Assert(err, gc.ErrorMatches, "cannot export the state server environment")
+	env, err := s.State.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.StartExport(testing.OtherCACert)
+	c.Assert(err, gc.ErrorMatches, "cannot migrate the state server environment")
+}
+
+func (s *MigrationSuite) TestSetMigrated(c *gc.C) {
+	st := s.factory.MakeEnvironment(c, nil)
+	defer st.Close()
+	env, err := st.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.MigratedTo(), gc.IsNil)
+
+	w := st.WatchAPIHostPorts()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, st, w)
+	wc.AssertOneChange()
+
+	target := [][]network.HostPort{network.NewHostPorts(17070, "10.0.0.1")}
+	err = env.SetMigrated(target)
+	c.Assert(err, gc.ErrorMatches, `cannot set environment migrated: environment ".*" is not being exported`)
+
+	err = env.StartExport(testing.OtherCACert)
+	c.Assert(err, jc.ErrorIsNil)
+	wc.AssertOneChange()
+	err = env.SetMigrated(target)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.Life(), gc.Equals, state.Dead)
+	wc.AssertOneChange()
+
+	err = env.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.Life(), gc.Equals, state.Dead)
+	c.Assert(env.MigratedTo(), jc.DeepEquals, target)
+	c.Assert(env.MigrationMode(), gc.Equals, state.MigrationNone)
+
+	// The environment's agents are given the new addresses, and
+	// continue to trust the new state server's CA; the state
+	// server's own addresses are unaffected.
+	hostPorts, err := st.APIHostPorts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(hostPorts, jc.DeepEquals, target)
+	hostPorts, err = s.State.APIHostPorts()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(hostPorts, gc.Not(jc.DeepEquals), target)
+	trusted, err := st.TrustedCACert()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(trusted, gc.Equals, testing.CACert+testing.OtherCACert)
+
+	err = env.SetMigrated(target)
+	c.Assert(err, gc.ErrorMatches, `cannot set environment migrated: environment ".*" is not being exported`)
+}
+
+func (s *MigrationSuite) TestSetMigratedStateServerEnvironment(c *gc.C) {
+	env, err := s.State.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.SetMigrated([][]network.HostPort{network.NewHostPorts(17070, "10.0.0.1")})
+	c.Assert(err, gc.ErrorMatches, "cannot migrate the state server environment")
+}
@@ -161,6 +161,10 @@ var indexes = []struct {
 	key        []string
 	unique     bool
 	sparse     bool
+	// background indexes are built without blocking other
+	// operations on their collection, for collections that
+	// may already be large when the index is first added.
+	background bool
 }{
 
 	// Create an upgrade step to remove old indexes when editing or removing
@@ -179,6 +183,7 @@ var indexes = []struct {
 	{networkInterfacesC, []string{"env-uuid", "machineid"}, false, false},
 	{blockDevicesC, []string{"env-uuid", "machineid"}, false, false},
 	{subnetsC, []string{"providerid"}, true, true},
+	{subnetsC, []string{"env-uuid", "spacename"}, false, false},
 	{ipaddressesC, []string{"uuid"}, false, false},
 	{ipaddressesC, []string{"env-uuid", "state"}, false, false},
 	{ipaddressesC, []string{"env-uuid", "subnetid"}, false, false},
@@ -188,6 +193,21 @@ var indexes = []struct {
 	{volumesC, []string{"env-uuid", "storageid"}, false, false},
 	{filesystemsC, []string{"env-uuid", "storageid"}, false, false},
 	{statusesHistoryC, []string{"env-uuid", "entityid"}, false, false},
+	{payloadsC, []string{"env-uuid", "unitid"}, false, false},
+	{hookTranscriptsC, []string{"env-uuid", "unitid", "seq"}, false, false},
+	{configHistoryC, []string{"env-uuid", "key", "revision"}, false, false},
+	{actionSchedulesC, []string{"env-uuid", "seq"}, false, false},
+	{actionScheduleRunsC, []string{"env-uuid", "scheduleid", "run"}, false, false},
+	{actionBatchesC, []string{"env-uuid", "seq"}, false, false},
+	{actionsC, []string{"env-uuid", "batch"}, false, false},
+	{runJobsC, []string{"env-uuid", "seq"}, false, false},
+	{apiTokensC, []string{"env-uuid", "owner"}, false, false},
+	{repositoryCharmsC, []string{"user", "name", "series"}, false, false},
+	// Lets DatabaseHealth count pending transactions without scanning
+	// every transaction ever run. Existing environments may have run
+	// very many, so the index is built in the background rather than
+	// holding up the opening of state.
+	{collection: txnsC, key: []string{"s"}, background: true},
 }
 
 // The capped collection used for transaction logs defaults to 10MB.
@@ -271,7 +291,12 @@ func newState(session *mgo.Session, mongoInfo *mongo.MongoInfo, policy Policy) (
 
 	// Create DB indexes.
 	for _, item := range indexes {
-		index := mgo.Index{Key: item.key, Unique: item.unique, Sparse: item.sparse}
+		index := mgo.Index{
+			Key:        item.key,
+			Unique:     item.unique,
+			Sparse:     item.sparse,
+			Background: item.background,
+		}
 		if err := db.C(item.collection).EnsureIndex(index); err != nil {
 			return nil, errors.Annotate(err, "cannot create database index")
 		}
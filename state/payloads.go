@@ -0,0 +1,405 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jujutxn "github.com/juju/txn"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// PayloadStatus describes the state of a workload payload (for
+// example, a daemon process or a docker container) as reported by
+// the charm that launched it.
+type PayloadStatus string
+
+const (
+	// PayloadStarting indicates that the payload is being started.
+	PayloadStarting PayloadStatus = "starting"
+
+	// PayloadRunning indicates that the payload is up and running.
+	PayloadRunning PayloadStatus = "running"
+
+	// PayloadStopping indicates that the payload is being stopped.
+	PayloadStopping PayloadStatus = "stopping"
+
+	// PayloadStopped indicates that the payload is no longer running.
+	PayloadStopped PayloadStatus = "stopped"
+)
+
+// Valid returns whether the status is one of the known payload statuses.
+func (s PayloadStatus) Valid() bool {
+	switch s {
+	case PayloadStarting, PayloadRunning, PayloadStopping, PayloadStopped:
+		return true
+	}
+	return false
+}
+
+// PayloadInfo holds the information a charm supplies when
+// registering a payload.
+type PayloadInfo struct {
+	// Name is the charm-assigned name of the payload, unique for
+	// the unit.
+	Name string
+
+	// Type is the kind of payload, e.g. "docker" or "process".
+	Type string
+
+	// ID is the payload's identifier as known to its type,
+	// e.g. a docker container id or a process id.
+	ID string
+
+	// Status is the current status of the payload.
+	Status PayloadStatus
+
+	// Labels are arbitrary tags attached to the payload.
+	Labels []string
+}
+
+// Validate returns an error if the payload information is incomplete
+// or invalid.
+func (p PayloadInfo) Validate() error {
+	if p.Name == "" {
+		return errors.NotValidf("missing payload name")
+	}
+	if p.Type == "" {
+		return errors.NotValidf("payload %q with missing type", p.Name)
+	}
+	if p.ID == "" {
+		return errors.NotValidf("payload %q with missing id", p.Name)
+	}
+	if !p.Status.Valid() {
+		return errors.NotValidf("payload %q with status %q", p.Name, p.Status)
+	}
+	for _, label := range p.Labels {
+		if label == "" || strings.ContainsAny(label, " \t\n") {
+			return errors.NotValidf("payload %q with label %q", p.Name, label)
+		}
+	}
+	return nil
+}
+
+// Payload represents a workload payload registered by a unit.
+type Payload struct {
+	PayloadInfo
+
+	// Unit is the name of the unit that registered the payload.
+	Unit string
+
+	// Machine is the id of the machine the unit is assigned to.
+	Machine string
+}
+
+// FullID returns the unique identifier of the payload within the
+// environment, of the form <unit>/<name>.
+func (p Payload) FullID() string {
+	return fmt.Sprintf("%s/%s", p.Unit, p.Name)
+}
+
+// payloadDoc records information about a payload registered by a unit.
+type payloadDoc struct {
+	DocID     string   `bson:"_id"`
+	EnvUUID   string   `bson:"env-uuid"`
+	UnitID    string   `bson:"unitid"`
+	MachineID string   `bson:"machineid"`
+	Name      string   `bson:"name"`
+	Type      string   `bson:"type"`
+	RawID     string   `bson:"rawid"`
+	Status    string   `bson:"status"`
+	Labels    []string `bson:"labels,omitempty"`
+}
+
+func (doc payloadDoc) payload() Payload {
+	return Payload{
+		PayloadInfo: PayloadInfo{
+			Name:   doc.Name,
+			Type:   doc.Type,
+			ID:     doc.RawID,
+			Status: PayloadStatus(doc.Status),
+			Labels: doc.Labels,
+		},
+		Unit:    doc.UnitID,
+		Machine: doc.MachineID,
+	}
+}
+
+// payloadGlobalKey returns the global database key for the named
+// payload of the given unit.
+func payloadGlobalKey(unitName, name string) string {
+	return unitGlobalKey(unitName) + "#payload#" + name
+}
+
+// RegisterPayload records the supplied payload against the unit. If a
+// payload with the same name is already registered for the unit, it is
+// replaced.
+func (u *Unit) RegisterPayload(info PayloadInfo) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot register payload %q for unit %q", info.Name, u.Name())
+	if err := info.Validate(); err != nil {
+		return errors.Trace(err)
+	}
+	machineId, err := u.AssignedMachineId()
+	if err != nil && !errors.IsNotAssigned(err) {
+		return errors.Trace(err)
+	}
+	docID := u.st.docID(payloadGlobalKey(u.Name(), info.Name))
+	doc := &payloadDoc{
+		DocID:     docID,
+		EnvUUID:   u.st.EnvironUUID(),
+		UnitID:    u.Name(),
+		MachineID: machineId,
+		Name:      info.Name,
+		Type:      info.Type,
+		RawID:     info.ID,
+		Status:    string(info.Status),
+		Labels:    info.Labels,
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := u.Refresh(); errors.IsNotFound(err) {
+				return nil, errors.NotFoundf("unit")
+			} else if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if u.Life() == Dead {
+			return nil, errors.New("unit is dead")
+		}
+		ops := []txn.Op{{
+			C:      unitsC,
+			Id:     u.doc.DocID,
+			Assert: notDeadDoc,
+		}}
+		_, err := u.payloadDoc(info.Name)
+		switch {
+		case errors.IsNotFound(err):
+			ops = append(ops, txn.Op{
+				C:      payloadsC,
+				Id:     docID,
+				Assert: txn.DocMissing,
+				Insert: doc,
+			})
+		case err != nil:
+			return nil, errors.Trace(err)
+		default:
+			ops = append(ops, txn.Op{
+				C:      payloadsC,
+				Id:     docID,
+				Assert: txn.DocExists,
+				Update: bson.D{{"$set", bson.D{
+					{"machineid", doc.MachineID},
+					{"type", doc.Type},
+					{"rawid", doc.RawID},
+					{"status", doc.Status},
+					{"labels", doc.Labels},
+				}}},
+			})
+		}
+		return ops, nil
+	}
+	return u.st.run(buildTxn)
+}
+
+// UnregisterPayload removes the named payload from the unit. It is not
+// an error to unregister a payload that is not registered.
+func (u *Unit) UnregisterPayload(name string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot unregister payload %q for unit %q", name, u.Name())
+	docID := u.st.docID(payloadGlobalKey(u.Name(), name))
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		_, err := u.payloadDoc(name)
+		if errors.IsNotFound(err) {
+			return nil, jujutxn.ErrNoOperations
+		} else if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return []txn.Op{{
+			C:      payloadsC,
+			Id:     docID,
+			Assert: txn.DocExists,
+			Remove: true,
+		}}, nil
+	}
+	return u.st.run(buildTxn)
+}
+
+// SetPayloadStatus updates the status of the named payload of the unit.
+// It returns a NotFound error if the payload is not registered.
+func (u *Unit) SetPayloadStatus(name string, status PayloadStatus) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set status of payload %q for unit %q", name, u.Name())
+	if !status.Valid() {
+		return errors.NotValidf("payload status %q", status)
+	}
+	docID := u.st.docID(payloadGlobalKey(u.Name(), name))
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		doc, err := u.payloadDoc(name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if doc.Status == string(status) {
+			return nil, jujutxn.ErrNoOperations
+		}
+		return []txn.Op{{
+			C:      payloadsC,
+			Id:     docID,
+			Assert: txn.DocExists,
+			Update: bson.D{{"$set", bson.D{{"status", string(status)}}}},
+		}}, nil
+	}
+	return u.st.run(buildTxn)
+}
+
+// Payload returns the named payload registered by the unit.
+func (u *Unit) Payload(name string) (Payload, error) {
+	doc, err := u.payloadDoc(name)
+	if err != nil {
+		return Payload{}, errors.Trace(err)
+	}
+	return doc.payload(), nil
+}
+
+// Payloads returns all payloads registered by the unit, sorted by name.
+func (u *Unit) Payloads() ([]Payload, error) {
+	return u.st.payloads(bson.D{{"unitid", u.Name()}})
+}
+
+// AllPayloads returns all payloads registered in the environment,
+// sorted by unit and then by name.
+func (st *State) AllPayloads() ([]Payload, error) {
+	return st.payloads(nil)
+}
+
+func (st *State) payloads(query bson.D) ([]Payload, error) {
+	coll, closer := st.getCollection(payloadsC)
+	defer closer()
+
+	var docs []payloadDoc
+	if err := coll.Find(query).All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get payloads")
+	}
+	payloads := make([]Payload, len(docs))
+	for i, doc := range docs {
+		payloads[i] = doc.payload()
+	}
+	sort.Sort(payloadsByID(payloads))
+	return payloads, nil
+}
+
+func (u *Unit) payloadDoc(name string) (*payloadDoc, error) {
+	coll, closer := u.st.getCollection(payloadsC)
+	defer closer()
+
+	var doc payloadDoc
+	err := coll.FindId(payloadGlobalKey(u.Name(), name)).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("payload %q", name)
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot get payload %q", name)
+	}
+	return &doc, nil
+}
+
+// removePayloadsForUnitOps returns the operations needed to remove all
+// payloads registered by the given unit.
+func removePayloadsForUnitOps(st *State, unitName string) ([]txn.Op, error) {
+	coll, closer := st.getCollection(payloadsC)
+	defer closer()
+
+	var docs []payloadDoc
+	err := coll.Find(bson.D{{"unitid", unitName}}).Select(bson.D{{"_id", 1}}).All(&docs)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ops := make([]txn.Op, len(docs))
+	for i, doc := range docs {
+		ops[i] = txn.Op{
+			C:      payloadsC,
+			Id:     doc.DocID,
+			Remove: true,
+		}
+	}
+	return ops, nil
+}
+
+// cleanupPayloadsForRemovedUnit removes any payloads left behind by a
+// unit that has been removed from state.
+func (st *State) cleanupPayloadsForRemovedUnit(unitName string) error {
+	ops, err := removePayloadsForUnitOps(st, unitName)
+	if err != nil {
+		return errors.Annotatef(err, "cannot remove payloads for unit %q", unitName)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	return errors.Annotatef(st.runTransaction(ops), "cannot remove payloads for unit %q", unitName)
+}
+
+// FilterPayloads returns the payloads matching any of the given
+// patterns. A pattern matches a payload if it equals the payload's
+// name, type, id, status, unit, machine, service or one of its labels,
+// or if it is a glob pattern matching one of those. If no patterns are
+// supplied, all payloads are returned.
+func FilterPayloads(payloads []Payload, patterns ...string) ([]Payload, error) {
+	if len(patterns) == 0 {
+		return payloads, nil
+	}
+	var result []Payload
+	for _, p := range payloads {
+		matched, err := payloadMatches(p, patterns)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if matched {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func payloadMatches(p Payload, patterns []string) (bool, error) {
+	candidates := []string{
+		p.Name,
+		p.Type,
+		p.ID,
+		string(p.Status),
+		p.Unit,
+		p.Machine,
+		p.FullID(),
+	}
+	if names.IsValidUnit(p.Unit) {
+		serviceName, err := names.UnitService(p.Unit)
+		if err == nil {
+			candidates = append(candidates, serviceName)
+		}
+	}
+	candidates = append(candidates, p.Labels...)
+	for _, pattern := range patterns {
+		for _, candidate := range candidates {
+			if candidate == "" {
+				continue
+			}
+			matched, err := path.Match(pattern, candidate)
+			if err != nil {
+				return false, errors.Annotatef(err, "invalid payload pattern %q", pattern)
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+type payloadsByID []Payload
+
+func (p payloadsByID) Len() int           { return len(p) }
+func (p payloadsByID) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p payloadsByID) Less(i, j int) bool { return p[i].FullID() < p[j].FullID() }
@@ -0,0 +1,225 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing/factory"
+)
+
+type PayloadsSuite struct {
+	ConnSuite
+	unit *state.Unit
+}
+
+var _ = gc.Suite(&PayloadsSuite{})
+
+func (s *PayloadsSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.unit = s.Factory.MakeUnit(c, nil)
+}
+
+func (s *PayloadsSuite) newPayload(name string) state.PayloadInfo {
+	return state.PayloadInfo{
+		Name:   name,
+		Type:   "docker",
+		ID:     "id-" + name,
+		Status: state.PayloadRunning,
+		Labels: []string{"a-tag"},
+	}
+}
+
+func (s *PayloadsSuite) TestRegisterPayload(c *gc.C) {
+	err := s.unit.RegisterPayload(s.newPayload("web"))
+	c.Assert(err, jc.ErrorIsNil)
+
+	payload, err := s.unit.Payload("web")
+	c.Assert(err, jc.ErrorIsNil)
+	machineId, err := s.unit.AssignedMachineId()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(payload, jc.DeepEquals, state.Payload{
+		PayloadInfo: s.newPayload("web"),
+		Unit:        s.unit.Name(),
+		Machine:     machineId,
+	})
+}
+
+func (s *PayloadsSuite) TestRegisterPayloadIncludesEnvUUID(c *gc.C) {
+	err := s.unit.RegisterPayload(s.newPayload("web"))
+	c.Assert(err, jc.ErrorIsNil)
+
+	payloads := s.MgoSuite.Session.DB("juju").C("payloads")
+	var docs []bson.M
+	err = payloads.Find(nil).All(&docs)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(docs, gc.HasLen, 1)
+	c.Assert(docs[0]["env-uuid"], gc.Equals, s.State.EnvironUUID())
+}
+
+func (s *PayloadsSuite) TestRegisterPayloadReplaces(c *gc.C) {
+	err := s.unit.RegisterPayload(s.newPayload("web"))
+	c.Assert(err, jc.ErrorIsNil)
+
+	info := s.newPayload("web")
+	info.ID = "another-id"
+	info.Status = state.PayloadStarting
+	info.Labels = nil
+	err = s.unit.RegisterPayload(info)
+	c.Assert(err, jc.ErrorIsNil)
+
+	payloads, err := s.unit.Payloads()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(payloads, gc.HasLen, 1)
+	c.Assert(payloads[0].PayloadInfo, jc.DeepEquals, info)
+}
+
+func (s *PayloadsSuite) TestRegisterPayloadInvalid(c *gc.C) {
+	for i, test := range []struct {
+		info state.PayloadInfo
+		err  string
+	}{{
+		info: state.PayloadInfo{Type: "docker", ID: "x", Status: state.PayloadRunning},
+		err:  `.*missing payload name not valid`,
+	}, {
+		info: state.PayloadInfo{Name: "web", ID: "x", Status: state.PayloadRunning},
+		err:  `.*payload "web" with missing type not valid`,
+	}, {
+		info: state.PayloadInfo{Name: "web", Type: "docker", Status: state.PayloadRunning},
+		err:  `.*payload "web" with missing id not valid`,
+	}, {
+		info: state.PayloadInfo{Name: "web", Type: "docker", ID: "x", Status: "exploded"},
+		err:  `.*payload "web" with status "exploded" not valid`,
+	}, {
+		info: state.PayloadInfo{Name: "web", Type: "docker", ID: "x", Status: state.PayloadRunning, Labels: []string{"a b"}},
+		err:  `.*payload "web" with label "a b" not valid`,
+	}} {
+		c.Logf("test %d", i)
+		err := s.unit.RegisterPayload(test.info)
+		c.Check(err, gc.ErrorMatches, test.err)
+		c.Check(err, jc.Satisfies, errors.IsNotValid)
+	}
+}
+
+func (s *PayloadsSuite) TestRegisterPayloadDeadUnit(c *gc.C) {
+	err := s.unit.EnsureDead()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.RegisterPayload(s.newPayload("web"))
+	c.Assert(err, gc.ErrorMatches, `cannot register payload "web" for unit ".*": unit is dead`)
+}
+
+func (s *PayloadsSuite) TestUnregisterPayload(c *gc.C) {
+	err := s.unit.RegisterPayload(s.newPayload("web"))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.UnregisterPayload("web")
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.unit.Payload("web")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// Unregistering again is a no-op.
+	err = s.unit.UnregisterPayload("web")
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *PayloadsSuite) TestSetPayloadStatus(c *gc.C) {
+	err := s.unit.RegisterPayload(s.newPayload("web"))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.SetPayloadStatus("web", state.PayloadStopped)
+	c.Assert(err, jc.ErrorIsNil)
+
+	payload, err := s.unit.Payload("web")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(payload.Status, gc.Equals, state.PayloadStopped)
+}
+
+func (s *PayloadsSuite) TestSetPayloadStatusNotFound(c *gc.C) {
+	err := s.unit.SetPayloadStatus("web", state.PayloadStopped)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *PayloadsSuite) TestSetPayloadStatusInvalid(c *gc.C) {
+	err := s.unit.RegisterPayload(s.newPayload("web"))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.unit.SetPayloadStatus("web", "exploded")
+	c.Assert(err, gc.ErrorMatches, `.*payload status "exploded" not valid`)
+}
+
+func (s *PayloadsSuite) TestAllPayloads(c *gc.C) {
+	service, err := s.unit.Service()
+	c.Assert(err, jc.ErrorIsNil)
+	other := s.Factory.MakeUnit(c, &factory.UnitParams{Service: service})
+	c.Assert(other.RegisterPayload(s.newPayload("db")), jc.ErrorIsNil)
+	c.Assert(s.unit.RegisterPayload(s.newPayload("web")), jc.ErrorIsNil)
+	c.Assert(s.unit.RegisterPayload(s.newPayload("cache")), jc.ErrorIsNil)
+
+	payloads, err := s.State.AllPayloads()
+	c.Assert(err, jc.ErrorIsNil)
+	var ids []string
+	for _, p := range payloads {
+		ids = append(ids, p.FullID())
+	}
+	c.Assert(ids, jc.DeepEquals, []string{
+		s.unit.Name() + "/cache",
+		s.unit.Name() + "/web",
+		other.Name() + "/db",
+	})
+}
+
+func (s *PayloadsSuite) TestPayloadsRemovedWithUnit(c *gc.C) {
+	c.Assert(s.unit.RegisterPayload(s.newPayload("web")), jc.ErrorIsNil)
+	c.Assert(s.unit.EnsureDead(), jc.ErrorIsNil)
+	c.Assert(s.unit.Remove(), jc.ErrorIsNil)
+	c.Assert(s.State.Cleanup(), jc.ErrorIsNil)
+
+	payloads, err := s.State.AllPayloads()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(payloads, gc.HasLen, 0)
+}
+
+func (s *PayloadsSuite) TestFilterPayloads(c *gc.C) {
+	payloads := []state.Payload{{
+		PayloadInfo: state.PayloadInfo{Name: "web", Type: "docker", ID: "abc", Status: state.PayloadRunning},
+		Unit:        "wordpress/0",
+		Machine:     "1",
+	}, {
+		PayloadInfo: state.PayloadInfo{Name: "db", Type: "process", ID: "123", Status: state.PayloadStopped, Labels: []string{"primary"}},
+		Unit:        "mysql/0",
+		Machine:     "2",
+	}}
+	for i, test := range []struct {
+		patterns []string
+		expected []string
+	}{
+		{nil, []string{"web", "db"}},
+		{[]string{"docker"}, []string{"web"}},
+		{[]string{"stopped"}, []string{"db"}},
+		{[]string{"mysql"}, []string{"db"}},
+		{[]string{"wordpress/*"}, []string{"web"}},
+		{[]string{"primary", "1"}, []string{"web", "db"}},
+		{[]string{"nothing"}, nil},
+	} {
+		c.Logf("test %d: %v", i, test.patterns)
+		filtered, err := state.FilterPayloads(payloads, test.patterns...)
+		c.Assert(err, jc.ErrorIsNil)
+		var names []string
+		for _, p := range filtered {
+			names = append(names, p.Name)
+		}
+		c.Check(names, jc.DeepEquals, test.expected)
+	}
+}
+
+func (s *PayloadsSuite) TestFilterPayloadsBadPattern(c *gc.C) {
+	payloads := []state.Payload{{
+		PayloadInfo: state.PayloadInfo{Name: "web", Type: "docker", ID: "abc", Status: state.PayloadRunning},
+		Unit:        "wordpress/0",
+	}}
+	_, err := state.FilterPayloads(payloads, "[")
+	c.Assert(err, gc.ErrorMatches, `invalid payload pattern "\["(.|\n)*`)
+}
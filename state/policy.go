@@ -77,9 +77,14 @@ type EnvironCapability interface {
 	SupportsUnitPlacement() error
 }
 
-// precheckInstance calls the state's assigned policy, if non-nil, to obtain
-// a Prechecker, and calls PrecheckInstance if a non-nil Prechecker is returned.
+// precheckInstance checks that the environment's quota allows an instance
+// with the given constraints, then calls the state's assigned policy, if
+// non-nil, to obtain a Prechecker, and calls PrecheckInstance if a non-nil
+// Prechecker is returned.
 func (st *State) precheckInstance(series string, cons constraints.Value, placement string) error {
+	if err := st.checkInstanceTypeQuota(cons); err != nil {
+		return errors.Trace(err)
+	}
 	if st.policy == nil {
 		return nil
 	}
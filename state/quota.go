@@ -0,0 +1,522 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils/set"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/mongo"
+)
+
+// Quota holds the limits on the resources that an environment, or all
+// the environments owned by a user, may consume. A nil limit leaves
+// the resource unlimited. If InstanceTypes is not empty, new machines
+// must be given an instance-type constraint naming one of them.
+type Quota struct {
+	MaxMachines   *int     `bson:"max-machines,omitempty"`
+	MaxUnits      *int     `bson:"max-units,omitempty"`
+	MaxStorageGiB *uint64  `bson:"max-storage-gib,omitempty"`
+	InstanceTypes []string `bson:"instance-types,omitempty"`
+}
+
+// Validate returns an error if the quota is not valid.
+func (q Quota) Validate() error {
+	if q.MaxMachines != nil && *q.MaxMachines < 0 {
+		return errors.NotValidf("negative machine limit")
+	}
+	if q.MaxUnits != nil && *q.MaxUnits < 0 {
+		return errors.NotValidf("negative unit limit")
+	}
+	for _, instanceType := range q.InstanceTypes {
+		if instanceType == "" {
+			return errors.NotValidf("empty instance type")
+		}
+	}
+	return nil
+}
+
+// limitsUsage returns whether the quota limits the number of machines
+// or units, or the amount of storage, that may be used.
+func (q Quota) limitsUsage() bool {
+	return q.MaxMachines != nil || q.MaxUnits != nil || q.MaxStorageGiB != nil
+}
+
+// QuotaUsage holds the resources counted against a quota. Only top
+// level machines that do not manage the environment, and principal
+// units, are counted; storage is counted by the size requested for
+// each storage instance.
+type QuotaUsage struct {
+	Machines   int    `bson:"machines"`
+	Units      int    `bson:"units"`
+	StorageMiB uint64 `bson:"storage-mib"`
+}
+
+// QuotaExceededError is returned when adding a machine, unit or
+// storage would exceed the quota of an environment or of its owner.
+type QuotaExceededError struct {
+	// Quota describes the quota that would be exceeded.
+	Quota string
+	// Limit describes the limit the quota places.
+	Limit string
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: %s allows %s", e.Quota, e.Limit)
+}
+
+// IsQuotaExceededError reports whether or not the error is a
+// QuotaExceededError.
+func IsQuotaExceededError(err error) bool {
+	_, ok := errors.Cause(err).(*QuotaExceededError)
+	return ok
+}
+
+// quotaDoc records the quota placed on an environment and the
+// environment's usage, or the quota placed on a user and the combined
+// usage of all the environments the user owns. An environment or user
+// only has a quota document once a quota has been set for it. Usage is
+// only kept up to date while the quota limits it, so that unlimited
+// environments do not contend on the document; it is counted afresh
+// whenever the quota is set.
+type quotaDoc struct {
+	DocID   string     `bson:"_id"`
+	EnvUUID string     `bson:"env-uuid,omitempty"`
+	Owner   string     `bson:"owner"`
+	Quota   Quota      `bson:"quota"`
+	Usage   QuotaUsage `bson:"usage"`
+}
+
+// describe returns a description of the quota for use in errors.
+func (doc *quotaDoc) describe() string {
+	if doc.EnvUUID != "" {
+		return "environment quota"
+	}
+	return fmt.Sprintf("quota for %s", doc.Owner)
+}
+
+func environQuotaKey(envUUID string) string {
+	return "e#" + envUUID
+}
+
+func ownerQuotaKey(owner string) string {
+	return "u#" + owner
+}
+
+// quotaDelta holds a change in the resources used by an environment.
+type quotaDelta struct {
+	machines   int
+	units      int
+	storageMiB int64
+}
+
+func usageDelta(usage QuotaUsage, sign int) quotaDelta {
+	return quotaDelta{
+		machines:   sign * usage.Machines,
+		units:      sign * usage.Units,
+		storageMiB: int64(sign) * int64(usage.StorageMiB),
+	}
+}
+
+// incQuotaUsageOp returns the operation to apply the given change to
+// the usage recorded in a quota document, if the given assertions hold.
+func incQuotaUsageOp(id string, delta quotaDelta, assert bson.D) txn.Op {
+	op := txn.Op{
+		C:  quotasC,
+		Id: id,
+		Update: bson.D{{"$inc", bson.D{
+			{"usage.machines", delta.machines},
+			{"usage.units", delta.units},
+			{"usage.storage-mib", delta.storageMiB},
+		}}},
+	}
+	if len(assert) > 0 {
+		op.Assert = assert
+	}
+	return op
+}
+
+// quotaUpdateOp returns the operation to apply the given change to the
+// usage recorded in the given quota document. If the change increases
+// usage beyond the quota a *QuotaExceededError is returned; otherwise
+// the operation asserts that the quota still holds.
+func quotaUpdateOp(doc *quotaDoc, delta quotaDelta) (txn.Op, error) {
+	var assert bson.D
+	exceeded := func(limit string, args ...interface{}) error {
+		return &QuotaExceededError{
+			Quota: doc.describe(),
+			Limit: fmt.Sprintf(limit, args...),
+		}
+	}
+	quota := doc.Quota
+	if max := quota.MaxMachines; max != nil && delta.machines > 0 {
+		if doc.Usage.Machines+delta.machines > *max {
+			return txn.Op{}, exceeded("at most %d machines", *max)
+		}
+		assert = append(assert, bson.DocElem{
+			"usage.machines", bson.D{{"$lte", *max - delta.machines}},
+		})
+	}
+	if max := quota.MaxUnits; max != nil && delta.units > 0 {
+		if doc.Usage.Units+delta.units > *max {
+			return txn.Op{}, exceeded("at most %d units", *max)
+		}
+		assert = append(assert, bson.DocElem{
+			"usage.units", bson.D{{"$lte", *max - delta.units}},
+		})
+	}
+	if max := quota.MaxStorageGiB; max != nil && delta.storageMiB > 0 {
+		maxMiB := int64(*max * 1024)
+		if int64(doc.Usage.StorageMiB)+delta.storageMiB > maxMiB {
+			return txn.Op{}, exceeded("at most %dGiB of storage", *max)
+		}
+		assert = append(assert, bson.DocElem{
+			"usage.storage-mib", bson.D{{"$lte", maxMiB - delta.storageMiB}},
+		})
+	}
+	return incQuotaUsageOp(doc.DocID, delta, assert), nil
+}
+
+// machinesQuotaDelta returns the change in usage from adding (sign 1)
+// or removing (sign -1) the given machines; containers and state server
+// machines are not counted.
+func machinesQuotaDelta(sign int, mdocs ...*machineDoc) quotaDelta {
+	var delta quotaDelta
+	for _, mdoc := range mdocs {
+		if mdoc.ContainerType != "" || hasJob(mdoc.Jobs, JobManageEnviron) {
+			continue
+		}
+		delta.machines += sign
+	}
+	return delta
+}
+
+// limitingQuotaDoc returns the quota document with the given id, or
+// nil if there is none or its quota does not limit usage.
+func limitingQuotaDoc(quotas mongo.Collection, id string) (*quotaDoc, error) {
+	var doc quotaDoc
+	err := quotas.FindId(id).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if !doc.Quota.limitsUsage() {
+		return nil, nil
+	}
+	return &doc, nil
+}
+
+// quotaOps returns the operations to record the given change in the
+// usage of the environment, against its own quota and that of its
+// owner when they limit usage. If the change would exceed either
+// quota, a *QuotaExceededError is returned.
+func (st *State) quotaOps(delta quotaDelta) ([]txn.Op, error) {
+	if delta == (quotaDelta{}) {
+		return nil, nil
+	}
+	env, err := st.Environment()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	quotas, closer := st.getCollection(quotasC)
+	defer closer()
+
+	var ops []txn.Op
+	for _, id := range []string{
+		environQuotaKey(st.EnvironUUID()),
+		ownerQuotaKey(env.Owner().Username()),
+	} {
+		doc, err := limitingQuotaDoc(quotas, id)
+		if err != nil {
+			return nil, errors.Annotate(err, "cannot read quota")
+		}
+		if doc == nil {
+			continue
+		}
+		op, err := quotaUpdateOp(doc, delta)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// ownerQuotaUsageOps returns the operations to apply the given change
+// to the usage recorded for the owner of an environment that is being
+// added or removed, if the owner's quota limits usage. The change is
+// not checked against the quota.
+func (st *State) ownerQuotaUsageOps(owner names.UserTag, delta quotaDelta) ([]txn.Op, error) {
+	quotas, closer := st.getCollection(quotasC)
+	defer closer()
+
+	doc, err := limitingQuotaDoc(quotas, ownerQuotaKey(owner.Username()))
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read owner quota")
+	}
+	if doc == nil || delta == (quotaDelta{}) {
+		return nil, nil
+	}
+	return []txn.Op{incQuotaUsageOp(doc.DocID, delta, nil)}, nil
+}
+
+// removeEnvironQuotaOps returns the operations to remove the
+// environment's quota document, if it has one, and to remove its usage
+// from that recorded for its owner.
+func (st *State) removeEnvironQuotaOps(owner names.UserTag) ([]txn.Op, error) {
+	usage, err := st.environUsage(st.EnvironUUID())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ops, err := st.ownerQuotaUsageOps(owner, usageDelta(usage, -1))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	quotas, closer := st.getCollection(quotasC)
+	defer closer()
+	n, err := quotas.FindId(environQuotaKey(st.EnvironUUID())).Count()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read environment quota")
+	}
+	if n > 0 {
+		ops = append(ops, txn.Op{
+			C:      quotasC,
+			Id:     environQuotaKey(st.EnvironUUID()),
+			Remove: true,
+		})
+	}
+	return ops, nil
+}
+
+// checkInstanceTypeQuota returns a *QuotaExceededError if the quota of
+// the environment, or of its owner, restricts the instance types that
+// new machines may use and the given constraints do not name one of
+// them.
+func (st *State) checkInstanceTypeQuota(cons constraints.Value) error {
+	quotas, closer := st.getCollection(quotasC)
+	defer closer()
+
+	var envDoc quotaDoc
+	err := quotas.FindId(environQuotaKey(st.EnvironUUID())).One(&envDoc)
+	if err == mgo.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return errors.Annotate(err, "cannot read environment quota")
+	}
+	docs := []quotaDoc{envDoc}
+	var ownerDoc quotaDoc
+	err = quotas.FindId(ownerQuotaKey(envDoc.Owner)).One(&ownerDoc)
+	if err == nil {
+		docs = append(docs, ownerDoc)
+	} else if err != mgo.ErrNotFound {
+		return errors.Annotate(err, "cannot read owner quota")
+	}
+	for _, doc := range docs {
+		allowed := doc.Quota.InstanceTypes
+		if len(allowed) == 0 {
+			continue
+		}
+		if cons.InstanceType != nil && set.NewStrings(allowed...).Contains(*cons.InstanceType) {
+			continue
+		}
+		return &QuotaExceededError{
+			Quota: doc.describe(),
+			Limit: "only instance types " + strings.Join(allowed, ", "),
+		}
+	}
+	return nil
+}
+
+// environUsage counts the resources currently used by the environment
+// with the given UUID.
+func (st *State) environUsage(envUUID string) (QuotaUsage, error) {
+	var usage QuotaUsage
+	machines, closer := st.getRawCollection(machinesC)
+	defer closer()
+	n, err := machines.Find(bson.D{
+		{"env-uuid", envUUID},
+		{"containertype", ""},
+		{"jobs", bson.D{{"$ne", JobManageEnviron}}},
+	}).Count()
+	if err != nil {
+		return usage, errors.Annotate(err, "cannot count machines")
+	}
+	usage.Machines = n
+
+	units, closer := st.getRawCollection(unitsC)
+	defer closer()
+	n, err = units.Find(bson.D{
+		{"env-uuid", envUUID},
+		{"principal", ""},
+	}).Count()
+	if err != nil {
+		return usage, errors.Annotate(err, "cannot count units")
+	}
+	usage.Units = n
+
+	storageInstances, closer := st.getRawCollection(storageInstancesC)
+	defer closer()
+	var docs []storageInstanceDoc
+	err = storageInstances.Find(bson.D{{"env-uuid", envUUID}}).Select(bson.D{{"size", 1}}).All(&docs)
+	if err != nil {
+		return usage, errors.Annotate(err, "cannot read storage instances")
+	}
+	for _, doc := range docs {
+		usage.StorageMiB += doc.Size
+	}
+	return usage, nil
+}
+
+// EnvironQuota returns the quota placed on the environment, and the
+// resources the environment currently uses.
+func (st *State) EnvironQuota() (Quota, QuotaUsage, error) {
+	quotas, closer := st.getCollection(quotasC)
+	defer closer()
+
+	var doc quotaDoc
+	err := quotas.FindId(environQuotaKey(st.EnvironUUID())).One(&doc)
+	if err != nil && err != mgo.ErrNotFound {
+		return Quota{}, QuotaUsage{}, errors.Annotate(err, "cannot read environment quota")
+	}
+	if doc.Quota.limitsUsage() {
+		return doc.Quota, doc.Usage, nil
+	}
+	usage, err := st.environUsage(st.EnvironUUID())
+	return doc.Quota, usage, errors.Trace(err)
+}
+
+// SetEnvironQuota replaces the quota placed on the environment. A quota
+// lower than the environment's current usage does not affect existing
+// resources, but no more may be added.
+func (st *State) SetEnvironQuota(quota Quota) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set environment quota")
+	if err := quota.Validate(); err != nil {
+		return errors.Trace(err)
+	}
+	env, err := st.Environment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	key := environQuotaKey(st.EnvironUUID())
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		usage, err := st.environUsage(st.EnvironUUID())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return setQuotaOps(st, key, quotaDoc{
+			DocID:   key,
+			EnvUUID: st.EnvironUUID(),
+			Owner:   env.Owner().Username(),
+			Quota:   quota,
+			Usage:   usage,
+		})
+	}
+	return st.run(buildTxn)
+}
+
+// setQuotaOps returns the operations to replace the quota document
+// with the given id, recording the quota and usage in doc.
+func setQuotaOps(st *State, key string, doc quotaDoc) ([]txn.Op, error) {
+	quotas, closer := st.getCollection(quotasC)
+	defer closer()
+	n, err := quotas.FindId(key).Count()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if n > 0 {
+		return []txn.Op{{
+			C:      quotasC,
+			Id:     key,
+			Assert: txn.DocExists,
+			Update: bson.D{{"$set", bson.D{
+				{"quota", doc.Quota},
+				{"usage", doc.Usage},
+			}}},
+		}}, nil
+	}
+	return []txn.Op{{
+		C:      quotasC,
+		Id:     key,
+		Assert: txn.DocMissing,
+		Insert: &doc,
+	}}, nil
+}
+
+// OwnerQuota returns the quota placed on the environments owned by the
+// given user, and the resources those environments currently use.
+func (st *State) OwnerQuota(owner names.UserTag) (Quota, QuotaUsage, error) {
+	quotas, closer := st.getCollection(quotasC)
+	defer closer()
+
+	var doc quotaDoc
+	err := quotas.FindId(ownerQuotaKey(owner.Username())).One(&doc)
+	if err != nil && err != mgo.ErrNotFound {
+		return Quota{}, QuotaUsage{}, errors.Annotate(err, "cannot read owner quota")
+	}
+	if doc.Quota.limitsUsage() {
+		return doc.Quota, doc.Usage, nil
+	}
+	usage, err := st.ownerUsage(owner.Username())
+	return doc.Quota, usage, errors.Trace(err)
+}
+
+// SetOwnerQuota replaces the quota placed on the environments owned by
+// the given user.
+func (st *State) SetOwnerQuota(owner names.UserTag, quota Quota) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set quota for %s", owner.Username())
+	if err := quota.Validate(); err != nil {
+		return errors.Trace(err)
+	}
+	if owner.IsLocal() {
+		if _, err := st.User(owner); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	key := ownerQuotaKey(owner.Username())
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		usage, err := st.ownerUsage(owner.Username())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return setQuotaOps(st, key, quotaDoc{
+			DocID: key,
+			Owner: owner.Username(),
+			Quota: quota,
+			Usage: usage,
+		})
+	}
+	return st.run(buildTxn)
+}
+
+// ownerUsage counts the resources currently used by all the
+// environments owned by the given user.
+func (st *State) ownerUsage(owner string) (QuotaUsage, error) {
+	var usage QuotaUsage
+	environments, closer := st.getCollection(environmentsC)
+	defer closer()
+	var envDocs []environmentDoc
+	err := environments.Find(bson.D{{"owner", owner}}).All(&envDocs)
+	if err != nil {
+		return usage, errors.Annotate(err, "cannot read environments")
+	}
+	for _, envDoc := range envDocs {
+		envUsage, err := st.environUsage(envDoc.UUID)
+		if err != nil {
+			return usage, errors.Trace(err)
+		}
+		usage.Machines += envUsage.Machines
+		usage.Units += envUsage.Units
+		usage.StorageMiB += envUsage.StorageMiB
+	}
+	return usage, nil
+}
@@ -0,0 +1,231 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing/factory"
+)
+
+type QuotaSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&QuotaSuite{})
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func (s *QuotaSuite) assertUsage(c *gc.C, st *state.State, expect state.QuotaUsage) {
+	_, usage, err := st.EnvironQuota()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(usage, jc.DeepEquals, expect)
+}
+
+func (s *QuotaSuite) TestEnvironQuotaDefault(c *gc.C) {
+	quota, usage, err := s.State.EnvironQuota()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(quota, jc.DeepEquals, state.Quota{})
+	c.Assert(usage, jc.DeepEquals, state.QuotaUsage{})
+}
+
+func (s *QuotaSuite) TestSetEnvironQuota(c *gc.C) {
+	quota := state.Quota{
+		MaxMachines:   intPtr(2),
+		InstanceTypes: []string{"m1.small"},
+	}
+	err := s.State.SetEnvironQuota(quota)
+	c.Assert(err, jc.ErrorIsNil)
+	stored, _, err := s.State.EnvironQuota()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(stored, jc.DeepEquals, quota)
+
+	err = s.State.SetEnvironQuota(state.Quota{MaxUnits: intPtr(-1)})
+	c.Assert(err, gc.ErrorMatches, "cannot set environment quota: negative unit limit not valid")
+}
+
+func (s *QuotaSuite) TestMachineQuota(c *gc.C) {
+	err := s.State.SetEnvironQuota(state.Quota{MaxMachines: intPtr(1)})
+	c.Assert(err, jc.ErrorIsNil)
+
+	// State server machines are not counted.
+	_, err = s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertUsage(c, s.State, state.QuotaUsage{})
+
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertUsage(c, s.State, state.QuotaUsage{Machines: 1})
+
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: quota exceeded: environment quota allows at most 1 machines")
+	c.Assert(err, jc.Satisfies, state.IsQuotaExceededError)
+
+	// Nor are containers.
+	_, err = s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, m.Id(), instance.LXC)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddMachineInsideNewMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, instance.LXC)
+	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: quota exceeded: environment quota allows at most 1 machines")
+	s.assertUsage(c, s.State, state.QuotaUsage{Machines: 1})
+}
+
+func (s *QuotaSuite) TestSetEnvironQuotaCountsUsage(c *gc.C) {
+	// Usage is not tracked while there is no limit, but is counted
+	// when one is set.
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.SetEnvironQuota(state.Quota{MaxMachines: intPtr(3)})
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertUsage(c, s.State, state.QuotaUsage{Machines: 1})
+
+	err = s.State.SetEnvironQuota(state.Quota{InstanceTypes: []string{"m1.small"}})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddOneMachine(state.MachineTemplate{
+		Series:      "quantal",
+		Jobs:        []state.MachineJob{state.JobHostUnits},
+		Constraints: constraints.MustParse("instance-type=m1.small"),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertUsage(c, s.State, state.QuotaUsage{Machines: 2})
+
+	err = s.State.SetEnvironQuota(state.Quota{MaxMachines: intPtr(2)})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: quota exceeded: environment quota allows at most 2 machines")
+}
+
+func (s *QuotaSuite) TestRemoveMachineReleasesQuota(c *gc.C) {
+	err := s.State.SetEnvironQuota(state.Quota{MaxMachines: intPtr(1)})
+	c.Assert(err, jc.ErrorIsNil)
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = m.EnsureDead()
+	c.Assert(err, jc.ErrorIsNil)
+	err = m.Remove()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertUsage(c, s.State, state.QuotaUsage{})
+
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *QuotaSuite) TestUnitQuota(c *gc.C) {
+	err := s.State.SetEnvironQuota(state.Quota{MaxUnits: intPtr(1)})
+	c.Assert(err, jc.ErrorIsNil)
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	u, err := svc.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertUsage(c, s.State, state.QuotaUsage{Units: 1})
+
+	_, err = svc.AddUnit()
+	c.Assert(err, gc.ErrorMatches, `cannot add unit to service "wordpress": quota exceeded: environment quota allows at most 1 units`)
+	c.Assert(err, jc.Satisfies, state.IsQuotaExceededError)
+
+	err = u.EnsureDead()
+	c.Assert(err, jc.ErrorIsNil)
+	err = u.Remove()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertUsage(c, s.State, state.QuotaUsage{})
+	_, err = svc.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *QuotaSuite) TestInstanceTypeQuota(c *gc.C) {
+	err := s.State.SetEnvironQuota(state.Quota{InstanceTypes: []string{"m1.small", "m1.medium"}})
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: quota exceeded: environment quota allows only instance types m1.small, m1.medium")
+	c.Assert(err, jc.Satisfies, state.IsQuotaExceededError)
+
+	_, err = s.State.AddOneMachine(state.MachineTemplate{
+		Series:      "quantal",
+		Jobs:        []state.MachineJob{state.JobHostUnits},
+		Constraints: constraints.MustParse("instance-type=m1.large"),
+	})
+	c.Assert(err, jc.Satisfies, state.IsQuotaExceededError)
+
+	_, err = s.State.AddOneMachine(state.MachineTemplate{
+		Series:      "quantal",
+		Jobs:        []state.MachineJob{state.JobHostUnits},
+		Constraints: constraints.MustParse("instance-type=m1.medium"),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Manually provisioned machines are not checked.
+	_, err = s.State.AddOneMachine(state.MachineTemplate{
+		Series:     "quantal",
+		Jobs:       []state.MachineJob{state.JobHostUnits},
+		InstanceId: "i-manual",
+		Nonce:      "nonce",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *QuotaSuite) TestOwnerQuota(c *gc.C) {
+	owner := s.Factory.MakeUser(c, &factory.UserParams{Name: "bob"}).UserTag()
+	st1 := s.Factory.MakeEnvironment(c, &factory.EnvParams{Owner: owner})
+	defer st1.Close()
+	_, err := st1.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Setting the quota counts the machines already in use.
+	err = s.State.SetOwnerQuota(owner, state.Quota{MaxMachines: intPtr(2)})
+	c.Assert(err, jc.ErrorIsNil)
+	quota, usage, err := s.State.OwnerQuota(owner)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(quota, jc.DeepEquals, state.Quota{MaxMachines: intPtr(2)})
+	c.Assert(usage, jc.DeepEquals, state.QuotaUsage{Machines: 1})
+
+	st2 := s.Factory.MakeEnvironment(c, &factory.EnvParams{Owner: owner})
+	defer st2.Close()
+	_, err = st2.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = st1.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: quota exceeded: quota for bob allows at most 2 machines")
+
+	// Other users' environments are unaffected.
+	st3 := s.Factory.MakeEnvironment(c, nil)
+	defer st3.Close()
+	_, err = st3.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *QuotaSuite) TestRemoveEnvironmentReleasesOwnerQuota(c *gc.C) {
+	owner := s.Factory.MakeUser(c, &factory.UserParams{Name: "bob"}).UserTag()
+	err := s.State.SetOwnerQuota(owner, state.Quota{MaxMachines: intPtr(1)})
+	c.Assert(err, jc.ErrorIsNil)
+	st := s.Factory.MakeEnvironment(c, &factory.EnvParams{Owner: owner})
+	defer st.Close()
+	_, err = st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = st.RemoveImportedEnvironment()
+	c.Assert(err, jc.ErrorIsNil)
+	_, usage, err := s.State.OwnerQuota(owner)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(usage, jc.DeepEquals, state.QuotaUsage{})
+}
+
+func (s *QuotaSuite) TestSetOwnerQuotaUnknownUser(c *gc.C) {
+	err := s.State.SetOwnerQuota(names.NewUserTag("nobody"), state.Quota{MaxUnits: intPtr(1)})
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
@@ -63,6 +63,33 @@ func (r *Relation) String() string {
 	return r.doc.Key
 }
 
+// relationGlobalKey returns the global database key for the relation
+// with the given key. It is only used for the relation's status
+// history, which outlives the relation itself.
+func relationGlobalKey(key string) string {
+	return "r#" + key
+}
+
+// StatusHistory returns a slice of StatusInfo items, most recent
+// first, representing the changes in the relation's lifecycle that
+// match filter.
+func (r *Relation) StatusHistory(filter StatusHistoryFilter) ([]StatusInfo, error) {
+	return RelationStatusHistory(r.st, r.doc.Key, filter)
+}
+
+// RelationStatusHistory returns the status history of the relation
+// with the given key, which need not still exist.
+func RelationStatusHistory(st *State, key string, filter StatusHistoryFilter) ([]StatusInfo, error) {
+	return filteredStatusHistory(filter, relationGlobalKey(key), st)
+}
+
+// recordStatusHistory records a change in the relation's lifecycle.
+func (r *Relation) recordStatusHistory(status Status) {
+	if err := recordStatusHistory(status, "", relationGlobalKey(r.doc.Key), r.st); err != nil {
+		logger.Errorf("could not record status history of relation %q: %v", r, err)
+	}
+}
+
 // Tag returns a name identifying the relation.
 func (r *Relation) Tag() names.Tag {
 	return names.NewRelationTag(r.doc.Key)
@@ -105,6 +132,12 @@ func (r *Relation) Destroy() (err error) {
 	if len(r.doc.Endpoints) == 1 && r.doc.Endpoints[0].Role == charm.RolePeer {
 		return fmt.Errorf("is a peer relation")
 	}
+	destroyed := false
+	defer func() {
+		if err == nil && destroyed {
+			r.recordStatusHistory(StatusBroken)
+		}
+	}()
 	defer func() {
 		if err == nil {
 			// This is a white lie; the document might actually be removed.
@@ -124,12 +157,14 @@ func (r *Relation) Destroy() (err error) {
 				return nil, err
 			}
 		}
+		destroyed = false
 		ops, _, err := rel.destroyOps("")
 		if err == errAlreadyDying {
 			return nil, jujutxn.ErrNoOperations
 		} else if err != nil {
 			return nil, err
 		}
+		destroyed = true
 		return ops, nil
 	}
 	return rel.st.run(buildTxn)
@@ -260,6 +260,48 @@ func (s *RelationSuite) TestDestroyRelation(c *gc.C) {
 	c.Assert(err, jc.Satisfies, errors.IsNotFound)
 }
 
+func (s *RelationSuite) TestRelationStatusHistory(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+
+	history, err := rel.StatusHistory(state.StatusHistoryFilter{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 1)
+	c.Check(history[0].Status, gc.Equals, state.StatusJoined)
+
+	// Destroying the relation twice records one change, which can
+	// be read after the relation has been removed.
+	err = rel.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	err = rel.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	history, err = state.RelationStatusHistory(s.State, rel.String(), state.StatusHistoryFilter{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 2)
+	c.Check(history[0].Status, gc.Equals, state.StatusBroken)
+	c.Check(history[1].Status, gc.Equals, state.StatusJoined)
+}
+
+func (s *RelationSuite) TestRelationStatusHistoryServiceDestroyed(c *gc.C) {
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = wordpress.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	history, err := rel.StatusHistory(state.StatusHistoryFilter{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 2)
+	c.Check(history[0].Status, gc.Equals, state.StatusBroken)
+}
+
 func (s *RelationSuite) TestDestroyPeerRelation(c *gc.C) {
 	// Check that a peer relation cannot be destroyed directly.
 	riakch := s.AddTestingCharm(c, "riak")
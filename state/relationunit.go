@@ -39,8 +39,27 @@ func (ru *RelationUnit) Endpoint() Endpoint {
 }
 
 // PrivateAddress returns the private address of the unit and whether it is valid.
+// If the unit's service binds the relation endpoint to a space, the address
+// of the unit's machine in that space is returned.
 func (ru *RelationUnit) PrivateAddress() (string, bool) {
-	return ru.unit.PrivateAddress()
+	service, err := ru.unit.Service()
+	if err != nil {
+		logger.Errorf("cannot get service of unit %q: %v", ru.unit, err)
+		return ru.unit.PrivateAddress()
+	}
+	spaceName, ok := service.EndpointBindings()[ru.endpoint.Name]
+	if !ok {
+		return ru.unit.PrivateAddress()
+	}
+	address, err := ru.unit.spaceAddress(spaceName)
+	if err != nil {
+		logger.Warningf(
+			"cannot use space %q for unit %q in relation %q, using its private address: %v",
+			spaceName, ru.unit, ru.relation, err,
+		)
+		return ru.unit.PrivateAddress()
+	}
+	return address, true
 }
 
 // ErrCannotEnterScope indicates that a relation unit failed to enter its scope
@@ -0,0 +1,348 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/state/storage"
+)
+
+// Resource holds information about the current revision of a charm
+// resource uploaded for a service.
+type Resource struct {
+	// Service is the name of the service the resource belongs to.
+	Service string
+
+	// Name is the name of the resource, as declared in the service's
+	// charm metadata.
+	Name string
+
+	// Revision is incremented each time the resource is uploaded.
+	Revision int
+
+	// Size is the size of the resource content in bytes.
+	Size int64
+
+	// SHA256 is the hex-encoded SHA-256 hash of the resource content.
+	SHA256 string
+
+	// Uploaded is the time the revision was uploaded.
+	Uploaded time.Time
+
+	// UploadedBy is the name of the user that uploaded the revision.
+	UploadedBy string
+
+	storagePath string
+}
+
+// resourcesDoc records the resources uploaded for a service. There
+// is a single document for each service, so that a unit can watch it
+// for changes to any of its service's resources.
+type resourcesDoc struct {
+	DocID     string                 `bson:"_id"`
+	EnvUUID   string                 `bson:"env-uuid"`
+	Service   string                 `bson:"service"`
+	Resources map[string]resourceDoc `bson:"resources"`
+
+	// Removed is set when the service is removed; the document is
+	// then deleted, along with the resource content, by a cleanup.
+	Removed bool `bson:"removed,omitempty"`
+}
+
+// resourceDoc records a single resource revision.
+type resourceDoc struct {
+	Revision    int       `bson:"revision"`
+	Size        int64     `bson:"size"`
+	SHA256      string    `bson:"sha256"`
+	StoragePath string    `bson:"storagepath"`
+	Uploaded    time.Time `bson:"uploaded"`
+	UploadedBy  string    `bson:"uploadedby"`
+}
+
+func (doc *resourcesDoc) resource(name string) Resource {
+	r := doc.Resources[name]
+	return Resource{
+		Service:     doc.Service,
+		Name:        name,
+		Revision:    r.Revision,
+		Size:        r.Size,
+		SHA256:      r.SHA256,
+		Uploaded:    r.Uploaded,
+		UploadedBy:  r.UploadedBy,
+		storagePath: r.StoragePath,
+	}
+}
+
+// resourcesGlobalKey returns the key of the resources document of the
+// given service.
+func resourcesGlobalKey(serviceName string) string {
+	return "r#" + serviceName
+}
+
+func resourceStoragePath(serviceName, name string) (string, error) {
+	uuid, err := utils.NewUUID()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return fmt.Sprintf("resources/%s/%s/%s", serviceName, name, uuid), nil
+}
+
+// SetResource stores a new revision of the named resource for the
+// service, replacing the current one. The content is read from r,
+// which must supply exactly size bytes whose SHA-256 hash matches the
+// given hex-encoded hash. Declaring the resource in the charm's
+// metadata is the responsibility of the caller.
+func (s *Service) SetResource(name string, r io.Reader, size int64, hash string, uploadedBy names.UserTag) (_ Resource, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set resource %q for service %q", name, s.Name())
+
+	storagePath, err := resourceStoragePath(s.Name(), name)
+	if err != nil {
+		return Resource{}, errors.Trace(err)
+	}
+	stor := storage.NewStorage(s.st.EnvironUUID(), s.st.MongoSession())
+	hasher := sha256.New()
+	if err := stor.Put(storagePath, io.TeeReader(r, hasher), size); err != nil {
+		return Resource{}, errors.Annotate(err, "cannot store resource content")
+	}
+	removeContent := true
+	defer func() {
+		if removeContent {
+			if err := stor.Remove(storagePath); err != nil {
+				logger.Errorf("cannot remove content of resource %q: %v", name, err)
+			}
+		}
+	}()
+	if got := hex.EncodeToString(hasher.Sum(nil)); got != hash {
+		return Resource{}, errors.Errorf("SHA-256 hash mismatch: expected %q, got %q", hash, got)
+	}
+
+	rdoc := resourceDoc{
+		Size:        size,
+		SHA256:      hash,
+		StoragePath: storagePath,
+		Uploaded:    nowToTheSecond(),
+		UploadedBy:  uploadedBy.Name(),
+	}
+	key := resourcesGlobalKey(s.Name())
+	docID := s.st.docID(key)
+	field := "resources." + name
+	var oldStoragePath string
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := s.Refresh(); errors.IsNotFound(err) {
+				return nil, errors.NotFoundf("service")
+			} else if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if s.Life() != Alive {
+			return nil, errors.New("service is not alive")
+		}
+		ops := []txn.Op{{
+			C:      servicesC,
+			Id:     s.doc.DocID,
+			Assert: isAliveDoc,
+		}}
+		doc, err := s.st.resourcesDoc(s.Name())
+		switch {
+		case errors.IsNotFound(err):
+			rdoc.Revision = 1
+			oldStoragePath = ""
+			return append(ops, txn.Op{
+				C:      resourcesC,
+				Id:     docID,
+				Assert: txn.DocMissing,
+				Insert: &resourcesDoc{
+					DocID:     docID,
+					EnvUUID:   s.st.EnvironUUID(),
+					Service:   s.Name(),
+					Resources: map[string]resourceDoc{name: rdoc},
+				},
+			}), nil
+		case err != nil:
+			return nil, errors.Trace(err)
+		case doc.Removed:
+			return nil, errors.New("resources of a previous service with the same name are still being removed")
+		}
+		var assert bson.D
+		if old, ok := doc.Resources[name]; ok {
+			rdoc.Revision = old.Revision + 1
+			oldStoragePath = old.StoragePath
+			assert = bson.D{{field + ".revision", old.Revision}}
+		} else {
+			rdoc.Revision = 1
+			oldStoragePath = ""
+			assert = bson.D{{field, bson.D{{"$exists", false}}}}
+		}
+		assert = append(assert, bson.DocElem{"removed", bson.D{{"$ne", true}}})
+		return append(ops, txn.Op{
+			C:      resourcesC,
+			Id:     docID,
+			Assert: assert,
+			Update: bson.D{{"$set", bson.D{{field, rdoc}}}},
+		}), nil
+	}
+	if err := s.st.run(buildTxn); err != nil {
+		return Resource{}, errors.Trace(err)
+	}
+	removeContent = false
+	if oldStoragePath != "" {
+		if err := stor.Remove(oldStoragePath); err != nil && !errors.IsNotFound(err) {
+			logger.Errorf("cannot remove previous content of resource %q: %v", name, err)
+		}
+	}
+	doc := resourcesDoc{
+		Service:   s.Name(),
+		Resources: map[string]resourceDoc{name: rdoc},
+	}
+	return doc.resource(name), nil
+}
+
+// Resource returns the current revision of the named resource of the
+// service. It returns a NotFound error if the resource has not been
+// uploaded.
+func (s *Service) Resource(name string) (Resource, error) {
+	doc, err := s.st.resourcesDoc(s.Name())
+	if errors.IsNotFound(err) {
+		return Resource{}, errors.NotFoundf("resource %q", name)
+	} else if err != nil {
+		return Resource{}, errors.Trace(err)
+	}
+	if _, ok := doc.Resources[name]; !ok || doc.Removed {
+		return Resource{}, errors.NotFoundf("resource %q", name)
+	}
+	return doc.resource(name), nil
+}
+
+// Resources returns the current revisions of all resources uploaded
+// for the service, sorted by name.
+func (s *Service) Resources() ([]Resource, error) {
+	doc, err := s.st.resourcesDoc(s.Name())
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if doc.Removed {
+		return nil, nil
+	}
+	resourceNames := make([]string, 0, len(doc.Resources))
+	for name := range doc.Resources {
+		resourceNames = append(resourceNames, name)
+	}
+	sort.Strings(resourceNames)
+	resources := make([]Resource, len(resourceNames))
+	for i, name := range resourceNames {
+		resources[i] = doc.resource(name)
+	}
+	return resources, nil
+}
+
+// OpenResource returns the current revision of the named resource of
+// the service, and a reader for its content. The caller is
+// responsible for closing the reader.
+func (s *Service) OpenResource(name string) (Resource, io.ReadCloser, error) {
+	res, err := s.Resource(name)
+	if err != nil {
+		return Resource{}, nil, errors.Trace(err)
+	}
+	stor := storage.NewStorage(s.st.EnvironUUID(), s.st.MongoSession())
+	r, _, err := stor.Get(res.storagePath)
+	if err != nil {
+		return Resource{}, nil, errors.Annotatef(err, "cannot read resource %q", name)
+	}
+	return res, r, nil
+}
+
+func (st *State) resourcesDoc(serviceName string) (*resourcesDoc, error) {
+	coll, closer := st.getCollection(resourcesC)
+	defer closer()
+
+	var doc resourcesDoc
+	err := coll.FindId(resourcesGlobalKey(serviceName)).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("resources for service %q", serviceName)
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot get resources for service %q", serviceName)
+	}
+	return &doc, nil
+}
+
+// allResourcesDocs returns the resources documents of all services
+// that have not been removed.
+func (st *State) allResourcesDocs() ([]resourcesDoc, error) {
+	coll, closer := st.getCollection(resourcesC)
+	defer closer()
+
+	var docs []resourcesDoc
+	if err := coll.Find(bson.D{{"removed", bson.D{{"$ne", true}}}}).All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get resources")
+	}
+	return docs, nil
+}
+
+// removeResourcesOps returns the operations needed when the given
+// service is removed: its resources document, if any, is marked as
+// removed, and a cleanup is scheduled to remove the document and the
+// resource content.
+func removeResourcesOps(st *State, serviceName string) []txn.Op {
+	docID := st.docID(resourcesGlobalKey(serviceName))
+	if _, err := st.resourcesDoc(serviceName); errors.IsNotFound(err) {
+		// Nothing to clean up, as long as no resource is uploaded
+		// before the service is removed.
+		return []txn.Op{{
+			C:      resourcesC,
+			Id:     docID,
+			Assert: txn.DocMissing,
+		}}
+	}
+	return []txn.Op{{
+		C:      resourcesC,
+		Id:     docID,
+		Update: bson.D{{"$set", bson.D{{"removed", true}}}},
+	}, st.newCleanupOp(cleanupServiceResources, serviceName)}
+}
+
+// cleanupServiceResources removes the content and the resources
+// document of a removed service.
+func (st *State) cleanupServiceResources(serviceName string) error {
+	doc, err := st.resourcesDoc(serviceName)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	if !doc.Removed {
+		return nil
+	}
+	stor := storage.NewStorage(st.EnvironUUID(), st.MongoSession())
+	for name, r := range doc.Resources {
+		if err := stor.Remove(r.StoragePath); err != nil && !errors.IsNotFound(err) {
+			return errors.Annotatef(err, "cannot remove content of resource %q", name)
+		}
+	}
+	ops := []txn.Op{{
+		C:      resourcesC,
+		Id:     doc.DocID,
+		Assert: bson.D{{"removed", true}},
+		Remove: true,
+	}}
+	if err := st.runTransaction(ops); err != nil && err != txn.ErrAborted {
+		return errors.Trace(err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/testing"
+)
+
+type ResourcesSuite struct {
+	ConnSuite
+	service *state.Service
+	user    names.UserTag
+}
+
+var _ = gc.Suite(&ResourcesSuite{})
+
+func (s *ResourcesSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.service = s.AddTestingService(c, "resourced", s.AddTestingCharm(c, "resourced"))
+	s.user = s.Owner
+}
+
+func sha256Hex(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+func (s *ResourcesSuite) setResource(c *gc.C, name, content string) state.Resource {
+	r, err := s.service.SetResource(name, strings.NewReader(content), int64(len(content)), sha256Hex(content), s.user)
+	c.Assert(err, jc.ErrorIsNil)
+	return r
+}
+
+func (s *ResourcesSuite) assertContent(c *gc.C, name, content string) {
+	_, r, err := s.service.OpenResource(name)
+	c.Assert(err, jc.ErrorIsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, content)
+}
+
+func (s *ResourcesSuite) TestSetResource(c *gc.C) {
+	r := s.setResource(c, "jdk", "java")
+	c.Assert(r.Service, gc.Equals, "resourced")
+	c.Assert(r.Name, gc.Equals, "jdk")
+	c.Assert(r.Revision, gc.Equals, 1)
+	c.Assert(r.Size, gc.Equals, int64(4))
+	c.Assert(r.SHA256, gc.Equals, sha256Hex("java"))
+	c.Assert(r.UploadedBy, gc.Equals, s.user.Name())
+	c.Assert(r.Uploaded.IsZero(), jc.IsFalse)
+
+	got, err := s.service.Resource("jdk")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(got.Revision, gc.Equals, 1)
+	c.Assert(got.SHA256, gc.Equals, r.SHA256)
+	c.Assert(got.Uploaded.Equal(r.Uploaded), jc.IsTrue)
+	s.assertContent(c, "jdk", "java")
+}
+
+func (s *ResourcesSuite) TestSetResourceNewRevision(c *gc.C) {
+	s.setResource(c, "jdk", "java 7")
+	r := s.setResource(c, "jdk", "java 8")
+	c.Assert(r.Revision, gc.Equals, 2)
+	s.assertContent(c, "jdk", "java 8")
+
+	s.setResource(c, "licence", "key")
+	resources, err := s.service.Resources()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resources, gc.HasLen, 2)
+	c.Assert(resources[0].Name, gc.Equals, "jdk")
+	c.Assert(resources[0].Revision, gc.Equals, 2)
+	c.Assert(resources[1].Name, gc.Equals, "licence")
+	c.Assert(resources[1].Revision, gc.Equals, 1)
+}
+
+func (s *ResourcesSuite) TestSetResourceHashMismatch(c *gc.C) {
+	_, err := s.service.SetResource("jdk", strings.NewReader("java"), 4, sha256Hex("javascript"), s.user)
+	c.Assert(err, gc.ErrorMatches, `cannot set resource "jdk" for service "resourced": SHA-256 hash mismatch: .*`)
+	_, err = s.service.Resource("jdk")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ResourcesSuite) TestSetResourceDyingService(c *gc.C) {
+	_, err := s.service.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.service.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.service.SetResource("jdk", strings.NewReader("java"), 4, sha256Hex("java"), s.user)
+	c.Assert(err, gc.ErrorMatches, `cannot set resource "jdk" for service "resourced": service is not alive`)
+}
+
+func (s *ResourcesSuite) TestResourceNotFound(c *gc.C) {
+	_, err := s.service.Resource("jdk")
+	c.Assert(err, gc.ErrorMatches, `resource "jdk" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	resources, err := s.service.Resources()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resources, gc.HasLen, 0)
+}
+
+func (s *ResourcesSuite) TestRemoveServiceCleansUpResources(c *gc.C) {
+	s.setResource(c, "jdk", "java")
+	err := s.service.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+
+	// A new service with the same name cannot use the resources
+	// until they have been cleaned up.
+	service := s.AddTestingService(c, "resourced", s.AddTestingCharm(c, "resourced"))
+	_, err = service.Resource("jdk")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = service.SetResource("jdk", strings.NewReader("java"), 4, sha256Hex("java"), s.user)
+	c.Assert(err, gc.ErrorMatches, ".*still being removed")
+
+	err = s.State.Cleanup()
+	c.Assert(err, jc.ErrorIsNil)
+	r, err := service.SetResource("jdk", strings.NewReader("java"), 4, sha256Hex("java"), s.user)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(r.Revision, gc.Equals, 1)
+}
+
+func (s *ResourcesSuite) TestRemoveServiceWithoutResources(c *gc.C) {
+	err := s.service.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+	dirty, err := s.State.NeedsCleanup()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(dirty, jc.IsFalse)
+}
+
+func (s *ResourcesSuite) TestWatchConfigSettingsSeesResources(c *gc.C) {
+	unit, err := s.service.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	curl, _ := s.service.CharmURL()
+	err = unit.SetCharmURL(curl)
+	c.Assert(err, jc.ErrorIsNil)
+	w, err := unit.WatchConfigSettings()
+	c.Assert(err, jc.ErrorIsNil)
+	defer testing.AssertStop(c, w)
+
+	wc := testing.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	s.setResource(c, "jdk", "java 7")
+	wc.AssertOneChange()
+	s.setResource(c, "jdk", "java 8")
+	wc.AssertOneChange()
+}
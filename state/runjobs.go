@@ -0,0 +1,257 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// RunJobResult records the outcome of running a job's commands on one
+// of its targets.
+type RunJobResult struct {
+	MachineId string `bson:"machineid"`
+	UnitId    string `bson:"unitid,omitempty"`
+	Code      int    `bson:"code"`
+	Stdout    []byte `bson:"stdout,omitempty"`
+	Stderr    []byte `bson:"stderr,omitempty"`
+	Error     string `bson:"error,omitempty"`
+}
+
+// runJobDoc records commands run on a set of machines and units in the
+// background, and the results from each target so far.
+type runJobDoc struct {
+	DocID         string         `bson:"_id"`
+	EnvUUID       string         `bson:"env-uuid"`
+	Seq           int            `bson:"seq"`
+	Commands      string         `bson:"commands"`
+	Targets       int            `bson:"targets"`
+	MaxConcurrent int            `bson:"maxconcurrent,omitempty"`
+	Server        string         `bson:"server"`
+	Started       time.Time      `bson:"started"`
+	Completed     time.Time      `bson:"completed"`
+	Results       []RunJobResult `bson:"results"`
+	Error         string         `bson:"error,omitempty"`
+}
+
+// RunJob represents commands run on a set of machines and units in the
+// background. Results are added to the job in the order in which its
+// targets finish.
+type RunJob struct {
+	st  *State
+	doc runJobDoc
+}
+
+// runJobGlobalKey returns the global database key for the run job with
+// the given id.
+func runJobGlobalKey(id string) string {
+	return "rj#" + id
+}
+
+// Id returns the id of the job, which is unique within the environment.
+func (j *RunJob) Id() string {
+	return strconv.Itoa(j.doc.Seq)
+}
+
+// Commands returns the commands that are run.
+func (j *RunJob) Commands() string {
+	return j.doc.Commands
+}
+
+// Targets returns the number of machines and units the commands are run
+// on.
+func (j *RunJob) Targets() int {
+	return j.doc.Targets
+}
+
+// MaxConcurrent returns the limit on the number of targets the commands
+// are run on at once, or zero if there is none.
+func (j *RunJob) MaxConcurrent() int {
+	return j.doc.MaxConcurrent
+}
+
+// Server returns the id of the API server machine that runs the job.
+func (j *RunJob) Server() string {
+	return j.doc.Server
+}
+
+// Started returns the time the job was started.
+func (j *RunJob) Started() time.Time {
+	return j.doc.Started.UTC()
+}
+
+// Completed returns the time the last of the job's results was added,
+// or the job failed, or the zero time if the job is still running.
+func (j *RunJob) Completed() time.Time {
+	if j.doc.Completed.IsZero() {
+		return time.Time{}
+	}
+	return j.doc.Completed.UTC()
+}
+
+// Done returns whether results have been added for all the job's
+// targets, or the job has failed.
+func (j *RunJob) Done() bool {
+	return len(j.doc.Results) >= j.doc.Targets || j.doc.Error != ""
+}
+
+// Error returns why the job failed before results were added for all
+// its targets, or the empty string if it has not failed.
+func (j *RunJob) Error() string {
+	return j.doc.Error
+}
+
+// Results returns the results added to the job so far, in the order in
+// which they were added.
+func (j *RunJob) Results() []RunJobResult {
+	return j.doc.Results
+}
+
+// Refresh refreshes the contents of the job from the underlying state.
+func (j *RunJob) Refresh() error {
+	jobs, closer := j.st.getCollection(runJobsC)
+	defer closer()
+
+	var doc runJobDoc
+	err := jobs.FindId(j.doc.DocID).One(&doc)
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("run job %s", j.Id())
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot refresh run job %s", j.Id())
+	}
+	j.doc = doc
+	return nil
+}
+
+// AddResult records the outcome of running the job's commands on one of
+// its targets.
+func (j *RunJob) AddResult(result RunJobResult) error {
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := j.Refresh(); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if j.doc.Error != "" {
+			return nil, errors.Errorf("run job failed: %s", j.doc.Error)
+		}
+		if j.Done() {
+			return nil, errors.Errorf("all %d results already added", j.doc.Targets)
+		}
+		update := bson.D{{"$push", bson.D{{"results", result}}}}
+		if len(j.doc.Results)+1 == j.doc.Targets {
+			update = append(update, bson.DocElem{"$set", bson.D{{"completed", nowToTheSecond()}}})
+		}
+		return []txn.Op{{
+			C:      runJobsC,
+			Id:     j.doc.DocID,
+			Assert: bson.D{{"results", bson.D{{"$size", len(j.doc.Results)}}}},
+			Update: update,
+		}}, nil
+	}
+	if err := j.st.run(buildTxn); err != nil {
+		return errors.Annotatef(err, "cannot add result to run job %s", j.Id())
+	}
+	return j.Refresh()
+}
+
+// AddRunJob records a job, run by the API server on the machine with
+// the given id, that runs the given commands on the given number of
+// targets.
+func (st *State) AddRunJob(server, commands string, targets, maxConcurrent int) (_ *RunJob, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add run job")
+	if commands == "" {
+		return nil, errors.NotValidf("missing commands")
+	}
+	if targets < 0 {
+		return nil, errors.NotValidf("%d targets", targets)
+	}
+	if maxConcurrent < 0 {
+		return nil, errors.NotValidf("max concurrent %d", maxConcurrent)
+	}
+	seq, err := st.sequence("runjob")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	id := strconv.Itoa(seq)
+	now := nowToTheSecond()
+	doc := runJobDoc{
+		DocID:         st.docID(runJobGlobalKey(id)),
+		EnvUUID:       st.EnvironUUID(),
+		Seq:           seq,
+		Commands:      commands,
+		Targets:       targets,
+		MaxConcurrent: maxConcurrent,
+		Server:        server,
+		Started:       now,
+		Results:       []RunJobResult{},
+	}
+	if targets == 0 {
+		doc.Completed = now
+	}
+	ops := []txn.Op{{
+		C:      runJobsC,
+		Id:     doc.DocID,
+		Assert: txn.DocMissing,
+		Insert: &doc,
+	}}
+	if err := st.runTransaction(ops); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &RunJob{st: st, doc: doc}, nil
+}
+
+// RunJob returns the run job with the given id.
+func (st *State) RunJob(id string) (*RunJob, error) {
+	jobs, closer := st.getCollection(runJobsC)
+	defer closer()
+
+	var doc runJobDoc
+	err := jobs.FindId(st.docID(runJobGlobalKey(id))).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("run job %s", id)
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot get run job %s", id)
+	}
+	return &RunJob{st: st, doc: doc}, nil
+}
+
+// FailInterruptedRunJobs marks as failed the unfinished run jobs, in
+// all environments, of the API server on the machine with the given
+// id. It should be called when the API server starts, as the jobs it
+// was running when it stopped will never finish.
+func (st *State) FailInterruptedRunJobs(server string) error {
+	jobs, closer := st.getRawCollection(runJobsC)
+	defer closer()
+
+	var docs []runJobDoc
+	sel := bson.D{{"server", server}, {"error", bson.D{{"$exists", false}}}}
+	if err := jobs.Find(sel).All(&docs); err != nil {
+		return errors.Annotate(err, "cannot get interrupted run jobs")
+	}
+	now := nowToTheSecond()
+	for _, doc := range docs {
+		if len(doc.Results) >= doc.Targets {
+			continue
+		}
+		ops := []txn.Op{{
+			C:      runJobsC,
+			Id:     doc.DocID,
+			Assert: bson.D{{"results", bson.D{{"$size", len(doc.Results)}}}},
+			Update: bson.D{{"$set", bson.D{
+				{"error", "interrupted by API server restart"},
+				{"completed", now},
+			}}},
+		}}
+		if err := st.runRawTransaction(ops); err != nil {
+			return errors.Annotatef(err, "cannot fail run job %s", doc.DocID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/state"
+)
+
+type RunJobsSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&RunJobsSuite{})
+
+func (s *RunJobsSuite) TestAddRunJob(c *gc.C) {
+	job, err := s.State.AddRunJob("0", "hostname", 2, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(job.Commands(), gc.Equals, "hostname")
+	c.Assert(job.Targets(), gc.Equals, 2)
+	c.Assert(job.MaxConcurrent(), gc.Equals, 1)
+	c.Assert(job.Server(), gc.Equals, "0")
+	c.Assert(job.Done(), jc.IsFalse)
+	c.Assert(job.Completed().IsZero(), jc.IsTrue)
+	c.Assert(job.Results(), gc.HasLen, 0)
+
+	fetched, err := s.State.RunJob(job.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fetched.Commands(), gc.Equals, "hostname")
+	c.Assert(fetched.Started(), gc.DeepEquals, job.Started())
+}
+
+func (s *RunJobsSuite) TestAddRunJobNoTargets(c *gc.C) {
+	job, err := s.State.AddRunJob("0", "hostname", 0, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(job.Done(), jc.IsTrue)
+	c.Assert(job.Completed().IsZero(), jc.IsFalse)
+}
+
+func (s *RunJobsSuite) TestAddRunJobInvalid(c *gc.C) {
+	_, err := s.State.AddRunJob("0", "", 1, 0)
+	c.Assert(err, gc.ErrorMatches, "cannot add run job: missing commands not valid")
+	_, err = s.State.AddRunJob("0", "hostname", 1, -1)
+	c.Assert(err, gc.ErrorMatches, "cannot add run job: max concurrent -1 not valid")
+}
+
+func (s *RunJobsSuite) TestAddResult(c *gc.C) {
+	job, err := s.State.AddRunJob("0", "hostname", 2, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	first := state.RunJobResult{MachineId: "0", Stdout: []byte("zero\n")}
+	second := state.RunJobResult{MachineId: "1", UnitId: "mysql/0", Code: 2, Stderr: []byte("oops\n")}
+
+	c.Assert(job.AddResult(first), jc.ErrorIsNil)
+	c.Assert(job.Done(), jc.IsFalse)
+
+	// Add the second result through another copy of the job, as
+	// a concurrent runner would.
+	other, err := s.State.RunJob(job.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(other.AddResult(second), jc.ErrorIsNil)
+	c.Assert(other.Done(), jc.IsTrue)
+	c.Assert(other.Completed().IsZero(), jc.IsFalse)
+	c.Assert(other.Results(), jc.DeepEquals, []state.RunJobResult{first, second})
+
+	err = job.AddResult(first)
+	c.Assert(err, gc.ErrorMatches, "cannot add result to run job .*: all 2 results already added")
+}
+
+func (s *RunJobsSuite) TestFailInterruptedRunJobs(c *gc.C) {
+	running, err := s.State.AddRunJob("0", "hostname", 2, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(running.AddResult(state.RunJobResult{MachineId: "0"}), jc.ErrorIsNil)
+	finished, err := s.State.AddRunJob("0", "hostname", 1, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(finished.AddResult(state.RunJobResult{MachineId: "0"}), jc.ErrorIsNil)
+	otherServer, err := s.State.AddRunJob("1", "hostname", 1, 0)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = s.State.FailInterruptedRunJobs("0")
+	c.Assert(err, jc.ErrorIsNil)
+
+	c.Assert(running.Refresh(), jc.ErrorIsNil)
+	c.Assert(running.Done(), jc.IsTrue)
+	c.Assert(running.Error(), gc.Equals, "interrupted by API server restart")
+	c.Assert(running.Completed().IsZero(), jc.IsFalse)
+	c.Assert(running.Results(), gc.HasLen, 1)
+	err = running.AddResult(state.RunJobResult{MachineId: "1"})
+	c.Assert(err, gc.ErrorMatches, "cannot add result to run job .*: run job failed: interrupted by API server restart")
+
+	c.Assert(finished.Refresh(), jc.ErrorIsNil)
+	c.Assert(finished.Error(), gc.Equals, "")
+	c.Assert(otherServer.Refresh(), jc.ErrorIsNil)
+	c.Assert(otherServer.Done(), jc.IsFalse)
+}
+
+func (s *RunJobsSuite) TestRunJobNotFound(c *gc.C) {
+	_, err := s.State.RunJob("42")
+	c.Assert(err, gc.ErrorMatches, "run job 42 not found")
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/juju/names"
 	jujutxn "github.com/juju/txn"
+	"github.com/juju/utils/set"
 	"gopkg.in/juju/charm.v5"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
@@ -46,6 +47,10 @@ type serviceDoc struct {
 	OwnerTag          string     `bson:"ownertag"`
 	TxnRevno          int64      `bson:"txn-revno"`
 	MetricCredentials []byte     `bson:"metric-credentials"`
+
+	// EndpointBindings maps relation endpoint names to the names
+	// of the spaces they are bound to.
+	EndpointBindings map[string]string `bson:"endpointbindings,omitempty"`
 }
 
 func newService(st *State, doc *serviceDoc) *Service {
@@ -108,7 +113,11 @@ func (s *Service) Destroy() (err error) {
 		}
 	}()
 	svc := &Service{st: s.st, doc: s.doc}
+	// destroyed holds the relations destroyed along with the
+	// service, so that their destruction can be recorded.
+	var destroyed []*Relation
 	buildTxn := func(attempt int) ([]txn.Op, error) {
+		destroyed = nil
 		if attempt > 0 {
 			if err := svc.Refresh(); errors.IsNotFound(err) {
 				return nil, jujutxn.ErrNoOperations
@@ -121,13 +130,28 @@ func (s *Service) Destroy() (err error) {
 		case errAlreadyDying:
 			return nil, jujutxn.ErrNoOperations
 		case nil:
+			rels, err := svc.Relations()
+			if err != nil {
+				return nil, err
+			}
+			for _, rel := range rels {
+				if rel.Life() == Alive {
+					destroyed = append(destroyed, rel)
+				}
+			}
 			return ops, nil
 		default:
 			return nil, err
 		}
 		return nil, jujutxn.ErrTransientFailure
 	}
-	return s.st.run(buildTxn)
+	if err := s.st.run(buildTxn); err != nil {
+		return err
+	}
+	for _, rel := range destroyed {
+		rel.recordStatusHistory(StatusBroken)
+	}
+	return nil
 }
 
 // destroyOps returns the operations required to destroy the service. If it
@@ -230,6 +254,8 @@ func (s *Service) removeOps(asserts bson.D) []txn.Op {
 		annotationRemoveOp(s.st, s.globalKey()),
 		removeLeadershipSettingsOp(s.Tag().Id()),
 	}
+	ops = append(ops, removeResourcesOps(s.st, s.doc.Name)...)
+	ops = append(ops, removeConfigHistoryOps(s.st, s.doc.Name)...)
 	return ops
 }
 
@@ -500,9 +526,16 @@ func (s *Service) changeCharmOps(ch *Charm, force bool) ([]txn.Op, error) {
 		}
 	}
 
+	// Make sure no endpoint the new charm drops is bound to a space.
+	bindingsAsserts, err := s.checkEndpointBindingsUpgrade(ch)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
 	// Build the transaction.
 	var ops []txn.Op
 	differentCharm := bson.D{{"charmurl", bson.D{{"$ne", ch.URL()}}}}
+	differentCharm = append(differentCharm, bindingsAsserts...)
 	if oldSettings != nil {
 		// Old settings shouldn't change (when they exist).
 		ops = append(ops, oldSettings.assertUnchangedOp())
@@ -727,6 +760,18 @@ func (s *Service) addUnitOps(principalName string, asserts bson.D) (string, []tx
 	}
 	ops = append(ops, storageOps...)
 
+	// Subordinate units are not counted against quotas, but their
+	// storage is.
+	delta := storageQuotaDelta(storageOps)
+	if !s.doc.Subordinate {
+		delta.units = 1
+	}
+	quotaOps, err := s.st.quotaOps(delta)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	ops = append(ops, quotaOps...)
+
 	if s.doc.Subordinate {
 		ops = append(ops, txn.Op{
 			C:  unitsC,
@@ -825,6 +870,13 @@ func (s *Service) removeUnitOps(u *Unit, asserts bson.D) ([]txn.Op, error) {
 	if err != nil {
 		return nil, err
 	}
+	var quotaOps []txn.Op
+	if u.IsPrincipal() {
+		quotaOps, err = s.st.quotaOps(quotaDelta{units: -1})
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	observedFieldsMatch := bson.D{
 		{"charmurl", u.doc.CharmURL},
@@ -846,6 +898,7 @@ func (s *Service) removeUnitOps(u *Unit, asserts bson.D) ([]txn.Op, error) {
 	)
 	ops = append(ops, portsOps...)
 	ops = append(ops, storageInstanceOps...)
+	ops = append(ops, quotaOps...)
 	if u.doc.CharmURL != nil {
 		decOps, err := settingsDecRefOps(s.st, s.doc.Name, u.doc.CharmURL)
 		if errors.IsNotFound(err) {
@@ -934,6 +987,17 @@ func (s *Service) ConfigSettings() (charm.Settings, error) {
 // UpdateConfigSettings changes a service's charm config settings. Values set
 // to nil will be deleted; unknown and invalid values will return an error.
 func (s *Service) UpdateConfigSettings(changes charm.Settings) error {
+	return s.updateConfigSettings("", changes)
+}
+
+// UpdateConfigSettingsAs is like UpdateConfigSettings, but records the
+// given user as the author of the change in the service's config
+// history.
+func (s *Service) UpdateConfigSettingsAs(author names.UserTag, changes charm.Settings) error {
+	return s.updateConfigSettings(author.Name(), changes)
+}
+
+func (s *Service) updateConfigSettings(author string, changes charm.Settings) error {
 	charm, _, err := s.Charm()
 	if err != nil {
 		return err
@@ -957,7 +1021,7 @@ func (s *Service) UpdateConfigSettings(changes charm.Settings) error {
 			node.Set(name, value)
 		}
 	}
-	_, err = node.Write()
+	_, err = s.st.writeSettingsWithHistory(node, s.globalKey(), author)
 	return err
 }
 
@@ -1005,6 +1069,139 @@ func (s *Service) Networks() ([]string, error) {
 	return readRequestedNetworks(s.st, s.globalKey())
 }
 
+// EndpointBindings returns the names of the spaces the service's
+// relation endpoints are bound to, keyed by endpoint name. Endpoints
+// that are not bound to a space are not included.
+func (s *Service) EndpointBindings() map[string]string {
+	bindings := make(map[string]string, len(s.doc.EndpointBindings))
+	for endpoint, space := range s.doc.EndpointBindings {
+		bindings[endpoint] = space
+	}
+	return bindings
+}
+
+// SetEndpointBindings replaces the service's endpoint bindings with
+// the given ones, which map relation endpoint names of the service's
+// charm to the names of existing spaces. The addresses units of the
+// service advertise on a bound endpoint's relations are chosen from
+// the bound space.
+func (s *Service) SetEndpointBindings(bindings map[string]string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set endpoint bindings for service %q", s)
+
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := s.Refresh(); errors.IsNotFound(err) {
+				return nil, errNotAlive
+			} else if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if s.doc.Life != Alive {
+				return nil, errNotAlive
+			}
+		}
+		ch, _, err := s.Charm()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		spaceOps, err := endpointBindingsOps(s.st, ch.Meta(), bindings)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		ops := []txn.Op{{
+			C:      servicesC,
+			Id:     s.doc.DocID,
+			Assert: bson.D{{"life", Alive}, {"charmurl", s.doc.CharmURL}},
+			Update: bson.D{{"$set", bson.D{{"endpointbindings", bindings}}}},
+		}}
+		return append(ops, spaceOps...), nil
+	}
+	if err := s.st.run(buildTxn); err != nil {
+		return err
+	}
+	s.doc.EndpointBindings = make(map[string]string, len(bindings))
+	for endpoint, space := range bindings {
+		s.doc.EndpointBindings[endpoint] = space
+	}
+	return nil
+}
+
+// charmEndpointNames returns the names of the relation endpoints of a
+// service whose charm has the given metadata.
+func charmEndpointNames(meta *charm.Meta) set.Strings {
+	endpoints := set.NewStrings("juju-info")
+	for _, relations := range []map[string]charm.Relation{meta.Peers, meta.Provides, meta.Requires} {
+		for name := range relations {
+			endpoints.Add(name)
+		}
+	}
+	return endpoints
+}
+
+// endpointBindingsOps checks that the given endpoint bindings map
+// relation endpoints of a charm with the given metadata to the names of
+// alive spaces, and returns the operations needed to assert that those
+// spaces are still alive.
+func endpointBindingsOps(st *State, meta *charm.Meta, bindings map[string]string) ([]txn.Op, error) {
+	endpoints := charmEndpointNames(meta)
+	spaces := make(set.Strings)
+	var ops []txn.Op
+	for endpoint, spaceName := range bindings {
+		if !endpoints.Contains(endpoint) {
+			return nil, errors.NotFoundf("endpoint %q", endpoint)
+		}
+		if spaces.Contains(spaceName) {
+			continue
+		}
+		spaces.Add(spaceName)
+		space, err := st.Space(spaceName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if space.Life() != Alive {
+			return nil, errors.Errorf("space %q is not alive", spaceName)
+		}
+		ops = append(ops, txn.Op{
+			C:      spacesC,
+			Id:     space.doc.DocID,
+			Assert: isAliveDoc,
+		})
+	}
+	return ops, nil
+}
+
+// checkEndpointBindingsUpgrade returns an error if the service has an
+// endpoint bound to a space that is not defined by the given charm, and
+// otherwise the assertions needed to make sure that none of the
+// endpoints the charm drops are bound meanwhile.
+func (s *Service) checkEndpointBindingsUpgrade(ch *Charm) (bson.D, error) {
+	services, closer := s.st.getCollection(servicesC)
+	defer closer()
+
+	var doc serviceDoc
+	err := services.FindId(s.doc.DocID).Select(bson.D{{"charmurl", 1}, {"endpointbindings", 1}}).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("service %q", s)
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	oldCharm, err := s.st.Charm(doc.CharmURL)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	newEndpoints := charmEndpointNames(ch.Meta())
+	var asserts bson.D
+	for _, endpoint := range charmEndpointNames(oldCharm.Meta()).SortedValues() {
+		if newEndpoints.Contains(endpoint) {
+			continue
+		}
+		if space, ok := doc.EndpointBindings[endpoint]; ok {
+			return nil, errors.Errorf("endpoint %q is bound to space %q but not defined by charm %q", endpoint, space, ch.URL())
+		}
+		asserts = append(asserts, bson.DocElem{"endpointbindings." + endpoint, bson.D{{"$exists", false}}})
+	}
+	return asserts, nil
+}
+
 // MetricCredentials returns any metric credentials associated with this service.
 func (s *Service) MetricCredentials() []byte {
 	return s.doc.MetricCredentials
@@ -1204,6 +1401,12 @@ func (s *Service) SetStatus(status Status, info string, data map[string]interfac
 	return nil
 }
 
+// StatusHistory returns a slice of StatusInfo items, most recent
+// first, representing past statuses for this service that match filter.
+func (s *Service) StatusHistory(filter StatusHistoryFilter) ([]StatusInfo, error) {
+	return filteredStatusHistory(filter, s.globalKey(), s.st)
+}
+
 // ServiceAndUnitsStatus returns the status for this service and all its units.
 func (s *Service) ServiceAndUnitsStatus() (StatusInfo, map[string]StatusInfo, error) {
 	serviceStatus, err := s.Status()
@@ -133,6 +133,13 @@ func cacheKeys(caches ...map[string]interface{}) map[string]bool {
 // as a delta applied on top of the latest version of the node, to prevent
 // overwriting unrelated changes made to the node since it was last read.
 func (c *Settings) Write() ([]ItemChange, error) {
+	return c.write(nil)
+}
+
+// write writes changes made to c back onto its node as Write does. If
+// extraOps is not nil, it is called with the changes to be written, and
+// the operations it returns are run in the same transaction.
+func (c *Settings) write(extraOps func([]ItemChange) ([]txn.Op, error)) ([]ItemChange, error) {
 	changes := []ItemChange{}
 	updates := bson.M{}
 	deletions := bson.M{}
@@ -169,6 +176,13 @@ func (c *Settings) Write() ([]ItemChange, error) {
 		Assert: txn.DocExists,
 		Update: setUnsetUpdate(updates, deletions),
 	}}
+	if extraOps != nil {
+		moreOps, err := extraOps(changes)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		ops = append(ops, moreOps...)
+	}
 	err := c.st.runTransaction(ops)
 	if err == txn.ErrAborted {
 		return nil, errors.NotFoundf("settings")
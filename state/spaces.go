@@ -0,0 +1,242 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"net"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils/set"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/network"
+)
+
+// Space represents a named group of subnets, such as those of a
+// storage or public network. Relation endpoints of a service can be
+// bound to a space, and machines can be constrained to have network
+// interfaces in particular spaces.
+type Space struct {
+	st  *State
+	doc spaceDoc
+}
+
+type spaceDoc struct {
+	DocID   string `bson:"_id"`
+	EnvUUID string `bson:"env-uuid"`
+	Life    Life   `bson:"life"`
+	Name    string `bson:"name"`
+}
+
+// Name returns the name of the space.
+func (s *Space) Name() string {
+	return s.doc.Name
+}
+
+// Tag returns the tag of the space.
+func (s *Space) Tag() names.Tag {
+	return names.NewSpaceTag(s.doc.Name)
+}
+
+// Life returns whether the space is Alive, Dying or Dead.
+func (s *Space) Life() Life {
+	return s.doc.Life
+}
+
+// String implements fmt.Stringer.
+func (s *Space) String() string {
+	return s.doc.Name
+}
+
+// Subnets returns the subnets in the space, ordered by CIDR.
+func (s *Space) Subnets() ([]*Subnet, error) {
+	return spaceSubnets(s.st, s.doc.Name)
+}
+
+// Refresh refreshes the contents of the Space from the underlying
+// state. It returns an error that satisfies errors.IsNotFound if the
+// space has been removed.
+func (s *Space) Refresh() error {
+	spaces, closer := s.st.getCollection(spacesC)
+	defer closer()
+
+	err := spaces.FindId(s.doc.DocID).One(&s.doc)
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("space %q", s)
+	}
+	if err != nil {
+		return errors.Errorf("cannot refresh space %q: %v", s, err)
+	}
+	return nil
+}
+
+func spaceSubnets(st *State, name string) ([]*Subnet, error) {
+	subnets, closer := st.getCollection(subnetsC)
+	defer closer()
+
+	var docs []subnetDoc
+	err := subnets.Find(bson.D{{"spacename", name}}).Sort("cidr").All(&docs)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get subnets of space %q", name)
+	}
+	result := make([]*Subnet, len(docs))
+	for i, doc := range docs {
+		result[i] = &Subnet{st, doc}
+	}
+	return result, nil
+}
+
+// notInSpaceDoc asserts that a subnet is alive and not yet part of
+// any space.
+var notInSpaceDoc = bson.D{
+	{"life", Alive},
+	{"spacename", bson.D{{"$exists", false}}},
+}
+
+// AddSpace creates and returns a new space containing the subnets
+// with the given CIDRs, which must already exist and not be part of
+// another space. If a space with the same name already exists, an
+// error satisfying errors.IsAlreadyExists is returned.
+func (st *State) AddSpace(name string, subnets []string) (space *Space, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add space %q", name)
+
+	if !names.IsValidSpace(name) {
+		return nil, errors.NotValidf("space name %q", name)
+	}
+	seen := make(set.Strings)
+	for _, cidr := range subnets {
+		if seen.Contains(cidr) {
+			return nil, errors.Errorf("subnet %q specified more than once", cidr)
+		}
+		seen.Add(cidr)
+	}
+	doc := spaceDoc{
+		DocID:   st.docID(name),
+		EnvUUID: st.EnvironUUID(),
+		Life:    Alive,
+		Name:    name,
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if _, err := st.Space(name); err == nil {
+			return nil, errors.AlreadyExistsf("space %q", name)
+		} else if !errors.IsNotFound(err) {
+			return nil, errors.Trace(err)
+		}
+		ops := []txn.Op{{
+			C:      spacesC,
+			Id:     doc.DocID,
+			Assert: txn.DocMissing,
+			Insert: doc,
+		}}
+		for _, cidr := range subnets {
+			subnet, err := st.Subnet(cidr)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if subnet.Life() != Alive {
+				return nil, errors.Errorf("subnet %q is not alive", cidr)
+			}
+			if subnet.SpaceName() != "" {
+				return nil, errors.Errorf("subnet %q is already in space %q", cidr, subnet.SpaceName())
+			}
+			ops = append(ops, txn.Op{
+				C:      subnetsC,
+				Id:     subnet.ID(),
+				Assert: notInSpaceDoc,
+				Update: bson.D{{"$set", bson.D{{"spacename", name}}}},
+			})
+		}
+		return ops, nil
+	}
+	if err := st.run(buildTxn); err != nil {
+		return nil, err
+	}
+	return &Space{st, doc}, nil
+}
+
+// Space returns the space with the given name.
+func (st *State) Space(name string) (*Space, error) {
+	spaces, closer := st.getCollection(spacesC)
+	defer closer()
+
+	var doc spaceDoc
+	err := spaces.FindId(name).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("space %q", name)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get space %q", name)
+	}
+	return &Space{st, doc}, nil
+}
+
+// AllSpaces returns all the spaces in the environment, ordered by name.
+func (st *State) AllSpaces() ([]*Space, error) {
+	spaces, closer := st.getCollection(spacesC)
+	defer closer()
+
+	var docs []spaceDoc
+	if err := spaces.Find(nil).Sort("name").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get all spaces")
+	}
+	result := make([]*Space, len(docs))
+	for i, doc := range docs {
+		result[i] = &Space{st, doc}
+	}
+	return result, nil
+}
+
+// spaceNetworks returns the IP networks of the subnets in the named
+// space.
+func spaceNetworks(st *State, name string) ([]*net.IPNet, error) {
+	subnets, err := spaceSubnets(st, name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var networks []*net.IPNet
+	for _, subnet := range subnets {
+		_, ipNet, err := net.ParseCIDR(subnet.CIDR())
+		if err != nil {
+			// Subnet CIDRs are validated when added,
+			// so this should never happen.
+			return nil, errors.Annotatef(err, "invalid CIDR of subnet %q", subnet)
+		}
+		networks = append(networks, ipNet)
+	}
+	return networks, nil
+}
+
+// addressesInNetworks returns those of the given addresses that are
+// IP addresses within one of the given networks.
+func addressesInNetworks(addresses []network.Address, networks []*net.IPNet) []network.Address {
+	var result []network.Address
+	for _, addr := range addresses {
+		ip := net.ParseIP(addr.Value)
+		if ip == nil {
+			continue
+		}
+		for _, ipNet := range networks {
+			if ipNet.Contains(ip) {
+				result = append(result, addr)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// machineInSpaces reports whether the machine has an address in each
+// of the spaces whose networks are given, keyed by space name.
+func machineInSpaces(m *Machine, spaces map[string][]*net.IPNet) bool {
+	addresses := m.Addresses()
+	for _, networks := range spaces {
+		if len(addressesInNetworks(addresses, networks)) == 0 {
+			return false
+		}
+	}
+	return true
+}
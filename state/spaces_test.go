@@ -0,0 +1,238 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+)
+
+type SpaceSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&SpaceSuite{})
+
+func (s *SpaceSuite) addSubnets(c *gc.C, cidrs ...string) {
+	for _, cidr := range cidrs {
+		_, err := s.State.AddSubnet(state.SubnetInfo{CIDR: cidr})
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *SpaceSuite) subnetCIDRs(c *gc.C, space *state.Space) []string {
+	subnets, err := space.Subnets()
+	c.Assert(err, jc.ErrorIsNil)
+	var cidrs []string
+	for _, subnet := range subnets {
+		c.Check(subnet.SpaceName(), gc.Equals, space.Name())
+		cidrs = append(cidrs, subnet.CIDR())
+	}
+	return cidrs
+}
+
+func (s *SpaceSuite) TestAddSpace(c *gc.C) {
+	s.addSubnets(c, "10.0.0.0/24", "10.1.0.0/24", "10.2.0.0/24")
+
+	space, err := s.State.AddSpace("storage", []string{"10.2.0.0/24", "10.1.0.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(space.Name(), gc.Equals, "storage")
+	c.Assert(space.Life(), gc.Equals, state.Alive)
+	c.Assert(space.Tag().String(), gc.Equals, "space-storage")
+	c.Assert(s.subnetCIDRs(c, space), jc.DeepEquals, []string{"10.1.0.0/24", "10.2.0.0/24"})
+
+	_, err = s.State.AddSpace("apps", nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	space, err = s.State.Space("storage")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(space.Name(), gc.Equals, "storage")
+
+	spaces, err := s.State.AllSpaces()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(spaces, gc.HasLen, 2)
+	c.Assert(spaces[0].Name(), gc.Equals, "apps")
+	c.Assert(s.subnetCIDRs(c, spaces[0]), gc.HasLen, 0)
+	c.Assert(spaces[1].Name(), gc.Equals, "storage")
+
+	subnet, err := s.State.Subnet("10.0.0.0/24")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(subnet.SpaceName(), gc.Equals, "")
+}
+
+func (s *SpaceSuite) TestAddSpaceErrors(c *gc.C) {
+	s.addSubnets(c, "10.0.0.0/24")
+	_, err := s.State.AddSpace("storage", []string{"10.0.0.0/24"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.State.AddSpace("Bad_Name", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add space "Bad_Name": space name "Bad_Name" not valid`)
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+
+	_, err = s.State.AddSpace("storage", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add space "storage": space "storage" already exists`)
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+
+	_, err = s.State.AddSpace("apps", []string{"10.9.0.0/24"})
+	c.Assert(err, gc.ErrorMatches, `cannot add space "apps": subnet "10.9.0.0/24" not found`)
+
+	_, err = s.State.AddSpace("apps", []string{"10.0.0.0/24"})
+	c.Assert(err, gc.ErrorMatches, `cannot add space "apps": subnet "10.0.0.0/24" is already in space "storage"`)
+
+	_, err = s.State.AddSpace("apps", []string{"10.3.0.0/24", "10.3.0.0/24"})
+	c.Assert(err, gc.ErrorMatches, `cannot add space "apps": subnet "10.3.0.0/24" specified more than once`)
+
+	_, err = s.State.Space("apps")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *SpaceSuite) TestAddSubnetInSpace(c *gc.C) {
+	_, err := s.State.AddSpace("storage", nil)
+	c.Assert(err, jc.ErrorIsNil)
+
+	subnet, err := s.State.AddSubnet(state.SubnetInfo{CIDR: "10.0.0.0/24", SpaceName: "storage"})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(subnet.SpaceName(), gc.Equals, "storage")
+
+	_, err = s.State.AddSubnet(state.SubnetInfo{CIDR: "10.1.0.0/24", SpaceName: "missing"})
+	c.Assert(err, gc.ErrorMatches, `cannot add subnet "10.1.0.0/24": space "missing" not found`)
+}
+
+func (s *SpaceSuite) TestSetEndpointBindings(c *gc.C) {
+	_, err := s.State.AddSpace("db", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(wordpress.EndpointBindings(), gc.HasLen, 0)
+
+	err = wordpress.SetEndpointBindings(map[string]string{"db": "db"})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(wordpress.EndpointBindings(), jc.DeepEquals, map[string]string{"db": "db"})
+
+	wordpress, err = s.State.Service("wordpress")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(wordpress.EndpointBindings(), jc.DeepEquals, map[string]string{"db": "db"})
+
+	err = wordpress.SetEndpointBindings(map[string]string{"bogus": "db"})
+	c.Assert(err, gc.ErrorMatches, `cannot set endpoint bindings for service "wordpress": endpoint "bogus" not found`)
+	err = wordpress.SetEndpointBindings(map[string]string{"url": "missing"})
+	c.Assert(err, gc.ErrorMatches, `cannot set endpoint bindings for service "wordpress": space "missing" not found`)
+	c.Assert(wordpress.EndpointBindings(), jc.DeepEquals, map[string]string{"db": "db"})
+}
+
+func (s *SpaceSuite) TestAddServiceWithEndpointBindings(c *gc.C) {
+	_, err := s.State.AddSpace("db", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	owner := s.Owner.String()
+	ch := s.AddTestingCharm(c, "wordpress")
+
+	wordpress, err := s.State.AddServiceWithEndpointBindings("wordpress", owner, ch, nil, nil, map[string]string{"db": "db"})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(wordpress.EndpointBindings(), jc.DeepEquals, map[string]string{"db": "db"})
+	wordpress, err = s.State.Service("wordpress")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(wordpress.EndpointBindings(), jc.DeepEquals, map[string]string{"db": "db"})
+
+	// Invalid bindings are rejected before the service is added.
+	_, err = s.State.AddServiceWithEndpointBindings("blog", owner, ch, nil, nil, map[string]string{"db": "missing"})
+	c.Assert(err, gc.ErrorMatches, `cannot add service "blog": cannot bind endpoints: space "missing" not found`)
+	_, err = s.State.Service("blog")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *SpaceSuite) TestSetCharmRevalidatesEndpointBindings(c *gc.C) {
+	_, err := s.State.AddSpace("db", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	err = wordpress.SetEndpointBindings(map[string]string{"db": "db", "url": "db"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	// The new charm no longer defines the bound db endpoint.
+	noDB := s.AddMetaCharm(c, "wordpress", `
+name: wordpress
+summary: "Blog engine"
+description: "A pretty popular blog engine"
+provides:
+  url:
+    interface: http
+`, 99)
+	err = wordpress.SetCharm(noDB, false)
+	c.Assert(err, gc.ErrorMatches, `endpoint "db" is bound to space "db" but not defined by charm ".*wordpress-99"`)
+
+	err = wordpress.SetEndpointBindings(map[string]string{"url": "db"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = wordpress.SetCharm(noDB, false)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *SpaceSuite) TestRelationUnitPrivateAddressInBoundSpace(c *gc.C) {
+	s.addSubnets(c, "10.1.0.0/16")
+	_, err := s.State.AddSpace("db", []string{"10.1.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints("wordpress", "mysql")
+	c.Assert(err, jc.ErrorIsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, jc.ErrorIsNil)
+
+	unit, err := wordpress.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, jc.ErrorIsNil)
+	err = machine.SetProviderAddresses(
+		network.NewScopedAddress("10.0.0.5", network.ScopeCloudLocal),
+		network.NewScopedAddress("10.1.0.5", network.ScopeCloudLocal),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+
+	ru, err := rel.Unit(unit)
+	c.Assert(err, jc.ErrorIsNil)
+	address, ok := ru.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(address, gc.Equals, "10.0.0.5")
+
+	err = wordpress.SetEndpointBindings(map[string]string{"db": "db"})
+	c.Assert(err, jc.ErrorIsNil)
+	address, ok = ru.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(address, gc.Equals, "10.1.0.5")
+}
+
+func (s *SpaceSuite) TestAssignToCleanMachineWithSpacesConstraint(c *gc.C) {
+	s.addSubnets(c, "10.1.0.0/16")
+	_, err := s.State.AddSpace("db", []string{"10.1.0.0/16"})
+	c.Assert(err, jc.ErrorIsNil)
+
+	outside, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = outside.SetProviderAddresses(network.NewScopedAddress("10.0.0.5", network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+	inside, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	err = inside.SetProviderAddresses(network.NewScopedAddress("10.1.0.5", network.ScopeCloudLocal))
+	c.Assert(err, jc.ErrorIsNil)
+
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	err = mysql.SetConstraints(constraints.MustParse("spaces=db"))
+	c.Assert(err, jc.ErrorIsNil)
+	unit, err := mysql.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	m, err := unit.AssignToCleanMachine()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.Id(), gc.Equals, inside.Id())
+
+	// No other machine has an address in the space.
+	unit, err = mysql.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = unit.AssignToCleanMachine()
+	c.Assert(err, gc.ErrorMatches, eligibleMachinesInUse)
+}
@@ -55,6 +55,7 @@ const (
 	constraintsC       = "constraints"
 	unitsC             = "units"
 	subnetsC           = "subnets"
+	spacesC            = "spaces"
 	ipaddressesC       = "ipaddresses"
 
 	// actionsC and related collections store state of Actions that
@@ -114,6 +115,59 @@ const (
 	// blocksC is used to identify collection of environment blocks.
 	blocksC = "blocks"
 
+	// payloadsC is used to record the workload payloads registered
+	// by units.
+	payloadsC = "payloads"
+
+	// resourcesC is used to record the charm resources uploaded
+	// for services.
+	resourcesC = "resources"
+
+	// hookTranscriptsC is used to record transcripts of hook
+	// executions reported by unit agents.
+	hookTranscriptsC = "hooktranscripts"
+
+	// configHistoryC is used to record changes to the environment
+	// config and to services' charm config settings.
+	configHistoryC = "confighistory"
+
+	// actionSchedulesC is used to record the schedules on which
+	// actions are enqueued, and actionScheduleRunsC records the
+	// actions enqueued each time one of them runs.
+	actionSchedulesC    = "actionschedules"
+	actionScheduleRunsC = "actionscheduleruns"
+
+	// actionBatchesC is used to record actions run across the units
+	// of a service as a single operation.
+	actionBatchesC = "actionbatches"
+
+	// runJobsC is used to record the results of commands run on
+	// machines and units in the background by "juju run".
+	runJobsC = "runjobs"
+
+	// apiTokensC is used to record the tokens with which users log
+	// in to the API in place of their passwords.
+	apiTokensC = "apitokens"
+
+	// apiServerReportsC is used to record the connection counts and
+	// clock samples periodically reported by each API server.
+	apiServerReportsC = "apiserverreports"
+
+	// quotasC is used to record the resource quotas placed on
+	// environments and their owners, and the usage counted against
+	// them.
+	quotasC = "quotas"
+
+	// unusedBlobsC is used to record when each charm archive, tools
+	// tarball and container image in blob storage was first found to
+	// be unused, so that it can be removed after a grace period.
+	unusedBlobsC = "unusedblobs"
+
+	// repositoryCharmsC is used to record the charm archives published
+	// in the controller's charm repository, which is shared by all
+	// environments.
+	repositoryCharmsC = "repositorycharms"
+
 	// The following mongo collections are used as unique key restraints. The
 	// _id field of each collection is a concatenation of multiple fields
 	// that form a compound index.
@@ -183,6 +237,11 @@ func (st *State) RemoveAllEnvironDocs() error {
 		Assert: bson.D{{"life", Dying}},
 		Remove: true,
 	}}
+	quotaOps, err := st.removeEnvironQuotaOps(env.Owner())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ops = append(ops, quotaOps...)
 
 	// add all multiEnv docs to the txn
 	var ids []bson.M
@@ -503,6 +562,17 @@ type ValidateConfigFunc func(updateAttrs map[string]interface{}, removeAttrs []s
 // configuration of the environment with the provided updateAttrs and
 // removeAttrs.
 func (st *State) UpdateEnvironConfig(updateAttrs map[string]interface{}, removeAttrs []string, additionalValidation ValidateConfigFunc) error {
+	return st.updateEnvironConfig("", updateAttrs, removeAttrs, additionalValidation)
+}
+
+// UpdateEnvironConfigAs is like UpdateEnvironConfig, but records the
+// given user as the author of the change in the environment config
+// history.
+func (st *State) UpdateEnvironConfigAs(author names.UserTag, updateAttrs map[string]interface{}, removeAttrs []string, additionalValidation ValidateConfigFunc) error {
+	return st.updateEnvironConfig(author.Name(), updateAttrs, removeAttrs, additionalValidation)
+}
+
+func (st *State) updateEnvironConfig(author string, updateAttrs map[string]interface{}, removeAttrs []string, additionalValidation ValidateConfigFunc) error {
 	if len(updateAttrs)+len(removeAttrs) == 0 {
 		return nil
 	}
@@ -541,7 +611,7 @@ func (st *State) UpdateEnvironConfig(updateAttrs map[string]interface{}, removeA
 		}
 	}
 	settings.Update(validAttrs)
-	_, err = settings.Write()
+	_, err = st.writeSettingsWithHistory(settings, environGlobalKey, author)
 	return errors.Trace(err)
 }
 
@@ -794,7 +864,7 @@ func (st *State) AddCharm(ch charm.Charm, curl *charm.URL, storagePath, bundleSh
 		} else if err != nil {
 			return nil, errors.Trace(err)
 		} else if placeholderDoc.Placeholder {
-			return updateCharmOps(st, ch, curl, storagePath, bundleSha256, stillPlaceholder)
+			return updateCharmOps(st, ch, curl, storagePath, bundleSha256, "", stillPlaceholder)
 		}
 		return nil, errors.AlreadyExistsf("charm %q", curl)
 	}
@@ -1023,8 +1093,10 @@ func (st *State) AddStoreCharmPlaceholder(curl *charm.URL) (err error) {
 }
 
 // UpdateUploadedCharm marks the given charm URL as uploaded and
-// updates the rest of its data, returning it as *state.Charm.
-func (st *State) UpdateUploadedCharm(ch charm.Charm, curl *charm.URL, storagePath, bundleSha256 string) (*Charm, error) {
+// updates the rest of its data, returning it as *state.Charm. The
+// signer identifies the owner of the key whose signature of the charm
+// archive was verified, and is empty if the archive was not verified.
+func (st *State) UpdateUploadedCharm(ch charm.Charm, curl *charm.URL, storagePath, bundleSha256, signer string) (*Charm, error) {
 	charms, closer := st.getCollection(charmsC)
 	defer closer()
 
@@ -1040,7 +1112,7 @@ func (st *State) UpdateUploadedCharm(ch charm.Charm, curl *charm.URL, storagePat
 		return nil, errors.Trace(&ErrCharmAlreadyUploaded{curl})
 	}
 
-	ops, err := updateCharmOps(st, ch, curl, storagePath, bundleSha256, stillPending)
+	ops, err := updateCharmOps(st, ch, curl, storagePath, bundleSha256, signer, stillPending)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -1087,6 +1159,15 @@ func (st *State) addPeerRelationsOps(serviceName string, peers map[string]charm.
 // they will be created automatically.
 func (st *State) AddService(
 	name, owner string, ch *Charm, networks []string, storage map[string]StorageConstraints,
+) (service *Service, err error) {
+	return st.AddServiceWithEndpointBindings(name, owner, ch, networks, storage, nil)
+}
+
+// AddServiceWithEndpointBindings creates a new service as AddService
+// does, with its relation endpoints bound to spaces as described by
+// bindings; see Service.SetEndpointBindings.
+func (st *State) AddServiceWithEndpointBindings(
+	name, owner string, ch *Charm, networks []string, storage map[string]StorageConstraints, bindings map[string]string,
 ) (service *Service, err error) {
 	defer errors.DeferredAnnotatef(&err, "cannot add service %q", name)
 	ownerTag, err := names.ParseUserTag(owner)
@@ -1123,6 +1204,10 @@ func (st *State) AddService(
 	if err := validateStorageConstraints(st, storage, ch.Meta()); err != nil {
 		return nil, errors.Trace(err)
 	}
+	bindingsOps, err := endpointBindingsOps(st, ch.Meta(), bindings)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot bind endpoints")
+	}
 	serviceID := st.docID(name)
 	// Create the service addition operations.
 	peers := ch.Meta().Peers
@@ -1137,6 +1222,9 @@ func (st *State) AddService(
 		Life:          Alive,
 		OwnerTag:      owner,
 	}
+	if len(bindings) > 0 {
+		svcDoc.EndpointBindings = bindings
+	}
 	svc := newService(st, svcDoc)
 	ops := []txn.Op{
 		env.assertAliveOp(),
@@ -1170,6 +1258,7 @@ func (st *State) AddService(
 		return nil, errors.Trace(err)
 	}
 	ops = append(ops, peerOps...)
+	ops = append(ops, bindingsOps...)
 
 	if err := st.runTransaction(ops); err == txn.ErrAborted {
 		err := env.Refresh()
@@ -1178,6 +1267,9 @@ func (st *State) AddService(
 		} else if err != nil {
 			return nil, errors.Trace(err)
 		}
+		if _, err := endpointBindingsOps(st, ch.Meta(), bindings); err != nil {
+			return nil, errors.Annotate(err, "cannot bind endpoints")
+		}
 		return nil, errors.Errorf("service already exists")
 	} else if err != nil {
 		return nil, errors.Trace(err)
@@ -1232,6 +1324,7 @@ func (st *State) AddSubnet(args SubnetInfo) (subnet *Subnet, err error) {
 		AllocatableIPHigh: args.AllocatableIPHigh,
 		AllocatableIPLow:  args.AllocatableIPLow,
 		AvailabilityZone:  args.AvailabilityZone,
+		SpaceName:         args.SpaceName,
 	}
 	subnet = &Subnet{doc: subDoc, st: st}
 	err = subnet.Validate()
@@ -1244,12 +1337,28 @@ func (st *State) AddSubnet(args SubnetInfo) (subnet *Subnet, err error) {
 		Assert: txn.DocMissing,
 		Insert: subDoc,
 	}}
+	if args.SpaceName != "" {
+		space, err := st.Space(args.SpaceName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if space.Life() != Alive {
+			return nil, errors.Errorf("space %q is not alive", args.SpaceName)
+		}
+		ops = append(ops, txn.Op{
+			C:      spacesC,
+			Id:     space.doc.DocID,
+			Assert: isAliveDoc,
+		})
+	}
 
 	err = st.runTransaction(ops)
 	switch err {
 	case txn.ErrAborted:
 		if _, err = st.Subnet(args.CIDR); err == nil {
 			return nil, errors.AlreadyExistsf("subnet %q", args.CIDR)
+		} else if errors.IsNotFound(err) && args.SpaceName != "" {
+			return nil, errors.Errorf("space %q is not alive", args.SpaceName)
 		} else if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -1664,7 +1773,9 @@ func (st *State) AddRelation(eps ...Endpoint) (r *Relation, err error) {
 		return ops, nil
 	}
 	if err = st.run(buildTxn); err == nil {
-		return &Relation{st, *doc}, nil
+		relation := &Relation{st, *doc}
+		relation.recordStatusHistory(StatusJoined)
+		return relation, nil
 	}
 	return nil, errors.Trace(err)
 }
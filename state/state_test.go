@@ -828,18 +828,18 @@ func (s *StateSuite) TestUpdateUploadedCharm(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 
 	// Test with already uploaded and a missing charms.
-	sch, err := s.State.UpdateUploadedCharm(ch, curl, storagePath, bundleSHA256)
+	sch, err := s.State.UpdateUploadedCharm(ch, curl, storagePath, bundleSHA256, "")
 	c.Assert(err, gc.ErrorMatches, fmt.Sprintf("charm %q already uploaded", curl))
 	c.Assert(sch, gc.IsNil)
 	missingCurl := charm.MustParseURL("local:quantal/missing-1")
-	sch, err = s.State.UpdateUploadedCharm(ch, missingCurl, storagePath, "missing")
+	sch, err = s.State.UpdateUploadedCharm(ch, missingCurl, storagePath, "missing", "")
 	c.Assert(err, jc.Satisfies, errors.IsNotFound)
 	c.Assert(sch, gc.IsNil)
 
 	// Test with with an uploaded local charm.
 	_, err = s.State.PrepareLocalCharmUpload(missingCurl)
 	c.Assert(err, jc.ErrorIsNil)
-	sch, err = s.State.UpdateUploadedCharm(ch, missingCurl, storagePath, "missing")
+	sch, err = s.State.UpdateUploadedCharm(ch, missingCurl, storagePath, "missing", "Charmer <charmer@example.com>")
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(sch.URL(), gc.DeepEquals, missingCurl)
 	c.Assert(sch.Revision(), gc.Equals, missingCurl.Revision)
@@ -849,6 +849,7 @@ func (s *StateSuite) TestUpdateUploadedCharm(c *gc.C) {
 	c.Assert(sch.Config(), gc.DeepEquals, ch.Config())
 	c.Assert(sch.StoragePath(), gc.DeepEquals, storagePath)
 	c.Assert(sch.BundleSha256(), gc.Equals, "missing")
+	c.Assert(sch.Signer(), gc.Equals, "Charmer <charmer@example.com>")
 }
 
 func (s *StateSuite) TestUpdateUploadedCharmEscapesSpecialCharsInConfig(c *gc.C) {
@@ -882,7 +883,7 @@ options:
 
 	preparedCurl, err := s.State.PrepareLocalCharmUpload(missingCurl)
 	c.Assert(err, jc.ErrorIsNil)
-	sch, err := s.State.UpdateUploadedCharm(ch, preparedCurl, storagePath, "missing")
+	sch, err := s.State.UpdateUploadedCharm(ch, preparedCurl, storagePath, "missing", "")
 	c.Assert(err, jc.ErrorIsNil)
 	c.Assert(sch.URL(), gc.DeepEquals, missingCurl)
 	c.Assert(sch.Revision(), gc.Equals, missingCurl.Revision)
@@ -1180,6 +1181,21 @@ func (s *StateSuite) TestAddMachineExtraConstraints(c *gc.C) {
 	c.Assert(mcons, gc.DeepEquals, expectedCons)
 }
 
+func (s *StateSuite) TestAddMachineCloudInitUserData(c *gc.C) {
+	userData := "runcmd: [echo hello]\n"
+	m, err := s.State.AddOneMachine(state.MachineTemplate{
+		Series:            "quantal",
+		Jobs:              []state.MachineJob{state.JobHostUnits},
+		CloudInitUserData: userData,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.CloudInitUserData(), gc.Equals, userData)
+
+	m, err = s.State.Machine(m.Id())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.CloudInitUserData(), gc.Equals, userData)
+}
+
 func (s *StateSuite) TestAddMachineWithVolumes(c *gc.C) {
 	pm := poolmanager.New(state.NewStateSettings(s.State))
 	_, err := pm.Create("loop-pool", provider.LoopProviderType, map[string]interface{}{})
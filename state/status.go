@@ -123,6 +123,18 @@ const (
 	StatusActive Status = "active"
 )
 
+const (
+	// Status values recorded only in the status history of relations,
+	// which have no current status.
+
+	// The relation has been added between its services.
+	StatusJoined Status = "joined"
+
+	// The relation has been destroyed, and will be removed once no
+	// units remain in scope.
+	StatusBroken Status = "broken"
+)
+
 type statusNotFoundError struct {
 	error
 }
@@ -300,13 +312,59 @@ func updateStatusHistory(oldDoc statusDoc, globalKey string, st *State) error {
 	return errors.Annotatef(err, "cannot update status history of unit agent %q", globalKey)
 }
 
+// recordStatusHistory adds an entry to the status history of the
+// entity with the given global key, for a status that takes effect now.
+// It is used for entities whose current status is not kept in the
+// statuses collection, and so is not recorded when it is replaced.
+func recordStatusHistory(status Status, info string, globalKey string, st *State) error {
+	timestamp := nowToTheSecond()
+	doc := statusDoc{
+		EnvUUID:    st.EnvironUUID(),
+		Status:     status,
+		StatusInfo: info,
+		Updated:    &timestamp,
+	}
+	return updateStatusHistory(doc, globalKey, st)
+}
+
+// StatusHistoryFilter restricts the entries returned by a status
+// history query.
+type StatusHistoryFilter struct {
+	// Size, if not zero, limits the entries to the most recent Size.
+	Size int
+
+	// From and To, if not zero, limit the entries to those for
+	// statuses set no earlier than From and before To.
+	From time.Time
+	To   time.Time
+}
+
 func statusHistory(size int, globalKey string, st *State) ([]StatusInfo, error) {
+	return filteredStatusHistory(StatusHistoryFilter{Size: size}, globalKey, st)
+}
+
+// filteredStatusHistory returns the entries in the status history of
+// the entity with the given global key that match the filter, most
+// recent first.
+func filteredStatusHistory(filter StatusHistoryFilter, globalKey string, st *State) ([]StatusInfo, error) {
 	statusHistory, closer := st.getCollection(statusesHistoryC)
 	defer closer()
 
+	query := bson.D{{"entityid", globalKey}}
+	updated := bson.D{}
+	if !filter.From.IsZero() {
+		updated = append(updated, bson.DocElem{"$gte", filter.From})
+	}
+	if !filter.To.IsZero() {
+		updated = append(updated, bson.DocElem{"$lt", filter.To})
+	}
+	if len(updated) > 0 {
+		query = append(query, bson.DocElem{"updated", updated})
+	}
+
 	sInfo := []StatusInfo{}
 	results := []historicalStatusDoc{}
-	err := statusHistory.Find(bson.D{{"entityid", globalKey}}).Sort("-_id").Limit(size).All(&results)
+	err := statusHistory.Find(query).Sort("-_id").Limit(filter.Size).All(&results)
 	if err == mgo.ErrNotFound {
 		return []StatusInfo{}, errors.NotFoundf("statusHistory")
 	}
@@ -606,6 +664,21 @@ func PruneStatusHistory(st *State, maxLogsPerEntity int) error {
 	return nil
 }
 
+// PruneStatusHistoryOlderThan removes the status history entries for
+// statuses set more than maxAge ago.
+func PruneStatusHistoryOlderThan(st *State, maxAge time.Duration) error {
+	history, closer := st.getCollection(statusesHistoryC)
+	defer closer()
+	// The same caveat about mixing txn and non-txn operations
+	// applies here as in PruneStatusHistory.
+	historyW := history.Writeable()
+
+	_, err := historyW.RemoveAll(bson.D{
+		{"updated", bson.M{"$lt": nowToTheSecond().Add(-maxAge)}},
+	})
+	return errors.Trace(err)
+}
+
 // getOldestTimeToKeep returns the create time for the oldest
 // status log to be kept.
 func getOldestTimeToKeep(coll mongo.Collection, globalKey string, size int) (int, bool, error) {
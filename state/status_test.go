@@ -5,6 +5,7 @@ package state_test
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
@@ -61,6 +62,58 @@ func (s *statusSuite) TestPruneStatusHistory(c *gc.C) {
 	c.Assert(history[99].Message, gc.Equals, "Status change 101")
 }
 
+func (s *statusSuite) addHistory(c *gc.C, globalKey string, updated ...time.Time) {
+	for i, t := range updated {
+		t := t
+		doc := state.StatusDoc{
+			Status:     state.StatusActive,
+			StatusInfo: fmt.Sprintf("Status change %d", i),
+			Updated:    &t,
+		}
+		err := state.UpdateStatusHistory(state.NewStatusDoc(doc), globalKey, s.State)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *statusSuite) TestFilteredStatusHistory(c *gc.C) {
+	globalKey := "BogusKey"
+	base := state.NowToTheSecond().Add(-time.Hour)
+	s.addHistory(c, globalKey, base, base.Add(time.Minute), base.Add(2*time.Minute), base.Add(3*time.Minute))
+
+	history, err := state.FilteredStatusHistory(state.StatusHistoryFilter{
+		From: base.Add(time.Minute),
+		To:   base.Add(3 * time.Minute),
+	}, globalKey, s.State)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 2)
+	c.Check(history[0].Message, gc.Equals, "Status change 2")
+	c.Check(history[1].Message, gc.Equals, "Status change 1")
+
+	history, err = state.FilteredStatusHistory(state.StatusHistoryFilter{
+		Size: 1,
+		From: base.Add(time.Minute),
+	}, globalKey, s.State)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 1)
+	c.Check(history[0].Message, gc.Equals, "Status change 3")
+}
+
+func (s *statusSuite) TestPruneStatusHistoryOlderThan(c *gc.C) {
+	now := state.NowToTheSecond()
+	s.addHistory(c, "BogusKey", now.Add(-3*time.Hour), now.Add(-time.Hour))
+	s.addHistory(c, "OtherKey", now.Add(-4*time.Hour))
+
+	err := state.PruneStatusHistoryOlderThan(s.State, 2*time.Hour)
+	c.Assert(err, jc.ErrorIsNil)
+	history, err := state.StatusHistory(10, "BogusKey", s.State)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 1)
+	c.Check(history[0].Message, gc.Equals, "Status change 1")
+	history, err = state.StatusHistory(10, "OtherKey", s.State)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(history, gc.HasLen, 0)
+}
+
 func (s *statusSuite) TestTranslateLegacyAgentState(c *gc.C) {
 	for i, test := range []struct {
 		agentStatus     state.Status
@@ -133,6 +133,10 @@ type storageInstanceDoc struct {
 	StorageName     string      `bson:"storagename"`
 	AttachmentCount int         `bson:"attachmentcount"`
 	CharmURL        *charm.URL  `bson:"charmurl"`
+
+	// Size is the size requested for the storage instance, in MiB,
+	// which is counted against the environment's storage quota.
+	Size uint64 `bson:"size"`
 }
 
 type storageAttachment struct {
@@ -247,7 +251,7 @@ func (st *State) destroyStorageInstanceOps(s *storageInstance) ([]txn.Op, error)
 		// remove the storage instance immediately.
 		hasNoAttachments := bson.D{{"attachmentcount", 0}}
 		assert := append(hasNoAttachments, isAliveDoc...)
-		return removeStorageInstanceOps(st, s.StorageTag(), assert)
+		return removeStorageInstanceOps(st, s, assert)
 	}
 	// There are still attachments: the storage instance will be removed
 	// when the last attachment is removed. We schedule a cleanup to destroy
@@ -269,13 +273,14 @@ func (st *State) destroyStorageInstanceOps(s *storageInstance) ([]txn.Op, error)
 	return ops, nil
 }
 
-// removeStorageInstanceOps removes the storage instance with the given
-// tag from state, if the specified assertions hold true.
+// removeStorageInstanceOps removes the given storage instance from
+// state, if the specified assertions hold true.
 func removeStorageInstanceOps(
 	st *State,
-	tag names.StorageTag,
+	s *storageInstance,
 	assert bson.D,
 ) ([]txn.Op, error) {
+	tag := s.StorageTag()
 	ops := []txn.Op{{
 		C:      storageInstancesC,
 		Id:     tag.Id(),
@@ -317,7 +322,11 @@ func removeStorageInstanceOps(
 	} else if !errors.IsNotFound(err) {
 		return nil, errors.Trace(err)
 	}
-	return ops, nil
+	quotaOps, err := st.quotaOps(quotaDelta{storageMiB: -int64(s.doc.Size)})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return append(ops, quotaOps...), nil
 }
 
 // createStorageOps returns txn.Ops for creating storage instances
@@ -409,6 +418,7 @@ func createStorageOps(
 				Owner:       owner,
 				StorageName: t.storageName,
 				CharmURL:    curl,
+				Size:        t.cons.Size,
 			}
 			if unit, ok := entity.(names.UnitTag); ok {
 				doc.AttachmentCount = 1
@@ -681,7 +691,7 @@ func removeStorageAttachmentOps(
 			// Either the storage instance is dying, or its owner
 			// is a unit; in either case, no more attachments can
 			// be added to the instance, so it can be removed.
-			siOps, err := removeStorageInstanceOps(st, si.StorageTag(), hasLastRef)
+			siOps, err := removeStorageInstanceOps(st, si, hasLastRef)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
@@ -723,19 +733,37 @@ func removeStorageInstancesOps(st *State, owner names.Tag) ([]txn.Op, error) {
 	defer closer()
 
 	var docs []storageInstanceDoc
-	err := coll.Find(bson.D{{"owner", owner.String()}}).Select(bson.D{{"id", true}}).All(&docs)
+	err := coll.Find(bson.D{{"owner", owner.String()}}).Select(bson.D{{"id", true}, {"size", true}}).All(&docs)
 	if err != nil {
 		return nil, errors.Annotatef(err, "cannot get storage instances for %s", owner)
 	}
 	ops := make([]txn.Op, len(docs))
+	var delta quotaDelta
 	for i, doc := range docs {
 		ops[i] = txn.Op{
 			C:      storageInstancesC,
 			Id:     doc.Id,
 			Remove: true,
 		}
+		delta.storageMiB -= int64(doc.Size)
 	}
-	return ops, nil
+	quotaOps, err := st.quotaOps(delta)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return append(ops, quotaOps...), nil
+}
+
+// storageQuotaDelta returns the change in usage from the storage
+// instances inserted by the given operations.
+func storageQuotaDelta(ops []txn.Op) quotaDelta {
+	var delta quotaDelta
+	for _, op := range ops {
+		if doc, ok := op.Insert.(*storageInstanceDoc); ok && op.C == storageInstancesC {
+			delta.storageMiB += int64(doc.Size)
+		}
+	}
+	return delta
 }
 
 // storageConstraintsDoc contains storage constraints for an entity.
@@ -1201,6 +1229,11 @@ func (st *State) constructAddUnitStorageOps(
 		Update: bson.D{{"$set",
 			bson.D{{"storageattachmentcount", newCount}}}},
 	}}
+	quotaOps, err := st.quotaOps(storageQuotaDelta(storageOps))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ops = append(ops, quotaOps...)
 	return append(ops, storageOps...), nil
 }
 
@@ -38,6 +38,10 @@ type SubnetInfo struct {
 	// AvailabilityZone describes which availability zone this subnet is in. It can
 	// be empty if the provider does not support availability zones.
 	AvailabilityZone string
+
+	// SpaceName is the name of the space the subnet is part of. It can
+	// be empty if the subnet is not in a space yet.
+	SpaceName string
 }
 
 type Subnet struct {
@@ -55,6 +59,7 @@ type subnetDoc struct {
 	AllocatableIPLow  string `bson:"allocatableiplow,omitempty"`
 	VLANTag           int    `bson:"vlantag,omitempty"`
 	AvailabilityZone  string `bson:"availabilityzone,omitempty"`
+	SpaceName         string `bson:"spacename,omitempty"`
 }
 
 // Life returns whether the subnet is Alive, Dying or Dead.
@@ -168,6 +173,12 @@ func (s *Subnet) AvailabilityZone() string {
 	return s.doc.AvailabilityZone
 }
 
+// SpaceName returns the name of the space the subnet is part of. If the
+// subnet is not in a space it will be the empty string.
+func (s *Subnet) SpaceName() string {
+	return s.doc.SpaceName
+}
+
 // Validate validates the subnet, checking the CIDR, VLANTag and
 // AllocatableIPHigh and Low, if present.
 func (s *Subnet) Validate() error {
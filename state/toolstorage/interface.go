@@ -35,6 +35,11 @@ type Storage interface {
 	// Metadata returns the Metadata for the specified version
 	// if it exists, else an error satisfying errors.IsNotFound.
 	Metadata(v version.Binary) (Metadata, error)
+
+	// RemoveTools removes the metadata and tools tarball for the
+	// specified version, returning an error satisfying
+	// errors.IsNotFound if they do not exist.
+	RemoveTools(v version.Binary) error
 }
 
 // StorageCloser extends the Storage interface with a Close method.
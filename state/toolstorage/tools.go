@@ -169,6 +169,35 @@ func (s *toolsStorage) AllMetadata() ([]Metadata, error) {
 	return list, nil
 }
 
+func (s *toolsStorage) RemoveTools(v version.Binary) error {
+	var path string
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		doc, err := s.toolsMetadata(v)
+		if err != nil {
+			return nil, err
+		}
+		path = doc.Path
+		return []txn.Op{{
+			C:      s.metadataCollection.Name,
+			Id:     doc.Id,
+			Assert: bson.D{{"path", path}},
+			Remove: true,
+		}}, nil
+	}
+	if err := s.txnRunner.Run(buildTxn); errors.IsNotFound(err) {
+		return err
+	} else if err != nil {
+		return errors.Annotate(err, "cannot remove tools metadata")
+	}
+	// The metadata is removed first, so that the tools are never
+	// listed without their tarball.
+	err := s.managedStorage.RemoveForEnvironment(s.envUUID, path)
+	if err != nil && !errors.IsNotFound(err) {
+		return errors.Annotate(err, "cannot remove tools tarball")
+	}
+	return nil
+}
+
 type toolsMetadataDoc struct {
 	Id      string         `bson:"_id"`
 	Version version.Binary `bson:"version"`
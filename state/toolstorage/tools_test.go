@@ -169,6 +169,20 @@ func (s *ToolsSuite) TestTools(c *gc.C) {
 	c.Assert(string(data), gc.Equals, "blah")
 }
 
+func (s *ToolsSuite) TestRemoveTools(c *gc.C) {
+	err := s.storage.RemoveTools(version.Current)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	s.testAddTools(c, "abc")
+	err = s.storage.RemoveTools(version.Current)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = s.storage.Metadata(version.Current)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, _, err = s.managedStorage.GetForEnvironment("my-uuid", fmt.Sprintf("tools/%s-%s", version.Current, "hash(abc)"))
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
 func (s *ToolsSuite) TestAddToolsRemovesExisting(c *gc.C) {
 	// Add a metadata doc and a blob at a known path, then
 	// call AddTools and ensure the original blob is removed.
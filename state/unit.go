@@ -6,6 +6,7 @@ package state
 import (
 	stderrors "errors"
 	"fmt"
+	"net"
 	"time"
 
 	"github.com/juju/errors"
@@ -762,6 +763,21 @@ func (u *Unit) PrivateAddress() (string, bool) {
 	return privateAddress, privateAddress != ""
 }
 
+// spaceAddress returns the internal address of the unit's machine in
+// the named space. If the machine has no address in the space, an
+// error satisfying errors.IsNotFound is returned.
+func (u *Unit) spaceAddress(spaceName string) (string, error) {
+	networks, err := spaceNetworks(u.st, spaceName)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	addresses := addressesInNetworks(u.addressesOfMachine(), networks)
+	if address := network.SelectInternalAddress(addresses, false); address != "" {
+		return address, nil
+	}
+	return "", errors.NotFoundf("address of unit %q in space %q", u, spaceName)
+}
+
 // AvailabilityZone returns the name of the availability zone into which
 // the unit's machine instance was provisioned.
 func (u *Unit) AvailabilityZone() (string, error) {
@@ -810,10 +826,10 @@ func (u *Unit) AgentStatus() (StatusInfo, error) {
 	return agent.Status()
 }
 
-// StatusHistory returns a slice of at most <size> StatusInfo items
-// representing past statuses for this unit.
-func (u *Unit) StatusHistory(size int) ([]StatusInfo, error) {
-	return statusHistory(size, u.globalKey(), u.st)
+// StatusHistory returns a slice of StatusInfo items, most recent
+// first, representing past statuses for this unit that match filter.
+func (u *Unit) StatusHistory(filter StatusHistoryFilter) ([]StatusInfo, error) {
+	return filteredStatusHistory(filter, u.globalKey(), u.st)
 }
 
 // Status returns the status of the unit.
@@ -1365,6 +1381,15 @@ func (u *Unit) assignToNewMachine(template MachineTemplate, parentId string, con
 	if err != nil {
 		return err
 	}
+	if parentId == "" && containerType == "" {
+		// A new container's host machine is counted by
+		// addMachineInsideNewMachineOps.
+		quotaOps, err := u.st.quotaOps(machinesQuotaDelta(1, mdoc))
+		if err != nil {
+			return err
+		}
+		ops = append(ops, quotaOps...)
+	}
 	// Ensure the host machine is really clean.
 	if parentId != "" {
 		parentDocId := u.st.docID(parentId)
@@ -1878,11 +1903,29 @@ func (u *Unit) assignToCleanMaybeEmptyMachine(requireEmpty bool) (m *Machine, er
 		assignContextf(&err, u, context)
 		return nil, err
 	}
+
+	// If the unit must be deployed into particular spaces, only
+	// machines with an address in each of them are suitable.
+	var spaces map[string][]*net.IPNet
+	if cons.HaveSpaces() {
+		spaces = make(map[string][]*net.IPNet)
+		for _, spaceName := range *cons.Spaces {
+			networks, err := spaceNetworks(u.st, spaceName)
+			if err != nil {
+				assignContextf(&err, u, context)
+				return nil, err
+			}
+			spaces[spaceName] = networks
+		}
+	}
 	var unprovisioned []*Machine
 	var instances []instance.Id
 	instanceMachines := make(map[instance.Id]*Machine)
 	for _, mdoc := range mdocs {
 		m := newMachine(u.st, mdoc)
+		if spaces != nil && !machineInSpaces(m, spaces) {
+			continue
+		}
 		instance, err := m.InstanceId()
 		if errors.IsNotProvisioned(err) {
 			unprovisioned = append(unprovisioned, m)
@@ -1981,6 +2024,30 @@ type ActionSpecsByName map[string]charm.ActionSpec
 // this Unit, and returns its ID.  Note that the use of spec.InsertDefaults
 // mutates payload.
 func (u *Unit) AddAction(name string, payload map[string]interface{}) (*Action, error) {
+	payloadWithDefaults, err := u.actionPayload(name, payload)
+	if err != nil {
+		return nil, err
+	}
+	return u.st.EnqueueAction(u.Tag(), name, payloadWithDefaults)
+}
+
+// addActionOps returns the document of a new action of type name, and
+// the operations needed to enqueue it on the unit. Like AddAction, it
+// mutates payload.
+func (u *Unit) addActionOps(name string, payload map[string]interface{}) (actionDoc, []txn.Op, error) {
+	if u.Life() == Dead {
+		return actionDoc{}, nil, ErrDead
+	}
+	payloadWithDefaults, err := u.actionPayload(name, payload)
+	if err != nil {
+		return actionDoc{}, nil, err
+	}
+	return u.st.enqueueActionOps(u.Tag(), name, payloadWithDefaults)
+}
+
+// actionPayload validates the payload of the named action against the
+// unit's charm, and returns it with any defaults inserted.
+func (u *Unit) actionPayload(name string, payload map[string]interface{}) (map[string]interface{}, error) {
 	if len(name) == 0 {
 		return nil, errors.New("no action name given")
 	}
@@ -1997,11 +2064,7 @@ func (u *Unit) AddAction(name string, payload map[string]interface{}) (*Action,
 	if err != nil {
 		return nil, err
 	}
-	payloadWithDefaults, err := spec.InsertDefaults(payload)
-	if err != nil {
-		return nil, err
-	}
-	return u.st.EnqueueAction(u.Tag(), name, payloadWithDefaults)
+	return spec.InsertDefaults(payload)
 }
 
 // ActionSpecs gets the ActionSpec map for the Unit's charm.
@@ -694,7 +694,7 @@ func (s *UnitSuite) TestGetUnitStatusHistory(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 	globalKey := state.UnitGlobalKey(s.unit)
 	history := func(i int) ([]state.StatusInfo, error) {
-		return s.unit.StatusHistory(i)
+		return s.unit.StatusHistory(state.StatusHistoryFilter{Size: i})
 	}
 	testGetUnitStatusHistory(c, history, s.State, globalKey)
 }
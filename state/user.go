@@ -40,6 +40,24 @@ func (st *State) checkUserExists(name string) (bool, error) {
 
 // AddUser adds a user to the database.
 func (st *State) AddUser(name, displayName, password, creator string) (*User, error) {
+	return st.addUser(name, displayName, password, creator, "")
+}
+
+// AddDirectoryUser adds a user that is authenticated against the named
+// external directory, rather than with a password held in the database.
+func (st *State) AddDirectoryUser(name, displayName, directory, creator string) (*User, error) {
+	if directory == "" {
+		return nil, errors.NotValidf("empty directory name")
+	}
+	// The user is given a random password, but it is never checked.
+	password, err := utils.RandomPassword()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return st.addUser(name, displayName, password, creator, directory)
+}
+
+func (st *State) addUser(name, displayName, password, creator, directory string) (*User, error) {
 	if !names.IsValidUserName(name) {
 		return nil, errors.Errorf("invalid user name %q", name)
 	}
@@ -58,6 +76,7 @@ func (st *State) AddUser(name, displayName, password, creator string) (*User, er
 			PasswordSalt: salt,
 			CreatedBy:    creator,
 			DateCreated:  nowToTheSecond(),
+			Directory:    directory,
 		},
 	}
 	ops := []txn.Op{{
@@ -173,6 +192,13 @@ type userDoc struct {
 	// It is really informational only as far as everyone except the
 	// api server is concerned.
 	LastLogin *time.Time `bson:"lastlogin"`
+	// Directory holds the name of the external directory the user
+	// is authenticated against, if any.
+	Directory string `bson:"directory,omitempty"`
+	// RemovedFromDirectory records that the user was disabled
+	// because its directory no longer knew it, rather than by an
+	// administrator.
+	RemovedFromDirectory bool `bson:"removed-from-directory,omitempty"`
 }
 
 // String returns "<name>@local" where <name> is the Name of the user.
@@ -200,6 +226,13 @@ func (u *User) DateCreated() time.Time {
 	return u.doc.DateCreated.UTC()
 }
 
+// Directory returns the name of the external directory the User is
+// authenticated against, or the empty string if the User has a password
+// held in the database.
+func (u *User) Directory() string {
+	return u.doc.Directory
+}
+
 // Tag returns the Tag for the User.
 func (u *User) Tag() names.Tag {
 	return u.UserTag()
@@ -299,6 +332,11 @@ func (u *User) PasswordValid(password string) bool {
 	if u.IsDisabled() {
 		return false
 	}
+	// Users authenticated against an external directory have no
+	// password of their own.
+	if u.doc.Directory != "" {
+		return false
+	}
 	if u.doc.PasswordSalt != "" {
 		return utils.UserPasswordHash(password, u.doc.PasswordSalt) == u.doc.PasswordHash
 	}
@@ -331,6 +369,58 @@ func (u *User) Refresh() error {
 
 // Disable deactivates the user.  Disabled identities cannot log in.
 func (u *User) Disable() error {
+	if err := u.checkCanDisable(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(u.setDeactivated(true, false), "cannot disable user %q", u.Name())
+}
+
+// Enable reactivates the user, setting disabled to false.
+func (u *User) Enable() error {
+	return errors.Annotatef(u.setDeactivated(false, false), "cannot enable user %q", u.Name())
+}
+
+// DisableRemovedFromDirectory deactivates a user authenticated against
+// an external directory that no longer knows the user. Unlike users
+// disabled with Disable, such users may be reactivated with
+// EnableReturnedToDirectory once the directory knows them again.
+func (u *User) DisableRemovedFromDirectory() error {
+	if err := u.checkCanDisable(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(u.setDeactivated(true, true), "cannot disable user %q", u.Name())
+}
+
+// EnableReturnedToDirectory reactivates a user disabled with
+// DisableRemovedFromDirectory. It fails if the user has since been
+// enabled or disabled by other means.
+func (u *User) EnableReturnedToDirectory() error {
+	ops := []txn.Op{{
+		C:      usersC,
+		Id:     u.Name(),
+		Assert: bson.D{{"removed-from-directory", true}},
+		Update: bson.D{
+			{"$set", bson.D{{"deactivated", false}}},
+			{"$unset", bson.D{{"removed-from-directory", nil}}},
+		},
+	}}
+	if err := u.st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.Errorf("cannot enable user %q: user was not disabled for leaving its directory", u.Name())
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot enable user %q", u.Name())
+	}
+	u.doc.Deactivated = false
+	u.doc.RemovedFromDirectory = false
+	return nil
+}
+
+// IsRemovedFromDirectory returns whether the user was disabled with
+// DisableRemovedFromDirectory.
+func (u *User) IsRemovedFromDirectory() bool {
+	return u.doc.RemovedFromDirectory
+}
+
+func (u *User) checkCanDisable() error {
 	environment, err := u.st.StateServerEnvironment()
 	if err != nil {
 		return errors.Trace(err)
@@ -338,20 +428,18 @@ func (u *User) Disable() error {
 	if u.doc.Name == environment.Owner().Name() {
 		return errors.Unauthorizedf("cannot disable state server environment owner")
 	}
-	return errors.Annotatef(u.setDeactivated(true), "cannot disable user %q", u.Name())
-}
-
-// Enable reactivates the user, setting disabled to false.
-func (u *User) Enable() error {
-	return errors.Annotatef(u.setDeactivated(false), "cannot enable user %q", u.Name())
+	return nil
 }
 
-func (u *User) setDeactivated(value bool) error {
+func (u *User) setDeactivated(value, removedFromDirectory bool) error {
 	ops := []txn.Op{{
 		C:      usersC,
 		Id:     u.Name(),
 		Assert: txn.DocExists,
-		Update: bson.D{{"$set", bson.D{{"deactivated", value}}}},
+		Update: bson.D{{"$set", bson.D{
+			{"deactivated", value},
+			{"removed-from-directory", removedFromDirectory},
+		}}},
 	}}
 	if err := u.st.runTransaction(ops); err != nil {
 		if err == txn.ErrAborted {
@@ -360,6 +448,7 @@ func (u *User) setDeactivated(value bool) error {
 		return err
 	}
 	u.doc.Deactivated = value
+	u.doc.RemovedFromDirectory = removedFromDirectory
 	return nil
 }
 
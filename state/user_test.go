@@ -71,6 +71,28 @@ func (s *UserSuite) TestAddUser(c *gc.C) {
 	c.Assert(user.LastLogin(), gc.IsNil)
 }
 
+func (s *UserSuite) TestAddDirectoryUser(c *gc.C) {
+	user, err := s.State.AddDirectoryUser("bob", "Bob Brown", "ldap", "admin")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.Name(), gc.Equals, "bob")
+	c.Assert(user.DisplayName(), gc.Equals, "Bob Brown")
+	c.Assert(user.Directory(), gc.Equals, "ldap")
+	c.Assert(user.CreatedBy(), gc.Equals, "admin")
+	c.Assert(user.PasswordValid(""), jc.IsFalse)
+
+	// Directory users never have a valid password of their own.
+	c.Assert(user.SetPassword("a-password"), jc.ErrorIsNil)
+	user, err = s.State.User(user.UserTag())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.Directory(), gc.Equals, "ldap")
+	c.Assert(user.PasswordValid("a-password"), jc.IsFalse)
+
+	_, err = s.State.AddDirectoryUser("bob", "Bob Brown", "ldap", "admin")
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+	_, err = s.State.AddDirectoryUser("alice", "", "", "admin")
+	c.Assert(err, gc.ErrorMatches, "empty directory name not valid")
+}
+
 func (s *UserSuite) TestCheckUserExists(c *gc.C) {
 	user := s.factory.MakeUser(c, nil)
 	exists, err := state.CheckUserExists(s.State, user.Name())
@@ -138,6 +160,45 @@ func (s *UserSuite) TestDisable(c *gc.C) {
 	c.Assert(user.PasswordValid("a-password"), jc.IsTrue)
 }
 
+func (s *UserSuite) TestDisableRemovedFromDirectory(c *gc.C) {
+	user, err := s.State.AddDirectoryUser("bob", "Bob Brown", "ldap", "admin")
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = user.EnableReturnedToDirectory()
+	c.Assert(err, gc.ErrorMatches, `cannot enable user "bob": user was not disabled for leaving its directory`)
+
+	err = user.DisableRemovedFromDirectory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.IsDisabled(), jc.IsTrue)
+	c.Assert(user.IsRemovedFromDirectory(), jc.IsTrue)
+
+	err = user.EnableReturnedToDirectory()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.IsDisabled(), jc.IsFalse)
+	err = user.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.IsDisabled(), jc.IsFalse)
+	c.Assert(user.IsRemovedFromDirectory(), jc.IsFalse)
+}
+
+func (s *UserSuite) TestDisableOverridesRemovedFromDirectory(c *gc.C) {
+	user, err := s.State.AddDirectoryUser("bob", "Bob Brown", "ldap", "admin")
+	c.Assert(err, jc.ErrorIsNil)
+	err = user.DisableRemovedFromDirectory()
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Once disabled by an administrator, the user is not enabled
+	// when it returns to the directory.
+	err = user.Disable()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.IsRemovedFromDirectory(), jc.IsFalse)
+	err = user.EnableReturnedToDirectory()
+	c.Assert(err, gc.ErrorMatches, `cannot enable user "bob": user was not disabled for leaving its directory`)
+	err = user.Refresh()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(user.IsDisabled(), jc.IsTrue)
+}
+
 func (s *UserSuite) TestSetPasswordHash(c *gc.C) {
 	user := s.factory.MakeUser(c, nil)
 
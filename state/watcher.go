@@ -1406,9 +1406,13 @@ func (st *State) WatchForEnvironConfigChanges() NotifyWatcher {
 }
 
 // WatchAPIHostPorts returns a NotifyWatcher that notifies
-// when the set of API addresses changes.
+// when the set of API addresses changes, including when the
+// environment is migrated to another state server.
 func (st *State) WatchAPIHostPorts() NotifyWatcher {
-	return newEntityWatcher(st, stateServersC, apiHostPortsKey)
+	return newDocWatcher(st, []docKey{
+		{stateServersC, apiHostPortsKey},
+		{environmentsC, st.EnvironUUID()},
+	})
 }
 
 // WatchStorageAttachment returns a watcher for observing changes
@@ -1435,7 +1439,9 @@ func (st *State) WatchFilesystemAttachment(m names.MachineTag, f names.Filesyste
 // WatchConfigSettings returns a watcher for observing changes to the
 // unit's service configuration settings. The unit must have a charm URL
 // set before this method is called, and the returned watcher will be
-// valid only while the unit's charm URL is not changed.
+// valid only while the unit's charm URL is not changed. Uploading a
+// resource for the unit's service also triggers the watcher, so that
+// the charm can fetch it in its config-changed hook.
 // TODO(fwereade): this could be much smarter; if it were, uniter.Filter
 // could be somewhat simpler.
 func (u *Unit) WatchConfigSettings() (NotifyWatcher, error) {
@@ -1443,7 +1449,15 @@ func (u *Unit) WatchConfigSettings() (NotifyWatcher, error) {
 		return nil, fmt.Errorf("unit charm not set")
 	}
 	settingsKey := serviceSettingsKey(u.doc.Service, u.doc.CharmURL)
-	return newEntityWatcher(u.st, settingsC, u.st.docID(settingsKey)), nil
+	return newDocWatcher(u.st, []docKey{
+		{
+			settingsC,
+			u.st.docID(settingsKey),
+		}, {
+			resourcesC,
+			u.st.docID(resourcesGlobalKey(u.doc.Service)),
+		},
+	}), nil
 }
 
 // WatchMeterStatus returns a watcher observing changes that affect the meter status
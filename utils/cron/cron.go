@@ -0,0 +1,230 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package cron parses the five-field schedule expressions understood by
+// cron(8) and computes the times at which they fire.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears bounds the search for the next matching time, so that
+// expressions that can never fire (such as "0 0 30 2 *") terminate.
+const maxSearchYears = 5
+
+// field describes one of the five fields of an expression.
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// shorthands maps the supported "@" forms onto their full expressions.
+var shorthands = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expr   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDom bool
+	anyDow bool
+}
+
+// Parse parses a cron expression of the form
+//
+//	minute hour day-of-month month day-of-week
+//
+// Each field may be "*", a number, a range "a-b", or a comma-separated
+// list of those, and numbers and ranges may be followed by "/step".
+// Months and days of the week may also be given by their three letter
+// English names. The shorthands @hourly, @daily, @midnight, @weekly,
+// @monthly, @yearly and @annually are also accepted.
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@") {
+		full, ok := shorthands[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron shorthand %q", spec)
+		}
+		spec = full
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, not %d", expr, len(fields))
+	}
+	s := &Schedule{expr: expr}
+	var err error
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	// Sunday may be written as either 0 or 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.anyDom = fields[2] == "*"
+	s.anyDow = fields[4] == "*"
+	return s, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time strictly after t, truncated to the minute,
+// at which the schedule fires; times are matched in t's location. The
+// zero time is returned if the schedule never fires.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+	for t.Before(limit) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether t's day matches the schedule. As in cron(8),
+// when both day fields are restricted a day matching either will do.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	domMatch := has(s.dom, t.Day())
+	dowMatch := has(s.dow, int(t.Weekday()))
+	switch {
+	case s.anyDom && s.anyDow:
+		return true
+	case s.anyDom:
+		return dowMatch
+	case s.anyDow:
+		return domMatch
+	}
+	return domMatch || dowMatch
+}
+
+func has(set uint64, value int) bool {
+	return set&(1<<uint(value)) != 0
+}
+
+// parse returns the set of values described by spec, as a bitmask.
+func (f field) parse(spec string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(spec, ",") {
+		bits, err := f.parsePart(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q: %v", f.name, spec, err)
+		}
+		set |= bits
+	}
+	return set, nil
+}
+
+func (f field) parsePart(part string) (uint64, error) {
+	rangeSpec, step := part, 1
+	if i := strings.Index(part, "/"); i >= 0 {
+		var err error
+		rangeSpec = part[:i]
+		step, err = strconv.Atoi(part[i+1:])
+		if err != nil || step < 1 {
+			return 0, fmt.Errorf("bad step %q", part[i+1:])
+		}
+	}
+	start, end := f.min, f.max
+	switch {
+	case rangeSpec == "*":
+	case strings.Contains(rangeSpec, "-"):
+		bounds := strings.SplitN(rangeSpec, "-", 2)
+		var err error
+		if start, err = f.value(bounds[0]); err != nil {
+			return 0, err
+		}
+		if end, err = f.value(bounds[1]); err != nil {
+			return 0, err
+		}
+		if end < start {
+			return 0, fmt.Errorf("range %q is backwards", rangeSpec)
+		}
+	default:
+		value, err := f.value(rangeSpec)
+		if err != nil {
+			return 0, err
+		}
+		start = value
+		if step == 1 {
+			end = value
+		}
+	}
+	var bits uint64
+	for v := start; v <= end; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, f.min, f.max)
+	}
+	return v, nil
+}
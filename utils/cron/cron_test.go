@@ -0,0 +1,81 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cron_test
+
+import (
+	"time"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/utils/cron"
+)
+
+type cronSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&cronSuite{})
+
+// base is a Wednesday.
+var base = time.Date(2015, 7, 1, 12, 3, 30, 0, time.UTC)
+
+var nextTests = []struct {
+	expr string
+	next time.Time
+}{
+	{"* * * * *", time.Date(2015, 7, 1, 12, 4, 0, 0, time.UTC)},
+	{"*/15 * * * *", time.Date(2015, 7, 1, 12, 15, 0, 0, time.UTC)},
+	{"5/20 * * * *", time.Date(2015, 7, 1, 12, 5, 0, 0, time.UTC)},
+	{"0,30 9-17 * * *", time.Date(2015, 7, 1, 12, 30, 0, 0, time.UTC)},
+	{"0 3 * * *", time.Date(2015, 7, 2, 3, 0, 0, 0, time.UTC)},
+	{"0 0 * * sun", time.Date(2015, 7, 5, 0, 0, 0, 0, time.UTC)},
+	{"0 0 * * 7", time.Date(2015, 7, 5, 0, 0, 0, 0, time.UTC)},
+	{"0 0 1 * mon", time.Date(2015, 7, 6, 0, 0, 0, 0, time.UTC)},
+	{"30 2 1 jan *", time.Date(2016, 1, 1, 2, 30, 0, 0, time.UTC)},
+	{"0 0 29 2 *", time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC)},
+	{"@hourly", time.Date(2015, 7, 1, 13, 0, 0, 0, time.UTC)},
+	{"@weekly", time.Date(2015, 7, 5, 0, 0, 0, 0, time.UTC)},
+	{"0 0 30 2 *", time.Time{}},
+}
+
+func (*cronSuite) TestNext(c *gc.C) {
+	for i, test := range nextTests {
+		c.Logf("test %d: %s", i, test.expr)
+		schedule, err := cron.Parse(test.expr)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(schedule.Next(base), gc.DeepEquals, test.next)
+		c.Check(schedule.String(), gc.Equals, test.expr)
+	}
+}
+
+func (*cronSuite) TestNextIsStrictlyAfter(c *gc.C) {
+	schedule, err := cron.Parse("0 12 * * *")
+	c.Assert(err, jc.ErrorIsNil)
+	noon := time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC)
+	c.Assert(schedule.Next(noon), gc.DeepEquals, noon.AddDate(0, 0, 1))
+}
+
+var parseErrorTests = []struct {
+	expr string
+	err  string
+}{
+	{"* * * *", `cron expression "\* \* \* \*" must have 5 fields, not 4`},
+	{"60 * * * *", `invalid minute "60": value 60 out of range 0-59`},
+	{"* 24 * * *", `invalid hour "24": value 24 out of range 0-23`},
+	{"* * 0 * *", `invalid day of month "0": value 0 out of range 1-31`},
+	{"* * * foo *", `invalid month "foo": bad value "foo"`},
+	{"5-1 * * * *", `invalid minute "5-1": range "5-1" is backwards`},
+	{"*/0 * * * *", `invalid minute "\*/0": bad step "0"`},
+	{"@often", `unknown cron shorthand "@often"`},
+}
+
+func (*cronSuite) TestParseErrors(c *gc.C) {
+	for i, test := range parseErrorTests {
+		c.Logf("test %d: %s", i, test.expr)
+		_, err := cron.Parse(test.expr)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package actionscheduler
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.actionscheduler")
+
+// period is the interval at which schedules are checked. Schedules are
+// specified to the minute, so there is no point checking more often.
+var period = time.Minute
+
+// timeNow returns the time against which schedules are run.
+var timeNow = time.Now
+
+// NewActionScheduler returns a worker that enqueues the actions of the
+// environment's action schedules as they fall due. Only one such worker
+// should run for each environment.
+func NewActionScheduler(st *state.State) worker.Worker {
+	return worker.NewPeriodicWorker(func(stop <-chan struct{}) error {
+		return runDueSchedules(st, timeNow())
+	}, period)
+}
+
+// runDueSchedules runs each of the environment's action schedules that
+// is due at the given time. A failure to run one schedule is logged
+// rather than preventing the others from running.
+func runDueSchedules(st *state.State, now time.Time) error {
+	schedules, err := st.ActionSchedules()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, schedule := range schedules {
+		run, err := schedule.RunIfDue(now)
+		if errors.IsNotFound(err) {
+			// The schedule was removed while we were working.
+			continue
+		} else if err != nil {
+			logger.Errorf("cannot run action schedule %s: %v", schedule.Id(), err)
+			continue
+		}
+		if run == nil {
+			continue
+		}
+		logger.Infof("action schedule %s queued %d %q action(s)", schedule.Id(), len(run.Actions), schedule.Name())
+		for _, message := range run.Errors {
+			logger.Warningf("action schedule %s: %s", schedule.Id(), message)
+		}
+	}
+	return nil
+}
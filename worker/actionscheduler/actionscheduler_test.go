@@ -0,0 +1,113 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package actionscheduler_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/actionscheduler"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type actionSchedulerSuite struct {
+	testing.JujuConnSuite
+	unit *state.Unit
+}
+
+var _ = gc.Suite(&actionSchedulerSuite{})
+
+func (s *actionSchedulerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	service := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	var err error
+	s.unit, err = service.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *actionSchedulerSuite) addSchedule(c *gc.C, receiver names.Tag) *state.ActionSchedule {
+	schedule, err := s.State.AddActionSchedule(state.ActionScheduleParams{
+		Receivers: []names.Tag{receiver},
+		Name:      "snapshot",
+		Interval:  time.Hour,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	return schedule
+}
+
+func (s *actionSchedulerSuite) pendingActions(c *gc.C) []*state.Action {
+	actions, err := s.unit.PendingActions()
+	c.Assert(err, jc.ErrorIsNil)
+	return actions
+}
+
+func (s *actionSchedulerSuite) TestRunDueSchedules(c *gc.C) {
+	schedule := s.addSchedule(c, s.unit.Tag())
+
+	err := actionscheduler.RunDueSchedules(s.State, schedule.NextRun().Add(-time.Minute))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.pendingActions(c), gc.HasLen, 0)
+
+	err = actionscheduler.RunDueSchedules(s.State, schedule.NextRun())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.pendingActions(c), gc.HasLen, 1)
+
+	// Running again at the same time does not enqueue the action twice.
+	err = actionscheduler.RunDueSchedules(s.State, schedule.NextRun())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.pendingActions(c), gc.HasLen, 1)
+
+	runs, err := schedule.Runs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(runs, gc.HasLen, 1)
+}
+
+func (s *actionSchedulerSuite) TestRunDueSchedulesContinuesPastFailures(c *gc.C) {
+	service, err := s.State.Service("dummy")
+	c.Assert(err, jc.ErrorIsNil)
+	other, err := service.AddUnit()
+	c.Assert(err, jc.ErrorIsNil)
+	first := s.addSchedule(c, other.Tag())
+	second := s.addSchedule(c, s.unit.Tag())
+	c.Assert(other.EnsureDead(), jc.ErrorIsNil)
+	c.Assert(other.Remove(), jc.ErrorIsNil)
+
+	err = actionscheduler.RunDueSchedules(s.State, second.NextRun())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.pendingActions(c), gc.HasLen, 1)
+
+	runs, err := first.Runs()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(runs, gc.HasLen, 1)
+	c.Assert(runs[0].Errors, gc.HasLen, 1)
+}
+
+func (s *actionSchedulerSuite) TestWorker(c *gc.C) {
+	schedule := s.addSchedule(c, s.unit.Tag())
+	s.PatchValue(actionscheduler.Period, coretesting.ShortWait)
+	s.PatchValue(actionscheduler.TimeNow, func() time.Time {
+		return schedule.NextRun()
+	})
+
+	w := actionscheduler.NewActionScheduler(s.State)
+	defer func() { c.Assert(worker.Stop(w), jc.ErrorIsNil) }()
+
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		if len(s.pendingActions(c)) > 0 {
+			return
+		}
+	}
+	c.Fatalf("action schedule %s never ran", schedule.Id())
+}
@@ -0,0 +1,53 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package blobgc
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.blobgc")
+
+// Collector defines the interface for types capable of removing
+// unused blobs from controller storage.
+type Collector interface {
+	CollectUnusedBlobs(gracePeriod time.Duration, dryRun bool) ([]state.UnusedBlob, error)
+}
+
+// New returns a worker which periodically removes the charm archives,
+// tools tarballs and container images that have been unused for longer
+// than the grace period.
+func New(collector Collector, interval, gracePeriod time.Duration) worker.Worker {
+	return worker.NewSimpleWorker(func(stopCh <-chan struct{}) error {
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+				blobs, err := collector.CollectUnusedBlobs(gracePeriod, false)
+				if err != nil {
+					return errors.Annotate(err, "blob collection failed, blobgc stopping")
+				}
+				var removed int
+				var size int64
+				for _, blob := range blobs {
+					if blob.Removed {
+						removed++
+						size += blob.Size
+					}
+				}
+				logger.Debugf("found %d unused blob(s), removed %d (%d bytes)", len(blobs), removed, size)
+				timer.Reset(interval)
+			case <-stopCh:
+				return nil
+			}
+		}
+	})
+}
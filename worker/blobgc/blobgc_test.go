@@ -0,0 +1,83 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package blobgc_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/blobgc"
+)
+
+type BlobGCSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&BlobGCSuite{})
+
+func (s *BlobGCSuite) TestCollects(c *gc.C) {
+	collector := newFakeCollector()
+	w := blobgc.New(collector, 10*time.Millisecond, time.Hour)
+	defer w.Kill()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case call := <-collector.calls:
+			c.Assert(call.gracePeriod, gc.Equals, time.Hour)
+			c.Assert(call.dryRun, jc.IsFalse)
+		case <-time.After(testing.LongWait):
+			c.Fatal("timed out waiting for collection to happen")
+		}
+	}
+}
+
+func (s *BlobGCSuite) TestStopsOnError(c *gc.C) {
+	collector := newFakeCollector()
+	collector.err = errors.New("boom")
+	w := blobgc.New(collector, 10*time.Millisecond, time.Hour)
+	defer w.Kill()
+
+	select {
+	case <-collector.calls:
+	case <-time.After(testing.LongWait):
+		c.Fatal("timed out waiting for collection to happen")
+	}
+	c.Assert(w.Wait(), gc.ErrorMatches, "blob collection failed, blobgc stopping: boom")
+}
+
+func (s *BlobGCSuite) TestStops(c *gc.C) {
+	w := blobgc.New(newFakeCollector(), time.Minute, time.Hour)
+	w.Kill()
+	c.Assert(w.Wait(), jc.ErrorIsNil)
+}
+
+type collectCall struct {
+	gracePeriod time.Duration
+	dryRun      bool
+}
+
+func newFakeCollector() *fakeCollector {
+	return &fakeCollector{
+		calls: make(chan collectCall),
+	}
+}
+
+type fakeCollector struct {
+	calls chan collectCall
+	err   error
+}
+
+// CollectUnusedBlobs implements the blobgc.Collector interface.
+func (f *fakeCollector) CollectUnusedBlobs(gracePeriod time.Duration, dryRun bool) ([]state.UnusedBlob, error) {
+	f.calls <- collectCall{gracePeriod, dryRun}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []state.UnusedBlob{{Kind: state.CharmBlob, Name: "cs:quantal/mysql-1", Removed: true}}, nil
+}
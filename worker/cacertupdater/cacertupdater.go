@@ -0,0 +1,75 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cacertupdater
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/api/watcher"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.cacertupdater")
+
+// CACertUpdater is responsible for propagating the CA certificates
+// that agents trust.
+//
+// In practice, CACertUpdater is used by machine and unit agents to
+// watch the trusted CA certificates in state and write the changes to
+// the agent's config file, so that agents keep trusting the state
+// servers while their CA is rotated.
+type CACertUpdater struct {
+	getter CACertGetter
+	setter CACertSetter
+	caCert string
+}
+
+// CACertGetter is an interface that is provided to NewCACertUpdater
+// which can be used to watch for changes to the trusted CA
+// certificates.
+type CACertGetter interface {
+	TrustedCACert() (string, error)
+	WatchTrustedCACert() (watcher.NotifyWatcher, error)
+}
+
+// CACertSetter is an interface that is provided to NewCACertUpdater
+// whose SetCACert method will be invoked whenever the trusted CA
+// certificates change.
+type CACertSetter interface {
+	SetCACert(caCert string) error
+}
+
+// NewCACertUpdater returns a worker.Worker that watches for changes to
+// the trusted CA certificates and then sets them on the CACertSetter.
+func NewCACertUpdater(getter CACertGetter, setter CACertSetter) worker.Worker {
+	return worker.NewNotifyWorker(&CACertUpdater{
+		getter: getter,
+		setter: setter,
+	})
+}
+
+func (c *CACertUpdater) SetUp() (watcher.NotifyWatcher, error) {
+	return c.getter.WatchTrustedCACert()
+}
+
+func (c *CACertUpdater) Handle(_ <-chan struct{}) error {
+	caCert, err := c.getter.TrustedCACert()
+	if err != nil {
+		return errors.Annotate(err, "error getting CA certificates")
+	}
+	if caCert == "" || caCert == c.caCert {
+		return nil
+	}
+	if err := c.setter.SetCACert(caCert); err != nil {
+		return errors.Annotate(err, "error setting CA certificates")
+	}
+	c.caCert = caCert
+	logger.Infof("trusted CA certificates updated")
+	return nil
+}
+
+func (c *CACertUpdater) TearDown() error {
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cacertupdater_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/cert"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/cacertupdater"
+)
+
+type CACertUpdaterSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&CACertUpdaterSuite{})
+
+type caCertSetter struct {
+	caCerts chan string
+	err     error
+}
+
+func (s *caCertSetter) SetCACert(caCert string) error {
+	s.caCerts <- caCert
+	return s.err
+}
+
+func (s *CACertUpdaterSuite) TestStartStop(c *gc.C) {
+	st, _ := s.OpenAPIAsNewMachine(c, state.JobHostUnits)
+	worker := cacertupdater.NewCACertUpdater(st.Agent(), &caCertSetter{})
+	worker.Kill()
+	c.Assert(worker.Wait(), gc.IsNil)
+}
+
+func (s *CACertUpdaterSuite) TestCACertUpdates(c *gc.C) {
+	setter := &caCertSetter{caCerts: make(chan string, 1)}
+	st, _ := s.OpenAPIAsNewMachine(c, state.JobHostUnits)
+	worker := cacertupdater.NewCACertUpdater(st.Agent(), setter)
+	defer func() { c.Assert(worker.Wait(), gc.IsNil) }()
+	defer worker.Kill()
+
+	// SetCACert should be called with the initial value.
+	select {
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for SetCACert to be called")
+	case caCert := <-setter.caCerts:
+		c.Assert(caCert, gc.Equals, coretesting.CACert)
+	}
+
+	// Starting a CA rotation adds the new CA to the trusted bundle.
+	newCACert, newCAKey, err := cert.NewCA("testenv", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, jc.ErrorIsNil)
+	err = s.State.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, jc.ErrorIsNil)
+	select {
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for SetCACert to be called")
+	case caCert := <-setter.caCerts:
+		c.Assert(caCert, gc.Equals, coretesting.CACert+newCACert)
+	}
+
+	// Advancing the rotation does not change the bundle, so it is
+	// not set again.
+	_, err = s.State.AdvanceCARotation(true)
+	c.Assert(err, jc.ErrorIsNil)
+	select {
+	case caCert := <-setter.caCerts:
+		c.Fatalf("unexpected CA certificates set: %q", caCert)
+	case <-time.After(coretesting.ShortWait):
+	}
+}
@@ -8,12 +8,14 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
+	"launchpad.net/tomb"
 
 	"github.com/juju/juju/api/watcher"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
+	statewatcher "github.com/juju/juju/state/watcher"
 	"github.com/juju/juju/worker"
 )
 
@@ -23,19 +25,23 @@ var logger = loggo.GetLogger("juju.worker.certupdater")
 //
 // In practice, CertificateUpdater is used by a state server's machine agent to watch
 // that server's machines addresses in state, and write a new certificate to the
-// agent's config file.
+// agent's config file. It also reissues the certificate, signed by the new CA,
+// when a CA rotation starts reissuing certificates.
 type CertificateUpdater struct {
 	addressWatcher AddressWatcher
 	getter         StateServingInfoGetter
 	setter         StateServingInfoSetter
 	configGetter   EnvironConfigGetter
+	caGetter       CAGetter
 	certChanged    chan params.StateServingInfo
 	addresses      []network.Address
+	reissuing      bool
 }
 
 // AddressWatcher is an interface that is provided to NewCertificateUpdater
 // which can be used to watch for machine address changes.
 type AddressWatcher interface {
+	Id() string
 	WatchAddresses() state.NotifyWatcher
 	Addresses() (addresses []network.Address)
 }
@@ -46,6 +52,16 @@ type EnvironConfigGetter interface {
 	EnvironConfig() (*config.Config, error)
 }
 
+// CAGetter is an interface that is provided to NewCertificateUpdater
+// which can be used to watch for CA rotations, get the CA that should
+// sign the state server certificate while one is reissuing certificates,
+// and record that the certificate has been reissued.
+type CAGetter interface {
+	WatchCARotation() state.NotifyWatcher
+	ReissuingCA() (caCert, caPrivateKey string, ok bool, err error)
+	AcknowledgeReissuedCert(machineId, caCert string) error
+}
+
 // StateServingInfoGetter is an interface that is provided to NewCertificateUpdater
 // whose StateServingInfo method will be invoked to get state serving info.
 type StateServingInfoGetter interface {
@@ -58,13 +74,16 @@ type StateServingInfoSetter func(info params.StateServingInfo, done <-chan struc
 
 // NewCertificateUpdater returns a worker.Worker that watches for changes to
 // machine addresses and then generates a new state server certificate with those
-// addresses in the certificate's SAN value.
+// addresses in the certificate's SAN value. It also generates a new certificate
+// when a CA rotation starts reissuing certificates.
 func NewCertificateUpdater(addressWatcher AddressWatcher, getter StateServingInfoGetter,
-	configGetter EnvironConfigGetter, setter StateServingInfoSetter, certChanged chan params.StateServingInfo,
+	configGetter EnvironConfigGetter, caGetter CAGetter, setter StateServingInfoSetter,
+	certChanged chan params.StateServingInfo,
 ) worker.Worker {
 	return worker.NewNotifyWorker(&CertificateUpdater{
 		addressWatcher: addressWatcher,
 		configGetter:   configGetter,
+		caGetter:       caGetter,
 		getter:         getter,
 		setter:         setter,
 		certChanged:    certChanged,
@@ -73,14 +92,23 @@ func NewCertificateUpdater(addressWatcher AddressWatcher, getter StateServingInf
 
 // SetUp is defined on the NotifyWatchHandler interface.
 func (c *CertificateUpdater) SetUp() (watcher.NotifyWatcher, error) {
-	return c.addressWatcher.WatchAddresses(), nil
+	return newCombinedWatcher(
+		c.addressWatcher.WatchAddresses(),
+		c.caGetter.WatchCARotation(),
+	), nil
 }
 
 // Handle is defined on the NotifyWatchHandler interface.
 func (c *CertificateUpdater) Handle(done <-chan struct{}) error {
 	addresses := c.addressWatcher.Addresses()
 	logger.Debugf("new machine addresses: %#v", addresses)
-	if reflect.DeepEqual(addresses, c.addresses) {
+	newCACert, newCAPrivateKey, reissuing, err := c.caGetter.ReissuingCA()
+	if err != nil {
+		return errors.Annotate(err, "cannot read CA rotation")
+	}
+	startedReissuing := reissuing && !c.reissuing
+	c.reissuing = reissuing
+	if reflect.DeepEqual(addresses, c.addresses) && !startedReissuing {
 		// Sometimes the watcher will tell us things have changed, when they
 		// haven't as far as we can tell.
 		logger.Debugf("addresses haven't really changed since last updated cert")
@@ -105,7 +133,15 @@ func (c *CertificateUpdater) Handle(done <-chan struct{}) error {
 	if err != nil {
 		return errors.Annotate(err, "cannot read environment config")
 	}
-	envConfig, err = envConfig.Apply(map[string]interface{}{"ca-private-key": caPrivateKey})
+	caAttrs := map[string]interface{}{"ca-private-key": caPrivateKey}
+	if reissuing {
+		// Sign the certificate with the new CA, and keep its key so
+		// that later certificates are signed with it too.
+		caAttrs["ca-cert"] = newCACert
+		caAttrs["ca-private-key"] = newCAPrivateKey
+		stateInfo.CAPrivateKey = newCAPrivateKey
+	}
+	envConfig, err = envConfig.Apply(caAttrs)
 	if err != nil {
 		return errors.Annotate(err, "cannot add CA private key to environment config")
 	}
@@ -130,8 +166,17 @@ func (c *CertificateUpdater) Handle(done <-chan struct{}) error {
 	}
 	stateInfo.Cert = string(newCert)
 	stateInfo.PrivateKey = string(newKey)
-	c.setter(stateInfo, done)
+	if err := c.setter(stateInfo, done); err != nil {
+		return errors.Annotate(err, "cannot set state server certificate")
+	}
 	logger.Infof("State Server cerificate addresses updated to %q", addresses)
+	if reissuing {
+		// The CA rotation cannot finish until every state server
+		// serves a certificate signed by the new CA.
+		if err := c.caGetter.AcknowledgeReissuedCert(c.addressWatcher.Id(), newCACert); err != nil {
+			return errors.Annotate(err, "cannot record reissued certificate")
+		}
+	}
 	return nil
 }
 
@@ -140,3 +185,75 @@ func (c *CertificateUpdater) TearDown() error {
 	close(c.certChanged)
 	return nil
 }
+
+// combinedWatcher is a NotifyWatcher that notifies when either of
+// two other NotifyWatchers does.
+type combinedWatcher struct {
+	tomb    tomb.Tomb
+	w1, w2  state.NotifyWatcher
+	changes chan struct{}
+}
+
+func newCombinedWatcher(w1, w2 state.NotifyWatcher) *combinedWatcher {
+	w := &combinedWatcher{
+		w1:      w1,
+		w2:      w2,
+		changes: make(chan struct{}),
+	}
+	go func() {
+		defer w.tomb.Done()
+		defer close(w.changes)
+		defer statewatcher.Stop(w2, &w.tomb)
+		defer statewatcher.Stop(w1, &w.tomb)
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+func (w *combinedWatcher) loop() error {
+	var out chan struct{}
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case _, ok := <-w.w1.Changes():
+			if !ok {
+				return statewatcher.EnsureErr(w.w1)
+			}
+			out = w.changes
+		case _, ok := <-w.w2.Changes():
+			if !ok {
+				return statewatcher.EnsureErr(w.w2)
+			}
+			out = w.changes
+		case out <- struct{}{}:
+			out = nil
+		}
+	}
+}
+
+// Changes is defined on the NotifyWatcher interface.
+func (w *combinedWatcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Kill is defined on the NotifyWatcher interface.
+func (w *combinedWatcher) Kill() {
+	w.tomb.Kill(nil)
+}
+
+// Wait is defined on the NotifyWatcher interface.
+func (w *combinedWatcher) Wait() error {
+	return w.tomb.Wait()
+}
+
+// Stop is defined on the NotifyWatcher interface.
+func (w *combinedWatcher) Stop() error {
+	w.Kill()
+	return w.Wait()
+}
+
+// Err is defined on the NotifyWatcher interface.
+func (w *combinedWatcher) Err() error {
+	return w.tomb.Err()
+}
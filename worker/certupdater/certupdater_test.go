@@ -60,6 +60,10 @@ type mockMachine struct {
 	changes chan struct{}
 }
 
+func (m *mockMachine) Id() string {
+	return "0"
+}
+
 func (m *mockMachine) WatchAddresses() state.NotifyWatcher {
 	return newMockNotifyWatcher(m.changes)
 }
@@ -89,6 +93,26 @@ func (g *mockConfigGetter) EnvironConfig() (*config.Config, error) {
 
 }
 
+type mockCAGetter struct {
+	changes      chan struct{}
+	caCert       string
+	caPrivateKey string
+	reissued     chan string
+}
+
+func (g *mockCAGetter) WatchCARotation() state.NotifyWatcher {
+	return newMockNotifyWatcher(g.changes)
+}
+
+func (g *mockCAGetter) ReissuingCA() (string, string, bool, error) {
+	return g.caCert, g.caPrivateKey, g.caCert != "", nil
+}
+
+func (g *mockCAGetter) AcknowledgeReissuedCert(machineId, caCert string) error {
+	g.reissued <- machineId + " " + caCert
+	return nil
+}
+
 func (s *CertUpdaterSuite) TestStartStop(c *gc.C) {
 	setter := func(info params.StateServingInfo, dying <-chan struct{}) error {
 		return nil
@@ -96,7 +120,7 @@ func (s *CertUpdaterSuite) TestStartStop(c *gc.C) {
 	changes := make(chan struct{})
 	certChangedChan := make(chan params.StateServingInfo)
 	worker := certupdater.NewCertificateUpdater(
-		&mockMachine{changes}, &mockStateServingGetter{}, &mockConfigGetter{}, setter, certChangedChan,
+		&mockMachine{changes}, &mockStateServingGetter{}, &mockConfigGetter{}, &mockCAGetter{}, setter, certChangedChan,
 	)
 	worker.Kill()
 	c.Assert(worker.Wait(), gc.IsNil)
@@ -121,7 +145,7 @@ func (s *CertUpdaterSuite) TestAddressChange(c *gc.C) {
 	changes := make(chan struct{})
 	certChangedChan := make(chan params.StateServingInfo)
 	worker := certupdater.NewCertificateUpdater(
-		&mockMachine{changes}, &mockStateServingGetter{}, &mockConfigGetter{}, setter, certChangedChan,
+		&mockMachine{changes}, &mockStateServingGetter{}, &mockConfigGetter{}, &mockCAGetter{}, setter, certChangedChan,
 	)
 	defer func() { c.Assert(worker.Wait(), gc.IsNil) }()
 	defer worker.Kill()
@@ -163,7 +187,7 @@ func (s *CertUpdaterSuite) TestAddressChangeNoCAKey(c *gc.C) {
 	changes := make(chan struct{})
 	certChangedChan := make(chan params.StateServingInfo)
 	worker := certupdater.NewCertificateUpdater(
-		&mockMachine{changes}, &mockStateServingGetterNoCAKey{}, &mockConfigGetter{}, setter, certChangedChan,
+		&mockMachine{changes}, &mockStateServingGetterNoCAKey{}, &mockConfigGetter{}, &mockCAGetter{}, setter, certChangedChan,
 	)
 	defer func() { c.Assert(worker.Wait(), gc.IsNil) }()
 	defer worker.Kill()
@@ -176,3 +200,70 @@ func (s *CertUpdaterSuite) TestAddressChangeNoCAKey(c *gc.C) {
 		c.Fatalf("set state serving info unexpectedly called")
 	}
 }
+
+func (s *CertUpdaterSuite) TestCAReissuing(c *gc.C) {
+	newCACert, newCAKey, err := cert.NewCA("testenv", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, jc.ErrorIsNil)
+	newCA, err := cert.ParseCert(newCACert)
+	c.Assert(err, jc.ErrorIsNil)
+
+	infos := make(chan params.StateServingInfo, 1)
+	setter := func(info params.StateServingInfo, dying <-chan struct{}) error {
+		infos <- info
+		return nil
+	}
+	addressChanges := make(chan struct{})
+	caGetter := &mockCAGetter{
+		changes:  make(chan struct{}),
+		reissued: make(chan string, 1),
+	}
+	certChangedChan := make(chan params.StateServingInfo)
+	worker := certupdater.NewCertificateUpdater(
+		&mockMachine{addressChanges}, &mockStateServingGetter{}, &mockConfigGetter{}, caGetter, setter, certChangedChan,
+	)
+	defer func() { c.Assert(worker.Wait(), gc.IsNil) }()
+	defer worker.Kill()
+
+	// The certificate is first issued by the old CA.
+	addressChanges <- struct{}{}
+	select {
+	case info := <-infos:
+		c.Assert(info.CAPrivateKey, gc.Equals, coretesting.CAKey)
+		srvCert, err := cert.ParseCert(info.Cert)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(srvCert.CheckSignatureFrom(newCA), gc.NotNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for certificate to be updated")
+	}
+
+	// When the CA rotation starts reissuing certificates, the
+	// certificate is reissued by the new CA even though the
+	// addresses have not changed.
+	caGetter.caCert, caGetter.caPrivateKey = newCACert, newCAKey
+	caGetter.changes <- struct{}{}
+	select {
+	case info := <-infos:
+		c.Assert(info.CAPrivateKey, gc.Equals, newCAKey)
+		srvCert, err := cert.ParseCert(info.Cert)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(srvCert.CheckSignatureFrom(newCA), jc.ErrorIsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for certificate to be reissued")
+	}
+	// Once reissued, the certificate is recorded as signed by the
+	// new CA, so the rotation can finish.
+	select {
+	case reissued := <-caGetter.reissued:
+		c.Assert(reissued, gc.Equals, "0 "+newCACert)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for reissued certificate to be recorded")
+	}
+
+	// Further CA rotation changes do not reissue it again.
+	caGetter.changes <- struct{}{}
+	select {
+	case <-infos:
+		c.Fatalf("certificate unexpectedly reissued")
+	case <-time.After(coretesting.ShortWait):
+	}
+}
@@ -149,5 +149,8 @@ func (m *envWorkerManager) isEnvAlive(tag names.EnvironTag) (bool, error) {
 	} else if err != nil {
 		return false, errors.Annotatef(err, "error loading environment %s", tag.Id())
 	}
-	return env.Life() == state.Alive, nil
+	// The workers of a Dying environment are still needed to destroy
+	// its services and machines; they are only stopped once the
+	// environment is Dead or has been removed.
+	return env.Life() != state.Dead, nil
 }
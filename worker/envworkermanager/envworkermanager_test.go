@@ -83,11 +83,22 @@ func (s *suite) TestStopsWorkersWhenEnvGoesAway(c *gc.C) {
 	otherState := s.makeEnvironment(c)
 	runner1 := s.seeRunnersStart(c, 1)[0]
 
-	// Destroy the new environment.
+	// Destroy the new environment. Its runner keeps going while the
+	// environment is Dying.
 	env, err := otherState.Environment()
 	c.Assert(err, jc.ErrorIsNil)
 	err = env.Destroy()
 	c.Assert(err, jc.ErrorIsNil)
+	s.State.StartSync()
+	select {
+	case <-runner1.tomb.Dying():
+		c.Fatal("runner for dying environment should not die here")
+	case <-time.After(testing.ShortWait):
+	}
+
+	// Remove the environment.
+	err = otherState.RemoveAllEnvironDocs()
+	c.Assert(err, jc.ErrorIsNil)
 
 	// See that the first runner is still running but the runner for
 	// the new environment is stopped.
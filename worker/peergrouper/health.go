@@ -0,0 +1,98 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package peergrouper
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/replicaset"
+	"gopkg.in/mgo.v2"
+)
+
+// MemberHealth holds the health of a member of the mongo replica set.
+type MemberHealth struct {
+	Id      int
+	Address string
+
+	// MachineId holds the id of the juju machine running the member,
+	// or "" if the member is not tagged with one.
+	MachineId string
+
+	State   replicaset.MemberState
+	Healthy bool
+
+	// Lag holds how far the member's replication lags behind the
+	// primary.
+	Lag time.Duration
+}
+
+// ReplicaSetHealth returns the health of each member of the replica
+// set to which the session is connected.
+func ReplicaSetHealth(session *mgo.Session) ([]MemberHealth, error) {
+	status, err := replicaset.CurrentStatus(session)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot get replica set status")
+	}
+	members, err := replicaset.CurrentMembers(session)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot get replica set members")
+	}
+	optimes, err := memberOptimes(session)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot get replica set optimes")
+	}
+	return memberHealth(status.Members, members, optimes), nil
+}
+
+// memberOptimes returns the time of the last operation applied by each
+// member of the replica set, keyed by member id.
+func memberOptimes(session *mgo.Session) (map[int]time.Time, error) {
+	var status struct {
+		Members []struct {
+			Id         int       `bson:"_id"`
+			OptimeDate time.Time `bson:"optimeDate"`
+		} `bson:"members"`
+	}
+	if err := session.Run("replSetGetStatus", &status); err != nil {
+		return nil, errors.Trace(err)
+	}
+	optimes := make(map[int]time.Time)
+	for _, member := range status.Members {
+		optimes[member.Id] = member.OptimeDate
+	}
+	return optimes, nil
+}
+
+// memberHealth combines the statuses, configuration and optimes of the
+// members of a replica set.
+func memberHealth(statuses []replicaset.MemberStatus, members []replicaset.Member, optimes map[int]time.Time) []MemberHealth {
+	var primaryOptime time.Time
+	for _, status := range statuses {
+		if status.State == replicaset.PrimaryState {
+			primaryOptime = optimes[status.Id]
+		}
+	}
+	result := make([]MemberHealth, len(statuses))
+	for i, status := range statuses {
+		health := MemberHealth{
+			Id:      status.Id,
+			Address: status.Address,
+			State:   status.State,
+			Healthy: status.Healthy,
+		}
+		for _, member := range members {
+			if member.Id == status.Id {
+				health.MachineId = member.Tags[jujuMachineKey]
+				break
+			}
+		}
+		optime, ok := optimes[status.Id]
+		if ok && !primaryOptime.IsZero() && optime.Before(primaryOptime) {
+			health.Lag = primaryOptime.Sub(optime)
+		}
+		result[i] = health
+	}
+	return result
+}
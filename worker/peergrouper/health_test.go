@@ -0,0 +1,87 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package peergrouper
+
+import (
+	"time"
+
+	"github.com/juju/replicaset"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/testing"
+)
+
+type healthSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&healthSuite{})
+
+func (s *healthSuite) TestMemberHealth(c *gc.C) {
+	now := time.Now()
+	statuses := []replicaset.MemberStatus{{
+		Id:      1,
+		Address: "10.0.0.1:37017",
+		Healthy: true,
+		State:   replicaset.PrimaryState,
+	}, {
+		Id:      2,
+		Address: "10.0.0.2:37017",
+		Healthy: true,
+		State:   replicaset.SecondaryState,
+	}, {
+		Id:      3,
+		Address: "10.0.0.3:37017",
+		Healthy: false,
+		State:   replicaset.UnknownState,
+	}}
+	members := []replicaset.Member{{
+		Id:   1,
+		Tags: map[string]string{jujuMachineKey: "0"},
+	}, {
+		Id:   2,
+		Tags: map[string]string{jujuMachineKey: "1"},
+	}, {
+		Id: 3,
+	}}
+	optimes := map[int]time.Time{
+		1: now,
+		2: now.Add(-3 * time.Second),
+	}
+	health := memberHealth(statuses, members, optimes)
+	c.Assert(health, jc.DeepEquals, []MemberHealth{{
+		Id:        1,
+		Address:   "10.0.0.1:37017",
+		MachineId: "0",
+		State:     replicaset.PrimaryState,
+		Healthy:   true,
+	}, {
+		Id:        2,
+		Address:   "10.0.0.2:37017",
+		MachineId: "1",
+		State:     replicaset.SecondaryState,
+		Healthy:   true,
+		Lag:       3 * time.Second,
+	}, {
+		Id:      3,
+		Address: "10.0.0.3:37017",
+		State:   replicaset.UnknownState,
+	}})
+}
+
+func (s *healthSuite) TestMemberHealthWithoutPrimary(c *gc.C) {
+	statuses := []replicaset.MemberStatus{{
+		Id:      1,
+		Healthy: true,
+		State:   replicaset.SecondaryState,
+	}}
+	optimes := map[int]time.Time{1: time.Now()}
+	health := memberHealth(statuses, nil, optimes)
+	c.Assert(health, jc.DeepEquals, []MemberHealth{{
+		Id:      1,
+		Healthy: true,
+		State:   replicaset.SecondaryState,
+	}})
+}
@@ -153,6 +153,7 @@ func (broker *lxcBroker) StartInstance(args environs.StartInstanceParams) (*envi
 		config.PreferIPv6,
 		config.EnableOSRefreshUpdate,
 		config.EnableOSUpgrade,
+		config.CloudInitUserData,
 	); err != nil {
 		lxcLogger.Errorf("failed to populate machine config: %v", err)
 		return nil, err
@@ -562,6 +562,14 @@ func constructStartInstanceParams(
 		}
 	}
 
+	var subnetsToZones map[network.Id][]string
+	if len(provisioningInfo.SubnetsToZones) > 0 {
+		subnetsToZones = make(map[network.Id][]string)
+		for subnetId, zones := range provisioningInfo.SubnetsToZones {
+			subnetsToZones[network.Id(subnetId)] = zones
+		}
+	}
+
 	return environs.StartInstanceParams{
 		Constraints:       provisioningInfo.Constraints,
 		Tools:             possibleTools,
@@ -569,6 +577,7 @@ func constructStartInstanceParams(
 		Placement:         provisioningInfo.Placement,
 		DistributionGroup: machine.DistributionGroup,
 		Volumes:           volumes,
+		SubnetsToZones:    subnetsToZones,
 	}, nil
 }
 
@@ -737,6 +746,7 @@ func assocProvInfoAndMachCfg(
 
 	instanceConfig.Networks = provInfo.Networks
 	instanceConfig.Tags = provInfo.Tags
+	instanceConfig.MachineCloudInitUserData = provInfo.CloudInitUserData
 
 	if len(provInfo.Jobs) > 0 {
 		instanceConfig.Jobs = provInfo.Jobs
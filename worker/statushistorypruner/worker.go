@@ -17,7 +17,14 @@ import (
 type HistoryPrunerParams struct {
 	// TODO(perrito666) We might want to have some sort of limitation of the collection size too.
 	MaxLogsPerState int
-	PruneInterval   time.Duration
+
+	// MaxHistoryTime, if not zero, is the age beyond which history
+	// entries are removed, however few there are for an entity. If
+	// zero, the environment's status-history-max-age setting is used,
+	// and entries are only removed by age if that is set.
+	MaxHistoryTime time.Duration
+
+	PruneInterval time.Duration
 }
 
 const DefaultMaxLogsPerState = 100
@@ -53,6 +60,20 @@ func (w *pruneWorker) loop(stopCh <-chan struct{}) error {
 		case <-stopCh:
 			return tomb.ErrDying
 		case <-time.After(p.PruneInterval):
+			maxHistoryTime := p.MaxHistoryTime
+			if maxHistoryTime == 0 {
+				cfg, err := w.st.EnvironConfig()
+				if err != nil {
+					return errors.Trace(err)
+				}
+				maxHistoryTime = cfg.StatusHistoryMaxAge()
+			}
+			if maxHistoryTime > 0 {
+				err := state.PruneStatusHistoryOlderThan(w.st, maxHistoryTime)
+				if err != nil {
+					return errors.Trace(err)
+				}
+			}
 			err := state.PruneStatusHistory(w.st, p.MaxLogsPerState)
 			if err != nil {
 				return errors.Trace(err)
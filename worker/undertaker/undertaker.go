@@ -0,0 +1,85 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package undertaker
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.undertaker")
+
+// State defines the State functionality used by the undertaker.
+type State interface {
+	Environment() (*state.Environment, error)
+	AllMachines() ([]*state.Machine, error)
+	AllServices() ([]*state.Service, error)
+	RemoveAllEnvironDocs() error
+}
+
+// NewUndertaker returns a worker which waits for a Dying hosted
+// environment to lose all its machines and services, and then removes
+// all of the environment's documents from state. The machines and
+// services are destroyed by the cleaner, and the machines' instances
+// stopped by the provisioner, so the check is made periodically.
+// The worker exits once the environment has been removed.
+func NewUndertaker(st State, interval time.Duration) worker.Worker {
+	return worker.NewSimpleWorker(func(stopCh <-chan struct{}) error {
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+				removed, err := processDyingEnviron(st)
+				if err != nil {
+					return errors.Annotate(err, "undertaker stopping")
+				}
+				if removed {
+					return nil
+				}
+				timer.Reset(interval)
+			case <-stopCh:
+				return nil
+			}
+		}
+	})
+}
+
+// processDyingEnviron removes all documents of the environment if it
+// is Dying and has no machines or services left, and reports whether
+// it did so.
+func processDyingEnviron(st State) (bool, error) {
+	env, err := st.Environment()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if env.Life() == state.Alive {
+		return false, nil
+	}
+	machines, err := st.AllMachines()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	services, err := st.AllServices()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if len(machines) > 0 || len(services) > 0 {
+		logger.Debugf(
+			"environment %s is dying: waiting for %d machine(s) and %d service(s) to be removed",
+			env.UUID(), len(machines), len(services),
+		)
+		return false, nil
+	}
+	logger.Infof("removing documents of environment %s", env.UUID())
+	if err := st.RemoveAllEnvironDocs(); err != nil {
+		return false, errors.Annotatef(err, "cannot remove environment %s", env.UUID())
+	}
+	return true, nil
+}
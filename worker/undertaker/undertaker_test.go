@@ -0,0 +1,99 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package undertaker_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+	"github.com/juju/juju/worker/undertaker"
+)
+
+func TestPackage(t *stdtesting.T) {
+	testing.MgoTestPackage(t)
+}
+
+type undertakerSuite struct {
+	statetesting.StateSuite
+	hostedState *state.State
+}
+
+var _ = gc.Suite(&undertakerSuite{})
+
+func (s *undertakerSuite) SetUpTest(c *gc.C) {
+	s.StateSuite.SetUpTest(c)
+	s.hostedState = factory.NewFactory(s.State).MakeEnvironment(c, nil)
+	s.AddCleanup(func(*gc.C) { s.hostedState.Close() })
+}
+
+func (s *undertakerSuite) destroyEnvironment(c *gc.C) {
+	env, err := s.hostedState.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	err = env.Destroy()
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *undertakerSuite) assertEnvironmentRemoved(c *gc.C, w interface {
+	Wait() error
+}) {
+	done := make(chan error)
+	go func() {
+		done <- w.Wait()
+	}()
+	select {
+	case err := <-done:
+		c.Assert(err, jc.ErrorIsNil)
+	case <-time.After(testing.LongWait):
+		c.Fatal("timed out waiting for environment to be removed")
+	}
+	_, err := s.State.GetEnvironment(s.hostedState.EnvironTag())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *undertakerSuite) TestRemovesEmptyDyingEnvironment(c *gc.C) {
+	s.destroyEnvironment(c)
+	w := undertaker.NewUndertaker(s.hostedState, 10*time.Millisecond)
+	defer w.Kill()
+	s.assertEnvironmentRemoved(c, w)
+}
+
+func (s *undertakerSuite) TestLeavesAliveEnvironment(c *gc.C) {
+	w := undertaker.NewUndertaker(s.hostedState, 10*time.Millisecond)
+	time.Sleep(testing.ShortWait)
+	w.Kill()
+	c.Assert(w.Wait(), jc.ErrorIsNil)
+
+	env, err := s.hostedState.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.Life(), gc.Equals, state.Alive)
+}
+
+func (s *undertakerSuite) TestWaitsForMachinesToBeRemoved(c *gc.C) {
+	machine, err := s.hostedState.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, jc.ErrorIsNil)
+	s.destroyEnvironment(c)
+
+	w := undertaker.NewUndertaker(s.hostedState, 10*time.Millisecond)
+	defer w.Kill()
+	time.Sleep(testing.ShortWait)
+	env, err := s.hostedState.Environment()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(env.Life(), gc.Equals, state.Dying)
+
+	// Once the provisioner has removed the machine, the
+	// environment goes away.
+	err = machine.EnsureDead()
+	c.Assert(err, jc.ErrorIsNil)
+	err = machine.Remove()
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertEnvironmentRemoved(c, w)
+}
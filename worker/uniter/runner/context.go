@@ -20,6 +20,7 @@ import (
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/worker/uniter/runner/jujuc"
+	"github.com/juju/juju/worker/uniter/runner/transcript"
 )
 
 var logger = loggo.GetLogger("juju.worker.uniter.context")
@@ -176,6 +177,18 @@ type HookContext struct {
 	// This collection will be added to the unit on successful
 	// hook run, so the actual add will happen in a flush.
 	storageAddConstraints map[string][]params.StorageConstraints
+
+	// recordTranscripts is true if hook and action executions in this
+	// context should be recorded, as requested by the environment's
+	// hook-transcripts setting.
+	recordTranscripts bool
+
+	// charmDir is the directory holding the unit's deployed charm.
+	charmDir string
+
+	// resourcesDir is the directory to which charm resources are
+	// downloaded by resource-get.
+	resourcesDir string
 }
 
 func (ctx *HookContext) RequestReboot(priority jujuc.RebootPriority) error {
@@ -319,6 +332,63 @@ func (ctx *HookContext) SetServiceStatus(status jujuc.StatusInfo) error {
 	)
 }
 
+// RegisterPayload records the given workload payload against this unit.
+func (ctx *HookContext) RegisterPayload(payload jujuc.PayloadInfo) error {
+	logger.Debugf("[PAYLOAD] registering %s (%s %s): %s", payload.Name, payload.Type, payload.ID, payload.Status)
+	return ctx.unit.RegisterPayload(params.Payload{
+		Name:   payload.Name,
+		Type:   payload.Type,
+		ID:     payload.ID,
+		Status: payload.Status,
+		Labels: payload.Labels,
+	})
+}
+
+// UnregisterPayload removes the named workload payload from this unit.
+func (ctx *HookContext) UnregisterPayload(name string) error {
+	logger.Debugf("[PAYLOAD] unregistering %s", name)
+	return ctx.unit.UnregisterPayload(name)
+}
+
+// SetPayloadStatus sets the status of the named workload payload.
+func (ctx *HookContext) SetPayloadStatus(name, status string) error {
+	logger.Debugf("[PAYLOAD] %s: %s", name, status)
+	return ctx.unit.SetPayloadStatus(name, status)
+}
+
+// RecordsTranscripts returns whether hook and action executions in
+// this context should be recorded.
+func (ctx *HookContext) RecordsTranscripts() bool {
+	return ctx.recordTranscripts
+}
+
+// SaveTranscript stores the supplied record of an execution in this
+// context with the controller.
+func (ctx *HookContext) SaveTranscript(t *transcript.Transcript) error {
+	calls := make([]params.HookToolCall, len(t.Calls))
+	for i, call := range t.Calls {
+		calls[i] = params.HookToolCall{
+			Command:   call.Command,
+			Args:      call.Args,
+			Stdin:     call.Stdin,
+			Stdout:    call.Stdout,
+			Stderr:    call.Stderr,
+			Code:      call.Code,
+			Truncated: call.Truncated,
+		}
+	}
+	return ctx.unit.AddHookTranscript(params.HookTranscript{
+		Kind:     string(t.Kind),
+		Name:     t.Name,
+		Env:      t.Env,
+		Calls:    calls,
+		ExitCode: t.ExitCode,
+		Error:    t.Error,
+		Started:  t.Started,
+		Duration: t.Duration,
+	})
+}
+
 func (ctx *HookContext) HasExecutionSetUnitStatus() bool {
 	return ctx.hasRunStatusSet
 }
@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"syscall"
 
+	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils/exec"
 	gc "gopkg.in/check.v1"
@@ -117,6 +118,30 @@ func (s *InterfaceSuite) TestSetUnitStatusUpdatesFlag(c *gc.C) {
 	c.Assert(ctx.(runner.Context).HasExecutionSetUnitStatus(), jc.IsTrue)
 }
 
+func (s *InterfaceSuite) TestPayloads(c *gc.C) {
+	ctx := s.GetContext(c, -1, "")
+	err := ctx.RegisterPayload(jujuc.PayloadInfo{
+		Name:   "web",
+		Type:   "docker",
+		ID:     "abc123",
+		Status: "running",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	err = ctx.SetPayloadStatus("web", "stopped")
+	c.Assert(err, jc.ErrorIsNil)
+
+	payload, err := s.unit.Payload("web")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(payload.Type, gc.Equals, "docker")
+	c.Check(payload.ID, gc.Equals, "abc123")
+	c.Check(payload.Status, gc.Equals, state.PayloadStopped)
+
+	err = ctx.UnregisterPayload("web")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = s.unit.Payload("web")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
 func (s *InterfaceSuite) TestUnitStatusCaching(c *gc.C) {
 	ctx := s.GetContext(c, -1, "")
 	status, err := ctx.UnitStatus()
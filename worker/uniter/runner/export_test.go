@@ -13,6 +13,7 @@ import (
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/worker/leadership"
 	"github.com/juju/juju/worker/uniter/runner/jujuc"
+	"github.com/juju/juju/worker/uniter/runner/transcript"
 )
 
 var (
@@ -26,6 +27,13 @@ var (
 	LockTimeout             = lockTimeout
 )
 
+// NewTranscriptReplayer returns the jujuc.Responder used to replay the
+// supplied calls, and a function that reports the divergences found.
+func NewTranscriptReplayer(calls []transcript.ToolCall) (jujuc.Responder, func() []string) {
+	r := &transcriptReplayer{calls: calls}
+	return r.respond, r.finish
+}
+
 func RunnerPaths(rnr Runner) Paths {
 	return rnr.(*runner).paths
 }
@@ -109,6 +117,17 @@ func PatchMetricsReader(ctx Context, reader MetricsReader) func() {
 	}
 }
 
+// PatchResourcePaths patches the charm and resources directories used
+// by the context.
+func PatchResourcePaths(ctx Context, charmDir, resourcesDir string) func() {
+	hctx := ctx.(*HookContext)
+	oldCharmDir, oldResourcesDir := hctx.charmDir, hctx.resourcesDir
+	hctx.charmDir, hctx.resourcesDir = charmDir, resourcesDir
+	return func() {
+		hctx.charmDir, hctx.resourcesDir = oldCharmDir, oldResourcesDir
+	}
+}
+
 func NewHookContext(
 	unit *uniter.Unit,
 	state *uniter.State,
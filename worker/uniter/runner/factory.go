@@ -287,6 +287,8 @@ func (f *factory) coreContext() (*HookContext, error) {
 		metricsSender:      f.unit,
 		pendingPorts:       make(map[PortRange]PortRangeInfo),
 		storage:            f.storage,
+		charmDir:           f.paths.GetCharmDir(),
+		resourcesDir:       f.paths.GetResourcesDir(),
 	}
 	if err := f.updateContext(ctx); err != nil {
 		return nil, err
@@ -359,6 +361,7 @@ func (f *factory) updateContext(ctx *HookContext) (err error) {
 		return err
 	}
 	ctx.proxySettings = environConfig.ProxySettings()
+	ctx.recordTranscripts = environConfig.HookTranscripts()
 
 	// Calling these last, because there's a potential race: they're not guaranteed
 	// to be set in time to be needed for a hook. If they're not, we just leave them
@@ -51,6 +51,8 @@ type HookContext interface {
 	ContextMetrics
 	ContextStorage
 	ContextRelations
+	ContextPayloads
+	ContextResources
 }
 
 // UnitHookContext is the context for a unit hook.
@@ -196,6 +198,28 @@ type ContextStorage interface {
 	AddUnitStorage(map[string]params.StorageConstraints)
 }
 
+// ContextPayloads is the part of a hook context related to the
+// workload payloads (processes, containers, ...) launched by the charm.
+type ContextPayloads interface {
+	// RegisterPayload records the payload against the executing unit.
+	RegisterPayload(PayloadInfo) error
+
+	// UnregisterPayload removes the named payload from the executing unit.
+	UnregisterPayload(name string) error
+
+	// SetPayloadStatus updates the status of the named payload.
+	SetPayloadStatus(name, status string) error
+}
+
+// ContextResources is the part of a hook context related to the
+// charm resources uploaded for the unit's service.
+type ContextResources interface {
+	// ResourceGet makes the current revision of the named resource
+	// available on the unit, downloading it if necessary, and returns
+	// the path of the local file.
+	ResourceGet(name string) (string, error)
+}
+
 // ContextRelations exposes the relations associated with the unit.
 type ContextRelations interface {
 	// Relation returns the relation with the supplied id if it was found, and
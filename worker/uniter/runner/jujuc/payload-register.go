@@ -0,0 +1,84 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+)
+
+// PayloadInfo holds the information about a workload payload that a
+// charm supplies when registering it.
+type PayloadInfo struct {
+	Name   string
+	Type   string
+	ID     string
+	Status string
+	Labels []string
+}
+
+// validPayloadStatus holds the statuses a payload may be registered with.
+var validPayloadStatus = []string{
+	"starting",
+	"running",
+	"stopping",
+	"stopped",
+}
+
+func checkPayloadStatus(status string) error {
+	for _, s := range validPayloadStatus {
+		if s == status {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid status %q, expected one of %v", status, validPayloadStatus)
+}
+
+// PayloadRegisterCommand implements the payload-register command.
+type PayloadRegisterCommand struct {
+	cmd.CommandBase
+	ctx     Context
+	payload PayloadInfo
+}
+
+// NewPayloadRegisterCommand makes a jujuc payload-register command.
+func NewPayloadRegisterCommand(ctx Context) cmd.Command {
+	return &PayloadRegisterCommand{ctx: ctx}
+}
+
+func (c *PayloadRegisterCommand) Info() *cmd.Info {
+	doc := `
+Records a workload payload (for example a daemon process or a docker
+container) launched by the charm, so that it is reported by "juju status"
+and "juju list-payloads". The name identifies the payload within the unit;
+registering a payload with the same name again replaces it. Any labels
+given are recorded with the payload and may be used for filtering.
+`
+	return &cmd.Info{
+		Name:    "payload-register",
+		Args:    "<name> <type> <id> [label...]",
+		Purpose: "register a workload payload",
+		Doc:     doc,
+	}
+}
+
+func (c *PayloadRegisterCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.payload.Status, "status", "running", "the initial status of the payload")
+}
+
+func (c *PayloadRegisterCommand) Init(args []string) error {
+	if len(args) < 3 {
+		return errors.Errorf("invalid args, require <name> <type> <id> [label...]")
+	}
+	c.payload.Name = args[0]
+	c.payload.Type = args[1]
+	c.payload.ID = args[2]
+	c.payload.Labels = args[3:]
+	return checkPayloadStatus(c.payload.Status)
+}
+
+func (c *PayloadRegisterCommand) Run(ctx *cmd.Context) error {
+	return c.ctx.RegisterPayload(c.payload)
+}
@@ -0,0 +1,51 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+)
+
+// PayloadStatusSetCommand implements the payload-status-set command.
+type PayloadStatusSetCommand struct {
+	cmd.CommandBase
+	ctx    Context
+	name   string
+	status string
+}
+
+// NewPayloadStatusSetCommand makes a jujuc payload-status-set command.
+func NewPayloadStatusSetCommand(ctx Context) cmd.Command {
+	return &PayloadStatusSetCommand{ctx: ctx}
+}
+
+func (c *PayloadStatusSetCommand) Info() *cmd.Info {
+	doc := `
+Updates the status of a workload payload previously recorded with
+payload-register.
+`
+	return &cmd.Info{
+		Name:    "payload-status-set",
+		Args:    "<name> <starting | running | stopping | stopped>",
+		Purpose: "update the status of a workload payload",
+		Doc:     doc,
+	}
+}
+
+func (c *PayloadStatusSetCommand) Init(args []string) error {
+	if len(args) < 2 {
+		return errors.Errorf("invalid args, require <name> <status>")
+	}
+	c.name = args[0]
+	c.status = args[1]
+	if err := checkPayloadStatus(c.status); err != nil {
+		return err
+	}
+	return cmd.CheckEmpty(args[2:])
+}
+
+func (c *PayloadStatusSetCommand) Run(ctx *cmd.Context) error {
+	return c.ctx.SetPayloadStatus(c.name, c.status)
+}
@@ -0,0 +1,46 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+)
+
+// PayloadUnregisterCommand implements the payload-unregister command.
+type PayloadUnregisterCommand struct {
+	cmd.CommandBase
+	ctx  Context
+	name string
+}
+
+// NewPayloadUnregisterCommand makes a jujuc payload-unregister command.
+func NewPayloadUnregisterCommand(ctx Context) cmd.Command {
+	return &PayloadUnregisterCommand{ctx: ctx}
+}
+
+func (c *PayloadUnregisterCommand) Info() *cmd.Info {
+	doc := `
+Removes a workload payload previously recorded with payload-register.
+It is not an error to unregister a payload that is not registered.
+`
+	return &cmd.Info{
+		Name:    "payload-unregister",
+		Args:    "<name>",
+		Purpose: "unregister a workload payload",
+		Doc:     doc,
+	}
+}
+
+func (c *PayloadUnregisterCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.Errorf("missing payload name")
+	}
+	c.name = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *PayloadUnregisterCommand) Run(ctx *cmd.Context) error {
+	return c.ctx.UnregisterPayload(c.name)
+}
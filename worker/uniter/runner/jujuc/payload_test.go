@@ -0,0 +1,116 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	"github.com/juju/cmd"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/uniter/runner/jujuc"
+)
+
+type payloadSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&payloadSuite{})
+
+func (s *payloadSuite) run(c *gc.C, hctx *Context, name string, args ...string) (int, *cmd.Context) {
+	com, err := jujuc.NewCommand(hctx, cmdString(name))
+	c.Assert(err, jc.ErrorIsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, args)
+	return code, ctx
+}
+
+var payloadRegisterInitTests = []struct {
+	args []string
+	err  string
+}{
+	{[]string{"web", "docker", "abc"}, ""},
+	{[]string{"web", "docker", "abc", "frontend", "blue"}, ""},
+	{[]string{"--status", "starting", "web", "docker", "abc"}, ""},
+	{[]string{}, `invalid args, require <name> <type> <id> \[label...\]`},
+	{[]string{"web", "docker"}, `invalid args, require <name> <type> <id> \[label...\]`},
+	{[]string{"--status", "exploded", "web", "docker", "abc"}, `invalid status "exploded", expected one of \[starting running stopping stopped\]`},
+}
+
+func (s *payloadSuite) TestPayloadRegisterInit(c *gc.C) {
+	for i, t := range payloadRegisterInitTests {
+		c.Logf("test %d: %#v", i, t.args)
+		hctx := s.GetStatusHookContext(c)
+		com, err := jujuc.NewCommand(hctx, cmdString("payload-register"))
+		c.Assert(err, jc.ErrorIsNil)
+		testing.TestInit(c, com, t.args, t.err)
+	}
+}
+
+func (s *payloadSuite) TestPayloadRegister(c *gc.C) {
+	hctx := s.GetStatusHookContext(c)
+	code, ctx := s.run(c, hctx, "payload-register", "web", "docker", "abc", "frontend")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+	c.Assert(bufferString(ctx.Stdout), gc.Equals, "")
+	c.Assert(hctx.info.Payloads.Payloads, jc.DeepEquals, map[string]jujuc.PayloadInfo{
+		"web": {
+			Name:   "web",
+			Type:   "docker",
+			ID:     "abc",
+			Status: "running",
+			Labels: []string{"frontend"},
+		},
+	})
+}
+
+func (s *payloadSuite) TestPayloadStatusSetInit(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"web", "stopped"}, ""},
+		{[]string{"web"}, `invalid args, require <name> <status>`},
+		{[]string{"web", "broken"}, `invalid status "broken", expected one of \[starting running stopping stopped\]`},
+		{[]string{"web", "stopped", "extra"}, `unrecognized args: \["extra"\]`},
+	} {
+		c.Logf("test %d: %#v", i, t.args)
+		hctx := s.GetStatusHookContext(c)
+		com, err := jujuc.NewCommand(hctx, cmdString("payload-status-set"))
+		c.Assert(err, jc.ErrorIsNil)
+		testing.TestInit(c, com, t.args, t.err)
+	}
+}
+
+func (s *payloadSuite) TestPayloadStatusSet(c *gc.C) {
+	hctx := s.GetStatusHookContext(c)
+	hctx.info.SetPayload(jujuc.PayloadInfo{Name: "web", Type: "docker", ID: "abc", Status: "running"})
+	code, ctx := s.run(c, hctx, "payload-status-set", "web", "stopping")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+	c.Assert(hctx.info.Payloads.Payloads["web"].Status, gc.Equals, "stopping")
+}
+
+func (s *payloadSuite) TestPayloadStatusSetNotFound(c *gc.C) {
+	hctx := s.GetStatusHookContext(c)
+	code, ctx := s.run(c, hctx, "payload-status-set", "web", "stopping")
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "error: payload \"web\" not found\n")
+}
+
+func (s *payloadSuite) TestPayloadUnregister(c *gc.C) {
+	hctx := s.GetStatusHookContext(c)
+	hctx.info.SetPayload(jujuc.PayloadInfo{Name: "web", Type: "docker", ID: "abc", Status: "running"})
+	code, ctx := s.run(c, hctx, "payload-unregister", "web")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+	c.Assert(hctx.info.Payloads.Payloads, gc.HasLen, 0)
+}
+
+func (s *payloadSuite) TestPayloadUnregisterInit(c *gc.C) {
+	hctx := s.GetStatusHookContext(c)
+	com, err := jujuc.NewCommand(hctx, cmdString("payload-unregister"))
+	c.Assert(err, jc.ErrorIsNil)
+	testing.TestInit(c, com, []string{}, "missing payload name")
+}
@@ -0,0 +1,60 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+)
+
+// ResourceGetCommand implements the resource-get command.
+type ResourceGetCommand struct {
+	cmd.CommandBase
+	ctx  Context
+	name string
+	out  cmd.Output
+}
+
+// NewResourceGetCommand makes a jujuc resource-get command.
+func NewResourceGetCommand(ctx Context) cmd.Command {
+	return &ResourceGetCommand{ctx: ctx}
+}
+
+func (c *ResourceGetCommand) Info() *cmd.Info {
+	doc := `
+Downloads the current revision of the named resource, as declared in the
+charm's metadata and uploaded with "juju push-resource", and prints the
+path of the local file. The content is verified against the checksum
+recorded when it was uploaded; a file already downloaded is reused if it
+is still current. Uploading a new revision triggers the config-changed
+hook, from which the charm may fetch it again.
+`
+	return &cmd.Info{
+		Name:    "resource-get",
+		Args:    "<name>",
+		Purpose: "get the path of a charm resource",
+		Doc:     doc,
+	}
+}
+
+func (c *ResourceGetCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+}
+
+func (c *ResourceGetCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("no resource name specified")
+	}
+	c.name = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *ResourceGetCommand) Run(ctx *cmd.Context) error {
+	path, err := c.ctx.ResourceGet(c.name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return c.out.Write(ctx, path)
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	"github.com/juju/cmd"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/uniter/runner/jujuc"
+)
+
+type ResourceGetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&ResourceGetSuite{})
+
+func (s *ResourceGetSuite) TestInit(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"jdk"}, ""},
+		{[]string{}, "no resource name specified"},
+		{[]string{"jdk", "extra"}, `unrecognized args: \["extra"\]`},
+	} {
+		c.Logf("test %d: %#v", i, t.args)
+		hctx := s.GetStatusHookContext(c)
+		com, err := jujuc.NewCommand(hctx, cmdString("resource-get"))
+		c.Assert(err, jc.ErrorIsNil)
+		testing.TestInit(c, com, t.args, t.err)
+	}
+}
+
+func (s *ResourceGetSuite) TestResourceGet(c *gc.C) {
+	hctx := s.GetStatusHookContext(c)
+	hctx.info.SetResource("jdk", "/var/lib/juju/resources/jdk/jdk.tar.gz")
+	com, err := jujuc.NewCommand(hctx, cmdString("resource-get"))
+	c.Assert(err, jc.ErrorIsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"jdk"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+	c.Assert(bufferString(ctx.Stdout), gc.Equals, "/var/lib/juju/resources/jdk/jdk.tar.gz\n")
+}
+
+func (s *ResourceGetSuite) TestResourceGetNotFound(c *gc.C) {
+	hctx := s.GetStatusHookContext(c)
+	com, err := jujuc.NewCommand(hctx, cmdString("resource-get"))
+	c.Assert(err, jc.ErrorIsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"jdk"})
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "error: resource \"jdk\" not found\n")
+}
@@ -54,6 +54,16 @@ var baseCommands = map[string]creator{
 	"status-set" + cmdSuffix:    NewStatusSetCommand,
 }
 
+var payloadCommands = map[string]creator{
+	"payload-register" + cmdSuffix:   NewPayloadRegisterCommand,
+	"payload-unregister" + cmdSuffix: NewPayloadUnregisterCommand,
+	"payload-status-set" + cmdSuffix: NewPayloadStatusSetCommand,
+}
+
+var resourceCommands = map[string]creator{
+	"resource-get" + cmdSuffix: NewResourceGetCommand,
+}
+
 var storageCommands = map[string]creator{
 	"storage-add" + cmdSuffix: NewStorageAddCommand,
 	"storage-get" + cmdSuffix: NewStorageGetCommand,
@@ -75,6 +85,8 @@ func allEnabledCommands() map[string]creator {
 	add(baseCommands)
 	add(storageCommands)
 	add(leaderCommands)
+	add(payloadCommands)
+	add(resourceCommands)
 	return all
 }
 
@@ -114,10 +126,21 @@ type Request struct {
 // CmdGetter looks up a Command implementation connected to a particular Context.
 type CmdGetter func(contextId, cmdName string) (cmd.Command, error)
 
+// CallRecorder is informed of every hook tool invocation served, along
+// with the response sent back to the hook tool.
+type CallRecorder interface {
+	RecordCall(req Request, resp exec.ExecResponse)
+}
+
+// Responder supplies the response to a hook tool invocation without
+// running any command; it is used to replay recorded hook executions.
+type Responder func(req Request) (*exec.ExecResponse, error)
+
 // Jujuc implements the jujuc command in the form required by net/rpc.
 type Jujuc struct {
-	mu     sync.Mutex
-	getCmd CmdGetter
+	mu       sync.Mutex
+	getCmd   CmdGetter
+	recorder CallRecorder
 }
 
 // badReqErrorf returns an error indicating a bad Request.
@@ -164,6 +187,33 @@ func (j *Jujuc) Main(req Request, resp *exec.ExecResponse) error {
 	}
 	resp.Stdout = stdout.Bytes()
 	resp.Stderr = stderr.Bytes()
+	if j.recorder != nil {
+		j.recorder.RecordCall(req, *resp)
+	}
+	return nil
+}
+
+// replayJujuc implements the jujuc command in the form required by
+// net/rpc, answering each request with a Responder.
+type replayJujuc struct {
+	mu      sync.Mutex
+	respond Responder
+}
+
+// Main fills in resp with the response supplied for req. A single
+// request is answered at a time.
+func (j *replayJujuc) Main(req Request, resp *exec.ExecResponse) error {
+	if req.CommandName == "" {
+		return badReqErrorf("command not specified")
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	logger.Infof("replaying hook tool %q %q", req.CommandName, req.Args)
+	result, err := j.respond(req)
+	if err != nil {
+		return err
+	}
+	*resp = *result
 	return nil
 }
 
@@ -182,10 +232,32 @@ type Server struct {
 // remote command invocations against an appropriate Context. It will not
 // actually do so until Run is called.
 func NewServer(getCmd CmdGetter, socketPath string) (*Server, error) {
+	return NewRecordingServer(getCmd, socketPath, nil)
+}
+
+// NewRecordingServer is like NewServer, but reports every command
+// invocation, and its response, to the supplied recorder.
+func NewRecordingServer(getCmd CmdGetter, socketPath string, recorder CallRecorder) (*Server, error) {
 	server := rpc.NewServer()
-	if err := server.Register(&Jujuc{getCmd: getCmd}); err != nil {
+	if err := server.Register(&Jujuc{getCmd: getCmd, recorder: recorder}); err != nil {
 		return nil, err
 	}
+	return newServer(server, socketPath)
+}
+
+// NewReplayServer creates an RPC server bound to socketPath which,
+// instead of executing remote command invocations, answers them with
+// the responses supplied by respond. It will not actually do so until
+// Run is called.
+func NewReplayServer(respond Responder, socketPath string) (*Server, error) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Jujuc", &replayJujuc{respond: respond}); err != nil {
+		return nil, err
+	}
+	return newServer(server, socketPath)
+}
+
+func newServer(server *rpc.Server, socketPath string) (*Server, error) {
 	listener, err := sockets.Listen(socketPath)
 	if err != nil {
 		return nil, err
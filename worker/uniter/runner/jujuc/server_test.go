@@ -241,6 +241,88 @@ func (s *ServerSuite) TestBrokenCommand(c *gc.C) {
 	c.Assert(string(resp.Stderr), gc.Equals, "error: blam\n")
 }
 
+type callRecorder struct {
+	reqs  []jujuc.Request
+	resps []exec.ExecResponse
+}
+
+func (r *callRecorder) RecordCall(req jujuc.Request, resp exec.ExecResponse) {
+	r.reqs = append(r.reqs, req)
+	r.resps = append(r.resps, resp)
+}
+
+func (s *ServerSuite) TestRecordingServer(c *gc.C) {
+	recorder := &callRecorder{}
+	sockPath := s.osDependentSockPath(c)
+	srv, err := jujuc.NewRecordingServer(factory, sockPath, recorder)
+	c.Assert(err, jc.ErrorIsNil)
+	go srv.Run()
+	defer srv.Close()
+
+	client, err := sockets.Dial(sockPath)
+	c.Assert(err, jc.ErrorIsNil)
+	defer client.Close()
+	req := jujuc.Request{
+		ContextId:   "validCtx",
+		Dir:         c.MkDir(),
+		CommandName: "remote",
+		Args:        []string{"--value", "error"},
+	}
+	var resp exec.ExecResponse
+	err = client.Call("Jujuc.Main", req, &resp)
+	c.Assert(err, jc.ErrorIsNil)
+
+	c.Assert(recorder.reqs, jc.DeepEquals, []jujuc.Request{req})
+	c.Assert(recorder.resps, gc.HasLen, 1)
+	c.Assert(recorder.resps[0].Code, gc.Equals, 1)
+	c.Assert(string(recorder.resps[0].Stderr), gc.Equals, "error: blam\n")
+}
+
+func (s *ServerSuite) TestReplayServer(c *gc.C) {
+	var reqs []jujuc.Request
+	respond := func(req jujuc.Request) (*exec.ExecResponse, error) {
+		reqs = append(reqs, req)
+		if req.CommandName != "remote" {
+			return nil, fmt.Errorf("unexpected command %q", req.CommandName)
+		}
+		return &exec.ExecResponse{
+			Code:   3,
+			Stdout: []byte("recorded out"),
+			Stderr: []byte("recorded err"),
+		}, nil
+	}
+	sockPath := s.osDependentSockPath(c)
+	srv, err := jujuc.NewReplayServer(respond, sockPath)
+	c.Assert(err, jc.ErrorIsNil)
+	go srv.Run()
+	defer srv.Close()
+
+	client, err := sockets.Dial(sockPath)
+	c.Assert(err, jc.ErrorIsNil)
+	defer client.Close()
+	var resp exec.ExecResponse
+	err = client.Call("Jujuc.Main", jujuc.Request{
+		ContextId:   "anyCtx",
+		Dir:         c.MkDir(),
+		CommandName: "remote",
+		Args:        []string{"--cheese"},
+	}, &resp)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resp, jc.DeepEquals, exec.ExecResponse{
+		Code:   3,
+		Stdout: []byte("recorded out"),
+		Stderr: []byte("recorded err"),
+	})
+
+	err = client.Call("Jujuc.Main", jujuc.Request{
+		ContextId:   "anyCtx",
+		Dir:         c.MkDir(),
+		CommandName: "witchcraft",
+	}, &resp)
+	c.Assert(err, gc.ErrorMatches, `unexpected command "witchcraft"`)
+	c.Assert(reqs, gc.HasLen, 2)
+}
+
 type NewCommandSuite struct {
 	relationSuite
 }
@@ -265,6 +347,10 @@ var newCommandTests = []struct {
 	{"storage-get", ""},
 	{"status-get", ""},
 	{"status-set", ""},
+	{"payload-register", ""},
+	{"payload-unregister", ""},
+	{"payload-status-set", ""},
+	{"resource-get", ""},
 	// The error message contains .exe on Windows
 	{"random", "unknown command: random(.exe)?"},
 }
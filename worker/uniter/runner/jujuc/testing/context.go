@@ -21,6 +21,8 @@ type ContextInfo struct {
 	Relations
 	RelationHook
 	ActionHook
+	Payloads
+	Resources
 }
 
 // Context returns a Context that wraps the info.
@@ -59,6 +61,8 @@ type Context struct {
 	ContextRelations
 	ContextRelationHook
 	ContextActionHook
+	ContextPayloads
+	ContextResources
 }
 
 // NewContext builds a jujuc.Context test double.
@@ -84,5 +88,9 @@ func NewContext(stub *testing.Stub, info *ContextInfo) *Context {
 	ctx.ContextRelationHook.info = &info.RelationHook
 	ctx.ContextActionHook.stub = stub
 	ctx.ContextActionHook.info = &info.ActionHook
+	ctx.ContextPayloads.stub = stub
+	ctx.ContextPayloads.info = &info.Payloads
+	ctx.ContextResources.stub = stub
+	ctx.ContextResources.info = &info.Resources
 	return &ctx
 }
@@ -0,0 +1,67 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package testing
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/worker/uniter/runner/jujuc"
+)
+
+// Payloads holds the values for the hook sub-context.
+type Payloads struct {
+	Payloads map[string]jujuc.PayloadInfo
+}
+
+// SetPayload adds or replaces the payload in the sub-context.
+func (p *Payloads) SetPayload(info jujuc.PayloadInfo) {
+	if p.Payloads == nil {
+		p.Payloads = make(map[string]jujuc.PayloadInfo)
+	}
+	p.Payloads[info.Name] = info
+}
+
+// ContextPayloads is a test double for jujuc.ContextPayloads.
+type ContextPayloads struct {
+	contextBase
+	info *Payloads
+}
+
+// RegisterPayload implements jujuc.ContextPayloads.
+func (c *ContextPayloads) RegisterPayload(info jujuc.PayloadInfo) error {
+	c.stub.AddCall("RegisterPayload", info)
+	if err := c.stub.NextErr(); err != nil {
+		return errors.Trace(err)
+	}
+
+	c.info.SetPayload(info)
+	return nil
+}
+
+// UnregisterPayload implements jujuc.ContextPayloads.
+func (c *ContextPayloads) UnregisterPayload(name string) error {
+	c.stub.AddCall("UnregisterPayload", name)
+	if err := c.stub.NextErr(); err != nil {
+		return errors.Trace(err)
+	}
+
+	delete(c.info.Payloads, name)
+	return nil
+}
+
+// SetPayloadStatus implements jujuc.ContextPayloads.
+func (c *ContextPayloads) SetPayloadStatus(name, status string) error {
+	c.stub.AddCall("SetPayloadStatus", name, status)
+	if err := c.stub.NextErr(); err != nil {
+		return errors.Trace(err)
+	}
+
+	info, ok := c.info.Payloads[name]
+	if !ok {
+		return errors.NotFoundf("payload %q", name)
+	}
+	info.Status = status
+	c.info.Payloads[name] = info
+	return nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package testing
+
+import (
+	"github.com/juju/errors"
+)
+
+// Resources holds the values for the hook sub-context.
+type Resources struct {
+	// Paths maps resource names to the paths of their local files.
+	Paths map[string]string
+}
+
+// SetResource records the local path of the named resource.
+func (r *Resources) SetResource(name, path string) {
+	if r.Paths == nil {
+		r.Paths = make(map[string]string)
+	}
+	r.Paths[name] = path
+}
+
+// ContextResources is a test double for jujuc.ContextResources.
+type ContextResources struct {
+	contextBase
+	info *Resources
+}
+
+// ResourceGet implements jujuc.ContextResources.
+func (c *ContextResources) ResourceGet(name string) (string, error) {
+	c.stub.AddCall("ResourceGet", name)
+	if err := c.stub.NextErr(); err != nil {
+		return "", errors.Trace(err)
+	}
+
+	path, ok := c.info.Paths[name]
+	if !ok {
+		return "", errors.NotFoundf("resource %q", name)
+	}
+	return path, nil
+}
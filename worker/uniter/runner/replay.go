@@ -0,0 +1,184 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	utilexec "github.com/juju/utils/exec"
+
+	"github.com/juju/juju/version"
+	"github.com/juju/juju/worker/uniter/runner/jujuc"
+	"github.com/juju/juju/worker/uniter/runner/transcript"
+)
+
+// ReplayResult describes the outcome of replaying a transcript.
+type ReplayResult struct {
+	// ExitCode holds the exit code of the replayed script.
+	ExitCode int
+
+	// Divergences describes every point at which the replayed script
+	// behaved differently from the recorded one.
+	Divergences []string
+}
+
+// Replay runs the script recorded in t, found in the charm directory
+// given by paths, in the recorded environment. Every hook tool call made
+// by the script is answered with the recorded response instead of being
+// executed, so the script sees exactly what it saw when it was recorded.
+// Values redacted when the transcript was recorded are replayed as
+// transcript.Redacted, so scripts that depend on them may behave
+// differently. The script's output is written to stdout and stderr.
+func Replay(t *transcript.Transcript, paths Paths, stdout, stderr io.Writer) (*ReplayResult, error) {
+	charmDir := paths.GetCharmDir()
+	script, err := searchHook(charmDir, filepath.Join(t.Location(), t.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	replayer := &transcriptReplayer{calls: t.Calls}
+	srv, err := jujuc.NewReplayServer(replayer.respond, paths.GetJujucSocket())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	go srv.Run()
+	defer srv.Close()
+
+	env := replayEnv(t.Env, paths)
+	if version.Current.OS == version.Windows {
+		env = mergeWindowsEnvironment(env, os.Environ())
+	}
+	scriptCmd := hookCommand(script)
+	ps := exec.Command(scriptCmd[0], scriptCmd[1:]...)
+	ps.Env = env
+	ps.Dir = charmDir
+	ps.Stdout = stdout
+	ps.Stderr = stderr
+	runErr := ps.Run()
+	code := exitCode(runErr)
+	if code == -1 {
+		return nil, errors.Annotatef(runErr, "cannot run %s %q", t.Kind, t.Name)
+	}
+
+	result := &ReplayResult{
+		ExitCode:    code,
+		Divergences: replayer.finish(),
+	}
+	if code != t.ExitCode {
+		result.Divergences = append(result.Divergences, fmt.Sprintf(
+			"exited with code %d; recorded exit code was %d", code, t.ExitCode,
+		))
+	}
+	return result, nil
+}
+
+// replayEnv returns the recorded environment, with the variables that
+// refer to the local filesystem replaced by ones derived from paths.
+func replayEnv(recorded []string, paths Paths) []string {
+	replaced := map[string]bool{
+		"CHARM_DIR":         true,
+		"JUJU_CHARM_DIR":    true,
+		"JUJU_AGENT_SOCKET": true,
+		"PATH":              true,
+		"PSMODULEPATH":      true,
+	}
+	var env []string
+	for _, v := range recorded {
+		name := strings.SplitN(v, "=", 2)[0]
+		if !replaced[strings.ToUpper(name)] {
+			env = append(env, v)
+		}
+	}
+	env = append(env,
+		"CHARM_DIR="+paths.GetCharmDir(),
+		"JUJU_CHARM_DIR="+paths.GetCharmDir(),
+		"JUJU_AGENT_SOCKET="+paths.GetJujucSocket(),
+	)
+	return append(env, osDependentEnvVars(paths)...)
+}
+
+// transcriptReplayer answers hook tool calls with the responses
+// recorded in a transcript, noting wherever the calls made differ from
+// the recorded ones.
+type transcriptReplayer struct {
+	mu          sync.Mutex
+	calls       []transcript.ToolCall
+	next        int
+	divergences []string
+}
+
+// respond is a jujuc.Responder. Recorded calls are consumed in order;
+// a call that matches a later recorded call causes any calls skipped
+// over to be reported as divergences.
+func (r *transcriptReplayer) respond(req jujuc.Request) (*utilexec.ExecResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := r.next; i < len(r.calls); i++ {
+		call := r.calls[i]
+		args := transcript.RedactArgs(req.CommandName, req.Args)
+		if call.Command != req.CommandName || !sameArgs(call.Args, args) {
+			continue
+		}
+		if call.Stdin != "" && !req.StdinSet {
+			// Ask the client for stdin, just as the live server
+			// did when the call was recorded.
+			return nil, jujuc.ErrNoStdin
+		}
+		for _, skipped := range r.calls[r.next:i] {
+			r.divergences = append(r.divergences, fmt.Sprintf(
+				"recorded call not made: %s", describeCall(skipped.Command, skipped.Args),
+			))
+		}
+		if req.StdinSet && call.Stdin != transcript.Redacted && string(req.Stdin) != call.Stdin {
+			r.divergences = append(r.divergences, fmt.Sprintf(
+				"different stdin supplied to %s", describeCall(req.CommandName, req.Args),
+			))
+		}
+		r.next = i + 1
+		return &utilexec.ExecResponse{
+			Code:   call.Code,
+			Stdout: []byte(call.Stdout),
+			Stderr: []byte(call.Stderr),
+		}, nil
+	}
+	description := describeCall(req.CommandName, req.Args)
+	r.divergences = append(r.divergences, "unexpected call: "+description)
+	return &utilexec.ExecResponse{
+		Code:   1,
+		Stderr: []byte(fmt.Sprintf("error: %s was not recorded\n", description)),
+	}, nil
+}
+
+// finish returns the divergences found, including any recorded calls
+// that were never made.
+func (r *transcriptReplayer) finish() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, unused := range r.calls[r.next:] {
+		r.divergences = append(r.divergences, fmt.Sprintf(
+			"recorded call not made: %s", describeCall(unused.Command, unused.Args),
+		))
+	}
+	r.next = len(r.calls)
+	return r.divergences
+}
+
+func sameArgs(recorded, args []string) bool {
+	if len(recorded) == 0 && len(args) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(recorded, args)
+}
+
+func describeCall(command string, args []string) string {
+	return fmt.Sprintf("%s %q", command, args)
+}
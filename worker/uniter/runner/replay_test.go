@@ -0,0 +1,184 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runner_test
+
+import (
+	"bytes"
+
+	envtesting "github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils/exec"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/worker/uniter/runner"
+	"github.com/juju/juju/worker/uniter/runner/jujuc"
+	"github.com/juju/juju/worker/uniter/runner/transcript"
+)
+
+type ReplaySuite struct {
+	envtesting.IsolationSuite
+	paths RealPaths
+}
+
+var _ = gc.Suite(&ReplaySuite{})
+
+func (s *ReplaySuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.paths = NewRealPaths(c)
+}
+
+var recordedCalls = []transcript.ToolCall{{
+	Command: "config-get",
+	Args:    []string{"--format", "json"},
+	Stdout:  `{"port": 80}`,
+}, {
+	Command: "relation-set",
+	Args:    []string{"-"},
+	Stdin:   "port: 80\n",
+}, {
+	Command: "status-set",
+	Args:    []string{"active"},
+	Stderr:  "error: blam\n",
+	Code:    1,
+}}
+
+func (s *ReplaySuite) TestResponderReplaysCalls(c *gc.C) {
+	respond, finish := runner.NewTranscriptReplayer(recordedCalls)
+
+	resp, err := respond(jujuc.Request{
+		CommandName: "config-get",
+		Args:        []string{"--format", "json"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resp, jc.DeepEquals, &exec.ExecResponse{
+		Stdout: []byte(`{"port": 80}`),
+		Stderr: []byte{},
+	})
+
+	// The recorded call read stdin, so the client is asked for it.
+	_, err = respond(jujuc.Request{
+		CommandName: "relation-set",
+		Args:        []string{"-"},
+	})
+	c.Assert(err, gc.Equals, jujuc.ErrNoStdin)
+	resp, err = respond(jujuc.Request{
+		CommandName: "relation-set",
+		Args:        []string{"-"},
+		StdinSet:    true,
+		Stdin:       []byte("port: 80\n"),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resp.Code, gc.Equals, 0)
+
+	resp, err = respond(jujuc.Request{
+		CommandName: "status-set",
+		Args:        []string{"active"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resp.Code, gc.Equals, 1)
+	c.Assert(string(resp.Stderr), gc.Equals, "error: blam\n")
+
+	c.Assert(finish(), gc.HasLen, 0)
+}
+
+func (s *ReplaySuite) TestResponderReportsDivergences(c *gc.C) {
+	respond, finish := runner.NewTranscriptReplayer(recordedCalls)
+
+	resp, err := respond(jujuc.Request{
+		CommandName: "unit-get",
+		Args:        []string{"private-address"},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resp.Code, gc.Equals, 1)
+	c.Assert(string(resp.Stderr), gc.Equals, `error: unit-get ["private-address"] was not recorded`+"\n")
+
+	// Matching a later call skips over the ones before it.
+	resp, err = respond(jujuc.Request{
+		CommandName: "relation-set",
+		Args:        []string{"-"},
+		StdinSet:    true,
+		Stdin:       []byte("port: 8080\n"),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resp.Code, gc.Equals, 0)
+
+	c.Assert(finish(), jc.DeepEquals, []string{
+		`unexpected call: unit-get ["private-address"]`,
+		`recorded call not made: config-get ["--format" "json"]`,
+		`different stdin supplied to relation-set ["-"]`,
+		`recorded call not made: status-set ["active"]`,
+	})
+}
+
+func (s *ReplaySuite) TestResponderMatchesRedactedCalls(c *gc.C) {
+	respond, finish := runner.NewTranscriptReplayer([]transcript.ToolCall{{
+		Command: "relation-set",
+		Args:    []string{"password=" + transcript.Redacted, "-"},
+		Stdin:   transcript.Redacted,
+	}})
+
+	resp, err := respond(jujuc.Request{
+		CommandName: "relation-set",
+		Args:        []string{"password=sekrit", "-"},
+		StdinSet:    true,
+		Stdin:       []byte("user: admin\n"),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(resp.Code, gc.Equals, 0)
+	c.Assert(finish(), gc.HasLen, 0)
+}
+
+func (s *ReplaySuite) TestReplay(c *gc.C) {
+	makeCharm(c, hookSpec{
+		dir:    "hooks",
+		name:   hookName,
+		perm:   0700,
+		stdout: "hello",
+		code:   7,
+	}, s.paths.charm)
+	t := &transcript.Transcript{
+		Unit:     "some-unit/999",
+		Kind:     transcript.KindHook,
+		Name:     "something-happened",
+		Env:      []string{"VAR=value", "JUJU_AGENT_SOCKET=/nowhere"},
+		ExitCode: 7,
+	}
+	var stdout, stderr bytes.Buffer
+	result, err := runner.Replay(t, s.paths, &stdout, &stderr)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.ExitCode, gc.Equals, 7)
+	c.Assert(result.Divergences, gc.HasLen, 0)
+	c.Assert(stdout.String(), gc.Matches, "(?s).*hello.*")
+}
+
+func (s *ReplaySuite) TestReplayDifferentExitCode(c *gc.C) {
+	makeCharm(c, hookSpec{
+		dir:  "actions",
+		name: hookName,
+		perm: 0700,
+	}, s.paths.charm)
+	t := &transcript.Transcript{
+		Kind:     transcript.KindAction,
+		Name:     "something-happened",
+		Calls:    recordedCalls[:1],
+		ExitCode: 1,
+	}
+	var stdout, stderr bytes.Buffer
+	result, err := runner.Replay(t, s.paths, &stdout, &stderr)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.ExitCode, gc.Equals, 0)
+	c.Assert(result.Divergences, jc.DeepEquals, []string{
+		`recorded call not made: config-get ["--format" "json"]`,
+		"exited with code 0; recorded exit code was 1",
+	})
+}
+
+func (s *ReplaySuite) TestReplayMissingScript(c *gc.C) {
+	t := &transcript.Transcript{
+		Kind: transcript.KindHook,
+		Name: "something-happened",
+	}
+	_, err := runner.Replay(t, s.paths, &bytes.Buffer{}, &bytes.Buffer{})
+	c.Assert(err, jc.Satisfies, runner.IsMissingHookError)
+}
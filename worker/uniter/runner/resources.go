@@ -0,0 +1,126 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/resource"
+)
+
+// ResourceGet makes the current revision of the named charm resource
+// available in the unit's resources directory, and returns the path
+// of the local file. The resource is only downloaded if the local file
+// is missing or does not match the current revision.
+func (ctx *HookContext) ResourceGet(name string) (string, error) {
+	metadata, err := ioutil.ReadFile(filepath.Join(ctx.charmDir, "metadata.yaml"))
+	if err != nil {
+		return "", errors.Annotate(err, "cannot read charm metadata")
+	}
+	declared, err := resource.ParseMeta(metadata)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	meta, ok := declared[name]
+	if !ok {
+		return "", errors.NotFoundf("resource %q in charm metadata", name)
+	}
+
+	expected, err := ctx.currentResourceHash(name)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	dir := filepath.Join(ctx.resourcesDir, name)
+	path := filepath.Join(dir, meta.Filename)
+	if hash, err := fileSHA256(path); err == nil && hash == expected {
+		logger.Debugf("resource %q is up to date", name)
+		return path, nil
+	} else if err != nil && !os.IsNotExist(err) {
+		return "", errors.Trace(err)
+	}
+
+	logger.Infof("downloading resource %q", name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err := ctx.downloadResource(name, expected, path); err != nil {
+		return "", errors.Annotatef(err, "cannot download resource %q", name)
+	}
+	return path, nil
+}
+
+// currentResourceHash returns the hash of the current revision of the
+// named resource of the unit's service.
+func (ctx *HookContext) currentResourceHash(name string) (string, error) {
+	resources, err := ctx.unit.Resources()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	for _, r := range resources {
+		if r.Name == name {
+			return r.SHA256, nil
+		}
+	}
+	return "", errors.NotFoundf("resource %q", name)
+}
+
+// downloadResource writes the content of the named resource to path,
+// which is only replaced once the content has been verified against
+// the expected hash.
+func (ctx *HookContext) downloadResource(name, expected, path string) (err error) {
+	content, hash, err := ctx.unit.OpenResource(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer content.Close()
+	if hash != expected {
+		// The resource was updated since it was listed; the new
+		// revision will be fetched after the next config-changed.
+		return errors.Errorf("resource changed while downloading")
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), ".download")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		f.Close()
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hasher), content); err != nil {
+		return errors.Trace(err)
+	}
+	if got := hex.EncodeToString(hasher.Sum(nil)); got != expected {
+		return errors.Errorf("SHA-256 hash mismatch: expected %q, got %q", expected, got)
+	}
+	if err := f.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return utils.ReplaceFile(f.Name(), path)
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 hash of the file's content.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", errors.Trace(err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
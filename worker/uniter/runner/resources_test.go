@@ -0,0 +1,103 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runner_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/juju/worker/uniter/runner"
+)
+
+type ResourcesSuite struct {
+	HookContextSuite
+	charmDir     string
+	resourcesDir string
+}
+
+var _ = gc.Suite(&ResourcesSuite{})
+
+const resourcedMetadata = `
+name: wordpress
+summary: "blog"
+description: "blog"
+resources:
+  jdk:
+    filename: jdk.tar.gz
+`
+
+func (s *ResourcesSuite) SetUpTest(c *gc.C) {
+	s.HookContextSuite.SetUpTest(c)
+	s.charmDir = c.MkDir()
+	s.resourcesDir = c.MkDir()
+	err := ioutil.WriteFile(filepath.Join(s.charmDir, "metadata.yaml"), []byte(resourcedMetadata), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *ResourcesSuite) setResource(c *gc.C, content string) {
+	hash := sha256.Sum256([]byte(content))
+	_, err := s.service.SetResource("jdk", strings.NewReader(content), int64(len(content)), hex.EncodeToString(hash[:]), s.AdminUserTag(c))
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *ResourcesSuite) context(c *gc.C) runner.Context {
+	ctx := s.GetContext(c, -1, "").(runner.Context)
+	runner.PatchResourcePaths(ctx, s.charmDir, s.resourcesDir)
+	return ctx
+}
+
+func (s *ResourcesSuite) assertResourceGet(c *gc.C, ctx runner.Context, content string) {
+	path, err := ctx.ResourceGet("jdk")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(path, gc.Equals, filepath.Join(s.resourcesDir, "jdk", "jdk.tar.gz"))
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, content)
+}
+
+func (s *ResourcesSuite) TestResourceGet(c *gc.C) {
+	s.setResource(c, "java 7")
+	ctx := s.context(c)
+	s.assertResourceGet(c, ctx, "java 7")
+
+	// A new revision replaces the local file.
+	s.setResource(c, "java 8")
+	s.assertResourceGet(c, ctx, "java 8")
+}
+
+func (s *ResourcesSuite) TestResourceGetReusesLocalFile(c *gc.C) {
+	s.setResource(c, "java")
+	ctx := s.context(c)
+	s.assertResourceGet(c, ctx, "java")
+
+	// Once the resource is current, it is not downloaded again.
+	path := filepath.Join(s.resourcesDir, "jdk", "jdk.tar.gz")
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	err := os.Chtimes(path, past, past)
+	c.Assert(err, jc.ErrorIsNil)
+	s.assertResourceGet(c, ctx, "java")
+	info, err := os.Stat(path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.ModTime().Equal(past), jc.IsTrue)
+}
+
+func (s *ResourcesSuite) TestResourceGetNotDeclared(c *gc.C) {
+	ctx := s.context(c)
+	_, err := ctx.ResourceGet("jre")
+	c.Assert(err, gc.ErrorMatches, `resource "jre" in charm metadata not found`)
+}
+
+func (s *ResourcesSuite) TestResourceGetNotUploaded(c *gc.C) {
+	ctx := s.context(c)
+	_, err := ctx.ResourceGet("jdk")
+	c.Assert(err, gc.ErrorMatches, `resource "jdk" not found`)
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"time"
 
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
@@ -17,6 +18,7 @@ import (
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker/uniter/runner/debug"
 	"github.com/juju/juju/worker/uniter/runner/jujuc"
+	"github.com/juju/juju/worker/uniter/runner/transcript"
 )
 
 // Runner is responsible for invoking commands in a context.
@@ -45,6 +47,8 @@ type Context interface {
 	FlushContext(badge string, failure error) error
 	HasExecutionSetUnitStatus() bool
 	ResetExecutionSetUnitStatus()
+	RecordsTranscripts() bool
+	SaveTranscript(t *transcript.Transcript) error
 }
 
 // Paths exposes the paths needed by Runner.
@@ -66,6 +70,10 @@ type Paths interface {
 	// GetMetricsSpoolDir returns the path to a metrics spool dir, used
 	// to store metrics recorded during a single hook run.
 	GetMetricsSpoolDir() string
+
+	// GetResourcesDir returns the path to the directory to which
+	// charm resources are downloaded.
+	GetResourcesDir() string
 }
 
 // NewRunner returns a Runner backed by the supplied context and paths.
@@ -85,7 +93,7 @@ func (runner *runner) Context() Context {
 
 // RunCommands exists to satisfy the Runner interface.
 func (runner *runner) RunCommands(commands string) (*utilexec.ExecResponse, error) {
-	srv, err := runner.startJujucServer()
+	srv, err := runner.startJujucServer(nil)
 	if err != nil {
 		return nil, err
 	}
@@ -122,13 +130,18 @@ func (runner *runner) RunHook(hookName string) error {
 }
 
 func (runner *runner) runCharmHookWithLocation(hookName, charmLocation string) error {
-	srv, err := runner.startJujucServer()
+	var recorder *transcriptRecorder
+	if runner.context.RecordsTranscripts() {
+		recorder = &transcriptRecorder{}
+	}
+	srv, err := runner.startJujucServer(recorder)
 	if err != nil {
 		return err
 	}
 	defer srv.Close()
 
 	env := runner.context.HookVars(runner.paths)
+	hookEnv := env
 	if version.Current.OS == version.Windows {
 		// TODO(fwereade): somehow consolidate with utils/exec?
 		// We don't do this on the other code path, which uses exec.RunCommands,
@@ -136,6 +149,7 @@ func (runner *runner) runCharmHookWithLocation(hookName, charmLocation string) e
 		env = mergeWindowsEnvironment(env, os.Environ())
 	}
 
+	started := time.Now()
 	debugctx := debug.NewHooksContext(runner.context.UnitName())
 	if session, _ := debugctx.FindSession(); session != nil && session.MatchHook(hookName) {
 		logger.Infof("executing %s via debug-hooks", hookName)
@@ -143,6 +157,9 @@ func (runner *runner) runCharmHookWithLocation(hookName, charmLocation string) e
 	} else {
 		err = runner.runCharmHook(hookName, env, charmLocation)
 	}
+	if recorder != nil && !IsMissingHookError(err) {
+		runner.saveTranscript(hookName, charmLocation, hookEnv, recorder, started, err)
+	}
 	return runner.context.FlushContext(hookName, err)
 }
 
@@ -184,7 +201,9 @@ func (runner *runner) runCharmHook(hookName string, env []string, charmLocation
 	return errors.Trace(err)
 }
 
-func (runner *runner) startJujucServer() (*jujuc.Server, error) {
+// startJujucServer starts a server for the hook tools to connect to,
+// reporting every call made to the supplied recorder if it is not nil.
+func (runner *runner) startJujucServer(recorder *transcriptRecorder) (*jujuc.Server, error) {
 	// Prepare server.
 	getCmd := func(ctxId, cmdName string) (cmd.Command, error) {
 		if ctxId != runner.context.Id() {
@@ -192,7 +211,13 @@ func (runner *runner) startJujucServer() (*jujuc.Server, error) {
 		}
 		return jujuc.NewCommand(runner.context, cmdName)
 	}
-	srv, err := jujuc.NewServer(getCmd, runner.paths.GetJujucSocket())
+	var srv *jujuc.Server
+	var err error
+	if recorder != nil {
+		srv, err = jujuc.NewRecordingServer(getCmd, runner.paths.GetJujucSocket(), recorder)
+	} else {
+		srv, err = jujuc.NewServer(getCmd, runner.paths.GetJujucSocket())
+	}
 	if err != nil {
 		return nil, err
 	}
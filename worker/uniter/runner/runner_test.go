@@ -19,6 +19,7 @@ import (
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/juju/worker/uniter/runner"
+	"github.com/juju/juju/worker/uniter/runner/transcript"
 )
 
 type RunCommandSuite struct {
@@ -151,6 +152,9 @@ type MockContext struct {
 	flushBadge   string
 	flushFailure error
 	flushResult  error
+
+	recordTranscripts bool
+	transcripts       []*transcript.Transcript
 }
 
 func (ctx *MockContext) UnitName() string {
@@ -178,6 +182,15 @@ func (ctx *MockContext) FlushContext(badge string, failure error) error {
 	return ctx.flushResult
 }
 
+func (ctx *MockContext) RecordsTranscripts() bool {
+	return ctx.recordTranscripts
+}
+
+func (ctx *MockContext) SaveTranscript(t *transcript.Transcript) error {
+	ctx.transcripts = append(ctx.transcripts, t)
+	return nil
+}
+
 type RunMockContextSuite struct {
 	envtesting.IsolationSuite
 	paths RealPaths
@@ -293,3 +306,63 @@ func (s *RunMockContextSuite) TestRunCommandsFlushFailure(c *gc.C) {
 	c.Assert(ctx.flushFailure, gc.IsNil) // exit code in _ result, as tested elsewhere
 	s.assertRecordedPid(c, ctx.expectPid)
 }
+
+func (s *RunMockContextSuite) TestRunHookRecordsTranscript(c *gc.C) {
+	ctx := &MockContext{recordTranscripts: true}
+	makeCharm(c, hookSpec{
+		dir:  "hooks",
+		name: hookName,
+		perm: 0700,
+		code: 123,
+	}, s.paths.charm)
+	err := runner.NewRunner(ctx, s.paths).RunHook("something-happened")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ctx.transcripts, gc.HasLen, 1)
+	t := ctx.transcripts[0]
+	c.Assert(t.Unit, gc.Equals, "some-unit/999")
+	c.Assert(t.Kind, gc.Equals, transcript.KindHook)
+	c.Assert(t.Name, gc.Equals, "something-happened")
+	c.Assert(t.Env, jc.DeepEquals, []string{"VAR=value"})
+	c.Assert(t.Calls, gc.HasLen, 0)
+	c.Assert(t.ExitCode, gc.Equals, 123)
+	c.Assert(t.Error, gc.Equals, "exit status 123")
+	c.Assert(t.Started.IsZero(), jc.IsFalse)
+}
+
+func (s *RunMockContextSuite) TestRunActionRecordsTranscript(c *gc.C) {
+	ctx := &MockContext{
+		recordTranscripts: true,
+		actionData:        &runner.ActionData{},
+	}
+	makeCharm(c, hookSpec{
+		dir:  "actions",
+		name: hookName,
+		perm: 0700,
+	}, s.paths.charm)
+	err := runner.NewRunner(ctx, s.paths).RunAction("something-happened")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ctx.transcripts, gc.HasLen, 1)
+	c.Assert(ctx.transcripts[0].Kind, gc.Equals, transcript.KindAction)
+	c.Assert(ctx.transcripts[0].ExitCode, gc.Equals, 0)
+	c.Assert(ctx.transcripts[0].Error, gc.Equals, "")
+}
+
+func (s *RunMockContextSuite) TestRunHookNoTranscriptByDefault(c *gc.C) {
+	ctx := &MockContext{}
+	makeCharm(c, hookSpec{
+		dir:  "hooks",
+		name: hookName,
+		perm: 0700,
+	}, s.paths.charm)
+	err := runner.NewRunner(ctx, s.paths).RunHook("something-happened")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ctx.transcripts, gc.HasLen, 0)
+}
+
+func (s *RunMockContextSuite) TestRunMissingHookRecordsNoTranscript(c *gc.C) {
+	ctx := &MockContext{recordTranscripts: true}
+	err := runner.NewRunner(ctx, s.paths).RunHook("something-happened")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ctx.flushFailure, jc.Satisfies, runner.IsMissingHookError)
+	c.Assert(ctx.transcripts, gc.HasLen, 0)
+}
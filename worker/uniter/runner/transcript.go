@@ -0,0 +1,97 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runner
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/juju/errors"
+	utilexec "github.com/juju/utils/exec"
+
+	"github.com/juju/juju/worker/uniter/runner/jujuc"
+	"github.com/juju/juju/worker/uniter/runner/transcript"
+)
+
+// transcriptRecorder collects the hook tool calls made during a single
+// execution. It implements jujuc.CallRecorder.
+type transcriptRecorder struct {
+	mu    sync.Mutex
+	calls []transcript.ToolCall
+}
+
+// RecordCall is part of the jujuc.CallRecorder interface. Values that
+// may be secret, such as charm configuration and relation settings,
+// are redacted before the call is recorded.
+func (r *transcriptRecorder) RecordCall(req jujuc.Request, resp utilexec.ExecResponse) {
+	call := transcript.ToolCall{
+		Command: req.CommandName,
+		Args:    req.Args,
+		Code:    resp.Code,
+	}
+	var stdinCut, stdoutCut, stderrCut bool
+	call.Stdin, stdinCut = transcript.Truncate(req.Stdin)
+	call.Stdout, stdoutCut = transcript.Truncate(resp.Stdout)
+	call.Stderr, stderrCut = transcript.Truncate(resp.Stderr)
+	call.Truncated = stdinCut || stdoutCut || stderrCut
+	call = transcript.RedactCall(call)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+// Calls returns the hook tool calls recorded so far.
+func (r *transcriptRecorder) Calls() []transcript.ToolCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := make([]transcript.ToolCall, len(r.calls))
+	copy(calls, r.calls)
+	return calls
+}
+
+// saveTranscript stores a transcript of an execution of the named
+// script, which finished with the supplied error at the current time.
+// Failure to save is logged rather than returned, so as not to affect
+// the outcome of the execution itself.
+func (runner *runner) saveTranscript(
+	name, charmLocation string, env []string, recorder *transcriptRecorder, started time.Time, runErr error,
+) {
+	kind := transcript.KindHook
+	if charmLocation == "actions" {
+		kind = transcript.KindAction
+	}
+	t := &transcript.Transcript{
+		Unit:     runner.context.UnitName(),
+		Kind:     kind,
+		Name:     name,
+		Env:      transcript.RedactEnv(env),
+		Calls:    recorder.Calls(),
+		ExitCode: exitCode(runErr),
+		Started:  started.UTC(),
+		Duration: time.Since(started),
+	}
+	if runErr != nil {
+		t.Error = runErr.Error()
+	}
+	if err := runner.context.SaveTranscript(t); err != nil {
+		logger.Warningf("cannot save transcript of %s %q: %v", kind, name, err)
+	}
+}
+
+// exitCode returns the exit code of the process whose completion
+// produced err, or -1 if err does not describe a process exit.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := errors.Cause(err).(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus()
+		}
+	}
+	return -1
+}
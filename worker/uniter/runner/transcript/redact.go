@@ -0,0 +1,94 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package transcript
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/juju/utils/set"
+)
+
+// Redacted replaces any value withheld from a transcript because it
+// may be secret.
+const Redacted = "<redacted>"
+
+// sensitiveOutput holds the hook tools whose output may include charm
+// configuration, relation or leader settings, or action parameters,
+// any of which may hold passwords or keys.
+var sensitiveOutput = set.NewStrings(
+	"action-get",
+	"config-get",
+	"leader-get",
+	"relation-get",
+)
+
+// sensitiveInput holds the hook tools that take settings or results,
+// which may likewise be secret, as key=value arguments or on stdin.
+var sensitiveInput = set.NewStrings(
+	"action-set",
+	"leader-set",
+	"relation-set",
+)
+
+// RedactCall returns a copy of call with any values that may be
+// secret replaced by Redacted. The command, the option arguments and
+// the setting keys are kept, so a redacted call can still be matched
+// against the calls made when it is replayed.
+func RedactCall(call ToolCall) ToolCall {
+	call.Args = RedactArgs(call.Command, call.Args)
+	if sensitiveInput.Contains(call.Command) && call.Stdin != "" {
+		call.Stdin = Redacted
+	}
+	if sensitiveOutput.Contains(call.Command) && call.Stdout != "" {
+		call.Stdout = Redacted
+	}
+	return call
+}
+
+// RedactArgs returns the arguments of a call to the named hook tool
+// with the values of any key=value settings replaced by Redacted.
+func RedactArgs(command string, args []string) []string {
+	if !sensitiveInput.Contains(command) || len(args) == 0 {
+		return args
+	}
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if eq := strings.Index(arg, "="); eq > 0 && !strings.HasPrefix(arg, "-") && eq < len(arg)-1 {
+			arg = arg[:eq+1] + Redacted
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
+
+// RedactEnv returns a copy of the os.Environ-style list env in which
+// the values of any proxy variables that hold credentials are replaced
+// by Redacted.
+func RedactEnv(env []string) []string {
+	redacted := make([]string, len(env))
+	for i, v := range env {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) == 2 && hasProxyCredentials(parts[0], parts[1]) {
+			v = parts[0] + "=" + Redacted
+		}
+		redacted[i] = v
+	}
+	return redacted
+}
+
+// hasProxyCredentials reports whether the environment variable with
+// the given name and value is a proxy setting that includes a user
+// name or password.
+func hasProxyCredentials(name, value string) bool {
+	if !strings.HasSuffix(strings.ToLower(name), "_proxy") {
+		return false
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		// Be cautious with values we cannot make sense of.
+		return strings.Contains(value, "@")
+	}
+	return u.User != nil
+}
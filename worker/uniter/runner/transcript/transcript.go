@@ -0,0 +1,70 @@
+// Copyright 2015 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The transcript package defines the record a unit agent keeps of a
+// single hook execution: the environment the hook ran in, every hook
+// tool it invoked along with the responses it was given, and how it
+// finished. Transcripts can be fetched with juju hook-transcripts and
+// replayed locally with jujud replay-hook.
+package transcript
+
+import (
+	"time"
+)
+
+// MaxOutputSize is the largest number of bytes of any single stream
+// that will be kept for a recorded hook tool call; anything beyond
+// that is discarded and the call is marked as truncated.
+const MaxOutputSize = 64 * 1024
+
+// ToolCall records a single hook tool invocation and its response.
+type ToolCall struct {
+	Command   string   `json:"command" yaml:"command"`
+	Args      []string `json:"args,omitempty" yaml:"args,omitempty"`
+	Stdin     string   `json:"stdin,omitempty" yaml:"stdin,omitempty"`
+	Stdout    string   `json:"stdout,omitempty" yaml:"stdout,omitempty"`
+	Stderr    string   `json:"stderr,omitempty" yaml:"stderr,omitempty"`
+	Code      int      `json:"code" yaml:"code"`
+	Truncated bool     `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+}
+
+// Kind identifies the sort of charm script a transcript records.
+type Kind string
+
+const (
+	KindHook   Kind = "hook"
+	KindAction Kind = "action"
+)
+
+// Transcript records a single hook or action execution.
+type Transcript struct {
+	// Id is assigned when the transcript is stored by the controller.
+	Id       string        `json:"id,omitempty" yaml:"id,omitempty"`
+	Unit     string        `json:"unit" yaml:"unit"`
+	Kind     Kind          `json:"kind" yaml:"kind"`
+	Name     string        `json:"name" yaml:"name"`
+	Env      []string      `json:"env" yaml:"env"`
+	Calls    []ToolCall    `json:"calls,omitempty" yaml:"calls,omitempty"`
+	ExitCode int           `json:"exit-code" yaml:"exit-code"`
+	Error    string        `json:"error,omitempty" yaml:"error,omitempty"`
+	Started  time.Time     `json:"started" yaml:"started"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+}
+
+// Location returns the directory, relative to the charm directory, in
+// which the script recorded by the transcript is found.
+func (t *Transcript) Location() string {
+	if t.Kind == KindAction {
+		return "actions"
+	}
+	return "hooks"
+}
+
+// Truncate returns data as a string, cut down to at most MaxOutputSize
+// bytes, and whether anything was cut.
+func Truncate(data []byte) (string, bool) {
+	if len(data) > MaxOutputSize {
+		return string(data[:MaxOutputSize]), true
+	}
+	return string(data), false
+}
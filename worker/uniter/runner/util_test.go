@@ -54,12 +54,17 @@ func (MockEnvPaths) GetMetricsSpoolDir() string {
 	return "path-to-metrics-spool-dir"
 }
 
+func (MockEnvPaths) GetResourcesDir() string {
+	return "path-to-resources-dir"
+}
+
 // RealPaths implements Paths for tests that do touch the filesystem.
 type RealPaths struct {
 	tools        string
 	charm        string
 	socket       string
 	metricsspool string
+	resources    string
 }
 
 func osDependentSockPath(c *gc.C) string {
@@ -76,6 +81,7 @@ func NewRealPaths(c *gc.C) RealPaths {
 		charm:        c.MkDir(),
 		socket:       osDependentSockPath(c),
 		metricsspool: c.MkDir(),
+		resources:    c.MkDir(),
 	}
 }
 
@@ -83,6 +89,10 @@ func (p RealPaths) GetMetricsSpoolDir() string {
 	return p.metricsspool
 }
 
+func (p RealPaths) GetResourcesDir() string {
+	return p.resources
+}
+
 func (p RealPaths) GetToolsDir() string {
 	return p.tools
 }